@@ -0,0 +1,290 @@
+// Package artifact defines a stable, versioned serialization of SM2/SM9 keys,
+// signatures and ciphertexts, so that services can exchange them without
+// inventing their own formats. An Artifact is encoded either as the protocol
+// buffers message gmsm.artifact.v1.Artifact, see ProtoSchema, or as its proto3
+// JSON mapping, see JSONSchema:
+//
+//	{"version":1,"type":"SM2_PUBLIC_KEY","data":"MFkwEwYHKoZIzj0CAQYIKoEcz1UBgi0DQgAE..."}
+//
+// The data of an artifact is the canonical ASN.1 DER encoding of its type.
+// It is validated when an artifact is marshaled or unmarshaled, non-canonical
+// encodings are rejected, so equal artifacts always have equal encodings.
+// The encodings don't depend on any protocol buffers runtime.
+package artifact
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	_ "embed"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/emmansun/gmsm/codec"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm9"
+	"github.com/emmansun/gmsm/sm9/bn256"
+	"github.com/emmansun/gmsm/smx509"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// Version is the version of the encodings.
+const Version = 1
+
+// ProtoSchema is the protocol buffers definition of an artifact.
+//
+//go:embed artifact.proto
+var ProtoSchema string
+
+// JSONSchema is the JSON Schema of the JSON encoding of an artifact.
+//
+//go:embed artifact.schema.json
+var JSONSchema string
+
+// ErrUnsupportedVersion is returned when an artifact of another version is
+// unmarshaled.
+var ErrUnsupportedVersion = errors.New("artifact: unsupported version")
+
+// Type is the type of an artifact, the values are those of the protocol
+// buffers enum gmsm.artifact.v1.Type.
+type Type int32
+
+const (
+	TypeUnspecified Type = iota
+	TypeSM2PrivateKey
+	TypeSM2PublicKey
+	TypeSM2Signature
+	TypeSM2Ciphertext
+	TypeSM9SignMasterPrivateKey
+	TypeSM9SignMasterPublicKey
+	TypeSM9SignPrivateKey
+	TypeSM9EncryptMasterPrivateKey
+	TypeSM9EncryptMasterPublicKey
+	TypeSM9EncryptPrivateKey
+	TypeSM9Signature
+	TypeSM9Ciphertext
+)
+
+var typeNames = [...]string{
+	TypeUnspecified:                "TYPE_UNSPECIFIED",
+	TypeSM2PrivateKey:              "SM2_PRIVATE_KEY",
+	TypeSM2PublicKey:               "SM2_PUBLIC_KEY",
+	TypeSM2Signature:               "SM2_SIGNATURE",
+	TypeSM2Ciphertext:              "SM2_CIPHERTEXT",
+	TypeSM9SignMasterPrivateKey:    "SM9_SIGN_MASTER_PRIVATE_KEY",
+	TypeSM9SignMasterPublicKey:     "SM9_SIGN_MASTER_PUBLIC_KEY",
+	TypeSM9SignPrivateKey:          "SM9_SIGN_PRIVATE_KEY",
+	TypeSM9EncryptMasterPrivateKey: "SM9_ENCRYPT_MASTER_PRIVATE_KEY",
+	TypeSM9EncryptMasterPublicKey:  "SM9_ENCRYPT_MASTER_PUBLIC_KEY",
+	TypeSM9EncryptPrivateKey:       "SM9_ENCRYPT_PRIVATE_KEY",
+	TypeSM9Signature:               "SM9_SIGNATURE",
+	TypeSM9Ciphertext:              "SM9_CIPHERTEXT",
+}
+
+// String returns the protocol buffers name of t.
+func (t Type) String() string {
+	if t >= 0 && int(t) < len(typeNames) {
+		return typeNames[t]
+	}
+	return fmt.Sprintf("Type(%d)", int32(t))
+}
+
+func parseType(name string) (Type, bool) {
+	for t, n := range typeNames {
+		if n == name && t != int(TypeUnspecified) {
+			return Type(t), true
+		}
+	}
+	return TypeUnspecified, false
+}
+
+// Artifact is a key, a signature or a ciphertext.
+type Artifact struct {
+	Type Type
+	// Data is the ASN.1 DER encoding of the artifact.
+	Data []byte
+	// KeyID optionally identifies the key, e.g. in a KMS.
+	KeyID string
+	// UID and HID are the SM9 user identity and its identifier, of SM9 user
+	// keys, signatures and ciphertexts.
+	UID []byte
+	HID byte
+}
+
+// FromKey returns the artifact of a *sm2.PrivateKey, a SM2 *ecdsa.PublicKey
+// or a SM9 master or user key. The UID and HID of SM9 user keys are set by
+// the caller.
+func FromKey(key any) (*Artifact, error) {
+	var (
+		a   = new(Artifact)
+		err error
+	)
+	switch k := key.(type) {
+	case *sm2.PrivateKey:
+		a.Type = TypeSM2PrivateKey
+		a.Data, err = smx509.MarshalPKCS8PrivateKey(k)
+	case *ecdsa.PublicKey:
+		if !sm2.IsSM2PublicKey(k) {
+			return nil, errors.New("artifact: not a SM2 public key")
+		}
+		a.Type = TypeSM2PublicKey
+		a.Data, err = smx509.MarshalPKIXPublicKey(k)
+	case *sm9.SignMasterPrivateKey:
+		a.Type = TypeSM9SignMasterPrivateKey
+		a.Data, err = k.MarshalASN1()
+	case *sm9.SignMasterPublicKey:
+		a.Type = TypeSM9SignMasterPublicKey
+		a.Data, err = k.MarshalASN1()
+	case *sm9.SignPrivateKey:
+		a.Type = TypeSM9SignPrivateKey
+		a.Data, err = k.MarshalASN1()
+	case *sm9.EncryptMasterPrivateKey:
+		a.Type = TypeSM9EncryptMasterPrivateKey
+		a.Data, err = k.MarshalASN1()
+	case *sm9.EncryptMasterPublicKey:
+		a.Type = TypeSM9EncryptMasterPublicKey
+		a.Data, err = k.MarshalASN1()
+	case *sm9.EncryptPrivateKey:
+		a.Type = TypeSM9EncryptPrivateKey
+		a.Data, err = k.MarshalASN1()
+	default:
+		return nil, fmt.Errorf("artifact: unsupported key type %T", key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Key parses the key of a key artifact. It returns a *sm2.PrivateKey, a SM2
+// *ecdsa.PublicKey or a SM9 key, like *sm9.SignMasterPublicKey. The master
+// public key of a SM9 user key isn't part of the artifact and is nil.
+func (a *Artifact) Key() (any, error) {
+	if len(a.Data) == 0 {
+		return nil, errors.New("artifact: empty data")
+	}
+	var (
+		key any
+		err error
+	)
+	switch a.Type {
+	case TypeSM2PrivateKey:
+		if key, err = smx509.ParsePKCS8PrivateKey(a.Data); err == nil {
+			if _, ok := key.(*sm2.PrivateKey); !ok {
+				return nil, errors.New("artifact: not a SM2 private key")
+			}
+		}
+	case TypeSM2PublicKey:
+		if key, err = smx509.ParsePKIXPublicKey(a.Data); err == nil && !sm2.IsSM2PublicKey(key) {
+			return nil, errors.New("artifact: not a SM2 public key")
+		}
+	case TypeSM9SignMasterPrivateKey:
+		k := new(sm9.SignMasterPrivateKey)
+		key, err = k, k.UnmarshalASN1(a.Data)
+	case TypeSM9SignMasterPublicKey:
+		k := new(sm9.SignMasterPublicKey)
+		key, err = k, k.UnmarshalASN1(a.Data)
+	case TypeSM9SignPrivateKey:
+		k := new(sm9.SignPrivateKey)
+		key, err = k, k.UnmarshalASN1(a.Data)
+	case TypeSM9EncryptMasterPrivateKey:
+		k := new(sm9.EncryptMasterPrivateKey)
+		key, err = k, k.UnmarshalASN1(a.Data)
+	case TypeSM9EncryptMasterPublicKey:
+		k := new(sm9.EncryptMasterPublicKey)
+		key, err = k, k.UnmarshalASN1(a.Data)
+	case TypeSM9EncryptPrivateKey:
+		k := new(sm9.EncryptPrivateKey)
+		key, err = k, k.UnmarshalASN1(a.Data)
+	default:
+		return nil, fmt.Errorf("artifact: %v is not a key", a.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Validate checks that the data of a is the canonical encoding of its type.
+func (a *Artifact) Validate() error {
+	if len(a.Data) == 0 {
+		return errors.New("artifact: empty data")
+	}
+	if !utf8.ValidString(a.KeyID) {
+		return errors.New("artifact: key id is not valid UTF-8")
+	}
+	switch a.Type {
+	case TypeSM2Signature:
+		if _, err := codec.SignatureASN1ToRS(a.Data); err != nil {
+			return err
+		}
+	case TypeSM2Ciphertext:
+		plain, err := codec.CiphertextASN1ToPlain(a.Data, sm2.C1C3C2)
+		if err != nil {
+			return err
+		}
+		if der, err := codec.CiphertextPlainToASN1(plain, sm2.C1C3C2); err != nil || !bytes.Equal(der, a.Data) {
+			return errors.New("artifact: non-canonical SM2 ciphertext")
+		}
+	case TypeSM9Signature:
+		return validateSM9Signature(a.Data)
+	case TypeSM9Ciphertext:
+		return validateSM9Ciphertext(a.Data)
+	default:
+		key, err := a.Key()
+		if err != nil {
+			return err
+		}
+		again, err := FromKey(key)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(again.Data, a.Data) {
+			return fmt.Errorf("artifact: non-canonical %v", a.Type)
+		}
+	}
+	return nil
+}
+
+// validateG1 checks that p is an uncompressed point of G1.
+func validateG1(p []byte) bool {
+	if len(p) == 0 || p[0] != 4 {
+		return false
+	}
+	_, err := new(bn256.G1).Unmarshal(p[1:])
+	return err == nil
+}
+
+func validateSM9Signature(sig []byte) error {
+	var (
+		h, s  []byte
+		inner cryptobyte.String
+	)
+	input := cryptobyte.String(sig)
+	if !input.ReadASN1(&inner, asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1Bytes(&h, asn1.OCTET_STRING) ||
+		!inner.ReadASN1BitStringAsBytes(&s) || !inner.Empty() ||
+		len(h) != 32 || !validateG1(s) {
+		return errors.New("artifact: invalid SM9 signature")
+	}
+	return nil
+}
+
+func validateSM9Ciphertext(ciphertext []byte) error {
+	var (
+		encType    int
+		c1, c3, c2 []byte
+		inner      cryptobyte.String
+	)
+	input := cryptobyte.String(ciphertext)
+	if !input.ReadASN1(&inner, asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1Integer(&encType) ||
+		!inner.ReadASN1BitStringAsBytes(&c1) ||
+		!inner.ReadASN1Bytes(&c3, asn1.OCTET_STRING) ||
+		!inner.ReadASN1Bytes(&c2, asn1.OCTET_STRING) || !inner.Empty() ||
+		encType < 0 || len(c3) != 32 || len(c2) == 0 || !validateG1(c1) {
+		return errors.New("artifact: invalid SM9 ciphertext")
+	}
+	return nil
+}
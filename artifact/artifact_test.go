@@ -0,0 +1,235 @@
+package artifact
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm9"
+)
+
+func testArtifacts(t *testing.T) []*Artifact {
+	t.Helper()
+	sm2Key, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signMaster, err := sm9.GenerateSignMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signKey, err := signMaster.GenerateUserKey([]byte("alice"), 0x01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encMaster, err := sm9.GenerateEncryptMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encKey, err := encMaster.GenerateUserKey([]byte("alice"), 0x03)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var artifacts []*Artifact
+	for _, key := range []any{sm2Key, &sm2Key.PublicKey, signMaster, signMaster.Public(), signKey, encMaster, encMaster.Public(), encKey} {
+		a, err := FromKey(key)
+		if err != nil {
+			t.Fatalf("%T: %v", key, err)
+		}
+		artifacts = append(artifacts, a)
+	}
+	artifacts[4].UID, artifacts[4].HID = []byte("alice"), 0x01
+	artifacts[7].UID, artifacts[7].HID, artifacts[7].KeyID = []byte("alice"), 0x03, "enc-1"
+
+	hash := sm3.Sum([]byte("hello"))
+	sm2Sig, err := sm2.SignASN1(rand.Reader, sm2Key, hash[:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm2Ct, err := sm2.EncryptASN1(rand.Reader, &sm2Key.PublicKey, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm9Sig, err := sm9.SignASN1(rand.Reader, signKey, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm9Ct, err := sm9.EncryptASN1(rand.Reader, encMaster.Public(), []byte("alice"), 0x03, []byte("hello"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(artifacts,
+		&Artifact{Type: TypeSM2Signature, Data: sm2Sig, KeyID: "sm2-1"},
+		&Artifact{Type: TypeSM2Ciphertext, Data: sm2Ct},
+		&Artifact{Type: TypeSM9Signature, Data: sm9Sig, UID: []byte("alice"), HID: 0x01},
+		&Artifact{Type: TypeSM9Ciphertext, Data: sm9Ct, UID: []byte("alice"), HID: 0x03},
+	)
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, a := range testArtifacts(t) {
+		pb, err := a.MarshalProto()
+		if err != nil {
+			t.Fatalf("%v: %v", a.Type, err)
+		}
+		var got Artifact
+		if err := got.UnmarshalProto(pb); err != nil {
+			t.Fatalf("%v: %v", a.Type, err)
+		}
+		if !reflect.DeepEqual(&got, a) {
+			t.Errorf("%v: proto round trip mismatch", a.Type)
+		}
+
+		js, err := json.Marshal(a)
+		if err != nil {
+			t.Fatalf("%v: %v", a.Type, err)
+		}
+		if !strings.Contains(string(js), `"type":"`+a.Type.String()+`"`) {
+			t.Errorf("unexpected JSON %s", js)
+		}
+		got = Artifact{}
+		if err := json.Unmarshal(js, &got); err != nil {
+			t.Fatalf("%v: %v", a.Type, err)
+		}
+		if !reflect.DeepEqual(&got, a) {
+			t.Errorf("%v: JSON round trip mismatch", a.Type)
+		}
+
+		if a.Type >= TypeSM2Signature && a.Type <= TypeSM2Ciphertext || a.Type >= TypeSM9Signature {
+			continue
+		}
+		key, err := a.Key()
+		if err != nil {
+			t.Fatalf("%v: %v", a.Type, err)
+		}
+		if again, err := FromKey(key); err != nil || !bytes.Equal(again.Data, a.Data) {
+			t.Errorf("%v: key round trip mismatch", a.Type)
+		}
+	}
+}
+
+// TestKnownEncoding checks that the encodings are stable.
+func TestKnownEncoding(t *testing.T) {
+	pub, err := sm2.NewPublicKey(mustHex("043787cb35e445fd27456c0bfc8725c09511af395896b968d1946b65c8b1ec1f6fe64fd4378d9e9933c1abb0af72de2b43a88b86e5aaa7085ea9362184ef54c53a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := FromKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.KeyID = "k1"
+	pb, err := a.MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "0801 1002 1a5b" + "3059301306072a8648ce3d020106082a811ccf5501822d03420004" +
+		"3787cb35e445fd27456c0bfc8725c09511af395896b968d1946b65c8b1ec1f6fe64fd4378d9e9933c1abb0af72de2b43a88b86e5aaa7085ea9362184ef54c53a" +
+		"2202 6b31"
+	if !bytes.Equal(pb, mustHex(want)) {
+		t.Errorf("got %x", pb)
+	}
+	js, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const wantJSON = `{"version":1,"type":"SM2_PUBLIC_KEY","data":"MFkwEwYHKoZIzj0CAQYIKoEcz1UBgi0DQgAEN4fLNeRF/SdFbAv8hyXAlRGvOViWuWjRlGtlyLHsH2/mT9Q3jZ6ZM8GrsK9y3itDqIuG5aqnCF6pNiGE71TFOg==","keyId":"k1"}`
+	if string(js) != wantJSON {
+		t.Errorf("got %s", js)
+	}
+	var byNumber Artifact
+	if err := json.Unmarshal([]byte(strings.Replace(wantJSON, `"SM2_PUBLIC_KEY"`, "2", 1)), &byNumber); err != nil || !reflect.DeepEqual(&byNumber, a) {
+		t.Errorf("type by number: %v", err)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	artifacts := testArtifacts(t)
+	for _, a := range artifacts {
+		for _, data := range [][]byte{nil, a.Data[:len(a.Data)-1], append(append([]byte(nil), a.Data...), 0)} {
+			b := *a
+			b.Data = data
+			if _, err := b.MarshalProto(); err == nil {
+				t.Errorf("%v: expected error with data %x", a.Type, data)
+			}
+		}
+	}
+	if _, err := (&Artifact{Type: TypeSM2Signature, Data: artifacts[0].Data}).MarshalProto(); err == nil {
+		t.Error("expected error with a key as signature")
+	}
+
+	pb, err := artifacts[1].MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var a Artifact
+	for name, b := range map[string][]byte{
+		"truncated":     pb[:len(pb)-1],
+		"wrong wire":    append([]byte{0x0a, 0x01, 0x01}, pb[2:]...),
+		"group":         append(pb, 0x3b),
+		"varint":        append(pb, 0x38, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f),
+		"large hid":     append(pb, 0x30, 0x80, 0x02),
+		"unknown type":  append(pb, 0x10, 0x63),
+		"field zero":    append(pb, 0x00, 0x00),
+		"empty message": nil,
+	} {
+		if err := a.UnmarshalProto(b); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if err := a.UnmarshalProto(append([]byte{0x08, 0x02}, pb[2:]...)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("got %v, want ErrUnsupportedVersion", err)
+	}
+	// unknown fields are skipped
+	if err := a.UnmarshalProto(append(pb, 0x78, 0x01, 0x82, 0x01, 0x01, 0x00)); err != nil {
+		t.Errorf("unknown fields: %v", err)
+	}
+
+	js, err := json.Marshal(artifacts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, s := range map[string]string{
+		"version":       strings.Replace(string(js), `"version":1`, `"version":2`, 1),
+		"type":          strings.Replace(string(js), `"SM2_PUBLIC_KEY"`, `"SM2_KEY"`, 1),
+		"unspecified":   strings.Replace(string(js), `"SM2_PUBLIC_KEY"`, `"TYPE_UNSPECIFIED"`, 1),
+		"unknown field": strings.Replace(string(js), `{`, `{"foo":1,`, 1),
+		"trailing data": string(js) + "{}",
+		"hid":           strings.Replace(string(js), `}`, `,"hid":256}`, 1),
+	} {
+		if err := json.Unmarshal([]byte(s), &a); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(JSONSchema), &schema); err != nil {
+		t.Fatal(err)
+	}
+	for typ := TypeSM2PrivateKey; typ <= TypeSM9Ciphertext; typ++ {
+		if !strings.Contains(JSONSchema, `"`+typ.String()+`"`) {
+			t.Errorf("%v is missing from the JSON schema", typ)
+		}
+		if !strings.Contains(ProtoSchema, typ.String()+" = ") {
+			t.Errorf("%v is missing from the proto schema", typ)
+		}
+	}
+}
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
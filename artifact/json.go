@@ -0,0 +1,77 @@
+package artifact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// jsonArtifact is the proto3 JSON mapping of gmsm.artifact.v1.Artifact.
+type jsonArtifact struct {
+	Version uint32          `json:"version"`
+	Type    json.RawMessage `json:"type"`
+	Data    []byte          `json:"data"`
+	KeyID   string          `json:"keyId,omitempty"`
+	UID     []byte          `json:"uid,omitempty"`
+	HID     uint32          `json:"hid,omitempty"`
+}
+
+// MarshalJSON returns the proto3 JSON mapping of a: the type is written by
+// name and bytes are standard base64 encoded.
+func (a *Artifact) MarshalJSON() ([]byte, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	typ, err := json.Marshal(a.Type.String())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&jsonArtifact{
+		Version: Version,
+		Type:    typ,
+		Data:    a.Data,
+		KeyID:   a.KeyID,
+		UID:     a.UID,
+		HID:     uint32(a.HID),
+	})
+}
+
+// UnmarshalJSON parses the JSON encoding of an artifact into a and validates
+// it. As in the proto3 JSON mapping, the type may be given by name or by
+// number. Unknown fields are rejected.
+func (a *Artifact) UnmarshalJSON(b []byte) error {
+	var v jsonArtifact
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+	if d.More() {
+		return errors.New("artifact: trailing data after JSON artifact")
+	}
+	if v.Version != Version {
+		return ErrUnsupportedVersion
+	}
+	var (
+		out  = Artifact{Data: v.Data, KeyID: v.KeyID, UID: v.UID}
+		name string
+		ok   bool
+	)
+	if err := json.Unmarshal(v.Type, &name); err == nil {
+		out.Type, ok = parseType(name)
+	} else if err := json.Unmarshal(v.Type, &out.Type); err == nil {
+		ok = out.Type > TypeUnspecified && int(out.Type) < len(typeNames)
+	}
+	if !ok {
+		return errors.New("artifact: unknown type " + string(v.Type))
+	}
+	if v.HID > 0xff {
+		return errors.New("artifact: invalid hid")
+	}
+	out.HID = byte(v.HID)
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	*a = out
+	return nil
+}
@@ -0,0 +1,167 @@
+package artifact
+
+import (
+	"errors"
+)
+
+// field numbers and wire types of gmsm.artifact.v1.Artifact
+const (
+	fieldVersion = 1
+	fieldType    = 2
+	fieldData    = 3
+	fieldKeyID   = 4
+	fieldUID     = 5
+	fieldHID     = 6
+
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errProto = errors.New("artifact: invalid protocol buffers encoding")
+
+// MarshalProto returns the protocol buffers encoding of a. The fields are
+// written in field number order and fields with default values are omitted,
+// as the deterministic encoding of the protocol buffers runtimes does.
+func (a *Artifact) MarshalProto() ([]byte, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	b := appendVarintField(nil, fieldVersion, Version)
+	b = appendVarintField(b, fieldType, uint64(a.Type))
+	b = appendBytesField(b, fieldData, a.Data)
+	b = appendBytesField(b, fieldKeyID, []byte(a.KeyID))
+	b = appendBytesField(b, fieldUID, a.UID)
+	b = appendVarintField(b, fieldHID, uint64(a.HID))
+	return b, nil
+}
+
+// UnmarshalProto parses the protocol buffers encoding of an artifact into a
+// and validates it. Unknown fields are skipped.
+func (a *Artifact) UnmarshalProto(b []byte) error {
+	var (
+		out     Artifact
+		version uint64
+	)
+	for len(b) > 0 {
+		tag, n := readVarint(b)
+		if n == 0 || tag>>3 == 0 || tag>>3 > 1<<29-1 {
+			return errProto
+		}
+		b = b[n:]
+		field, wire := tag>>3, tag&7
+		var (
+			v    uint64
+			data []byte
+		)
+		switch wire {
+		case wireVarint:
+			if v, n = readVarint(b); n == 0 {
+				return errProto
+			}
+		case wireBytes:
+			var l uint64
+			if l, n = readVarint(b); n == 0 || l > uint64(len(b)-n) {
+				return errProto
+			}
+			data, n = b[n:n+int(l)], n+int(l)
+		case wireFixed64:
+			n = 8
+		case wireFixed32:
+			n = 4
+		default:
+			return errProto
+		}
+		if n > len(b) {
+			return errProto
+		}
+		b = b[n:]
+
+		if w, known := fieldWireType(field); !known {
+			continue
+		} else if w != wire {
+			return errProto
+		}
+		switch field {
+		case fieldVersion:
+			version = v
+		case fieldType:
+			if v > 1<<31-1 {
+				return errProto
+			}
+			out.Type = Type(v)
+		case fieldData:
+			out.Data = append([]byte(nil), data...)
+		case fieldKeyID:
+			out.KeyID = string(data)
+		case fieldUID:
+			out.UID = append([]byte(nil), data...)
+		case fieldHID:
+			if v > 0xff {
+				return errors.New("artifact: invalid hid")
+			}
+			out.HID = byte(v)
+		}
+	}
+	if version != Version {
+		return ErrUnsupportedVersion
+	}
+	if err := out.Validate(); err != nil {
+		return err
+	}
+	*a = out
+	return nil
+}
+
+// fieldWireType returns the wire type of a known field.
+func fieldWireType(field uint64) (uint64, bool) {
+	switch field {
+	case fieldVersion, fieldType, fieldHID:
+		return wireVarint, true
+	case fieldData, fieldKeyID, fieldUID:
+		return wireBytes, true
+	}
+	return 0, false
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = appendVarint(b, uint64(field<<3|wireVarint))
+	return appendVarint(b, v)
+}
+
+func appendBytesField(b []byte, field int, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = appendVarint(b, uint64(field<<3|wireBytes))
+	b = appendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// readVarint returns the varint at the start of b and its length, or 0 if
+// the varint is truncated or overflows.
+func readVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i] < 0x80 {
+			if i == 9 && b[i] > 1 {
+				return 0, 0
+			}
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,272 @@
+// Package auditlog implements a tamper-evident log for compliance logging:
+// the entries are chained with SM3 and the head of the chain is periodically
+// signed with SM2 in checkpoints.
+//
+// A log is a sequence of JSON lines, entries and checkpoints:
+//
+//	{"type":"entry","seq":1,"time":"<RFC 3339>","data":"<base64>","hash":"<hex>"}
+//	{"type":"checkpoint","seq":1,"time":"<RFC 3339>","hash":"<hex>","final":false,"sig":"<base64>"}
+//
+// The hash of the entry seq is
+//
+//	SM3(0x00 || hash of entry seq-1 || uint64 seq || int64 unix nanoseconds || data)
+//
+// with 32 zero bytes before the first entry, so that changing, removing,
+// reordering or inserting an entry breaks the chain. A checkpoint signs the
+// sequence number and the hash of the last entry, with the SM3 Z value of the
+// default user id, it binds the chain to the signer. The checkpoint written by
+// Close is final, a log which doesn't end with a final checkpoint has been
+// truncated or its writer didn't exit cleanly. A log continued after a
+// restart or a rotation starts from the head of the previous chain. Truncation at a checkpoint is
+// detected against an anchor, a checkpoint saved elsewhere, e.g. by a previous
+// verification.
+package auditlog
+
+import (
+	"crypto"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+)
+
+// HashSize is the size of the entry hashes.
+const HashSize = sm3.Size
+
+// DefaultCheckpointInterval is the number of entries between two checkpoints
+// if Options.CheckpointInterval is zero.
+const DefaultCheckpointInterval = 1000
+
+const (
+	typeEntry      = "entry"
+	typeCheckpoint = "checkpoint"
+
+	checkpointContext = "gmsm auditlog checkpoint v1\x00"
+)
+
+// Entry is an entry of the log.
+type Entry struct {
+	Seq  uint64
+	Time time.Time
+	Data []byte
+	// Hash is the hash of the chain up to and including this entry.
+	Hash []byte
+}
+
+// Checkpoint is a signed head of the chain.
+type Checkpoint struct {
+	// Seq is the sequence number of the last entry, zero for an empty log.
+	Seq  uint64
+	Time time.Time
+	// Hash is the hash of the entry Seq.
+	Hash []byte
+	// Final is true for the checkpoint written by Writer.Close.
+	Final     bool
+	Signature []byte
+}
+
+// record is the JSON line of an entry or a checkpoint.
+type record struct {
+	Type  string    `json:"type"`
+	Seq   uint64    `json:"seq"`
+	Time  time.Time `json:"time"`
+	Data  []byte    `json:"data,omitempty"`
+	Hash  string    `json:"hash"`
+	Final bool      `json:"final,omitempty"`
+	Sig   []byte    `json:"sig,omitempty"`
+}
+
+func entryHash(prev []byte, seq uint64, t time.Time, data []byte) []byte {
+	var buf [17]byte
+	binary.BigEndian.PutUint64(buf[1:], seq)
+	binary.BigEndian.PutUint64(buf[9:], uint64(t.UnixNano()))
+	h := sm3.New()
+	h.Write(buf[:1])
+	h.Write(prev)
+	h.Write(buf[1:])
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// signedMessage returns the message signed by a checkpoint.
+func (c *Checkpoint) signedMessage() []byte {
+	msg := make([]byte, 0, len(checkpointContext)+17+len(c.Hash))
+	msg = append(msg, checkpointContext...)
+	var buf [17]byte
+	binary.BigEndian.PutUint64(buf[:], c.Seq)
+	binary.BigEndian.PutUint64(buf[8:], uint64(c.Time.UnixNano()))
+	if c.Final {
+		buf[16] = 1
+	}
+	msg = append(msg, buf[:]...)
+	return append(msg, c.Hash...)
+}
+
+// Options are the options of a Writer.
+type Options struct {
+	// CheckpointInterval is the number of entries after which a checkpoint is
+	// written, DefaultCheckpointInterval if zero, none if negative.
+	CheckpointInterval int
+	// Head continues the chain of a previous Writer, e.g. Report.Head of the
+	// verification of the log, when the new entries are appended to the same
+	// file after a restart or written to a new file after a rotation. A new
+	// chain starts if nil.
+	Head *Checkpoint
+	// Now returns the time of the entries, time.Now if nil.
+	Now func() time.Time
+	// Rand is the randomness of the signatures, the default reader of the
+	// entropy package if nil.
+	Rand io.Reader
+}
+
+// Writer appends entries to a log. It is safe for concurrent use.
+type Writer struct {
+	w        io.Writer
+	signer   crypto.Signer
+	interval int
+	now      func() time.Time
+	rand     io.Reader
+
+	mu        sync.Mutex
+	seq       uint64
+	hash      []byte
+	unsigned  int
+	last      *Checkpoint
+	closed    bool
+	lineBytes []byte
+}
+
+// NewWriter returns a Writer which writes the log to w and signs the
+// checkpoints with signer, an *sm2.PrivateKey or a crypto.Signer of an SM2 key
+// such as the ones of the kms package.
+func NewWriter(w io.Writer, signer crypto.Signer, opts *Options) (*Writer, error) {
+	if signer == nil || !sm2.IsSM2PublicKey(signer.Public()) {
+		return nil, errors.New("auditlog: signer is not an SM2 key")
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+	lw := &Writer{
+		w:        w,
+		signer:   signer,
+		interval: opts.CheckpointInterval,
+		now:      opts.Now,
+		rand:     opts.Rand,
+		hash:     make([]byte, HashSize),
+	}
+	if lw.interval == 0 {
+		lw.interval = DefaultCheckpointInterval
+	}
+	if lw.now == nil {
+		lw.now = time.Now
+	}
+	if lw.rand == nil {
+		lw.rand = randutil.Reader()
+	}
+	if opts.Head != nil {
+		if len(opts.Head.Hash) != HashSize {
+			return nil, errors.New("auditlog: invalid head hash")
+		}
+		lw.seq = opts.Head.Seq
+		copy(lw.hash, opts.Head.Hash)
+	}
+	return lw, nil
+}
+
+// Append appends an entry with data to the log and writes a checkpoint if the
+// checkpoint interval is reached.
+func (w *Writer) Append(data []byte) (*Entry, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil, errors.New("auditlog: writer is closed")
+	}
+	e := &Entry{
+		Seq:  w.seq + 1,
+		Time: w.now().Round(0),
+		Data: append([]byte{}, data...),
+	}
+	e.Hash = entryHash(w.hash, e.Seq, e.Time, e.Data)
+	if err := w.writeRecord(&record{Type: typeEntry, Seq: e.Seq, Time: e.Time, Data: e.Data, Hash: hex.EncodeToString(e.Hash)}); err != nil {
+		return nil, err
+	}
+	w.seq, w.hash = e.Seq, e.Hash
+	w.unsigned++
+	if w.interval > 0 && w.unsigned >= w.interval {
+		if _, err := w.checkpoint(false); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Checkpoint signs the head of the chain and writes the checkpoint, which can
+// be kept elsewhere as an anchor for the truncation detection of Verify.
+func (w *Writer) Checkpoint() (*Checkpoint, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil, errors.New("auditlog: writer is closed")
+	}
+	return w.checkpoint(false)
+}
+
+// Close writes the final checkpoint. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	if _, err := w.checkpoint(true); err != nil {
+		return err
+	}
+	w.closed = true
+	return nil
+}
+
+// LastCheckpoint returns the last checkpoint written by w, nil if none.
+func (w *Writer) LastCheckpoint() *Checkpoint {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.last
+}
+
+func (w *Writer) checkpoint(final bool) (*Checkpoint, error) {
+	c := &Checkpoint{
+		Seq:   w.seq,
+		Time:  w.now().Round(0),
+		Hash:  w.hash,
+		Final: final,
+	}
+	sig, err := w.signer.Sign(w.rand, c.signedMessage(), sm2.DefaultSM2SignerOpts)
+	if err != nil {
+		return nil, err
+	}
+	c.Signature = sig
+	if err := w.writeRecord(&record{Type: typeCheckpoint, Seq: c.Seq, Time: c.Time, Hash: hex.EncodeToString(c.Hash), Final: final, Sig: sig}); err != nil {
+		return nil, err
+	}
+	w.unsigned = 0
+	w.last = c
+	return c, nil
+}
+
+// writeRecord writes r as one line with a single Write call, so that lines
+// are not interleaved with the writes of other processes to an O_APPEND file.
+func (w *Writer) writeRecord(r *record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	w.lineBytes = append(append(w.lineBytes[:0], b...), '\n')
+	_, err = w.w.Write(w.lineBytes)
+	return err
+}
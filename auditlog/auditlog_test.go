@@ -0,0 +1,171 @@
+package auditlog
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emmansun/gmsm/sm2"
+)
+
+func writeLog(t *testing.T, priv *sm2.PrivateKey, n, interval int, close bool) (*bytes.Buffer, *Writer) {
+	t.Helper()
+	var buf bytes.Buffer
+	now := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	w, err := NewWriter(&buf, priv, &Options{
+		CheckpointInterval: interval,
+		Now:                func() time.Time { now = now.Add(time.Millisecond); return now },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if _, err := w.Append([]byte(fmt.Sprintf("event %d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if close {
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &buf, w
+}
+
+func lines(buf *bytes.Buffer) []string {
+	ls := strings.SplitAfter(buf.String(), "\n")
+	return ls[:len(ls)-1]
+}
+
+func TestWriteVerify(t *testing.T) {
+	priv, _ := sm2.GenerateKey(rand.Reader)
+	buf, w := writeLog(t, priv, 10, 4, true)
+	if got := len(lines(buf)); got != 10+2+1 {
+		t.Fatalf("got %d lines, want 13", got)
+	}
+	report, err := Verify(bytes.NewReader(buf.Bytes()), &priv.PublicKey, &VerifyOptions{RequireFinal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Entries != 10 || report.Checkpoints != 3 || !report.Final || report.Unsigned != 0 || report.Head.Seq != 10 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if !bytes.Equal(report.LastCheckpoint.Hash, w.LastCheckpoint().Hash) {
+		t.Error("last checkpoint mismatch")
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), &priv.PublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := r.Next()
+	if err != nil || e.Seq != 1 || string(e.Data) != "event 0" {
+		t.Errorf("Next = %+v, %v", e, err)
+	}
+
+	other, _ := sm2.GenerateKey(rand.Reader)
+	if _, err := Verify(bytes.NewReader(buf.Bytes()), &other.PublicKey, nil); !errors.Is(err, ErrCheckpoint) {
+		t.Errorf("other key: got %v, want ErrCheckpoint", err)
+	}
+}
+
+func TestContinue(t *testing.T) {
+	priv, _ := sm2.GenerateKey(rand.Reader)
+	buf, _ := writeLog(t, priv, 3, 0, true)
+	report, err := Verify(bytes.NewReader(buf.Bytes()), &priv.PublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWriter(buf, priv, &Options{Head: &report.Head})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Append([]byte("after restart")); err != nil {
+		t.Fatal(err)
+	}
+	report, err = Verify(bytes.NewReader(buf.Bytes()), &priv.PublicKey, &VerifyOptions{Anchor: report.LastCheckpoint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Entries != 4 || report.Final || report.Unsigned != 1 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if _, err := Verify(bytes.NewReader(buf.Bytes()), &priv.PublicKey, &VerifyOptions{RequireFinal: true}); !errors.Is(err, ErrTruncated) {
+		t.Errorf("got %v, want ErrTruncated", err)
+	}
+
+	// a rotated file continues from the head of the previous one.
+	var next bytes.Buffer
+	w, err = NewWriter(&next, priv, &Options{Head: &report.Head})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Append([]byte("rotated"))
+	w.Close()
+	report, err = Verify(&next, &priv.PublicKey, &VerifyOptions{Head: &report.Head, RequireFinal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Entries != 1 || report.Head.Seq != 5 {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func TestTamper(t *testing.T) {
+	priv, _ := sm2.GenerateKey(rand.Reader)
+	buf, _ := writeLog(t, priv, 6, 3, true)
+	ls := lines(buf)
+	verify := func(ls []string, opts *VerifyOptions) error {
+		_, err := Verify(strings.NewReader(strings.Join(ls, "")), &priv.PublicKey, opts)
+		return err
+	}
+
+	changed := append([]string{}, ls...)
+	changed[1] = strings.Replace(changed[1], `"data":"`, `"data":"AAAA`, 1)
+	if err := verify(changed, nil); !errors.Is(err, ErrChain) {
+		t.Errorf("changed entry: got %v", err)
+	}
+
+	removed := append(append([]string{}, ls[:1]...), ls[2:]...)
+	if err := verify(removed, nil); !errors.Is(err, ErrChain) {
+		t.Errorf("removed entry: got %v", err)
+	}
+
+	swapped := append([]string{}, ls...)
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+	if err := verify(swapped, nil); !errors.Is(err, ErrChain) {
+		t.Errorf("swapped entries: got %v", err)
+	}
+
+	// truncated after the first checkpoint: only detected against the final
+	// checkpoint requirement or an anchor.
+	truncated := ls[:4]
+	if err := verify(truncated, nil); err != nil {
+		t.Errorf("truncated at a checkpoint: %v", err)
+	}
+	if err := verify(truncated, &VerifyOptions{RequireFinal: true}); !errors.Is(err, ErrTruncated) {
+		t.Errorf("truncated, final required: got %v", err)
+	}
+	report, err := Verify(bytes.NewReader(buf.Bytes()), &priv.PublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(truncated, &VerifyOptions{Anchor: report.LastCheckpoint}); !errors.Is(err, ErrTruncated) {
+		t.Errorf("truncated before the anchor: got %v", err)
+	}
+
+	partial := append(append([]string{}, ls[:len(ls)-1]...), strings.TrimSuffix(ls[len(ls)-1], "\n")[:20])
+	if err := verify(partial, nil); !errors.Is(err, ErrTruncated) {
+		t.Errorf("partial line: got %v", err)
+	}
+
+	forged := append([]string{}, ls...)
+	forged[len(forged)-1] = strings.Replace(forged[len(forged)-1], `"final":true`, `"final":false`, 1)
+	if err := verify(forged, nil); !errors.Is(err, ErrCheckpoint) {
+		t.Errorf("forged checkpoint: got %v", err)
+	}
+}
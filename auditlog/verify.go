@@ -0,0 +1,208 @@
+package auditlog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/emmansun/gmsm/sm2"
+)
+
+var (
+	// ErrChain is wrapped by the errors of an entry which doesn't follow the
+	// previous one: a changed, removed, reordered or inserted entry.
+	ErrChain = errors.New("auditlog: hash chain broken")
+	// ErrCheckpoint is wrapped by the errors of a checkpoint whose signature
+	// or head doesn't verify.
+	ErrCheckpoint = errors.New("auditlog: invalid checkpoint")
+	// ErrTruncated is wrapped by the errors of a log which has been truncated:
+	// it ends with an incomplete line, without the final checkpoint when it's
+	// required, or before the anchor.
+	ErrTruncated = errors.New("auditlog: log truncated")
+)
+
+// Reader reads and verifies the entries of a log.
+type Reader struct {
+	r    *bufio.Reader
+	pub  *ecdsa.PublicKey
+	line int
+
+	seq  uint64
+	hash []byte
+	last *Checkpoint
+	// entries is the number of entries read, which differs from seq for a
+	// log continued from a head.
+	entries     uint64
+	checkpoints int
+	final       bool
+}
+
+// NewReader returns a Reader of the log r whose checkpoints are signed by the
+// SM2 key pub. head is the head of the previous file the log continues from
+// after a rotation, see Options.Head, nil if the log starts from scratch.
+func NewReader(r io.Reader, pub *ecdsa.PublicKey, head *Checkpoint) (*Reader, error) {
+	if !sm2.IsSM2PublicKey(pub) {
+		return nil, errors.New("auditlog: not an SM2 public key")
+	}
+	lr := &Reader{r: bufio.NewReader(r), pub: pub, hash: make([]byte, HashSize)}
+	if head != nil {
+		if len(head.Hash) != HashSize {
+			return nil, errors.New("auditlog: invalid head hash")
+		}
+		lr.seq = head.Seq
+		copy(lr.hash, head.Hash)
+	}
+	return lr, nil
+}
+
+// Next returns the next entry, after verifying it and the checkpoints before
+// it. It returns io.EOF at the end of the log.
+func (r *Reader) Next() (*Entry, error) {
+	for {
+		line, err := r.r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) != 0 {
+				return nil, fmt.Errorf("%w: incomplete line %d", ErrTruncated, r.line+1)
+			}
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.line++
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, fmt.Errorf("auditlog: line %d: %v", r.line, err)
+		}
+		hash, err := hex.DecodeString(rec.Hash)
+		if err != nil || len(hash) != HashSize {
+			return nil, fmt.Errorf("auditlog: line %d: invalid hash", r.line)
+		}
+		switch rec.Type {
+		case typeEntry:
+			if rec.Seq != r.seq+1 {
+				return nil, fmt.Errorf("%w: line %d has sequence number %d, want %d", ErrChain, r.line, rec.Seq, r.seq+1)
+			}
+			e := &Entry{Seq: rec.Seq, Time: rec.Time, Data: rec.Data, Hash: hash}
+			if e.Data == nil {
+				e.Data = []byte{}
+			}
+			if !bytes.Equal(entryHash(r.hash, e.Seq, e.Time, e.Data), hash) {
+				return nil, fmt.Errorf("%w: entry %d", ErrChain, e.Seq)
+			}
+			r.seq, r.hash = e.Seq, hash
+			r.entries++
+			r.final = false
+			return e, nil
+		case typeCheckpoint:
+			c := &Checkpoint{Seq: rec.Seq, Time: rec.Time, Hash: hash, Final: rec.Final, Signature: rec.Sig}
+			if c.Seq != r.seq || !bytes.Equal(c.Hash, r.hash) {
+				return nil, fmt.Errorf("%w: line %d doesn't match the head of the chain", ErrCheckpoint, r.line)
+			}
+			if !sm2.VerifyASN1WithSM2(r.pub, nil, c.signedMessage(), c.Signature) {
+				return nil, fmt.Errorf("%w: line %d has an invalid signature", ErrCheckpoint, r.line)
+			}
+			r.last = c
+			r.checkpoints++
+			r.final = c.Final
+		default:
+			return nil, fmt.Errorf("auditlog: line %d: unknown record type %q", r.line, rec.Type)
+		}
+	}
+}
+
+// Report is the result of the verification of a log.
+type Report struct {
+	// Entries is the number of entries, Checkpoints the number of checkpoints.
+	Entries     uint64
+	Checkpoints int
+	// Head is the sequence number and hash of the last entry. It's unsigned
+	// when Unsigned is not zero.
+	Head Checkpoint
+	// LastCheckpoint is the last checkpoint, nil if none. It can be kept as
+	// the anchor of the next verification.
+	LastCheckpoint *Checkpoint
+	// Unsigned is the number of entries after the last checkpoint, which are
+	// chained but not signed.
+	Unsigned uint64
+	// Final is true if the log ends with a final checkpoint.
+	Final bool
+}
+
+// VerifyOptions are the options of Verify.
+type VerifyOptions struct {
+	// Head is the checkpoint the log continues from, see Options.Head.
+	Head *Checkpoint
+	// Anchor is a checkpoint of the log saved elsewhere, the log must contain
+	// an entry with the same sequence number and hash.
+	Anchor *Checkpoint
+	// RequireFinal requires the log to end with a final checkpoint.
+	RequireFinal bool
+}
+
+// Verify reads and verifies the log r whose checkpoints are signed by the SM2
+// key pub. It returns an error wrapping ErrChain, ErrCheckpoint or
+// ErrTruncated if the log has been tampered with.
+func Verify(r io.Reader, pub *ecdsa.PublicKey, opts *VerifyOptions) (*Report, error) {
+	if opts == nil {
+		opts = &VerifyOptions{}
+	}
+	lr, err := NewReader(r, pub, opts.Head)
+	if err != nil {
+		return nil, err
+	}
+	anchored := opts.Anchor == nil
+	check := func(seq uint64, hash []byte) error {
+		if anchored || seq != opts.Anchor.Seq {
+			return nil
+		}
+		if !bytes.Equal(hash, opts.Anchor.Hash) {
+			return fmt.Errorf("%w: entry %d doesn't match the anchor", ErrChain, seq)
+		}
+		anchored = true
+		return nil
+	}
+	if err := check(lr.seq, lr.hash); err != nil {
+		return nil, err
+	}
+	for {
+		e, err := lr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := check(e.Seq, e.Hash); err != nil {
+			return nil, err
+		}
+	}
+	if !anchored {
+		return nil, fmt.Errorf("%w: log ends at entry %d before the anchor %d", ErrTruncated, lr.seq, opts.Anchor.Seq)
+	}
+	if opts.RequireFinal && !lr.final {
+		return nil, fmt.Errorf("%w: no final checkpoint", ErrTruncated)
+	}
+	report := &Report{
+		Entries:        lr.entries,
+		Checkpoints:    lr.checkpoints,
+		Head:           Checkpoint{Seq: lr.seq, Hash: lr.hash},
+		LastCheckpoint: lr.last,
+		Unsigned:       lr.seq,
+		Final:          lr.final,
+	}
+	if lr.last != nil {
+		report.Unsigned -= lr.last.Seq
+	} else if opts.Head != nil {
+		report.Unsigned -= opts.Head.Seq
+	}
+	return report, nil
+}
@@ -0,0 +1,94 @@
+// Package backend reports which implementation of each primitive is active at
+// runtime, e.g. pure Go, SIMD or CPU cryptographic extensions, and whether it
+// runs in constant time.
+//
+// Operators can use it at startup to verify that the deployments actually run
+// the accelerated and hardened code paths.
+//
+// It also defines the provider interfaces of the core primitives, so that
+// alternative implementations (cgo engines, vendor SDKs, remote KMS) can be
+// registered and selected per algorithm at runtime, the pure Go implementation
+// of this module remains the default. The selection applies to the entry points
+// of this package, e.g. NewSM2PrivateKey, NewSM3 or NewSM9Signer, and to the
+// bench package; the sm2, sm3, sm4 and sm9 packages always run the pure Go
+// implementation, which is also what the built-in provider calls.
+package backend
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emmansun/gmsm/internal/impl"
+
+	// make sure that all primitives are registered.
+	_ "github.com/emmansun/gmsm/internal/bigmod"
+	_ "github.com/emmansun/gmsm/internal/sm2ec"
+	_ "github.com/emmansun/gmsm/sm3"
+	_ "github.com/emmansun/gmsm/sm4"
+	_ "github.com/emmansun/gmsm/sm9/bn256"
+	_ "github.com/emmansun/gmsm/zuc"
+)
+
+// Implementation describes one implementation of a primitive.
+type Implementation struct {
+	Name         string // "generic" for the pure Go implementation
+	Available    bool   // whether the CPU supports it
+	ConstantTime bool   // whether its running time is independent of secret data
+}
+
+// Primitive describes the active and all the compiled implementations of a primitive.
+type Primitive struct {
+	Name            string // sm2, sm3, sm4, sm9, zuc or bigmod
+	Active          Implementation
+	Implementations []Implementation
+}
+
+// String returns a summary of the primitive like "sm4: AES (constant time)".
+func (p Primitive) String() string {
+	ct := "constant time"
+	if !p.Active.ConstantTime {
+		ct = "NOT constant time"
+	}
+	return fmt.Sprintf("%s: %s (%s)", p.Name, p.Active.Name, ct)
+}
+
+// Primitives returns all primitives, sorted by name.
+func Primitives() []Primitive {
+	pkgs := impl.Packages()
+	ps := make([]Primitive, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		p, _ := Lookup(pkg)
+		ps = append(ps, p)
+	}
+	return ps
+}
+
+// Lookup returns the primitive with the given name, it returns false if the
+// primitive is unknown.
+func Lookup(name string) (Primitive, bool) {
+	list := impl.List(name)
+	if len(list) == 0 {
+		return Primitive{}, false
+	}
+	active := impl.Active(name)
+	p := Primitive{Name: name}
+	for _, i := range list {
+		ii := Implementation{Name: i.Name, Available: i.Available, ConstantTime: i.ConstantTime}
+		if i.Name == active {
+			p.Active = ii
+		}
+		p.Implementations = append(p.Implementations, ii)
+	}
+	return p, true
+}
+
+// Report returns a human readable report of the active implementations, one
+// primitive per line.
+func Report() string {
+	var b strings.Builder
+	for _, p := range Primitives() {
+		b.WriteString(p.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrimitives(t *testing.T) {
+	expected := []string{"bigmod", "sm2", "sm3", "sm4", "sm9", "zuc"}
+	ps := Primitives()
+	if len(ps) != len(expected) {
+		t.Fatalf("got %v primitives, want %v", len(ps), len(expected))
+	}
+	for i, p := range ps {
+		if p.Name != expected[i] {
+			t.Errorf("primitive %d: got %s, want %s", i, p.Name, expected[i])
+		}
+		if p.Active.Name == "" || !p.Active.Available {
+			t.Errorf("%s: no active implementation", p.Name)
+		}
+		found := false
+		for _, i := range p.Implementations {
+			if i == p.Active {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s: active implementation %s is not listed", p.Name, p.Active.Name)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if _, ok := Lookup("sm5"); ok {
+		t.Errorf("unknown primitive should not be found")
+	}
+	p, ok := Lookup("sm3")
+	if !ok {
+		t.Fatal("sm3 is not registered")
+	}
+	if !strings.HasPrefix(p.String(), "sm3: ") {
+		t.Errorf("unexpected summary %q", p.String())
+	}
+	t.Log("\n" + Report())
+	if !strings.Contains(Report(), p.String()+"\n") {
+		t.Errorf("report does not contain %q", p.String())
+	}
+}
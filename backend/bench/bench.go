@@ -0,0 +1,412 @@
+// Package bench runs standardized throughput and latency measurements of the
+// primitives, for every provider registered in the backend package which
+// supports them, and returns machine-readable results.
+//
+// Platform teams can use it to qualify hardware, or to compare an alternative
+// provider with the built-in one, before deployment:
+//
+//	results, err := bench.Run(&bench.Options{Duration: time.Second})
+//	if err != nil {
+//		return err
+//	}
+//	json.NewEncoder(os.Stdout).Encode(results)
+package bench
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/emmansun/gmsm/backend"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm9"
+	"github.com/emmansun/gmsm/zuc"
+)
+
+// Result is the measurement of one benchmark with one provider and input size.
+type Result struct {
+	Benchmark      string  `json:"benchmark"`
+	Provider       string  `json:"provider"`
+	Implementation string  `json:"implementation,omitempty"` // active implementation of the built-in provider
+	ConstantTime   bool    `json:"constant_time,omitempty"`  // whether the active implementation is constant time
+	Size           int     `json:"size,omitempty"`           // input size in bytes, 0 for fixed size operations
+	Iterations     int     `json:"iterations"`
+	NsPerOp        float64 `json:"ns_per_op"` // mean latency
+	OpsPerSec      float64 `json:"ops_per_sec"`
+	MBPerSec       float64 `json:"mb_per_sec,omitempty"`
+}
+
+// Options are the options of Run.
+type Options struct {
+	// Duration is the minimum measuring time of each result, one second if zero.
+	Duration time.Duration
+	// Sizes are the input sizes of the hash and cipher benchmarks, they must be
+	// positive multiples of the SM4 block size. The default is 16, 1024 and 8192.
+	Sizes []int
+	// Benchmarks are the names of the benchmarks to run, all of Names if empty.
+	Benchmarks []string
+}
+
+var defaultSizes = []int{16, 1024, 8192}
+
+// op is one operation under measurement.
+type op func() error
+
+type benchmark struct {
+	name      string
+	primitive string            // primitive name of the backend package
+	alg       backend.Algorithm // empty if only the built-in implementation is available
+	sized     bool
+	setup     func(p backend.Provider, size int) (op, error)
+}
+
+var benchmarks = []benchmark{
+	{name: "sm2-sign", primitive: "sm2", alg: backend.SM2, setup: setupSM2Sign},
+	{name: "sm2-verify", primitive: "sm2", alg: backend.SM2, setup: setupSM2Verify},
+	{name: "sm2-encrypt", primitive: "sm2", alg: backend.SM2, setup: setupSM2Encrypt},
+	{name: "sm2-decrypt", primitive: "sm2", alg: backend.SM2, setup: setupSM2Decrypt},
+	{name: "sm3", primitive: "sm3", alg: backend.SM3, sized: true, setup: setupSM3},
+	{name: "sm4-cbc", primitive: "sm4", alg: backend.SM4, sized: true, setup: setupSM4CBC},
+	{name: "sm4-gcm", primitive: "sm4", alg: backend.SM4, sized: true, setup: setupSM4GCM},
+	{name: "sm9-sign", primitive: "sm9", alg: backend.SM9, setup: setupSM9Sign},
+	{name: "sm9-verify", primitive: "sm9", alg: backend.SM9, setup: setupSM9Verify},
+	{name: "sm9-wrapkey", primitive: "sm9", alg: backend.SM9, setup: setupSM9WrapKey},
+	{name: "zuc-eea", primitive: "zuc", sized: true, setup: setupZUC},
+}
+
+// Names returns the names of all benchmarks.
+func Names() []string {
+	names := make([]string, len(benchmarks))
+	for i, b := range benchmarks {
+		names[i] = b.name
+	}
+	return names
+}
+
+// Run runs the benchmarks and returns the results, ordered by benchmark,
+// provider and size.
+func Run(opts *Options) ([]Result, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	duration := opts.Duration
+	if duration <= 0 {
+		duration = time.Second
+	}
+	sizes := opts.Sizes
+	if len(sizes) == 0 {
+		sizes = defaultSizes
+	}
+	for _, size := range sizes {
+		if size <= 0 || size%16 != 0 {
+			return nil, fmt.Errorf("bench: invalid size %d", size)
+		}
+	}
+	selected, err := selectBenchmarks(opts.Benchmarks)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, b := range selected {
+		providers := []backend.Provider{nil}
+		if b.alg != "" {
+			providers = backend.Providers(b.alg)
+		}
+		bSizes := []int{0}
+		if b.sized {
+			bSizes = sizes
+		}
+		for _, p := range providers {
+			r := Result{Benchmark: b.name, Provider: backend.DefaultProvider}
+			if p != nil {
+				r.Provider = p.Name()
+			}
+			if r.Provider == backend.DefaultProvider {
+				if prim, ok := backend.Lookup(b.primitive); ok {
+					r.Implementation = prim.Active.Name
+					r.ConstantTime = prim.Active.ConstantTime
+				}
+			}
+			for _, size := range bSizes {
+				f, err := b.setup(p, size)
+				if err != nil {
+					return nil, fmt.Errorf("bench: %s with provider %s: %w", b.name, r.Provider, err)
+				}
+				n, elapsed, err := measure(f, duration)
+				if err != nil {
+					return nil, fmt.Errorf("bench: %s with provider %s: %w", b.name, r.Provider, err)
+				}
+				r.Size = size
+				r.Iterations = n
+				r.NsPerOp = float64(elapsed.Nanoseconds()) / float64(n)
+				r.OpsPerSec = float64(n) / elapsed.Seconds()
+				if size > 0 {
+					r.MBPerSec = r.OpsPerSec * float64(size) / 1e6
+				}
+				results = append(results, r)
+			}
+		}
+	}
+	return results, nil
+}
+
+func selectBenchmarks(names []string) ([]benchmark, error) {
+	if len(names) == 0 {
+		return benchmarks, nil
+	}
+	var selected []benchmark
+	for _, name := range names {
+		found := false
+		for _, b := range benchmarks {
+			if b.name == name {
+				selected = append(selected, b)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("bench: unknown benchmark %q", name)
+		}
+	}
+	return selected, nil
+}
+
+// measure runs f repeatedly until the last batch takes at least d, growing the
+// batch size like the testing package does, and returns the iterations and
+// elapsed time of the last batch.
+func measure(f op, d time.Duration) (int, time.Duration, error) {
+	// warm up, and fail early
+	if err := f(); err != nil {
+		return 0, 0, err
+	}
+	n := 1
+	for {
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			if err := f(); err != nil {
+				return 0, 0, err
+			}
+		}
+		elapsed := time.Since(start)
+		if elapsed >= d {
+			return n, elapsed, nil
+		}
+		next := n * 100
+		if elapsed > 0 {
+			// aim 20% beyond d
+			predicted := int64(n) * int64(d) / int64(elapsed) * 6 / 5
+			if predicted < int64(next) {
+				next = int(predicted)
+			}
+		}
+		if next < n+1 {
+			next = n + 1
+		}
+		n = next
+	}
+}
+
+var (
+	errVerify  = errors.New("verification failed")
+	benchUID   = []byte("bench@example.com")
+	benchHash  = sm3.Sum([]byte("benchmark"))
+	sm9UserHID = byte(0x01)
+)
+
+func setupSM2Sign(p backend.Provider, _ int) (op, error) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	key, err := p.(backend.SM2Provider).SM2PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		_, err := key.Sign(rand.Reader, benchHash[:], nil)
+		return err
+	}, nil
+}
+
+func setupSM2Verify(p backend.Provider, _ int) (op, error) {
+	sp := p.(backend.SM2Provider)
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	key, err := sp.SM2PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := key.Sign(rand.Reader, benchHash[:], nil)
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		if !sp.VerifySM2(&priv.PublicKey, benchHash[:], sig) {
+			return errVerify
+		}
+		return nil
+	}, nil
+}
+
+func setupSM2Encrypt(p backend.Provider, _ int) (op, error) {
+	sp := p.(backend.SM2Provider)
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		_, err := sp.EncryptSM2(rand.Reader, &priv.PublicKey, benchHash[:])
+		return err
+	}, nil
+}
+
+func setupSM2Decrypt(p backend.Provider, _ int) (op, error) {
+	sp := p.(backend.SM2Provider)
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	key, err := sp.SM2PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := sp.EncryptSM2(rand.Reader, &priv.PublicKey, benchHash[:])
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		_, err := key.Decrypt(nil, ciphertext, nil)
+		return err
+	}, nil
+}
+
+func setupSM3(p backend.Provider, size int) (op, error) {
+	h := p.(backend.SM3Provider).NewSM3()
+	buf := make([]byte, size)
+	sum := make([]byte, 0, h.Size())
+	return func() error {
+		h.Reset()
+		h.Write(buf)
+		h.Sum(sum)
+		return nil
+	}, nil
+}
+
+func newSM4Block(p backend.Provider) (cipher.Block, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return p.(backend.SM4Provider).NewSM4Cipher(key)
+}
+
+func setupSM4CBC(p backend.Provider, size int) (op, error) {
+	block, err := newSM4Block(p)
+	if err != nil {
+		return nil, err
+	}
+	cbc := cipher.NewCBCEncrypter(block, make([]byte, block.BlockSize()))
+	buf := make([]byte, size)
+	return func() error {
+		cbc.CryptBlocks(buf, buf)
+		return nil
+	}, nil
+}
+
+func setupSM4GCM(p backend.Provider, size int) (op, error) {
+	block, err := newSM4Block(p)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	buf := make([]byte, size, size+aead.Overhead())
+	return func() error {
+		aead.Seal(buf[:0], nonce, buf, nil)
+		return nil
+	}, nil
+}
+
+func setupSM9Sign(p backend.Provider, _ int) (op, error) {
+	masterKey, err := sm9.GenerateSignMasterKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	userKey, err := masterKey.GenerateUserKey(benchUID, sm9UserHID)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := p.(backend.SM9Provider).SM9Signer(userKey)
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		_, err := signer.Sign(rand.Reader, benchHash[:], nil)
+		return err
+	}, nil
+}
+
+func setupSM9Verify(p backend.Provider, _ int) (op, error) {
+	sp := p.(backend.SM9Provider)
+	masterKey, err := sm9.GenerateSignMasterKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	userKey, err := masterKey.GenerateUserKey(benchUID, sm9UserHID)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := sp.SM9Signer(userKey)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signer.Sign(rand.Reader, benchHash[:], nil)
+	if err != nil {
+		return nil, err
+	}
+	pub := masterKey.Public()
+	return func() error {
+		if !sp.VerifySM9(pub, benchUID, sm9UserHID, benchHash[:], sig) {
+			return errVerify
+		}
+		return nil
+	}, nil
+}
+
+func setupSM9WrapKey(p backend.Provider, _ int) (op, error) {
+	sp := p.(backend.SM9Provider)
+	masterKey, err := sm9.GenerateEncryptMasterKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	pub := masterKey.Public()
+	return func() error {
+		_, _, err := sp.WrapKeySM9(rand.Reader, pub, benchUID, 0x03, 16)
+		return err
+	}, nil
+}
+
+func setupZUC(_ backend.Provider, size int) (op, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	stream, err := zuc.NewCipher(key, make([]byte, 16))
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	return func() error {
+		stream.XORKeyStream(buf, buf)
+		return nil
+	}, nil
+}
@@ -0,0 +1,55 @@
+package bench
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	results, err := Run(&Options{Duration: time.Millisecond, Sizes: []int{16, 64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]int)
+	for _, r := range results {
+		seen[r.Benchmark]++
+		if r.Iterations <= 0 || r.NsPerOp <= 0 || r.OpsPerSec <= 0 {
+			t.Errorf("%s: invalid result %+v", r.Benchmark, r)
+		}
+		if r.Provider == "go" && r.Implementation == "" {
+			t.Errorf("%s: missing implementation", r.Benchmark)
+		}
+		if (r.Size > 0) != (r.MBPerSec > 0) {
+			t.Errorf("%s: invalid throughput %+v", r.Benchmark, r)
+		}
+	}
+	for _, b := range benchmarks {
+		want := 1
+		if b.sized {
+			want = 2
+		}
+		if seen[b.name] != want {
+			t.Errorf("%s: got %d results, want %d", b.name, seen[b.name], want)
+		}
+	}
+	if _, err := json.Marshal(results); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunOptions(t *testing.T) {
+	results, err := Run(&Options{Duration: time.Millisecond, Benchmarks: []string{"sm3"}, Sizes: []int{32}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Benchmark != "sm3" || results[0].Size != 32 {
+		t.Errorf("unexpected results %+v", results)
+	}
+	if _, err := Run(&Options{Benchmarks: []string{"sm5"}}); err == nil {
+		t.Error("expected unknown benchmark error")
+	}
+	if _, err := Run(&Options{Sizes: []int{15}}); err == nil {
+		t.Error("expected invalid size error")
+	}
+}
@@ -0,0 +1,36 @@
+package backend
+
+import "github.com/emmansun/gmsm/internal/impl"
+
+// ErrVariableTime is wrapped by the errors of the operations which are refused
+// because they would run a variable-time code path, see RequireConstantTime.
+var ErrVariableTime = impl.ErrVariableTime
+
+// RequireConstantTime enables or disables the constant-time enforcement mode
+// for audited deployments. Once enabled, the operations which would run a
+// variable-time code path, as listed by VariableTimePaths, return an error
+// wrapping ErrVariableTime instead:
+//
+//   - sm4.NewCipher, if SM4 falls back to the table-lookup implementation,
+//   - the zuc constructors, if ZUC falls back to the table-lookup implementation,
+//   - SM2 signing, encryption and decryption on custom curves, which use math/big.
+//   - group.NewSuite of sm9/bn256/group, whose scalars use math/big.
+//
+// Operations on public data only, like signature verification, are not
+// refused. Cipher instances created before the mode is enabled keep working.
+func RequireConstantTime(required bool) {
+	impl.SetConstantTimeRequired(required)
+}
+
+// ConstantTimeRequired reports whether the constant-time enforcement mode is
+// enabled.
+func ConstantTimeRequired() bool {
+	return impl.ConstantTimeRequired()
+}
+
+// VariableTimePaths returns the code paths of this process whose running time
+// may depend on secret data, like "sm4: generic", sorted. They are refused once
+// RequireConstantTime(true) is called.
+func VariableTimePaths() []string {
+	return impl.VariableTimePaths()
+}
@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm4"
+	"github.com/emmansun/gmsm/sm9/bn256/group"
+	"github.com/emmansun/gmsm/zuc"
+)
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRequireConstantTime(t *testing.T) {
+	paths := VariableTimePaths()
+	t.Log(paths)
+	if !contains(paths, "sm2: custom curves (math/big)") {
+		t.Errorf("sm2 custom curves missing from %v", paths)
+	}
+	if !contains(paths, "sm9/bn256/group: math/big scalars") {
+		t.Errorf("group scalars missing from %v", paths)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom := &sm2.PrivateKey{PrivateKey: *ecKey}
+	sm2Key, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := make([]byte, 32)
+	sig, err := sm2.SignASN1(rand.Reader, custom, hash, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer RequireConstantTime(false)
+	RequireConstantTime(true)
+	if !ConstantTimeRequired() {
+		t.Fatal("constant time is not required")
+	}
+
+	if _, err := sm2.SignASN1(rand.Reader, custom, hash, nil); !errors.Is(err, ErrVariableTime) {
+		t.Errorf("custom curve signing: got %v, want ErrVariableTime", err)
+	}
+	if _, err := sm2.Encrypt(rand.Reader, &custom.PublicKey, hash, nil); !errors.Is(err, ErrVariableTime) {
+		t.Errorf("custom curve encryption: got %v, want ErrVariableTime", err)
+	}
+	if !sm2.VerifyASN1(&custom.PublicKey, hash, sig) {
+		t.Error("verification on public data must not be refused")
+	}
+	if _, err := sm2.SignASN1(rand.Reader, sm2Key, hash, nil); err != nil {
+		t.Errorf("sm2 curve signing: %v", err)
+	}
+	if _, err := group.NewSuite(); !errors.Is(err, ErrVariableTime) {
+		t.Errorf("group suite: got %v, want ErrVariableTime", err)
+	}
+
+	_, err = sm4.NewCipher(make([]byte, 16))
+	if want := contains(paths, "sm4: generic"); want != errors.Is(err, ErrVariableTime) {
+		t.Errorf("sm4: got %v with paths %v", err, paths)
+	}
+	_, err = zuc.NewCipher(make([]byte, 16), make([]byte, 16))
+	if want := contains(paths, "zuc: generic"); want != errors.Is(err, ErrVariableTime) {
+		t.Errorf("zuc: got %v with paths %v", err, paths)
+	}
+	_, err = zuc.NewHash(make([]byte, 16), make([]byte, 16))
+	if want := contains(paths, "zuc: generic"); want != errors.Is(err, ErrVariableTime) {
+		t.Errorf("zuc eia: got %v with paths %v", err, paths)
+	}
+
+	RequireConstantTime(false)
+	if _, err := sm2.SignASN1(rand.Reader, custom, hash, nil); err != nil {
+		t.Errorf("custom curve signing after the mode is disabled: %v", err)
+	}
+	if _, err := group.NewSuite(); err != nil {
+		t.Errorf("group suite after the mode is disabled: %v", err)
+	}
+}
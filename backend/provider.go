@@ -0,0 +1,303 @@
+package backend
+
+import (
+	"crypto"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm4"
+	"github.com/emmansun/gmsm/sm9"
+)
+
+// Algorithm identifies a primitive which can be served by a provider.
+type Algorithm string
+
+const (
+	SM2 Algorithm = "sm2"
+	SM3 Algorithm = "sm3"
+	SM4 Algorithm = "sm4"
+	SM9 Algorithm = "sm9"
+)
+
+// DefaultProvider is the name of the built-in pure Go provider, it's selected
+// for all algorithms unless Select is called.
+const DefaultProvider = "go"
+
+// Provider is an alternative implementation of some of the primitives, e.g. an
+// OpenSSL/Tongsuo engine via cgo, a vendor SDK or a remote KMS. A provider
+// supports an algorithm if it implements the corresponding interface:
+// SM2Provider, SM3Provider, SM4Provider or SM9Provider.
+//
+// Private keys are opaque to this package, a provider accepts its own key
+// representation, e.g. *sm2.PrivateKey for the built-in provider or a key
+// handle for an engine or HSM, and returns it as a crypto.Signer or
+// crypto.Decrypter.
+type Provider interface {
+	// Name returns the unique name of the provider.
+	Name() string
+}
+
+// SM2Provider provides SM2 signature and public key encryption.
+type SM2Provider interface {
+	Provider
+	// SM2PrivateKey returns the private key key of the provider, or an error if
+	// the provider does not support the type of key.
+	SM2PrivateKey(key crypto.PrivateKey) (SM2PrivateKey, error)
+	// VerifySM2 verifies the ASN.1 encoded signature of the digest, which is
+	// already the SM2 hash (with ZA) of the message.
+	VerifySM2(pub *ecdsa.PublicKey, digest, sig []byte) bool
+	// EncryptSM2 encrypts msg and returns the ASN.1 encoded ciphertext.
+	EncryptSM2(rand io.Reader, pub *ecdsa.PublicKey, msg []byte) ([]byte, error)
+}
+
+// SM2PrivateKey is a SM2 private key of a provider, it behaves like
+// *sm2.PrivateKey: Sign returns the ASN.1 encoded signature of the digest
+// (or of the message with *sm2.SM2SignerOption), Decrypt takes
+// *sm2.DecrypterOpts.
+type SM2PrivateKey interface {
+	crypto.Signer
+	crypto.Decrypter
+}
+
+// SM3Provider provides the SM3 hash function.
+type SM3Provider interface {
+	Provider
+	NewSM3() hash.Hash
+}
+
+// SM4Provider provides the SM4 block cipher.
+type SM4Provider interface {
+	Provider
+	NewSM4Cipher(key []byte) (cipher.Block, error)
+}
+
+// SM9Provider provides SM9 signature and key encapsulation.
+type SM9Provider interface {
+	Provider
+	// SM9Signer returns the signer of the user signature private key key, Sign
+	// returns the ASN.1 encoded signature of the hash and Public returns the
+	// *sm9.SignMasterPublicKey.
+	SM9Signer(key crypto.PrivateKey) (crypto.Signer, error)
+	// VerifySM9 verifies the ASN.1 encoded signature of the hash.
+	VerifySM9(pub *sm9.SignMasterPublicKey, uid []byte, hid byte, hash, sig []byte) bool
+	// WrapKeySM9 returns a key of kLen bytes and its ASN.1 encoded cipher, like
+	// sm9.EncryptMasterPublicKey.WrapKey.
+	WrapKeySM9(rand io.Reader, pub *sm9.EncryptMasterPublicKey, uid []byte, hid byte, kLen int) ([]byte, []byte, error)
+	// SM9KeyUnwrapper returns the user encryption private key key, or an error
+	// if the provider does not support the type of key.
+	SM9KeyUnwrapper(key crypto.PrivateKey) (SM9KeyUnwrapper, error)
+}
+
+// SM9KeyUnwrapper unwraps the keys of WrapKeySM9, like *sm9.EncryptPrivateKey.
+type SM9KeyUnwrapper interface {
+	UnwrapKey(uid, cipher []byte, kLen int) ([]byte, error)
+}
+
+var (
+	errUnknownProvider = errors.New("backend: unknown provider")
+	errDuplicate       = errors.New("backend: provider already registered")
+)
+
+type goProvider struct{}
+
+func (goProvider) Name() string { return DefaultProvider }
+
+func (goProvider) SM2PrivateKey(key crypto.PrivateKey) (SM2PrivateKey, error) {
+	priv, ok := key.(*sm2.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("backend: unsupported SM2 private key type %T", key)
+	}
+	return priv, nil
+}
+
+func (goProvider) VerifySM2(pub *ecdsa.PublicKey, digest, sig []byte) bool {
+	return sm2.VerifyASN1(pub, digest, sig)
+}
+
+func (goProvider) EncryptSM2(rand io.Reader, pub *ecdsa.PublicKey, msg []byte) ([]byte, error) {
+	return sm2.EncryptASN1(rand, pub, msg)
+}
+
+func (goProvider) NewSM3() hash.Hash { return sm3.New() }
+
+func (goProvider) NewSM4Cipher(key []byte) (cipher.Block, error) { return sm4.NewCipher(key) }
+
+// sm9Signer adds the Public method of crypto.Signer to *sm9.SignPrivateKey.
+type sm9Signer struct {
+	*sm9.SignPrivateKey
+}
+
+func (s sm9Signer) Public() crypto.PublicKey {
+	return s.MasterPublic()
+}
+
+func (goProvider) SM9Signer(key crypto.PrivateKey) (crypto.Signer, error) {
+	priv, ok := key.(*sm9.SignPrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("backend: unsupported SM9 signature private key type %T", key)
+	}
+	return sm9Signer{priv}, nil
+}
+
+func (goProvider) VerifySM9(pub *sm9.SignMasterPublicKey, uid []byte, hid byte, hash, sig []byte) bool {
+	return sm9.VerifyASN1(pub, uid, hid, hash, sig)
+}
+
+func (goProvider) WrapKeySM9(rand io.Reader, pub *sm9.EncryptMasterPublicKey, uid []byte, hid byte, kLen int) ([]byte, []byte, error) {
+	return pub.WrapKey(rand, uid, hid, kLen)
+}
+
+func (goProvider) SM9KeyUnwrapper(key crypto.PrivateKey) (SM9KeyUnwrapper, error) {
+	priv, ok := key.(*sm9.EncryptPrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("backend: unsupported SM9 encryption private key type %T", key)
+	}
+	return priv, nil
+}
+
+var registry = struct {
+	sync.RWMutex
+	providers map[string]Provider
+	selected  map[Algorithm]Provider
+}{
+	providers: map[string]Provider{DefaultProvider: goProvider{}},
+	selected: map[Algorithm]Provider{
+		SM2: goProvider{},
+		SM3: goProvider{},
+		SM4: goProvider{},
+		SM9: goProvider{},
+	},
+}
+
+// RegisterProvider registers the provider p, it does not select it for any algorithm.
+func RegisterProvider(p Provider) error {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.providers[p.Name()]; ok {
+		return errDuplicate
+	}
+	registry.providers[p.Name()] = p
+	return nil
+}
+
+func supports(p Provider, alg Algorithm) bool {
+	switch alg {
+	case SM2:
+		_, ok := p.(SM2Provider)
+		return ok
+	case SM3:
+		_, ok := p.(SM3Provider)
+		return ok
+	case SM4:
+		_, ok := p.(SM4Provider)
+		return ok
+	case SM9:
+		_, ok := p.(SM9Provider)
+		return ok
+	}
+	return false
+}
+
+// Providers returns the registered providers which support the algorithm, sorted
+// by name.
+func Providers(alg Algorithm) []Provider {
+	registry.RLock()
+	defer registry.RUnlock()
+	var ps []Provider
+	for _, p := range registry.providers {
+		if supports(p, alg) {
+			ps = append(ps, p)
+		}
+	}
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Name() < ps[j].Name() })
+	return ps
+}
+
+// Select selects the registered provider with the given name for the algorithm,
+// the provider must support the algorithm.
+func Select(alg Algorithm, name string) error {
+	registry.Lock()
+	defer registry.Unlock()
+	p, ok := registry.providers[name]
+	if !ok {
+		return errUnknownProvider
+	}
+	if !supports(p, alg) {
+		return fmt.Errorf("backend: provider %s does not support %s", name, alg)
+	}
+	registry.selected[alg] = p
+	return nil
+}
+
+// Selected returns the name of the provider selected for the algorithm.
+func Selected(alg Algorithm) string {
+	registry.RLock()
+	defer registry.RUnlock()
+	if p, ok := registry.selected[alg]; ok {
+		return p.Name()
+	}
+	return ""
+}
+
+func selected(alg Algorithm) Provider {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.selected[alg]
+}
+
+// NewSM2PrivateKey returns the private key key of the provider selected for SM2.
+func NewSM2PrivateKey(key crypto.PrivateKey) (SM2PrivateKey, error) {
+	return selected(SM2).(SM2Provider).SM2PrivateKey(key)
+}
+
+// VerifySM2 verifies the ASN.1 encoded signature of the digest with the provider
+// selected for SM2.
+func VerifySM2(pub *ecdsa.PublicKey, digest, sig []byte) bool {
+	return selected(SM2).(SM2Provider).VerifySM2(pub, digest, sig)
+}
+
+// EncryptSM2 encrypts msg with the provider selected for SM2.
+func EncryptSM2(rand io.Reader, pub *ecdsa.PublicKey, msg []byte) ([]byte, error) {
+	return selected(SM2).(SM2Provider).EncryptSM2(rand, pub, msg)
+}
+
+// NewSM3 returns a new SM3 hash.Hash of the provider selected for SM3.
+func NewSM3() hash.Hash {
+	return selected(SM3).(SM3Provider).NewSM3()
+}
+
+// NewSM4Cipher returns a new SM4 cipher.Block of the provider selected for SM4.
+func NewSM4Cipher(key []byte) (cipher.Block, error) {
+	return selected(SM4).(SM4Provider).NewSM4Cipher(key)
+}
+
+// NewSM9Signer returns the signer of the user signature private key key of the
+// provider selected for SM9.
+func NewSM9Signer(key crypto.PrivateKey) (crypto.Signer, error) {
+	return selected(SM9).(SM9Provider).SM9Signer(key)
+}
+
+// VerifySM9 verifies the ASN.1 encoded signature of the hash with the provider
+// selected for SM9.
+func VerifySM9(pub *sm9.SignMasterPublicKey, uid []byte, hid byte, hash, sig []byte) bool {
+	return selected(SM9).(SM9Provider).VerifySM9(pub, uid, hid, hash, sig)
+}
+
+// WrapKeySM9 wraps a key of kLen bytes with the provider selected for SM9.
+func WrapKeySM9(rand io.Reader, pub *sm9.EncryptMasterPublicKey, uid []byte, hid byte, kLen int) ([]byte, []byte, error) {
+	return selected(SM9).(SM9Provider).WrapKeySM9(rand, pub, uid, hid, kLen)
+}
+
+// NewSM9KeyUnwrapper returns the user encryption private key key of the
+// provider selected for SM9.
+func NewSM9KeyUnwrapper(key crypto.PrivateKey) (SM9KeyUnwrapper, error) {
+	return selected(SM9).(SM9Provider).SM9KeyUnwrapper(key)
+}
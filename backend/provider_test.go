@@ -0,0 +1,166 @@
+package backend
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"hash"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm4"
+	"github.com/emmansun/gmsm/sm9"
+)
+
+type countingProvider struct {
+	name  string
+	calls int
+}
+
+func (p *countingProvider) Name() string { return p.name }
+
+func (p *countingProvider) NewSM3() hash.Hash {
+	p.calls++
+	return sm3.New()
+}
+
+func (p *countingProvider) NewSM4Cipher(key []byte) (cipher.Block, error) {
+	p.calls++
+	return sm4.NewCipher(key)
+}
+
+func TestProviderSelection(t *testing.T) {
+	p := &countingProvider{name: "counting"}
+	if err := RegisterProvider(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterProvider(p); err == nil {
+		t.Fatal("expected duplicate registration error")
+	}
+	defer func() {
+		Select(SM3, DefaultProvider)
+		Select(SM4, DefaultProvider)
+	}()
+
+	if ps := Providers(SM3); len(ps) != 2 || ps[0].Name() != "counting" || ps[1].Name() != DefaultProvider {
+		t.Errorf("unexpected SM3 providers %v", ps)
+	}
+	if ps := Providers(SM2); len(ps) != 1 || ps[0].Name() != DefaultProvider {
+		t.Errorf("unexpected SM2 providers %v", ps)
+	}
+
+	if err := Select(SM3, "unknown"); err == nil {
+		t.Error("expected unknown provider error")
+	}
+	if err := Select(SM2, "counting"); err == nil {
+		t.Error("expected unsupported algorithm error")
+	}
+	if Selected(SM2) != DefaultProvider {
+		t.Errorf("unexpected provider %s for SM2", Selected(SM2))
+	}
+
+	NewSM3()
+	if p.calls != 0 {
+		t.Fatal("provider is used before selected")
+	}
+	if err := Select(SM3, "counting"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Select(SM4, "counting"); err != nil {
+		t.Fatal(err)
+	}
+	NewSM3()
+	if _, err := NewSM4Cipher(make([]byte, 16)); err != nil {
+		t.Fatal(err)
+	}
+	if p.calls != 2 {
+		t.Errorf("provider called %d times, want 2", p.calls)
+	}
+}
+
+func TestDefaultProviderSM2(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewSM2PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sm3.Sum([]byte("backend"))
+	sig, err := key.Sign(rand.Reader, digest[:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifySM2(&priv.PublicKey, digest[:], sig) {
+		t.Error("verify failed")
+	}
+	ciphertext, err := EncryptSM2(rand.Reader, &priv.PublicKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := key.Decrypt(nil, ciphertext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plaintext) != string(digest[:]) {
+		t.Error("decrypt mismatch")
+	}
+	if _, err := NewSM2PrivateKey(priv.PrivateKey); err == nil {
+		t.Error("expected unsupported key type error")
+	}
+}
+
+func TestDefaultProviderSM9(t *testing.T) {
+	uid, hid := []byte("backend"), byte(0x01)
+	signMaster, err := sm9.GenerateSignMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signKey, err := signMaster.GenerateUserKey(uid, hid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := NewSM9Signer(signKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.Public() != signMaster.Public() {
+		t.Error("unexpected public key")
+	}
+	digest := sm3.Sum([]byte("backend"))
+	sig, err := signer.Sign(rand.Reader, digest[:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifySM9(signMaster.Public(), uid, hid, digest[:], sig) {
+		t.Error("verify failed")
+	}
+
+	encMaster, err := sm9.GenerateEncryptMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encKey, err := encMaster.GenerateUserKey(uid, 0x03)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, cipher, err := WrapKeySM9(rand.Reader, encMaster.Public(), uid, 0x03, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unwrapper, err := NewSM9KeyUnwrapper(encKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := unwrapper.UnwrapKey(uid, cipher, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(key) {
+		t.Error("unwrap mismatch")
+	}
+	if _, err := NewSM9Signer(encKey); err == nil {
+		t.Error("expected unsupported key type error")
+	}
+}
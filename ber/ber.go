@@ -0,0 +1,277 @@
+// Package ber converts BER encoded ASN.1 to DER. Many legacy PKCS#7, PKCS#12
+// and CFCA envelopes, e.g. produced by bank systems or streaming encoders, use
+// BER features which the DER parsers of encoding/asn1 and cryptobyte reject:
+//
+//   - indefinite lengths, terminated by end-of-contents octets,
+//   - constructed (segmented) OCTET STRING, BIT STRING and character strings,
+//   - high tag number forms of low tag numbers,
+//   - BOOLEAN true values other than 0xff.
+//
+// ToDER converts such input to the canonical DER encoding, which the
+// structures are then parsed from; the output of this module is always DER.
+// The order of the elements of a SET OF is kept, as signatures may have been
+// computed on the received encoding.
+package ber
+
+import (
+	"encoding/asn1"
+	"errors"
+)
+
+// maxDepth bounds the nesting of constructed elements, so that crafted input
+// can't exhaust the stack.
+const maxDepth = 64
+
+const (
+	classUniversal   = 0x00
+	constructedFlag  = 0x20
+	highTagNumber    = 0x1f
+	maxTagBytes      = 4
+	indefiniteLength = 0x80
+
+	// universal string tags not defined by encoding/asn1
+	tagVideotexString  = 21
+	tagGraphicString   = 25
+	tagVisibleString   = 26
+	tagUniversalString = 28
+)
+
+// element is a decoded element whose content is already DER.
+type element struct {
+	identifier byte // class and constructed bits
+	tag        int
+	content    []byte
+}
+
+func (e *element) universal() bool {
+	return e.identifier&0xc0 == classUniversal
+}
+
+func (e *element) constructed() bool {
+	return e.identifier&constructedFlag != 0
+}
+
+// appendTo appends the DER encoding of e to out.
+func (e *element) appendTo(out []byte) []byte {
+	if e.tag < highTagNumber {
+		out = append(out, e.identifier|byte(e.tag))
+	} else {
+		out = append(out, e.identifier|highTagNumber)
+		n := 0
+		for t := e.tag; t > 0; t >>= 7 {
+			n++
+		}
+		for i := n - 1; i >= 0; i-- {
+			b := byte(e.tag>>(7*i)) & 0x7f
+			if i > 0 {
+				b |= 0x80
+			}
+			out = append(out, b)
+		}
+	}
+	out = appendLength(out, len(e.content))
+	return append(out, e.content...)
+}
+
+func appendLength(out []byte, length int) []byte {
+	if length < 128 {
+		return append(out, byte(length))
+	}
+	n := 0
+	for l := length; l > 0; l >>= 8 {
+		n++
+	}
+	out = append(out, 0x80|byte(n))
+	for i := n - 1; i >= 0; i-- {
+		out = append(out, byte(length>>(8*i)))
+	}
+	return out
+}
+
+// isString reports whether the universal tag is a string type, which BER
+// allows to be segmented in a constructed encoding.
+func isString(tag int) bool {
+	switch tag {
+	case asn1.TagBitString, asn1.TagOctetString, asn1.TagUTF8String, asn1.TagNumericString,
+		asn1.TagPrintableString, asn1.TagT61String, tagVideotexString, asn1.TagIA5String,
+		asn1.TagUTCTime, asn1.TagGeneralizedTime, tagGraphicString, tagVisibleString,
+		asn1.TagGeneralString, tagUniversalString, asn1.TagBMPString:
+		return true
+	}
+	return false
+}
+
+var errEndOfData = errors.New("ber: cannot move offset forward, end of ber data reached")
+
+// ToDER converts the first BER element of data to DER and returns the bytes
+// after it. DER input is returned unchanged, in a new slice.
+func ToDER(data []byte) (der, rest []byte, err error) {
+	if len(data) == 0 {
+		return nil, nil, errors.New("ber: input ber is empty")
+	}
+	e, rest, err := parse(data, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return e.appendTo(nil), rest, nil
+}
+
+// Unmarshal converts the first BER element of b to DER and parses it into
+// val with encoding/asn1. It returns the BER bytes after the element.
+func Unmarshal(b []byte, val any) (rest []byte, err error) {
+	der, rest, err := ToDER(b)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = asn1.Unmarshal(der, val); err != nil {
+		return nil, err
+	}
+	return rest, nil
+}
+
+func parse(data []byte, depth int) (*element, []byte, error) {
+	if depth > maxDepth {
+		return nil, nil, errors.New("ber: too deeply nested")
+	}
+	if len(data) < 2 {
+		return nil, nil, errEndOfData
+	}
+	e := &element{identifier: data[0] &^ highTagNumber, tag: int(data[0] & highTagNumber)}
+	offset := 1
+	if e.tag == highTagNumber {
+		e.tag = 0
+		for n := 1; ; n++ {
+			if offset >= len(data) {
+				return nil, nil, errEndOfData
+			}
+			if n > maxTagBytes {
+				return nil, nil, errors.New("ber: tag number too large")
+			}
+			b := data[offset]
+			offset++
+			e.tag = e.tag<<7 | int(b&0x7f)
+			if b&0x80 == 0 {
+				break
+			}
+		}
+	}
+
+	if offset >= len(data) {
+		return nil, nil, errEndOfData
+	}
+	l := data[offset]
+	offset++
+	if l == indefiniteLength {
+		if !e.constructed() {
+			return nil, nil, errors.New("ber: Indefinite form tag must have constructed encoding")
+		}
+		children, rest, err := parseIndefinite(data[offset:], depth)
+		if err != nil {
+			return nil, nil, err
+		}
+		return e, rest, e.setChildren(children)
+	}
+	length := int(l)
+	if l > 0x80 {
+		n := int(l & 0x7f)
+		if n > 4 { // int is only guaranteed to be 32bit
+			return nil, nil, errors.New("ber: BER tag length too long")
+		}
+		if offset+n > len(data) {
+			return nil, nil, errEndOfData
+		}
+		if n == 4 && data[offset] > 0x7f {
+			return nil, nil, errors.New("ber: BER tag length is negative")
+		}
+		if data[offset] == 0 {
+			return nil, nil, errors.New("ber: BER tag length has leading zero")
+		}
+		length = 0
+		for _, b := range data[offset : offset+n] {
+			length = length<<8 | int(b)
+		}
+		offset += n
+	}
+	if length > len(data)-offset {
+		return nil, nil, errors.New("ber: BER tag length is more than available data")
+	}
+	content, rest := data[offset:offset+length], data[offset+length:]
+
+	if !e.constructed() {
+		e.content = content
+		if e.universal() && e.tag == asn1.TagBoolean && len(content) == 1 && content[0] != 0 {
+			e.content = []byte{0xff}
+		}
+		return e, rest, nil
+	}
+	var children []*element
+	// end-of-contents octets in definite length elements, written by some
+	// encoders, are kept as is.
+	for len(content) > 0 {
+		child, next, err := parse(content, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		children = append(children, child)
+		content = next
+	}
+	return e, rest, e.setChildren(children)
+}
+
+// parseIndefinite parses the children of an indefinite length element up to
+// the end-of-contents octets.
+func parseIndefinite(data []byte, depth int) ([]*element, []byte, error) {
+	var children []*element
+	for {
+		if len(data) < 2 {
+			return nil, nil, errors.New("ber: Invalid BER format, missing end-of-contents")
+		}
+		if data[0] == 0 && data[1] == 0 {
+			return children, data[2:], nil
+		}
+		child, next, err := parse(data, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		children = append(children, child)
+		data = next
+	}
+}
+
+// setChildren sets the DER content of the constructed element e, a segmented
+// string is converted to its primitive encoding.
+func (e *element) setChildren(children []*element) error {
+	if !e.universal() || !isString(e.tag) {
+		for _, child := range children {
+			e.content = child.appendTo(e.content)
+		}
+		return nil
+	}
+
+	e.identifier &^= constructedFlag
+	if e.tag != asn1.TagBitString {
+		e.content = []byte{}
+		for _, child := range children {
+			if !child.universal() || child.constructed() || child.tag != e.tag {
+				return errors.New("ber: invalid segment of constructed string")
+			}
+			e.content = append(e.content, child.content...)
+		}
+		return nil
+	}
+	// Every segment starts with its number of unused bits, only the last one
+	// may have unused bits.
+	e.content = []byte{0}
+	for i, child := range children {
+		if !child.universal() || child.constructed() || child.tag != e.tag || len(child.content) == 0 {
+			return errors.New("ber: invalid segment of constructed bit string")
+		}
+		unused := child.content[0]
+		if unused > 7 || unused != 0 && (i != len(children)-1 || len(child.content) == 1) {
+			return errors.New("ber: invalid segment of constructed bit string")
+		}
+		e.content[0] = unused
+		e.content = append(e.content, child.content[1:]...)
+	}
+	return nil
+}
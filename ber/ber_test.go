@@ -0,0 +1,104 @@
+package ber
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+var toDERTests = []struct {
+	name, ber, der string
+}{
+	{"der", "3003 020101", "3003 020101"},
+	{"indefinite", "3080 020101 0000", "3003 020101"},
+	{"nested indefinite", "3080 3080 020101 0000 3080 020102 0000 0000", "300a 3003 020101 3003 020102"},
+	{"empty indefinite", "3080 0000", "3000"},
+	{"long form length", "3081 03 020101", "3003 020101"},
+	{"segmented octet string", "2480 0402 0102 0401 03 0000", "0403 010203"},
+	{"nested segments", "240a 2480 0401 01 0000 0401 02", "0402 0102"},
+	{"segmented bit string", "2380 0302 00aa 0302 04b0 0000", "0303 04aab0"},
+	{"empty segmented string", "2480 0000", "0400"},
+	{"segmented utf8 string", "2c80 0c02 6869 0c01 21 0000", "0c03 686921"},
+	{"context specific indefinite", "a080 0401 01 0000", "a003 040101"},
+	{"high tag number form", "1f02 0101", "0201 01"},
+	{"high tag number", "9f8148 0101", "9f8148 0101"},
+	{"boolean", "3080 010101 0000", "3003 0101ff"},
+	{"large", "2480 0481 80" + strings.Repeat("aa", 128) + "0000", "0481 80" + strings.Repeat("aa", 128)},
+}
+
+func TestToDER(t *testing.T) {
+	for _, tt := range toDERTests {
+		in := mustDecodeHex(tt.ber)
+		der, rest, err := ToDER(append(in, 0x05, 0x00))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if want := mustDecodeHex(tt.der); !bytes.Equal(der, want) {
+			t.Errorf("%s: got %x, want %x", tt.name, der, want)
+		}
+		if !bytes.Equal(rest, []byte{0x05, 0x00}) {
+			t.Errorf("%s: got rest %x", tt.name, rest)
+		}
+		again, _, err := ToDER(der)
+		if err != nil || !bytes.Equal(again, der) {
+			t.Errorf("%s: not idempotent: %x, %v", tt.name, again, err)
+		}
+	}
+}
+
+func TestToDERErrors(t *testing.T) {
+	for _, tt := range []struct {
+		ber, err string
+	}{
+		{"", "empty"},
+		{"30", "end of ber data reached"},
+		{"3085", "tag length too long"},
+		{"3084 80000000", "length is negative"},
+		{"3082 0001", "length has leading zero"},
+		{"3080 01020102", "Invalid BER format"},
+		{"3080 0102", "length is more than available data"},
+		{"3003 0102", "length is more than available data"},
+		{"0480 0000", "must have constructed encoding"},
+		{"2480 0201 01 0000", "invalid segment"},
+		{"2480 2401 00 0000", "end of ber data reached"},
+		{"2380 0302 01aa 0302 00b0 0000", "invalid segment"},
+		{"2380 0300 0000", "invalid segment"},
+		{"1f8080808001 00", "tag number too large"},
+		{strings.Repeat("3080", maxDepth+2), "too deeply nested"},
+	} {
+		_, _, err := ToDER(mustDecodeHex(tt.ber))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got %v, want error containing %q", tt.ber, err, tt.err)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	var v struct {
+		Version int
+		Content []byte `asn1:"explicit,tag:0"`
+		Flag    bool
+	}
+	ber := mustDecodeHex("3080 020101 a080 2480 0402 6869 0000 0000 010101 0000 ff")
+	rest, err := Unmarshal(ber, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Version != 1 || string(v.Content) != "hi" || !v.Flag || !bytes.Equal(rest, []byte{0xff}) {
+		t.Errorf("unexpected %+v, rest %x", v, rest)
+	}
+	if _, err := asn1.Unmarshal(ber, &v); err == nil {
+		t.Error("encoding/asn1 is expected to reject BER")
+	}
+}
@@ -0,0 +1,169 @@
+// Package blockchain implements the SM2 signature and address conventions of
+// blockchains with SM2/SM3 support, like FISCO BCOS in its national
+// cryptography mode, so that nodes and wallets can use this module directly.
+//
+// Transactions are signed over their 32 bytes SM3 digest, which is used as e
+// of GB/T 32918.2, no Z value is prepended. Signatures are either
+//
+//   - r || s || v, 65 bytes, v is the recovery id, 0 to 3 or 27 to 30, the
+//     public key is recovered from the signature like with ecrecover, or
+//   - r || s || x || y, 128 bytes, the signature carries the public key.
+//
+// The address of a public key is the last 20 bytes of SM3(x || y).
+package blockchain
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/emmansun/gmsm/codec"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+)
+
+const (
+	// AddressLength is the length of an address in bytes.
+	AddressLength = 20
+	// SignatureLength is the length of a r || s || v signature.
+	SignatureLength = 65
+	// SignatureWithPublicKeyLength is the length of a r || s || x || y signature.
+	SignatureWithPublicKeyLength = 128
+
+	digestSize = sm3.Size
+	rsLength   = 64
+	// legacyRecoveryOffset is added to the recovery id by Ethereum style signers.
+	legacyRecoveryOffset = 27
+)
+
+var errInvalidSignature = errors.New("blockchain: invalid signature")
+
+// Address is the address of a SM2 public key.
+type Address [AddressLength]byte
+
+// PubkeyToAddress returns the address of pub, the last 20 bytes of SM3(x || y).
+func PubkeyToAddress(pub *ecdsa.PublicKey) Address {
+	var xy [rsLength]byte
+	pub.X.FillBytes(xy[:32])
+	pub.Y.FillBytes(xy[32:])
+	h := sm3.Sum(xy[:])
+	var a Address
+	copy(a[:], h[len(h)-AddressLength:])
+	return a
+}
+
+// ParseAddress parses the hex encoding of an address, with or without 0x
+// prefix. Letters may be of any case.
+func ParseAddress(s string) (Address, error) {
+	var a Address
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s = s[2:]
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != AddressLength {
+		return a, errors.New("blockchain: invalid address " + s)
+	}
+	copy(a[:], b)
+	return a, nil
+}
+
+// Hex returns the lower case hex encoding of a with 0x prefix.
+func (a Address) Hex() string {
+	return "0x" + hex.EncodeToString(a[:])
+}
+
+func (a Address) String() string {
+	return a.Hex()
+}
+
+// Sign signs the 32 bytes SM3 digest with priv and returns the r || s || v
+// signature, v is from 0 to 3.
+func Sign(rand io.Reader, priv *sm2.PrivateKey, digest []byte) ([]byte, error) {
+	if len(digest) != digestSize {
+		return nil, errors.New("blockchain: invalid digest length")
+	}
+	sig, err := sm2.SignASN1(rand, priv, digest, nil)
+	if err != nil {
+		return nil, err
+	}
+	v, err := sm2.RecoveryID(&priv.PublicKey, digest, sig)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := codec.SignatureASN1ToRS(sig)
+	if err != nil {
+		return nil, err
+	}
+	return append(rs, v), nil
+}
+
+// SignWithPublicKey signs the 32 bytes SM3 digest with priv and returns the
+// r || s || x || y signature.
+func SignWithPublicKey(rand io.Reader, priv *sm2.PrivateKey, digest []byte) ([]byte, error) {
+	if len(digest) != digestSize {
+		return nil, errors.New("blockchain: invalid digest length")
+	}
+	sig, err := sm2.SignASN1(rand, priv, digest, nil)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := codec.SignatureASN1ToRS(sig)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, SignatureWithPublicKeyLength)
+	copy(out, rs)
+	priv.X.FillBytes(out[rsLength : rsLength+32])
+	priv.Y.FillBytes(out[rsLength+32:])
+	return out, nil
+}
+
+// Recover returns the public key which signed the 32 bytes SM3 digest. sig
+// is a r || s || v signature, the key is recovered, or a r || s || x || y
+// signature, the signature is verified with the key it carries.
+func Recover(digest, sig []byte) (*ecdsa.PublicKey, error) {
+	if len(digest) != digestSize {
+		return nil, errors.New("blockchain: invalid digest length")
+	}
+	if len(sig) != SignatureLength && len(sig) != SignatureWithPublicKeyLength {
+		return nil, errInvalidSignature
+	}
+	der, err := codec.SignatureRSToASN1(sig[:rsLength])
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) == SignatureLength {
+		v := sig[rsLength]
+		if v >= legacyRecoveryOffset {
+			v -= legacyRecoveryOffset
+		}
+		return sm2.RecoverPublicKey(digest, der, v)
+	}
+	pub, err := sm2.NewPublicKey(append([]byte{4}, sig[rsLength:]...))
+	if err != nil {
+		return nil, err
+	}
+	if !sm2.VerifyASN1(pub, digest, der) {
+		return nil, errInvalidSignature
+	}
+	return pub, nil
+}
+
+// RecoverAddress returns the address of the public key which signed the 32
+// bytes SM3 digest, see Recover.
+func RecoverAddress(digest, sig []byte) (Address, error) {
+	pub, err := Recover(digest, sig)
+	if err != nil {
+		return Address{}, err
+	}
+	return PubkeyToAddress(pub), nil
+}
+
+// Verify reports whether sig is a valid signature of the 32 bytes SM3 digest
+// by the owner of addr.
+func Verify(addr Address, digest, sig []byte) bool {
+	got, err := RecoverAddress(digest, sig)
+	return err == nil && got == addr
+}
@@ -0,0 +1,85 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+)
+
+func TestSignRecover(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := PubkeyToAddress(&priv.PublicKey)
+	xy := append(priv.X.FillBytes(make([]byte, 32)), priv.Y.FillBytes(make([]byte, 32))...)
+	if h := sm3.Sum(xy); !bytes.Equal(addr[:], h[12:]) {
+		t.Fatalf("unexpected address %v", addr)
+	}
+	digest := sm3.Sum([]byte("transaction"))
+	for _, sign := range []func() ([]byte, error){
+		func() ([]byte, error) { return Sign(rand.Reader, priv, digest[:]) },
+		func() ([]byte, error) { return SignWithPublicKey(rand.Reader, priv, digest[:]) },
+	} {
+		sig, err := sign()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := Recover(digest[:], sig)
+		if err != nil || !pub.Equal(&priv.PublicKey) {
+			t.Fatalf("got %v, %v", pub, err)
+		}
+		if !Verify(addr, digest[:], sig) {
+			t.Error("valid signature rejected")
+		}
+		other := digest
+		other[0] ^= 1
+		if Verify(addr, other[:], sig) {
+			t.Error("signature of another digest accepted")
+		}
+		bad := append([]byte(nil), sig...)
+		bad[10] ^= 1
+		if Verify(addr, digest[:], bad) {
+			t.Error("corrupted signature accepted")
+		}
+		if len(sig) == SignatureLength {
+			legacy := append([]byte(nil), sig...)
+			legacy[64] += 27
+			if !Verify(addr, digest[:], legacy) {
+				t.Error("legacy recovery id rejected")
+			}
+			legacy[64] = 4
+			if _, err := Recover(digest[:], legacy); err == nil {
+				t.Error("invalid recovery id accepted")
+			}
+		}
+	}
+	if _, err := Sign(rand.Reader, priv, digest[:31]); err == nil {
+		t.Error("expected error with a short digest")
+	}
+	if _, err := Recover(digest[:], make([]byte, 64)); err == nil {
+		t.Error("expected error with a 64 bytes signature")
+	}
+}
+
+func TestAddress(t *testing.T) {
+	const s = "0x3e5d5fa2c0e18c7a3a43f3fa0a4c3be46ad3a2c7"
+	a, err := ParseAddress(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Hex() != s || a.String() != s {
+		t.Errorf("got %v", a)
+	}
+	if b, err := ParseAddress("3E5D5FA2C0E18C7A3A43F3FA0A4C3BE46AD3A2C7"); err != nil || b != a {
+		t.Errorf("got %v, %v", b, err)
+	}
+	for _, s := range []string{"", "0x", "0x3e5d", s + "00", "0xzz5d5fa2c0e18c7a3a43f3fa0a4c3be46ad3a2c7"} {
+		if _, err := ParseAddress(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
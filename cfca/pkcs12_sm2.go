@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/emmansun/gmsm/ber"
 	"github.com/emmansun/gmsm/kdf"
 	"github.com/emmansun/gmsm/padding"
 	"github.com/emmansun/gmsm/pkcs"
@@ -47,7 +48,7 @@ var (
 // ParseSM2 parses the der data, returns private key and related certificate, it's CFCA private structure.
 func ParseSM2(password, data []byte) (*sm2.PrivateKey, *smx509.Certificate, error) {
 	var keys cfcaKeyPairData
-	if _, err := asn1.Unmarshal(data, &keys); err != nil {
+	if _, err := ber.Unmarshal(data, &keys); err != nil {
 		return nil, nil, err
 	}
 	if !keys.Certificate.ContentType.Equal(oidSM2Data) {
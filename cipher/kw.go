@@ -0,0 +1,101 @@
+package cipher
+
+import (
+	goCipher "crypto/cipher"
+	goSubtle "crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// defaultKeyWrapIV is the default initial value of RFC 3394 section 2.2.3.1.
+var defaultKeyWrapIV = [8]byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+var errKeyUnwrap = errors.New("cipher: key unwrap integrity check failed")
+
+// KeyWrapper wraps and unwraps keys with a key encryption key. It's
+// implemented in software by NewKeyWrapper, and can be implemented by keys
+// which never leave a hardware token, e.g. with CKM_SM4_KEY_WRAP.
+type KeyWrapper interface {
+	// Wrap wraps plaintext, typically a content encryption key.
+	Wrap(plaintext []byte) ([]byte, error)
+	// Unwrap unwraps the output of Wrap, it returns an error if the integrity
+	// check fails.
+	Unwrap(ciphertext []byte) ([]byte, error)
+}
+
+type blockKeyWrapper struct {
+	b goCipher.Block
+}
+
+// NewKeyWrapper returns a KeyWrapper of KeyWrap and KeyUnwrap with the given
+// 128-bit block cipher.
+func NewKeyWrapper(b goCipher.Block) (KeyWrapper, error) {
+	if b.BlockSize() != 16 {
+		return nil, errors.New("cipher: key wrap requires a 128-bit block cipher")
+	}
+	return blockKeyWrapper{b}, nil
+}
+
+func (w blockKeyWrapper) Wrap(plaintext []byte) ([]byte, error) { return KeyWrap(w.b, plaintext) }
+
+func (w blockKeyWrapper) Unwrap(ciphertext []byte) ([]byte, error) {
+	return KeyUnwrap(w.b, ciphertext)
+}
+
+// KeyWrap wraps plaintext, typically a content encryption key, with the given
+// 128-bit block cipher as the key encryption key, according to RFC 3394. The
+// plaintext must be a multiple of 8 bytes and at least 16 bytes long. The
+// output is 8 bytes longer than the plaintext.
+func KeyWrap(b goCipher.Block, plaintext []byte) ([]byte, error) {
+	if b.BlockSize() != 16 {
+		return nil, errors.New("cipher: key wrap requires a 128-bit block cipher")
+	}
+	if len(plaintext) < 16 || len(plaintext)%8 != 0 {
+		return nil, errors.New("cipher: invalid key wrap plaintext length")
+	}
+	n := len(plaintext) / 8
+	out := make([]byte, 8+len(plaintext))
+	copy(out, defaultKeyWrapIV[:])
+	copy(out[8:], plaintext)
+	var block [16]byte
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(block[:8], out[:8])
+			copy(block[8:], out[i*8:])
+			b.Encrypt(block[:], block[:])
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(block[:8])^t)
+			copy(out[i*8:], block[8:])
+		}
+	}
+	return out, nil
+}
+
+// KeyUnwrap unwraps the output of KeyWrap, it returns an error if the integrity
+// check fails.
+func KeyUnwrap(b goCipher.Block, ciphertext []byte) ([]byte, error) {
+	if b.BlockSize() != 16 {
+		return nil, errors.New("cipher: key wrap requires a 128-bit block cipher")
+	}
+	if len(ciphertext) < 24 || len(ciphertext)%8 != 0 {
+		return nil, errors.New("cipher: invalid key wrap ciphertext length")
+	}
+	n := len(ciphertext)/8 - 1
+	out := make([]byte, len(ciphertext))
+	copy(out, ciphertext)
+	var block [16]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(block[:8], binary.BigEndian.Uint64(out[:8])^t)
+			copy(block[8:], out[i*8:])
+			b.Decrypt(block[:], block[:])
+			copy(out[:8], block[:8])
+			copy(out[i*8:], block[8:])
+		}
+	}
+	if goSubtle.ConstantTimeCompare(out[:8], defaultKeyWrapIV[:]) != 1 {
+		return nil, errKeyUnwrap
+	}
+	return out[8:], nil
+}
@@ -0,0 +1,96 @@
+package cipher_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+
+	smcipher "github.com/emmansun/gmsm/cipher"
+	"github.com/emmansun/gmsm/sm4"
+)
+
+var kwTests = []struct {
+	kek, key, wrapped string
+}{
+	{ // RFC 3394 4.1 Wrap 128 bits of Key Data with a 128-bit KEK
+		"000102030405060708090A0B0C0D0E0F",
+		"00112233445566778899AABBCCDDEEFF",
+		"1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5",
+	},
+	{ // RFC 3394 4.3 Wrap 128 bits of Key Data with a 256-bit KEK
+		"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F",
+		"00112233445566778899AABBCCDDEEFF",
+		"64E8C3F9CE0F5BA263E9777905818A2A93C8191E7D6E8AE7",
+	},
+	{ // RFC 3394 4.6 Wrap 256 bits of Key Data with a 256-bit KEK
+		"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F",
+		"00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F",
+		"28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21",
+	},
+}
+
+func TestKeyWrapAES(t *testing.T) {
+	for i, tt := range kwTests {
+		kek, _ := hex.DecodeString(tt.kek)
+		key, _ := hex.DecodeString(tt.key)
+		want, _ := hex.DecodeString(tt.wrapped)
+		c, err := aes.NewCipher(kek)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wrapped, err := smcipher.KeyWrap(c, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(wrapped, want) {
+			t.Errorf("#%d: got %x, want %x", i, wrapped, want)
+		}
+		unwrapped, err := smcipher.KeyUnwrap(c, wrapped)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(unwrapped, key) {
+			t.Errorf("#%d: got %x, want %x", i, unwrapped, key)
+		}
+	}
+}
+
+func TestKeyWrapSM4(t *testing.T) {
+	kek, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	key, _ := hex.DecodeString("00112233445566778899aabbccddeeff0001020304050607")
+	c, err := sm4.NewCipher(kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := smcipher.KeyWrap(c, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wrapped) != len(key)+8 {
+		t.Fatalf("got %d bytes, want %d", len(wrapped), len(key)+8)
+	}
+	unwrapped, err := smcipher.KeyUnwrap(c, wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unwrapped, key) {
+		t.Errorf("got %x, want %x", unwrapped, key)
+	}
+	wrapped[len(wrapped)-1] ^= 1
+	if _, err := smcipher.KeyUnwrap(c, wrapped); err == nil {
+		t.Error("expected integrity check failure")
+	}
+	if _, err := smcipher.KeyWrap(c, key[:12]); err == nil {
+		t.Error("expected invalid length error")
+	}
+
+	w, err := smcipher.NewKeyWrapper(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped[len(wrapped)-1] ^= 1
+	if unwrapped, err = w.Unwrap(wrapped); err != nil || !bytes.Equal(unwrapped, key) {
+		t.Errorf("got %x, %v", unwrapped, err)
+	}
+}
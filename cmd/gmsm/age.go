@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/smage"
+)
+
+// fileList is a repeatable flag.
+type fileList []string
+
+func (l *fileList) String() string     { return strings.Join(*l, ",") }
+func (l *fileList) Set(v string) error { *l = append(*l, v); return nil }
+
+func runAge(e *env, args []string) error {
+	fs := newFlagSet(e, "age")
+	var recipients fileList
+	fs.Var(&recipients, "r", "recipient SM2 public key or certificate file, can be repeated")
+	pass := fs.String("pass", "", "encrypt or decrypt with a pass phrase, e.g. pass:secret")
+	iter := fs.Int("iter", smage.DefaultIterations, "PBKDF2 iteration count of the pass phrase")
+	decrypt := fs.Bool("d", false, "decrypt")
+	keyFile := fs.String("i", "", "SM2 private key file to decrypt with")
+	passin := fs.String("passin", "", "private key pass phrase source")
+	in := fs.String("in", "", "input file")
+	out := fs.String("out", "", "output file")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if *decrypt {
+		if (*keyFile == "") == (*pass == "") || len(recipients) > 0 {
+			fs.Usage()
+			return errUsage
+		}
+	} else if (len(recipients) == 0) == (*pass == "") || *keyFile != "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	src := e.stdin
+	if *in != "" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		src = f
+	}
+	var dst io.Writer = e.stdout
+	if *out != "" {
+		f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		dst = f
+	}
+
+	if *decrypt {
+		var id smage.Identity
+		if *pass != "" {
+			password, err := parsePassword(*pass)
+			if err != nil {
+				return err
+			}
+			if id, err = smage.NewPassphraseIdentity(string(password)); err != nil {
+				return err
+			}
+		} else {
+			key, err := readPrivateKey(*keyFile, *passin)
+			if err != nil {
+				return err
+			}
+			priv, ok := key.(*sm2.PrivateKey)
+			if !ok {
+				return fmt.Errorf("%s is not a SM2 private key", *keyFile)
+			}
+			if id, err = smage.NewSM2Identity(priv); err != nil {
+				return err
+			}
+		}
+		r, err := smage.Decrypt(src, id)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(dst, r)
+		return err
+	}
+
+	var rs []smage.Recipient
+	if *pass != "" {
+		password, err := parsePassword(*pass)
+		if err != nil {
+			return err
+		}
+		r, err := smage.NewPassphraseRecipient(string(password))
+		if err != nil {
+			return err
+		}
+		if err = r.SetIterations(*iter); err != nil {
+			return err
+		}
+		rs = append(rs, r)
+	}
+	for _, name := range recipients {
+		key, err := readPublicKey(name)
+		if err != nil {
+			return err
+		}
+		pub, err := sm2PublicKey(key)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		r, err := smage.NewSM2Recipient(pub)
+		if err != nil {
+			return err
+		}
+		rs = append(rs, r)
+	}
+	w, err := smage.Encrypt(dst, rs...)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(w, src); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return errors.New("failed to finish the encryption: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/smx509"
+)
+
+func runRequest(e *env, args []string) error {
+	fs := newFlagSet(e, "req")
+	keyFile := fs.String("key", "", "SM2 private key file")
+	passin := fs.String("passin", "", "private key pass phrase source")
+	subj := fs.String("subj", "", "subject, e.g. CN=example.com,O=Example,C=CN")
+	dns := fs.String("dns", "", "comma separated DNS names")
+	out := fs.String("out", "", "output file")
+	if err := fs.Parse(args); err != nil || *keyFile == "" || *subj == "" {
+		fs.Usage()
+		return errUsage
+	}
+	key, err := readSM2PrivateKey(*keyFile, *passin)
+	if err != nil {
+		return err
+	}
+	subject, err := parseSubject(*subj)
+	if err != nil {
+		return err
+	}
+	template := &x509.CertificateRequest{
+		Subject:            subject,
+		SignatureAlgorithm: x509.SignatureAlgorithm(smx509.SM2WithSM3),
+	}
+	if *dns != "" {
+		template.DNSNames = strings.Split(*dns, ",")
+	}
+	der, err := smx509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return err
+	}
+	return e.writeOutput(*out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), 0644)
+}
+
+func runCertificate(e *env, args []string) error {
+	fs := newFlagSet(e, "x509")
+	reqFile := fs.String("req", "", "certificate signing request file")
+	keyFile := fs.String("key", "", "SM2 private key file of the issuer")
+	passin := fs.String("passin", "", "private key pass phrase source")
+	caFile := fs.String("ca", "", "issuer certificate file, self-signed if it's empty")
+	days := fs.Int("days", 365, "number of days the certificate is valid for")
+	isCA := fs.Bool("isca", false, "issue a CA certificate")
+	out := fs.String("out", "", "output file")
+	if err := fs.Parse(args); err != nil || *reqFile == "" || *keyFile == "" {
+		fs.Usage()
+		return errUsage
+	}
+	key, err := readSM2PrivateKey(*keyFile, *passin)
+	if err != nil {
+		return err
+	}
+	block, err := readPEM(*reqFile)
+	if err != nil {
+		return err
+	}
+	csr, err := smx509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return fmt.Errorf("invalid certificate signing request: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	template := &smx509.Certificate{
+		SerialNumber:          serial,
+		Subject:               csr.Subject,
+		DNSNames:              csr.DNSNames,
+		EmailAddresses:        csr.EmailAddresses,
+		IPAddresses:           csr.IPAddresses,
+		URIs:                  csr.URIs,
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.AddDate(0, 0, *days),
+		SignatureAlgorithm:    smx509.SM2WithSM3,
+		BasicConstraintsValid: true,
+		IsCA:                  *isCA,
+	}
+	if *isCA {
+		template.KeyUsage = smx509.KeyUsageCertSign | smx509.KeyUsageCRLSign | smx509.KeyUsageDigitalSignature
+	} else {
+		template.KeyUsage = smx509.KeyUsageDigitalSignature | smx509.KeyUsageKeyEncipherment
+	}
+	parent := template
+	if *caFile != "" {
+		block, err := readPEM(*caFile)
+		if err != nil {
+			return err
+		}
+		if parent, err = smx509.ParseCertificate(block.Bytes); err != nil {
+			return err
+		}
+	}
+	der, err := smx509.CreateCertificate(rand.Reader, template, parent, csr.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	return e.writeOutput(*out, pem.EncodeToMemory(&pem.Block{Type: pemCertificate, Bytes: der}), 0644)
+}
+
+func readSM2PrivateKey(name, passin string) (*sm2.PrivateKey, error) {
+	key, err := readPrivateKey(name, passin)
+	if err != nil {
+		return nil, err
+	}
+	k, ok := key.(*sm2.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a SM2 private key", name)
+	}
+	return k, nil
+}
+
+// parseSubject parses subject in "CN=name,O=org,OU=unit,L=city,ST=province,C=CN" form.
+func parseSubject(s string) (pkix.Name, error) {
+	var name pkix.Name
+	for _, rdn := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(rdn), "=")
+		if !ok || v == "" {
+			return name, fmt.Errorf("invalid subject component %q", rdn)
+		}
+		switch strings.ToUpper(k) {
+		case "CN":
+			name.CommonName = v
+		case "O":
+			name.Organization = append(name.Organization, v)
+		case "OU":
+			name.OrganizationalUnit = append(name.OrganizationalUnit, v)
+		case "L":
+			name.Locality = append(name.Locality, v)
+		case "ST":
+			name.Province = append(name.Province, v)
+		case "C":
+			name.Country = append(name.Country, v)
+		default:
+			return name, fmt.Errorf("unsupported subject attribute %q", k)
+		}
+	}
+	return name, nil
+}
+
+func runText(e *env, args []string) error {
+	fs := newFlagSet(e, "text")
+	in := fs.String("in", "", "PEM encoded certificate, certificate request or CRL file")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	data, err := e.readInput(*in)
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return errors.New("no PEM data found")
+	}
+	var text string
+	switch block.Type {
+	case pemCertificate:
+		cert, err := smx509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return err
+		}
+		text, err = smx509.CertificateText(cert)
+		if err != nil {
+			return err
+		}
+	case "CERTIFICATE REQUEST":
+		csr, err := smx509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return err
+		}
+		text, err = smx509.CertificateRequestText(csr)
+		if err != nil {
+			return err
+		}
+	case "X509 CRL":
+		crl, err := smx509.ParseDERCRL(block.Bytes)
+		if err != nil {
+			return err
+		}
+		text = smx509.CRLText(crl)
+	default:
+		return fmt.Errorf("unexpected PEM type %q", block.Type)
+	}
+	_, err = io.WriteString(e.stdout, text)
+	return err
+}
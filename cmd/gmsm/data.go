@@ -0,0 +1,302 @@
+package main
+
+import (
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm4"
+	"github.com/emmansun/gmsm/sm9"
+)
+
+var errVerification = errors.New("verification failure")
+
+func runDigest(e *env, args []string) error {
+	fs := newFlagSet(e, "dgst")
+	hmacKey := fs.String("hmac", "", "HMAC-SM3 key")
+	in := fs.String("in", "", "input file")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	data, err := e.readInput(*in)
+	if err != nil {
+		return err
+	}
+	var h hash.Hash
+	name := "SM3"
+	if *hmacKey != "" {
+		h = hmac.New(sm3.New, []byte(*hmacKey))
+		name = "HMAC-SM3"
+	} else {
+		h = sm3.New()
+	}
+	h.Write(data)
+	source := *in
+	if source == "" {
+		source = "stdin"
+	}
+	// same output as "openssl dgst -sm3"
+	_, err = fmt.Fprintf(e.stdout, "%s(%s)= %x\n", name, source, h.Sum(nil))
+	return err
+}
+
+func runSign(e *env, args []string) error {
+	fs := newFlagSet(e, "sign")
+	keyFile := fs.String("key", "", "SM2 or SM9 sign user private key file")
+	passin := fs.String("passin", "", "private key pass phrase source")
+	uid := fs.String("uid", "", "SM2 user identity, defaults to 1234567812345678")
+	emptyID := fs.Bool("emptyid", false, "SM2 empty user identity, as \"openssl dgst -sm3 -sign\" without distid")
+	in := fs.String("in", "", "input file")
+	out := fs.String("out", "", "signature file")
+	if err := fs.Parse(args); err != nil || *keyFile == "" {
+		fs.Usage()
+		return errUsage
+	}
+	key, err := readPrivateKey(*keyFile, *passin)
+	if err != nil {
+		return err
+	}
+	msg, err := e.readInput(*in)
+	if err != nil {
+		return err
+	}
+	var sig []byte
+	switch k := key.(type) {
+	case *sm2.PrivateKey:
+		if *emptyID {
+			sig, err = sm2.SignASN1(rand.Reader, k, emptyIDHash(&k.PublicKey, msg), nil)
+		} else {
+			sig, err = k.Sign(rand.Reader, msg, sm2.NewSM2SignerOption(true, []byte(*uid)))
+		}
+	case *sm9.SignPrivateKey:
+		sig, err = sm9.SignASN1(rand.Reader, k, msg)
+	default:
+		return fmt.Errorf("%s is not a SM2 or SM9 sign user private key", *keyFile)
+	}
+	if err != nil {
+		return err
+	}
+	return e.writeOutput(*out, sig, 0644)
+}
+
+func runVerify(e *env, args []string) error {
+	fs := newFlagSet(e, "verify")
+	pubFile := fs.String("pub", "", "SM2 public key, certificate or SM9 sign master public key file")
+	sigFile := fs.String("sig", "", "signature file")
+	uid := fs.String("uid", "", "user identity, mandatory for SM9")
+	emptyID := fs.Bool("emptyid", false, "SM2 empty user identity, as \"openssl dgst -sm3 -verify\" without distid")
+	hid := fs.Int("hid", 0x01, "SM9 hid")
+	in := fs.String("in", "", "input file")
+	if err := fs.Parse(args); err != nil || *pubFile == "" || *sigFile == "" {
+		fs.Usage()
+		return errUsage
+	}
+	pub, err := readPublicKey(*pubFile)
+	if err != nil {
+		return err
+	}
+	sig, err := e.readInput(*sigFile)
+	if err != nil {
+		return err
+	}
+	msg, err := e.readInput(*in)
+	if err != nil {
+		return err
+	}
+	var ok bool
+	switch k := pub.(type) {
+	case *sm9.SignMasterPublicKey:
+		if *uid == "" {
+			return errors.New("uid is required for SM9")
+		}
+		ok = sm9.VerifyASN1(k, []byte(*uid), byte(*hid), msg, sig)
+	default:
+		pk, err := sm2PublicKey(pub)
+		if err != nil {
+			return err
+		}
+		if *emptyID {
+			ok = sm2.VerifyASN1(pk, emptyIDHash(pk, msg), sig)
+		} else {
+			ok = sm2.VerifyASN1WithSM2(pk, []byte(*uid), msg, sig)
+		}
+	}
+	if !ok {
+		return errVerification
+	}
+	_, err = fmt.Fprintln(e.stdout, "Verified OK")
+	return err
+}
+
+// emptyIDHash returns SM3(ZA || msg) where ZA is calculated with an empty user
+// identity (ENTL is zero). OpenSSL 3.x uses it unless "-sigopt distid:..." is given,
+// while GB/T 35276 specifies the default identity 1234567812345678.
+func emptyIDHash(pub *ecdsa.PublicKey, msg []byte) []byte {
+	za, _ := sm2.CalculateZA(pub, nil) // an empty uid never fails
+	h := sm3.New()
+	h.Write(za)
+	h.Write(msg)
+	return h.Sum(nil)
+}
+
+func runEncrypt(e *env, args []string) error {
+	fs := newFlagSet(e, "encrypt")
+	pubFile := fs.String("pub", "", "SM2 public key, certificate or SM9 encrypt master public key file")
+	uid := fs.String("uid", "", "SM9 user identity")
+	hid := fs.Int("hid", 0x03, "SM9 hid")
+	in := fs.String("in", "", "input file")
+	out := fs.String("out", "", "output file")
+	if err := fs.Parse(args); err != nil || *pubFile == "" {
+		fs.Usage()
+		return errUsage
+	}
+	pub, err := readPublicKey(*pubFile)
+	if err != nil {
+		return err
+	}
+	msg, err := e.readInput(*in)
+	if err != nil {
+		return err
+	}
+	var ciphertext []byte
+	switch k := pub.(type) {
+	case *sm9.EncryptMasterPublicKey:
+		if *uid == "" {
+			return errors.New("uid is required for SM9")
+		}
+		ciphertext, err = sm9.EncryptASN1(rand.Reader, k, []byte(*uid), byte(*hid), msg, sm9.SM4CBCEncrypterOpts)
+	default:
+		var pk *ecdsa.PublicKey
+		if pk, err = sm2PublicKey(pub); err == nil {
+			ciphertext, err = sm2.EncryptASN1(rand.Reader, pk, msg)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return e.writeOutput(*out, ciphertext, 0644)
+}
+
+func runDecrypt(e *env, args []string) error {
+	fs := newFlagSet(e, "decrypt")
+	keyFile := fs.String("key", "", "SM2 or SM9 encrypt user private key file")
+	passin := fs.String("passin", "", "private key pass phrase source")
+	uid := fs.String("uid", "", "SM9 user identity")
+	in := fs.String("in", "", "input file")
+	out := fs.String("out", "", "output file")
+	if err := fs.Parse(args); err != nil || *keyFile == "" {
+		fs.Usage()
+		return errUsage
+	}
+	key, err := readPrivateKey(*keyFile, *passin)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := e.readInput(*in)
+	if err != nil {
+		return err
+	}
+	var plaintext []byte
+	switch k := key.(type) {
+	case *sm2.PrivateKey:
+		plaintext, err = k.Decrypt(nil, ciphertext, sm2.ASN1DecrypterOpts)
+	case *sm9.EncryptPrivateKey:
+		if *uid == "" {
+			return errors.New("uid is required for SM9")
+		}
+		plaintext, err = sm9.DecryptASN1(k, []byte(*uid), ciphertext)
+	default:
+		return fmt.Errorf("%s is not a SM2 or SM9 encrypt user private key", *keyFile)
+	}
+	if err != nil {
+		return err
+	}
+	return e.writeOutput(*out, plaintext, 0600)
+}
+
+// runSM4 encrypts or decrypts data. With -key, data is encrypted with SM4-GCM and
+// the output is nonce || ciphertext || tag; with -pass, the output is the same as
+// "openssl enc -sm4-cbc".
+func runSM4(e *env, args []string) error {
+	fs := newFlagSet(e, "enc")
+	hexKey := fs.String("key", "", "hex encoded 128 bits SM4 key")
+	pass := fs.String("pass", "", "pass phrase source, e.g. pass:secret")
+	usePBKDF2 := fs.Bool("pbkdf2", false, "use PBKDF2 to derive the key from the pass phrase")
+	iter := fs.Int("iter", 0, "PBKDF2 iteration count, implies -pbkdf2, defaults to 10000")
+	mdName := fs.String("md", "sha256", "digest to derive the key from the pass phrase: sha256 or sm3")
+	decrypt := fs.Bool("d", false, "decrypt")
+	in := fs.String("in", "", "input file")
+	out := fs.String("out", "", "output file")
+	if err := fs.Parse(args); err != nil || (*hexKey == "") == (*pass == "") {
+		fs.Usage()
+		return errUsage
+	}
+	if *pass != "" {
+		password, err := parsePassword(*pass)
+		if err != nil {
+			return err
+		}
+		md, err := opensslDigest(*mdName)
+		if err != nil {
+			return err
+		}
+		if *usePBKDF2 && *iter == 0 {
+			*iter = 10000
+		}
+		data, err := e.readInput(*in)
+		if err != nil {
+			return err
+		}
+		if *decrypt {
+			plaintext, err := opensslDecrypt(md, password, *iter, data)
+			if err != nil {
+				return err
+			}
+			return e.writeOutput(*out, plaintext, 0600)
+		}
+		ciphertext, err := opensslEncrypt(md, password, *iter, data)
+		if err != nil {
+			return err
+		}
+		return e.writeOutput(*out, ciphertext, 0644)
+	}
+	key, err := hex.DecodeString(*hexKey)
+	if err != nil {
+		return fmt.Errorf("invalid key: %w", err)
+	}
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	data, err := e.readInput(*in)
+	if err != nil {
+		return err
+	}
+	nonceSize := aead.NonceSize()
+	if *decrypt {
+		if len(data) < nonceSize+aead.Overhead() {
+			return errors.New("ciphertext too short")
+		}
+		plaintext, err := aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+		if err != nil {
+			return err
+		}
+		return e.writeOutput(*out, plaintext, 0600)
+	}
+	nonce := make([]byte, nonceSize, nonceSize+len(data)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	return e.writeOutput(*out, aead.Seal(nonce, nonce, data, nil), 0644)
+}
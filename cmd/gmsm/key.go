@@ -0,0 +1,269 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/emmansun/gmsm/pkcs"
+	"github.com/emmansun/gmsm/pkcs8"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm9"
+	"github.com/emmansun/gmsm/smx509"
+)
+
+const (
+	pemPrivateKey             = "PRIVATE KEY"
+	pemEncryptedPrivateKey    = "ENCRYPTED PRIVATE KEY"
+	pemECPrivateKey           = "EC PRIVATE KEY"
+	pemSM2PrivateKey          = "SM2 PRIVATE KEY"
+	pemECParameters           = "EC PARAMETERS"
+	pemSM2Parameters          = "SM2 PARAMETERS"
+	pemPublicKey              = "PUBLIC KEY"
+	pemCertificate            = "CERTIFICATE"
+	pemSignMasterPublicKey    = "SM9 SIGN MASTER PUBLIC KEY"
+	pemEncryptMasterPublicKey = "SM9 ENC MASTER PUBLIC KEY"
+)
+
+func runGenKey(e *env, args []string) error {
+	fs := newFlagSet(e, "genkey")
+	typ := fs.String("type", "sm2", "key type: sm2, sm9-sign or sm9-enc")
+	passout := fs.String("passout", "", "output file pass phrase source, e.g. pass:secret")
+	cipherName := fs.String("cipher", "sm4", "cipher of the encrypted private key: sm4 or aes256")
+	out := fs.String("out", "", "output file")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	opts, err := newPrivateKeyOpts(*passout, *cipherName)
+	if err != nil {
+		return err
+	}
+	var key any
+	switch *typ {
+	case "sm2":
+		key, err = sm2.GenerateKey(rand.Reader)
+	case "sm9-sign":
+		key, err = sm9.GenerateSignMasterKey(rand.Reader)
+	case "sm9-enc":
+		key, err = sm9.GenerateEncryptMasterKey(rand.Reader)
+	default:
+		return fmt.Errorf("unknown key type %q", *typ)
+	}
+	if err != nil {
+		return err
+	}
+	return writePrivateKey(e, *out, key, opts)
+}
+
+func runPubKey(e *env, args []string) error {
+	fs := newFlagSet(e, "pubkey")
+	keyFile := fs.String("key", "", "private key file")
+	passin := fs.String("passin", "", "private key pass phrase source")
+	out := fs.String("out", "", "output file")
+	if err := fs.Parse(args); err != nil || *keyFile == "" {
+		fs.Usage()
+		return errUsage
+	}
+	key, err := readPrivateKey(*keyFile, *passin)
+	if err != nil {
+		return err
+	}
+	block := &pem.Block{}
+	switch k := key.(type) {
+	case *sm2.PrivateKey:
+		block.Type = pemPublicKey
+		block.Bytes, err = smx509.MarshalPKIXPublicKey(&k.PublicKey)
+	case *sm9.SignMasterPrivateKey:
+		block.Type = pemSignMasterPublicKey
+		block.Bytes, err = k.Public().MarshalASN1()
+	case *sm9.EncryptMasterPrivateKey:
+		block.Type = pemEncryptMasterPublicKey
+		block.Bytes, err = k.Public().MarshalASN1()
+	default:
+		return fmt.Errorf("unsupported private key type %T", key)
+	}
+	if err != nil {
+		return err
+	}
+	return e.writeOutput(*out, pem.EncodeToMemory(block), 0644)
+}
+
+func runUserKey(e *env, args []string) error {
+	fs := newFlagSet(e, "userkey")
+	masterFile := fs.String("master", "", "SM9 master private key file")
+	uid := fs.String("uid", "", "user identity")
+	hid := fs.Int("hid", 0, "hid, defaults to 1 for sign and 3 for encrypt")
+	passin := fs.String("passin", "", "master private key pass phrase source")
+	passout := fs.String("passout", "", "output file pass phrase source")
+	cipherName := fs.String("cipher", "sm4", "cipher of the encrypted private key: sm4 or aes256")
+	out := fs.String("out", "", "output file")
+	if err := fs.Parse(args); err != nil || *masterFile == "" || *uid == "" {
+		fs.Usage()
+		return errUsage
+	}
+	opts, err := newPrivateKeyOpts(*passout, *cipherName)
+	if err != nil {
+		return err
+	}
+	key, err := readPrivateKey(*masterFile, *passin)
+	if err != nil {
+		return err
+	}
+	var userKey any
+	switch k := key.(type) {
+	case *sm9.SignMasterPrivateKey:
+		userKey, err = k.GenerateUserKey([]byte(*uid), hidOrDefault(*hid, 0x01))
+	case *sm9.EncryptMasterPrivateKey:
+		userKey, err = k.GenerateUserKey([]byte(*uid), hidOrDefault(*hid, 0x03))
+	default:
+		return fmt.Errorf("%s is not a SM9 master private key", *masterFile)
+	}
+	if err != nil {
+		return err
+	}
+	return writePrivateKey(e, *out, userKey, opts)
+}
+
+func hidOrDefault(hid int, def byte) byte {
+	if hid == 0 {
+		return def
+	}
+	return byte(hid)
+}
+
+// privateKeyOpts are the options to write a private key, the private key is
+// encrypted if password is not empty.
+type privateKeyOpts struct {
+	password []byte
+	opts     *pkcs8.Opts
+}
+
+// newPrivateKeyOpts returns the options of PBES2 with PBKDF2 and HMAC-SHA256 as
+// "openssl pkcs8 -topk8 -v2 sm4-cbc" does. OpenSSL 3.x doesn't know hmacWithSM3.
+func newPrivateKeyOpts(passout, cipherName string) (*privateKeyOpts, error) {
+	password, err := parsePassword(passout)
+	if err != nil {
+		return nil, err
+	}
+	opts := &pkcs8.Opts{
+		KDFOpts: pkcs8.PBKDF2Opts{SaltSize: 8, IterationCount: 2048, HMACHash: pkcs8.SHA256},
+	}
+	switch cipherName {
+	case "sm4":
+		opts.Cipher = pkcs.SM4CBC
+	case "aes256":
+		opts.Cipher = pkcs.AES256CBC
+	default:
+		return nil, fmt.Errorf("unsupported cipher %q", cipherName)
+	}
+	return &privateKeyOpts{password, opts}, nil
+}
+
+func writePrivateKey(e *env, name string, key any, opts *privateKeyOpts) error {
+	der, err := pkcs8.MarshalPrivateKey(key, opts.password, opts.opts)
+	if err != nil {
+		return err
+	}
+	typ := pemPrivateKey
+	if len(opts.password) > 0 {
+		typ = pemEncryptedPrivateKey
+	}
+	return e.writeOutput(name, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0600)
+}
+
+// readPEM returns the first PEM block of the file name, the parameters blocks
+// which OpenSSL "ecparam -genkey" emits before the key are skipped.
+func readPEM(name string) (*pem.Block, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%s: no PEM data found", name)
+		}
+		if block.Type != pemECParameters && block.Type != pemSM2Parameters {
+			return block, nil
+		}
+	}
+}
+
+// readPrivateKey reads a private key, it returns a *sm2.PrivateKey,
+// a *sm9.SignMasterPrivateKey, a *sm9.SignPrivateKey, a *sm9.EncryptMasterPrivateKey
+// or a *sm9.EncryptPrivateKey. Besides PKCS #8 and encrypted PKCS #8, the SEC 1
+// SM2 private keys written by "openssl ec" and Tongsuo are accepted too, with
+// the legacy Proc-Type and DEK-Info encryption, e.g. "openssl ec -sm4".
+func readPrivateKey(name, passin string) (any, error) {
+	block, err := readPEM(name)
+	if err != nil {
+		return nil, err
+	}
+	switch block.Type {
+	case pemPrivateKey:
+		return smx509.ParsePKCS8PrivateKey(block.Bytes)
+	case pemEncryptedPrivateKey:
+		password, err := parsePassword(passin)
+		if err != nil {
+			return nil, err
+		}
+		if len(password) == 0 {
+			return nil, fmt.Errorf("%s: pass phrase is required", name)
+		}
+		return pkcs8.ParsePKCS8PrivateKey(block.Bytes, password)
+	case pemECPrivateKey, pemSM2PrivateKey:
+		der := block.Bytes
+		if smx509.IsEncryptedPEMBlock(block) {
+			password, err := parsePassword(passin)
+			if err != nil {
+				return nil, err
+			}
+			if len(password) == 0 {
+				return nil, fmt.Errorf("%s: pass phrase is required", name)
+			}
+			if der, err = smx509.DecryptPEMBlock(block, password); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		return smx509.ParseSM2PrivateKey(der)
+	}
+	return nil, fmt.Errorf("%s: unexpected PEM type %q", name, block.Type)
+}
+
+// readPublicKey reads a public key, it returns a *ecdsa.PublicKey of SM2 curve,
+// a *sm9.SignMasterPublicKey or a *sm9.EncryptMasterPublicKey.
+func readPublicKey(name string) (any, error) {
+	block, err := readPEM(name)
+	if err != nil {
+		return nil, err
+	}
+	switch block.Type {
+	case pemPublicKey:
+		return smx509.ParsePKIXPublicKey(block.Bytes)
+	case pemCertificate:
+		cert, err := smx509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	case pemSignMasterPublicKey:
+		pub := new(sm9.SignMasterPublicKey)
+		return pub, pub.UnmarshalASN1(block.Bytes)
+	case pemEncryptMasterPublicKey:
+		pub := new(sm9.EncryptMasterPublicKey)
+		return pub, pub.UnmarshalASN1(block.Bytes)
+	}
+	return nil, fmt.Errorf("%s: unexpected PEM type %q", name, block.Type)
+}
+
+func sm2PublicKey(pub any) (*ecdsa.PublicKey, error) {
+	k, ok := pub.(*ecdsa.PublicKey)
+	if !ok || !sm2.IsSM2PublicKey(k) {
+		return nil, errors.New("not a SM2 public key")
+	}
+	return k, nil
+}
@@ -0,0 +1,141 @@
+// Command gmsm is a command line tool of the ShangMi algorithms: SM2/SM9 key
+// generation, sign/verify, encrypt/decrypt, SM3 digest, SM4 data encryption,
+// certificate requests and certificate issuance.
+//
+// Usage:
+//
+//	gmsm <command> [flags]
+//
+// The commands are:
+//
+//	genkey    generate a SM2 private key or a SM9 master private key
+//	pubkey    output the public key or the master public key of a private key
+//	userkey   generate a SM9 user private key from a SM9 master private key
+//	dgst      calculate the SM3 digest or HMAC-SM3 of data
+//	sign      sign data with a SM2 or SM9 user private key
+//	verify    verify a SM2 or SM9 signature
+//	encrypt   encrypt data with a SM2 public key or a SM9 master public key
+//	decrypt   decrypt data with a SM2 or SM9 user private key
+//	enc       encrypt or decrypt data with SM4-GCM
+//	age       encrypt or decrypt files to SM2 recipients or a pass phrase, in the smage format
+//	req       create a certificate signing request
+//	x509      issue a certificate from a certificate signing request
+//	text      print a certificate, certificate signing request or CRL in text form
+//
+// Private keys are PEM encoded PKCS #8 "PRIVATE KEY" or "ENCRYPTED PRIVATE KEY"
+// blocks, SM2 public keys are PEM encoded "PUBLIC KEY" or "CERTIFICATE" blocks,
+// SM9 master public keys are "SM9 SIGN MASTER PUBLIC KEY" or "SM9 ENC MASTER PUBLIC KEY"
+// blocks. Data is read from stdin and written to stdout unless -in or -out is given.
+//
+// The formats are compatible with the SM commands of OpenSSL 3.x and Tongsuo:
+// keys, SM2 signatures and SM2 ciphertexts
+// (ASN.1, C1C3C2) are interchangeable, SEC 1 "EC PRIVATE KEY" / "SM2 PRIVATE KEY"
+// keys are accepted, "gmsm enc -pass" is "openssl enc -sm4-cbc" and the output of
+// "gmsm dgst" is the same as "openssl dgst -sm3". Pass phrase arguments take the
+// OpenSSL "pass:", "env:" and "file:" forms. Note that "openssl dgst -sm3 -sign"
+// uses an empty SM2 user identity unless "-sigopt distid:1234567812345678" is
+// given, use "-emptyid" to sign or verify such signatures.
+//
+// PKCS #12 is not handled here, please refer to github.com/emmansun/go-pkcs12.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+type command struct {
+	usage string
+	run   func(env *env, args []string) error
+}
+
+var commands = map[string]command{
+	"genkey":  {"genkey -type sm2|sm9-sign|sm9-enc [-passout arg] [-out file]", runGenKey},
+	"pubkey":  {"pubkey -key file [-passin arg] [-out file]", runPubKey},
+	"userkey": {"userkey -master file -uid id [-hid n] [-passin arg] [-passout arg] [-out file]", runUserKey},
+	"dgst":    {"dgst [-hmac key] [-in file]", runDigest},
+	"sign":    {"sign -key file [-passin arg] [-uid id|-emptyid] [-in file] [-out file]", runSign},
+	"verify":  {"verify -pub file -sig file [-uid id|-emptyid] [-hid n] [-in file]", runVerify},
+	"encrypt": {"encrypt -pub file [-uid id] [-hid n] [-in file] [-out file]", runEncrypt},
+	"decrypt": {"decrypt -key file [-passin arg] [-uid id] [-in file] [-out file]", runDecrypt},
+	"enc":     {"enc -key hexkey|-pass arg [-pbkdf2] [-iter n] [-md sha256|sm3] [-d] [-in file] [-out file]", runSM4},
+	"age":     {"age -r file [-r file...]|-pass arg [-iter n] [-in file] [-out file], age -d -i file [-passin arg]|-pass arg [-in file] [-out file]", runAge},
+	"req":     {"req -key file [-passin arg] -subj CN=name[,O=org...] [-dns name,...] [-out file]", runRequest},
+	"text":    {"text [-in file]", runText},
+	"x509":    {"x509 -req file -key file [-passin arg] [-ca file] [-days n] [-isca] [-out file]", runCertificate},
+}
+
+// env is the execution environment of a command, it is replaced in tests.
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+var errUsage = errors.New("usage")
+
+func main() {
+	e := &env{os.Stdin, os.Stdout, os.Stderr}
+	if err := run(e, os.Args[1:]); err != nil {
+		if err != errUsage {
+			fmt.Fprintln(os.Stderr, "gmsm:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(e *env, args []string) error {
+	if len(args) == 0 {
+		usage(e)
+		return errUsage
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage(e)
+		return errUsage
+	}
+	return cmd.run(e, args[1:])
+}
+
+func usage(e *env) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(e.stderr, "usage: gmsm <command> [flags]")
+	for _, name := range names {
+		fmt.Fprintln(e.stderr, "  gmsm", commands[name].usage)
+	}
+}
+
+func newFlagSet(e *env, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: gmsm %s [flags]\n", name)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// readInput reads the whole content of the file name, or stdin if name is empty.
+func (e *env) readInput(name string) ([]byte, error) {
+	if name == "" {
+		return io.ReadAll(e.stdin)
+	}
+	return os.ReadFile(name)
+}
+
+// writeOutput writes data to the file name, or stdout if name is empty.
+func (e *env) writeOutput(name string, data []byte, perm os.FileMode) error {
+	if name == "" {
+		_, err := e.stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(name, data, perm)
+}
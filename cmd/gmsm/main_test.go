@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runCmd(t *testing.T, stdin []byte, args ...string) ([]byte, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	err := run(&env{bytes.NewReader(stdin), &stdout, &stderr}, args)
+	return stdout.Bytes(), err
+}
+
+func mustRun(t *testing.T, stdin []byte, args ...string) []byte {
+	t.Helper()
+	out, err := runCmd(t, stdin, args...)
+	if err != nil {
+		t.Fatalf("gmsm %s: %v", strings.Join(args, " "), err)
+	}
+	return out
+}
+
+func TestSM2(t *testing.T) {
+	dir := t.TempDir()
+	key, pub := filepath.Join(dir, "key.pem"), filepath.Join(dir, "pub.pem")
+	msg := []byte("hello world")
+
+	mustRun(t, nil, "genkey", "-type", "sm2", "-out", key)
+	mustRun(t, nil, "pubkey", "-key", key, "-out", pub)
+
+	sig := filepath.Join(dir, "sig")
+	mustRun(t, msg, "sign", "-key", key, "-out", sig)
+	mustRun(t, msg, "verify", "-pub", pub, "-sig", sig)
+	if _, err := runCmd(t, []byte("tampered"), "verify", "-pub", pub, "-sig", sig); err != errVerification {
+		t.Errorf("expected verification failure, got %v", err)
+	}
+
+	ciphertext := mustRun(t, msg, "encrypt", "-pub", pub)
+	if plaintext := mustRun(t, ciphertext, "decrypt", "-key", key); !bytes.Equal(plaintext, msg) {
+		t.Errorf("got %q, want %q", plaintext, msg)
+	}
+
+	csr, cert := filepath.Join(dir, "req.pem"), filepath.Join(dir, "cert.pem")
+	mustRun(t, nil, "req", "-key", key, "-subj", "CN=test,O=GMSM,C=CN", "-dns", "example.com", "-out", csr)
+	mustRun(t, nil, "x509", "-req", csr, "-key", key, "-isca", "-out", cert)
+	mustRun(t, msg, "verify", "-pub", cert, "-sig", sig)
+	if text := mustRun(t, nil, "text", "-in", cert); !bytes.Contains(text, []byte("Signature Algorithm: SM2-with-SM3")) {
+		t.Errorf("unexpected certificate text:\n%s", text)
+	}
+
+	leafKey, leafCSR, leafCert := filepath.Join(dir, "leaf.pem"), filepath.Join(dir, "leafreq.pem"), filepath.Join(dir, "leafcert.pem")
+	mustRun(t, nil, "genkey", "-out", leafKey)
+	mustRun(t, nil, "req", "-key", leafKey, "-subj", "CN=leaf", "-out", leafCSR)
+	mustRun(t, nil, "x509", "-req", leafCSR, "-key", key, "-ca", cert, "-out", leafCert)
+}
+
+func TestSM9(t *testing.T) {
+	dir := t.TempDir()
+	msg := []byte("hello world")
+
+	master, pub, user := filepath.Join(dir, "master.pem"), filepath.Join(dir, "pub.pem"), filepath.Join(dir, "user.pem")
+	mustRun(t, nil, "genkey", "-type", "sm9-sign", "-out", master)
+	mustRun(t, nil, "pubkey", "-key", master, "-out", pub)
+	mustRun(t, nil, "userkey", "-master", master, "-uid", "alice", "-out", user)
+	sig := filepath.Join(dir, "sig")
+	mustRun(t, msg, "sign", "-key", user, "-out", sig)
+	mustRun(t, msg, "verify", "-pub", pub, "-uid", "alice", "-sig", sig)
+	if _, err := runCmd(t, msg, "verify", "-pub", pub, "-uid", "bob", "-sig", sig); err != errVerification {
+		t.Errorf("expected verification failure, got %v", err)
+	}
+
+	mustRun(t, nil, "genkey", "-type", "sm9-enc", "-out", master)
+	mustRun(t, nil, "pubkey", "-key", master, "-out", pub)
+	mustRun(t, nil, "userkey", "-master", master, "-uid", "alice", "-out", user)
+	ciphertext := mustRun(t, msg, "encrypt", "-pub", pub, "-uid", "alice")
+	if plaintext := mustRun(t, ciphertext, "decrypt", "-key", user, "-uid", "alice"); !bytes.Equal(plaintext, msg) {
+		t.Errorf("got %q, want %q", plaintext, msg)
+	}
+}
+
+func TestDigestAndSM4(t *testing.T) {
+	out := mustRun(t, []byte("abc"), "dgst")
+	if want := "SM3(stdin)= 66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0\n"; string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+	key := "0123456789abcdeffedcba9876543210"
+	msg := []byte("hello world")
+	ciphertext := mustRun(t, msg, "enc", "-key", key)
+	if plaintext := mustRun(t, ciphertext, "enc", "-d", "-key", key); !bytes.Equal(plaintext, msg) {
+		t.Errorf("got %q, want %q", plaintext, msg)
+	}
+	ciphertext[len(ciphertext)-1] ^= 1
+	if _, err := runCmd(t, ciphertext, "enc", "-d", "-key", key); err == nil {
+		t.Errorf("expected authentication failure")
+	}
+}
+
+func TestAge(t *testing.T) {
+	dir := t.TempDir()
+	key, pub := filepath.Join(dir, "key.pem"), filepath.Join(dir, "pub.pem")
+	mustRun(t, nil, "genkey", "-type", "sm2", "-out", key)
+	mustRun(t, nil, "pubkey", "-key", key, "-out", pub)
+
+	msg := bytes.Repeat([]byte("hello world"), 10000)
+	ciphertext := mustRun(t, msg, "age", "-r", pub)
+	if plaintext := mustRun(t, ciphertext, "age", "-d", "-i", key); !bytes.Equal(plaintext, msg) {
+		t.Errorf("plaintext mismatch")
+	}
+	ciphertext = mustRun(t, msg, "age", "-pass", "pass:secret", "-iter", "1000")
+	if plaintext := mustRun(t, ciphertext, "age", "-d", "-pass", "pass:secret"); !bytes.Equal(plaintext, msg) {
+		t.Errorf("plaintext mismatch")
+	}
+	if _, err := runCmd(t, ciphertext, "age", "-d", "-pass", "pass:wrong"); err == nil {
+		t.Errorf("expected decryption failure")
+	}
+	if _, err := runCmd(t, msg, "age", "-r", pub, "-pass", "pass:secret"); err != errUsage {
+		t.Errorf("expected usage error, got %v", err)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	if _, err := runCmd(t, nil); err != errUsage {
+		t.Errorf("expected usage error, got %v", err)
+	}
+	if _, err := runCmd(t, nil, "unknown"); err != errUsage {
+		t.Errorf("expected usage error, got %v", err)
+	}
+	if _, err := runCmd(t, nil, "sign"); err != errUsage {
+		t.Errorf("expected usage error, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+
+	"github.com/emmansun/gmsm/padding"
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm4"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// The layouts in this file follow OpenSSL 3.x and Tongsuo, so that artifacts can be
+// exchanged with their SM commands.
+
+// opensslSaltMagic is the header of the output of "openssl enc" with salt.
+const opensslSaltMagic = "Salted__"
+
+// parsePassword parses a pass phrase argument in OpenSSL form: "pass:password",
+// "env:var" or "file:pathname" (the first line of the file).
+func parsePassword(arg string) ([]byte, error) {
+	if arg == "" {
+		return nil, nil
+	}
+	typ, value, ok := strings.Cut(arg, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid pass phrase argument %q", arg)
+	}
+	switch typ {
+	case "pass":
+		return []byte(value), nil
+	case "env":
+		v, ok := os.LookupEnv(value)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %q is not set", value)
+		}
+		return []byte(v), nil
+	case "file":
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return nil, err
+		}
+		line, _, _ := bytes.Cut(data, []byte("\n"))
+		return bytes.TrimSuffix(line, []byte("\r")), nil
+	}
+	return nil, fmt.Errorf("unsupported pass phrase source %q", typ)
+}
+
+func opensslDigest(name string) (func() hash.Hash, error) {
+	switch strings.ToLower(name) {
+	case "sha256":
+		return sha256.New, nil
+	case "sm3":
+		return sm3.New, nil
+	}
+	return nil, fmt.Errorf("unsupported message digest %q", name)
+}
+
+// opensslKeyIV derives the SM4 key and IV as "openssl enc" does, with PBKDF2 if
+// iter is positive, else with the legacy EVP_BytesToKey.
+func opensslKeyIV(md func() hash.Hash, password, salt []byte, iter int) (key, iv []byte) {
+	const size = sm4.BlockSize * 2
+	var keyIV []byte
+	if iter > 0 {
+		keyIV = pbkdf2.Key(password, salt, iter, size, md)
+	} else {
+		keyIV = evpBytesToKey(md, password, salt, size)
+	}
+	return keyIV[:sm4.BlockSize], keyIV[sm4.BlockSize:]
+}
+
+// evpBytesToKey implements EVP_BytesToKey with iteration count 1.
+func evpBytesToKey(md func() hash.Hash, password, salt []byte, size int) []byte {
+	h := md()
+	var out, prev []byte
+	for len(out) < size {
+		h.Reset()
+		h.Write(prev)
+		h.Write(password)
+		h.Write(salt)
+		prev = h.Sum(nil)
+		out = append(out, prev...)
+	}
+	return out[:size]
+}
+
+// opensslEncrypt encrypts plaintext as "openssl enc -sm4-cbc -salt" does, the
+// output is "Salted__" || salt || SM4-CBC-PKCS7(plaintext).
+func opensslEncrypt(md func() hash.Hash, password []byte, iter int, plaintext []byte) ([]byte, error) {
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, iv := opensslKeyIV(md, password, salt, iter)
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	padded := padding.NewPKCS7Padding(sm4.BlockSize).Pad(plaintext)
+	out := make([]byte, len(opensslSaltMagic)+len(salt)+len(padded))
+	n := copy(out, opensslSaltMagic)
+	n += copy(out[n:], salt)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out[n:], padded)
+	return out, nil
+}
+
+// opensslDecrypt decrypts the output of "openssl enc -sm4-cbc -salt".
+func opensslDecrypt(md func() hash.Hash, password []byte, iter int, data []byte) ([]byte, error) {
+	const headerSize = len(opensslSaltMagic) + 8
+	if len(data) < headerSize+sm4.BlockSize || !bytes.HasPrefix(data, []byte(opensslSaltMagic)) {
+		return nil, errors.New("bad magic number")
+	}
+	ciphertext := data[headerSize:]
+	if len(ciphertext)%sm4.BlockSize != 0 {
+		return nil, errors.New("ciphertext is not a multiple of the block size")
+	}
+	key, iv := opensslKeyIV(md, password, data[len(opensslSaltMagic):headerSize], iter)
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+	plaintext, err = padding.NewPKCS7Padding(sm4.BlockSize).Unpad(plaintext)
+	if err != nil {
+		return nil, errors.New("bad decrypt")
+	}
+	return plaintext, nil
+}
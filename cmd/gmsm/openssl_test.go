@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// The files in testdata were generated by OpenSSL 3.0:
+//
+//	openssl genpkey -algorithm SM2 -out openssl_sm2.pem
+//	openssl pkey -in openssl_sm2.pem -pubout -out openssl_sm2_pub.pem
+//	openssl ec -in openssl_sm2.pem -out openssl_sm2_sec1.pem
+//	openssl ec -in openssl_sm2.pem -sm4 -passout pass:secret -out openssl_sm2_sec1_enc.pem
+//	openssl ecparam -name SM2 -genkey -out openssl_sm2_ecparam.pem
+//	openssl pkcs8 -topk8 -in openssl_sm2.pem -v2 sm4-cbc -passout pass:secret -out openssl_sm2_enc.pem
+//	openssl dgst -sm3 -sign openssl_sm2.pem -out openssl_sm2.sig message.txt
+//	openssl pkeyutl -encrypt -pubin -inkey openssl_sm2_pub.pem -in message.txt -out openssl_sm2.enc
+//	openssl enc -sm4-cbc -pbkdf2 -pass pass:secret -in message.txt -out openssl_sm4_pbkdf2.bin
+//	openssl enc -sm4-cbc -md sm3 -pbkdf2 -iter 2000 -pass pass:secret -in message.txt -out openssl_sm4_pbkdf2_sm3.bin
+//	openssl enc -sm4-cbc -pass pass:secret -in message.txt -out openssl_sm4_legacy.bin
+//	openssl dgst -sm3 message.txt > message.sm3
+//	openssl dgst -sm3 -hmac secret message.txt > message.hmac-sm3
+
+func golden(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestOpenSSLGoldenKeys(t *testing.T) {
+	want := mustRun(t, nil, "pubkey", "-key", "testdata/openssl_sm2.pem")
+	if !bytes.Equal(want, golden(t, "openssl_sm2_pub.pem")) {
+		t.Errorf("public key mismatch:\n%s", want)
+	}
+	for _, args := range [][]string{
+		{"-key", "testdata/openssl_sm2_sec1.pem"},
+		{"-key", "testdata/openssl_sm2_enc.pem", "-passin", "pass:secret"},
+		{"-key", "testdata/openssl_sm2_sec1_enc.pem", "-passin", "pass:secret"},
+	} {
+		got := mustRun(t, nil, append([]string{"pubkey"}, args...)...)
+		if !bytes.Equal(got, want) {
+			t.Errorf("%v: public key mismatch:\n%s", args, got)
+		}
+	}
+	mustRun(t, nil, "pubkey", "-key", "testdata/openssl_sm2_ecparam.pem")
+	if _, err := runCmd(t, nil, "pubkey", "-key", "testdata/openssl_sm2_enc.pem", "-passin", "pass:wrong"); err == nil {
+		t.Errorf("expected error with wrong pass phrase")
+	}
+	if _, err := runCmd(t, nil, "pubkey", "-key", "testdata/openssl_sm2_sec1_enc.pem"); err == nil {
+		t.Errorf("expected error without pass phrase")
+	}
+}
+
+func TestOpenSSLGoldenData(t *testing.T) {
+	msg := golden(t, "message.txt")
+	mustRun(t, nil, "verify", "-emptyid", "-pub", "testdata/openssl_sm2_pub.pem", "-sig", "testdata/openssl_sm2.sig", "-in", "testdata/message.txt")
+	if _, err := runCmd(t, nil, "verify", "-pub", "testdata/openssl_sm2_pub.pem", "-sig", "testdata/openssl_sm2.sig", "-in", "testdata/message.txt"); err != errVerification {
+		t.Errorf("expected verification failure with the default user identity, got %v", err)
+	}
+
+	plaintext := mustRun(t, nil, "decrypt", "-key", "testdata/openssl_sm2.pem", "-in", "testdata/openssl_sm2.enc")
+	if !bytes.Equal(plaintext, msg) {
+		t.Errorf("SM2 decrypt: got %q", plaintext)
+	}
+
+	for _, tt := range []struct {
+		file string
+		args []string
+	}{
+		{"openssl_sm4_pbkdf2.bin", []string{"-pbkdf2"}},
+		{"openssl_sm4_pbkdf2_sm3.bin", []string{"-md", "sm3", "-iter", "2000"}},
+		{"openssl_sm4_legacy.bin", nil},
+	} {
+		args := append([]string{"enc", "-d", "-pass", "pass:secret", "-in", "testdata/" + tt.file}, tt.args...)
+		plaintext := mustRun(t, nil, args...)
+		if !bytes.Equal(plaintext, msg) {
+			t.Errorf("%s: got %q", tt.file, plaintext)
+		}
+	}
+
+	wantDigest, wantHMAC := golden(t, "message.sm3"), golden(t, "message.hmac-sm3")
+	// OpenSSL prints the file name as given
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("testdata"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if got := mustRun(t, nil, "dgst", "-in", "message.txt"); !bytes.Equal(got, wantDigest) {
+		t.Errorf("dgst: got %s", got)
+	}
+	if got := mustRun(t, nil, "dgst", "-hmac", "secret", "-in", "message.txt"); !bytes.Equal(got, wantHMAC) {
+		t.Errorf("dgst -hmac: got %s", got)
+	}
+}
+
+// TestOpenSSLInterop checks that OpenSSL consumes the artifacts of this tool.
+func TestOpenSSLInterop(t *testing.T) {
+	openssl, err := exec.LookPath("openssl")
+	if err != nil {
+		t.Skip("openssl not found")
+	}
+	if err := exec.Command(openssl, "list", "-digest-algorithms").Run(); err != nil {
+		t.Skip("openssl 3.x is required")
+	}
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	openSSL := func(args ...string) []byte {
+		t.Helper()
+		out, err := exec.Command(openssl, args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("openssl %v: %v\n%s", args, err, out)
+		}
+		return out
+	}
+	msg := []byte("hello openssl")
+	if err := os.WriteFile(path("msg"), msg, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mustRun(t, nil, "genkey", "-passout", "pass:secret", "-out", path("key.pem"))
+	openSSL("pkey", "-in", path("key.pem"), "-passin", "pass:secret", "-pubout", "-out", path("pub.pem"))
+
+	mustRun(t, nil, "sign", "-key", path("key.pem"), "-passin", "pass:secret", "-in", path("msg"), "-out", path("sig"))
+	openSSL("dgst", "-sm3", "-sigopt", "distid:1234567812345678", "-verify", path("pub.pem"), "-signature", path("sig"), path("msg"))
+	mustRun(t, nil, "sign", "-emptyid", "-key", path("key.pem"), "-passin", "pass:secret", "-in", path("msg"), "-out", path("sig"))
+	openSSL("dgst", "-sm3", "-verify", path("pub.pem"), "-signature", path("sig"), path("msg"))
+
+	mustRun(t, nil, "encrypt", "-pub", path("pub.pem"), "-in", path("msg"), "-out", path("ct"))
+	if got := openSSL("pkeyutl", "-decrypt", "-inkey", path("key.pem"), "-passin", "pass:secret", "-in", path("ct")); !bytes.Equal(got, msg) {
+		t.Errorf("openssl pkeyutl -decrypt: got %q", got)
+	}
+
+	mustRun(t, nil, "enc", "-pass", "pass:secret", "-pbkdf2", "-in", path("msg"), "-out", path("enc"))
+	if got := openSSL("enc", "-d", "-sm4-cbc", "-pbkdf2", "-pass", "pass:secret", "-in", path("enc")); !bytes.Equal(got, msg) {
+		t.Errorf("openssl enc -d: got %q", got)
+	}
+
+	mustRun(t, nil, "req", "-key", path("key.pem"), "-passin", "pass:secret", "-subj", "CN=interop", "-out", path("req.pem"))
+	openSSL("req", "-in", path("req.pem"), "-verify", "-noout")
+	mustRun(t, nil, "x509", "-req", path("req.pem"), "-key", path("key.pem"), "-passin", "pass:secret", "-isca", "-out", path("cert.pem"))
+	openSSL("verify", "-CAfile", path("cert.pem"), path("cert.pem"))
+}
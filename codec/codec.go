@@ -0,0 +1,211 @@
+// Package codec converts SM2 signatures, ciphertexts and public keys between
+// their wire encodings. Every conversion is explicit, the caller names both
+// the input and the output encoding, and the input is fully validated:
+// non-canonical DER, trailing data, out of range integers and points not on
+// the curve are rejected instead of being passed on to the peer.
+//
+//   - signatures: raw r||s (64 bytes) and ASN.1 SEQUENCE { r, s }
+//   - ciphertexts: plain sm2.C1C3C2 or sm2.C1C2C3 and ASN.1 (GB/T 35276)
+//   - public keys: raw x||y (64 bytes), SEC 1 points and PKIX DER
+package codec
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm2/sm2ec"
+	"github.com/emmansun/gmsm/smx509"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+const (
+	byteSize       = 32
+	digestSize     = 32
+	rawSize        = 2 * byteSize
+	uncompressed   = 0x04
+	pointSize      = 1 + rawSize
+	compressedSize = 1 + byteSize
+)
+
+var (
+	errInvalidSignature  = errors.New("codec: invalid SM2 signature")
+	errInvalidCiphertext = errors.New("codec: invalid SM2 ciphertext")
+	errInvalidPublicKey  = errors.New("codec: invalid SM2 public key")
+	errUnknownOrder      = errors.New("codec: unknown ciphertext order")
+)
+
+func curve() elliptic.Curve {
+	return sm2.P256()
+}
+
+// inScalarRange reports whether 0 < k < N.
+func inScalarRange(k *big.Int) bool {
+	return k.Sign() > 0 && k.Cmp(curve().Params().N) < 0
+}
+
+// SignatureRSToASN1 converts the raw r||s encoding of a SM2 signature, two
+// 32 bytes big endian integers, to the ASN.1 encoding.
+func SignatureRSToASN1(rs []byte) ([]byte, error) {
+	if len(rs) != rawSize {
+		return nil, errInvalidSignature
+	}
+	r := new(big.Int).SetBytes(rs[:byteSize])
+	s := new(big.Int).SetBytes(rs[byteSize:])
+	if !inScalarRange(r) || !inScalarRange(s) {
+		return nil, errInvalidSignature
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(r)
+		b.AddASN1BigInt(s)
+	})
+	return b.Bytes()
+}
+
+// SignatureASN1ToRS converts the ASN.1 encoding of a SM2 signature to the raw
+// r||s encoding.
+func SignatureASN1ToRS(sig []byte) ([]byte, error) {
+	var (
+		inner cryptobyte.String
+		r, s  = new(big.Int), new(big.Int)
+	)
+	input := cryptobyte.String(sig)
+	if !input.ReadASN1(&inner, asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1Integer(r) || !inner.ReadASN1Integer(s) || !inner.Empty() {
+		return nil, errInvalidSignature
+	}
+	if !inScalarRange(r) || !inScalarRange(s) {
+		return nil, errInvalidSignature
+	}
+	out := make([]byte, rawSize)
+	r.FillBytes(out[:byteSize])
+	s.FillBytes(out[byteSize:])
+	return out, nil
+}
+
+// CiphertextPlainToASN1 converts a plain SM2 ciphertext in the given order
+// to the ASN.1 encoding, like sm2.PlainCiphertext2ASN1 but C1 must be an
+// uncompressed or a compressed point, the hybrid form is rejected.
+func CiphertextPlainToASN1(ciphertext []byte, order sm2.CiphertextSplicingOrder) ([]byte, error) {
+	if order != sm2.C1C3C2 && order != sm2.C1C2C3 {
+		return nil, errUnknownOrder
+	}
+	if len(ciphertext) == 0 {
+		return nil, errInvalidCiphertext
+	}
+	c1Len := pointSize
+	if ciphertext[0] != uncompressed {
+		c1Len = compressedSize
+	}
+	// C2 can't be empty, SM2 doesn't encrypt empty messages.
+	if len(ciphertext) <= c1Len+digestSize {
+		return nil, errInvalidCiphertext
+	}
+	if _, _, err := unmarshalPoint(ciphertext[:c1Len]); err != nil {
+		return nil, errInvalidCiphertext
+	}
+	return sm2.PlainCiphertext2ASN1(ciphertext, order)
+}
+
+// CiphertextASN1ToPlain converts the ASN.1 encoding of a SM2 ciphertext to
+// the plain encoding in the given order, C1 is an uncompressed point. It is
+// sm2.ASN1Ciphertext2Plain, which also rejects C1 not on the curve.
+func CiphertextASN1ToPlain(ciphertext []byte, order sm2.CiphertextSplicingOrder) ([]byte, error) {
+	if order != sm2.C1C3C2 && order != sm2.C1C2C3 {
+		return nil, errUnknownOrder
+	}
+	out, err := sm2.ASN1Ciphertext2Plain(ciphertext, sm2.NewPlainEncrypterOpts(sm2.MarshalUncompressed, order))
+	if err != nil {
+		return nil, errInvalidCiphertext
+	}
+	return out, nil
+}
+
+// unmarshalPoint decodes an uncompressed or compressed SEC 1 point of the
+// SM2 curve, the point at infinity and the hybrid form are rejected.
+func unmarshalPoint(point []byte) (x, y *big.Int, err error) {
+	switch {
+	case len(point) == pointSize && point[0] == uncompressed:
+		x, y = sm2ec.Unmarshal(curve(), point)
+	case len(point) == compressedSize && (point[0] == 2 || point[0] == 3):
+		x, y = sm2ec.UnmarshalCompressed(curve(), point)
+	}
+	if x == nil {
+		return nil, nil, errInvalidPublicKey
+	}
+	return x, y, nil
+}
+
+// PublicKeyPointToRaw converts an uncompressed or compressed SEC 1 point to
+// the raw x||y encoding.
+func PublicKeyPointToRaw(point []byte) ([]byte, error) {
+	x, y, err := unmarshalPoint(point)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, rawSize)
+	x.FillBytes(out[:byteSize])
+	y.FillBytes(out[byteSize:])
+	return out, nil
+}
+
+// PublicKeyRawToPoint converts the raw x||y encoding of a public key to an
+// uncompressed SEC 1 point.
+func PublicKeyRawToPoint(raw []byte) ([]byte, error) {
+	if len(raw) != rawSize {
+		return nil, errInvalidPublicKey
+	}
+	point := append([]byte{uncompressed}, raw...)
+	if _, _, err := unmarshalPoint(point); err != nil {
+		return nil, err
+	}
+	return point, nil
+}
+
+// PublicKeyPointToDER converts an uncompressed or compressed SEC 1 point to
+// the PKIX, ASN.1 DER SubjectPublicKeyInfo, encoding.
+func PublicKeyPointToDER(point []byte) ([]byte, error) {
+	x, y, err := unmarshalPoint(point)
+	if err != nil {
+		return nil, err
+	}
+	return smx509.MarshalPKIXPublicKey(&ecdsa.PublicKey{Curve: curve(), X: x, Y: y})
+}
+
+// PublicKeyDERToPoint converts the PKIX encoding of a SM2 public key to an
+// uncompressed SEC 1 point. Keys of other curves or algorithms are rejected.
+func PublicKeyDERToPoint(der []byte) ([]byte, error) {
+	key, err := smx509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := key.(*ecdsa.PublicKey)
+	if !ok || !sm2.IsSM2PublicKey(pub) {
+		return nil, errors.New("codec: not a SM2 public key")
+	}
+	return elliptic.Marshal(curve(), pub.X, pub.Y), nil
+}
+
+// PublicKeyRawToDER converts the raw x||y encoding of a public key to the
+// PKIX encoding.
+func PublicKeyRawToDER(raw []byte) ([]byte, error) {
+	point, err := PublicKeyRawToPoint(raw)
+	if err != nil {
+		return nil, err
+	}
+	return PublicKeyPointToDER(point)
+}
+
+// PublicKeyDERToRaw converts the PKIX encoding of a SM2 public key to the
+// raw x||y encoding.
+func PublicKeyDERToRaw(der []byte) ([]byte, error) {
+	point, err := PublicKeyDERToPoint(der)
+	if err != nil {
+		return nil, err
+	}
+	return point[1:], nil
+}
@@ -0,0 +1,233 @@
+package codec
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/smx509"
+)
+
+func TestSignature(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := make([]byte, 32)
+	sig, err := sm2.SignASN1(rand.Reader, priv, hash, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := SignatureASN1ToRS(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 64 {
+		t.Fatalf("got %d bytes", len(rs))
+	}
+	der, err := SignatureRSToASN1(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(der, sig) {
+		t.Errorf("got %x, want %x", der, sig)
+	}
+}
+
+func TestSignatureInvalid(t *testing.T) {
+	n := sm2.P256().Params().N
+	for _, rs := range [][]byte{
+		nil,
+		make([]byte, 63),
+		make([]byte, 64),
+		append(n.FillBytes(make([]byte, 32)), bytes.Repeat([]byte{1}, 32)...),
+		append(bytes.Repeat([]byte{1}, 32), make([]byte, 32)...),
+	} {
+		if _, err := SignatureRSToASN1(rs); err == nil {
+			t.Errorf("%x: expected error", rs)
+		}
+	}
+	for _, sig := range []string{
+		"",
+		"3006020101020101ff",        // trailing data
+		"30070201010202000101",      // non-minimal integer
+		"3006020101020100",          // s is zero
+		"30060201010201ff",          // s is negative
+		"3009020101020101020101",    // extra element
+		"3180060201010201010000",    // not a SEQUENCE
+		"3080020101020101" + "0000", // indefinite length
+	} {
+		b, _ := hex.DecodeString(sig)
+		if _, err := SignatureASN1ToRS(b); err == nil {
+			t.Errorf("%s: expected error", sig)
+		}
+	}
+}
+
+func TestCiphertext(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("hello codec")
+	for _, tt := range []struct {
+		order sm2.CiphertextSplicingOrder
+		opts  *sm2.EncrypterOpts
+	}{
+		{sm2.C1C3C2, sm2.NewPlainEncrypterOpts(sm2.MarshalUncompressed, sm2.C1C3C2)},
+		{sm2.C1C2C3, sm2.NewPlainEncrypterOpts(sm2.MarshalUncompressed, sm2.C1C2C3)},
+		{sm2.C1C3C2, sm2.NewPlainEncrypterOpts(sm2.MarshalCompressed, sm2.C1C3C2)},
+	} {
+		plain, err := sm2.Encrypt(rand.Reader, &priv.PublicKey, msg, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := CiphertextPlainToASN1(plain, tt.order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := priv.Decrypt(nil, der, sm2.ASN1DecrypterOpts)
+		if err != nil || !bytes.Equal(got, msg) {
+			t.Fatalf("got %q, %v", got, err)
+		}
+		back, err := CiphertextASN1ToPlain(der, tt.order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plain[0] == 0x04 && !bytes.Equal(back, plain) {
+			t.Errorf("got %x, want %x", back, plain)
+		}
+		if back[0] != 0x04 || len(back) != 65+32+len(msg) {
+			t.Errorf("unexpected plain ciphertext %x", back)
+		}
+	}
+}
+
+func TestCiphertextInvalid(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := sm2.Encrypt(rand.Reader, &priv.PublicKey, []byte("x"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := CiphertextPlainToASN1(plain, sm2.C1C3C2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notOnCurve := append([]byte(nil), plain...)
+	notOnCurve[64] ^= 1
+	for _, ct := range [][]byte{
+		nil,
+		plain[:65+32], // empty C2
+		notOnCurve,
+		append([]byte{0x06}, plain[1:]...), // hybrid point
+		der,
+	} {
+		if _, err := CiphertextPlainToASN1(ct, sm2.C1C3C2); err == nil {
+			t.Errorf("%x: expected error", ct)
+		}
+	}
+	if _, err := CiphertextPlainToASN1(plain, sm2.CiphertextSplicingOrder(2)); err == nil {
+		t.Error("expected error with an unknown order")
+	}
+
+	intBytes := func(b *big.Int) []byte { return b.Bytes() }
+	build := func(x, y []byte, c3, c2 []byte) []byte {
+		x, y = asn1Int(x), asn1Int(y)
+		var out []byte
+		out = append(out, 0x02, byte(len(x)))
+		out = append(out, x...)
+		out = append(out, 0x02, byte(len(y)))
+		out = append(out, y...)
+		out = append(out, 0x04, byte(len(c3)))
+		out = append(out, c3...)
+		out = append(out, 0x04, byte(len(c2)))
+		out = append(out, c2...)
+		return append([]byte{0x30, byte(len(out))}, out...)
+	}
+	x, y := intBytes(new(big.Int).SetBytes(plain[1:33])), intBytes(new(big.Int).SetBytes(plain[33:65]))
+	c3, c2 := plain[65:97], plain[97:]
+	if _, err := CiphertextASN1ToPlain(build(x, y, c3, c2), sm2.C1C3C2); err != nil {
+		t.Fatalf("valid ciphertext rejected: %v", err)
+	}
+	for name, ct := range map[string][]byte{
+		"trailing data":  append(append([]byte(nil), der...), 0),
+		"short C3":       build(x, y, c3[:31], c2),
+		"empty C2":       build(x, y, c3, nil),
+		"not on curve":   build(x, intBytes(new(big.Int).SetBytes(notOnCurve[33:65])), c3, c2),
+		"x out of range": build(intBytes(new(big.Int).Add(new(big.Int).SetBytes(x), sm2.P256().Params().P)), y, c3, c2),
+		"plain":          plain,
+	} {
+		if _, err := CiphertextASN1ToPlain(ct, sm2.C1C3C2); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// asn1Int returns the content of the ASN.1 INTEGER of the positive big endian b.
+func asn1Int(b []byte) []byte {
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		return append([]byte{0}, b...)
+	}
+	return b
+}
+
+func TestPublicKey(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &priv.PublicKey
+	point := elliptic.Marshal(sm2.P256(), pub.X, pub.Y)
+	want, err := smx509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := PublicKeyPointToRaw(elliptic.MarshalCompressed(sm2.P256(), pub.X, pub.Y))
+	if err != nil || !bytes.Equal(raw, point[1:]) {
+		t.Fatalf("got %x, %v", raw, err)
+	}
+	der, err := PublicKeyRawToDER(raw)
+	if err != nil || !bytes.Equal(der, want) {
+		t.Fatalf("got %x, %v", der, err)
+	}
+	if raw, err = PublicKeyDERToRaw(der); err != nil || !bytes.Equal(raw, point[1:]) {
+		t.Fatalf("got %x, %v", raw, err)
+	}
+	if got, err := PublicKeyRawToPoint(raw); err != nil || !bytes.Equal(got, point) {
+		t.Fatalf("got %x, %v", got, err)
+	}
+
+	bad := append([]byte(nil), raw...)
+	bad[63] ^= 1
+	if _, err := PublicKeyRawToDER(bad); err == nil {
+		t.Error("expected error with a point not on the curve")
+	}
+	if _, err := PublicKeyRawToPoint(point); err == nil {
+		t.Error("expected error with a SEC 1 point as raw key")
+	}
+	if _, err := PublicKeyPointToDER(make([]byte, 65)); err == nil {
+		t.Error("expected error with the zero point")
+	}
+	if _, err := PublicKeyDERToRaw(append(der, 0)); err == nil {
+		t.Error("expected error with trailing data")
+	}
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if der, err = smx509.MarshalPKIXPublicKey(&p256.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PublicKeyDERToRaw(der); err == nil {
+		t.Error("expected error with a P-256 key")
+	}
+}
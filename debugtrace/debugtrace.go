@@ -0,0 +1,63 @@
+// Package debugtrace surfaces the intermediate values of the SM2 and SM9
+// operations, so that implementers can diff them against the examples of the
+// standards and against other implementations, e.g. of hardware vendors, when
+// they don't interoperate.
+//
+// The values are named like the examples of GB/T 32918 (SM2) and GM/T 0044
+// (SM9), per primitive and operation:
+//
+//   - sm2 sign: e, k, x1, r, s
+//   - sm2 verify: e, t, x1', R
+//   - sm2 encrypt: k, C1, x2, y2, t, C2, C3
+//   - sm2 decrypt: x2, y2, t, M', u
+//   - sm9 sign: r, w, h, l, S
+//   - sm9 verify: t, w', h2
+//   - sm9 wrapkey: QB, r, C1, w, K
+//   - sm9 encrypt: C2, C3, after the values of wrapkey
+//   - sm9 unwrapkey: w', K'
+//   - sm9 decrypt: u, after the values of unwrapkey
+//
+// The values of an attempt are traced again when it's retried, e.g. when r
+// of an SM9 signature is zero.
+//
+// The intermediate values include the secrets: the random numbers, the shared
+// points and the derived keys. The tracing is only compiled in with the
+// gmsm_trace build tag, without it SetTracer has no effect and the tracing
+// code is removed by the compiler. Never build production binaries with it.
+package debugtrace
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/emmansun/gmsm/internal/instrument"
+)
+
+// Enabled reports whether the tracing is compiled in, with the gmsm_trace
+// build tag.
+const Enabled = instrument.TraceEnabled
+
+// Tracer receives the intermediate value name of an operation of primitive.
+// value must not be retained or modified. Tracers are called concurrently.
+type Tracer func(primitive, operation, name string, value []byte)
+
+// SetTracer installs the tracer of all operations, nil disables the tracing.
+// It has no effect unless Enabled.
+func SetTracer(t Tracer) {
+	instrument.SetTracer(instrument.Tracer(t))
+}
+
+// NewWriter returns a Tracer which writes a line per value to w, the
+// primitive, the operation, the name and the value in hex:
+//
+//	sm9 sign h = 823c4b21e4bd2dfe1ed92c606653e996668563152fc33f55d7bfbb9bd9705adb
+func NewWriter(w io.Writer) Tracer {
+	var mu sync.Mutex
+	return func(primitive, operation, name string, value []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, "%s %s %s = %s\n", primitive, operation, name, hex.EncodeToString(value))
+	}
+}
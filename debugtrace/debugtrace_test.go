@@ -0,0 +1,194 @@
+package debugtrace
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm9"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func record(t *testing.T) *recorder {
+	r := &recorder{values: make(map[string][]byte)}
+	SetTracer(func(primitive, operation, name string, value []byte) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.values[primitive+" "+operation+" "+name] = append([]byte{}, value...)
+	})
+	t.Cleanup(func() { SetTracer(nil) })
+	return r
+}
+
+func (r *recorder) get(t *testing.T, key string) []byte {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.values[key]
+	if !ok {
+		t.Fatalf("%s is not traced", key)
+	}
+	return v
+}
+
+func TestDisabled(t *testing.T) {
+	if Enabled {
+		t.Skip("built with gmsm_trace")
+	}
+	r := record(t)
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := priv.Sign(rand.Reader, []byte("message"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.values) != 0 {
+		t.Errorf("traced %d values without gmsm_trace", len(r.values))
+	}
+}
+
+func TestSM2(t *testing.T) {
+	if !Enabled {
+		t.Skip("requires gmsm_trace")
+	}
+	r := record(t)
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := priv.Sign(rand.Reader, []byte("message"), sm2.DefaultSM2SignerOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sm2.VerifyASN1WithSM2(&priv.PublicKey, nil, []byte("message"), sig) {
+		t.Fatal("verification failed")
+	}
+	if !bytes.Equal(r.get(t, "sm2 sign e"), r.get(t, "sm2 verify e")) {
+		t.Error("e of sign and verify differ")
+	}
+	if !bytes.Equal(r.get(t, "sm2 sign x1"), r.get(t, "sm2 verify x1'")) {
+		t.Error("x1 of sign and x1' of verify differ")
+	}
+	if !bytes.Equal(r.get(t, "sm2 sign r"), r.get(t, "sm2 verify R")) {
+		t.Error("r of sign and R of verify differ")
+	}
+
+	ciphertext, err := sm2.Encrypt(rand.Reader, &priv.PublicKey, []byte("plaintext"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(ciphertext, r.get(t, "sm2 encrypt C1")) {
+		t.Error("C1 is not the prefix of the ciphertext")
+	}
+	if _, err := sm2.Decrypt(priv, ciphertext); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"x2", "y2", "t"} {
+		if !bytes.Equal(r.get(t, "sm2 encrypt "+name), r.get(t, "sm2 decrypt "+name)) {
+			t.Errorf("%s of encrypt and decrypt differ", name)
+		}
+	}
+	if !bytes.Equal(r.get(t, "sm2 encrypt C3"), r.get(t, "sm2 decrypt u")) {
+		t.Error("C3 of encrypt and u of decrypt differ")
+	}
+}
+
+// fixedReader returns the bytes of a hex string, e.g. the random numbers of
+// the examples of GM/T 0044.
+func fixedReader(t *testing.T, s string) *bytes.Reader {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(b)
+}
+
+// GM/T 0044.5 Appendix D, the encryption with the XOR cipher
+func TestSM9EncryptSample(t *testing.T) {
+	if !Enabled {
+		t.Skip("requires gmsm_trace")
+	}
+	r := record(t)
+	master, err := sm9.GenerateEncryptMasterKey(fixedReader(t, "0001EDEE3778F441F8DEA3D9FA0ACC4E07EE36C93F9A08618AF4AD85CEDE1C22"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid, hid := []byte("Bob"), byte(0x03)
+	plaintext := []byte("Chinese IBE standard")
+	random := fixedReader(t, "0000AAC0541779C8FC45E3E2CB25C12B5D2576B2129AE8BB5EE2CBE5EC9E785C")
+	ciphertext, err := sm9.Encrypt(random, master.Public(), uid, hid, plaintext, sm9.DefaultEncrypterOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		"sm9 wrapkey QB": "709d165808b0a43e2574e203fa885abcbab16a240c4c1916552e7c43d09763b8693269a6be2456f43333758274786b6051ff87b7f198da4ba1a2c6e336f51fcc",
+		"sm9 wrapkey C1": "2445471164490618e1ee20528ff1d545b0f14c8bcaa44544f03dab5dac07d8ff42ffca97d57cddc05ea405f2e586feb3a6930715532b8000759f13059ed59ac0",
+		"sm9 wrapkey K":  "58373260f067ec48667c21c144f8bc33cd3049788651ffd5f738003e51df31174d0e4e402fd87f4581b612f74259db574f67ece6",
+		"sm9 encrypt C3": "ba672387bcd6de5016a158a52bb2e7fc429197bcab70b25afee37a2b9db9f367",
+	} {
+		if got := hex.EncodeToString(r.get(t, key)); got != want {
+			t.Errorf("%s = %s, want %s", key, got, want)
+		}
+	}
+
+	priv, err := master.GenerateUserKey(uid, hid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sm9.Decrypt(priv, uid, ciphertext, sm9.DefaultEncrypterOpts); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r.get(t, "sm9 wrapkey w"), r.get(t, "sm9 unwrapkey w'")) {
+		t.Error("w of wrapkey and w' of unwrapkey differ")
+	}
+	if !bytes.Equal(r.get(t, "sm9 encrypt C3"), r.get(t, "sm9 decrypt u")) {
+		t.Error("C3 of encrypt and u of decrypt differ")
+	}
+}
+
+func TestSM9Sign(t *testing.T) {
+	if !Enabled {
+		t.Skip("requires gmsm_trace")
+	}
+	r := record(t)
+	master, err := sm9.GenerateSignMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid, hid := []byte("Alice"), byte(0x01)
+	priv, err := master.GenerateUserKey(uid, hid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := []byte("Chinese IBS standard")
+	sig, err := sm9.SignASN1(rand.Reader, priv, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sm9.VerifyASN1(master.Public(), uid, hid, hash, sig) {
+		t.Fatal("verification failed")
+	}
+	if !bytes.Equal(r.get(t, "sm9 sign w"), r.get(t, "sm9 verify w'")) {
+		t.Error("w of sign and w' of verify differ")
+	}
+	if !bytes.Equal(r.get(t, "sm9 sign h"), r.get(t, "sm9 verify h2")) {
+		t.Error("h of sign and h2 of verify differ")
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	var buf strings.Builder
+	NewWriter(&buf)("sm9", "sign", "h", []byte{0x82, 0x3c})
+	if got, want := buf.String(), "sm9 sign h = 823c\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -3,12 +3,13 @@ package drbg
 
 import (
 	"crypto/cipher"
-	"crypto/rand"
 	"errors"
 	"hash"
 	"io"
+	"os"
 	"time"
 
+	"github.com/emmansun/gmsm/internal/randutil"
 	"github.com/emmansun/gmsm/sm3"
 	"github.com/emmansun/gmsm/sm4"
 )
@@ -36,9 +37,11 @@ const (
 
 // DrbgPrng sample pseudo random number generator base on DRBG
 type DrbgPrng struct {
-	entropySource    io.Reader
-	securityStrength int
-	impl             DRBG
+	entropySource        io.Reader
+	securityStrength     int
+	impl                 DRBG
+	pid                  int
+	predictionResistance bool
 }
 
 // NewCtrDrbgPrng create pseudo random number generator base on CTR DRBG
@@ -47,7 +50,7 @@ func NewCtrDrbgPrng(cipherProvider func(key []byte) (cipher.Block, error), keyLe
 	if entropySource != nil {
 		prng.entropySource = entropySource
 	} else {
-		prng.entropySource = rand.Reader
+		prng.entropySource = randutil.Reader()
 	}
 
 	prng.securityStrength = selectSecurityStrength(securityStrength)
@@ -73,6 +76,7 @@ func NewCtrDrbgPrng(cipherProvider func(key []byte) (cipher.Block, error), keyLe
 	if err != nil {
 		return nil, err
 	}
+	prng.pid = os.Getpid()
 
 	return prng, nil
 }
@@ -93,7 +97,7 @@ func NewHashDrbgPrng(newHash func() hash.Hash, entropySource io.Reader, security
 	if entropySource != nil {
 		prng.entropySource = entropySource
 	} else {
-		prng.entropySource = rand.Reader
+		prng.entropySource = randutil.Reader()
 	}
 	prng.securityStrength = selectSecurityStrength(securityStrength)
 	if gm && securityStrength < 32 {
@@ -118,6 +122,7 @@ func NewHashDrbgPrng(newHash func() hash.Hash, entropySource io.Reader, security
 	if err != nil {
 		return nil, err
 	}
+	prng.pid = os.Getpid()
 
 	return prng, nil
 }
@@ -143,24 +148,55 @@ func (prng *DrbgPrng) getEntropy(entropyInput []byte) error {
 	return nil
 }
 
+// SetPredictionResistance enables or disables prediction resistance.
+// When enabled, the DRBG is reseeded with fresh entropy before every generate request.
+func (prng *DrbgPrng) SetPredictionResistance(enabled bool) {
+	prng.predictionResistance = enabled
+}
+
+// Reseed reseeds the DRBG with fresh entropy input and optional additional input,
+// it can be used to explicitly request prediction resistance.
+func (prng *DrbgPrng) Reseed(additional []byte) error {
+	entropyInput := make([]byte, prng.securityStrength)
+	err := prng.getEntropy(entropyInput)
+	if err != nil {
+		return err
+	}
+	err = prng.impl.Reseed(entropyInput, additional)
+	if err != nil {
+		return err
+	}
+	prng.pid = os.Getpid()
+	return nil
+}
+
 func (prng *DrbgPrng) Read(data []byte) (int, error) {
 	maxBytesPerRequest := prng.impl.MaxBytesPerRequest()
 	total := 0
 
+	// A forked child process shares the parent's internal state,
+	// reseed to avoid both processes producing the same output.
+	if prng.pid != os.Getpid() {
+		if err := prng.Reseed(nil); err != nil {
+			return 0, err
+		}
+	}
+
 	for len(data) > 0 {
 		b := data
 		if len(data) > maxBytesPerRequest {
 			b = data[:maxBytesPerRequest]
 		}
 
-		err := prng.impl.Generate(b, nil)
-		if err == ErrReseedRequired {
-			entropyInput := make([]byte, prng.securityStrength)
-			err := prng.getEntropy(entropyInput)
-			if err != nil {
+		if prng.predictionResistance {
+			if err := prng.Reseed(nil); err != nil {
 				return 0, err
 			}
-			err = prng.impl.Reseed(entropyInput, nil)
+		}
+
+		err := prng.impl.Generate(b, nil)
+		if err == ErrReseedRequired {
+			err = prng.Reseed(nil)
 			if err != nil {
 				return 0, err
 			}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/sha256"
+	"os"
 	"testing"
 )
 
@@ -105,3 +106,46 @@ func TestGMSecurityStrengthValidation(t *testing.T) {
 		t.Fatalf("expected error here")
 	}
 }
+
+func TestDrbgPrngPredictionResistance(t *testing.T) {
+	prng, err := NewGmHashDrbgPrng(nil, 32, SECURITY_LEVEL_ONE, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prng.SetPredictionResistance(true)
+	data := make([]byte, 64)
+	for i := 0; i < 4; i++ {
+		n, err := prng.Read(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 64 {
+			t.Errorf("not got enough random bytes")
+		}
+		if hd := prng.impl.(*HashDrbg); hd.reseedCounter != 2 {
+			t.Errorf("expected reseed before each generate, reseed counter=%v", hd.reseedCounter)
+		}
+	}
+}
+
+func TestDrbgPrngForkSafety(t *testing.T) {
+	prng, err := NewGmCtrDrbgPrng(nil, 32, SECURITY_LEVEL_ONE, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 16)
+	if _, err = prng.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	// simulate a fork by changing recorded pid
+	prng.pid = -1
+	if _, err = prng.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	if prng.pid != os.Getpid() {
+		t.Errorf("expected pid to be updated after reseed")
+	}
+	if cd := prng.impl.(*CtrDrbg); cd.reseedCounter != 2 {
+		t.Errorf("expected reseed after pid change, reseed counter=%v", cd.reseedCounter)
+	}
+}
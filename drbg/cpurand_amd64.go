@@ -0,0 +1,22 @@
+//go:build amd64 && !purego
+
+package drbg
+
+import "golang.org/x/sys/cpu"
+
+func rdseed64() (v uint64, ok bool)
+
+func rdrand64() (v uint64, ok bool)
+
+// cpuRandom returns the RDSEED instruction, which returns conditioned
+// entropy, or RDRAND, whose output is a DRBG of the CPU, if RDSEED isn't
+// supported.
+func cpuRandom() (func() (uint64, bool), string) {
+	switch {
+	case cpu.X86.HasRDSEED:
+		return rdseed64, "rdseed"
+	case cpu.X86.HasRDRAND:
+		return rdrand64, "rdrand"
+	}
+	return nil, ""
+}
@@ -0,0 +1,19 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// func rdseed64() (v uint64, ok bool)
+TEXT ·rdseed64(SB),NOSPLIT,$0
+	RDSEEDQ AX
+	SETCS   BX
+	MOVQ    AX, v+0(FP)
+	MOVB    BX, ok+8(FP)
+	RET
+
+// func rdrand64() (v uint64, ok bool)
+TEXT ·rdrand64(SB),NOSPLIT,$0
+	RDRANDQ AX
+	SETCS   BX
+	MOVQ    AX, v+0(FP)
+	MOVB    BX, ok+8(FP)
+	RET
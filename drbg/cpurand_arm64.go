@@ -0,0 +1,40 @@
+//go:build arm64 && linux && !purego
+
+package drbg
+
+import (
+	"encoding/binary"
+	"os"
+)
+
+const (
+	_AT_HWCAP2   = 26
+	_HWCAP2_RNG  = 1 << 16
+	auxvPairSize = 16
+)
+
+// supportRNDR reports whether the kernel reports the FEAT_RNG extension.
+var supportRNDR = hasHWCAP2(_HWCAP2_RNG)
+
+func hasHWCAP2(bit uint64) bool {
+	auxv, err := os.ReadFile("/proc/self/auxv")
+	if err != nil {
+		return false
+	}
+	for ; len(auxv) >= auxvPairSize; auxv = auxv[auxvPairSize:] {
+		if binary.LittleEndian.Uint64(auxv) == _AT_HWCAP2 {
+			return binary.LittleEndian.Uint64(auxv[8:])&bit != 0
+		}
+	}
+	return false
+}
+
+func rndr64() (v uint64, ok bool)
+
+// cpuRandom returns the RNDR instruction of the FEAT_RNG extension.
+func cpuRandom() (func() (uint64, bool), string) {
+	if supportRNDR {
+		return rndr64, "rndr"
+	}
+	return nil, ""
+}
@@ -0,0 +1,13 @@
+//go:build arm64 && linux && !purego
+
+#include "textflag.h"
+
+// func rndr64() (v uint64, ok bool)
+TEXT ·rndr64(SB),NOSPLIT,$0
+	// MRS RNDR, R0
+	WORD $0xd53b2400
+	// Z is set if no random number is available
+	CSET NE, R1
+	MOVD R0, v+0(FP)
+	MOVB R1, ok+8(FP)
+	RET
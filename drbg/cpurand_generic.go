@@ -0,0 +1,7 @@
+//go:build !(amd64 || (arm64 && linux)) || purego
+
+package drbg
+
+func cpuRandom() (func() (uint64, bool), string) {
+	return nil, ""
+}
@@ -0,0 +1,188 @@
+package drbg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/emmansun/gmsm/internal/randutil"
+)
+
+// APT_WINDOW_SIZE is the adaptive proportion test window size for non-binary samples, see SP 800-90B 4.4.2.
+const APT_WINDOW_SIZE = 512
+
+// STARTUP_SAMPLES is the number of samples which must pass the continuous health tests
+// before the entropy source output can be used, see SP 800-90B 4.3.
+const STARTUP_SAMPLES = 1024
+
+// healthTestAlpha is the false positive probability of the health tests, 2^-20.
+const healthTestAlpha = 1.0 / (1 << 20)
+
+// ErrEntropySourceFailure is returned (wrapped) by HealthTestedSource once a health test failed.
+var ErrEntropySourceFailure = errors.New("drbg: entropy source health test failure")
+
+// HealthTestError describes a health test failure of an entropy source.
+type HealthTestError struct {
+	Test   string // "repetition count" or "adaptive proportion"
+	Sample byte   // the offending sample value
+	Count  int    // observed occurrences which reached the cutoff
+	Cutoff int
+}
+
+func (e *HealthTestError) Error() string {
+	return fmt.Sprintf("drbg: %s test failed, sample 0x%02x occurred %d times (cutoff %d)", e.Test, e.Sample, e.Count, e.Cutoff)
+}
+
+func (e *HealthTestError) Unwrap() error {
+	return ErrEntropySourceFailure
+}
+
+// HealthTestedSource wraps an entropy source with the continuous repetition count test
+// and adaptive proportion test defined in GM/T 0062 and SP 800-90B 4.4. Each output byte is
+// treated as one sample.
+//
+// Once a test failed, every Read returns a *HealthTestError until Restart succeeds.
+// HealthTestedSource is safe for concurrent use.
+type HealthTestedSource struct {
+	mu         sync.Mutex
+	source     io.Reader
+	rctCutoff  int
+	aptCutoff  int
+	lastSample byte
+	rctCount   int
+	aptBase    byte
+	aptCount   int
+	aptIndex   int
+	err        error
+}
+
+// NewHealthTestedSource creates a health tested entropy source, minEntropy is the assessed
+// min-entropy per byte in bits, it must be in (0, 8]. A nil source means the default
+// reader of the entropy package.
+// The startup tests are run before it returns.
+func NewHealthTestedSource(source io.Reader, minEntropy float64) (*HealthTestedSource, error) {
+	if !(minEntropy > 0 && minEntropy <= 8) {
+		return nil, errors.New("drbg: invalid min-entropy per sample")
+	}
+	if source == nil {
+		source = randutil.Reader()
+	}
+	s := &HealthTestedSource{
+		source:    source,
+		rctCutoff: rctCutoff(minEntropy),
+		aptCutoff: aptCutoff(minEntropy),
+	}
+	if err := s.Restart(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Cutoffs returns the repetition count test and adaptive proportion test cutoff values.
+func (s *HealthTestedSource) Cutoffs() (rct, apt int) {
+	return s.rctCutoff, s.aptCutoff
+}
+
+// Err returns the latched health test failure, or nil.
+func (s *HealthTestedSource) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Restart clears the health test state and failure condition, then runs the startup tests
+// over STARTUP_SAMPLES fresh samples, which are discarded.
+func (s *HealthTestedSource) Restart() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = nil
+	s.rctCount = 0
+	s.aptIndex = 0
+	s.aptCount = 0
+
+	var samples [STARTUP_SAMPLES]byte
+	if _, err := io.ReadFull(s.source, samples[:]); err != nil {
+		return err
+	}
+	s.test(samples[:])
+	return s.err
+}
+
+// Read reads entropy from the underlying source and applies the continuous health tests.
+func (s *HealthTestedSource) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+	n, err := io.ReadFull(s.source, p)
+	s.test(p[:n])
+	if s.err != nil {
+		// never hand out samples which failed health testing
+		for i := range p[:n] {
+			p[i] = 0
+		}
+		return 0, s.err
+	}
+	return n, err
+}
+
+func (s *HealthTestedSource) test(samples []byte) {
+	for _, b := range samples {
+		// repetition count test, SP 800-90B 4.4.1
+		if s.rctCount > 0 && b == s.lastSample {
+			s.rctCount++
+			if s.rctCount >= s.rctCutoff {
+				s.err = &HealthTestError{"repetition count", b, s.rctCount, s.rctCutoff}
+				return
+			}
+		} else {
+			s.lastSample = b
+			s.rctCount = 1
+		}
+
+		// adaptive proportion test, SP 800-90B 4.4.2
+		if s.aptIndex == 0 {
+			s.aptBase = b
+			s.aptCount = 1
+		} else if b == s.aptBase {
+			s.aptCount++
+			if s.aptCount >= s.aptCutoff {
+				s.err = &HealthTestError{"adaptive proportion", b, s.aptCount, s.aptCutoff}
+				return
+			}
+		}
+		s.aptIndex++
+		if s.aptIndex == APT_WINDOW_SIZE {
+			s.aptIndex = 0
+		}
+	}
+}
+
+// rctCutoff returns C = 1 + ceil(-log2(alpha) / H).
+func rctCutoff(h float64) int {
+	return 1 + int(math.Ceil(20/h))
+}
+
+// aptCutoff returns C = 1 + CRITBINOM(W, 2^-H, 1-alpha), the smallest c with
+// P(X <= c-1) >= 1 - alpha for X ~ B(W, 2^-H), plus one.
+func aptCutoff(h float64) int {
+	p := math.Pow(2, -h)
+	if p >= 1 {
+		return APT_WINDOW_SIZE
+	}
+	lp, lq := math.Log(p), math.Log1p(-p)
+	cdf := 0.0
+	for k := 0; k < APT_WINDOW_SIZE; k++ {
+		lc, _ := math.Lgamma(float64(APT_WINDOW_SIZE + 1))
+		lk, _ := math.Lgamma(float64(k + 1))
+		lnk, _ := math.Lgamma(float64(APT_WINDOW_SIZE - k + 1))
+		cdf += math.Exp(lc - lk - lnk + float64(k)*lp + float64(APT_WINDOW_SIZE-k)*lq)
+		if cdf >= 1-healthTestAlpha {
+			return k + 1
+		}
+	}
+	return APT_WINDOW_SIZE
+}
@@ -0,0 +1,175 @@
+package drbg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/sm3"
+)
+
+// HWRNG_DEVICE is the character device of the Linux hw_random framework.
+const HWRNG_DEVICE = "/dev/hwrng"
+
+// CPU_RANDOM_RETRIES is the number of attempts of a CPU random number instruction before
+// the CPU entropy source fails, RDSEED in particular may transiently be exhausted.
+const CPU_RANDOM_RETRIES = 100
+
+// ErrNoHardwareEntropy is returned when the requested hardware entropy source isn't
+// available on this platform.
+var ErrNoHardwareEntropy = errors.New("drbg: hardware entropy source not available")
+
+// cpuSource reads the random number instruction of the CPU.
+type cpuSource struct {
+	next func() (uint64, bool)
+	name string
+}
+
+// NewCPUSource returns an entropy source reading RDSEED, or RDRAND if RDSEED isn't supported,
+// on amd64 and RNDR on arm64 Linux. It returns ErrNoHardwareEntropy if the CPU has none of them.
+//
+// The output of these instructions can't be audited, use it with NewMixedSource rather than
+// as the only entropy source of a DRBG.
+func NewCPUSource() (io.Reader, error) {
+	next, name := cpuRandom()
+	if next == nil {
+		return nil, ErrNoHardwareEntropy
+	}
+	return &cpuSource{next: next, name: name}, nil
+}
+
+func (s *cpuSource) Read(p []byte) (int, error) {
+	var buf [8]byte
+	for n := 0; n < len(p); {
+		v, ok := s.next()
+		for i := 1; !ok && i < CPU_RANDOM_RETRIES; i++ {
+			v, ok = s.next()
+		}
+		if !ok {
+			return n, fmt.Errorf("drbg: %s failed %d times", s.name, CPU_RANDOM_RETRIES)
+		}
+		binary.LittleEndian.PutUint64(buf[:], v)
+		n += copy(p[n:], buf[:])
+	}
+	return len(p), nil
+}
+
+func (s *cpuSource) String() string {
+	return s.name
+}
+
+// NewHWRNGSource opens the hardware random number generator device at path, HWRNG_DEVICE
+// if path is empty. The caller closes it once it is no longer used.
+func NewHWRNGSource(path string) (io.ReadCloser, error) {
+	if path == "" {
+		path = HWRNG_DEVICE
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %v", ErrNoHardwareEntropy, err)
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// MixedSource is an entropy source which mixes the output of the system reader and
+// of health tested hardware entropy sources with SM3, so that the DRBG seeded from it is
+// never less secure than when seeded from the operating system alone: a hardware source
+// is never trusted exclusively.
+//
+// A hardware source which fails its health tests or returns an error is excluded from
+// then on, see Err. An error of the operating system source is returned by Read.
+// MixedSource is safe for concurrent use.
+type MixedSource struct {
+	mu       sync.Mutex
+	system   io.Reader
+	hardware []*HealthTestedSource
+	counter  uint64
+	err      error
+}
+
+// NewMixedSource creates an entropy source mixing the system reader, the default reader of
+// the entropy package, crypto/rand.Reader unless set, with the given hardware sources, each
+// wrapped in a HealthTestedSource with the assessed min-entropy per byte, in (0, 8] bits. A
+// hardware source which fails its startup tests is an error.
+func NewMixedSource(minEntropy float64, hardware ...io.Reader) (*MixedSource, error) {
+	m := &MixedSource{system: randutil.Reader()}
+	for _, src := range hardware {
+		tested, err := NewHealthTestedSource(src, minEntropy)
+		if err != nil {
+			return nil, err
+		}
+		m.hardware = append(m.hardware, tested)
+	}
+	return m, nil
+}
+
+// NewHardwareMixedSource is NewMixedSource with the hardware sources of this machine which
+// are available, the CPU random number instruction and HWRNG_DEVICE. It is the system reader
+// conditioned with SM3 if there is none.
+func NewHardwareMixedSource(minEntropy float64) (*MixedSource, error) {
+	var hardware []io.Reader
+	if src, err := NewCPUSource(); err == nil {
+		hardware = append(hardware, src)
+	}
+	if src, err := NewHWRNGSource(""); err == nil {
+		hardware = append(hardware, src)
+	}
+	return NewMixedSource(minEntropy, hardware...)
+}
+
+// Sources returns the number of hardware sources which are still mixed in.
+func (m *MixedSource) Sources() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.hardware)
+}
+
+// Err returns the first failure of a hardware source, or nil.
+func (m *MixedSource) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+// Read fills p, every block of 32 bytes is SM3(counter || 32 bytes of the system reader ||
+// 32 bytes of each hardware source).
+func (m *MixedSource) Read(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var (
+		block [sm3.Size]byte
+		input [sm3.Size]byte
+		ctr   [8]byte
+	)
+	h := sm3.New()
+	for n := 0; n < len(p); {
+		m.counter++
+		binary.BigEndian.PutUint64(ctr[:], m.counter)
+		h.Reset()
+		h.Write(ctr[:])
+		if _, err := io.ReadFull(m.system, input[:]); err != nil {
+			return n, err
+		}
+		h.Write(input[:])
+		for i := 0; i < len(m.hardware); {
+			if _, err := m.hardware[i].Read(input[:]); err != nil {
+				if m.err == nil {
+					m.err = err
+				}
+				m.hardware = append(m.hardware[:i], m.hardware[i+1:]...)
+				continue
+			}
+			h.Write(input[:])
+			i++
+		}
+		n += copy(p[n:], h.Sum(block[:0]))
+	}
+	return len(p), nil
+}
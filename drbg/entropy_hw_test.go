@@ -0,0 +1,133 @@
+package drbg
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/sm3"
+)
+
+func TestCPUSource(t *testing.T) {
+	src, err := NewCPUSource()
+	if err == ErrNoHardwareEntropy {
+		t.Skip("no CPU random number instruction")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := make([]byte, 61), make([]byte, 61)
+	if _, err := io.ReadFull(src, a); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(src, b); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a, b) {
+		t.Errorf("%v returned the same output twice", src)
+	}
+	if _, err := NewHealthTestedSource(src, 6); err != nil {
+		t.Errorf("%v failed the startup tests: %v", src, err)
+	}
+}
+
+func TestHWRNGSourceNotExist(t *testing.T) {
+	if _, err := NewHWRNGSource(t.TempDir() + "/hwrng"); !errors.Is(err, ErrNoHardwareEntropy) {
+		t.Errorf("got %v, want ErrNoHardwareEntropy", err)
+	}
+}
+
+// failingReader returns good entropy until limit bytes are read, then zeros.
+type failingReader struct {
+	limit int
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.limit <= 0 {
+		return constantReader(0).Read(p)
+	}
+	if len(p) > r.limit {
+		p = p[:r.limit]
+	}
+	r.limit -= len(p)
+	return rand.Read(p)
+}
+
+func TestMixedSource(t *testing.T) {
+	bad := &failingReader{limit: STARTUP_SAMPLES + 256}
+	m, err := NewMixedSource(6, bad, &failingReader{limit: 1 << 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Sources() != 2 {
+		t.Fatalf("got %d sources", m.Sources())
+	}
+	prng, err := NewGmHashDrbgPrng(m, 32, SECURITY_LEVEL_TEST, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prng.SetPredictionResistance(true)
+	buf := make([]byte, 64)
+	for i := 0; i < 20; i++ {
+		if _, err := prng.Read(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the failed source is excluded, the output still mixes the others
+	var hte *HealthTestError
+	if !errors.As(m.Err(), &hte) {
+		t.Errorf("got %v, want a health test failure", m.Err())
+	}
+	if m.Sources() != 1 {
+		t.Errorf("got %d sources, want 1", m.Sources())
+	}
+	a, b := make([]byte, 100), make([]byte, 100)
+	if _, err := m.Read(a); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Read(b); err != nil || bytes.Equal(a, b) {
+		t.Errorf("unexpected output %x, %v", b, err)
+	}
+
+	if _, err := NewMixedSource(6, constantReader(1)); err == nil {
+		t.Error("expected startup test failure")
+	}
+}
+
+func TestMixedSourceDefaultReader(t *testing.T) {
+	randutil.SetReader(constantReader(7), true)
+	defer randutil.SetReader(nil, false)
+	m, err := NewMixedSource(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]byte, 32)
+	if _, err := m.Read(out); err != nil {
+		t.Fatal(err)
+	}
+	// SM3(counter 1 || 32 bytes of the default reader)
+	h := sm3.New()
+	h.Write([]byte{0, 0, 0, 0, 0, 0, 0, 1})
+	h.Write(bytes.Repeat([]byte{7}, 32))
+	if want := h.Sum(nil); !bytes.Equal(out, want) {
+		t.Fatalf("the default reader is not mixed in, got %x", out)
+	}
+}
+
+func TestHardwareMixedSource(t *testing.T) {
+	m, err := NewHardwareMixedSource(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%d hardware sources", m.Sources())
+	prng, err := NewGmCtrDrbgPrng(m, 32, SECURITY_LEVEL_TEST, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prng.Read(make([]byte, 32)); err != nil {
+		t.Fatal(err)
+	}
+}
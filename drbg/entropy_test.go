@@ -0,0 +1,130 @@
+package drbg
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+type constantReader byte
+
+func (r constantReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+func TestHealthTestCutoffs(t *testing.T) {
+	// SP 800-90B Table 2, W = 512
+	var tests = []struct {
+		h   float64
+		rct int
+		apt int
+	}{
+		{0.5, 41, 410},
+		{1, 21, 311},
+		{2, 11, 177},
+		{4, 6, 62},
+		{8, 4, 13},
+	}
+	for _, test := range tests {
+		if got := rctCutoff(test.h); got != test.rct {
+			t.Errorf("H=%v: rct cutoff got %v, want %v", test.h, got, test.rct)
+		}
+		if got := aptCutoff(test.h); got != test.apt {
+			t.Errorf("H=%v: apt cutoff got %v, want %v", test.h, got, test.apt)
+		}
+	}
+}
+
+func TestHealthTestedSource(t *testing.T) {
+	src, err := NewHealthTestedSource(nil, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4096)
+	n, err := src.Read(buf)
+	if err != nil || n != len(buf) {
+		t.Fatalf("n=%v, err=%v", n, err)
+	}
+	prng, err := NewGmHashDrbgPrng(src, 32, SECURITY_LEVEL_TEST, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = prng.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHealthTestedSourceStartupFailure(t *testing.T) {
+	_, err := NewHealthTestedSource(constantReader(0), 8)
+	var hte *HealthTestError
+	if !errors.As(err, &hte) || hte.Test != "repetition count" {
+		t.Fatalf("expected repetition count failure, got %v", err)
+	}
+	if !errors.Is(err, ErrEntropySourceFailure) {
+		t.Fatalf("expected ErrEntropySourceFailure")
+	}
+}
+
+type patternReader struct {
+	pattern []byte
+	pos     int
+}
+
+func (r *patternReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.pattern[r.pos%len(r.pattern)]
+		r.pos++
+	}
+	return len(p), nil
+}
+
+func TestHealthTestedSourceAdaptiveProportion(t *testing.T) {
+	// no repetitions, but every other sample is the same value
+	src := &HealthTestedSource{source: &patternReader{pattern: []byte{1, 2, 1, 3}}, rctCutoff: rctCutoff(8), aptCutoff: aptCutoff(8)}
+	err := src.Restart()
+	var hte *HealthTestError
+	if !errors.As(err, &hte) || hte.Test != "adaptive proportion" {
+		t.Fatalf("expected adaptive proportion failure, got %v", err)
+	}
+}
+
+func TestHealthTestedSourceRestart(t *testing.T) {
+	r := &patternReader{pattern: make([]byte, 256)}
+	for i := range r.pattern {
+		r.pattern[i] = byte(i)
+	}
+	src, err := NewHealthTestedSource(r, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the source gets stuck
+	r.pattern = []byte{0x5a}
+	buf := make([]byte, 16)
+	if _, err = src.Read(buf); err == nil {
+		t.Fatal("expected health test failure")
+	}
+	if !bytes.Equal(buf, make([]byte, len(buf))) {
+		t.Fatal("failed samples should not be returned")
+	}
+	if src.Err() == nil {
+		t.Fatal("expected latched failure")
+	}
+	// still failing
+	if _, err = src.Read(buf); err == nil {
+		t.Fatal("expected health test failure")
+	}
+	// the source recovers
+	r.pattern = make([]byte, 256)
+	for i := range r.pattern {
+		r.pattern[i] = byte(255 - i)
+	}
+	if err = src.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = src.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+}
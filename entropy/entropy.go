@@ -0,0 +1,100 @@
+// Package entropy centralizes the default source of randomness of this
+// module, so that products can apply their entropy policy in one place and
+// tests can be reproducible.
+//
+// All the primitives take the source of randomness as an io.Reader, e.g.
+// sm2.GenerateKey, sm9.SignASN1 and the DRBGs of the drbg package. The
+// operations which don't, e.g. the SM4-GCM nonces and the content keys of
+// pkcs7 and smage, the salts of keystore and pkcs8, and the operations which
+// are passed a nil reader, e.g. the DRBGs without an entropy source, read the
+// default source of this package, crypto/rand.Reader unless set, e.g. to the
+// health tested entropy source of a hardware module:
+//
+//	source, err := drbg.NewHealthTestedSource(hsm, 8)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	entropy.SetDefault(source)
+//
+// The deterministic mode of SetDeterministic is for tests only: the outputs,
+// e.g. the signatures of sm2 and sm9, only depend on the seed and the order
+// of the operations.
+package entropy
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/sm3"
+)
+
+// Default returns the default source of randomness.
+func Default() io.Reader {
+	return randutil.Reader()
+}
+
+// SetDefault sets the default source of randomness, which must be safe for
+// concurrent use. nil restores crypto/rand.Reader. It leaves the
+// deterministic mode.
+func SetDefault(r io.Reader) {
+	randutil.SetReader(r, false)
+}
+
+// SetDeterministic sets the default source of randomness to the
+// deterministic reader of seed and enters the deterministic mode, in which
+// the primitives read the same bytes from the sources of randomness for the
+// same inputs, e.g. the signatures of a reader of fixed bytes are
+// reproducible. It must never be used in production.
+func SetDeterministic(seed []byte) {
+	randutil.SetReader(NewDeterministicReader(seed), true)
+}
+
+// Reset restores crypto/rand.Reader and leaves the deterministic mode.
+func Reset() {
+	randutil.SetReader(nil, false)
+}
+
+// IsDeterministic reports whether the deterministic mode is set.
+func IsDeterministic() bool {
+	return randutil.Deterministic()
+}
+
+// NewDeterministicReader returns a reader of the pseudorandom stream
+// SM3(seed || counter) for counter 0, 1, ..., a 64-bit big-endian integer.
+// It's safe for concurrent use. It's not a DRBG and must only be used in
+// tests.
+func NewDeterministicReader(seed []byte) io.Reader {
+	return &deterministicReader{seed: append([]byte{}, seed...)}
+}
+
+type deterministicReader struct {
+	mu      sync.Mutex
+	seed    []byte
+	counter uint64
+	block   [sm3.Size]byte
+	n       int // unread bytes at the end of block
+}
+
+func (r *deterministicReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	total := len(p)
+	for len(p) > 0 {
+		if r.n == 0 {
+			h := sm3.New()
+			h.Write(r.seed)
+			var counter [8]byte
+			binary.BigEndian.PutUint64(counter[:], r.counter)
+			h.Write(counter[:])
+			h.Sum(r.block[:0])
+			r.counter++
+			r.n = sm3.Size
+		}
+		n := copy(p, r.block[sm3.Size-r.n:])
+		r.n -= n
+		p = p[n:]
+	}
+	return total, nil
+}
@@ -0,0 +1,95 @@
+package entropy
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm9"
+)
+
+func TestDeterministicReader(t *testing.T) {
+	want := make([]byte, 100)
+	if _, err := io.ReadFull(NewDeterministicReader([]byte("seed")), want); err != nil {
+		t.Fatal(err)
+	}
+	r := NewDeterministicReader([]byte("seed"))
+	got := make([]byte, 0, len(want))
+	for _, n := range []int{1, 31, 33, 2, 33} {
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, b...)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("the stream depends on the read sizes")
+	}
+
+	other := make([]byte, len(want))
+	io.ReadFull(NewDeterministicReader([]byte("other seed")), other)
+	if bytes.Equal(other, want) {
+		t.Errorf("the stream doesn't depend on the seed")
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	defer Reset()
+	if Default() != rand.Reader {
+		t.Fatal("the default reader is not crypto/rand.Reader")
+	}
+	r := NewDeterministicReader(nil)
+	SetDefault(r)
+	if Default() != r || IsDeterministic() {
+		t.Error("SetDefault didn't set the reader")
+	}
+	SetDeterministic([]byte("seed"))
+	if !IsDeterministic() {
+		t.Error("SetDeterministic didn't enter the deterministic mode")
+	}
+	Reset()
+	if Default() != rand.Reader || IsDeterministic() {
+		t.Error("Reset didn't restore crypto/rand.Reader")
+	}
+}
+
+func TestDeterministicSignatures(t *testing.T) {
+	defer Reset()
+	hash := []byte("0123456789abcdef0123456789abcdef")
+	sign := func() ([]byte, []byte) {
+		SetDeterministic([]byte("seed"))
+		priv, err := sm2.GenerateKey(Default())
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := sm2.SignASN1(Default(), priv, hash, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		master, err := sm9.GenerateSignMasterKey(Default())
+		if err != nil {
+			t.Fatal(err)
+		}
+		userKey, err := master.GenerateUserKey([]byte("Alice"), 0x01)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig9, err := sm9.SignASN1(Default(), userKey, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig, sig9
+	}
+	for i := 0; i < 5; i++ {
+		sig1, sig91 := sign()
+		sig2, sig92 := sign()
+		if !bytes.Equal(sig1, sig2) {
+			t.Fatal("the SM2 signatures are not reproducible")
+		}
+		if !bytes.Equal(sig91, sig92) {
+			t.Fatal("the SM9 signatures are not reproducible")
+		}
+	}
+}
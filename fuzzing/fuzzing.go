@@ -0,0 +1,98 @@
+// Package fuzzing provides fuzz targets of the parsers of this module which
+// handle attacker controlled input: point encodings, ASN.1 signatures and
+// ciphertexts, certificates and PKCS envelopes, together with seed corpus
+// generators, so that downstream consumers can continuously fuzz them, e.g.
+//
+//	func FuzzCertificate(f *testing.F) {
+//		target, _ := fuzzing.Lookup("certificate")
+//		seeds, err := target.Corpus()
+//		if err != nil {
+//			f.Fatal(err)
+//		}
+//		for _, seed := range seeds {
+//			f.Add(seed)
+//		}
+//		f.Fuzz(func(t *testing.T, data []byte) {
+//			if err := target.RoundTrip(data); err != nil {
+//				t.Fatal(err)
+//			}
+//		})
+//	}
+//
+// Each RoundTrip parses the input, it returns nil if the input is rejected.
+// Otherwise it serializes the result, parses it again and reports an error if
+// the second parse fails or the results differ. A panic or an error is a finding.
+package fuzzing
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// Target is a fuzz target.
+type Target struct {
+	Name string
+	// RoundTrip checks the parse→serialize→parse cycle of data.
+	RoundTrip func(data []byte) error
+	// Corpus returns freshly generated valid inputs to seed the fuzzer.
+	Corpus func() ([][]byte, error)
+}
+
+var targets = []Target{
+	{"sm2-point", RoundTripSM2Point, corpusSM2Point},
+	{"sm2-signature", RoundTripSM2Signature, corpusSM2Signature},
+	{"sm2-ciphertext", RoundTripSM2Ciphertext, corpusSM2Ciphertext},
+	{"sm9-g1", RoundTripSM9G1, corpusSM9G1},
+	{"sm9-g2", RoundTripSM9G2, corpusSM9G2},
+	{"sm9-signature", RoundTripSM9Signature, corpusSM9Signature},
+	{"certificate", RoundTripCertificate, corpusCertificate},
+	{"certificate-request", RoundTripCertificateRequest, corpusCertificateRequest},
+	{"crl", RoundTripCRL, corpusCRL},
+	{"pkcs7", RoundTripPKCS7, corpusPKCS7},
+	{"pkcs8", RoundTripPKCS8, corpusPKCS8},
+}
+
+func init() {
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+}
+
+// Targets returns all fuzz targets, sorted by name.
+func Targets() []Target {
+	return append([]Target(nil), targets...)
+}
+
+// Lookup returns the fuzz target with the given name.
+func Lookup(name string) (Target, bool) {
+	for _, t := range targets {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Target{}, false
+}
+
+// roundTrip runs the generic cycle: parse data, serialize it, and require
+// that serializing the result of parsing the serialization is stable.
+func roundTrip(name string, data []byte, parse func([]byte) (any, error), serialize func(any) ([]byte, error)) error {
+	v, err := parse(data)
+	if err != nil {
+		return nil
+	}
+	out, err := serialize(v)
+	if err != nil {
+		return fmt.Errorf("fuzzing: %s: serialize parsed input: %w", name, err)
+	}
+	v2, err := parse(out)
+	if err != nil {
+		return fmt.Errorf("fuzzing: %s: parse serialized input %x: %w", name, out, err)
+	}
+	out2, err := serialize(v2)
+	if err != nil {
+		return fmt.Errorf("fuzzing: %s: serialize reparsed input: %w", name, err)
+	}
+	if !bytes.Equal(out, out2) {
+		return fmt.Errorf("fuzzing: %s: unstable round trip %x != %x", name, out, out2)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package fuzzing
+
+import (
+	"testing"
+)
+
+func TestTargets(t *testing.T) {
+	for _, target := range Targets() {
+		t.Run(target.Name, func(t *testing.T) {
+			corpus, err := target.Corpus()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(corpus) == 0 {
+				t.Fatal("empty corpus")
+			}
+			for _, data := range corpus {
+				if err := target.RoundTrip(data); err != nil {
+					t.Error(err)
+				}
+				// truncated and corrupted inputs must not panic
+				for _, n := range []int{0, 1, len(data) / 2, len(data) - 1} {
+					if err := target.RoundTrip(data[:n]); err != nil {
+						t.Error(err)
+					}
+				}
+				corrupted := append([]byte(nil), data...)
+				corrupted[len(corrupted)/2] ^= 0x5a
+				if err := target.RoundTrip(corrupted); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if _, ok := Lookup("certificate"); !ok {
+		t.Error("certificate target not found")
+	}
+	if _, ok := Lookup("unknown"); ok {
+		t.Error("unknown target found")
+	}
+}
+
+func fuzz(f *testing.F, name string) {
+	target, ok := Lookup(name)
+	if !ok {
+		f.Fatalf("unknown target %s", name)
+	}
+	corpus, err := target.Corpus()
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, data := range corpus {
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if err := target.RoundTrip(data); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func FuzzSM2Point(f *testing.F)           { fuzz(f, "sm2-point") }
+func FuzzSM2Signature(f *testing.F)       { fuzz(f, "sm2-signature") }
+func FuzzSM2Ciphertext(f *testing.F)      { fuzz(f, "sm2-ciphertext") }
+func FuzzSM9G1(f *testing.F)              { fuzz(f, "sm9-g1") }
+func FuzzSM9G2(f *testing.F)              { fuzz(f, "sm9-g2") }
+func FuzzSM9Signature(f *testing.F)       { fuzz(f, "sm9-signature") }
+func FuzzCertificate(f *testing.F)        { fuzz(f, "certificate") }
+func FuzzCertificateRequest(f *testing.F) { fuzz(f, "certificate-request") }
+func FuzzCRL(f *testing.F)                { fuzz(f, "crl") }
+func FuzzPKCS7(f *testing.F)              { fuzz(f, "pkcs7") }
+func FuzzPKCS8(f *testing.F)              { fuzz(f, "pkcs8") }
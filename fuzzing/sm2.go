@@ -0,0 +1,153 @@
+package fuzzing
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/emmansun/gmsm/ecdh"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm2/sm2ec"
+	"github.com/emmansun/gmsm/sm3"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+var (
+	sm2KeyOnce sync.Once
+	sm2Key     *sm2.PrivateKey
+	sm2KeyErr  error
+	// digest is signed by the corpus generators, so that signatures derived
+	// from the seeds exercise the verification path too.
+	digest = sm3.Sum([]byte("fuzzing"))
+)
+
+// fixedSM2Key returns the SM2 key of this process, used to verify fuzzed
+// signatures.
+func fixedSM2Key() (*sm2.PrivateKey, error) {
+	sm2KeyOnce.Do(func() {
+		sm2Key, sm2KeyErr = sm2.GenerateKey(rand.Reader)
+	})
+	return sm2Key, sm2KeyErr
+}
+
+// RoundTripSM2Point checks the uncompressed and compressed SM2 point decoders
+// of the sm2, sm2ec and ecdh packages, which must agree with each other.
+func RoundTripSM2Point(data []byte) error {
+	curve := sm2ec.P256()
+	pub, err := sm2.NewPublicKey(data)
+	ecdhPub, ecdhErr := ecdh.P256().NewPublicKey(data)
+	if (err == nil) != (ecdhErr == nil) {
+		return fmt.Errorf("fuzzing: sm2-point: sm2 error %v, ecdh error %v", err, ecdhErr)
+	}
+	if err == nil {
+		if out := elliptic.Marshal(curve, pub.X, pub.Y); !bytes.Equal(out, data) {
+			return fmt.Errorf("fuzzing: sm2-point: %x encoded as %x", data, out)
+		}
+		if !bytes.Equal(ecdhPub.Bytes(), data) {
+			return fmt.Errorf("fuzzing: sm2-point: %x encoded by ecdh as %x", data, ecdhPub.Bytes())
+		}
+	}
+	if x, y := sm2ec.UnmarshalCompressed(curve, data); x != nil {
+		if out := elliptic.MarshalCompressed(curve, x, y); !bytes.Equal(out, data) {
+			return fmt.Errorf("fuzzing: sm2-point: %x encoded as %x", data, out)
+		}
+		if _, err := sm2.NewPublicKey(elliptic.Marshal(curve, x, y)); err != nil {
+			return fmt.Errorf("fuzzing: sm2-point: decompressed %x is rejected: %w", data, err)
+		}
+	}
+	return nil
+}
+
+func corpusSM2Point() ([][]byte, error) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	curve := sm2ec.P256()
+	return [][]byte{
+		elliptic.Marshal(curve, priv.X, priv.Y),
+		elliptic.MarshalCompressed(curve, priv.X, priv.Y),
+		{0},
+	}, nil
+}
+
+// RoundTripSM2Signature checks the ASN.1 SM2 signature decoder: a signature
+// and its re-encoding must have the same verification result.
+func RoundTripSM2Signature(data []byte) error {
+	priv, err := fixedSM2Key()
+	if err != nil {
+		return err
+	}
+	var (
+		r, s  []byte
+		inner cryptobyte.String
+	)
+	input := cryptobyte.String(data)
+	valid := sm2.VerifyASN1(&priv.PublicKey, digest[:], data)
+	if !input.ReadASN1(&inner, asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1Integer(&r) || !inner.ReadASN1Integer(&s) || !inner.Empty() {
+		if valid {
+			return errors.New("fuzzing: sm2-signature: invalid ASN.1 signature is verified")
+		}
+		return nil
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(new(big.Int).SetBytes(r))
+		b.AddASN1BigInt(new(big.Int).SetBytes(s))
+	})
+	out, err := b.Bytes()
+	if err != nil {
+		return fmt.Errorf("fuzzing: sm2-signature: %w", err)
+	}
+	if sm2.VerifyASN1(&priv.PublicKey, digest[:], out) != valid {
+		return fmt.Errorf("fuzzing: sm2-signature: verification of %x and %x differ", data, out)
+	}
+	return nil
+}
+
+func corpusSM2Signature() ([][]byte, error) {
+	priv, err := fixedSM2Key()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sm2.SignASN1(rand.Reader, priv, digest[:], nil)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{sig}, nil
+}
+
+// RoundTripSM2Ciphertext checks the conversions between the ASN.1 and the plain
+// C1C3C2 encodings of SM2 ciphertexts.
+func RoundTripSM2Ciphertext(data []byte) error {
+	toPlain := func(b []byte) (any, error) { return sm2.ASN1Ciphertext2Plain(b, nil) }
+	toASN1 := func(v any) ([]byte, error) { return sm2.PlainCiphertext2ASN1(v.([]byte), sm2.C1C3C2) }
+	if err := roundTrip("sm2-ciphertext", data, toPlain, toASN1); err != nil {
+		return err
+	}
+	fromPlain := func(b []byte) (any, error) { return sm2.PlainCiphertext2ASN1(b, sm2.C1C3C2) }
+	fromASN1 := func(v any) ([]byte, error) { return sm2.ASN1Ciphertext2Plain(v.([]byte), nil) }
+	return roundTrip("sm2-ciphertext", data, fromPlain, fromASN1)
+}
+
+func corpusSM2Ciphertext() ([][]byte, error) {
+	priv, err := fixedSM2Key()
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := sm2.EncryptASN1(rand.Reader, &priv.PublicKey, []byte("fuzzing"))
+	if err != nil {
+		return nil, err
+	}
+	plain, err := sm2.ASN1Ciphertext2Plain(ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{ciphertext, plain}, nil
+}
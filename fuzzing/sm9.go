@@ -0,0 +1,130 @@
+package fuzzing
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/emmansun/gmsm/sm9"
+	"github.com/emmansun/gmsm/sm9/bn256"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+var (
+	sm9KeyOnce sync.Once
+	sm9Master  *sm9.SignMasterPrivateKey
+	sm9User    *sm9.SignPrivateKey
+	sm9KeyErr  error
+	sm9UID     = []byte("fuzzing")
+)
+
+const sm9HID = 0x01
+
+// fixedSM9Key returns the SM9 sign keys of this process, used to verify fuzzed
+// signatures.
+func fixedSM9Key() (*sm9.SignMasterPrivateKey, *sm9.SignPrivateKey, error) {
+	sm9KeyOnce.Do(func() {
+		sm9Master, sm9KeyErr = sm9.GenerateSignMasterKey(rand.Reader)
+		if sm9KeyErr == nil {
+			sm9User, sm9KeyErr = sm9Master.GenerateUserKey(sm9UID, sm9HID)
+		}
+	})
+	return sm9Master, sm9User, sm9KeyErr
+}
+
+// RoundTripSM9G1 checks the decoder of G1 points.
+func RoundTripSM9G1(data []byte) error {
+	return roundTrip("sm9-g1", data, func(b []byte) (any, error) {
+		e := new(bn256.G1)
+		_, err := e.Unmarshal(b)
+		return e, err
+	}, func(v any) ([]byte, error) {
+		return v.(*bn256.G1).Marshal(), nil
+	})
+}
+
+func corpusSM9G1() ([][]byte, error) {
+	_, e, err := bn256.RandomG1(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{e.Marshal(), make([]byte, 64)}, nil
+}
+
+// RoundTripSM9G2 checks the decoder of G2 points.
+func RoundTripSM9G2(data []byte) error {
+	return roundTrip("sm9-g2", data, func(b []byte) (any, error) {
+		e := new(bn256.G2)
+		_, err := e.Unmarshal(b)
+		return e, err
+	}, func(v any) ([]byte, error) {
+		return v.(*bn256.G2).Marshal(), nil
+	})
+}
+
+func corpusSM9G2() ([][]byte, error) {
+	_, e, err := bn256.RandomG2(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{e.Marshal(), make([]byte, 128)}, nil
+}
+
+// RoundTripSM9Signature checks the ASN.1 SM9 signature decoder: a signature
+// and its re-encoding must have the same verification result.
+func RoundTripSM9Signature(data []byte) error {
+	master, _, err := fixedSM9Key()
+	if err != nil {
+		return err
+	}
+	pub := master.Public()
+	valid := sm9.VerifyASN1(pub, sm9UID, sm9HID, digest[:], data)
+	var (
+		h, s  []byte
+		inner cryptobyte.String
+	)
+	input := cryptobyte.String(data)
+	if !input.ReadASN1(&inner, asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1Bytes(&h, asn1.OCTET_STRING) ||
+		!inner.ReadASN1BitStringAsBytes(&s) || !inner.Empty() ||
+		len(s) == 0 || s[0] != 4 {
+		if valid {
+			return errors.New("fuzzing: sm9-signature: invalid ASN.1 signature is verified")
+		}
+		return nil
+	}
+	p := new(bn256.G1)
+	if _, err := p.Unmarshal(s[1:]); err != nil {
+		if valid {
+			return errors.New("fuzzing: sm9-signature: signature with invalid point is verified")
+		}
+		return nil
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1OctetString(h)
+		b.AddASN1BitString(p.MarshalUncompressed())
+	})
+	out, err := b.Bytes()
+	if err != nil {
+		return fmt.Errorf("fuzzing: sm9-signature: %w", err)
+	}
+	if sm9.VerifyASN1(pub, sm9UID, sm9HID, digest[:], out) != valid {
+		return fmt.Errorf("fuzzing: sm9-signature: verification of %x and %x differ", data, out)
+	}
+	return nil
+}
+
+func corpusSM9Signature() ([][]byte, error) {
+	_, user, err := fixedSM9Key()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sm9.SignASN1(rand.Reader, user, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{sig}, nil
+}
@@ -0,0 +1,204 @@
+package fuzzing
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/emmansun/gmsm/pkcs"
+	"github.com/emmansun/gmsm/pkcs7"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm9"
+	"github.com/emmansun/gmsm/smx509"
+)
+
+// RoundTripCertificate checks the certificate parser.
+func RoundTripCertificate(data []byte) error {
+	return roundTrip("certificate", data, func(b []byte) (any, error) {
+		return smx509.ParseCertificate(b)
+	}, func(v any) ([]byte, error) {
+		return v.(*smx509.Certificate).Raw, nil
+	})
+}
+
+// RoundTripCertificateRequest checks the certificate request parser.
+func RoundTripCertificateRequest(data []byte) error {
+	return roundTrip("certificate-request", data, func(b []byte) (any, error) {
+		return smx509.ParseCertificateRequest(b)
+	}, func(v any) ([]byte, error) {
+		return v.(*smx509.CertificateRequest).Raw, nil
+	})
+}
+
+// RoundTripCRL checks the certificate revocation list parser.
+func RoundTripCRL(data []byte) error {
+	return roundTrip("crl", data, func(b []byte) (any, error) {
+		return smx509.ParseDERCRL(b)
+	}, func(v any) ([]byte, error) {
+		return asn1.Marshal(*v.(*pkix.CertificateList))
+	})
+}
+
+// RoundTripPKCS7 checks the PKCS #7 parser, the certificates of the parsed
+// message are re-encoded as a degenerate signed data.
+func RoundTripPKCS7(data []byte) error {
+	p7, err := pkcs7.Parse(data)
+	if err != nil || len(p7.Certificates) == 0 {
+		return nil
+	}
+	var certs []byte
+	for _, cert := range p7.Certificates {
+		certs = append(certs, cert.Raw...)
+	}
+	out, err := pkcs7.DegenerateCertificate(certs)
+	if err != nil {
+		return fmt.Errorf("fuzzing: pkcs7: serialize certificates: %w", err)
+	}
+	p7b, err := pkcs7.Parse(out)
+	if err != nil {
+		return fmt.Errorf("fuzzing: pkcs7: parse serialized input %x: %w", out, err)
+	}
+	if len(p7b.Certificates) != len(p7.Certificates) {
+		return fmt.Errorf("fuzzing: pkcs7: %d certificates reparsed as %d", len(p7.Certificates), len(p7b.Certificates))
+	}
+	for i, cert := range p7b.Certificates {
+		if !cert.Equal(p7.Certificates[i]) {
+			return fmt.Errorf("fuzzing: pkcs7: certificate %d differs", i)
+		}
+	}
+	return nil
+}
+
+// RoundTripPKCS8 checks the PKCS #8 private key parser.
+func RoundTripPKCS8(data []byte) error {
+	return roundTrip("pkcs8", data, smx509.ParsePKCS8PrivateKey, smx509.MarshalPKCS8PrivateKey)
+}
+
+type certificateCorpus struct {
+	priv    *sm2.PrivateKey
+	cert    *smx509.Certificate
+	csr     []byte
+	crl     []byte
+	signed  []byte
+	envelop []byte
+}
+
+func newCertificateCorpus() (*certificateCorpus, error) {
+	c := &certificateCorpus{}
+	var err error
+	if c.priv, err = fixedSM2Key(); err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fuzzing", Organization: []string{"GMSM"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"fuzzing.example.com"},
+	}
+	der, err := smx509.CreateCertificate(rand.Reader, template, template, &c.priv.PublicKey, c.priv)
+	if err != nil {
+		return nil, err
+	}
+	if c.cert, err = smx509.ParseCertificate(der); err != nil {
+		return nil, err
+	}
+	c.csr, err = smx509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  template.Subject,
+		DNSNames: template.DNSNames,
+	}, c.priv)
+	if err != nil {
+		return nil, err
+	}
+	c.crl, err = smx509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now(),
+		NextUpdate: time.Now().Add(time.Hour),
+		RevokedCertificates: []pkix.RevokedCertificate{
+			{SerialNumber: big.NewInt(2), RevocationTime: time.Now()},
+		},
+	}, c.cert, c.priv)
+	if err != nil {
+		return nil, err
+	}
+	sd, err := pkcs7.NewSMSignedData([]byte("fuzzing"))
+	if err != nil {
+		return nil, err
+	}
+	if err = sd.AddSigner(c.cert, c.priv, pkcs7.SignerInfoConfig{}); err != nil {
+		return nil, err
+	}
+	if c.signed, err = sd.Finish(); err != nil {
+		return nil, err
+	}
+	c.envelop, err = pkcs7.EncryptSM(pkcs.SM4CBC, []byte("fuzzing"), []*smx509.Certificate{c.cert})
+	return c, err
+}
+
+func corpusCertificate() ([][]byte, error) {
+	c, err := newCertificateCorpus()
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{c.cert.Raw}, nil
+}
+
+func corpusCertificateRequest() ([][]byte, error) {
+	c, err := newCertificateCorpus()
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{c.csr}, nil
+}
+
+func corpusCRL() ([][]byte, error) {
+	c, err := newCertificateCorpus()
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{c.crl}, nil
+}
+
+func corpusPKCS7() ([][]byte, error) {
+	c, err := newCertificateCorpus()
+	if err != nil {
+		return nil, err
+	}
+	degenerate, err := pkcs7.DegenerateCertificate(c.cert.Raw)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{c.signed, c.envelop, degenerate}, nil
+}
+
+func corpusPKCS8() ([][]byte, error) {
+	priv, err := fixedSM2Key()
+	if err != nil {
+		return nil, err
+	}
+	master, user, err := fixedSM9Key()
+	if err != nil {
+		return nil, err
+	}
+	encryptMaster, err := sm9.GenerateEncryptMasterKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	var corpus [][]byte
+	for _, key := range []any{priv, master, user, encryptMaster} {
+		der, err := smx509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		corpus = append(corpus, der)
+	}
+	return corpus, nil
+}
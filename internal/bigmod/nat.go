@@ -124,6 +124,25 @@ func (x *Nat) SetBig(n *big.Int) *Nat {
 	return x
 }
 
+// SetUnreducedBytes assigns x = b, where b is a slice of big-endian bytes of any length.
+//
+// The announced length of x is set based on the length of b, the value is not
+// reduced, it is intended to be used as the input of Mod or ModNat.
+func (x *Nat) SetUnreducedBytes(b []byte) *Nat {
+	x.reset((len(b) + _S - 1) / _S)
+	i, k := len(b), 0
+	for i >= _S {
+		x.limbs[k] = bigEndianUint(b[i-_S : i])
+		i -= _S
+		k++
+	}
+	for s := 0; i > 0; s += 8 {
+		x.limbs[k] |= uint(b[i-1]) << s
+		i--
+	}
+	return x
+}
+
 // Bytes returns x as a zero-extended big-endian byte slice. The size of the
 // slice will match the size of m.
 //
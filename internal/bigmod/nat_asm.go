@@ -6,7 +6,12 @@
 
 package bigmod
 
-import "golang.org/x/sys/cpu"
+import (
+	"runtime"
+
+	"github.com/emmansun/gmsm/internal/impl"
+	"golang.org/x/sys/cpu"
+)
 
 // amd64 assembly uses ADCX/ADOX/MULX if ADX is available to run two carry
 // chains in the flags in parallel across the whole operation, and aggressively
@@ -18,6 +23,11 @@ import "golang.org/x/sys/cpu"
 
 var supportADX = cpu.X86.HasADX && cpu.X86.HasBMI2
 
+func init() {
+	impl.Register("bigmod", "ADX", supportADX, true)
+	impl.Register("bigmod", runtime.GOARCH, true, true)
+}
+
 //go:noescape
 func addMulVVW256(z, x *uint, y uint) (c uint)
 
@@ -6,7 +6,15 @@
 
 package bigmod
 
-import "unsafe"
+import (
+	"unsafe"
+
+	"github.com/emmansun/gmsm/internal/impl"
+)
+
+func init() {
+	impl.Register("bigmod", impl.Generic, true, true)
+}
 
 // TODO: will use unsafe.Slice directly once upgrade golang sdk to 1.17+
 func slice256(ptr *uint) []uint {
@@ -489,3 +489,19 @@ func TestNewModFromBigZero(t *testing.T) {
 		t.Errorf("NewModulusFromBig(2) got %q, want %q", err, expected)
 	}
 }
+
+func TestSetUnreducedBytes(t *testing.T) {
+	m := modulusFromBytes([]byte{13})
+	for _, b := range [][]byte{
+		{0x01},
+		{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09},
+		{0xff, 0xee, 0xdd, 0xcc, 0xbb, 0xaa, 0x99, 0x88, 0x77, 0x66, 0x55, 0x44, 0x33, 0x22, 0x11, 0x00, 0x42},
+	} {
+		x := NewNat().SetUnreducedBytes(b)
+		got := NewNat().Mod(x, m)
+		expected := new(big.Int).Mod(new(big.Int).SetBytes(b), big.NewInt(13))
+		if new(big.Int).SetBytes(got.Bytes(m)).Cmp(expected) != 0 {
+			t.Errorf("%x mod 13: got %x, want %v", b, got.Bytes(m), expected)
+		}
+	}
+}
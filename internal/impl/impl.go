@@ -0,0 +1,179 @@
+// Package impl is a registry of alternative implementations of cryptographic
+// primitives, to allow introspection of the active implementation.
+package impl
+
+import (
+	"errors"
+	"sort"
+	"sync/atomic"
+)
+
+// Generic is the name of the pure Go implementation, it's used when none of
+// the other registered implementations is available.
+const Generic = "generic"
+
+// Implementation describes one registered implementation.
+type Implementation struct {
+	Package      string
+	Name         string
+	Available    bool
+	ConstantTime bool
+}
+
+var allImplementations []Implementation
+
+// Register records an implementation of a primitive of the package pkg.
+//
+// Implementations of the same package have priority in registration order,
+// except the Generic one, which is always the last resort.
+//
+// Register must be called from an init function.
+func Register(pkg, name string, available, constantTime bool) {
+	allImplementations = append(allImplementations, Implementation{
+		Package:      pkg,
+		Name:         name,
+		Available:    available,
+		ConstantTime: constantTime,
+	})
+}
+
+// Packages returns the list of all packages which registered implementations.
+func Packages() []string {
+	var pkgs []string
+	seen := make(map[string]bool)
+	for _, i := range allImplementations {
+		if !seen[i.Package] {
+			seen[i.Package] = true
+			pkgs = append(pkgs, i.Package)
+		}
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+// List returns all implementations registered for the package pkg.
+func List(pkg string) []Implementation {
+	var list []Implementation
+	for _, i := range allImplementations {
+		if i.Package == pkg {
+			list = append(list, i)
+		}
+	}
+	return list
+}
+
+// Active returns the name of the implementation in use for the package pkg, it
+// returns the empty string if there is no registered implementation.
+func Active(pkg string) string {
+	active := ""
+	for _, i := range allImplementations {
+		if i.Package != pkg || !i.Available {
+			continue
+		}
+		if i.Name != Generic {
+			return i.Name
+		}
+		active = Generic
+	}
+	return active
+}
+
+// ErrVariableTime is wrapped by the errors of CheckConstantTime and
+// CheckPath.
+var ErrVariableTime = errors.New("gmsm: variable-time code path refused")
+
+// VariableTimeError is returned once constant time is required, when an
+// operation would run a code path whose running time depends on secret data.
+type VariableTimeError struct {
+	Path string // like "sm4: generic"
+}
+
+func (e *VariableTimeError) Error() string {
+	return "gmsm: " + e.Path + " is not constant time"
+}
+
+func (e *VariableTimeError) Unwrap() error {
+	return ErrVariableTime
+}
+
+var (
+	constantTimeRequired int32
+	variableTimePaths    []string
+)
+
+// SetConstantTimeRequired enables or disables the refusal of variable-time
+// code paths.
+func SetConstantTimeRequired(required bool) {
+	var v int32
+	if required {
+		v = 1
+	}
+	atomic.StoreInt32(&constantTimeRequired, v)
+}
+
+// ConstantTimeRequired reports whether variable-time code paths are refused.
+func ConstantTimeRequired() bool {
+	return atomic.LoadInt32(&constantTimeRequired) == 1
+}
+
+// CheckConstantTime returns a *VariableTimeError if constant time is required
+// and the active implementation of the package pkg isn't constant time.
+func CheckConstantTime(pkg string) error {
+	if !ConstantTimeRequired() {
+		return nil
+	}
+	active := Active(pkg)
+	for _, i := range allImplementations {
+		if i.Package == pkg && i.Name == active && !i.ConstantTime {
+			return &VariableTimeError{Path: pkg + ": " + active}
+		}
+	}
+	return nil
+}
+
+// RegisterVariableTimePath records a code path which isn't constant time and
+// isn't an implementation of a primitive, like the math/big fallback for
+// custom curves, it must be guarded by CheckPath.
+//
+// RegisterVariableTimePath must be called from an init function.
+func RegisterVariableTimePath(path string) {
+	variableTimePaths = append(variableTimePaths, path)
+}
+
+// CheckPath returns a *VariableTimeError if constant time is required and
+// path is a code path registered with RegisterVariableTimePath. It panics if
+// path isn't registered, so that a guarded path can't be missing from
+// VariableTimePaths.
+func CheckPath(path string) error {
+	registered := false
+	for _, p := range variableTimePaths {
+		if p == path {
+			registered = true
+			break
+		}
+	}
+	if !registered {
+		panic("impl: unregistered variable-time path " + path)
+	}
+	if !ConstantTimeRequired() {
+		return nil
+	}
+	return &VariableTimeError{Path: path}
+}
+
+// VariableTimePaths returns the variable-time code paths of this process: the
+// active implementations which aren't constant time and the registered paths.
+func VariableTimePaths() []string {
+	var paths []string
+	for _, pkg := range Packages() {
+		active := Active(pkg)
+		for _, i := range List(pkg) {
+			if i.Name == active && !i.ConstantTime {
+				paths = append(paths, pkg+": "+active)
+			}
+		}
+	}
+	paths = append(paths, variableTimePaths...)
+	sort.Strings(paths)
+	return paths
+}
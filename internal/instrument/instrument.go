@@ -0,0 +1,46 @@
+// Package instrument holds the operation hook of the metrics package. The
+// primitives call Start before an operation and the returned function with
+// its result, which costs an atomic load when no hook is set.
+package instrument
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// Hook is called when an operation of primitive starts, done is called with
+// the result of the operation.
+type Hook func(primitive, operation string) (done func(err error))
+
+// ErrVerification is the result of a signature verification which failed.
+var ErrVerification = errors.New("signature verification failed")
+
+type holder struct {
+	hook Hook
+}
+
+var current atomic.Value // holder
+
+// SetHook sets the hook, nil disables the instrumentation.
+func SetHook(h Hook) {
+	current.Store(holder{h})
+}
+
+func nop(error) {}
+
+// Start reports the start of an operation of primitive and returns the
+// function which reports its result.
+func Start(primitive, operation string) (done func(err error)) {
+	if h, _ := current.Load().(holder); h.hook != nil {
+		return h.hook(primitive, operation)
+	}
+	return nop
+}
+
+// VerifyResult returns the result of a verification for done.
+func VerifyResult(ok bool) error {
+	if ok {
+		return nil
+	}
+	return ErrVerification
+}
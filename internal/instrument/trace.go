@@ -0,0 +1,41 @@
+package instrument
+
+import "sync/atomic"
+
+// Tracer receives the intermediate values of the operations, named like the
+// examples of the standards, e.g. "w" of an SM9 signature.
+type Tracer func(primitive, operation, name string, value []byte)
+
+type tracerHolder struct {
+	tracer Tracer
+}
+
+var currentTracer atomic.Value // tracerHolder
+
+// SetTracer sets the tracer, nil disables the tracing. It has no effect
+// unless TraceEnabled.
+func SetTracer(t Tracer) {
+	currentTracer.Store(tracerHolder{t})
+}
+
+// Tracing reports whether a tracer is set. The primitives check it before
+// computing the values they trace, so that the tracing code is removed by
+// the compiler unless TraceEnabled.
+func Tracing() bool {
+	if !TraceEnabled {
+		return false
+	}
+	h, _ := currentTracer.Load().(tracerHolder)
+	return h.tracer != nil
+}
+
+// Trace passes an intermediate value of an operation of primitive to the
+// tracer.
+func Trace(primitive, operation, name string, value []byte) {
+	if !TraceEnabled {
+		return
+	}
+	if h, _ := currentTracer.Load().(tracerHolder); h.tracer != nil {
+		h.tracer(primitive, operation, name, value)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !gmsm_trace
+
+package instrument
+
+// TraceEnabled reports whether the tracing of intermediate values is
+// compiled in, with the gmsm_trace build tag.
+const TraceEnabled = false
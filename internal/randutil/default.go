@@ -0,0 +1,36 @@
+package randutil
+
+import (
+	"crypto/rand"
+	"io"
+	"sync/atomic"
+)
+
+type readerHolder struct {
+	r             io.Reader
+	deterministic bool
+}
+
+var current atomic.Value // readerHolder
+
+// Reader returns the default source of randomness of the module, which is
+// used where the caller doesn't pass one, crypto/rand.Reader unless set.
+func Reader() io.Reader {
+	if h, _ := current.Load().(readerHolder); h.r != nil {
+		return h.r
+	}
+	return rand.Reader
+}
+
+// SetReader sets the default source of randomness, nil restores
+// crypto/rand.Reader. If deterministic is true MaybeReadByte doesn't read, so
+// that the outputs only depend on the bytes read from the sources.
+func SetReader(r io.Reader, deterministic bool) {
+	current.Store(readerHolder{r, deterministic})
+}
+
+// Deterministic reports whether the deterministic mode is set.
+func Deterministic() bool {
+	h, _ := current.Load().(readerHolder)
+	return h.deterministic
+}
@@ -21,8 +21,11 @@ var (
 // assuming that rsa.GenerateKey is deterministic w.r.t. a given random stream.
 //
 // This does not affect tests that pass a stream of fixed bytes as the random
-// source (e.g. a zeroReader).
+// source (e.g. a zeroReader). It doesn't read in the deterministic mode.
 func MaybeReadByte(r io.Reader) {
+	if Deterministic() {
+		return
+	}
 	closedChanOnce.Do(func() {
 		closedChan = make(chan struct{})
 		close(closedChan)
@@ -0,0 +1,7 @@
+//go:build tinygo || gmsm_small
+
+package sm2ec
+
+// smallFootprint reports whether to trade performance for memory, it's enabled
+// by the tinygo or gmsm_small build tag.
+const smallFootprint = true
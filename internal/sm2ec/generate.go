@@ -525,6 +525,10 @@ func (p *{{.P}}Point) ScalarBaseMult(scalar []byte) (*{{.P}}Point, error) {
 	if len(scalar) != {{.p}}ElementLength {
 		return nil, errors.New("invalid scalar length")
 	}
+	if smallFootprint {
+		// avoid the large precomputed tables of the generator.
+		return p.ScalarMult(New{{.P}}Point().SetGenerator(), scalar)
+	}
 	tables := p.generatorTable()
 	// This is also a scalar multiplication with a four-bit window like in
 	// ScalarMult, but in this case the doublings are precomputed. The value
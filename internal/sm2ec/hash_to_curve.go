@@ -0,0 +1,219 @@
+package sm2ec
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/emmansun/gmsm/internal/sm2ec/fiat"
+	"github.com/emmansun/gmsm/sm3"
+)
+
+// Hash to curve implementation for the SM2 curve, follows RFC 9380 with the suites
+//
+//	SM2P256_XMD:SM3_SSWU_RO_ (HashToCurve)
+//	SM2P256_XMD:SM3_SSWU_NU_ (EncodeToCurve)
+//
+// expand_message_xmd uses SM3, the simplified SWU map uses Z = -9, which is
+// selected by the find_z_sswu procedure of RFC 9380 Appendix H.2.
+
+const (
+	// ceil((ceil(log2(p)) + k) / 8), k = 128
+	h2cFieldElementLength = 48
+	h2cMaxDSTLength       = 255
+)
+
+var h2cParams struct {
+	once        sync.Once
+	a           *fiat.SM2P256Element
+	b           *fiat.SM2P256Element
+	z           *fiat.SM2P256Element
+	minusBOverA *fiat.SM2P256Element
+	bOverZA     *fiat.SM2P256Element
+	twoTo192    *fiat.SM2P256Element
+}
+
+// sqrtExp is (p + 1) / 4, p = 3 mod 4.
+var sqrtExp = [32]byte{
+	0x3f, 0xff, 0xff, 0xff, 0xbf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xc0, 0x00, 0x00, 0x00, 0x40, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+}
+
+func initH2CParams() {
+	h2cParams.once.Do(func() {
+		zero := new(fiat.SM2P256Element)
+		three := new(fiat.SM2P256Element).One()
+		three.Add(three, three).Add(three, new(fiat.SM2P256Element).One())
+		nine := new(fiat.SM2P256Element).Mul(three, three)
+
+		h2cParams.a = new(fiat.SM2P256Element).Sub(zero, three)
+		h2cParams.b, _ = new(fiat.SM2P256Element).SetBytes([]byte{
+			0x28, 0xe9, 0xfa, 0x9e, 0x9d, 0x9f, 0x5e, 0x34, 0x4d, 0x5a, 0x9e, 0x4b, 0xcf, 0x65, 0x09, 0xa7,
+			0xf3, 0x97, 0x89, 0xf5, 0x15, 0xab, 0x8f, 0x92, 0xdd, 0xbc, 0xbd, 0x41, 0x4d, 0x94, 0x0e, 0x93,
+		})
+		h2cParams.z = new(fiat.SM2P256Element).Sub(zero, nine)
+
+		t := new(fiat.SM2P256Element).Invert(h2cParams.a)
+		t.Mul(t, h2cParams.b)
+		h2cParams.minusBOverA = new(fiat.SM2P256Element).Sub(zero, t)
+
+		t.Mul(h2cParams.z, h2cParams.a)
+		t.Invert(t)
+		h2cParams.bOverZA = new(fiat.SM2P256Element).Mul(t, h2cParams.b)
+
+		var buf [32]byte
+		buf[7] = 1
+		h2cParams.twoTo192, _ = new(fiat.SM2P256Element).SetBytes(buf[:])
+	})
+}
+
+// HashToCurve hashes msg to a point of the SM2 curve with domain separation tag dst,
+// it's the random oracle encoding hash_to_curve of RFC 9380.
+func HashToCurve(msg, dst []byte) (*SM2P256Point, error) {
+	uniform, err := ExpandMessageXMD(msg, dst, 2*h2cFieldElementLength)
+	if err != nil {
+		return nil, err
+	}
+	q0, err := mapToCurve(hashToField(uniform[:h2cFieldElementLength]))
+	if err != nil {
+		return nil, err
+	}
+	q1, err := mapToCurve(hashToField(uniform[h2cFieldElementLength:]))
+	if err != nil {
+		return nil, err
+	}
+	// the cofactor of SM2 curve is 1, clear_cofactor is no-op.
+	return q0.Add(q0, q1), nil
+}
+
+// EncodeToCurve hashes msg to a point of the SM2 curve with domain separation tag dst,
+// it's the nonuniform encoding encode_to_curve of RFC 9380.
+func EncodeToCurve(msg, dst []byte) (*SM2P256Point, error) {
+	uniform, err := ExpandMessageXMD(msg, dst, h2cFieldElementLength)
+	if err != nil {
+		return nil, err
+	}
+	return mapToCurve(hashToField(uniform))
+}
+
+// ExpandMessageXMD implements expand_message_xmd of RFC 9380 5.3.1 with SM3.
+func ExpandMessageXMD(msg, dst []byte, lenInBytes int) ([]byte, error) {
+	ell := (lenInBytes + sm3.Size - 1) / sm3.Size
+	if ell > 255 || lenInBytes > 65535 || lenInBytes <= 0 {
+		return nil, errors.New("sm2ec: requested length is too large")
+	}
+	if len(dst) > h2cMaxDSTLength {
+		return nil, errors.New("sm2ec: domain separation tag is too long")
+	}
+	dstPrime := make([]byte, len(dst)+1)
+	copy(dstPrime, dst)
+	dstPrime[len(dst)] = byte(len(dst))
+
+	md := sm3.New()
+	// b_0 = H(Z_pad || msg || l_i_b_str || I2OSP(0, 1) || DST_prime)
+	md.Write(make([]byte, sm3.BlockSize))
+	md.Write(msg)
+	md.Write([]byte{byte(lenInBytes >> 8), byte(lenInBytes), 0})
+	md.Write(dstPrime)
+	b0 := md.Sum(nil)
+
+	// b_1 = H(b_0 || I2OSP(1, 1) || DST_prime)
+	md.Reset()
+	md.Write(b0)
+	md.Write([]byte{1})
+	md.Write(dstPrime)
+	bi := md.Sum(nil)
+
+	uniform := make([]byte, 0, ell*sm3.Size)
+	uniform = append(uniform, bi...)
+	tmp := make([]byte, sm3.Size)
+	for i := 2; i <= ell; i++ {
+		// b_i = H(strxor(b_0, b_(i - 1)) || I2OSP(i, 1) || DST_prime)
+		for j := range tmp {
+			tmp[j] = b0[j] ^ bi[j]
+		}
+		md.Reset()
+		md.Write(tmp)
+		md.Write([]byte{byte(i)})
+		md.Write(dstPrime)
+		bi = md.Sum(bi[:0])
+		uniform = append(uniform, bi...)
+	}
+	return uniform[:lenInBytes], nil
+}
+
+// hashToField reduces 48 bytes big endian value modulo p.
+func hashToField(b []byte) *fiat.SM2P256Element {
+	initH2CParams()
+	var buf [32]byte
+	copy(buf[8:], b[:24])
+	hi, _ := new(fiat.SM2P256Element).SetBytes(buf[:])
+	copy(buf[8:], b[24:])
+	lo, _ := new(fiat.SM2P256Element).SetBytes(buf[:])
+	hi.Mul(hi, h2cParams.twoTo192)
+	return hi.Add(hi, lo)
+}
+
+func h2cSqrtCandidate(z, x *fiat.SM2P256Element) {
+	t := new(fiat.SM2P256Element).One()
+	for _, b := range sqrtExp {
+		for i := 7; i >= 0; i-- {
+			t.Square(t)
+			if (b>>i)&1 == 1 {
+				t.Mul(t, x)
+			}
+		}
+	}
+	z.Set(t)
+}
+
+func sgn0(e *fiat.SM2P256Element) int {
+	return int(e.Bytes()[31] & 1)
+}
+
+func h2cPolynomial(y2, x *fiat.SM2P256Element) *fiat.SM2P256Element {
+	// y² = x³ + ax + b
+	y2.Square(x)
+	y2.Mul(y2, x)
+	t := new(fiat.SM2P256Element).Mul(h2cParams.a, x)
+	y2.Add(y2, t)
+	return y2.Add(y2, h2cParams.b)
+}
+
+// mapToCurve simplified SWU map of RFC 9380 6.6.2, it runs in constant time.
+func mapToCurve(u *fiat.SM2P256Element) (*SM2P256Point, error) {
+	initH2CParams()
+	// tv1 = inv0(Z^2 * u^4 + Z * u^2)
+	zu2 := new(fiat.SM2P256Element).Square(u)
+	zu2.Mul(zu2, h2cParams.z)
+	tv1 := new(fiat.SM2P256Element).Square(zu2)
+	tv1.Add(tv1, zu2)
+	tv1.Invert(tv1)
+	// x1 = (-B / A) * (1 + tv1)
+	x1 := new(fiat.SM2P256Element).One()
+	x1.Add(x1, tv1)
+	x1.Mul(x1, h2cParams.minusBOverA)
+	// If tv1 == 0, set x1 = B / (Z * A)
+	x1.Select(h2cParams.bOverZA, x1, tv1.IsZero())
+	gx1 := h2cPolynomial(new(fiat.SM2P256Element), x1)
+	// x2 = Z * u^2 * x1
+	x2 := new(fiat.SM2P256Element).Mul(zu2, x1)
+	gx2 := h2cPolynomial(new(fiat.SM2P256Element), x2)
+
+	y1 := new(fiat.SM2P256Element)
+	h2cSqrtCandidate(y1, gx1)
+	isSquare := new(fiat.SM2P256Element).Square(y1).Equal(gx1)
+	y2 := new(fiat.SM2P256Element)
+	h2cSqrtCandidate(y2, gx2)
+
+	x := new(fiat.SM2P256Element).Select(x1, x2, isSquare)
+	y := new(fiat.SM2P256Element).Select(y1, y2, isSquare)
+	// If sgn0(u) != sgn0(y), set y = -y
+	negY := new(fiat.SM2P256Element).Sub(new(fiat.SM2P256Element), y)
+	y.Select(negY, y, sgn0(u)^sgn0(y))
+
+	var buf [1 + 2*32]byte
+	buf[0] = 4
+	copy(buf[1:], x.Bytes())
+	copy(buf[33:], y.Bytes())
+	return NewSM2P256Point().SetBytes(buf[:])
+}
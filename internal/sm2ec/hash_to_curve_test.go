@@ -0,0 +1,78 @@
+package sm2ec
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/emmansun/gmsm/internal/sm2ec/fiat"
+)
+
+func TestExpandMessageXMD(t *testing.T) {
+	got, err := ExpandMessageXMD([]byte("abc"), []byte("QUUX-V01-CS02-with-expander-SM3-128"), 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(got) != "bc947b2bab2f347c366cdd414e278bb80b176a0a3dde02088be71fcbf8660603" {
+		t.Errorf("unexpected result %x", got)
+	}
+	if _, err = ExpandMessageXMD(nil, make([]byte, 256), 32); err == nil {
+		t.Errorf("expected error for long DST")
+	}
+	if _, err = ExpandMessageXMD(nil, nil, 256*32); err == nil {
+		t.Errorf("expected error for long output")
+	}
+}
+
+func TestHashToCurve(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-SM2P256_XMD:SM3_SSWU_RO_")
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"", "04fb5300d97adbb3fe56f876b4d6f73e7e28e27ef32c583bf3c9989b69b3a3335ed3efb316dfcc8df3695c9c7f319a1e8e69b4100270a77f96cc1bb90546480e1a"},
+		{"abc", "049ad637b1251f901c8fa3ba4c933a01e810d1fa8ddbb0b575379ae426e28c9b562bf16fbad7aece0c114cb50d28512cacaa2924c16b60099e9f051af83062bb17"},
+		{"abcdef0123456789", "04b138cfd81c3e3923b2aa5ad7b5b0887efded8184fff3521c4d9bee61d80aea52a3cda8ff26f6c478fb7b676b8d178ec923c507e4aff622f9e43e2b4dd1a6ea23"},
+		{strings.Repeat("a", 512), "04c8959c79f3f427cd9132be4c0187cdb7153ffcf914e80bbdb4e2e54b2a160d2912c42c8c7b71a819a8fbb1999b011420845e620b00e3ffabfd45dc430c45eb1d"},
+	}
+	for _, tt := range tests {
+		p, err := HashToCurve([]byte(tt.msg), dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(p.Bytes()); got != tt.want {
+			t.Errorf("HashToCurve(%q) = %v, want %v", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeToCurve(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-SM2P256_XMD:SM3_SSWU_NU_")
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"", "0489719ba7689b4dc15ff82e9367edd3533a1b81bfc62c8433aa3546ecf4ddc8a93f0e838ac245919287745c739b6962ff7fc8ff952ae096febaa12daaaea31533"},
+		{"abc", "042da27111fe5a8437ba77225516ac8666f7ce6d4c30c70742478bad122c701f2c68e9f2a939f8ad46508896290c3193d5b6b724247beff73db60f3397797bdf10"},
+	}
+	for _, tt := range tests {
+		p, err := EncodeToCurve([]byte(tt.msg), dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(p.Bytes()); got != tt.want {
+			t.Errorf("EncodeToCurve(%q) = %v, want %v", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestMapToCurveExceptionalCase(t *testing.T) {
+	// u = 0 makes tv1 = 0
+	p, err := mapToCurve(new(fiat.SM2P256Element))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Bytes()[0] != 4 {
+		t.Errorf("unexpected infinity")
+	}
+}
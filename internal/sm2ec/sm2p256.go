@@ -402,6 +402,10 @@ func (p *SM2P256Point) ScalarBaseMult(scalar []byte) (*SM2P256Point, error) {
 	if len(scalar) != sm2p256ElementLength {
 		return nil, errors.New("invalid scalar length")
 	}
+	if smallFootprint {
+		// avoid the large precomputed tables of the generator.
+		return p.ScalarMult(NewSM2P256Point().SetGenerator(), scalar)
+	}
 	tables := p.generatorTable()
 
 	// This is also a scalar multiplication with a four-bit window like in
@@ -15,8 +15,11 @@ import (
 	_ "embed"
 	"errors"
 	"math/bits"
+	"runtime"
 	"unsafe"
 
+	"github.com/emmansun/gmsm/internal/impl"
+
 	"golang.org/x/sys/cpu"
 )
 
@@ -306,6 +309,10 @@ var supportBMI2 = cpu.X86.HasBMI2
 
 var supportAVX2 = cpu.X86.HasAVX2
 
+func init() {
+	impl.Register("sm2", runtime.GOARCH, true, true)
+}
+
 // Montgomery multiplication. Sets res = in1 * in2 * R⁻¹ mod p.
 //
 //go:noescape
@@ -0,0 +1,9 @@
+//go:build (!amd64 && !arm64) || purego
+
+package sm2ec
+
+import "github.com/emmansun/gmsm/internal/impl"
+
+func init() {
+	impl.Register("sm2", impl.Generic, true, true)
+}
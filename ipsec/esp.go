@@ -0,0 +1,271 @@
+// Package ipsec implements the SM algorithm building blocks of GM/T 0022
+// IPsec VPNs, which are composed by IKE daemons and ESP data planes:
+//
+//   - the ESP transforms SM4-CBC with HMAC-SM3 and SM4-GCM (RFC 4106 style),
+//   - the PRF HMAC-SM3 with the IKEv2 prf+ and the key derivations of the
+//     IKE SA and of the IPsec SAs,
+//   - SM2 signatures of the IKE authentication payloads.
+//
+// Extended sequence numbers are not supported.
+package ipsec
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+	"math"
+
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm4"
+)
+
+const (
+	// SM4KeySize is the size of SM4 keys.
+	SM4KeySize = sm4.BlockSize
+	// GCMSaltSize is the size of the salt appended to the SM4-GCM key, the
+	// key material of the transform is key || salt.
+	GCMSaltSize = 4
+	// HMACSM3ICVSize is the size of the HMAC-SM3 ICV, which is not truncated.
+	HMACSM3ICVSize = sm3.Size
+
+	headerSize   = 8 // SPI and sequence number
+	gcmIVSize    = 8
+	gcmTagSize   = 16
+	trailerSize  = 2 // pad length and next header
+	minAlignment = 4
+)
+
+var (
+	// ErrAuthentication is returned by Open when the ICV is invalid.
+	ErrAuthentication = errors.New("ipsec: message authentication failed")
+	// ErrSequenceOverflow is returned by Seal when the sequence numbers of
+	// the SA are exhausted, the SA must be rekeyed.
+	ErrSequenceOverflow = errors.New("ipsec: sequence number overflow")
+	errShortPacket      = errors.New("ipsec: packet is too short")
+	errInvalidPadding   = errors.New("ipsec: invalid padding")
+)
+
+// ESP protects the packets of one direction of an IPsec SA with the
+// Encapsulating Security Payload of RFC 4303. It is not safe for concurrent use.
+type ESP struct {
+	spi uint32
+	seq uint32
+
+	// SM4-CBC with HMAC-SM3
+	block cipher.Block
+	mac   hash.Hash
+
+	// SM4-GCM
+	aead cipher.AEAD
+	salt [GCMSaltSize]byte
+}
+
+// NewESPCBC returns the SM4-CBC with HMAC-SM3 transform of the SA spi.
+func NewESPCBC(spi uint32, encKey, authKey []byte) (*ESP, error) {
+	block, err := sm4.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(authKey) == 0 {
+		return nil, errors.New("ipsec: empty integrity key")
+	}
+	return &ESP{spi: spi, block: block, mac: hmac.New(sm3.New, authKey)}, nil
+}
+
+// NewESPGCM returns the SM4-GCM transform of the SA spi, keyMaterial is the
+// 16 bytes key followed by the 4 bytes salt, as for AES-GCM in RFC 4106.
+func NewESPGCM(spi uint32, keyMaterial []byte) (*ESP, error) {
+	if len(keyMaterial) != SM4KeySize+GCMSaltSize {
+		return nil, errors.New("ipsec: invalid SM4-GCM key material length")
+	}
+	block, err := sm4.NewCipher(keyMaterial[:SM4KeySize])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	e := &ESP{spi: spi, aead: aead}
+	copy(e.salt[:], keyMaterial[SM4KeySize:])
+	return e, nil
+}
+
+// SPI returns the security parameters index of the SA.
+func (e *ESP) SPI() uint32 {
+	return e.spi
+}
+
+// Sequence returns the sequence number of the last sealed packet.
+func (e *ESP) Sequence() uint32 {
+	return e.seq
+}
+
+func (e *ESP) ivSize() int {
+	if e.aead != nil {
+		return gcmIVSize
+	}
+	return sm4.BlockSize
+}
+
+func (e *ESP) icvSize() int {
+	if e.aead != nil {
+		return gcmTagSize
+	}
+	return HMACSM3ICVSize
+}
+
+func (e *ESP) gcmNonce(iv []byte) []byte {
+	nonce := make([]byte, GCMSaltSize+gcmIVSize)
+	copy(nonce, e.salt[:])
+	copy(nonce[GCMSaltSize:], iv)
+	return nonce
+}
+
+// Seal appends the ESP packet of payload to dst: the SPI, the next sequence
+// number, the IV, the encrypted payload with its padding, pad length and
+// nextHeader, and the ICV. The IV of SM4-CBC is read from rand, the IV of
+// SM4-GCM is the sequence number and rand is not used.
+func (e *ESP) Seal(rand io.Reader, dst []byte, nextHeader byte, payload []byte) ([]byte, error) {
+	if e.seq == math.MaxUint32 {
+		return nil, ErrSequenceOverflow
+	}
+	e.seq++
+	align := minAlignment
+	if e.aead == nil {
+		align = sm4.BlockSize
+	}
+	padLen := (align - (len(payload)+trailerSize)%align) % align
+	plaintext := make([]byte, 0, len(payload)+padLen+trailerSize)
+	plaintext = append(plaintext, payload...)
+	for i := 1; i <= padLen; i++ {
+		plaintext = append(plaintext, byte(i))
+	}
+	plaintext = append(plaintext, byte(padLen), nextHeader)
+
+	start := len(dst)
+	var header [headerSize]byte
+	binary.BigEndian.PutUint32(header[:], e.spi)
+	binary.BigEndian.PutUint32(header[4:], e.seq)
+	dst = append(dst, header[:]...)
+	if e.aead != nil {
+		var iv [gcmIVSize]byte
+		binary.BigEndian.PutUint64(iv[:], uint64(e.seq))
+		dst = append(dst, iv[:]...)
+		return e.aead.Seal(dst, e.gcmNonce(iv[:]), plaintext, header[:]), nil
+	}
+
+	ret, out := sliceForAppend(dst, e.ivSize()+len(plaintext))
+	iv := out[:e.ivSize()]
+	if _, err := io.ReadFull(rand, iv); err != nil {
+		return nil, err
+	}
+	cipher.NewCBCEncrypter(e.block, iv).CryptBlocks(out[len(iv):], plaintext)
+	e.mac.Reset()
+	e.mac.Write(ret[start:])
+	return e.mac.Sum(ret), nil
+}
+
+// Open authenticates and decrypts the ESP packet and appends the payload to
+// dst. It returns the next header and the sequence number of the packet,
+// which the caller should check with a ReplayWindow.
+func (e *ESP) Open(dst, packet []byte) (payload []byte, nextHeader byte, seq uint32, err error) {
+	ivSize, icvSize := e.ivSize(), e.icvSize()
+	if len(packet) < headerSize+ivSize+icvSize+trailerSize {
+		return nil, 0, 0, errShortPacket
+	}
+	if binary.BigEndian.Uint32(packet) != e.spi {
+		return nil, 0, 0, errors.New("ipsec: unexpected SPI")
+	}
+	seq = binary.BigEndian.Uint32(packet[4:])
+	iv := packet[headerSize : headerSize+ivSize]
+	ciphertext := packet[headerSize+ivSize:]
+
+	var plaintext []byte
+	if e.aead != nil {
+		plaintext, err = e.aead.Open(nil, e.gcmNonce(iv), ciphertext, packet[:headerSize])
+		if err != nil {
+			return nil, 0, 0, ErrAuthentication
+		}
+	} else {
+		icv := ciphertext[len(ciphertext)-icvSize:]
+		ciphertext = ciphertext[:len(ciphertext)-icvSize]
+		e.mac.Reset()
+		e.mac.Write(packet[:len(packet)-icvSize])
+		if !hmac.Equal(e.mac.Sum(nil), icv) {
+			return nil, 0, 0, ErrAuthentication
+		}
+		if len(ciphertext)%sm4.BlockSize != 0 {
+			return nil, 0, 0, errInvalidPadding
+		}
+		plaintext = make([]byte, len(ciphertext))
+		cipher.NewCBCDecrypter(e.block, iv).CryptBlocks(plaintext, ciphertext)
+	}
+
+	if len(plaintext) < trailerSize {
+		return nil, 0, 0, errInvalidPadding
+	}
+	padLen := int(plaintext[len(plaintext)-2])
+	nextHeader = plaintext[len(plaintext)-1]
+	end := len(plaintext) - trailerSize - padLen
+	if end < 0 {
+		return nil, 0, 0, errInvalidPadding
+	}
+	for i, b := range plaintext[end : end+padLen] {
+		if b != byte(i+1) {
+			return nil, 0, 0, errInvalidPadding
+		}
+	}
+	return append(dst, plaintext[:end]...), nextHeader, seq, nil
+}
+
+// ReplayWindow is the 64 packets anti-replay window of RFC 4303, section 3.4.3.
+type ReplayWindow struct {
+	top    uint32
+	bitmap uint64
+}
+
+const replayWindowSize = 64
+
+// Check reports whether seq is neither too old nor already accepted.
+func (w *ReplayWindow) Check(seq uint32) bool {
+	if seq == 0 {
+		return false
+	}
+	if seq > w.top {
+		return true
+	}
+	diff := w.top - seq
+	return diff < replayWindowSize && w.bitmap&(1<<diff) == 0
+}
+
+// Accept records seq, it must be called only after the packet has been
+// authenticated and Check has returned true.
+func (w *ReplayWindow) Accept(seq uint32) {
+	if seq > w.top {
+		shift := seq - w.top
+		if shift >= replayWindowSize {
+			w.bitmap = 0
+		} else {
+			w.bitmap <<= shift
+		}
+		w.bitmap |= 1
+		w.top = seq
+		return
+	}
+	w.bitmap |= 1 << (w.top - seq)
+}
+
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
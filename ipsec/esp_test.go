@@ -0,0 +1,126 @@
+package ipsec
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm4"
+)
+
+func newTestESPs(t *testing.T) map[string][2]*ESP {
+	t.Helper()
+	encKey, authKey := make([]byte, 16), make([]byte, 32)
+	rand.Read(encKey)
+	rand.Read(authKey)
+	cbcOut, err := NewESPCBC(0x1234, encKey, authKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cbcIn, _ := NewESPCBC(0x1234, encKey, authKey)
+	km := make([]byte, 20)
+	rand.Read(km)
+	gcmOut, err := NewESPGCM(0x5678, km)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcmIn, _ := NewESPGCM(0x5678, km)
+	return map[string][2]*ESP{"cbc": {cbcOut, cbcIn}, "gcm": {gcmOut, gcmIn}}
+}
+
+func TestESP(t *testing.T) {
+	for name, p := range newTestESPs(t) {
+		out, in := p[0], p[1]
+		for n := 0; n < 40; n++ {
+			payload := bytes.Repeat([]byte{0xa5}, n)
+			packet, err := out.Seal(rand.Reader, []byte{0xff}, 4, payload)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			packet = packet[1:]
+			if binary.BigEndian.Uint32(packet) != out.SPI() || binary.BigEndian.Uint32(packet[4:]) != out.Sequence() {
+				t.Errorf("%s: invalid header %x", name, packet[:8])
+			}
+			body := len(packet) - headerSize - out.ivSize() - out.icvSize()
+			if name == "cbc" && body%16 != 0 || body%4 != 0 {
+				t.Errorf("%s: payload of %d bytes is not aligned", name, n)
+			}
+			got, nh, seq, err := in.Open(nil, packet)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if !bytes.Equal(got, payload) || nh != 4 || seq != uint32(n+1) {
+				t.Errorf("%s: got %x, %d, %d", name, got, nh, seq)
+			}
+			for _, i := range []int{0, 5, len(packet) - 1, len(packet) / 2} {
+				bad := append([]byte{}, packet...)
+				bad[i] ^= 1
+				if _, _, _, err := in.Open(nil, bad); err == nil {
+					t.Errorf("%s: expected error for modified byte %d", name, i)
+				}
+			}
+		}
+		if _, _, _, err := in.Open(nil, make([]byte, 20)); err == nil {
+			t.Errorf("%s: expected error for a short packet", name)
+		}
+		out.seq = 1<<32 - 1
+		if _, err := out.Seal(rand.Reader, nil, 4, nil); err != ErrSequenceOverflow {
+			t.Errorf("%s: got %v, want ErrSequenceOverflow", name, err)
+		}
+	}
+}
+
+// TestESPGCMFormat checks the SM4-GCM packet format of RFC 4106.
+func TestESPGCMFormat(t *testing.T) {
+	km := []byte("0123456789abcdefSALT")
+	e, _ := NewESPGCM(1, km)
+	packet, err := e.Seal(nil, nil, 41, []byte("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := sm4.NewCipher(km[:16])
+	aead, _ := cipher.NewGCM(block)
+	header := []byte{0, 0, 0, 1, 0, 0, 0, 1}
+	iv := []byte{0, 0, 0, 0, 0, 0, 0, 1}
+	want := append(append([]byte{}, header...), iv...)
+	want = aead.Seal(want, append([]byte("SALT"), iv...), []byte{'h', 'i', 0, 41}, header)
+	if !bytes.Equal(packet, want) {
+		t.Errorf("got %x, want %x", packet, want)
+	}
+}
+
+func TestESPInvalidKeys(t *testing.T) {
+	if _, err := NewESPGCM(1, make([]byte, 16)); err == nil {
+		t.Error("expected error for SM4-GCM key material without salt")
+	}
+	if _, err := NewESPCBC(1, make([]byte, 32), make([]byte, 32)); err == nil {
+		t.Error("expected error for invalid SM4 key")
+	}
+	if _, err := NewESPCBC(1, make([]byte, 16), nil); err == nil {
+		t.Error("expected error for empty integrity key")
+	}
+}
+
+func TestReplayWindow(t *testing.T) {
+	var w ReplayWindow
+	accept := func(seq uint32) bool {
+		if !w.Check(seq) {
+			return false
+		}
+		w.Accept(seq)
+		return true
+	}
+	for _, tt := range []struct {
+		seq  uint32
+		want bool
+	}{
+		{0, false}, {1, true}, {1, false}, {3, true}, {2, true}, {2, false},
+		{100, true}, {37, true}, {36, false}, {99, true}, {200, true}, {100, false},
+	} {
+		if got := accept(tt.seq); got != tt.want {
+			t.Errorf("seq %d: got %v, want %v", tt.seq, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package ipsec
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+)
+
+// PRFSize is the output size of the HMAC-SM3 PRF.
+const PRFSize = sm3.Size
+
+// maxPRFPlusSize is the maximum output of prf+, its counter is one byte.
+const maxPRFPlusSize = 255 * PRFSize
+
+// PRF returns HMAC-SM3(key, data...).
+func PRF(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(sm3.New, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+	return mac.Sum(nil)
+}
+
+// PRFPlus returns length bytes of the prf+ function of IKEv2 (RFC 7296,
+// section 2.13) with HMAC-SM3:
+//
+//	T1 = prf(K, S | 0x01), Ti = prf(K, Ti-1 | S | i)
+func PRFPlus(key, seed []byte, length int) ([]byte, error) {
+	if length < 0 || length > maxPRFPlusSize {
+		return nil, errors.New("ipsec: invalid prf+ output length")
+	}
+	mac := hmac.New(sm3.New, key)
+	out := make([]byte, 0, length+PRFSize)
+	var t []byte
+	for i := 1; len(out) < length; i++ {
+		mac.Reset()
+		mac.Write(t)
+		mac.Write(seed)
+		mac.Write([]byte{byte(i)})
+		t = mac.Sum(nil)
+		out = append(out, t...)
+	}
+	return out[:length], nil
+}
+
+// IKEKeys are the keys of an IKEv2 SA.
+type IKEKeys struct {
+	D      []byte // SK_d, derives the keys of the child SAs
+	AI, AR []byte // SK_ai, SK_ar, integrity keys of the initiator and the responder
+	EI, ER []byte // SK_ei, SK_er, encryption keys of the initiator and the responder
+	PI, PR []byte // SK_pi, SK_pr, used to build the authentication payloads
+}
+
+// DeriveIKEKeys derives the keys of an IKEv2 SA from the nonces, the shared
+// secret of the key exchange and the SPIs:
+//
+//	SKEYSEED = prf(Ni | Nr, g^ir)
+//	{SK_d | SK_ai | SK_ar | SK_ei | SK_er | SK_pi | SK_pr} = prf+(SKEYSEED, Ni | Nr | SPIi | SPIr)
+//
+// integKeyLen is 0 with SM4-GCM, encKeyLen is 20 with SM4-GCM (key and salt)
+// and 16 with SM4-CBC.
+func DeriveIKEKeys(ni, nr, sharedSecret []byte, spii, spir uint64, encKeyLen, integKeyLen int) (*IKEKeys, error) {
+	if encKeyLen < 0 || integKeyLen < 0 {
+		return nil, errors.New("ipsec: invalid key length")
+	}
+	skeyseed := PRF(append(append([]byte{}, ni...), nr...), sharedSecret)
+	seed := make([]byte, 0, len(ni)+len(nr)+16)
+	seed = append(append(seed, ni...), nr...)
+	var spis [16]byte
+	binary.BigEndian.PutUint64(spis[:], spii)
+	binary.BigEndian.PutUint64(spis[8:], spir)
+	seed = append(seed, spis[:]...)
+	km, err := PRFPlus(skeyseed, seed, 3*PRFSize+2*integKeyLen+2*encKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	k := &IKEKeys{}
+	for _, p := range []struct {
+		key *[]byte
+		n   int
+	}{
+		{&k.D, PRFSize}, {&k.AI, integKeyLen}, {&k.AR, integKeyLen},
+		{&k.EI, encKeyLen}, {&k.ER, encKeyLen}, {&k.PI, PRFSize}, {&k.PR, PRFSize},
+	} {
+		*p.key, km = km[:p.n:p.n], km[p.n:]
+	}
+	return k, nil
+}
+
+// ChildSAKeys are the keys of the two directions of a child SA, the encryption
+// key of each direction comes before the integrity key.
+type ChildSAKeys struct {
+	InitiatorToResponder []byte
+	ResponderToInitiator []byte
+}
+
+// DeriveChildSAKeys derives the keys of a child SA (RFC 7296, section 2.17):
+//
+//	KEYMAT = prf+(SK_d, [g^ir (new) |] Ni | Nr)
+//
+// sharedSecret is nil without perfect forward secrecy. Each direction gets
+// keyLen bytes, e.g. 20 with SM4-GCM and 16+32 with SM4-CBC and HMAC-SM3.
+func DeriveChildSAKeys(skd, sharedSecret, ni, nr []byte, keyLen int) (*ChildSAKeys, error) {
+	if keyLen < 0 {
+		return nil, errors.New("ipsec: invalid key length")
+	}
+	seed := make([]byte, 0, len(sharedSecret)+len(ni)+len(nr))
+	seed = append(append(append(seed, sharedSecret...), ni...), nr...)
+	km, err := PRFPlus(skd, seed, 2*keyLen)
+	if err != nil {
+		return nil, err
+	}
+	return &ChildSAKeys{km[:keyLen:keyLen], km[keyLen:]}, nil
+}
+
+// Phase1Keys are the keying materials of an ISAKMP SA of GM/T 0022, whose
+// phase 1 exchanges the nonces in SM2 digital envelopes.
+type Phase1Keys struct {
+	SKEYID  []byte
+	SKEYIDd []byte // derives the keys of the IPsec SAs
+	SKEYIDa []byte // authenticates the ISAKMP messages
+	SKEYIDe []byte // encrypts the ISAKMP messages
+}
+
+// DerivePhase1Keys derives the keying materials of an ISAKMP SA from the
+// nonces and the cookies of the initiator and the responder:
+//
+//	SKEYID   = prf(HASH(Ni_b | Nr_b), CKY-I | CKY-R)
+//	SKEYID_d = prf(SKEYID, CKY-I | CKY-R | 0)
+//	SKEYID_a = prf(SKEYID, SKEYID_d | CKY-I | CKY-R | 1)
+//	SKEYID_e = prf(SKEYID, SKEYID_a | CKY-I | CKY-R | 2)
+func DerivePhase1Keys(ni, nr []byte, ckyI, ckyR uint64) *Phase1Keys {
+	h := sm3.New()
+	h.Write(ni)
+	h.Write(nr)
+	var cookies [16]byte
+	binary.BigEndian.PutUint64(cookies[:], ckyI)
+	binary.BigEndian.PutUint64(cookies[8:], ckyR)
+
+	k := &Phase1Keys{SKEYID: PRF(h.Sum(nil), cookies[:])}
+	k.SKEYIDd = PRF(k.SKEYID, cookies[:], []byte{0})
+	k.SKEYIDa = PRF(k.SKEYID, k.SKEYIDd, cookies[:], []byte{1})
+	k.SKEYIDe = PRF(k.SKEYID, k.SKEYIDa, cookies[:], []byte{2})
+	return k
+}
+
+// DeriveKeyMaterial derives length bytes of the keying material of the
+// inbound or outbound IPsec SA spi in quick mode:
+//
+//	K1 = prf(SKEYID_d, protocol | SPI | Ni_b | Nr_b)
+//	Ki = prf(SKEYID_d, Ki-1 | protocol | SPI | Ni_b | Nr_b)
+//
+// protocol is the IPsec protocol id, 3 for ESP.
+func DeriveKeyMaterial(skeyidD []byte, protocol byte, spi uint32, ni, nr []byte, length int) []byte {
+	seed := make([]byte, 5, 5+len(ni)+len(nr))
+	seed[0] = protocol
+	binary.BigEndian.PutUint32(seed[1:], spi)
+	seed = append(append(seed, ni...), nr...)
+	out := make([]byte, 0, length+PRFSize)
+	var k []byte
+	for len(out) < length {
+		k = PRF(skeyidD, k, seed)
+		out = append(out, k...)
+	}
+	return out[:length]
+}
+
+// SignAuth signs the authentication data of an IKE peer, e.g. the signed
+// octets of IKEv2 or HASH_I / HASH_R of GM/T 0022, with SM2 and the default
+// user id. The signature is ASN.1 encoded.
+func SignAuth(rand io.Reader, priv *sm2.PrivateKey, octets []byte) ([]byte, error) {
+	return priv.Sign(rand, octets, sm2.DefaultSM2SignerOpts)
+}
+
+// VerifyAuth verifies a signature created by SignAuth.
+func VerifyAuth(pub *ecdsa.PublicKey, octets, sig []byte) bool {
+	return sm2.VerifyASN1WithSM2(pub, nil, octets, sig)
+}
+
+// SignedOctets returns the octets signed by an IKEv2 peer (RFC 7296, section
+// 2.15): its first message, the nonce of the peer and prf(SK_p, IDPayload),
+// where skp is SK_pi of the initiator or SK_pr of the responder and idPayload
+// is the body of its identification payload.
+func SignedOctets(message, peerNonce, skp, idPayload []byte) []byte {
+	out := make([]byte, 0, len(message)+len(peerNonce)+PRFSize)
+	out = append(append(out, message...), peerNonce...)
+	return append(out, PRF(skp, idPayload)...)
+}
@@ -0,0 +1,98 @@
+package ipsec
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm2"
+)
+
+func TestPRFPlus(t *testing.T) {
+	key, seed := []byte("key"), []byte("seed")
+	out, err := PRFPlus(key, seed, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t1 := PRF(key, seed, []byte{1})
+	t2 := PRF(key, t1, seed, []byte{2})
+	if !bytes.Equal(out[:32], t1) || !bytes.Equal(out[32:64], t2) {
+		t.Errorf("unexpected prf+ output %x", out)
+	}
+	short, _ := PRFPlus(key, seed, 10)
+	if !bytes.Equal(short, out[:10]) {
+		t.Errorf("got %x, want %x", short, out[:10])
+	}
+	if _, err := PRFPlus(key, seed, 255*32+1); err == nil {
+		t.Error("expected error for too long output")
+	}
+}
+
+func TestDeriveIKEKeys(t *testing.T) {
+	ni, nr, secret := []byte("initiator nonce"), []byte("responder nonce"), []byte("shared secret")
+	k, err := DeriveIKEKeys(ni, nr, secret, 1, 2, 20, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skeyseed := PRF(append(append([]byte{}, ni...), nr...), secret)
+	km, _ := PRFPlus(skeyseed, append(append(append([]byte{}, ni...), nr...), 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2), 32+40+64)
+	got := bytes.Join([][]byte{k.D, k.AI, k.AR, k.EI, k.ER, k.PI, k.PR}, nil)
+	if !bytes.Equal(got, km) || len(k.EI) != 20 || len(k.AI) != 0 {
+		t.Errorf("unexpected keys %+v", k)
+	}
+
+	child, err := DeriveChildSAKeys(k.D, nil, ni, nr, 48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(child.InitiatorToResponder) != 48 || len(child.ResponderToInitiator) != 48 ||
+		bytes.Equal(child.InitiatorToResponder, child.ResponderToInitiator) {
+		t.Errorf("unexpected child SA keys %+v", child)
+	}
+	out, err := NewESPCBC(1, child.InitiatorToResponder[:16], child.InitiatorToResponder[16:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, _ := NewESPCBC(1, child.InitiatorToResponder[:16], child.InitiatorToResponder[16:])
+	packet, _ := out.Seal(rand.Reader, nil, 4, []byte("payload"))
+	if _, _, _, err := in.Open(nil, packet); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDerivePhase1Keys(t *testing.T) {
+	k := DerivePhase1Keys([]byte("ni"), []byte("nr"), 1, 2)
+	for _, key := range [][]byte{k.SKEYID, k.SKEYIDd, k.SKEYIDa, k.SKEYIDe} {
+		if len(key) != PRFSize {
+			t.Fatalf("unexpected key length %d", len(key))
+		}
+	}
+	if bytes.Equal(k.SKEYIDa, k.SKEYIDe) {
+		t.Error("SKEYID_a and SKEYID_e are equal")
+	}
+	km := DeriveKeyMaterial(k.SKEYIDd, 3, 0x1234, []byte("ni"), []byte("nr"), 48)
+	k1 := PRF(k.SKEYIDd, []byte{3, 0, 0, 0x12, 0x34}, []byte("ni"), []byte("nr"))
+	k2 := PRF(k.SKEYIDd, k1, []byte{3, 0, 0, 0x12, 0x34}, []byte("ni"), []byte("nr"))
+	if !bytes.Equal(km, append(k1, k2...)[:48]) {
+		t.Errorf("unexpected key material %x", km)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	octets := SignedOctets([]byte("IKE_SA_INIT message"), []byte("nonce"), []byte("SK_pi"), []byte("id payload"))
+	sig, err := SignAuth(rand.Reader, priv, octets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyAuth(&priv.PublicKey, octets, sig) {
+		t.Error("invalid signature")
+	}
+	octets[0] ^= 1
+	if VerifyAuth(&priv.PublicKey, octets, sig) {
+		t.Error("signature of modified octets is valid")
+	}
+}
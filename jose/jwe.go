@@ -0,0 +1,406 @@
+// Package jose implements the JSON Web Encryption (RFC 7516) compact
+// serialization with the ShangMi algorithms, so that encrypted tokens can be
+// produced and consumed without any non-SM primitive.
+//
+// There are no IANA registered JOSE identifiers for the SM algorithms yet, the
+// identifiers follow the style of RFC 7518 and are the ones of the registry
+// package:
+//
+//   - "SM4GCM": content encryption with SM4-GCM, 128-bit key, 96-bit IV and
+//     128-bit tag, like "A128GCM".
+//   - "dir": direct use of a shared 128-bit SM4 key as the content key.
+//   - "SM4KW": RFC 3394 key wrap of the content key with a shared 128-bit SM4
+//     key, like "A128KW".
+//   - "ECDH-SM2": ephemeral-static ECDH on the SM2 curve, the content key is
+//     derived with the Concat KDF of RFC 7518 section 4.6 with SM3, like
+//     "ECDH-ES".
+//   - "ECDH-SM2+SM4KW": the same, the derived key wraps the content key with
+//     "SM4KW", like "ECDH-ES+A128KW".
+//
+// SigningMethodSM2 signs and verifies JWS and JWT with "SM2SM3", SM2 with SM3,
+// as a signing method of github.com/golang-jwt/jwt.
+package jose
+
+import (
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	smcipher "github.com/emmansun/gmsm/cipher"
+	"github.com/emmansun/gmsm/ecdh"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm4"
+)
+
+// Key management algorithms, the "alg" header parameter.
+const (
+	Direct       = "dir"
+	SM4KW        = "SM4KW"
+	ECDHSM2      = "ECDH-SM2"
+	ECDHSM2SM4KW = "ECDH-SM2+SM4KW"
+)
+
+// Content encryption algorithms, the "enc" header parameter.
+const (
+	SM4GCM = "SM4GCM"
+)
+
+const (
+	keySize = sm4.BlockSize
+	ivSize  = 12
+	tagSize = 16
+
+	// jwkCurveSM2 is the "crv" of SM2 keys, the same as the keyconv package.
+	jwkCurveSM2 = "SM2"
+)
+
+// Header is the JOSE header of a JWE. It is integrity protected, the EphemeralKey
+// is set by Encrypt for the ECDH algorithms.
+type Header struct {
+	Algorithm    string        `json:"alg"`
+	Encryption   string        `json:"enc"`
+	KeyID        string        `json:"kid,omitempty"`
+	Type         string        `json:"typ,omitempty"`
+	ContentType  string        `json:"cty,omitempty"`
+	EphemeralKey *EphemeralKey `json:"epk,omitempty"`
+	// PartyUInfo and PartyVInfo are the base64url encoded "apu" and "apv"
+	// of the ECDH algorithms, optional.
+	PartyUInfo string `json:"apu,omitempty"`
+	PartyVInfo string `json:"apv,omitempty"`
+}
+
+// EphemeralKey is the ephemeral SM2 public key of the ECDH algorithms, as a
+// JSON Web Key.
+type EphemeralKey struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+var b64 = base64.RawURLEncoding
+
+var (
+	errUnsupportedAlg = errors.New("jose: unsupported key management algorithm")
+	errUnsupportedEnc = errors.New("jose: unsupported content encryption algorithm")
+	errMalformed      = errors.New("jose: malformed compact JWE")
+	errUnexpectedAlg  = errors.New("jose: unexpected algorithms in the protected header")
+	errDecryption     = errors.New("jose: decryption error")
+)
+
+// Encrypt encrypts plaintext and returns the compact serialization of the JWE.
+// The Algorithm and Encryption of header select the algorithms, the other
+// parameters are copied as is. The key is a 16 bytes []byte for "dir" and
+// "SM4KW", the recipient SM2 *ecdsa.PublicKey for "ECDH-SM2" and
+// "ECDH-SM2+SM4KW". Keys held by a hardware token can be used as a SM4-GCM
+// cipher.AEAD for "dir" and as a cipher.KeyWrapper of this module for "SM4KW".
+//
+// Most applications should use [crypto/rand.Reader] as rand.
+func Encrypt(rand io.Reader, header *Header, key any, plaintext []byte) (string, error) {
+	h := *header
+	if h.Encryption != SM4GCM {
+		return "", errUnsupportedEnc
+	}
+	h.EphemeralKey = nil
+	var cek, encryptedKey []byte
+	var aead cipher.AEAD
+	switch h.Algorithm {
+	case Direct:
+		var err error
+		if aead, err = directAEAD(key); err != nil {
+			return "", err
+		}
+	case SM4KW:
+		w, err := keyWrapper(h.Algorithm, key)
+		if err != nil {
+			return "", err
+		}
+		if cek, encryptedKey, err = wrapNewKey(rand, w); err != nil {
+			return "", err
+		}
+	case ECDHSM2, ECDHSM2SM4KW:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || !sm2.IsSM2PublicKey(pub) {
+			return "", invalidKey(h.Algorithm, key)
+		}
+		remote, err := sm2.PublicKeyToECDH(pub)
+		if err != nil {
+			return "", err
+		}
+		ephemeral, err := ecdh.P256().GenerateKey(rand)
+		if err != nil {
+			return "", err
+		}
+		z, err := ephemeral.ECDH(remote)
+		if err != nil {
+			return "", err
+		}
+		point := ephemeral.PublicKey().Bytes()
+		h.EphemeralKey = &EphemeralKey{Kty: "EC", Crv: jwkCurveSM2, X: b64.EncodeToString(point[1:33]), Y: b64.EncodeToString(point[33:])}
+		derived, err := deriveKey(z, &h)
+		if err != nil {
+			return "", err
+		}
+		if h.Algorithm == ECDHSM2 {
+			cek = derived
+		} else if cek, encryptedKey, err = wrapNewKey(rand, derivedKeyWrapper(derived)); err != nil {
+			return "", err
+		}
+	default:
+		return "", errUnsupportedAlg
+	}
+
+	headerJSON, err := json.Marshal(&h)
+	if err != nil {
+		return "", err
+	}
+	protected := b64.EncodeToString(headerJSON)
+	if aead == nil {
+		if aead, err = newAEAD(cek); err != nil {
+			return "", err
+		}
+	}
+	iv := make([]byte, ivSize)
+	if _, err = io.ReadFull(rand, iv); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nil, iv, plaintext, []byte(protected))
+	ciphertext, tag := sealed[:len(plaintext)], sealed[len(plaintext):]
+	return strings.Join([]string{
+		protected,
+		b64.EncodeToString(encryptedKey),
+		b64.EncodeToString(iv),
+		b64.EncodeToString(ciphertext),
+		b64.EncodeToString(tag),
+	}, "."), nil
+}
+
+// Decrypt decrypts the compact serialization of a JWE and returns the plaintext
+// and the protected header. The key is a 16 bytes []byte for "dir" and "SM4KW",
+// the recipient *sm2.PrivateKey for "ECDH-SM2" and "ECDH-SM2+SM4KW", or a
+// cipher.AEAD for "dir" and a cipher.KeyWrapper for "SM4KW" as in Encrypt.
+//
+// alg and enc are the algorithms the key is meant for, the token is rejected
+// if its header names others, so that a sender can't choose how the key is
+// used.
+func Decrypt(token, alg, enc string, key any) ([]byte, *Header, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return nil, nil, errMalformed
+	}
+	var decoded [5][]byte
+	for i, part := range parts {
+		var err error
+		if decoded[i], err = b64.DecodeString(part); err != nil {
+			return nil, nil, errMalformed
+		}
+	}
+	h := new(Header)
+	if err := json.Unmarshal(decoded[0], h); err != nil {
+		return nil, nil, fmt.Errorf("jose: invalid protected header: %w", err)
+	}
+	if h.Algorithm != alg || h.Encryption != enc {
+		return nil, nil, errUnexpectedAlg
+	}
+	if h.Encryption != SM4GCM {
+		return nil, nil, errUnsupportedEnc
+	}
+	encryptedKey := decoded[1]
+
+	var cek []byte
+	var aead cipher.AEAD
+	switch h.Algorithm {
+	case Direct:
+		if len(encryptedKey) != 0 {
+			return nil, nil, errMalformed
+		}
+		var err error
+		if aead, err = directAEAD(key); err != nil {
+			return nil, nil, err
+		}
+	case SM4KW:
+		w, err := keyWrapper(h.Algorithm, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cek, err = unwrapKey(w, encryptedKey); err != nil {
+			return nil, nil, err
+		}
+	case ECDHSM2, ECDHSM2SM4KW:
+		priv, ok := key.(*sm2.PrivateKey)
+		if !ok {
+			return nil, nil, invalidKey(h.Algorithm, key)
+		}
+		local, err := priv.ECDH()
+		if err != nil {
+			return nil, nil, err
+		}
+		remote, err := ephemeralKey(h.EphemeralKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		z, err := local.ECDH(remote)
+		if err != nil {
+			return nil, nil, err
+		}
+		derived, err := deriveKey(z, h)
+		if err != nil {
+			return nil, nil, err
+		}
+		if h.Algorithm == ECDHSM2 {
+			if len(encryptedKey) != 0 {
+				return nil, nil, errMalformed
+			}
+			cek = derived
+		} else if cek, err = unwrapKey(derivedKeyWrapper(derived), encryptedKey); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, errUnsupportedAlg
+	}
+
+	iv, ciphertext, tag := decoded[2], decoded[3], decoded[4]
+	if len(iv) != ivSize || len(tag) != tagSize {
+		return nil, nil, errMalformed
+	}
+	if aead == nil {
+		var err error
+		if aead, err = newAEAD(cek); err != nil {
+			return nil, nil, err
+		}
+	}
+	sealed := make([]byte, 0, len(ciphertext)+len(tag))
+	sealed = append(sealed, ciphertext...)
+	sealed = append(sealed, tag...)
+	plaintext, err := aead.Open(nil, iv, sealed, []byte(parts[0]))
+	if err != nil {
+		return nil, nil, errDecryption
+	}
+	return plaintext, h, nil
+}
+
+func invalidKey(alg string, key any) error {
+	return fmt.Errorf("jose: invalid key %T for algorithm %q", key, alg)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// directAEAD returns the content encryption AEAD of a "dir" key.
+func directAEAD(key any) (cipher.AEAD, error) {
+	switch k := key.(type) {
+	case []byte:
+		if len(k) == keySize {
+			return newAEAD(k)
+		}
+	case cipher.AEAD:
+		if k.NonceSize() == ivSize && k.Overhead() == tagSize {
+			return k, nil
+		}
+	}
+	return nil, invalidKey(Direct, key)
+}
+
+// keyWrapper returns the key encryption key of a "SM4KW" key.
+func keyWrapper(alg string, key any) (smcipher.KeyWrapper, error) {
+	switch k := key.(type) {
+	case []byte:
+		if len(k) == keySize {
+			return derivedKeyWrapper(k), nil
+		}
+	case smcipher.KeyWrapper:
+		return k, nil
+	}
+	return nil, invalidKey(alg, key)
+}
+
+// derivedKeyWrapper returns the SM4 key wrapper of a 16 bytes key.
+func derivedKeyWrapper(kek []byte) smcipher.KeyWrapper {
+	block, err := sm4.NewCipher(kek)
+	if err != nil {
+		panic("jose: internal error: " + err.Error())
+	}
+	w, _ := smcipher.NewKeyWrapper(block)
+	return w
+}
+
+// wrapNewKey generates a content key and wraps it with kek.
+func wrapNewKey(rand io.Reader, kek smcipher.KeyWrapper) (cek, encryptedKey []byte, err error) {
+	cek = make([]byte, keySize)
+	if _, err = io.ReadFull(rand, cek); err != nil {
+		return nil, nil, err
+	}
+	if encryptedKey, err = kek.Wrap(cek); err != nil {
+		return nil, nil, err
+	}
+	return cek, encryptedKey, nil
+}
+
+func unwrapKey(kek smcipher.KeyWrapper, encryptedKey []byte) ([]byte, error) {
+	cek, err := kek.Unwrap(encryptedKey)
+	if err != nil || len(cek) != keySize {
+		return nil, errDecryption
+	}
+	return cek, nil
+}
+
+func ephemeralKey(epk *EphemeralKey) (*ecdh.PublicKey, error) {
+	if epk == nil || epk.Kty != "EC" || epk.Crv != jwkCurveSM2 {
+		return nil, errors.New("jose: missing or invalid ephemeral key")
+	}
+	x, err := b64.DecodeString(epk.X)
+	if err != nil || len(x) != 32 {
+		return nil, errors.New("jose: invalid ephemeral key coordinate")
+	}
+	y, err := b64.DecodeString(epk.Y)
+	if err != nil || len(y) != 32 {
+		return nil, errors.New("jose: invalid ephemeral key coordinate")
+	}
+	point := make([]byte, 0, 65)
+	point = append(point, 4)
+	point = append(point, x...)
+	point = append(point, y...)
+	return ecdh.P256().NewPublicKey(point)
+}
+
+// deriveKey is the Concat KDF of RFC 7518 section 4.6.2 with SM3. The
+// AlgorithmID is the "enc" for direct key agreement, else the "alg".
+func deriveKey(z []byte, h *Header) ([]byte, error) {
+	algID := h.Algorithm
+	if algID == ECDHSM2 {
+		algID = h.Encryption
+	}
+	apu, err := b64.DecodeString(h.PartyUInfo)
+	if err != nil {
+		return nil, errors.New("jose: invalid apu header parameter")
+	}
+	apv, err := b64.DecodeString(h.PartyVInfo)
+	if err != nil {
+		return nil, errors.New("jose: invalid apv header parameter")
+	}
+	md := sm3.New()
+	md.Write([]byte{0, 0, 0, 1}) // one round is enough for a 128-bit key
+	md.Write(z)
+	for _, info := range [][]byte{[]byte(algID), apu, apv} {
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(info)))
+		md.Write(length[:])
+		md.Write(info)
+	}
+	var keyDataLen [4]byte
+	binary.BigEndian.PutUint32(keyDataLen[:], keySize*8)
+	md.Write(keyDataLen[:])
+	return md.Sum(nil)[:keySize], nil
+}
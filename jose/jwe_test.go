@@ -0,0 +1,132 @@
+package jose
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm2"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	plaintext := []byte(`{"sub":"alice","scope":"payments"}`)
+	tests := []struct {
+		alg        string
+		encryptKey any
+		decryptKey any
+		keyLen     int
+	}{
+		{Direct, shared, shared, 0},
+		{SM4KW, shared, shared, 24},
+		{ECDHSM2, &priv.PublicKey, priv, 0},
+		{ECDHSM2SM4KW, &priv.PublicKey, priv, 24},
+	}
+	for _, tt := range tests {
+		header := &Header{Algorithm: tt.alg, Encryption: SM4GCM, KeyID: "k1", PartyUInfo: "QWxpY2U", PartyVInfo: "Qm9i"}
+		token, err := Encrypt(rand.Reader, header, tt.encryptKey, plaintext)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.alg, err)
+		}
+		parts := strings.Split(token, ".")
+		if len(parts) != 5 {
+			t.Fatalf("%s: got %d parts", tt.alg, len(parts))
+		}
+		if encryptedKey, _ := b64.DecodeString(parts[1]); len(encryptedKey) != tt.keyLen {
+			t.Errorf("%s: got encrypted key of %d bytes, want %d", tt.alg, len(encryptedKey), tt.keyLen)
+		}
+		got, h, err := Decrypt(token, tt.alg, SM4GCM, tt.decryptKey)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.alg, err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("%s: got %q, want %q", tt.alg, got, plaintext)
+		}
+		if h.Algorithm != tt.alg || h.Encryption != SM4GCM || h.KeyID != "k1" {
+			t.Errorf("%s: unexpected header %+v", tt.alg, h)
+		}
+		if (h.EphemeralKey != nil) != (tt.alg == ECDHSM2 || tt.alg == ECDHSM2SM4KW) {
+			t.Errorf("%s: unexpected ephemeral key %+v", tt.alg, h.EphemeralKey)
+		}
+
+		// the protected header is authenticated
+		tampered := strings.Replace(token, parts[0], b64.EncodeToString([]byte(strings.Replace(string(mustDecode(t, parts[0])), `"k1"`, `"k2"`, 1))), 1)
+		if _, _, err := Decrypt(tampered, tt.alg, SM4GCM, tt.decryptKey); err == nil {
+			t.Errorf("%s: tampered header accepted", tt.alg)
+		}
+	}
+}
+
+func mustDecode(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := b64.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDecryptWrongKey(t *testing.T) {
+	priv, _ := sm2.GenerateKey(rand.Reader)
+	other, _ := sm2.GenerateKey(rand.Reader)
+	token, err := Encrypt(rand.Reader, &Header{Algorithm: ECDHSM2SM4KW, Encryption: SM4GCM}, &priv.PublicKey, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Decrypt(token, ECDHSM2SM4KW, SM4GCM, other); err == nil {
+		t.Error("expected decryption error with another key")
+	}
+	if _, _, err := Decrypt(token, ECDHSM2SM4KW, SM4GCM, make([]byte, 16)); err == nil {
+		t.Error("expected error with a key of the wrong type")
+	}
+
+	token, err = Encrypt(rand.Reader, &Header{Algorithm: SM4KW, Encryption: SM4GCM}, make([]byte, 16), []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongKey := make([]byte, 16)
+	wrongKey[0] = 1
+	if _, _, err := Decrypt(token, SM4KW, SM4GCM, wrongKey); err == nil {
+		t.Error("expected decryption error with another key")
+	}
+}
+
+func TestDecryptUnexpectedAlgorithm(t *testing.T) {
+	key := make([]byte, 16)
+	token, err := Encrypt(rand.Reader, &Header{Algorithm: Direct, Encryption: SM4GCM}, key, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Decrypt(token, Direct, SM4GCM, key); err != nil {
+		t.Fatal(err)
+	}
+	// the same key must not be used with the algorithm chosen by the sender
+	if _, _, err := Decrypt(token, SM4KW, SM4GCM, key); err != errUnexpectedAlg {
+		t.Errorf("got %v, want %v", err, errUnexpectedAlg)
+	}
+	if _, _, err := Decrypt(token, Direct, "A128GCM", key); err != errUnexpectedAlg {
+		t.Errorf("got %v, want %v", err, errUnexpectedAlg)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	key := make([]byte, 16)
+	if _, err := Encrypt(rand.Reader, &Header{Algorithm: "A128KW", Encryption: SM4GCM}, key, nil); err == nil {
+		t.Error("expected unsupported alg error")
+	}
+	if _, err := Encrypt(rand.Reader, &Header{Algorithm: Direct, Encryption: "A128GCM"}, key, nil); err == nil {
+		t.Error("expected unsupported enc error")
+	}
+	if _, err := Encrypt(rand.Reader, &Header{Algorithm: Direct, Encryption: SM4GCM}, key[:8], nil); err == nil {
+		t.Error("expected invalid key error")
+	}
+	if _, _, err := Decrypt("a.b.c", Direct, SM4GCM, key); err == nil {
+		t.Error("expected malformed error")
+	}
+}
@@ -0,0 +1,127 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"encoding/pem"
+	"errors"
+
+	"github.com/emmansun/gmsm/codec"
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/smx509"
+)
+
+// SM2SM3 is the "alg" of SM2 signatures with SM3, the same as the registry
+// package.
+const SM2SM3 = "SM2SM3"
+
+// ErrSignatureInvalid is returned by SigningMethodSM2.Verify when the
+// signature doesn't verify.
+var ErrSignatureInvalid = errors.New("jose: signature is invalid")
+
+// SigningMethodSM2 is the SM2 signing method of JWS and JWT. It implements
+// the SigningMethod interface of github.com/golang-jwt/jwt/v5, without
+// depending on it, and is registered with
+//
+//	jwt.RegisterSigningMethod(jose.SigningMethodSM2SM3.Alg(), func() jwt.SigningMethod {
+//		return jose.SigningMethodSM2SM3
+//	})
+//
+// The signing input is hashed with SM3 and the Z value of UID (GB/T 32918.2),
+// the signature is the 64 bytes r || s like ES256, or ASN.1 DER when ASN1 is
+// true for peers which send the encoding of GM/T 0009.
+type SigningMethodSM2 struct {
+	Name string
+	// UID is the user id of the Z value, the default one of GM/T 0009 if nil.
+	UID  []byte
+	ASN1 bool
+}
+
+var (
+	// SigningMethodSM2SM3 is "SM2SM3" with the default user id and r || s signatures.
+	SigningMethodSM2SM3 = &SigningMethodSM2{Name: SM2SM3}
+	// SigningMethodSM2SM3ASN1 is "SM2SM3" with the default user id and ASN.1
+	// signatures.
+	SigningMethodSM2SM3ASN1 = &SigningMethodSM2{Name: SM2SM3, ASN1: true}
+)
+
+// Alg returns the "alg" header parameter.
+func (m *SigningMethodSM2) Alg() string {
+	return m.Name
+}
+
+// Sign signs signingString with key, a *sm2.PrivateKey or a crypto.Signer of
+// an SM2 key, e.g. of the kms package.
+func (m *SigningMethodSM2) Sign(signingString string, key any) ([]byte, error) {
+	signer, ok := key.(crypto.Signer)
+	if !ok || !sm2.IsSM2PublicKey(signer.Public()) {
+		return nil, invalidKey(m.Name, key)
+	}
+	sig, err := signer.Sign(randutil.Reader(), []byte(signingString), sm2.NewSM2SignerOption(true, m.UID))
+	if err != nil {
+		return nil, err
+	}
+	if m.ASN1 {
+		return sig, nil
+	}
+	return codec.SignatureASN1ToRS(sig)
+}
+
+// Verify verifies the signature sig of signingString with key, an SM2
+// *ecdsa.PublicKey.
+func (m *SigningMethodSM2) Verify(signingString string, sig []byte, key any) error {
+	pub, ok := key.(*ecdsa.PublicKey)
+	if !ok || !sm2.IsSM2PublicKey(pub) {
+		return invalidKey(m.Name, key)
+	}
+	if !m.ASN1 {
+		var err error
+		if sig, err = codec.SignatureRSToASN1(sig); err != nil {
+			return ErrSignatureInvalid
+		}
+	}
+	if !sm2.VerifyASN1WithSM2(pub, m.UID, []byte(signingString), sig) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// ParseSM2PrivateKeyFromPEM parses a PEM encoded PKCS #8 or SEC 1 SM2
+// private key, like jwt.ParseECPrivateKeyFromPEM.
+func ParseSM2PrivateKeyFromPEM(data []byte) (*sm2.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("jose: key must be PEM encoded")
+	}
+	if key, err := smx509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		priv, ok := key.(*sm2.PrivateKey)
+		if !ok {
+			return nil, errors.New("jose: not an SM2 private key")
+		}
+		return priv, nil
+	}
+	return smx509.ParseSM2PrivateKey(block.Bytes)
+}
+
+// ParseSM2PublicKeyFromPEM parses a PEM encoded PKIX SM2 public key or the
+// public key of a certificate, like jwt.ParseECPublicKeyFromPEM.
+func ParseSM2PublicKeyFromPEM(data []byte) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("jose: key must be PEM encoded")
+	}
+	key, err := smx509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		cert, certErr := smx509.ParseCertificate(block.Bytes)
+		if certErr != nil {
+			return nil, err
+		}
+		key = cert.PublicKey
+	}
+	pub, ok := key.(*ecdsa.PublicKey)
+	if !ok || !sm2.IsSM2PublicKey(pub) {
+		return nil, errors.New("jose: not an SM2 public key")
+	}
+	return pub, nil
+}
@@ -0,0 +1,93 @@
+package jose
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/pem"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/smx509"
+)
+
+func TestSigningMethodSM2(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := sm2.GenerateKey(rand.Reader)
+	signingString := b64.EncodeToString([]byte(`{"alg":"SM2SM3","typ":"JWT"}`)) + "." + b64.EncodeToString([]byte(`{"sub":"alice"}`))
+	uidMethod := &SigningMethodSM2{Name: SM2SM3, UID: []byte("alice@example.com")}
+	for _, m := range []*SigningMethodSM2{SigningMethodSM2SM3, SigningMethodSM2SM3ASN1, uidMethod} {
+		if m.Alg() != SM2SM3 {
+			t.Errorf("unexpected alg %s", m.Alg())
+		}
+		sig, err := m.Sign(signingString, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !m.ASN1 && len(sig) != 64 {
+			t.Errorf("got signature of %d bytes, want 64", len(sig))
+		}
+		if err := m.Verify(signingString, sig, &priv.PublicKey); err != nil {
+			t.Errorf("asn1 %v: %v", m.ASN1, err)
+		}
+		if err := m.Verify(signingString+"x", sig, &priv.PublicKey); err != ErrSignatureInvalid {
+			t.Errorf("asn1 %v: got %v, want ErrSignatureInvalid", m.ASN1, err)
+		}
+		if err := m.Verify(signingString, sig, &other.PublicKey); err != ErrSignatureInvalid {
+			t.Errorf("asn1 %v: got %v, want ErrSignatureInvalid", m.ASN1, err)
+		}
+	}
+
+	// the encoding and the user id must match.
+	sig, _ := SigningMethodSM2SM3ASN1.Sign(signingString, priv)
+	if err := SigningMethodSM2SM3.Verify(signingString, sig, &priv.PublicKey); err == nil {
+		t.Error("ASN.1 signature accepted as r || s")
+	}
+	sig, _ = uidMethod.Sign(signingString, priv)
+	if err := SigningMethodSM2SM3.Verify(signingString, sig, &priv.PublicKey); err == nil {
+		t.Error("signature with another user id accepted")
+	}
+
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if _, err := SigningMethodSM2SM3.Sign(signingString, p256); err == nil {
+		t.Error("expected error for a P-256 key")
+	}
+	if err := SigningMethodSM2SM3.Verify(signingString, sig, &p256.PublicKey); err == nil || err == ErrSignatureInvalid {
+		t.Errorf("got %v, want an invalid key error", err)
+	}
+}
+
+func TestParseSM2KeysFromPEM(t *testing.T) {
+	priv, _ := sm2.GenerateKey(rand.Reader)
+	pkcs8, _ := smx509.MarshalPKCS8PrivateKey(priv)
+	sec1, _ := smx509.MarshalSM2PrivateKey(priv)
+	for _, block := range []*pem.Block{{Type: "PRIVATE KEY", Bytes: pkcs8}, {Type: "EC PRIVATE KEY", Bytes: sec1}} {
+		got, err := ParseSM2PrivateKeyFromPEM(pem.EncodeToMemory(block))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(priv) {
+			t.Errorf("%s: private key mismatch", block.Type)
+		}
+	}
+	spki, _ := smx509.MarshalPKIXPublicKey(&priv.PublicKey)
+	pub, err := ParseSM2PublicKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: spki}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(&priv.PublicKey) {
+		t.Error("public key mismatch")
+	}
+
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	spki, _ = smx509.MarshalPKIXPublicKey(&p256.PublicKey)
+	if _, err := ParseSM2PublicKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: spki})); err == nil {
+		t.Error("expected error for a P-256 key")
+	}
+	if _, err := ParseSM2PrivateKeyFromPEM([]byte("not pem")); err == nil {
+		t.Error("expected error for non PEM data")
+	}
+}
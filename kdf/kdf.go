@@ -1,4 +1,6 @@
 // Package kdf implements ShangMi(SM) used Key Derivation Function, compliances with GB/T 32918.4-2016 5.4.3.
+// It also implements the NIST SP 800-56C key derivation methods and the scrypt memory-hard function
+// which can be parameterized with SM3.
 package kdf
 
 import (
@@ -9,6 +11,12 @@ import (
 // Kdf key derivation function, compliance with GB/T 32918.4-2016 5.4.3.
 // ANSI-X9.63-KDF
 func Kdf(md hash.Hash, z []byte, len int) []byte {
+	return X963Kdf(md, z, nil, len)
+}
+
+// X963Kdf key derivation function with SharedInfo, compliance with ANSI X9.63 and SEC 1 3.6.1.
+// K(i) = Hash(Z || counter || SharedInfo)
+func X963Kdf(md hash.Hash, z, sharedInfo []byte, len int) []byte {
 	limit := uint64(len+md.Size()-1) / uint64(md.Size())
 	if limit >= uint64(1<<32)-1 {
 		panic("kdf: key length too long")
@@ -20,6 +28,7 @@ func Kdf(md hash.Hash, z []byte, len int) []byte {
 		binary.BigEndian.PutUint32(countBytes[:], ct)
 		md.Write(z)
 		md.Write(countBytes[:])
+		md.Write(sharedInfo)
 		k = md.Sum(k)
 		ct++
 		md.Reset()
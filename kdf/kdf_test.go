@@ -1,6 +1,7 @@
 package kdf
 
 import (
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"hash"
@@ -92,3 +93,35 @@ func BenchmarkKdf(b *testing.B) {
 		})
 	}
 }
+
+// NIST CAVS 12.0 ansx963_2001.rsp, SHA-256
+func TestX963KdfCAVS(t *testing.T) {
+	tests := []struct {
+		z, sharedInfo, keyData string
+	}{
+		{"96c05619d56c328ab95fe84b18264b08725b85e33fd34f08", "", "443024c3dae66b95e6f5670601558f71"},
+		{"22518b10e70f2a3f243810ae3254139efbee04aa57c7af7d", "75eef81aa3041e33b80971203d2c0c52", "c498af77161cc59f2962b9a713e2b215152d139766ce34a776df11866a69bf2e52a13d9c7c6fc878c50c5ea0bc7b00e0da2447cfd874f6cf92f30d0097111485500c90c3af8b487872d04685d14c8d1dc8d7fa08beb0ce0ababc11f0bd496269142d43525a78e5bc79a17f59676a5706dc54d54d4d1f0bd7e386128ec26afc21"},
+	}
+	for i, tt := range tests {
+		z, _ := hex.DecodeString(tt.z)
+		sharedInfo, _ := hex.DecodeString(tt.sharedInfo)
+		if got := hex.EncodeToString(X963Kdf(sha256.New(), z, sharedInfo, len(tt.keyData)/2)); got != tt.keyData {
+			t.Errorf("case %d: X963Kdf = %v, want %v", i, got, tt.keyData)
+		}
+	}
+}
+
+func TestX963KdfSM3(t *testing.T) {
+	z := []byte("emmansun")
+	if !reflect.DeepEqual(X963Kdf(sm3.New(), z, nil, 48), Kdf(sm3.New(), z, 48)) {
+		t.Errorf("X963Kdf without SharedInfo should be the same as Kdf")
+	}
+	got := X963Kdf(sm3.New(), z, []byte("shared info"), 48)
+	md := sm3.New()
+	md.Write(z)
+	md.Write([]byte{0, 0, 0, 1})
+	md.Write([]byte("shared info"))
+	if !reflect.DeepEqual(got[:sm3.Size], md.Sum(nil)) {
+		t.Errorf("unexpected X963Kdf result %x", got)
+	}
+}
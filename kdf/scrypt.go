@@ -0,0 +1,229 @@
+package kdf
+
+//
+// Reference https://datatracker.ietf.org/doc/html/rfc7914
+//
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/sm3"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/salsa20/salsa"
+)
+
+const maxInt = int(^uint(0) >> 1)
+
+// ScryptIdentifier is the algorithm identifier used in the encoded password hash.
+const ScryptIdentifier = "scrypt-sm3"
+
+// ErrMismatchedHashAndPassword is returned by CompareHashAndPassword when the password does not match the hash.
+var ErrMismatchedHashAndPassword = errors.New("kdf: hashed password is not the hash of the given password")
+
+// Scrypt derives a key from the password, salt and cost parameters with the scrypt memory-hard function
+// defined in RFC 7914, the PBKDF2 steps use HMAC-SM3 instead of HMAC-SHA256, the mixing function is still Salsa20/8.
+//
+// N is a CPU/memory cost parameter, which must be a power of two greater than 1.
+// r and p must satisfy r * p < 2³⁰.
+func Scrypt(password, salt []byte, N, r, p, keyLen int) ([]byte, error) {
+	return scryptKey(sm3.New, password, salt, N, r, p, keyLen)
+}
+
+func scryptKey(newHash func() hash.Hash, password, salt []byte, N, r, p, keyLen int) ([]byte, error) {
+	if N <= 1 || N&(N-1) != 0 {
+		return nil, errors.New("kdf: scrypt N must be > 1 and a power of 2")
+	}
+	if p <= 0 || r <= 0 || uint64(r)*uint64(p) >= 1<<30 || r > maxInt/128/p || r > maxInt/256 || N > maxInt/128/r {
+		return nil, errors.New("kdf: scrypt parameters are too large")
+	}
+
+	xy := make([]byte, 256*r)
+	v := make([]byte, 128*r*N)
+	b := pbkdf2.Key(password, salt, 1, p*128*r, newHash)
+
+	for i := 0; i < p; i++ {
+		smix(b[i*128*r:], r, N, v, xy)
+	}
+
+	return pbkdf2.Key(password, b, 1, keyLen, newHash), nil
+}
+
+// blockMix scryptBlockMix, in and out are 128*r bytes.
+func blockMix(tmp *[64]byte, in, out []byte, r int) {
+	copy(tmp[:], in[(2*r-1)*64:])
+	for i := 0; i < 2*r; i++ {
+		for j := range tmp {
+			tmp[j] ^= in[i*64+j]
+		}
+		salsa.Core208(tmp, tmp)
+		// even blocks go to the first half, odd blocks go to the second half
+		copy(out[(i>>1)*64+(i&1)*r*64:], tmp[:])
+	}
+}
+
+func integer(b []byte, r int) uint64 {
+	return binary.LittleEndian.Uint64(b[(2*r-1)*64:])
+}
+
+// smix scryptROMix
+func smix(b []byte, r, N int, v, xy []byte) {
+	var tmp [64]byte
+	R := 128 * r
+	x := xy[:R]
+	y := xy[R:]
+
+	copy(x, b[:R])
+	for i := 0; i < N; i += 2 {
+		copy(v[i*R:], x)
+		blockMix(&tmp, x, y, r)
+
+		copy(v[(i+1)*R:], y)
+		blockMix(&tmp, y, x, r)
+	}
+	for i := 0; i < N; i += 2 {
+		j := int(integer(x, r) & uint64(N-1))
+		xorBytes(x, v[j*R:(j+1)*R])
+		blockMix(&tmp, x, y, r)
+
+		j = int(integer(y, r) & uint64(N-1))
+		xorBytes(y, v[j*R:(j+1)*R])
+		blockMix(&tmp, y, x, r)
+	}
+	copy(b, x)
+}
+
+func xorBytes(dst, src []byte) {
+	for i := range src {
+		dst[i] ^= src[i]
+	}
+}
+
+// ScryptParams parameters of scrypt based password hashing.
+type ScryptParams struct {
+	LogN    uint8 // log2 of the CPU/memory cost parameter N
+	R       int   // block size parameter
+	P       int   // parallelization parameter
+	SaltLen int
+	KeyLen  int
+}
+
+// DefaultScryptParams the recommended parameters for interactive logins.
+var DefaultScryptParams = ScryptParams{LogN: 15, R: 8, P: 1, SaltLen: 16, KeyLen: 32}
+
+// The limits of the encoded scrypt-sm3 hash parameters, the same as the
+// keystore ones, so that an untrusted encoded hash can't make
+// CompareHashAndPassword allocate 128 * r * (N + p) bytes without bound.
+const (
+	maxScryptLogN = 22
+	maxScryptRP   = 1 << 10
+)
+
+func checkScryptParams(params *ScryptParams) bool {
+	return params.LogN > 0 && params.LogN <= maxScryptLogN &&
+		params.R > 0 && params.P > 0 && params.R <= maxScryptRP/params.P
+}
+
+// GenerateFromPassword returns the encoded scrypt-sm3 hash of the password with a random salt, the format is
+//
+//	$scrypt-sm3$ln=<LogN>,r=<R>,p=<P>$<base64 salt>$<base64 hash>
+//
+// which follows the PHC string format, base64 is standard encoding without padding.
+// If params is nil, DefaultScryptParams is used.
+func GenerateFromPassword(password []byte, params *ScryptParams) (string, error) {
+	if params == nil {
+		params = &DefaultScryptParams
+	}
+	if params.SaltLen <= 0 || params.KeyLen <= 0 || !checkScryptParams(params) {
+		return "", errors.New("kdf: invalid scrypt parameters")
+	}
+	salt := make([]byte, params.SaltLen)
+	if _, err := io.ReadFull(randutil.Reader(), salt); err != nil {
+		return "", err
+	}
+	key, err := Scrypt(password, salt, 1<<params.LogN, params.R, params.P, params.KeyLen)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("$%s$ln=%d,r=%d,p=%d$%s$%s", ScryptIdentifier, params.LogN, params.R, params.P,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CompareHashAndPassword compares an encoded scrypt-sm3 hash with the password,
+// returns nil on success, or an error on failure.
+func CompareHashAndPassword(encoded string, password []byte) error {
+	params, salt, key, err := DecodeHash(encoded)
+	if err != nil {
+		return err
+	}
+	other, err := Scrypt(password, salt, 1<<params.LogN, params.R, params.P, len(key))
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatchedHashAndPassword
+	}
+	return nil
+}
+
+// DecodeHash parses an encoded scrypt-sm3 hash, returns the parameters, salt and hash value.
+// LogN must not exceed 22 and r * p must not exceed 1024.
+func DecodeHash(encoded string) (params *ScryptParams, salt, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 || parts[0] != "" || parts[1] != ScryptIdentifier {
+		return nil, nil, nil, errors.New("kdf: invalid encoded scrypt-sm3 hash")
+	}
+	params = new(ScryptParams)
+	fields := strings.Split(parts[2], ",")
+	if len(fields) != 3 {
+		return nil, nil, nil, errors.New("kdf: invalid encoded scrypt-sm3 parameters")
+	}
+	var values [3]int
+	for i, name := range []string{"ln=", "r=", "p="} {
+		if values[i], err = parseParam(fields[i], name); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if values[0] > maxScryptLogN {
+		return nil, nil, nil, errors.New("kdf: invalid encoded scrypt-sm3 parameters")
+	}
+	params.LogN, params.R, params.P = uint8(values[0]), values[1], values[2]
+	if !checkScryptParams(params) {
+		return nil, nil, nil, errors.New("kdf: invalid encoded scrypt-sm3 parameters")
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[3]); err != nil {
+		return nil, nil, nil, err
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, nil, nil, err
+	}
+	if len(key) == 0 {
+		return nil, nil, nil, errors.New("kdf: invalid encoded scrypt-sm3 hash")
+	}
+	params.SaltLen = len(salt)
+	params.KeyLen = len(key)
+	return params, salt, key, nil
+}
+
+// parseParam parses the field name=value of the encoded hash, value must be a
+// decimal integer without sign or leading zeros.
+func parseParam(field, name string) (int, error) {
+	v := strings.TrimPrefix(field, name)
+	if len(v) == len(field) || len(v) == 0 || len(v) > 9 || (v[0] == '0' && len(v) > 1) {
+		return 0, errors.New("kdf: invalid encoded scrypt-sm3 parameters")
+	}
+	for i := 0; i < len(v); i++ {
+		if v[i] < '0' || v[i] > '9' {
+			return 0, errors.New("kdf: invalid encoded scrypt-sm3 parameters")
+		}
+	}
+	return strconv.Atoi(v)
+}
@@ -0,0 +1,100 @@
+package kdf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+func TestScryptStructure(t *testing.T) {
+	// with SHA256, the result should be the same as RFC 7914 scrypt
+	tests := []struct {
+		password, salt string
+		N, r, p        int
+	}{
+		{"", "", 16, 1, 1},
+		{"password", "NaCl", 1024, 8, 16},
+		{"pleaseletmein", "SodiumChloride", 256, 2, 3},
+	}
+	for _, tt := range tests {
+		want, err := scrypt.Key([]byte(tt.password), []byte(tt.salt), tt.N, tt.r, tt.p, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := scryptKey(sha256.New, []byte(tt.password), []byte(tt.salt), tt.N, tt.r, tt.p, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("scrypt(%v, %v) = %x, want %x", tt.password, tt.salt, got, want)
+		}
+	}
+}
+
+func TestScryptInvalidParameters(t *testing.T) {
+	for _, N := range []int{0, 1, 7} {
+		if _, err := Scrypt([]byte("password"), nil, N, 8, 1, 32); err == nil {
+			t.Errorf("N=%v: expected error", N)
+		}
+	}
+	if _, err := Scrypt([]byte("password"), nil, 16, 1<<20, 1<<10, 32); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestGenerateFromPassword(t *testing.T) {
+	params := &ScryptParams{LogN: 10, R: 8, P: 1, SaltLen: 16, KeyLen: 32}
+	encoded, err := GenerateFromPassword([]byte("password"), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encoded, "$scrypt-sm3$ln=10,r=8,p=1$") {
+		t.Fatalf("unexpected encoded hash %v", encoded)
+	}
+	if err = CompareHashAndPassword(encoded, []byte("password")); err != nil {
+		t.Fatal(err)
+	}
+	if err = CompareHashAndPassword(encoded, []byte("passw0rd")); err != ErrMismatchedHashAndPassword {
+		t.Fatalf("expected mismatch, got %v", err)
+	}
+	p, salt, key, err := DecodeHash(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *p != *params || len(salt) != 16 || len(key) != 32 {
+		t.Errorf("unexpected decoded hash %v", p)
+	}
+	want, _ := Scrypt([]byte("password"), salt, 1024, 8, 1, 32)
+	if !bytes.Equal(key, want) {
+		t.Errorf("unexpected hash value")
+	}
+}
+
+func TestDecodeHashInvalid(t *testing.T) {
+	for _, encoded := range []string{
+		"",
+		"$scrypt$ln=10,r=8,p=1$c2FsdA$aGFzaA",
+		"$scrypt-sm3$ln=0,r=8,p=1$c2FsdA$aGFzaA",
+		"$scrypt-sm3$ln=10,r=8$c2FsdA$aGFzaA",
+		"$scrypt-sm3$ln=10,r=8,p=1$!!$aGFzaA",
+		"$scrypt-sm3$ln=10,r=8,p=1$c2FsdA$",
+		"$scrypt-sm3$ln=62,r=8,p=1$c2FsdA$aGFzaA",
+		"$scrypt-sm3$ln=23,r=8,p=1$c2FsdA$aGFzaA",
+		"$scrypt-sm3$ln=10,r=1048576,p=1$c2FsdA$aGFzaA",
+		"$scrypt-sm3$ln=10,r=8,p=1048576$c2FsdA$aGFzaA",
+		"$scrypt-sm3$ln=10,r=64,p=32$c2FsdA$aGFzaA",
+		"$scrypt-sm3$ln=10,r=8,p=1junk$c2FsdA$aGFzaA",
+		"$scrypt-sm3$ln=10,r=8,p=1,x=1$c2FsdA$aGFzaA",
+		"$scrypt-sm3$ln=10,r=+8,p=1$c2FsdA$aGFzaA",
+		"$scrypt-sm3$ln=010,r=8,p=1$c2FsdA$aGFzaA",
+		"$scrypt-sm3$ln= 10,r=8,p=1$c2FsdA$aGFzaA",
+		"$scrypt-sm3$r=8,ln=10,p=1$c2FsdA$aGFzaA",
+	} {
+		if err := CompareHashAndPassword(encoded, []byte("password")); err == nil {
+			t.Errorf("%q: expected error", encoded)
+		}
+	}
+}
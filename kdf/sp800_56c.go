@@ -0,0 +1,100 @@
+package kdf
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"hash"
+)
+
+// NIST SP 800-56C Rev. 2 key derivation methods, which can be parameterized with sm3.New.
+
+// OneStep one-step key derivation with hash function, compliance with NIST SP 800-56C Rev. 2 4.1 option 1.
+// K(i) = H(counter || Z || FixedInfo)
+func OneStep(newHash func() hash.Hash, z, fixedInfo []byte, len int) []byte {
+	md := newHash()
+	return oneStep(md, nil, z, fixedInfo, len)
+}
+
+// OneStepHMAC one-step key derivation with HMAC, compliance with NIST SP 800-56C Rev. 2 4.1 option 2.
+// K(i) = HMAC(salt, counter || Z || FixedInfo), an empty salt is replaced with hash block size zero bytes.
+func OneStepHMAC(newHash func() hash.Hash, salt, z, fixedInfo []byte, len int) []byte {
+	if salt == nil {
+		salt = make([]byte, newHash().BlockSize())
+	}
+	md := hmac.New(newHash, salt)
+	return oneStep(md, nil, z, fixedInfo, len)
+}
+
+func oneStep(md hash.Hash, k, z, fixedInfo []byte, len int) []byte {
+	limit := uint64(len+md.Size()-1) / uint64(md.Size())
+	if limit >= uint64(1<<32)-1 {
+		panic("kdf: key length too long")
+	}
+	var countBytes [4]byte
+	var ct uint32 = 1
+	for i := 0; i < int(limit); i++ {
+		binary.BigEndian.PutUint32(countBytes[:], ct)
+		md.Write(countBytes[:])
+		md.Write(z)
+		md.Write(fixedInfo)
+		k = md.Sum(k)
+		ct++
+		md.Reset()
+	}
+	return k[:len]
+}
+
+// Extract randomness extraction step of two-step key derivation, compliance with NIST SP 800-56C Rev. 2 5.1.
+// It returns the key derivation key HMAC(salt, Z), an empty salt is replaced with hash block size zero bytes.
+func Extract(newHash func() hash.Hash, salt, z []byte) []byte {
+	if salt == nil {
+		salt = make([]byte, newHash().BlockSize())
+	}
+	md := hmac.New(newHash, salt)
+	md.Write(z)
+	return md.Sum(nil)
+}
+
+// Expand key expansion step of two-step key derivation, it is the HMAC based KDF in counter mode
+// defined in NIST SP 800-108 5.1 with an 32 bits counter before the fixed info.
+// K(i) = HMAC(kdk, [i]_32 || FixedInfo)
+func Expand(newHash func() hash.Hash, kdk, fixedInfo []byte, len int) []byte {
+	md := hmac.New(newHash, kdk)
+	return oneStep(md, nil, nil, fixedInfo, len)
+}
+
+// TwoStep extract-then-expand key derivation, compliance with NIST SP 800-56C Rev. 2 5.
+func TwoStep(newHash func() hash.Hash, salt, z, fixedInfo []byte, len int) []byte {
+	return Expand(newHash, Extract(newHash, salt, z), fixedInfo, len)
+}
+
+// FixedInfo builds FixedInfo in the concatenation format of NIST SP 800-56A Rev. 3 5.8.2.1.1:
+// AlgorithmID || PartyUInfo || PartyVInfo {|| SuppPubInfo}{|| SuppPrivInfo}.
+// AlgorithmID, PartyUInfo and PartyVInfo are variable length, they are encoded as
+// a 32 bits big endian length followed by the data, SuppPubInfo and SuppPrivInfo are appended as is.
+func FixedInfo(algorithmID, partyUInfo, partyVInfo, suppPubInfo, suppPrivInfo []byte) []byte {
+	info := make([]byte, 0, 12+len(algorithmID)+len(partyUInfo)+len(partyVInfo)+len(suppPubInfo)+len(suppPrivInfo))
+	info = appendLengthPrefixed(info, algorithmID)
+	info = appendLengthPrefixed(info, partyUInfo)
+	info = appendLengthPrefixed(info, partyVInfo)
+	info = append(info, suppPubInfo...)
+	info = append(info, suppPrivInfo...)
+	return info
+}
+
+// CounterFixedInfo builds the NIST SP 800-108 fixed input data Label || 0x00 || Context || [L]_32,
+// where L is the derived key length in bits.
+func CounterFixedInfo(label, context []byte, keyLen int) []byte {
+	info := make([]byte, len(label)+len(context)+5)
+	copy(info, label)
+	copy(info[len(label)+1:], context)
+	binary.BigEndian.PutUint32(info[len(info)-4:], uint32(keyLen)<<3)
+	return info
+}
+
+func appendLengthPrefixed(b, data []byte) []byte {
+	var lenBytes [4]byte
+	binary.BigEndian.PutUint32(lenBytes[:], uint32(len(data)))
+	b = append(b, lenBytes[:]...)
+	return append(b, data...)
+}
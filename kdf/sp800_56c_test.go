@@ -0,0 +1,94 @@
+package kdf
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm3"
+)
+
+func TestOneStep(t *testing.T) {
+	z := []byte("emmansun")
+	fixedInfo := FixedInfo([]byte("SM4"), []byte("alice"), []byte("bob"), nil, nil)
+	for _, l := range []int{16, 32, 48, 100} {
+		got := OneStep(sm3.New, z, fixedInfo, l)
+		var want []byte
+		for i := uint32(1); len(want) < l; i++ {
+			md := sm3.New()
+			var ct [4]byte
+			binary.BigEndian.PutUint32(ct[:], i)
+			md.Write(ct[:])
+			md.Write(z)
+			md.Write(fixedInfo)
+			want = md.Sum(want)
+		}
+		if !bytes.Equal(got, want[:l]) {
+			t.Errorf("OneStep(%v) = %x, want %x", l, got, want[:l])
+		}
+	}
+}
+
+func TestOneStepHMAC(t *testing.T) {
+	z := []byte("emmansun")
+	salt := []byte("salt")
+	got := OneStepHMAC(sm3.New, salt, z, nil, 40)
+	var want []byte
+	for i := uint32(1); len(want) < 40; i++ {
+		md := hmac.New(sm3.New, salt)
+		var ct [4]byte
+		binary.BigEndian.PutUint32(ct[:], i)
+		md.Write(ct[:])
+		md.Write(z)
+		want = md.Sum(want)
+	}
+	if !bytes.Equal(got, want[:40]) {
+		t.Errorf("OneStepHMAC = %x, want %x", got, want[:40])
+	}
+	if !bytes.Equal(OneStepHMAC(sm3.New, nil, z, nil, 32), OneStepHMAC(sm3.New, make([]byte, sm3.BlockSize), z, nil, 32)) {
+		t.Errorf("nil salt should be the same as block size zero bytes")
+	}
+}
+
+// NIST SP 800-108 KDF in counter mode, CAVP KDFCTR_gen.rsp, PRF=HMAC_SHA256, CTRLOCATION=BEFORE_FIXED, RLEN=32_BITS, COUNT=0
+func TestExpandCAVP(t *testing.T) {
+	kdk, _ := hex.DecodeString("dd1d91b7d90b2bd3138533ce92b272fbf8a369316aefe242e659cc0ae238afe0")
+	fixedInfo, _ := hex.DecodeString("01322b96b30acd197979444e468e1c5c6859bf1b1cf951b7e725303e237e46b864a145fab25e517b08f8683d0315bb2911d80a0e8aba17f3b413faac")
+	want := "10621342bfb0fd40046c0e29f2cfdbf0"
+	if got := hex.EncodeToString(Expand(sha256.New, kdk, fixedInfo, 16)); got != want {
+		t.Errorf("Expand = %v, want %v", got, want)
+	}
+}
+
+func TestTwoStep(t *testing.T) {
+	z := []byte("shared secret")
+	salt := []byte("salt")
+	fixedInfo := CounterFixedInfo([]byte("label"), []byte("context"), 48)
+	got := TwoStep(sm3.New, salt, z, fixedInfo, 48)
+	want := Expand(sm3.New, Extract(sm3.New, salt, z), fixedInfo, 48)
+	if !bytes.Equal(got, want) {
+		t.Errorf("TwoStep = %x, want %x", got, want)
+	}
+	if len(Extract(sm3.New, nil, z)) != sm3.Size {
+		t.Errorf("unexpected key derivation key length")
+	}
+	shouldPanic(t, func() {
+		OneStep(sm3.New, z, nil, 0xffffffff*sm3.Size)
+	})
+}
+
+func TestFixedInfo(t *testing.T) {
+	got := FixedInfo([]byte{1}, []byte{2, 3}, nil, []byte{0, 0, 1, 0}, []byte{9})
+	want := []byte{0, 0, 0, 1, 1, 0, 0, 0, 2, 2, 3, 0, 0, 0, 0, 0, 0, 1, 0, 9}
+	if !bytes.Equal(got, want) {
+		t.Errorf("FixedInfo = %x, want %x", got, want)
+	}
+	got = CounterFixedInfo([]byte("ab"), []byte("c"), 16)
+	want = []byte{'a', 'b', 0, 'c', 0, 0, 0, 128}
+	if !bytes.Equal(got, want) {
+		t.Errorf("CounterFixedInfo = %x, want %x", got, want)
+	}
+}
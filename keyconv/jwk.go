@@ -0,0 +1,77 @@
+package keyconv
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/emmansun/gmsm/sm2"
+)
+
+// jwk is a RFC 7517 JSON Web Key of kty "EC". There is no registered JOSE curve
+// name for SM2, "SM2" is used as crv, the same as the JOSE name of the registry
+// package.
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	D   string `json:"d,omitempty"`
+}
+
+const jwkCurveSM2 = "SM2"
+
+func marshalJWK(key any) ([]byte, error) {
+	pub, ok := sm2Public(key)
+	if !ok {
+		return nil, unsupported(key, JWK)
+	}
+	k := jwk{Kty: "EC", Crv: jwkCurveSM2, X: jwkInt(pub.X), Y: jwkInt(pub.Y)}
+	if priv, ok := key.(*sm2.PrivateKey); ok {
+		k.D = jwkInt(priv.D)
+	}
+	return json.Marshal(&k)
+}
+
+func parseJWK(data []byte) (any, error) {
+	var k jwk
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, err
+	}
+	if k.Kty != "EC" || k.Crv != jwkCurveSM2 {
+		return nil, errors.New("keyconv: unsupported JWK key type " + k.Kty + "/" + k.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil || len(x) != 32 {
+		return nil, errors.New("keyconv: invalid JWK x coordinate")
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil || len(y) != 32 {
+		return nil, errors.New("keyconv: invalid JWK y coordinate")
+	}
+	pub, err := sm2.NewPublicKey(append(append([]byte{4}, x...), y...))
+	if err != nil {
+		return nil, err
+	}
+	if k.D == "" {
+		return pub, nil
+	}
+	d, err := base64.RawURLEncoding.DecodeString(k.D)
+	if err != nil || len(d) != 32 {
+		return nil, errors.New("keyconv: invalid JWK private key")
+	}
+	priv, err := sm2.NewPrivateKey(d)
+	if err != nil {
+		return nil, err
+	}
+	if !priv.PublicKey.Equal(pub) {
+		return nil, errors.New("keyconv: JWK public key does not match private key")
+	}
+	return priv, nil
+}
+
+// jwkInt encodes n as a 32 bytes base64url string, RFC 7518 section 6.2.1.2.
+func jwkInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.FillBytes(make([]byte, 32)))
+}
@@ -0,0 +1,330 @@
+// Package keyconv converts SM2 and SM9 keys among the key formats: SEC 1,
+// legacy encrypted SEC 1 PEM, PKCS #8, encrypted PKCS #8, PKIX, JWK, OpenSSH
+// and raw, with auto-detection of the input format.
+//
+// The supported key types are *sm2.PrivateKey and *ecdsa.PublicKey of SM2 curve
+// for all formats, and the SM9 master / user private keys for PKCS #8 and
+// encrypted PKCS #8.
+package keyconv
+
+import (
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/pkcs8"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm9"
+	"github.com/emmansun/gmsm/smx509"
+)
+
+// Format is a key format.
+type Format int
+
+const (
+	Unknown        Format = iota
+	SEC1                  // RFC 5915 ECPrivateKey, PEM type "EC PRIVATE KEY"
+	PKCS8                 // RFC 5208 PrivateKeyInfo, PEM type "PRIVATE KEY"
+	EncryptedPKCS8        // RFC 5208 EncryptedPrivateKeyInfo, PEM type "ENCRYPTED PRIVATE KEY"
+	PKIX                  // RFC 5280 SubjectPublicKeyInfo, PEM type "PUBLIC KEY"
+	JWK                   // RFC 7517 JSON Web Key
+	OpenSSH               // OpenSSH private key or authorized_keys line
+	Raw                   // private key scalar or uncompressed public key point
+	EncryptedSEC1         // RFC 1423 encrypted PEM "EC PRIVATE KEY" with Proc-Type and DEK-Info headers
+)
+
+var formatNames = []string{"Unknown", "SEC1", "PKCS8", "EncryptedPKCS8", "PKIX", "JWK", "OpenSSH", "Raw", "EncryptedSEC1"}
+
+func (f Format) String() string {
+	if f < 0 || int(f) >= len(formatNames) {
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+	return formatNames[f]
+}
+
+var pemTypes = map[Format]string{
+	SEC1:           "EC PRIVATE KEY",
+	PKCS8:          "PRIVATE KEY",
+	EncryptedPKCS8: "ENCRYPTED PRIVATE KEY",
+	PKIX:           "PUBLIC KEY",
+	EncryptedSEC1:  "EC PRIVATE KEY",
+}
+
+// Options are the options of Marshal and Convert.
+type Options struct {
+	// PEM outputs PEM encoded SEC1, PKCS8, EncryptedPKCS8 and PKIX keys instead of DER.
+	PEM bool
+	// Password is used to encrypt EncryptedPKCS8 and EncryptedSEC1 keys.
+	Password []byte
+	// PKCS8 are the encryption options of EncryptedPKCS8, pkcs8.DefaultOpts if nil.
+	PKCS8 *pkcs8.Opts
+	// PEMCipher is the cipher of EncryptedSEC1, smx509.PEMCipherSM4 if zero.
+	// EncryptedSEC1 is always PEM encoded.
+	PEMCipher smx509.PEMCipher
+	// Comment is the comment of OpenSSH keys.
+	Comment string
+}
+
+var (
+	errUnknownFormat   = errors.New("keyconv: unknown key format")
+	errPasswordMissing = errors.New("keyconv: password is required for encrypted private keys")
+)
+
+// Detect returns the format of data, Raw is only reported for 32 bytes private
+// scalars and 65 bytes uncompressed public keys.
+func Detect(data []byte) Format {
+	if block, _ := pem.Decode(data); block != nil {
+		switch block.Type {
+		case "EC PRIVATE KEY", "SM2 PRIVATE KEY":
+			if smx509.IsEncryptedPEMBlock(block) {
+				return EncryptedSEC1
+			}
+			return SEC1
+		case "PRIVATE KEY":
+			return PKCS8
+		case "ENCRYPTED PRIVATE KEY":
+			return EncryptedPKCS8
+		case "PUBLIC KEY":
+			return PKIX
+		case "OPENSSH PRIVATE KEY":
+			return OpenSSH
+		}
+		return Unknown
+	}
+	// No other format has these lengths, check them first so that a random
+	// scalar is never taken for JSON or DER.
+	if len(data) == 32 || (len(data) == 65 && data[0] == 4) {
+		return Raw
+	}
+	if len(data) > 0 && data[0] == '{' && json.Valid(data) {
+		return JWK
+	}
+	if isSSHPublicKey(data) {
+		return OpenSSH
+	}
+	return detectDER(data)
+}
+
+func detectDER(der []byte) Format {
+	var seq []asn1.RawValue
+	if rest, err := asn1.Unmarshal(der, &seq); err != nil || len(rest) != 0 || len(seq) < 2 {
+		return Unknown
+	}
+	switch {
+	case seq[0].Tag == asn1.TagInteger && seq[1].Tag == asn1.TagOctetString:
+		return SEC1 // version, privateKey
+	case seq[0].Tag == asn1.TagInteger && seq[1].Tag == asn1.TagSequence:
+		return PKCS8 // version, privateKeyAlgorithm
+	case seq[0].Tag == asn1.TagSequence && seq[1].Tag == asn1.TagBitString:
+		return PKIX // algorithm, subjectPublicKey
+	case seq[0].Tag == asn1.TagSequence && seq[1].Tag == asn1.TagOctetString:
+		return EncryptedPKCS8 // encryptionAlgorithm, encryptedData
+	}
+	return Unknown
+}
+
+// Parse detects the format of data and parses the key, the password is only
+// used for EncryptedPKCS8 and EncryptedSEC1. It returns a *sm2.PrivateKey, a *ecdsa.PublicKey or
+// one of the SM9 private keys, and the detected format.
+func Parse(data, password []byte) (any, Format, error) {
+	format := Detect(data)
+	key, err := ParseAs(data, format, password)
+	return key, format, err
+}
+
+// ParseAs parses the key in the given format, PEM encoded input is accepted for
+// SEC1, PKCS8, EncryptedPKCS8 and PKIX, and required for EncryptedSEC1.
+func ParseAs(data []byte, format Format, password []byte) (any, error) {
+	switch format {
+	case EncryptedSEC1:
+		return parseEncryptedSEC1(data, password)
+	case SEC1, PKCS8, EncryptedPKCS8, PKIX:
+		if block, _ := pem.Decode(data); block != nil {
+			data = block.Bytes
+		}
+	}
+	switch format {
+	case SEC1:
+		return smx509.ParseSM2PrivateKey(data)
+	case PKCS8:
+		return smx509.ParsePKCS8PrivateKey(data)
+	case EncryptedPKCS8:
+		if len(password) == 0 {
+			return nil, errPasswordMissing
+		}
+		return pkcs8.ParsePKCS8PrivateKey(data, password)
+	case PKIX:
+		pub, err := smx509.ParsePKIXPublicKey(data)
+		if err != nil {
+			return nil, err
+		}
+		if !sm2.IsSM2PublicKey(pub) {
+			return nil, errors.New("keyconv: not a SM2 public key")
+		}
+		return pub, nil
+	case JWK:
+		return parseJWK(data)
+	case OpenSSH:
+		return parseOpenSSH(data)
+	case Raw:
+		switch len(data) {
+		case 32:
+			return sm2.NewPrivateKey(data)
+		case 65:
+			return sm2.NewPublicKey(data)
+		}
+		return nil, errors.New("keyconv: invalid raw key length")
+	}
+	return nil, errUnknownFormat
+}
+
+// Marshal encodes key in the given format.
+func Marshal(key any, format Format, opts *Options) ([]byte, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	var (
+		der []byte
+		err error
+	)
+	switch format {
+	case SEC1:
+		priv, ok := key.(*sm2.PrivateKey)
+		if !ok {
+			return nil, unsupported(key, format)
+		}
+		der, err = smx509.MarshalSM2PrivateKey(priv)
+	case PKCS8:
+		if !isPrivateKey(key) {
+			return nil, unsupported(key, format)
+		}
+		der, err = smx509.MarshalPKCS8PrivateKey(key)
+	case EncryptedPKCS8:
+		if !isPrivateKey(key) {
+			return nil, unsupported(key, format)
+		}
+		if len(opts.Password) == 0 {
+			return nil, errPasswordMissing
+		}
+		der, err = pkcs8.MarshalPrivateKey(key, opts.Password, opts.PKCS8)
+	case PKIX:
+		pub, ok := sm2Public(key)
+		if !ok {
+			return nil, unsupported(key, format)
+		}
+		der, err = smx509.MarshalPKIXPublicKey(pub)
+	case JWK:
+		return marshalJWK(key)
+	case OpenSSH:
+		return marshalOpenSSH(key, opts.Comment)
+	case EncryptedSEC1:
+		priv, ok := key.(*sm2.PrivateKey)
+		if !ok {
+			return nil, unsupported(key, format)
+		}
+		return marshalEncryptedSEC1(priv, opts)
+	case Raw:
+		switch k := key.(type) {
+		case *sm2.PrivateKey:
+			return k.D.FillBytes(make([]byte, 32)), nil
+		case *ecdsa.PublicKey:
+			if !sm2.IsSM2PublicKey(k) {
+				return nil, unsupported(key, format)
+			}
+			return append([]byte{4}, append(k.X.FillBytes(make([]byte, 32)), k.Y.FillBytes(make([]byte, 32))...)...), nil
+		}
+		return nil, unsupported(key, format)
+	default:
+		return nil, errUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.PEM {
+		return pem.EncodeToMemory(&pem.Block{Type: pemTypes[format], Bytes: der}), nil
+	}
+	return der, nil
+}
+
+// Convert parses data in any supported format, the password is used to decrypt
+// EncryptedPKCS8 and EncryptedSEC1 input, and encodes the key in the format to.
+func Convert(data []byte, password []byte, to Format, opts *Options) ([]byte, error) {
+	key, _, err := Parse(data, password)
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(key, to, opts)
+}
+
+// Public returns the public key of the SM2 private key, or key itself if it's
+// a SM2 public key already.
+func Public(key any) (*ecdsa.PublicKey, error) {
+	pub, ok := sm2Public(key)
+	if !ok {
+		return nil, fmt.Errorf("keyconv: %T has no SM2 public key", key)
+	}
+	return pub, nil
+}
+
+// parseEncryptedSEC1 decrypts a legacy OpenSSL encrypted PEM private key, as
+// written by "openssl ec -sm4" or "openssl ec -aes256", and parses it.
+func parseEncryptedSEC1(data, password []byte) (any, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || !smx509.IsEncryptedPEMBlock(block) {
+		return nil, errors.New("keyconv: not an encrypted PEM block")
+	}
+	if len(password) == 0 {
+		return nil, errPasswordMissing
+	}
+	der, err := smx509.DecryptPEMBlock(block, password)
+	if err != nil {
+		return nil, err
+	}
+	return smx509.ParseSM2PrivateKey(der)
+}
+
+func marshalEncryptedSEC1(priv *sm2.PrivateKey, opts *Options) ([]byte, error) {
+	if len(opts.Password) == 0 {
+		return nil, errPasswordMissing
+	}
+	der, err := smx509.MarshalSM2PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	alg := opts.PEMCipher
+	if alg == 0 {
+		alg = smx509.PEMCipherSM4
+	}
+	block, err := smx509.EncryptPEMBlock(randutil.Reader(), pemTypes[EncryptedSEC1], der, opts.Password, alg)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+func sm2Public(key any) (*ecdsa.PublicKey, bool) {
+	switch k := key.(type) {
+	case *sm2.PrivateKey:
+		return &k.PublicKey, true
+	case *ecdsa.PublicKey:
+		return k, sm2.IsSM2PublicKey(k)
+	}
+	return nil, false
+}
+
+func isPrivateKey(key any) bool {
+	switch key.(type) {
+	case *sm2.PrivateKey, *sm9.SignMasterPrivateKey, *sm9.SignPrivateKey,
+		*sm9.EncryptMasterPrivateKey, *sm9.EncryptPrivateKey:
+		return true
+	}
+	return false
+}
+
+func unsupported(key any, format Format) error {
+	return fmt.Errorf("keyconv: %T can't be encoded in %v format", key, format)
+}
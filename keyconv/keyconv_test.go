@@ -0,0 +1,228 @@
+package keyconv
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm9"
+	"github.com/emmansun/gmsm/smx509"
+)
+
+func TestConvertSM2PrivateKey(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	password := []byte("password")
+	for _, format := range []Format{SEC1, PKCS8, EncryptedPKCS8, JWK, OpenSSH, Raw, EncryptedSEC1} {
+		for _, usePEM := range []bool{false, true} {
+			opts := &Options{PEM: usePEM, Password: password, Comment: "test"}
+			data, err := Marshal(priv, format, opts)
+			if err != nil {
+				t.Fatalf("%v: %v", format, err)
+			}
+			if got := Detect(data); got != format {
+				t.Fatalf("%v: detected as %v", format, got)
+			}
+			key, detected, err := Parse(data, password)
+			if err != nil {
+				t.Fatalf("%v: %v", format, err)
+			}
+			if detected != format {
+				t.Errorf("%v: parsed as %v", format, detected)
+			}
+			if !priv.Equal(key) {
+				t.Errorf("%v: key mismatch", format)
+			}
+		}
+	}
+}
+
+func TestConvertSM2PublicKey(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []Format{PKIX, JWK, OpenSSH, Raw} {
+		data, err := Marshal(priv.Public(), format, &Options{PEM: true})
+		if err != nil {
+			t.Fatalf("%v: %v", format, err)
+		}
+		key, detected, err := Parse(data, nil)
+		if err != nil {
+			t.Fatalf("%v: %v", format, err)
+		}
+		if detected != format {
+			t.Errorf("%v: parsed as %v", format, detected)
+		}
+		if !priv.PublicKey.Equal(key) {
+			t.Errorf("%v: key mismatch", format)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	d, _ := hex.DecodeString("3945208f7b2144b13f36e38ac6d39f95889393692860b51a42fb81ef4df7c5b8")
+	priv, err := sm2.NewPrivateKey(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sec1, err := Marshal(priv, SEC1, &Options{PEM: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := Convert(sec1, nil, Raw, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(raw, d) {
+		t.Errorf("got %x, want %x", raw, d)
+	}
+	jwk, err := Convert(raw, nil, JWK, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(jwk), `"crv":"SM2"`) || !strings.Contains(string(jwk), `"d":"OUUgj3shRLE_NuOKxtOflYiTk2koYLUaQvuB7033xbg"`) {
+		t.Errorf("unexpected JWK %s", jwk)
+	}
+	enc, err := Convert(jwk, nil, EncryptedPKCS8, &Options{Password: []byte("secret")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Convert(enc, nil, SEC1, nil); err == nil {
+		t.Error("expected error without password")
+	}
+	pub, err := Convert(enc, []byte("secret"), OpenSSH, &Options{Comment: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParseAs(pub, OpenSSH, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !priv.Equal(key) {
+		t.Error("key mismatch")
+	}
+}
+
+// generated with:
+// openssl ecparam -name SM2 -genkey -noout | openssl ec -sm4 -passout pass:asdf
+const opensslEncryptedSM2Key = `-----BEGIN SM2 PRIVATE KEY-----
+Proc-Type: 4,ENCRYPTED
+DEK-Info: SM4-CBC,18B8F57E8109D35CBC0502C0D2417A20
+
+GWGTwmcQQDJQB0eQGyTf44CLIgktgfpyxy9WAjR2BcDbK0OvcbrOfU5EA79mr+a+
+C4pddQSJIx7St0q72s5Yrh8m1RCW4jKY20bQKNS8hUFx3g0zcY3t1AQV1wwgVtUS
+2AREzhSoewzyoXjMHgiaCKxz34Ir7WUmnSa5cgYSi1M=
+-----END SM2 PRIVATE KEY-----
+`
+
+func TestEncryptedSEC1(t *testing.T) {
+	data := []byte(opensslEncryptedSM2Key)
+	if f := Detect(data); f != EncryptedSEC1 {
+		t.Fatalf("detected as %v", f)
+	}
+	if _, _, err := Parse(data, nil); err == nil {
+		t.Error("expected error without password")
+	}
+	if _, _, err := Parse(data, []byte("wrong")); err == nil {
+		t.Error("expected error with wrong password")
+	}
+	raw, err := Convert(data, []byte("asdf"), Raw, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "b5c804f974f9164e68782cb952949ff03eab3d2c0d16e7bb5304fc636dc8d0cb"; hex.EncodeToString(raw) != want {
+		t.Errorf("got %x, want %s", raw, want)
+	}
+
+	opts := &Options{Password: []byte("secret"), PEMCipher: smx509.PEMCipherAES256}
+	data, err = Convert(raw, nil, EncryptedSEC1, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "DEK-Info: AES-256-CBC,") {
+		t.Errorf("unexpected PEM %s", data)
+	}
+	data, err = Convert(data, []byte("secret"), EncryptedSEC1, &Options{Password: []byte("secret")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "DEK-Info: SM4-CBC,") {
+		t.Errorf("unexpected PEM %s", data)
+	}
+	got, err := Convert(data, []byte("secret"), Raw, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, raw) {
+		t.Errorf("got %x, want %x", got, raw)
+	}
+}
+
+func TestConvertSM9(t *testing.T) {
+	masterKey, err := sm9.GenerateSignMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userKey, err := masterKey.GenerateUserKey([]byte("Alice"), 0x01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []any{masterKey, userKey} {
+		data, err := Marshal(key, EncryptedPKCS8, &Options{Password: []byte("secret"), PEM: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err = Convert(data, []byte("secret"), PKCS8, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, format, err := Parse(data, nil); err != nil || format != PKCS8 {
+			t.Fatalf("format %v, err %v", format, err)
+		}
+		if _, err := Marshal(key, JWK, nil); err == nil {
+			t.Errorf("%T: expected error for JWK", key)
+		}
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Marshal(priv.Public(), SEC1, nil); err == nil {
+		t.Error("expected error for public key in SEC1")
+	}
+	if _, err := Marshal(priv, EncryptedPKCS8, nil); err == nil {
+		t.Error("expected error without password")
+	}
+	if _, err := Marshal(&ecdsa.PublicKey{}, Raw, nil); err == nil {
+		t.Error("expected error for non SM2 key")
+	}
+	if _, _, err := Parse([]byte("hello"), nil); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if f := Detect([]byte("-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----\n")); f != Unknown {
+		t.Errorf("got %v", f)
+	}
+	if f := Detect([]byte("{not json")); f != Unknown {
+		t.Errorf("got %v", f)
+	}
+}
+
+func TestDetectRawScalar(t *testing.T) {
+	for _, first := range []byte{'{', 0x30, 0x2d} {
+		scalar := bytes.Repeat([]byte{0x11}, 32)
+		scalar[0] = first
+		if f := Detect(scalar); f != Raw {
+			t.Errorf("%#x: got %v", first, f)
+		}
+	}
+}
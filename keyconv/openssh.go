@@ -0,0 +1,219 @@
+package keyconv
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/sm2"
+)
+
+// sshKeyType is both the SSH key type and the curve identifier of SM2 keys.
+// There is no registered SSH key type for SM2, the key blob follows the
+// ecdsa-sha2-* layout of RFC 5656 section 3.1.
+const sshKeyType = "sm2"
+
+const sshPrivateKeyMagic = "openssh-key-v1\x00"
+
+func isSSHPublicKey(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(sshKeyType+" "))
+}
+
+func marshalOpenSSH(key any, comment string) ([]byte, error) {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if !sm2.IsSM2PublicKey(k) {
+			return nil, unsupported(key, OpenSSH)
+		}
+		line := sshKeyType + " " + base64.StdEncoding.EncodeToString(sshPublicBlob(k))
+		if comment != "" {
+			line += " " + comment
+		}
+		return []byte(line + "\n"), nil
+	case *sm2.PrivateKey:
+		return marshalOpenSSHPrivateKey(k, comment)
+	}
+	return nil, unsupported(key, OpenSSH)
+}
+
+// marshalOpenSSHPrivateKey encodes the unencrypted openssh-key-v1 format, see
+// PROTOCOL.key of OpenSSH.
+func marshalOpenSSHPrivateKey(priv *sm2.PrivateKey, comment string) ([]byte, error) {
+	var check [4]byte
+	if _, err := io.ReadFull(randutil.Reader(), check[:]); err != nil {
+		return nil, err
+	}
+	var secret []byte
+	secret = append(secret, check[:]...)
+	secret = append(secret, check[:]...)
+	secret = sshAppendString(secret, []byte(sshKeyType))
+	secret = sshAppendString(secret, []byte(sshKeyType))
+	secret = sshAppendString(secret, sshPoint(&priv.PublicKey))
+	secret = sshAppendString(secret, sshMPInt(priv.D))
+	secret = sshAppendString(secret, []byte(comment))
+	for i := byte(1); len(secret)%8 != 0; i++ {
+		secret = append(secret, i)
+	}
+
+	out := []byte(sshPrivateKeyMagic)
+	out = sshAppendString(out, []byte("none")) // cipher
+	out = sshAppendString(out, []byte("none")) // kdf
+	out = sshAppendString(out, nil)            // kdf options
+	out = sshAppendUint32(out, 1)
+	out = sshAppendString(out, sshPublicBlob(&priv.PublicKey))
+	out = sshAppendString(out, secret)
+	return pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: out}), nil
+}
+
+func parseOpenSSH(data []byte) (any, error) {
+	if isSSHPublicKey(data) {
+		fields := bytes.Fields(data)
+		if len(fields) < 2 {
+			return nil, errors.New("keyconv: invalid OpenSSH public key")
+		}
+		blob, err := base64.StdEncoding.DecodeString(string(fields[1]))
+		if err != nil {
+			return nil, err
+		}
+		return parseSSHPublicBlob(blob)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "OPENSSH PRIVATE KEY" {
+		return nil, errors.New("keyconv: invalid OpenSSH key")
+	}
+	return parseOpenSSHPrivateKey(block.Bytes)
+}
+
+func parseOpenSSHPrivateKey(data []byte) (any, error) {
+	if !bytes.HasPrefix(data, []byte(sshPrivateKeyMagic)) {
+		return nil, errors.New("keyconv: invalid OpenSSH private key magic")
+	}
+	r := sshReader(data[len(sshPrivateKeyMagic):])
+	cipher, kdf, _ := r.string(), r.string(), r.string()
+	n := r.uint32()
+	pubBlob, secret := r.string(), r.string()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if string(cipher) != "none" || string(kdf) != "none" {
+		return nil, errors.New("keyconv: encrypted OpenSSH private keys are not supported")
+	}
+	if n != 1 {
+		return nil, errors.New("keyconv: multiple keys in OpenSSH private key are not supported")
+	}
+	pub, err := parseSSHPublicBlob(pubBlob)
+	if err != nil {
+		return nil, err
+	}
+
+	r = sshReader(secret)
+	if r.uint32() != r.uint32() {
+		return nil, errors.New("keyconv: OpenSSH private key check mismatch")
+	}
+	keyType, curve, _, d := r.string(), r.string(), r.string(), r.string()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if string(keyType) != sshKeyType || string(curve) != sshKeyType {
+		return nil, errors.New("keyconv: unsupported OpenSSH key type " + string(keyType))
+	}
+	if len(d) > 0 && d[0] == 0 {
+		d = d[1:]
+	}
+	if len(d) > 32 {
+		return nil, errors.New("keyconv: invalid OpenSSH private key")
+	}
+	priv, err := sm2.NewPrivateKey(new(big.Int).SetBytes(d).FillBytes(make([]byte, 32)))
+	if err != nil {
+		return nil, err
+	}
+	if !priv.PublicKey.Equal(pub) {
+		return nil, errors.New("keyconv: OpenSSH public key does not match private key")
+	}
+	return priv, nil
+}
+
+func parseSSHPublicBlob(blob []byte) (*ecdsa.PublicKey, error) {
+	r := sshReader(blob)
+	keyType, curve, point := r.string(), r.string(), r.string()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.buf) != 0 || string(keyType) != sshKeyType || string(curve) != sshKeyType {
+		return nil, errors.New("keyconv: unsupported OpenSSH public key")
+	}
+	return sm2.NewPublicKey(point)
+}
+
+func sshPublicBlob(pub *ecdsa.PublicKey) []byte {
+	var b []byte
+	b = sshAppendString(b, []byte(sshKeyType))
+	b = sshAppendString(b, []byte(sshKeyType))
+	return sshAppendString(b, sshPoint(pub))
+}
+
+func sshPoint(pub *ecdsa.PublicKey) []byte {
+	b := make([]byte, 65)
+	b[0] = 4
+	pub.X.FillBytes(b[1:33])
+	pub.Y.FillBytes(b[33:])
+	return b
+}
+
+// sshMPInt returns the RFC 4251 mpint encoding of a positive n.
+func sshMPInt(n *big.Int) []byte {
+	b := n.Bytes()
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return b
+}
+
+func sshAppendString(b, s []byte) []byte {
+	b = sshAppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+func sshAppendUint32(b []byte, v uint32) []byte {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], v)
+	return append(b, n[:]...)
+}
+
+type sshBuffer struct {
+	buf []byte
+	err error
+}
+
+func sshReader(b []byte) *sshBuffer {
+	return &sshBuffer{buf: b}
+}
+
+var errSSHTruncated = errors.New("keyconv: truncated OpenSSH key")
+
+func (r *sshBuffer) uint32() uint32 {
+	if r.err != nil || len(r.buf) < 4 {
+		r.err = errSSHTruncated
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.buf)
+	r.buf = r.buf[4:]
+	return v
+}
+
+func (r *sshBuffer) string() []byte {
+	n := r.uint32()
+	if r.err != nil || uint64(len(r.buf)) < uint64(n) {
+		r.err = errSSHTruncated
+		return nil
+	}
+	s := r.buf[:n]
+	r.buf = r.buf[n:]
+	return s
+}
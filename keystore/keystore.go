@@ -0,0 +1,369 @@
+// Package keystore implements a password protected JSON format for SM2
+// private keys, in the style of the Ethereum keystore (Web3 Secret Storage
+// version 3) with the ShangMi algorithms:
+//
+//	{
+//	  "version": 1,
+//	  "id": "<random UUID>",
+//	  "algorithm": "sm2",
+//	  "publickey": "<hex uncompressed public key>",
+//	  "crypto": {
+//	    "cipher": "sm4-ctr" | "sm4-gcm",
+//	    "cipherparams": {"iv": "<hex>"},
+//	    "ciphertext": "<hex>",
+//	    "kdf": "scrypt-sm3" | "pbkdf2-sm3",
+//	    "kdfparams": {"dklen": 32, "salt": "<hex>", "n": N, "r": r, "p": p} | {"dklen": 32, "salt": "<hex>", "c": iterations, "prf": "hmac-sm3"},
+//	    "mac": "<hex SM3(dk[16:32] || ciphertext)>"
+//	  }
+//	}
+//
+// The first 16 bytes of the derived key dk are the SM4 key, the private key is
+// encrypted as a 32 bytes big endian integer. The public key and the
+// parameters can be audited with Parse without the password, they are checked
+// against the decrypted key by Open.
+package keystore
+
+import (
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/kdf"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm4"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Version is the version of the format written by this package.
+const Version = 1
+
+// The key derivation functions and ciphers.
+const (
+	KDFScrypt = "scrypt-sm3"
+	KDFPBKDF2 = "pbkdf2-sm3"
+
+	CipherSM4CTR = "sm4-ctr"
+	CipherSM4GCM = "sm4-gcm"
+)
+
+// The scrypt cost parameters of StandardOptions and LightOptions, they use
+// about 256 MB and 4 MB of memory.
+const (
+	StandardScryptN = 1 << 18
+	StandardScryptP = 1
+	LightScryptN    = 1 << 12
+	LightScryptP    = 6
+)
+
+const (
+	algorithmSM2 = "sm2"
+	prfHMACSM3   = "hmac-sm3"
+	dkLen        = 32
+	saltLen      = 32
+	keyLen       = 32
+
+	// bounds of the parameters accepted by Open, so that a crafted file
+	// can't exhaust the memory or the CPU.
+	maxScryptN      = 1 << 22
+	maxScryptRP     = 1 << 10
+	maxIterations   = 1 << 24
+	pbkdf2MinRounds = 1000
+)
+
+var (
+	// ErrDecrypt is returned by Open if the password is wrong or the file has
+	// been modified.
+	ErrDecrypt = errors.New("keystore: could not decrypt key with given password")
+	// ErrUnsupportedVersion is returned for files of an unknown version.
+	ErrUnsupportedVersion = errors.New("keystore: unsupported version")
+)
+
+// Options select the algorithms and cost parameters of Encrypt.
+type Options struct {
+	KDF string // KDFScrypt or KDFPBKDF2
+	// scrypt-sm3 parameters
+	ScryptN, ScryptR, ScryptP int
+	// pbkdf2-sm3 parameter
+	Iterations int
+
+	Cipher string // CipherSM4CTR or CipherSM4GCM
+}
+
+// StandardOptions is the default, scrypt-sm3 with N = 2^18 and SM4-CTR.
+var StandardOptions = Options{KDF: KDFScrypt, ScryptN: StandardScryptN, ScryptR: 8, ScryptP: StandardScryptP, Cipher: CipherSM4CTR}
+
+// LightOptions is scrypt-sm3 with N = 2^12, for devices with little memory.
+var LightOptions = Options{KDF: KDFScrypt, ScryptN: LightScryptN, ScryptR: 8, ScryptP: LightScryptP, Cipher: CipherSM4CTR}
+
+// Key is a keystore file.
+type Key struct {
+	Version   int        `json:"version"`
+	ID        string     `json:"id"`
+	Algorithm string     `json:"algorithm"`
+	PublicKey string     `json:"publickey"`
+	Crypto    CryptoJSON `json:"crypto"`
+}
+
+// CryptoJSON is the encrypted private key and its parameters.
+type CryptoJSON struct {
+	Cipher       string           `json:"cipher"`
+	CipherParams CipherParamsJSON `json:"cipherparams"`
+	CipherText   string           `json:"ciphertext"`
+	KDF          string           `json:"kdf"`
+	KDFParams    KDFParamsJSON    `json:"kdfparams"`
+	MAC          string           `json:"mac"`
+}
+
+// CipherParamsJSON are the parameters of the cipher.
+type CipherParamsJSON struct {
+	IV string `json:"iv"`
+}
+
+// KDFParamsJSON are the parameters of the key derivation function, N, R and
+// P for scrypt-sm3, C and PRF for pbkdf2-sm3.
+type KDFParamsJSON struct {
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+	N     int    `json:"n,omitempty"`
+	R     int    `json:"r,omitempty"`
+	P     int    `json:"p,omitempty"`
+	C     int    `json:"c,omitempty"`
+	PRF   string `json:"prf,omitempty"`
+}
+
+// Create generates a new SM2 key and returns it with its keystore file.
+// If opts is nil, StandardOptions is used.
+func Create(password []byte, opts *Options) (*sm2.PrivateKey, []byte, error) {
+	priv, err := sm2.GenerateKey(randutil.Reader())
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := Encrypt(priv, password, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return priv, data, nil
+}
+
+// Encrypt returns the keystore file of priv protected by password. If opts is
+// nil, StandardOptions is used.
+func Encrypt(priv *sm2.PrivateKey, password []byte, opts *Options) ([]byte, error) {
+	if opts == nil {
+		opts = &StandardOptions
+	}
+	if priv == nil || priv.Curve != sm2.P256() {
+		return nil, errors.New("keystore: not a SM2 private key")
+	}
+	salt := make([]byte, saltLen)
+	if _, err := io.ReadFull(randutil.Reader(), salt); err != nil {
+		return nil, err
+	}
+	c := CryptoJSON{Cipher: opts.Cipher, KDF: opts.KDF}
+	c.KDFParams = KDFParamsJSON{DKLen: dkLen, Salt: hex.EncodeToString(salt)}
+	switch opts.KDF {
+	case KDFScrypt:
+		c.KDFParams.N, c.KDFParams.R, c.KDFParams.P = opts.ScryptN, opts.ScryptR, opts.ScryptP
+	case KDFPBKDF2:
+		c.KDFParams.C, c.KDFParams.PRF = opts.Iterations, prfHMACSM3
+	}
+	if err := checkKDFParams(c.KDF, &c.KDFParams); err != nil {
+		return nil, err
+	}
+	dk, err := deriveKey(password, salt, c.KDF, &c.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+
+	ivLen, err := ivSize(c.Cipher)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, ivLen)
+	if _, err := io.ReadFull(randutil.Reader(), iv); err != nil {
+		return nil, err
+	}
+	plaintext := make([]byte, keyLen)
+	priv.D.FillBytes(plaintext)
+	ciphertext, err := crypt(c.Cipher, dk[:16], iv, plaintext, true)
+	if err != nil {
+		return nil, err
+	}
+	c.CipherParams.IV = hex.EncodeToString(iv)
+	c.CipherText = hex.EncodeToString(ciphertext)
+	c.MAC = hex.EncodeToString(mac(dk, ciphertext))
+
+	id, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(&Key{
+		Version:   Version,
+		ID:        id,
+		Algorithm: algorithmSM2,
+		PublicKey: hex.EncodeToString(elliptic.Marshal(priv.Curve, priv.X, priv.Y)),
+		Crypto:    c,
+	}, "", "  ")
+}
+
+// Parse parses a keystore file without decrypting it, e.g. to audit its
+// parameters or to find a key by its public key.
+func Parse(data []byte) (*Key, error) {
+	k := new(Key)
+	if err := json.Unmarshal(data, k); err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	if k.Version != Version {
+		return nil, ErrUnsupportedVersion
+	}
+	if k.Algorithm != algorithmSM2 {
+		return nil, fmt.Errorf("keystore: unsupported algorithm %q", k.Algorithm)
+	}
+	if err := checkKDFParams(k.Crypto.KDF, &k.Crypto.KDFParams); err != nil {
+		return nil, err
+	}
+	if _, err := ivSize(k.Crypto.Cipher); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Public returns the public key of the file.
+func (k *Key) Public() (*ecdsa.PublicKey, error) {
+	b, err := hex.DecodeString(k.PublicKey)
+	if err != nil {
+		return nil, errors.New("keystore: invalid public key")
+	}
+	return sm2.NewPublicKey(b)
+}
+
+// Open decrypts a keystore file with password.
+func Open(data, password []byte) (*sm2.PrivateKey, error) {
+	k, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return k.Decrypt(password)
+}
+
+// Decrypt decrypts the private key with password.
+func (k *Key) Decrypt(password []byte) (*sm2.PrivateKey, error) {
+	c := &k.Crypto
+	salt, err1 := hex.DecodeString(c.KDFParams.Salt)
+	iv, err2 := hex.DecodeString(c.CipherParams.IV)
+	ciphertext, err3 := hex.DecodeString(c.CipherText)
+	expectedMAC, err4 := hex.DecodeString(c.MAC)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return nil, errors.New("keystore: invalid hex encoding")
+	}
+	if ivLen, err := ivSize(c.Cipher); err != nil || len(iv) != ivLen {
+		return nil, errors.New("keystore: invalid iv")
+	}
+	if err := checkKDFParams(c.KDF, &c.KDFParams); err != nil {
+		return nil, err
+	}
+	dk, err := deriveKey(password, salt, c.KDF, &c.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(mac(dk, ciphertext), expectedMAC) != 1 {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := crypt(c.Cipher, dk[:16], iv, ciphertext, false)
+	if err != nil || len(plaintext) != keyLen {
+		return nil, ErrDecrypt
+	}
+	priv, err := sm2.NewPrivateKey(plaintext)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	pub, err := k.Public()
+	if err != nil {
+		return nil, err
+	}
+	if !pub.Equal(&priv.PublicKey) {
+		return nil, errors.New("keystore: public key doesn't match the private key")
+	}
+	return priv, nil
+}
+
+func checkKDFParams(name string, p *KDFParamsJSON) error {
+	if p.DKLen != dkLen {
+		return errors.New("keystore: invalid dklen")
+	}
+	switch name {
+	case KDFScrypt:
+		if p.N <= 1 || p.N&(p.N-1) != 0 || p.N > maxScryptN || p.R <= 0 || p.P <= 0 || p.R*p.P > maxScryptRP {
+			return errors.New("keystore: invalid scrypt-sm3 parameters")
+		}
+	case KDFPBKDF2:
+		if p.PRF != prfHMACSM3 || p.C < pbkdf2MinRounds || p.C > maxIterations {
+			return errors.New("keystore: invalid pbkdf2-sm3 parameters")
+		}
+	default:
+		return fmt.Errorf("keystore: unsupported kdf %q", name)
+	}
+	return nil
+}
+
+func deriveKey(password, salt []byte, name string, p *KDFParamsJSON) ([]byte, error) {
+	if name == KDFPBKDF2 {
+		return pbkdf2.Key(password, salt, p.C, p.DKLen, sm3.New), nil
+	}
+	return kdf.Scrypt(password, salt, p.N, p.R, p.P, p.DKLen)
+}
+
+func mac(dk, ciphertext []byte) []byte {
+	h := sm3.New()
+	h.Write(dk[16:32])
+	h.Write(ciphertext)
+	return h.Sum(nil)
+}
+
+func ivSize(name string) (int, error) {
+	switch name {
+	case CipherSM4CTR:
+		return sm4.BlockSize, nil
+	case CipherSM4GCM:
+		return 12, nil
+	}
+	return 0, fmt.Errorf("keystore: unsupported cipher %q", name)
+}
+
+func crypt(name string, key, iv, in []byte, encrypt bool) ([]byte, error) {
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if name == CipherSM4CTR {
+		out := make([]byte, len(in))
+		cipher.NewCTR(block, iv).XORKeyStream(out, in)
+		return out, nil
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if encrypt {
+		return aead.Seal(nil, iv, in, nil), nil
+	}
+	return aead.Open(nil, iv, in, nil)
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() (string, error) {
+	var u [16]byte
+	if _, err := io.ReadFull(randutil.Reader(), u[:]); err != nil {
+		return "", err
+	}
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:]), nil
+}
@@ -0,0 +1,117 @@
+package keystore
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testOptions are cheap options, the standard ones take too long in tests.
+var testOptions = []Options{
+	{KDF: KDFScrypt, ScryptN: 1 << 10, ScryptR: 8, ScryptP: 1, Cipher: CipherSM4CTR},
+	{KDF: KDFScrypt, ScryptN: 1 << 10, ScryptR: 8, ScryptP: 1, Cipher: CipherSM4GCM},
+	{KDF: KDFPBKDF2, Iterations: 1000, Cipher: CipherSM4CTR},
+	{KDF: KDFPBKDF2, Iterations: 1000, Cipher: CipherSM4GCM},
+}
+
+func TestRoundTrip(t *testing.T) {
+	password := []byte("foo")
+	for _, opts := range testOptions {
+		opts := opts
+		priv, data, err := Create(password, &opts)
+		if err != nil {
+			t.Fatalf("%s/%s: %v", opts.KDF, opts.Cipher, err)
+		}
+		k, err := Parse(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k.Crypto.KDF != opts.KDF || k.Crypto.Cipher != opts.Cipher || len(k.ID) != 36 {
+			t.Errorf("unexpected key file %s", data)
+		}
+		if pub, err := k.Public(); err != nil || !pub.Equal(&priv.PublicKey) {
+			t.Errorf("%s/%s: public key mismatch: %v", opts.KDF, opts.Cipher, err)
+		}
+		got, err := Open(data, password)
+		if err != nil {
+			t.Fatalf("%s/%s: %v", opts.KDF, opts.Cipher, err)
+		}
+		if !got.Equal(priv) {
+			t.Errorf("%s/%s: private key mismatch", opts.KDF, opts.Cipher)
+		}
+		if _, err := Open(data, []byte("bar")); err != ErrDecrypt {
+			t.Errorf("%s/%s: got %v, want ErrDecrypt", opts.KDF, opts.Cipher, err)
+		}
+	}
+}
+
+const testKeyFile = `{
+  "version": 1,
+  "id": "484c942d-afd0-46e2-976e-83f498fcdb39",
+  "algorithm": "sm2",
+  "publickey": "043787cb35e445fd27456c0bfc8725c09511af395896b968d1946b65c8b1ec1f6fe64fd4378d9e9933c1abb0af72de2b43a88b86e5aaa7085ea9362184ef54c53a",
+  "crypto": {
+    "cipher": "sm4-gcm",
+    "cipherparams": {
+      "iv": "75482e6c12802155ae2d22a2"
+    },
+    "ciphertext": "1e080e1532e91623ee76425495fe76c08d64801ea0a21eb1d3477af230688d48feff1345e8ae8a7f7c45784fe370a550",
+    "kdf": "pbkdf2-sm3",
+    "kdfparams": {
+      "dklen": 32,
+      "salt": "e898d730cb3d1dbae452f46c227a614e70e41ab284c14840e16fb4134b16fb18",
+      "c": 1000,
+      "prf": "hmac-sm3"
+    },
+    "mac": "bc40f553267c5fe8c2bc14b9fa2662485c63e5c2ea5a5c2a9c978ee8df72e4e4"
+  }
+}`
+
+func TestOpenKnownFile(t *testing.T) {
+	priv, err := Open([]byte(testKeyFile), []byte("testpassword"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := hex.DecodeString("6c5a0a0b2eed3cbec3e4f1252bfe0e28c504a1c6bf1999eaacd2c2b8d8a2e7b5")
+	if got := priv.D.FillBytes(make([]byte, 32)); !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
+
+func TestInvalidFiles(t *testing.T) {
+	password := []byte("testpassword")
+	for _, tt := range []struct {
+		name, old, new string
+		err            error
+	}{
+		{"version", `"version": 1`, `"version": 3`, ErrUnsupportedVersion},
+		{"ciphertext", `"ciphertext": "1e`, `"ciphertext": "1f`, ErrDecrypt},
+		{"mac", `"mac": "bc`, `"mac": "bd`, ErrDecrypt},
+		{"salt", `"salt": "e8`, `"salt": "e9`, ErrDecrypt},
+		{"iv", `"iv": "75`, `"iv": "76`, ErrDecrypt},
+		{"public key", `"publickey": "0437`, `"publickey": "04ff`, nil},
+		{"algorithm", `"algorithm": "sm2"`, `"algorithm": "secp256k1"`, nil},
+		{"cipher", `"cipher": "sm4-gcm"`, `"cipher": "aes-128-ctr"`, nil},
+		{"kdf", `"kdf": "pbkdf2-sm3"`, `"kdf": "pbkdf2"`, nil},
+		{"prf", `"prf": "hmac-sm3"`, `"prf": "hmac-sha256"`, nil},
+		{"iterations", `"c": 1000`, `"c": 1`, nil},
+		{"dklen", `"dklen": 32`, `"dklen": 16`, nil},
+		{"scrypt without parameters", `"kdf": "pbkdf2-sm3"`, `"kdf": "scrypt-sm3"`, nil},
+	} {
+		data := strings.Replace(testKeyFile, tt.old, tt.new, 1)
+		if data == testKeyFile {
+			t.Fatalf("%s: replacement not found", tt.name)
+		}
+		_, err := Open([]byte(data), password)
+		if err == nil || tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	opts := Options{KDF: KDFScrypt, ScryptN: 1 << 23, ScryptR: 8, ScryptP: 1, Cipher: CipherSM4CTR}
+	if _, _, err := Create(nil, &opts); err == nil {
+		t.Error("expected error with too expensive scrypt parameters")
+	}
+}
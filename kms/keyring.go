@@ -0,0 +1,116 @@
+package kms
+
+import (
+	"context"
+	"crypto/elliptic"
+	"sync"
+
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm9"
+)
+
+type sm9EncryptKey struct {
+	uid  []byte
+	priv *sm9.EncryptPrivateKey
+}
+
+// KeyRing is an in-memory SM2Provider and SM9Provider. It is safe for
+// concurrent use.
+type KeyRing struct {
+	mu      sync.RWMutex
+	sm2     map[string]*sm2.PrivateKey
+	sm9Sign map[string]*sm9.SignPrivateKey
+	sm9Enc  map[string]sm9EncryptKey
+}
+
+// NewKeyRing returns an empty key ring.
+func NewKeyRing() *KeyRing {
+	return &KeyRing{
+		sm2:     make(map[string]*sm2.PrivateKey),
+		sm9Sign: make(map[string]*sm9.SignPrivateKey),
+		sm9Enc:  make(map[string]sm9EncryptKey),
+	}
+}
+
+// AddSM2 adds the SM2 key priv as keyID, replacing any previous one.
+func (k *KeyRing) AddSM2(keyID string, priv *sm2.PrivateKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.sm2[keyID] = priv
+}
+
+// AddSM9Sign adds the SM9 signing key priv as keyID, replacing any previous
+// one.
+func (k *KeyRing) AddSM9Sign(keyID string, priv *sm9.SignPrivateKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.sm9Sign[keyID] = priv
+}
+
+// AddSM9Encrypt adds the SM9 encryption key priv of the user uid as keyID,
+// replacing any previous one.
+func (k *KeyRing) AddSM9Encrypt(keyID string, uid []byte, priv *sm9.EncryptPrivateKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.sm9Enc[keyID] = sm9EncryptKey{uid: append([]byte(nil), uid...), priv: priv}
+}
+
+func (k *KeyRing) sm2Key(keyID string) (*sm2.PrivateKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	priv, ok := k.sm2[keyID]
+	if !ok {
+		return nil, errUnknownKey
+	}
+	return priv, nil
+}
+
+// SM2PublicKey implements SM2Provider.
+func (k *KeyRing) SM2PublicKey(ctx context.Context, keyID string) ([]byte, error) {
+	priv, err := k.sm2Key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return elliptic.Marshal(priv.Curve, priv.X, priv.Y), nil
+}
+
+// SignSM2 implements SM2Provider.
+func (k *KeyRing) SignSM2(ctx context.Context, keyID string, digest []byte) ([]byte, error) {
+	priv, err := k.sm2Key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return sm2.SignASN1(randutil.Reader(), priv, digest, nil)
+}
+
+// DecryptSM2 implements SM2Provider.
+func (k *KeyRing) DecryptSM2(ctx context.Context, keyID string, ciphertext []byte) ([]byte, error) {
+	priv, err := k.sm2Key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return priv.Decrypt(nil, ciphertext, sm2.ASN1DecrypterOpts)
+}
+
+// SignSM9 implements SM9Provider.
+func (k *KeyRing) SignSM9(ctx context.Context, keyID string, hash []byte) ([]byte, error) {
+	k.mu.RLock()
+	priv, ok := k.sm9Sign[keyID]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, errUnknownKey
+	}
+	return sm9.SignASN1(randutil.Reader(), priv, hash)
+}
+
+// DecryptSM9 implements SM9Provider.
+func (k *KeyRing) DecryptSM9(ctx context.Context, keyID string, ciphertext []byte) ([]byte, error) {
+	k.mu.RLock()
+	key, ok := k.sm9Enc[keyID]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, errUnknownKey
+	}
+	return key.priv.DecryptASN1(key.uid, ciphertext)
+}
@@ -0,0 +1,149 @@
+// Package kms delegates SM2 and SM9 private key operations to a remote key
+// management service, while this module keeps the encoding, the hashing and
+// the ZA computation local: the KMS only signs digests and decrypts ASN.1
+// ciphertexts with keys it never exports.
+//
+// A KMS is an SM2Provider and/or an SM9Provider. NewSM2Signer and
+// NewSM9Signer turn a provider key into a crypto.Signer (and a
+// crypto.Decrypter for SM2), which can be used with smx509, pkcs7 and the
+// other packages of this module like a local key.
+//
+// The reference adapters call a KMS over REST (NewRESTClient, served by
+// NewRESTHandler) or gRPC (NewGRPCClient, served by Dispatch), with the same
+// Request and Response messages. KeyRing is an in-memory provider, e.g. to
+// back a KMS or for tests.
+package kms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"errors"
+	"io"
+
+	"github.com/emmansun/gmsm/codec"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm9"
+)
+
+// SM2Provider performs the operations of SM2 private keys, identified by the
+// key ID, in a KMS.
+type SM2Provider interface {
+	// SM2PublicKey returns the uncompressed public key of the key.
+	SM2PublicKey(ctx context.Context, keyID string) ([]byte, error)
+	// SignSM2 signs the digest, which is already the SM2 hash (with ZA) of the
+	// message, and returns the ASN.1 encoded signature.
+	SignSM2(ctx context.Context, keyID string, digest []byte) ([]byte, error)
+	// DecryptSM2 decrypts the ASN.1 encoded ciphertext.
+	DecryptSM2(ctx context.Context, keyID string, ciphertext []byte) ([]byte, error)
+}
+
+// SM9Provider performs the operations of SM9 user private keys, identified by
+// the key ID, in a KMS.
+type SM9Provider interface {
+	// SignSM9 signs the hash of the message and returns the ASN.1 encoded
+	// signature.
+	SignSM9(ctx context.Context, keyID string, hash []byte) ([]byte, error)
+	// DecryptSM9 decrypts the ASN.1 encoded ciphertext, the user identity is
+	// the one of the key.
+	DecryptSM9(ctx context.Context, keyID string, ciphertext []byte) ([]byte, error)
+}
+
+// SM2Signer is a SM2 key of a KMS, it implements crypto.Signer and
+// crypto.Decrypter like sm2.PrivateKey.
+type SM2Signer struct {
+	provider SM2Provider
+	keyID    string
+	pub      *ecdsa.PublicKey
+}
+
+// NewSM2Signer returns the signer of the SM2 key keyID of the provider, it
+// fetches the public key.
+func NewSM2Signer(ctx context.Context, provider SM2Provider, keyID string) (*SM2Signer, error) {
+	b, err := provider.SM2PublicKey(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := sm2.NewPublicKey(b)
+	if err != nil {
+		return nil, err
+	}
+	return &SM2Signer{provider: provider, keyID: keyID, pub: pub}, nil
+}
+
+// Public returns the *ecdsa.PublicKey of the key.
+func (s *SM2Signer) Public() crypto.PublicKey {
+	return s.pub
+}
+
+// Sign is SignContext with the background context, rand is not used.
+func (s *SM2Signer) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.SignContext(context.Background(), digest, opts)
+}
+
+// SignContext signs digest like sm2.PrivateKey.Sign: if opts is a
+// *sm2.SM2SignerOption with ForceGMSign, digest is the raw message and its SM2
+// hash with the UID of opts is computed locally.
+func (s *SM2Signer) SignContext(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if sm2Opts, ok := opts.(*sm2.SM2SignerOption); ok && sm2Opts.ForceGMSign() {
+		hash, err := sm2.CalculateSM2Hash(s.pub, digest, sm2Opts.UID())
+		if err != nil {
+			return nil, err
+		}
+		digest = hash
+	}
+	return s.provider.SignSM2(ctx, s.keyID, digest)
+}
+
+// Decrypt is DecryptContext with the background context, rand is not used.
+func (s *SM2Signer) Decrypt(rand io.Reader, msg []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	return s.DecryptContext(context.Background(), msg, opts)
+}
+
+// DecryptContext decrypts msg like sm2.PrivateKey.Decrypt, opts is a
+// *sm2.DecrypterOpts or nil for plain C1C3C2, ASN.1 ciphertexts are detected
+// by their leading SEQUENCE tag. Plain ciphertexts are converted to ASN.1
+// locally.
+func (s *SM2Signer) DecryptContext(ctx context.Context, msg []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	encoding, order := sm2.ENCODING_PLAIN, sm2.C1C3C2
+	if sm2Opts, ok := opts.(*sm2.DecrypterOpts); ok && sm2Opts != nil {
+		encoding, order = sm2Opts.Encoding(), sm2Opts.SplicingOrder()
+	}
+	if encoding == sm2.ENCODING_PLAIN && (len(msg) == 0 || msg[0] != 0x30) {
+		var err error
+		if msg, err = codec.CiphertextPlainToASN1(msg, order); err != nil {
+			return nil, err
+		}
+	}
+	return s.provider.DecryptSM2(ctx, s.keyID, msg)
+}
+
+// SM9Signer is a SM9 signing key of a KMS, it implements crypto.Signer like
+// sm9.SignPrivateKey.
+type SM9Signer struct {
+	provider SM9Provider
+	keyID    string
+	master   *sm9.SignMasterPublicKey
+}
+
+// NewSM9Signer returns the signer of the SM9 key keyID of the provider,
+// issued by the master public key.
+func NewSM9Signer(provider SM9Provider, keyID string, master *sm9.SignMasterPublicKey) *SM9Signer {
+	return &SM9Signer{provider: provider, keyID: keyID, master: master}
+}
+
+// Public returns the *sm9.SignMasterPublicKey of the key.
+func (s *SM9Signer) Public() crypto.PublicKey {
+	return s.master
+}
+
+// Sign signs the hash with the background context, rand and opts are not
+// used.
+func (s *SM9Signer) Sign(rand io.Reader, hash []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.provider.SignSM9(context.Background(), s.keyID, hash)
+}
+
+var (
+	errUnknownKey       = errors.New("kms: unknown key")
+	errUnknownOperation = errors.New("kms: unknown operation")
+)
@@ -0,0 +1,194 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm9"
+)
+
+var (
+	_ crypto.Signer    = (*SM2Signer)(nil)
+	_ crypto.Decrypter = (*SM2Signer)(nil)
+	_ crypto.Signer    = (*SM9Signer)(nil)
+	_ SM2Provider      = (*Client)(nil)
+	_ SM9Provider      = (*Client)(nil)
+)
+
+type testKeys struct {
+	ring      *KeyRing
+	sm2       *sm2.PrivateKey
+	sm9Master *sm9.SignMasterPrivateKey
+	encMaster *sm9.EncryptMasterPrivateKey
+}
+
+const sm9UID = "alice"
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+	k := &testKeys{ring: NewKeyRing()}
+	var err error
+	if k.sm2, err = sm2.GenerateKey(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	k.ring.AddSM2("sm2", k.sm2)
+	if k.sm9Master, err = sm9.GenerateSignMasterKey(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	signKey, err := k.sm9Master.GenerateUserKey([]byte(sm9UID), 0x01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.ring.AddSM9Sign("sm9-sign", signKey)
+	if k.encMaster, err = sm9.GenerateEncryptMasterKey(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	encKey, err := k.encMaster.GenerateUserKey([]byte(sm9UID), 0x03)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.ring.AddSM9Encrypt("sm9-enc", []byte(sm9UID), encKey)
+	return k
+}
+
+func testProviders(t *testing.T, k *testKeys) map[string]*Client {
+	server := httptest.NewServer(NewRESTHandler(k.ring))
+	t.Cleanup(server.Close)
+	// the gRPC invoker marshals the messages like a JSON codec.
+	invoke := func(ctx context.Context, method string, req, resp any) error {
+		prefix := "/" + GRPCService + "/"
+		if len(method) <= len(prefix) || method[:len(prefix)] != prefix {
+			return errors.New("unknown service")
+		}
+		b, _ := json.Marshal(req)
+		var r Request
+		if err := json.Unmarshal(b, &r); err != nil {
+			return err
+		}
+		out, err := Dispatch(ctx, k.ring, method[len(prefix):], &r)
+		if err != nil {
+			return err
+		}
+		b, _ = json.Marshal(out)
+		return json.Unmarshal(b, resp)
+	}
+	return map[string]*Client{
+		"rest": NewRESTClient(server.URL+"/v1/", nil),
+		"grpc": NewGRPCClient(invoke),
+	}
+}
+
+func TestSM2Signer(t *testing.T) {
+	k := newTestKeys(t)
+	ctx := context.Background()
+	for name, p := range testProviders(t, k) {
+		s, err := NewSM2Signer(ctx, p, "sm2")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !k.sm2.PublicKey.Equal(s.Public()) {
+			t.Fatalf("%s: public key mismatch", name)
+		}
+
+		msg := []byte("hello world")
+		sig, err := s.Sign(rand.Reader, msg, sm2.DefaultSM2SignerOpts)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !sm2.VerifyASN1WithSM2(&k.sm2.PublicKey, nil, msg, sig) {
+			t.Errorf("%s: invalid signature", name)
+		}
+		uid := []byte("bob@example.com")
+		if sig, err = s.Sign(rand.Reader, msg, sm2.NewSM2SignerOption(true, uid)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !sm2.VerifyASN1WithSM2(&k.sm2.PublicKey, uid, msg, sig) {
+			t.Errorf("%s: invalid signature with uid", name)
+		}
+		digest := sm3.Sum(msg)
+		if sig, err = s.Sign(rand.Reader, digest[:], nil); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !sm2.VerifyASN1(&k.sm2.PublicKey, digest[:], sig) {
+			t.Errorf("%s: invalid signature of digest", name)
+		}
+
+		for _, tt := range []struct {
+			enc *sm2.EncrypterOpts
+			dec crypto.DecrypterOpts
+		}{
+			{sm2.ASN1EncrypterOpts, sm2.ASN1DecrypterOpts},
+			{nil, nil},
+			{sm2.NewPlainEncrypterOpts(sm2.MarshalCompressed, sm2.C1C2C3), sm2.NewPlainDecrypterOpts(sm2.C1C2C3)},
+		} {
+			ciphertext, err := sm2.Encrypt(rand.Reader, &k.sm2.PublicKey, msg, tt.enc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			plaintext, err := s.Decrypt(nil, ciphertext, tt.dec)
+			if err != nil || !bytes.Equal(plaintext, msg) {
+				t.Errorf("%s: got %q, %v", name, plaintext, err)
+			}
+		}
+		// ASN.1 ciphertexts are detected with plain options, like sm2
+		ciphertext, err := sm2.Encrypt(rand.Reader, &k.sm2.PublicKey, msg, sm2.ASN1EncrypterOpts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plaintext, err := s.Decrypt(nil, ciphertext, sm2.NewPlainDecrypterOpts(sm2.C1C2C3)); err != nil || !bytes.Equal(plaintext, msg) {
+			t.Errorf("%s: ASN.1 ciphertext with plain options: %q, %v", name, plaintext, err)
+		}
+
+		var remoteErr *RemoteError
+		if _, err := NewSM2Signer(ctx, p, "unknown"); !errors.As(err, &remoteErr) {
+			t.Errorf("%s: got %v, expected a RemoteError", name, err)
+		}
+	}
+}
+
+func TestSM9(t *testing.T) {
+	k := newTestKeys(t)
+	ctx := context.Background()
+	for name, p := range testProviders(t, k) {
+		s := NewSM9Signer(p, "sm9-sign", k.sm9Master.Public())
+		hash := sm3.Sum([]byte("hello world"))
+		sig, err := s.Sign(rand.Reader, hash[:], nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !sm9.VerifyASN1(k.sm9Master.Public(), []byte(sm9UID), 0x01, hash[:], sig) {
+			t.Errorf("%s: invalid signature", name)
+		}
+
+		msg := []byte("hello sm9")
+		ciphertext, err := sm9.EncryptASN1(rand.Reader, k.encMaster.Public(), []byte(sm9UID), 0x03, msg, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plaintext, err := p.DecryptSM9(ctx, "sm9-enc", ciphertext)
+		if err != nil || !bytes.Equal(plaintext, msg) {
+			t.Errorf("%s: got %q, %v", name, plaintext, err)
+		}
+		if _, err := p.DecryptSM9(ctx, "sm9-sign", ciphertext); err == nil {
+			t.Errorf("%s: expected error with a signing key", name)
+		}
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	if _, err := Dispatch(context.Background(), NewKeyRing(), "Export", &Request{}); err == nil {
+		t.Error("expected unknown operation error")
+	}
+	var onlySM2 SM2Provider = NewKeyRing()
+	if _, err := Dispatch(context.Background(), struct{ SM2Provider }{onlySM2}, OpSignSM9, &Request{}); err == nil {
+		t.Error("expected unknown operation error for an unsupported algorithm")
+	}
+}
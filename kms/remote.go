@@ -0,0 +1,201 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// The operations of the remote protocol, they are the last element of the
+// REST paths and of the gRPC method names.
+const (
+	OpSM2PublicKey = "SM2PublicKey"
+	OpSignSM2      = "SignSM2"
+	OpDecryptSM2   = "DecryptSM2"
+	OpSignSM9      = "SignSM9"
+	OpDecryptSM9   = "DecryptSM9"
+)
+
+// GRPCService is the full name of the gRPC service, the method of an
+// operation is "/" + GRPCService + "/" + op.
+const GRPCService = "gmsm.kms.v1.KeyManagement"
+
+// maxMessageSize bounds the requests and responses of the REST adapters.
+const maxMessageSize = 1 << 20
+
+// Request is the request message of all the operations. Data is the digest,
+// the hash or the ciphertext, it's empty for OpSM2PublicKey.
+type Request struct {
+	KeyID string `json:"key_id"`
+	Data  []byte `json:"data,omitempty"`
+}
+
+// Response is the response message of all the operations. Data is the public
+// key, the signature or the plaintext. Error is the message of a failed
+// operation.
+type Response struct {
+	Data  []byte `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// RemoteError is a failure reported by the KMS.
+type RemoteError struct {
+	Op      string
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return "kms: " + e.Op + ": " + e.Message
+}
+
+// Dispatch performs the operation op of the request with the provider, which
+// is an SM2Provider, an SM9Provider or both. The failures of the operation
+// are returned in the Response, the error is only for an unknown operation.
+// It's the body of a gRPC method handler, or of any other transport.
+func Dispatch(ctx context.Context, provider any, op string, req *Request) (*Response, error) {
+	var (
+		data []byte
+		err  error
+	)
+	sm2p, isSM2 := provider.(SM2Provider)
+	sm9p, isSM9 := provider.(SM9Provider)
+	switch {
+	case op == OpSM2PublicKey && isSM2:
+		data, err = sm2p.SM2PublicKey(ctx, req.KeyID)
+	case op == OpSignSM2 && isSM2:
+		data, err = sm2p.SignSM2(ctx, req.KeyID, req.Data)
+	case op == OpDecryptSM2 && isSM2:
+		data, err = sm2p.DecryptSM2(ctx, req.KeyID, req.Data)
+	case op == OpSignSM9 && isSM9:
+		data, err = sm9p.SignSM9(ctx, req.KeyID, req.Data)
+	case op == OpDecryptSM9 && isSM9:
+		data, err = sm9p.DecryptSM9(ctx, req.KeyID, req.Data)
+	default:
+		return nil, errUnknownOperation
+	}
+	if err != nil {
+		return &Response{Error: err.Error()}, nil
+	}
+	return &Response{Data: data}, nil
+}
+
+// Client is an SM2Provider and SM9Provider of a remote KMS.
+type Client struct {
+	call func(ctx context.Context, op string, req *Request, resp *Response) error
+}
+
+func (c *Client) do(ctx context.Context, op, keyID string, data []byte) ([]byte, error) {
+	var resp Response
+	if err := c.call(ctx, op, &Request{KeyID: keyID, Data: data}, &resp); err != nil {
+		return nil, fmt.Errorf("kms: %s: %w", op, err)
+	}
+	if resp.Error != "" {
+		return nil, &RemoteError{Op: op, Message: resp.Error}
+	}
+	return resp.Data, nil
+}
+
+// SM2PublicKey implements SM2Provider.
+func (c *Client) SM2PublicKey(ctx context.Context, keyID string) ([]byte, error) {
+	return c.do(ctx, OpSM2PublicKey, keyID, nil)
+}
+
+// SignSM2 implements SM2Provider.
+func (c *Client) SignSM2(ctx context.Context, keyID string, digest []byte) ([]byte, error) {
+	return c.do(ctx, OpSignSM2, keyID, digest)
+}
+
+// DecryptSM2 implements SM2Provider.
+func (c *Client) DecryptSM2(ctx context.Context, keyID string, ciphertext []byte) ([]byte, error) {
+	return c.do(ctx, OpDecryptSM2, keyID, ciphertext)
+}
+
+// SignSM9 implements SM9Provider.
+func (c *Client) SignSM9(ctx context.Context, keyID string, hash []byte) ([]byte, error) {
+	return c.do(ctx, OpSignSM9, keyID, hash)
+}
+
+// DecryptSM9 implements SM9Provider.
+func (c *Client) DecryptSM9(ctx context.Context, keyID string, ciphertext []byte) ([]byte, error) {
+	return c.do(ctx, OpDecryptSM9, keyID, ciphertext)
+}
+
+// NewRESTClient returns a client of the KMS at baseURL, which POSTs the JSON
+// encoded Request of an operation to baseURL + "/" + op and reads the JSON
+// encoded Response. If client is nil, http.DefaultClient is used.
+func NewRESTClient(baseURL string, client *http.Client) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return &Client{call: func(ctx context.Context, op string, req *Request, resp *Response) error {
+		body, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/"+op, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpResp, err := client.Do(httpReq)
+		if err != nil {
+			return err
+		}
+		defer httpResp.Body.Close()
+		if httpResp.StatusCode != http.StatusOK {
+			return errors.New("unexpected HTTP status " + httpResp.Status)
+		}
+		return json.NewDecoder(io.LimitReader(httpResp.Body, maxMessageSize)).Decode(resp)
+	}}
+}
+
+// NewRESTHandler returns the http.Handler of the REST protocol of
+// NewRESTClient, serving the provider, which is an SM2Provider, an
+// SM9Provider or both. Authentication and authorization are left to the
+// enclosing handlers.
+func NewRESTHandler(provider any) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		op := r.URL.Path[strings.LastIndexByte(r.URL.Path, '/')+1:]
+		var req Request
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxMessageSize)).Decode(&req); err != nil {
+			http.Error(w, "malformed request", http.StatusBadRequest)
+			return
+		}
+		resp, err := Dispatch(r.Context(), provider, op, &req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+}
+
+// GRPCInvoker invokes the gRPC method with the request and fills the
+// response, e.g. a closure calling Invoke of a *grpc.ClientConn with a JSON
+// codec:
+//
+//	func(ctx context.Context, method string, req, resp any) error {
+//		return conn.Invoke(ctx, method, req, resp, grpc.CallContentSubtype("json"))
+//	}
+//
+// so that this module doesn't depend on gRPC.
+type GRPCInvoker func(ctx context.Context, method string, req, resp any) error
+
+// NewGRPCClient returns a client of the KMS service GRPCService, invoking its
+// methods with invoke. The server side handlers call Dispatch.
+func NewGRPCClient(invoke GRPCInvoker) *Client {
+	return &Client{call: func(ctx context.Context, op string, req *Request, resp *Response) error {
+		return invoke(ctx, "/"+GRPCService+"/"+op, req, resp)
+	}}
+}
@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the default upper bounds of the latency histograms,
+// from fast SM2 verifications to SM9 operations on slow hardware.
+var DefaultBuckets = []time.Duration{
+	10 * time.Microsecond,
+	25 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+}
+
+// Stats are the statistics of one operation of a primitive.
+type Stats struct {
+	Primitive string
+	Operation string
+	Count     uint64
+	Errors    uint64
+	Sum       time.Duration
+	// Buckets are the cumulative counts of the operations whose latency is
+	// less than or equal to the upper bound of the bucket with the same index.
+	Buckets []uint64
+}
+
+type opKey struct {
+	primitive, operation string
+}
+
+// Collector counts the operations and their latencies in histograms. It is
+// safe for concurrent use.
+type Collector struct {
+	buckets []time.Duration
+
+	mu    sync.Mutex
+	stats map[opKey]*Stats
+	now   func() time.Time
+}
+
+// NewCollector returns a Collector with the upper bounds of the latency
+// histograms, which must be increasing, DefaultBuckets if nil.
+func NewCollector(buckets []time.Duration) (*Collector, error) {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return nil, errors.New("metrics: bucket upper bounds must be increasing")
+		}
+	}
+	return &Collector{
+		buckets: append([]time.Duration{}, buckets...),
+		stats:   make(map[opKey]*Stats),
+		now:     time.Now,
+	}, nil
+}
+
+// Buckets returns the upper bounds of the latency histograms.
+func (c *Collector) Buckets() []time.Duration {
+	return append([]time.Duration{}, c.buckets...)
+}
+
+// Hook returns the Hook which records the operations in c.
+func (c *Collector) Hook() Hook {
+	return func(primitive, operation string) func(error) {
+		start := c.now()
+		return func(err error) {
+			c.record(primitive, operation, c.now().Sub(start), err)
+		}
+	}
+}
+
+func (c *Collector) record(primitive, operation string, d time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := opKey{primitive, operation}
+	s, ok := c.stats[k]
+	if !ok {
+		s = &Stats{Primitive: primitive, Operation: operation, Buckets: make([]uint64, len(c.buckets))}
+		c.stats[k] = s
+	}
+	s.Count++
+	if err != nil {
+		s.Errors++
+	}
+	s.Sum += d
+	for i := len(c.buckets) - 1; i >= 0 && d <= c.buckets[i]; i-- {
+		s.Buckets[i]++
+	}
+}
+
+// Snapshot returns the statistics of all recorded operations, sorted by
+// primitive and operation.
+func (c *Collector) Snapshot() []Stats {
+	c.mu.Lock()
+	out := make([]Stats, 0, len(c.stats))
+	for _, s := range c.stats {
+		cp := *s
+		cp.Buckets = append([]uint64{}, s.Buckets...)
+		out = append(out, cp)
+	}
+	c.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Primitive != out[j].Primitive {
+			return out[i].Primitive < out[j].Primitive
+		}
+		return out[i].Operation < out[j].Operation
+	})
+	return out
+}
+
+// Reset clears the recorded statistics.
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	c.stats = make(map[opKey]*Stats)
+	c.mu.Unlock()
+}
+
+// WritePrometheus writes the statistics in the Prometheus text exposition
+// format: the counters gmsm_operations_total and gmsm_operation_errors_total
+// and the histogram gmsm_operation_duration_seconds, labelled with primitive
+// and operation. It can be served as is by an HTTP handler of /metrics.
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	stats := c.Snapshot()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# HELP gmsm_operations_total Number of cryptographic operations.")
+	fmt.Fprintln(bw, "# TYPE gmsm_operations_total counter")
+	for _, s := range stats {
+		fmt.Fprintf(bw, "gmsm_operations_total{%s} %d\n", labels(&s), s.Count)
+	}
+	fmt.Fprintln(bw, "# HELP gmsm_operation_errors_total Number of failed cryptographic operations.")
+	fmt.Fprintln(bw, "# TYPE gmsm_operation_errors_total counter")
+	for _, s := range stats {
+		fmt.Fprintf(bw, "gmsm_operation_errors_total{%s} %d\n", labels(&s), s.Errors)
+	}
+	fmt.Fprintln(bw, "# HELP gmsm_operation_duration_seconds Latency of cryptographic operations.")
+	fmt.Fprintln(bw, "# TYPE gmsm_operation_duration_seconds histogram")
+	for _, s := range stats {
+		l := labels(&s)
+		for i, b := range c.buckets {
+			fmt.Fprintf(bw, "gmsm_operation_duration_seconds_bucket{%s,le=\"%s\"} %d\n", l, seconds(b), s.Buckets[i])
+		}
+		fmt.Fprintf(bw, "gmsm_operation_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", l, s.Count)
+		fmt.Fprintf(bw, "gmsm_operation_duration_seconds_sum{%s} %s\n", l, seconds(s.Sum))
+		fmt.Fprintf(bw, "gmsm_operation_duration_seconds_count{%s} %d\n", l, s.Count)
+	}
+	return bw.Flush()
+}
+
+func labels(s *Stats) string {
+	return "primitive=" + strconv.Quote(s.Primitive) + ",operation=" + strconv.Quote(s.Operation)
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
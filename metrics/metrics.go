@@ -0,0 +1,58 @@
+// Package metrics instruments the operations of this module, so that
+// operators can see the rates and latencies of sign, verify, encrypt, decrypt
+// and pairing operations and detect performance regressions after upgrades.
+//
+// The instrumentation is disabled by default. SetHook installs a Hook, which
+// is called when an operation starts and returns the function called with its
+// result. The Collector of this package counts the operations and their
+// latencies and exposes them in the Prometheus text format, other systems are
+// plugged in with their own Hook, e.g. an OpenTelemetry span per operation:
+//
+//	metrics.SetHook(metrics.Chain(collector.Hook(), func(primitive, operation string) func(error) {
+//		_, span := tracer.Start(context.Background(), primitive+"."+operation)
+//		return func(err error) {
+//			if err != nil {
+//				span.SetStatus(codes.Error, err.Error())
+//			}
+//			span.End()
+//		}
+//	}))
+//
+// The instrumented operations are, per primitive:
+//
+//   - sm2: sign, verify, encrypt, decrypt
+//   - sm9: sign, verify, encrypt, decrypt, pairing
+package metrics
+
+import (
+	"github.com/emmansun/gmsm/internal/instrument"
+)
+
+// Hook is called when an operation of primitive starts, done is called with
+// the result of the operation, which is ErrVerification for a signature
+// which failed to verify. Hooks are called concurrently and must be fast.
+type Hook func(primitive, operation string) (done func(err error))
+
+// ErrVerification is the result of a signature verification which failed.
+var ErrVerification = instrument.ErrVerification
+
+// SetHook installs the hook of all operations, nil disables the
+// instrumentation.
+func SetHook(h Hook) {
+	instrument.SetHook(instrument.Hook(h))
+}
+
+// Chain returns a Hook which calls all hooks.
+func Chain(hooks ...Hook) Hook {
+	return func(primitive, operation string) func(error) {
+		dones := make([]func(error), len(hooks))
+		for i, h := range hooks {
+			dones[i] = h(primitive, operation)
+		}
+		return func(err error) {
+			for _, done := range dones {
+				done(err)
+			}
+		}
+	}
+}
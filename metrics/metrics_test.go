@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"bytes"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm9/bn256"
+)
+
+func findStats(stats []Stats, primitive, operation string) *Stats {
+	for i := range stats {
+		if stats[i].Primitive == primitive && stats[i].Operation == operation {
+			return &stats[i]
+		}
+	}
+	return nil
+}
+
+func TestInstrumentation(t *testing.T) {
+	c, err := NewCollector(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var traced []string
+	tracer := func(primitive, operation string) func(error) {
+		return func(err error) {
+			traced = append(traced, primitive+"."+operation)
+		}
+	}
+	SetHook(Chain(c.Hook(), tracer))
+	defer SetHook(nil)
+
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := make([]byte, 32)
+	sig, err := sm2.SignASN1(rand.Reader, priv, hash, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm2.VerifyASN1(&priv.PublicKey, hash, sig)
+	sm2.VerifyASN1(&priv.PublicKey, []byte("other hash"), sig)
+	bn256.Pair(bn256.Gen1, bn256.Gen2)
+
+	stats := c.Snapshot()
+	for _, tt := range []struct {
+		primitive, operation string
+		count, errors        uint64
+	}{
+		{"sm2", "sign", 1, 0},
+		{"sm2", "verify", 2, 1},
+		{"sm9", "pairing", 1, 0},
+	} {
+		s := findStats(stats, tt.primitive, tt.operation)
+		if s == nil {
+			t.Errorf("%s.%s: not recorded", tt.primitive, tt.operation)
+			continue
+		}
+		if s.Count != tt.count || s.Errors != tt.errors || s.Sum <= 0 {
+			t.Errorf("%s.%s: unexpected stats %+v", tt.primitive, tt.operation, s)
+		}
+	}
+	if want := "sm2.sign,sm2.verify,sm2.verify,sm9.pairing"; strings.Join(traced, ",") != want {
+		t.Errorf("got traces %v, want %s", traced, want)
+	}
+
+	SetHook(nil)
+	sm2.VerifyASN1(&priv.PublicKey, hash, sig)
+	if s := findStats(c.Snapshot(), "sm2", "verify"); s.Count != 2 {
+		t.Errorf("operation recorded with the instrumentation disabled")
+	}
+}
+
+func TestCollector(t *testing.T) {
+	if _, err := NewCollector([]time.Duration{time.Second, time.Millisecond}); err == nil {
+		t.Error("expected error for decreasing buckets")
+	}
+	c, _ := NewCollector([]time.Duration{time.Millisecond, 10 * time.Millisecond})
+	var now time.Time
+	c.now = func() time.Time { return now }
+	h := c.Hook()
+	for _, d := range []time.Duration{500 * time.Microsecond, 5 * time.Millisecond, time.Second} {
+		done := h("sm2", "sign")
+		now = now.Add(d)
+		done(nil)
+	}
+	s := c.Snapshot()[0]
+	if s.Count != 3 || s.Buckets[0] != 1 || s.Buckets[1] != 2 || s.Sum != time.Second+5500*time.Microsecond {
+		t.Errorf("unexpected stats %+v", s)
+	}
+
+	var buf bytes.Buffer
+	if err := c.WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"# TYPE gmsm_operations_total counter",
+		`gmsm_operations_total{primitive="sm2",operation="sign"} 3`,
+		`gmsm_operation_errors_total{primitive="sm2",operation="sign"} 0`,
+		`gmsm_operation_duration_seconds_bucket{primitive="sm2",operation="sign",le="0.001"} 1`,
+		`gmsm_operation_duration_seconds_bucket{primitive="sm2",operation="sign",le="0.01"} 2`,
+		`gmsm_operation_duration_seconds_bucket{primitive="sm2",operation="sign",le="+Inf"} 3`,
+		`gmsm_operation_duration_seconds_sum{primitive="sm2",operation="sign"} 1.0055`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("missing %q in\n%s", line, buf.String())
+		}
+	}
+
+	c.Reset()
+	if len(c.Snapshot()) != 0 {
+		t.Error("statistics not reset")
+	}
+}
@@ -0,0 +1,350 @@
+package noise
+
+import (
+	"errors"
+	"io"
+
+	"github.com/emmansun/gmsm/ecdh"
+	"github.com/emmansun/gmsm/internal/randutil"
+)
+
+// randReader returns r, or the default reader of the entropy package if it
+// is nil.
+func randReader(r io.Reader) io.Reader {
+	if r == nil {
+		return randutil.Reader()
+	}
+	return r
+}
+
+// Token is a token of a handshake message pattern.
+type Token byte
+
+// The tokens of the specification, e.g. TokenES is "es".
+const (
+	TokenE Token = iota + 1
+	TokenS
+	TokenEE
+	TokenES
+	TokenSE
+	TokenSS
+)
+
+// HandshakePattern is a Noise handshake pattern.
+type HandshakePattern struct {
+	Name                 string
+	InitiatorPreMessages []Token
+	ResponderPreMessages []Token
+	// Messages are the message patterns, the initiator sends the even ones.
+	Messages [][]Token
+}
+
+// HandshakeXX is the XX pattern, both static keys are transmitted:
+//
+//	-> e
+//	<- e, ee, s, es
+//	-> s, se
+var HandshakeXX = HandshakePattern{
+	Name: "XX",
+	Messages: [][]Token{
+		{TokenE},
+		{TokenE, TokenEE, TokenS, TokenES},
+		{TokenS, TokenSE},
+	},
+}
+
+// HandshakeIK is the IK pattern, the initiator knows the static key of the
+// responder and transmits its own in the first message, like WireGuard:
+//
+//	<- s
+//	...
+//	-> e, es, s, ss
+//	<- e, ee, se
+var HandshakeIK = HandshakePattern{
+	Name:                 "IK",
+	ResponderPreMessages: []Token{TokenS},
+	Messages: [][]Token{
+		{TokenE, TokenES, TokenS, TokenSS},
+		{TokenE, TokenEE, TokenSE},
+	},
+}
+
+// Config configures a HandshakeState.
+type Config struct {
+	// Pattern is the handshake pattern, HandshakeXX or HandshakeIK.
+	Pattern HandshakePattern
+	// Initiator is true for the party which sends the first message.
+	Initiator bool
+	// Prologue is data both parties must agree on, it is authenticated by
+	// the handshake.
+	Prologue []byte
+	// StaticKeypair is the local static key.
+	StaticKeypair *ecdh.PrivateKey
+	// PeerStatic is the static key of the peer, required by the initiator
+	// of the IK pattern.
+	PeerStatic *ecdh.PublicKey
+	// Random is the source of the ephemeral keys, the default reader of the
+	// entropy package if nil.
+	Random io.Reader
+}
+
+// HandshakeState runs one handshake. It is not safe for concurrent use.
+type HandshakeState struct {
+	ss        symmetricState
+	s, e      *ecdh.PrivateKey
+	rs, re    *ecdh.PublicKey
+	initiator bool
+	messages  [][]Token
+	index     int
+	rand      io.Reader
+}
+
+// ProtocolName returns the Noise protocol name of the pattern, e.g.
+// "Noise_XX_SM2_SM4GCM_SM3".
+func (p HandshakePattern) ProtocolName() string {
+	return "Noise_" + p.Name + "_" + SuiteName
+}
+
+// NewHandshakeState creates the handshake state of one party.
+func NewHandshakeState(c Config) (*HandshakeState, error) {
+	if len(c.Pattern.Messages) == 0 {
+		return nil, errors.New("noise: empty handshake pattern")
+	}
+	if c.StaticKeypair != nil && c.StaticKeypair.Curve() != ecdh.P256() ||
+		c.PeerStatic != nil && c.PeerStatic.Curve() != ecdh.P256() {
+		return nil, errors.New("noise: static keys must be SM2 keys")
+	}
+	hs := &HandshakeState{
+		s:         c.StaticKeypair,
+		rs:        c.PeerStatic,
+		initiator: c.Initiator,
+		messages:  c.Pattern.Messages,
+		rand:      randReader(c.Random),
+	}
+	hs.ss.initialize(c.Pattern.ProtocolName())
+	hs.ss.mixHash(c.Prologue)
+	for _, t := range c.Pattern.InitiatorPreMessages {
+		if err := hs.mixPreMessage(t, c.Initiator); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range c.Pattern.ResponderPreMessages {
+		if err := hs.mixPreMessage(t, !c.Initiator); err != nil {
+			return nil, err
+		}
+	}
+	if hs.s == nil && hs.needsStatic() {
+		return nil, errors.New("noise: the handshake pattern requires a static key")
+	}
+	return hs, nil
+}
+
+func (hs *HandshakeState) mixPreMessage(t Token, local bool) error {
+	if t != TokenS {
+		return errors.New("noise: unsupported pre-message token")
+	}
+	if local {
+		if hs.s == nil {
+			return errors.New("noise: the handshake pattern requires a static key")
+		}
+		hs.ss.mixHash(hs.s.PublicKey().Bytes())
+		return nil
+	}
+	if hs.rs == nil {
+		return errors.New("noise: the handshake pattern requires the static key of the peer")
+	}
+	hs.ss.mixHash(hs.rs.Bytes())
+	return nil
+}
+
+// needsStatic reports whether the local static key is used by the messages.
+func (hs *HandshakeState) needsStatic() bool {
+	for i, m := range hs.messages {
+		local := i%2 == 0 == hs.initiator
+		for _, t := range m {
+			switch {
+			case t == TokenS && local, t == TokenSS,
+				t == TokenES && !hs.initiator, t == TokenSE && hs.initiator:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (hs *HandshakeState) checkTurn(write bool) error {
+	if hs.index >= len(hs.messages) {
+		return errors.New("noise: handshake is complete")
+	}
+	if (hs.index%2 == 0 == hs.initiator) != write {
+		if write {
+			return errors.New("noise: unexpected call to WriteMessage, should be ReadMessage")
+		}
+		return errors.New("noise: unexpected call to ReadMessage, should be WriteMessage")
+	}
+	return nil
+}
+
+// WriteMessage appends the next handshake message with the payload to out.
+// After the last message it returns the CipherStates of the transport, the
+// first one encrypts the messages from the initiator to the responder, the
+// second one the messages in the other direction.
+func (hs *HandshakeState) WriteMessage(out, payload []byte) ([]byte, *CipherState, *CipherState, error) {
+	if err := hs.checkTurn(true); err != nil {
+		return nil, nil, nil, err
+	}
+	start := len(out)
+	var err error
+	for _, t := range hs.messages[hs.index] {
+		switch t {
+		case TokenE:
+			if hs.e, err = ecdh.P256().GenerateKey(hs.rand); err != nil {
+				return nil, nil, nil, err
+			}
+			pub := hs.e.PublicKey().Bytes()
+			out = append(out, pub...)
+			hs.ss.mixHash(pub)
+		case TokenS:
+			if out, err = hs.ss.encryptAndHash(out, hs.s.PublicKey().Bytes()); err != nil {
+				return nil, nil, nil, err
+			}
+		default:
+			if err = hs.mixDH(t); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+	if out, err = hs.ss.encryptAndHash(out, payload); err != nil {
+		return nil, nil, nil, err
+	}
+	if len(out)-start > MaxMsgLen {
+		return nil, nil, nil, ErrMessageTooLong
+	}
+	cs1, cs2 := hs.next()
+	return out, cs1, cs2, nil
+}
+
+// ReadMessage processes the next handshake message and appends its payload
+// to out. After the last message it returns the CipherStates of the
+// transport, like WriteMessage.
+func (hs *HandshakeState) ReadMessage(out, message []byte) ([]byte, *CipherState, *CipherState, error) {
+	if err := hs.checkTurn(false); err != nil {
+		return nil, nil, nil, err
+	}
+	if len(message) > MaxMsgLen {
+		return nil, nil, nil, ErrMessageTooLong
+	}
+	// the state is only updated if the whole message is valid.
+	saved := hs.ss
+	re, rs := hs.re, hs.rs
+	out, err := hs.readMessage(out, message)
+	if err != nil {
+		hs.ss, hs.re, hs.rs = saved, re, rs
+		return nil, nil, nil, err
+	}
+	cs1, cs2 := hs.next()
+	return out, cs1, cs2, nil
+}
+
+func (hs *HandshakeState) readMessage(out, message []byte) ([]byte, error) {
+	var err error
+	for _, t := range hs.messages[hs.index] {
+		switch t {
+		case TokenE:
+			if len(message) < DHLen {
+				return nil, ErrShortMessage
+			}
+			if hs.re, err = ecdh.P256().NewPublicKey(message[:DHLen]); err != nil {
+				return nil, err
+			}
+			hs.ss.mixHash(message[:DHLen])
+			message = message[DHLen:]
+		case TokenS:
+			n := DHLen
+			if hs.ss.cs.hasKey() {
+				n += TagLen
+			}
+			if len(message) < n {
+				return nil, ErrShortMessage
+			}
+			pub, err := hs.ss.decryptAndHash(nil, message[:n])
+			if err != nil {
+				return nil, err
+			}
+			if hs.rs, err = ecdh.P256().NewPublicKey(pub); err != nil {
+				return nil, err
+			}
+			message = message[n:]
+		default:
+			if err = hs.mixDH(t); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if hs.ss.cs.hasKey() && len(message) < TagLen {
+		return nil, ErrShortMessage
+	}
+	return hs.ss.decryptAndHash(out, message)
+}
+
+// mixDH mixes the DH output of a ee, es, se or ss token into the key.
+func (hs *HandshakeState) mixDH(t Token) error {
+	var local *ecdh.PrivateKey
+	var remote *ecdh.PublicKey
+	switch t {
+	case TokenEE:
+		local, remote = hs.e, hs.re
+	case TokenES:
+		if hs.initiator {
+			local, remote = hs.e, hs.rs
+		} else {
+			local, remote = hs.s, hs.re
+		}
+	case TokenSE:
+		if hs.initiator {
+			local, remote = hs.s, hs.re
+		} else {
+			local, remote = hs.e, hs.rs
+		}
+	case TokenSS:
+		local, remote = hs.s, hs.rs
+	default:
+		return errors.New("noise: invalid token")
+	}
+	if local == nil || remote == nil {
+		return errors.New("noise: missing key for DH")
+	}
+	secret, err := local.ECDH(remote)
+	if err != nil {
+		return err
+	}
+	hs.ss.mixKey(secret)
+	return nil
+}
+
+// next moves to the next message and splits the symmetric state after the
+// last one.
+func (hs *HandshakeState) next() (*CipherState, *CipherState) {
+	hs.index++
+	if hs.index < len(hs.messages) {
+		return nil, nil
+	}
+	hs.e = nil
+	return hs.ss.split()
+}
+
+// MessageIndex returns the index of the next handshake message.
+func (hs *HandshakeState) MessageIndex() int {
+	return hs.index
+}
+
+// PeerStatic returns the static key of the peer, nil if it is not known yet.
+func (hs *HandshakeState) PeerStatic() *ecdh.PublicKey {
+	return hs.rs
+}
+
+// ChannelBinding returns the handshake hash, which uniquely identifies the
+// session once the handshake is complete.
+func (hs *HandshakeState) ChannelBinding() []byte {
+	return append([]byte{}, hs.ss.h[:]...)
+}
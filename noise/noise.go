@@ -0,0 +1,209 @@
+// Package noise implements handshakes of the Noise Protocol Framework
+// (revision 34) instantiated with SM algorithms only, so that WireGuard like
+// secure tunnels can be built from SM2, SM3 and SM4:
+//
+//   - DH is ECDH over the SM2 curve, public keys are 65 bytes uncompressed
+//     points and the DH output is the 32 bytes x coordinate of the shared point,
+//   - the cipher is SM4-GCM, with the first 16 bytes of the 32 bytes cipher key,
+//     the nonce is 4 zero bytes followed by the big endian 64 bits counter,
+//   - the hash is SM3, HKDF uses HMAC-SM3.
+//
+// The protocol name is, e.g., "Noise_XX_SM2_SM4GCM_SM3". The XX and IK
+// handshake patterns are provided.
+package noise
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm4"
+)
+
+const (
+	// DHLen is the length of a public key, an uncompressed SM2 point.
+	DHLen = 65
+	// HashLen is the length of the SM3 digest.
+	HashLen = sm3.Size
+	// TagLen is the length of the SM4-GCM authentication tag.
+	TagLen = 16
+	// MaxMsgLen is the maximum length of a Noise message.
+	MaxMsgLen = 65535
+
+	// SuiteName is the name of the DH, cipher and hash functions of the
+	// protocol name.
+	SuiteName = "SM2_SM4GCM_SM3"
+)
+
+var (
+	// ErrMaxNonce is returned when the nonce of a CipherState is exhausted,
+	// the CipherState must not be used any more.
+	ErrMaxNonce = errors.New("noise: cipher suite nonce exhausted")
+	// ErrShortMessage is returned by ReadMessage when the message is too short.
+	ErrShortMessage = errors.New("noise: message is too short")
+	// ErrMessageTooLong is returned when a message exceeds MaxMsgLen.
+	ErrMessageTooLong = errors.New("noise: message exceeds maximum length")
+	// ErrDecrypt is returned when a message fails to authenticate.
+	ErrDecrypt = errors.New("noise: message authentication failed")
+)
+
+// CipherState encrypts and decrypts transport messages in one direction.
+// It is not safe for concurrent use.
+type CipherState struct {
+	k    [32]byte
+	aead cipher.AEAD
+	n    uint64
+}
+
+func (c *CipherState) initializeKey(k []byte) {
+	copy(c.k[:], k)
+	block, err := sm4.NewCipher(c.k[:16])
+	if err != nil {
+		panic(err)
+	}
+	if c.aead, err = cipher.NewGCM(block); err != nil {
+		panic(err)
+	}
+	c.n = 0
+}
+
+func (c *CipherState) hasKey() bool {
+	return c.aead != nil
+}
+
+func nonce(n uint64) []byte {
+	var iv [12]byte
+	binary.BigEndian.PutUint64(iv[4:], n)
+	return iv[:]
+}
+
+// Encrypt appends the encryption of plaintext with the associated data ad to
+// out and returns the updated slice.
+func (c *CipherState) Encrypt(out, ad, plaintext []byte) ([]byte, error) {
+	if c.n == math.MaxUint64 {
+		return nil, ErrMaxNonce
+	}
+	out = c.aead.Seal(out, nonce(c.n), plaintext, ad)
+	c.n++
+	return out, nil
+}
+
+// Decrypt authenticates ciphertext with the associated data ad, appends the
+// plaintext to out and returns the updated slice. The nonce is only
+// incremented if the ciphertext is authentic.
+func (c *CipherState) Decrypt(out, ad, ciphertext []byte) ([]byte, error) {
+	if c.n == math.MaxUint64 {
+		return nil, ErrMaxNonce
+	}
+	out, err := c.aead.Open(out, nonce(c.n), ciphertext, ad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	c.n++
+	return out, nil
+}
+
+// Nonce returns the nonce of the next message.
+func (c *CipherState) Nonce() uint64 {
+	return c.n
+}
+
+// SetNonce sets the nonce of the next message, for transports which deliver
+// messages out of order and transmit the nonce, like WireGuard. The caller
+// must reject replayed nonces.
+func (c *CipherState) SetNonce(n uint64) {
+	c.n = n
+}
+
+// Rekey replaces the cipher key with the first 32 bytes of the encryption of
+// 32 zero bytes with the maximum nonce, the nonce is kept.
+func (c *CipherState) Rekey() {
+	var zeros [32]byte
+	k := c.aead.Seal(nil, nonce(math.MaxUint64), zeros[:], nil)
+	n := c.n
+	c.initializeKey(k[:32])
+	c.n = n
+}
+
+// symmetricState is the SymmetricState object of the specification.
+type symmetricState struct {
+	cs CipherState
+	ck [HashLen]byte
+	h  [HashLen]byte
+}
+
+func (s *symmetricState) initialize(protocolName string) {
+	if len(protocolName) <= HashLen {
+		copy(s.h[:], protocolName)
+	} else {
+		s.h = sm3.Sum([]byte(protocolName))
+	}
+	s.ck = s.h
+}
+
+func (s *symmetricState) mixKey(ikm []byte) {
+	ck, k := hkdf(s.ck[:], ikm)
+	s.ck = ck
+	s.cs.initializeKey(k[:])
+}
+
+func (s *symmetricState) mixHash(data []byte) {
+	md := sm3.New()
+	md.Write(s.h[:])
+	md.Write(data)
+	md.Sum(s.h[:0])
+}
+
+func (s *symmetricState) encryptAndHash(out, plaintext []byte) ([]byte, error) {
+	start := len(out)
+	if s.cs.hasKey() {
+		var err error
+		if out, err = s.cs.Encrypt(out, s.h[:], plaintext); err != nil {
+			return nil, err
+		}
+	} else {
+		out = append(out, plaintext...)
+	}
+	s.mixHash(out[start:])
+	return out, nil
+}
+
+func (s *symmetricState) decryptAndHash(out, data []byte) ([]byte, error) {
+	var err error
+	if s.cs.hasKey() {
+		if out, err = s.cs.Decrypt(out, s.h[:], data); err != nil {
+			return nil, err
+		}
+	} else {
+		out = append(out, data...)
+	}
+	s.mixHash(data)
+	return out, nil
+}
+
+func (s *symmetricState) split() (*CipherState, *CipherState) {
+	k1, k2 := hkdf(s.ck[:], nil)
+	c1, c2 := new(CipherState), new(CipherState)
+	c1.initializeKey(k1[:])
+	c2.initializeKey(k2[:])
+	return c1, c2
+}
+
+// hkdf returns the first two outputs of the HKDF function of the specification.
+func hkdf(ck, ikm []byte) (out1, out2 [HashLen]byte) {
+	mac := hmac.New(sm3.New, ck)
+	mac.Write(ikm)
+	tempKey := mac.Sum(nil)
+
+	mac = hmac.New(sm3.New, tempKey)
+	mac.Write([]byte{0x01})
+	mac.Sum(out1[:0])
+	mac.Reset()
+	mac.Write(out1[:])
+	mac.Write([]byte{0x02})
+	mac.Sum(out2[:0])
+	return
+}
@@ -0,0 +1,199 @@
+package noise
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/emmansun/gmsm/ecdh"
+)
+
+func mustGenerateKey(t *testing.T) *ecdh.PrivateKey {
+	t.Helper()
+	k, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// handshake runs the handshake of the two parties and returns the transport
+// cipher states of the initiator and the responder.
+func handshake(t *testing.T, init, resp *HandshakeState) (initSend, initRecv, respSend, respRecv *CipherState) {
+	t.Helper()
+	writer, reader := init, resp
+	for i := 0; ; i++ {
+		payload := []byte{byte(i), 'p'}
+		msg, c1, c2, err := writer.WriteMessage(nil, payload)
+		if err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		got, d1, d2, err := reader.ReadMessage(nil, msg)
+		if err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Fatalf("message %d: got payload %x", i, got)
+		}
+		if (c1 == nil) != (d1 == nil) {
+			t.Fatalf("message %d: the parties don't agree on the end of the handshake", i)
+		}
+		if c1 != nil {
+			if writer == init {
+				return c1, c2, d2, d1
+			}
+			return d1, d2, c2, c1
+		}
+		writer, reader = reader, writer
+	}
+}
+
+func testTransport(t *testing.T, send, recv *CipherState) {
+	t.Helper()
+	for i := 0; i < 3; i++ {
+		msg := []byte("hello noise")
+		ct, err := send.Encrypt(nil, nil, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ct) != len(msg)+TagLen {
+			t.Errorf("unexpected ciphertext length %d", len(ct))
+		}
+		pt, err := recv.Decrypt(nil, nil, ct)
+		if err != nil || !bytes.Equal(pt, msg) {
+			t.Fatalf("got %q, %v", pt, err)
+		}
+		ct[0] ^= 1
+		if _, err := recv.Decrypt(nil, nil, ct); err != ErrDecrypt {
+			t.Errorf("got %v, want ErrDecrypt", err)
+		}
+	}
+	send.Rekey()
+	recv.Rekey()
+	ct, _ := send.Encrypt(nil, []byte("ad"), []byte("rekeyed"))
+	if pt, err := recv.Decrypt(nil, []byte("ad"), ct); err != nil || string(pt) != "rekeyed" {
+		t.Errorf("after rekey: got %q, %v", pt, err)
+	}
+}
+
+func TestXX(t *testing.T) {
+	si, sr := mustGenerateKey(t), mustGenerateKey(t)
+	init, err := NewHandshakeState(Config{Pattern: HandshakeXX, Initiator: true, Prologue: []byte("test"), StaticKeypair: si})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := NewHandshakeState(Config{Pattern: HandshakeXX, Prologue: []byte("test"), StaticKeypair: sr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	initSend, initRecv, respSend, respRecv := handshake(t, init, resp)
+	if !init.PeerStatic().Equal(sr.PublicKey()) || !resp.PeerStatic().Equal(si.PublicKey()) {
+		t.Error("static key mismatch")
+	}
+	if !bytes.Equal(init.ChannelBinding(), resp.ChannelBinding()) {
+		t.Error("handshake hash mismatch")
+	}
+	testTransport(t, initSend, respRecv)
+	testTransport(t, respSend, initRecv)
+
+	if _, _, _, err := init.WriteMessage(nil, nil); err == nil {
+		t.Error("expected error after the handshake is complete")
+	}
+}
+
+func TestIK(t *testing.T) {
+	si, sr := mustGenerateKey(t), mustGenerateKey(t)
+	init, err := NewHandshakeState(Config{Pattern: HandshakeIK, Initiator: true, StaticKeypair: si, PeerStatic: sr.PublicKey()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := NewHandshakeState(Config{Pattern: HandshakeIK, StaticKeypair: sr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	initSend, initRecv, respSend, respRecv := handshake(t, init, resp)
+	if !resp.PeerStatic().Equal(si.PublicKey()) {
+		t.Error("static key mismatch")
+	}
+	testTransport(t, initSend, respRecv)
+	testTransport(t, respSend, initRecv)
+
+	// the initiator must know the static key of the responder.
+	if _, err := NewHandshakeState(Config{Pattern: HandshakeIK, Initiator: true, StaticKeypair: si}); err == nil {
+		t.Error("expected error without the static key of the responder")
+	}
+	if _, err := NewHandshakeState(Config{Pattern: HandshakeIK}); err == nil {
+		t.Error("expected error without a static key")
+	}
+}
+
+func TestIKWrongResponderKey(t *testing.T) {
+	si, sr := mustGenerateKey(t), mustGenerateKey(t)
+	init, _ := NewHandshakeState(Config{Pattern: HandshakeIK, Initiator: true, StaticKeypair: si, PeerStatic: mustGenerateKey(t).PublicKey()})
+	resp, _ := NewHandshakeState(Config{Pattern: HandshakeIK, StaticKeypair: sr})
+	msg, _, _, err := init.WriteMessage(nil, []byte("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := resp.ReadMessage(nil, msg); err != ErrDecrypt {
+		t.Errorf("got %v, want ErrDecrypt", err)
+	}
+}
+
+func TestReadMessageErrors(t *testing.T) {
+	newPair := func(prologue string) (*HandshakeState, *HandshakeState) {
+		init, _ := NewHandshakeState(Config{Pattern: HandshakeXX, Initiator: true, StaticKeypair: mustGenerateKey(t)})
+		resp, _ := NewHandshakeState(Config{Pattern: HandshakeXX, Prologue: []byte(prologue), StaticKeypair: mustGenerateKey(t)})
+		return init, resp
+	}
+
+	init, resp := newPair("")
+	if _, _, _, err := resp.WriteMessage(nil, nil); err == nil {
+		t.Error("expected error when the responder writes first")
+	}
+	if _, _, _, err := init.ReadMessage(nil, nil); err == nil {
+		t.Error("expected error when the initiator reads first")
+	}
+	msg, _, _, _ := init.WriteMessage(nil, nil)
+	if _, _, _, err := resp.ReadMessage(nil, msg[:DHLen-1]); err != ErrShortMessage {
+		t.Errorf("got %v, want ErrShortMessage", err)
+	}
+	bad := append([]byte{}, msg...)
+	bad[1] ^= 1
+	if _, _, _, err := resp.ReadMessage(nil, bad); err == nil {
+		t.Error("expected error for an invalid ephemeral key")
+	}
+	// a failed message doesn't change the state.
+	if _, _, _, err := resp.ReadMessage(nil, msg); err != nil {
+		t.Fatal(err)
+	}
+	msg, _, _, _ = resp.WriteMessage(nil, []byte("payload"))
+	msg[len(msg)-1] ^= 1
+	if _, _, _, err := init.ReadMessage(nil, msg); err != ErrDecrypt {
+		t.Errorf("got %v, want ErrDecrypt", err)
+	}
+
+	// different prologues
+	init, resp = newPair("other")
+	msg, _, _, _ = init.WriteMessage(nil, nil)
+	resp.ReadMessage(nil, msg)
+	msg, _, _, _ = resp.WriteMessage(nil, nil)
+	if _, _, _, err := init.ReadMessage(nil, msg); err != ErrDecrypt {
+		t.Errorf("got %v, want ErrDecrypt", err)
+	}
+}
+
+func TestProtocolName(t *testing.T) {
+	if got := HandshakeIK.ProtocolName(); got != "Noise_IK_SM2_SM4GCM_SM3" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestCipherStateNonce(t *testing.T) {
+	c := new(CipherState)
+	c.initializeKey(make([]byte, 32))
+	c.SetNonce(1<<64 - 1)
+	if _, err := c.Encrypt(nil, nil, nil); err != ErrMaxNonce {
+		t.Errorf("got %v, want ErrMaxNonce", err)
+	}
+}
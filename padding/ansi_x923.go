@@ -1,7 +1,7 @@
 package padding
 
 import (
-	"errors"
+	"crypto/subtle"
 
 	"github.com/emmansun/gmsm/internal/alias"
 )
@@ -23,20 +23,22 @@ func (pad ansiX923Padding) Pad(src []byte) []byte {
 	return ret
 }
 
-// Unpad decrypted plaintext, non-constant-time
+// Unpad decrypted plaintext in constant time with respect to the last block.
 func (pad ansiX923Padding) Unpad(src []byte) ([]byte, error) {
 	srcLen := len(src)
-	if srcLen == 0 || srcLen%pad.BlockSize() != 0 {
-		return nil, errors.New("padding: src length is not multiple of block size")
+	blockSize := pad.BlockSize()
+	if srcLen == 0 || srcLen%blockSize != 0 {
+		return nil, ErrInvalidLength
 	}
-	paddedLen := src[srcLen-1]
-	if paddedLen == 0 || int(paddedLen) > pad.BlockSize() {
-		return nil, errors.New("padding: invalid padding length")
+	paddedLen := int(src[srcLen-1])
+	good := subtle.ConstantTimeLessOrEq(1, paddedLen) & subtle.ConstantTimeLessOrEq(paddedLen, blockSize)
+	block := src[srcLen-blockSize : srcLen-1]
+	for i, b := range block {
+		inPad := subtle.ConstantTimeLessOrEq(blockSize-i, paddedLen)
+		good &= subtle.ConstantTimeSelect(inPad, subtle.ConstantTimeByteEq(b, 0), 1)
 	}
-	for _, b := range src[srcLen-int(paddedLen) : srcLen-1] {
-		if b != 0 {
-			return nil, errors.New("padding: invalid padding bytes")
-		}
+	if good != 1 {
+		return nil, ErrInvalidPadding
 	}
-	return src[:srcLen-int(paddedLen)], nil
+	return src[:srcLen-paddedLen], nil
 }
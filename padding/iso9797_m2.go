@@ -1,12 +1,12 @@
 package padding
 
 import (
-	"errors"
+	"crypto/subtle"
 
 	"github.com/emmansun/gmsm/internal/alias"
 )
 
-// Add a single bit with value 1 to the end of the data. 
+// Add a single bit with value 1 to the end of the data.
 // Then if necessary add bits with value 0 to the end of the data until the padded data is a multiple of n.
 //
 // https://en.wikipedia.org/wiki/ISO/IEC_9797-1
@@ -27,23 +27,27 @@ func (pad iso9797M2Padding) Pad(src []byte) []byte {
 	return ret
 }
 
-// Unpad decrypted plaintext, non-constant-time
+// Unpad decrypted plaintext in constant time with respect to the last block.
+// The padding is the last 0x80 byte of the last block followed only by zero
+// bytes, it is an error if there is none.
 func (pad iso9797M2Padding) Unpad(src []byte) ([]byte, error) {
 	srcLen := len(src)
-	if srcLen == 0 || srcLen%pad.BlockSize() != 0 {
-		return nil, errors.New("padding: src length is not multiple of block size")
+	blockSize := pad.BlockSize()
+	if srcLen == 0 || srcLen%blockSize != 0 {
+		return nil, ErrInvalidLength
 	}
-	padStart := -1
-
-	for i, b := range src[srcLen-pad.BlockSize() : srcLen] {
-		if b == 0x80 {
-			padStart = i
-		} else if b != 0 && padStart >=0 {
-			padStart = -1
-		}
+	block := src[srcLen-blockSize:]
+	found, padStart, good := 0, 0, 1
+	for i := blockSize - 1; i >= 0; i-- {
+		isMarker := subtle.ConstantTimeByteEq(block[i], 0x80)
+		isZero := subtle.ConstantTimeByteEq(block[i], 0)
+		// the bytes after the marker must be zero
+		good &= subtle.ConstantTimeSelect(found, 1, isMarker|isZero)
+		padStart = subtle.ConstantTimeSelect(isMarker&^found, i, padStart)
+		found |= isMarker
 	}
-	if padStart >= 0 {
-		return src[:srcLen-pad.BlockSize()+padStart], nil	
+	if good&found != 1 {
+		return nil, ErrInvalidPadding
 	}
-	return src, nil
+	return src[:srcLen-blockSize+padStart], nil
 }
@@ -1,7 +1,7 @@
 package padding
 
 import (
-	"errors"
+	"crypto/subtle"
 
 	"github.com/emmansun/gmsm/internal/alias"
 )
@@ -22,20 +22,23 @@ func (pad pkcs7Padding) Pad(src []byte) []byte {
 	return ret
 }
 
-// Unpad decrypted plaintext, non-constant-time
+// Unpad decrypted plaintext in constant time with respect to the last block.
 func (pad pkcs7Padding) Unpad(src []byte) ([]byte, error) {
 	srcLen := len(src)
-	if srcLen == 0 || srcLen%pad.BlockSize() != 0 {
-		return nil, errors.New("padding: src length is not multiple of block size")
+	blockSize := pad.BlockSize()
+	if srcLen == 0 || srcLen%blockSize != 0 {
+		return nil, ErrInvalidLength
 	}
-	paddedLen := src[srcLen-1]
-	if paddedLen == 0 || int(paddedLen) > pad.BlockSize() {
-		return nil, errors.New("padding: invalid padding byte/length")
+	paddedLen := int(src[srcLen-1])
+	good := subtle.ConstantTimeLessOrEq(1, paddedLen) & subtle.ConstantTimeLessOrEq(paddedLen, blockSize)
+	block := src[srcLen-blockSize:]
+	for i, b := range block {
+		// the byte is padding if it is one of the last paddedLen bytes
+		inPad := subtle.ConstantTimeLessOrEq(blockSize-i, paddedLen)
+		good &= subtle.ConstantTimeSelect(inPad, subtle.ConstantTimeByteEq(b, byte(paddedLen)), 1)
 	}
-	for _, b := range src[srcLen-int(paddedLen) : srcLen-1] {
-		if b != paddedLen {
-			return nil, errors.New("padding: inconsistent padding bytes")
-		}
+	if good != 1 {
+		return nil, ErrInvalidPadding
 	}
-	return src[:srcLen-int(paddedLen)], nil
+	return src[:srcLen-paddedLen], nil
 }
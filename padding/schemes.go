@@ -1,6 +1,14 @@
 // Package padding implements some padding schemes for padding octets at the trailing end.
+//
+// The Unpad methods run in constant time with respect to the content of the
+// last block, so that the result of unpadding a decrypted CBC or ECB message
+// doesn't leak through timing which bytes were wrong, but all schemes return
+// the same error for every invalid padding. Callers must still avoid sending
+// distinguishable errors to peers, which makes a padding oracle regardless.
 package padding
 
+import "errors"
+
 // Padding interface represents a padding scheme
 type Padding interface {
 	BlockSize() int
@@ -8,6 +16,15 @@ type Padding interface {
 	Unpad(src []byte) ([]byte, error)
 }
 
+// The failure modes of Unpad, test them with [errors.Is].
+var (
+	// ErrInvalidLength represents an input which is empty or not a multiple
+	// of the block size.
+	ErrInvalidLength = errors.New("padding: src length is not multiple of block size")
+	// ErrInvalidPadding represents malformed padding bytes.
+	ErrInvalidPadding = errors.New("padding: invalid padding byte/length")
+)
+
 func NewPKCS7Padding(blockSize uint) Padding {
 	if blockSize == 0 || blockSize > 255 {
 		panic("padding: invalid block size")
@@ -28,3 +45,15 @@ func NewISO9797M2Padding(blockSize uint) Padding {
 	}
 	return iso9797M2Padding(blockSize)
 }
+
+// NewZeroPadding returns the zero padding, which appends zero bytes up to a
+// multiple of blockSize and no block at all to an aligned message. Unpad
+// strips all trailing zero bytes of the last block, so it only round trips
+// messages which don't end with a zero byte, e.g. text. It is used by legacy
+// systems, new protocols should use PKCS#7.
+func NewZeroPadding(blockSize uint) Padding {
+	if blockSize == 0 || blockSize > 255 {
+		panic("padding: invalid block size")
+	}
+	return zeroPadding(blockSize)
+}
@@ -0,0 +1,47 @@
+package padding
+
+import (
+	"crypto/subtle"
+
+	"github.com/emmansun/gmsm/internal/alias"
+)
+
+// Add bytes with value 0 to the end of the data until the padded data is a
+// multiple of the block size, nothing if it already is.
+//
+// https://en.wikipedia.org/wiki/ISO/IEC_9797-1 Padding method 1
+type zeroPadding uint
+
+func (pad zeroPadding) BlockSize() int {
+	return int(pad)
+}
+
+func (pad zeroPadding) Pad(src []byte) []byte {
+	overhead := (pad.BlockSize() - len(src)%pad.BlockSize()) % pad.BlockSize()
+	ret, out := alias.SliceForAppend(src, overhead)
+	for i := range out {
+		out[i] = 0
+	}
+	return ret
+}
+
+// Unpad decrypted plaintext in constant time with respect to the last block,
+// the trailing zero bytes of the last block are removed.
+func (pad zeroPadding) Unpad(src []byte) ([]byte, error) {
+	srcLen := len(src)
+	blockSize := pad.BlockSize()
+	if srcLen%blockSize != 0 {
+		return nil, ErrInvalidLength
+	}
+	if srcLen == 0 {
+		return src, nil
+	}
+	block := src[srcLen-blockSize:]
+	paddedLen, done := 0, 0
+	for i := blockSize - 1; i >= 0; i-- {
+		isZero := subtle.ConstantTimeByteEq(block[i], 0)
+		done |= isZero ^ 1
+		paddedLen += isZero &^ done
+	}
+	return src[:srcLen-paddedLen], nil
+}
@@ -0,0 +1,128 @@
+package padding
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_zeroPadding_Pad(t *testing.T) {
+	zero := NewZeroPadding(16)
+	tests := []struct {
+		name string
+		src  []byte
+		want []byte
+	}{
+		{"empty", []byte{}, []byte{}},
+		{"16 bytes", []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}},
+		{"15 bytes", []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}, []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 0}},
+		{"3 bytes", []byte{1, 2, 3}, []byte{1, 2, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{"17 bytes", []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := zero.Pad(tt.src); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("zeroPadding.Pad() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_zeroPadding_Unpad(t *testing.T) {
+	zero := NewZeroPadding(16)
+	tests := []struct {
+		name    string
+		want    []byte
+		src     []byte
+		wantErr bool
+	}{
+		{"empty", []byte{}, []byte{}, false},
+		{"16 bytes", []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, false},
+		{"15 bytes", []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}, []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 0}, false},
+		{"3 bytes", []byte{1, 0, 3}, []byte{1, 0, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, false},
+		{"zero block", []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, false},
+		{"invalid src length", nil, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := zero.Unpad(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("zeroPadding.Unpad() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("zeroPadding.Unpad() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestUnpadAllLastBlocks checks the constant time Unpad of the padding schemes
+// against straightforward implementations for all 2 bytes tails of an 8 bytes
+// block.
+func TestUnpadAllLastBlocks(t *testing.T) {
+	const blockSize = 8
+	schemes := []struct {
+		name  string
+		pad   Padding
+		unpad func(block []byte) (int, bool)
+	}{
+		{"pkcs7", NewPKCS7Padding(blockSize), func(block []byte) (int, bool) {
+			n := int(block[blockSize-1])
+			if n == 0 || n > blockSize {
+				return 0, false
+			}
+			for _, b := range block[blockSize-n:] {
+				if int(b) != n {
+					return 0, false
+				}
+			}
+			return n, true
+		}},
+		{"ansi x9.23", NewANSIX923Padding(blockSize), func(block []byte) (int, bool) {
+			n := int(block[blockSize-1])
+			if n == 0 || n > blockSize {
+				return 0, false
+			}
+			for _, b := range block[blockSize-n : blockSize-1] {
+				if b != 0 {
+					return 0, false
+				}
+			}
+			return n, true
+		}},
+		{"iso9797 m2", NewISO9797M2Padding(blockSize), func(block []byte) (int, bool) {
+			i := len(bytes.TrimRight(block, "\x00")) - 1
+			if i < 0 || block[i] != 0x80 {
+				return 0, false
+			}
+			return blockSize - i, true
+		}},
+		{"zero", NewZeroPadding(blockSize), func(block []byte) (int, bool) {
+			return blockSize - len(bytes.TrimRight(block, "\x00")), true
+		}},
+	}
+	block := make([]byte, blockSize)
+	for _, s := range schemes {
+		for prefix := 0; prefix < blockSize-1; prefix++ {
+			for i := range block[:prefix] {
+				block[i] = byte(prefix)
+			}
+			for v := 0; v < 1<<16; v++ {
+				for i := prefix; i < blockSize-2; i++ {
+					block[i] = 0
+				}
+				block[blockSize-2], block[blockSize-1] = byte(v>>8), byte(v)
+				n, ok := s.unpad(block)
+				got, err := s.pad.Unpad(block)
+				if (err == nil) != ok || (ok && len(got) != blockSize-n) || (!ok && !errors.Is(err, ErrInvalidPadding)) {
+					t.Fatalf("%s: Unpad(%x) = %x, %v, want %d bytes removed, valid %v", s.name, block, got, err, n, ok)
+				}
+			}
+		}
+		if _, err := s.pad.Unpad(block[1:]); !errors.Is(err, ErrInvalidLength) {
+			t.Errorf("%s: Unpad of a partial block = %v, want ErrInvalidLength", s.name, err)
+		}
+	}
+}
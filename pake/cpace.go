@@ -0,0 +1,131 @@
+package pake
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/emmansun/gmsm/internal/bigmod"
+	_sm2ec "github.com/emmansun/gmsm/internal/sm2ec"
+	"github.com/emmansun/gmsm/sm3"
+)
+
+// CPaceDSI is the domain separation identifier of CPace over SM2 curve with SM3.
+const CPaceDSI = "CPaceSM2P256_XMD:SM3_SSWU_NU_"
+
+// CPace is one party of a CPace balanced PAKE session in initiator-responder mode.
+//
+// Both parties derive the generator from the password related string (PRS),
+// the channel identifier (CI) and the session id (sid), exchange their messages
+// and derive the same intermediate session key (ISK) only if they used the same PRS.
+// A CPace instance must be used for one session only.
+type CPace struct {
+	initiator bool
+	sid       []byte
+	ad        []byte
+	y         *bigmod.Nat
+	msg       []byte
+	share     []byte
+}
+
+// NewCPace creates one party of a CPace session, it samples the ephemeral secret
+// and computes the message to be sent to the peer.
+//
+// prs is the password related string, ci is the channel identifier, sid is the session id
+// which should be agreed by both parties in advance, ad is the associated data sent along with
+// the share, and initiator specifies the role of this party.
+func NewCPace(rand io.Reader, prs, ci, sid, ad []byte, initiator bool) (*CPace, error) {
+	g, err := cpaceGenerator(prs, ci, sid)
+	if err != nil {
+		return nil, err
+	}
+	y, err := randomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	Y, err := _sm2ec.NewSM2P256Point().ScalarMult(g, y.Bytes(curveOrder()))
+	if err != nil {
+		return nil, err
+	}
+	c := &CPace{
+		initiator: initiator,
+		sid:       append([]byte{}, sid...),
+		ad:        append([]byte{}, ad...),
+		y:         y,
+		share:     Y.Bytes(),
+	}
+	c.msg = lvCat(c.share, c.ad)
+	return c, nil
+}
+
+// Message returns the serialized protocol message lv_cat(Y, AD) to be sent to the peer.
+func (c *CPace) Message() []byte {
+	return append([]byte{}, c.msg...)
+}
+
+// ParseCPaceMessage parses a serialized CPace message, returns the share and the associated data.
+func ParseCPaceMessage(msg []byte) (share, ad []byte, err error) {
+	fields, err := lvSplit(msg, 2)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fields[0], fields[1], nil
+}
+
+// Finish processes the peer's message and returns the intermediate session key.
+func (c *CPace) Finish(peerMessage []byte) ([]byte, error) {
+	peerShare, peerAD, err := ParseCPaceMessage(peerMessage)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(peerShare, c.share) == 1 {
+		return nil, errors.New("pake: peer share reflects own share")
+	}
+	Y, err := parsePoint(peerShare)
+	if err != nil {
+		return nil, err
+	}
+	K, err := _sm2ec.NewSM2P256Point().ScalarMult(Y, c.y.Bytes(curveOrder()))
+	if err != nil {
+		return nil, err
+	}
+	if isInfinity(K) {
+		return nil, errInvalidPoint
+	}
+	kx, err := K.BytesX()
+	if err != nil {
+		return nil, err
+	}
+
+	// ISK = H(lv_cat(DSI || "_ISK", sid, K) || transcript_ir(Ya, ADa, Yb, ADb))
+	md := sm3.New()
+	md.Write(lvCat([]byte(CPaceDSI+"_ISK"), c.sid, kx))
+	if c.initiator {
+		md.Write(c.msg)
+		md.Write(lvCat(peerShare, peerAD))
+	} else {
+		md.Write(lvCat(peerShare, peerAD))
+		md.Write(c.msg)
+	}
+	return md.Sum(nil), nil
+}
+
+// cpaceGenerator calculates the password dependent generator
+// encode_to_curve(lv_cat(DSI, PRS, zero_pad, CI, sid)).
+func cpaceGenerator(prs, ci, sid []byte) (*_sm2ec.SM2P256Point, error) {
+	lenPRS := len(appendLEB128(nil, len(prs)))
+	lenDSI := len(appendLEB128(nil, len(CPaceDSI)))
+	zpad := sm3.BlockSize - 1 - lenPRS - len(prs) - lenDSI - len(CPaceDSI)
+	if zpad < 0 {
+		zpad = 0
+	}
+	genStr := lvCat([]byte(CPaceDSI), prs, make([]byte, zpad), ci, sid)
+	g, err := _sm2ec.EncodeToCurve(genStr, []byte(CPaceDSI))
+	if err != nil {
+		return nil, err
+	}
+	if isInfinity(g) {
+		return nil, errInvalidPoint
+	}
+	return g, nil
+}
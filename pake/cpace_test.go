@@ -0,0 +1,84 @@
+package pake
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func cpaceExchange(t *testing.T, prsA, prsB []byte) ([]byte, []byte) {
+	t.Helper()
+	ci := []byte("channel identifier")
+	sid := []byte("session id")
+	a, err := NewCPace(rand.Reader, prsA, ci, sid, []byte("ADa"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewCPace(rand.Reader, prsB, ci, sid, []byte("ADb"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iskA, err := a.Finish(b.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+	iskB, err := b.Finish(a.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return iskA, iskB
+}
+
+func TestCPace(t *testing.T) {
+	iskA, iskB := cpaceExchange(t, []byte("password"), []byte("password"))
+	if !bytes.Equal(iskA, iskB) {
+		t.Errorf("ISK mismatch")
+	}
+	iskA, iskB = cpaceExchange(t, []byte("password"), []byte("passw0rd"))
+	if bytes.Equal(iskA, iskB) {
+		t.Errorf("ISK should not match with different passwords")
+	}
+}
+
+func TestCPaceMessage(t *testing.T) {
+	a, err := NewCPace(rand.Reader, []byte("password"), nil, []byte("sid"), []byte("AD"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share, ad, err := ParseCPaceMessage(a.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(share) != 65 || string(ad) != "AD" {
+		t.Errorf("unexpected message fields %x, %x", share, ad)
+	}
+	// reflection
+	if _, err = a.Finish(a.Message()); err == nil {
+		t.Errorf("expected error for reflected message")
+	}
+	invalid := [][]byte{
+		nil,
+		{0x80},
+		lvCat([]byte{0}, nil),
+		lvCat(make([]byte, 65), nil),
+		append(lvCat(share, ad), 0),
+	}
+	for i, msg := range invalid {
+		if _, err = a.Finish(msg); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestLEB128(t *testing.T) {
+	for _, v := range []int{0, 1, 127, 128, 300, 1 << 20} {
+		b := appendLEB128(nil, v)
+		got, n, err := readLEB128(b)
+		if err != nil || got != v || n != len(b) {
+			t.Errorf("LEB128(%v) round trip failed: %v, %v, %v", v, got, n, err)
+		}
+	}
+	if b := appendLEB128(nil, 300); !bytes.Equal(b, []byte{0xac, 0x02}) {
+		t.Errorf("unexpected encoding %x", b)
+	}
+}
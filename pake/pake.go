@@ -0,0 +1,133 @@
+// Package pake implements password-authenticated key exchange protocols over SM2 curve with SM3:
+// the balanced CPace (draft-irtf-cfrg-cpace) and the augmented SPAKE2+ (RFC 9383).
+// It also implements the verifier-based SRP-6a (RFC 5054) with SM3 for legacy systems.
+package pake
+
+import (
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/emmansun/gmsm/internal/bigmod"
+	"github.com/emmansun/gmsm/internal/randutil"
+	_sm2ec "github.com/emmansun/gmsm/internal/sm2ec"
+	"github.com/emmansun/gmsm/sm2/sm2ec"
+)
+
+const scalarLength = 32
+
+var errInvalidPoint = errors.New("pake: invalid point")
+
+var orderOnce sync.Once
+var order *bigmod.Modulus
+var twoTo256 *bigmod.Nat
+
+func curveOrder() *bigmod.Modulus {
+	orderOnce.Do(func() {
+		order, _ = bigmod.NewModulusFromBig(sm2ec.P256().Params().N)
+		r := new(big.Int).Lsh(big.NewInt(1), 256)
+		r.Mod(r, sm2ec.P256().Params().N)
+		twoTo256, _ = bigmod.NewNat().SetBytes(r.Bytes(), order)
+	})
+	return order
+}
+
+// randomScalar returns a random scalar in [1, n-1].
+func randomScalar(rand io.Reader) (*bigmod.Nat, error) {
+	n := curveOrder()
+	randutil.MaybeReadByte(rand)
+	b := make([]byte, scalarLength)
+	k := bigmod.NewNat()
+	for {
+		if _, err := io.ReadFull(rand, b); err != nil {
+			return nil, err
+		}
+		if _, err := k.SetBytes(b, n); err == nil && k.IsZero() == 0 {
+			return k, nil
+		}
+	}
+}
+
+// reduceScalar reduces a big endian value of up to 40 bytes modulo n.
+func reduceScalar(b []byte) (*bigmod.Nat, error) {
+	n := curveOrder()
+	if len(b) > scalarLength+8 {
+		return nil, errors.New("pake: scalar input too long")
+	}
+	var buf [scalarLength + 8]byte
+	copy(buf[len(buf)-len(b):], b)
+	hi, err := bigmod.NewNat().SetBytes(buf[:8], n)
+	if err != nil {
+		return nil, err
+	}
+	lo, err := bigmod.NewNat().SetOverflowingBytes(buf[8:], n)
+	if err != nil {
+		return nil, err
+	}
+	return hi.Mul(twoTo256, n).Add(lo, n), nil
+}
+
+// parsePoint decodes an uncompressed point, the point at infinity is rejected.
+func parsePoint(b []byte) (*_sm2ec.SM2P256Point, error) {
+	if len(b) != 65 || b[0] != 4 {
+		return nil, errInvalidPoint
+	}
+	return _sm2ec.NewSM2P256Point().SetBytes(b)
+}
+
+func isInfinity(p *_sm2ec.SM2P256Point) bool {
+	return len(p.Bytes()) == 1
+}
+
+// appendLEB128 appends the unsigned LEB128 encoding of v.
+func appendLEB128(b []byte, v int) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// readLEB128 decodes an unsigned LEB128 value, returns the value and bytes consumed.
+func readLEB128(b []byte) (int, int, error) {
+	v := 0
+	for i := 0; i < len(b) && i < 4; i++ {
+		v |= int(b[i]&0x7f) << (7 * i)
+		if b[i] < 0x80 {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, errors.New("pake: invalid length encoding")
+}
+
+// lvCat concatenates the inputs, each prepended with its LEB128 encoded length.
+func lvCat(args ...[]byte) []byte {
+	var out []byte
+	for _, arg := range args {
+		out = appendLEB128(out, len(arg))
+		out = append(out, arg...)
+	}
+	return out
+}
+
+// lvSplit is the inverse of lvCat, it returns exactly n fields.
+func lvSplit(b []byte, n int) ([][]byte, error) {
+	fields := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		l, consumed, err := readLEB128(b)
+		if err != nil {
+			return nil, err
+		}
+		b = b[consumed:]
+		if l > len(b) {
+			return nil, errors.New("pake: truncated message")
+		}
+		fields = append(fields, b[:l])
+		b = b[l:]
+	}
+	if len(b) != 0 {
+		return nil, errors.New("pake: trailing data in message")
+	}
+	return fields, nil
+}
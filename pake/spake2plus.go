@@ -0,0 +1,276 @@
+package pake
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/emmansun/gmsm/internal/bigmod"
+	_sm2ec "github.com/emmansun/gmsm/internal/sm2ec"
+	"github.com/emmansun/gmsm/sm3"
+	"golang.org/x/crypto/hkdf"
+)
+
+// SPAKE2PlusSuite is the cipher suite name of SPAKE2+ over SM2 curve, it is also
+// the prefix of the seeds which the points M and N are hashed from.
+const SPAKE2PlusSuite = "SPAKE2+-SM2P256-SM3-HKDF-SM3-HMAC-SM3"
+
+// W0W1Length is the length of w0s and w1s, ceil(log2(n)) / 8 + 8.
+const W0W1Length = scalarLength + 8
+
+var errConfirmation = errors.New("pake: key confirmation failed")
+
+var spake2PlusOnce sync.Once
+var spake2PlusM, spake2PlusN *_sm2ec.SM2P256Point
+
+// spake2PlusMN returns the points M and N, which are hashed to curve from
+// "SPAKE2+-SM2P256-SM3-HKDF-SM3-HMAC-SM3 M" and "... N", nobody knows their discrete logarithms.
+func spake2PlusMN() (*_sm2ec.SM2P256Point, *_sm2ec.SM2P256Point) {
+	spake2PlusOnce.Do(func() {
+		dst := []byte(SPAKE2PlusSuite + "_XMD:SM3_SSWU_RO_")
+		spake2PlusM, _ = _sm2ec.HashToCurve([]byte(SPAKE2PlusSuite+" M"), dst)
+		spake2PlusN, _ = _sm2ec.HashToCurve([]byte(SPAKE2PlusSuite+" N"), dst)
+	})
+	return spake2PlusM, spake2PlusN
+}
+
+// SPAKE2PlusRegistration computes the prover's secrets w0, w1 and the verifier's
+// registration record L = w1*P from the output of a password based KDF (w0s || w1s),
+// which must be 2*W0W1Length bytes.
+func SPAKE2PlusRegistration(pbkdfOutput []byte) (w0, w1, L []byte, err error) {
+	if len(pbkdfOutput) != 2*W0W1Length {
+		return nil, nil, nil, errors.New("pake: invalid password based KDF output length")
+	}
+	n := curveOrder()
+	w0Nat, err := reduceScalar(pbkdfOutput[:W0W1Length])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	w1Nat, err := reduceScalar(pbkdfOutput[W0W1Length:])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	w1 = w1Nat.Bytes(n)
+	p, err := _sm2ec.NewSM2P256Point().ScalarBaseMult(w1)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return w0Nat.Bytes(n), w1, p.Bytes(), nil
+}
+
+type spake2PlusTranscript struct {
+	context, idProver, idVerifier []byte
+}
+
+// keys computes TT and derives the confirmation keys and shared key.
+func (t *spake2PlusTranscript) keys(shareP, shareV, Z, V, w0 []byte) (kConfirmP, kConfirmV, kShared []byte, err error) {
+	M, N := spake2PlusMN()
+	md := sm3.New()
+	for _, field := range [][]byte{t.context, t.idProver, t.idVerifier, M.Bytes(), N.Bytes(), shareP, shareV, Z, V, w0} {
+		var l [8]byte
+		binary.LittleEndian.PutUint64(l[:], uint64(len(field)))
+		md.Write(l[:])
+		md.Write(field)
+	}
+	kMain := md.Sum(nil)
+
+	confirmationKeys := make([]byte, 2*sm3.Size)
+	if _, err = io.ReadFull(hkdf.New(sm3.New, kMain, nil, []byte("ConfirmationKeys")), confirmationKeys); err != nil {
+		return
+	}
+	kShared = make([]byte, sm3.Size)
+	if _, err = io.ReadFull(hkdf.New(sm3.New, kMain, nil, []byte("SharedKey")), kShared); err != nil {
+		return
+	}
+	return confirmationKeys[:sm3.Size], confirmationKeys[sm3.Size:], kShared, nil
+}
+
+func mac(key, data []byte) []byte {
+	h := hmac.New(sm3.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// blind computes s*P + w0*Q.
+func blind(s, w0 *bigmod.Nat, Q *_sm2ec.SM2P256Point) (*_sm2ec.SM2P256Point, error) {
+	n := curveOrder()
+	p, err := _sm2ec.NewSM2P256Point().ScalarBaseMult(s.Bytes(n))
+	if err != nil {
+		return nil, err
+	}
+	q, err := _sm2ec.NewSM2P256Point().ScalarMult(Q, w0.Bytes(n))
+	if err != nil {
+		return nil, err
+	}
+	return p.Add(p, q), nil
+}
+
+// unblind computes share - w0*Q.
+func unblind(share []byte, w0 *bigmod.Nat, Q *_sm2ec.SM2P256Point) (*_sm2ec.SM2P256Point, error) {
+	n := curveOrder()
+	p, err := parsePoint(share)
+	if err != nil {
+		return nil, err
+	}
+	negW0 := bigmod.NewNat().ExpandFor(n).Sub(w0, n)
+	q, err := _sm2ec.NewSM2P256Point().ScalarMult(Q, negW0.Bytes(n))
+	if err != nil {
+		return nil, err
+	}
+	return p.Add(p, q), nil
+}
+
+func scalarMultBytes(Q *_sm2ec.SM2P256Point, k []byte) ([]byte, error) {
+	p, err := _sm2ec.NewSM2P256Point().ScalarMult(Q, k)
+	if err != nil {
+		return nil, err
+	}
+	if isInfinity(p) {
+		return nil, errInvalidPoint
+	}
+	return p.Bytes(), nil
+}
+
+// SPAKE2PlusProver is the prover (client) of SPAKE2+, who knows the password derived w0 and w1.
+type SPAKE2PlusProver struct {
+	transcript spake2PlusTranscript
+	w0, w1, x  *bigmod.Nat
+	shareP     []byte
+}
+
+// NewSPAKE2PlusProver creates the prover and computes shareP = x*P + w0*M.
+func NewSPAKE2PlusProver(rand io.Reader, context, idProver, idVerifier, w0, w1 []byte) (*SPAKE2PlusProver, error) {
+	n := curveOrder()
+	w0Nat, err := bigmod.NewNat().SetBytes(w0, n)
+	if err != nil {
+		return nil, err
+	}
+	w1Nat, err := bigmod.NewNat().SetBytes(w1, n)
+	if err != nil {
+		return nil, err
+	}
+	x, err := randomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	M, _ := spake2PlusMN()
+	X, err := blind(x, w0Nat, M)
+	if err != nil {
+		return nil, err
+	}
+	return &SPAKE2PlusProver{
+		transcript: spake2PlusTranscript{context, idProver, idVerifier},
+		w0:         w0Nat,
+		w1:         w1Nat,
+		x:          x,
+		shareP:     X.Bytes(),
+	}, nil
+}
+
+// ShareP returns the prover's share to be sent to the verifier.
+func (p *SPAKE2PlusProver) ShareP() []byte {
+	return append([]byte{}, p.shareP...)
+}
+
+// Finish processes the verifier's share and confirmation, it returns the prover's
+// confirmation to be sent to the verifier and the shared key.
+func (p *SPAKE2PlusProver) Finish(shareV, confirmV []byte) (confirmP, sharedKey []byte, err error) {
+	n := curveOrder()
+	_, N := spake2PlusMN()
+	T, err := unblind(shareV, p.w0, N)
+	if err != nil {
+		return nil, nil, err
+	}
+	Z, err := scalarMultBytes(T, p.x.Bytes(n))
+	if err != nil {
+		return nil, nil, err
+	}
+	V, err := scalarMultBytes(T, p.w1.Bytes(n))
+	if err != nil {
+		return nil, nil, err
+	}
+	kConfirmP, kConfirmV, kShared, err := p.transcript.keys(p.shareP, shareV, Z, V, p.w0.Bytes(n))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !hmac.Equal(confirmV, mac(kConfirmV, p.shareP)) {
+		return nil, nil, errConfirmation
+	}
+	return mac(kConfirmP, shareV), kShared, nil
+}
+
+// SPAKE2PlusVerifier is the verifier (server) of SPAKE2+, who only stores w0 and the registration record L.
+type SPAKE2PlusVerifier struct {
+	transcript spake2PlusTranscript
+	w0         *bigmod.Nat
+	L          *_sm2ec.SM2P256Point
+	kConfirmP  []byte
+	shareV     []byte
+	kShared    []byte
+}
+
+// NewSPAKE2PlusVerifier creates the verifier.
+func NewSPAKE2PlusVerifier(context, idProver, idVerifier, w0, L []byte) (*SPAKE2PlusVerifier, error) {
+	w0Nat, err := bigmod.NewNat().SetBytes(w0, curveOrder())
+	if err != nil {
+		return nil, err
+	}
+	l, err := parsePoint(L)
+	if err != nil {
+		return nil, err
+	}
+	return &SPAKE2PlusVerifier{
+		transcript: spake2PlusTranscript{context, idProver, idVerifier},
+		w0:         w0Nat,
+		L:          l,
+	}, nil
+}
+
+// Respond processes the prover's share, it returns the verifier's share and confirmation
+// to be sent to the prover.
+func (v *SPAKE2PlusVerifier) Respond(rand io.Reader, shareP []byte) (shareV, confirmV []byte, err error) {
+	n := curveOrder()
+	M, N := spake2PlusMN()
+	y, err := randomScalar(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	Y, err := blind(y, v.w0, N)
+	if err != nil {
+		return nil, nil, err
+	}
+	shareV = Y.Bytes()
+	T, err := unblind(shareP, v.w0, M)
+	if err != nil {
+		return nil, nil, err
+	}
+	Z, err := scalarMultBytes(T, y.Bytes(n))
+	if err != nil {
+		return nil, nil, err
+	}
+	V, err := scalarMultBytes(v.L, y.Bytes(n))
+	if err != nil {
+		return nil, nil, err
+	}
+	kConfirmP, kConfirmV, kShared, err := v.transcript.keys(shareP, shareV, Z, V, v.w0.Bytes(n))
+	if err != nil {
+		return nil, nil, err
+	}
+	v.kConfirmP = kConfirmP
+	v.shareV = shareV
+	v.kShared = kShared
+	return shareV, mac(kConfirmV, shareP), nil
+}
+
+// Finish verifies the prover's confirmation and returns the shared key.
+func (v *SPAKE2PlusVerifier) Finish(confirmP []byte) ([]byte, error) {
+	if v.kConfirmP == nil {
+		return nil, errors.New("pake: Respond must be called first")
+	}
+	if !hmac.Equal(confirmP, mac(v.kConfirmP, v.shareV)) {
+		return nil, errConfirmation
+	}
+	return v.kShared, nil
+}
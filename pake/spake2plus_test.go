@@ -0,0 +1,113 @@
+package pake
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm3"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func spake2PlusSecrets(t *testing.T, password string) (w0, w1, L []byte) {
+	t.Helper()
+	w0, w1, L, err := SPAKE2PlusRegistration(pbkdf2.Key([]byte(password), []byte("salt"), 1000, 2*W0W1Length, sm3.New))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestSPAKE2Plus(t *testing.T) {
+	w0, w1, L := spake2PlusSecrets(t, "password")
+	context := []byte("SPAKE2+ SM2 test")
+	prover, err := NewSPAKE2PlusProver(rand.Reader, context, []byte("client"), []byte("server"), w0, w1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := NewSPAKE2PlusVerifier(context, []byte("client"), []byte("server"), w0, L)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shareV, confirmV, err := verifier.Respond(rand.Reader, prover.ShareP())
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirmP, keyP, err := prover.Finish(shareV, confirmV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyV, err := verifier.Finish(confirmP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(keyP, keyV) {
+		t.Errorf("shared key mismatch")
+	}
+	if _, err = verifier.Finish(confirmV); err == nil {
+		t.Errorf("expected confirmation failure")
+	}
+}
+
+func TestSPAKE2PlusWrongPassword(t *testing.T) {
+	w0, w1, _ := spake2PlusSecrets(t, "passw0rd")
+	w0v, _, L := spake2PlusSecrets(t, "password")
+	prover, err := NewSPAKE2PlusProver(rand.Reader, nil, nil, nil, w0, w1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := NewSPAKE2PlusVerifier(nil, nil, nil, w0v, L)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shareV, confirmV, err := verifier.Respond(rand.Reader, prover.ShareP())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = prover.Finish(shareV, confirmV); err != errConfirmation {
+		t.Errorf("expected confirmation failure, got %v", err)
+	}
+}
+
+func TestSPAKE2PlusInvalidInput(t *testing.T) {
+	if _, _, _, err := SPAKE2PlusRegistration(make([]byte, 10)); err == nil {
+		t.Errorf("expected error")
+	}
+	w0, _, L := spake2PlusSecrets(t, "password")
+	verifier, err := NewSPAKE2PlusVerifier(nil, nil, nil, w0, L)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = verifier.Finish(nil); err == nil {
+		t.Errorf("expected error")
+	}
+	if _, _, err = verifier.Respond(rand.Reader, []byte{0}); err == nil {
+		t.Errorf("expected error")
+	}
+	if _, err = NewSPAKE2PlusVerifier(nil, nil, nil, w0, []byte{0}); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestReduceScalar(t *testing.T) {
+	n := curveOrder()
+	// n + 1 should be reduced to 1
+	b := []byte{0xFF, 0xFF, 0xFF, 0xFE, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+		0x72, 0x03, 0xDF, 0x6B, 0x21, 0xC6, 0x05, 0x2B, 0x53, 0xBB, 0xF4, 0x09, 0x39, 0xD5, 0x41, 0x24}
+	k, err := reduceScalar(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make([]byte, 32)
+	want[31] = 1
+	if !bytes.Equal(k.Bytes(n), want) {
+		t.Errorf("got %x", k.Bytes(n))
+	}
+	// 2^256 mod n
+	b = make([]byte, 33)
+	b[0] = 1
+	k, _ = reduceScalar(b)
+	if !bytes.Equal(k.Bytes(n), twoTo256.Bytes(n)) {
+		t.Errorf("got %x", k.Bytes(n))
+	}
+}
@@ -0,0 +1,271 @@
+package pake
+
+import (
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"io"
+	"math/big"
+
+	"github.com/emmansun/gmsm/internal/bigmod"
+	"github.com/emmansun/gmsm/sm3"
+)
+
+// SRP-6a verifier-based password authentication (RFC 2945, RFC 5054) with SM3.
+//
+//	x = H(s | H(I | ":" | P))
+//	v = g^x % N
+//	k = H(N | PAD(g))
+//	A = g^a % N
+//	B = (k*v + g^b) % N
+//	u = H(PAD(A) | PAD(B))
+//	S = (B - k*g^x)^(a + u*x) % N = (A * v^u)^b % N
+//	K = H(PAD(S))
+//	M1 = H(H(N) XOR H(g) | H(I) | s | PAD(A) | PAD(B) | K)
+//	M2 = H(PAD(A) | M1 | K)
+
+// SRPGroup is a SRP group, N is a safe prime and G is a generator modulo N.
+type SRPGroup struct {
+	N *big.Int
+	G *big.Int
+}
+
+func srpGroupFromHex(n string, g int64) *SRPGroup {
+	N, _ := new(big.Int).SetString(n, 16)
+	return &SRPGroup{N: N, G: big.NewInt(g)}
+}
+
+// SRPGroup1024 is the 1024-bit group of RFC 5054 Appendix A, it's for legacy compatibility only.
+var SRPGroup1024 = srpGroupFromHex("EEAF0AB9ADB38DD69C33F80AFA8FC5E86072618775FF3C0B9EA2314C9C256576D674DF7496EA81D3383B4813D692C6E0E0D5D8E250B98BE48E495C1D6089DAD15DC7D7B46154D6B6CE8EF4AD69B15D4982559B297BCF1885C529F566660E57EC68EDBC3C05726CC02FD4CBF4976EAA9AFD5138FE8376435B9FC61D2FC0EB06E3", 2)
+
+// SRPGroup2048 is the 2048-bit group of RFC 5054 Appendix A.
+var SRPGroup2048 = srpGroupFromHex("AC6BDB41324A9A9BF166DE5E1389582FAF72B6651987EE07FC3192943DB56050A37329CBB4A099ED8193E0757767A13DD52312AB4B03310DCD7F48A9DA04FD50E8083969EDB767B0CF6095179A163AB3661A05FBD5FAAAE82918A9962F0B93B855F97993EC975EEAA80D740ADBF4FF747359D041D5C33EA71D281E446B14773BCA97B43A23FB801676BD207A436C6481F1D2B9078717461A5B9D32E688F87748544523B524B0D57D5EA77A2775D2ECFA032CFBDBF52FB3786160279004E57AE6AF874E7303CE53299CCC041C7BC308D82A5698F3A8D0C38271AE35F8E9DBFBB694B5C803D89F7AE435DE236D525F54759B65E372FCD68EF20FA7111F9E4AFF73", 2)
+
+var errSRPIllegalParameter = errors.New("pake: illegal SRP parameter")
+
+// SRP holds the group and hash function of SRP-6a.
+type SRP struct {
+	group   *SRPGroup
+	newHash func() hash.Hash
+	n       *bigmod.Modulus
+	size    int
+	k       *big.Int
+}
+
+// NewSRP creates a SRP-6a instance, a nil group means SRPGroup2048, a nil newHash means sm3.New.
+func NewSRP(group *SRPGroup, newHash func() hash.Hash) (*SRP, error) {
+	if group == nil {
+		group = SRPGroup2048
+	}
+	if newHash == nil {
+		newHash = sm3.New
+	}
+	if group.N == nil || group.G == nil || group.G.Sign() <= 0 || group.G.Cmp(group.N) >= 0 {
+		return nil, errors.New("pake: invalid SRP group")
+	}
+	n, err := bigmod.NewModulusFromBig(group.N)
+	if err != nil {
+		return nil, err
+	}
+	s := &SRP{group: group, newHash: newHash, n: n, size: n.Size()}
+	s.k = new(big.Int).SetBytes(s.hash(group.N.Bytes(), s.pad(group.G)))
+	return s, nil
+}
+
+func (s *SRP) hash(data ...[]byte) []byte {
+	md := s.newHash()
+	for _, d := range data {
+		md.Write(d)
+	}
+	return md.Sum(nil)
+}
+
+func (s *SRP) pad(x *big.Int) []byte {
+	return x.FillBytes(make([]byte, s.size))
+}
+
+// exp returns base^e % N in constant time with respect to base and e of the given bit length.
+func (s *SRP) exp(base, e *big.Int, eLen int) *big.Int {
+	b, _ := bigmod.NewNat().SetBytes(s.pad(base), s.n)
+	out := bigmod.NewNat().Exp(b, e.FillBytes(make([]byte, eLen)), s.n)
+	return new(big.Int).SetBytes(out.Bytes(s.n))
+}
+
+func (s *SRP) x(identity, password, salt []byte) *big.Int {
+	inner := s.hash(identity, []byte(":"), password)
+	return new(big.Int).SetBytes(s.hash(salt, inner))
+}
+
+// ComputeVerifier computes the password verifier v = g^x % N which is stored by the server
+// along with the salt.
+func (s *SRP) ComputeVerifier(identity, password, salt []byte) []byte {
+	x := s.x(identity, password, salt)
+	return s.pad(s.exp(s.group.G, x, s.newHash().Size()))
+}
+
+func (s *SRP) randomSecret(rand io.Reader) (*big.Int, error) {
+	// 256 bits is sufficient, RFC 5054 requires at least 256 bits
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(rand, b); err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (s *SRP) u(A, B *big.Int) (*big.Int, error) {
+	u := new(big.Int).SetBytes(s.hash(s.pad(A), s.pad(B)))
+	if u.Sign() == 0 {
+		return nil, errSRPIllegalParameter
+	}
+	return u, nil
+}
+
+func (s *SRP) m1(identity, salt []byte, A, B *big.Int, K []byte) []byte {
+	hn := s.hash(s.group.N.Bytes())
+	hg := s.hash(s.group.G.Bytes())
+	for i := range hn {
+		hn[i] ^= hg[i]
+	}
+	return s.hash(hn, s.hash(identity), salt, s.pad(A), s.pad(B), K)
+}
+
+func (s *SRP) m2(A *big.Int, m1, K []byte) []byte {
+	return s.hash(s.pad(A), m1, K)
+}
+
+// parsePublic parses a public value and checks it's not 0 modulo N.
+func (s *SRP) parsePublic(b []byte) (*big.Int, error) {
+	v := new(big.Int).SetBytes(b)
+	if len(b) > s.size || v.Cmp(s.group.N) >= 0 || v.Sign() == 0 {
+		return nil, errSRPIllegalParameter
+	}
+	return v, nil
+}
+
+// SRPClient is the client side of SRP-6a.
+type SRPClient struct {
+	srp                *SRP
+	identity, password []byte
+	a, public          *big.Int
+	m1, K              []byte
+}
+
+// NewClient creates a client session, A returns the client's public value to be sent to the server.
+func (s *SRP) NewClient(rand io.Reader, identity, password []byte) (*SRPClient, error) {
+	a, err := s.randomSecret(rand)
+	if err != nil {
+		return nil, err
+	}
+	return s.newClient(a, identity, password), nil
+}
+
+func (s *SRP) newClient(a *big.Int, identity, password []byte) *SRPClient {
+	return &SRPClient{
+		srp:      s,
+		identity: identity,
+		password: password,
+		a:        a,
+		public:   s.exp(s.group.G, a, 32),
+	}
+}
+
+// A returns the client's public value.
+func (c *SRPClient) A() []byte {
+	return c.srp.pad(c.public)
+}
+
+// Finish processes the salt and the server's public value B, it returns the client's evidence M1
+// to be sent to the server.
+func (c *SRPClient) Finish(salt, serverPublic []byte) ([]byte, error) {
+	s := c.srp
+	B, err := s.parsePublic(serverPublic)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.u(c.public, B)
+	if err != nil {
+		return nil, err
+	}
+	x := s.x(c.identity, c.password, salt)
+	// S = (B - k*g^x)^(a + u*x) % N
+	t := s.exp(s.group.G, x, s.newHash().Size())
+	t.Mul(t, s.k)
+	t.Sub(B, t)
+	t.Mod(t, s.group.N)
+	e := new(big.Int).Mul(u, x)
+	e.Add(e, c.a)
+	S := s.exp(t, e, 2*s.newHash().Size()+33)
+	c.K = s.hash(s.pad(S))
+	c.m1 = s.m1(c.identity, salt, c.public, B, c.K)
+	return c.m1, nil
+}
+
+// VerifyServer verifies the server's evidence M2 and returns the session key K.
+func (c *SRPClient) VerifyServer(m2 []byte) ([]byte, error) {
+	if c.m1 == nil {
+		return nil, errors.New("pake: Finish must be called first")
+	}
+	if subtle.ConstantTimeCompare(m2, c.srp.m2(c.public, c.m1, c.K)) != 1 {
+		return nil, errConfirmation
+	}
+	return c.K, nil
+}
+
+// SRPServer is the server side of SRP-6a.
+type SRPServer struct {
+	srp            *SRP
+	identity, salt []byte
+	v, b, public   *big.Int
+}
+
+// NewServer creates a server session with the stored salt and verifier,
+// B returns the server's public value to be sent to the client along with the salt.
+func (s *SRP) NewServer(rand io.Reader, identity, salt, verifier []byte) (*SRPServer, error) {
+	b, err := s.randomSecret(rand)
+	if err != nil {
+		return nil, err
+	}
+	return s.newServer(b, identity, salt, verifier)
+}
+
+func (s *SRP) newServer(b *big.Int, identity, salt, verifier []byte) (*SRPServer, error) {
+	v, err := s.parsePublic(verifier)
+	if err != nil {
+		return nil, err
+	}
+	// B = (k*v + g^b) % N
+	B := new(big.Int).Mul(s.k, v)
+	B.Add(B, s.exp(s.group.G, b, 32))
+	B.Mod(B, s.group.N)
+	return &SRPServer{srp: s, identity: identity, salt: salt, v: v, b: b, public: B}, nil
+}
+
+// B returns the server's public value.
+func (sv *SRPServer) B() []byte {
+	return sv.srp.pad(sv.public)
+}
+
+// Finish processes the client's public value A and evidence M1, it returns the server's
+// evidence M2 to be sent to the client and the session key K.
+func (sv *SRPServer) Finish(clientPublic, m1 []byte) (m2, K []byte, err error) {
+	s := sv.srp
+	A, err := s.parsePublic(clientPublic)
+	if err != nil {
+		return nil, nil, err
+	}
+	u, err := s.u(A, sv.public)
+	if err != nil {
+		return nil, nil, err
+	}
+	// S = (A * v^u)^b % N
+	t := s.exp(sv.v, u, s.newHash().Size())
+	t.Mul(t, A)
+	t.Mod(t, s.group.N)
+	S := s.exp(t, sv.b, 32)
+	K = s.hash(s.pad(S))
+	expected := s.m1(sv.identity, sv.salt, A, sv.public, K)
+	if subtle.ConstantTimeCompare(m1, expected) != 1 {
+		return nil, nil, errConfirmation
+	}
+	return s.m2(A, m1, K), K, nil
+}
@@ -0,0 +1,138 @@
+package pake
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func fromHex(s string) []byte {
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// RFC 5054 Appendix B test vectors
+func TestSRPRFC5054(t *testing.T) {
+	s, err := NewSRP(SRPGroup1024, sha1.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, password := []byte("alice"), []byte("password123")
+	salt := fromHex("BEB25379 D1A8581E B5A72767 3A2441EE")
+	a := new(big.Int).SetBytes(fromHex("60975527 035CF2AD 1989806F 0407210B C81EDC04 E2762A56 AFD529DD DA2D4393"))
+	b := new(big.Int).SetBytes(fromHex("E487CB59 D31AC550 471E81F0 0F6928E0 1DDA08E9 74A004F4 9E61F5D1 05284D20"))
+
+	if !bytes.Equal(s.k.Bytes(), fromHex("7556AA04 5AEF2CDD 07ABAF0F 665C3E81 8913186F")) {
+		t.Errorf("unexpected k %x", s.k)
+	}
+	v := s.ComputeVerifier(identity, password, salt)
+	wantV := fromHex("7E273DE8 696FFC4F 4E337D05 B4B375BE B0DDE156 9E8FA00A 9886D812 9BADA1F1 822223CA 1A605B53 0E379BA4 729FDC59 F105B478 7E5186F5 C671085A 1447B52A 48CF1970 B4FB6F84 00BBF4CE BFBB1681 52E08AB5 EA53D15C 1AFF87B2 B9DA6E04 E058AD51 CC72BFC9 033B564E 26480D78 E955A5E2 9E7AB245 DB2BE315 E2099AFB")
+	if !bytes.Equal(v, wantV) {
+		t.Errorf("unexpected verifier %x", v)
+	}
+	client := s.newClient(a, identity, password)
+	wantA := fromHex("61D5E490 F6F1B795 47B0704C 436F523D D0E560F0 C64115BB 72557EC4 4352E890 3211C046 92272D8B 2D1A5358 A2CF1B6E 0BFCF99F 921530EC 8E393561 79EAE45E 42BA92AE ACED8251 71E1E8B9 AF6D9C03 E1327F44 BE087EF0 6530E69F 66615261 EEF54073 CA11CF58 58F0EDFD FE15EFEA B349EF5D 76988A36 72FAC47B 0769447B")
+	if !bytes.Equal(client.A(), wantA) {
+		t.Errorf("unexpected A %x", client.A())
+	}
+	server, err := s.newServer(b, identity, salt, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantB := fromHex("BD0C6151 2C692C0C B6D041FA 01BB152D 4916A1E7 7AF46AE1 05393011 BAF38964 DC46A067 0DD125B9 5A981652 236F99D9 B681CBF8 7837EC99 6C6DA044 53728610 D0C6DDB5 8B318885 D7D82C7F 8DEB75CE 7BD4FBAA 37089E6F 9C6059F3 88838E7A 00030B33 1EB76840 910440B1 B27AAEAE EB4012B7 D7665238 A8E3FB00 4B117B58")
+	if !bytes.Equal(server.B(), wantB) {
+		t.Errorf("unexpected B %x", server.B())
+	}
+	m1, err := client.Finish(salt, server.B())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantS := fromHex("B0DC82BA BCF30674 AE450C02 87745E79 90A3381F 63B387AA F271A10D 233861E3 59B48220 F7C4693C 9AE12B0A 6F67809F 0876E2D0 13800D6C 41BB59B6 D5979B5C 00A172B4 A2A5903A 0BDCAF8A 709585EB 2AFAFA8F 3499B200 210DCC1F 10EB3394 3CD67FC8 8A2F39A4 BE5BEC4E C0A3212D C346D7E4 74B29EDE 8A469FFE CA686E5A")
+	h := sha1.Sum(wantS)
+	if !bytes.Equal(client.K, h[:]) {
+		t.Errorf("unexpected premaster secret")
+	}
+	m2, key, err := server.Finish(client.A(), m1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientKey, err := client.VerifyServer(m2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, clientKey) {
+		t.Errorf("session key mismatch")
+	}
+}
+
+func TestSRP(t *testing.T) {
+	s, err := NewSRP(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := []byte("alice")
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	v := s.ComputeVerifier(identity, []byte("password"), salt)
+
+	for _, password := range []string{"password", "passw0rd"} {
+		client, err := s.NewClient(rand.Reader, identity, []byte(password))
+		if err != nil {
+			t.Fatal(err)
+		}
+		server, err := s.NewServer(rand.Reader, identity, salt, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = client.VerifyServer(nil); err == nil {
+			t.Errorf("expected error")
+		}
+		m1, err := client.Finish(salt, server.B())
+		if err != nil {
+			t.Fatal(err)
+		}
+		m2, key, err := server.Finish(client.A(), m1)
+		if password != "password" {
+			if err != errConfirmation {
+				t.Errorf("expected confirmation failure, got %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		clientKey, err := client.VerifyServer(m2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(key, clientKey) {
+			t.Errorf("session key mismatch")
+		}
+	}
+}
+
+func TestSRPIllegalParameter(t *testing.T) {
+	s, err := NewSRP(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := s.NewClient(rand.Reader, []byte("alice"), []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, B := range [][]byte{nil, make([]byte, 256), SRPGroup2048.N.Bytes(), make([]byte, 257)} {
+		if _, err = client.Finish(nil, B); err != errSRPIllegalParameter {
+			t.Errorf("expected illegal parameter error, got %v", err)
+		}
+	}
+	if _, err = NewSRP(&SRPGroup{N: big.NewInt(23), G: big.NewInt(23)}, nil); err == nil {
+		t.Errorf("expected invalid group error")
+	}
+}
@@ -3,13 +3,14 @@ package pkcs
 
 import (
 	"crypto/cipher"
-	"crypto/rand"
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"errors"
 	"fmt"
+	"io"
 
 	smcipher "github.com/emmansun/gmsm/cipher"
+	"github.com/emmansun/gmsm/internal/randutil"
 	"github.com/emmansun/gmsm/padding"
 )
 
@@ -63,8 +64,39 @@ func (b *baseBlockCipher) OID() asn1.ObjectIdentifier {
 	return b.oid
 }
 
+// WithPadding returns a copy of c, an ECB or CBC cipher of this package such
+// as SM4ECB or SM4CBC, which pads the plaintext with the scheme returned by
+// newPadding for the block size of the cipher instead of PKCS#7, e.g.
+// padding.NewISO9797M2Padding. The padding isn't part of the algorithm
+// identifier, both parties must agree on it.
+func WithPadding(c Cipher, newPadding func(blockSize uint) padding.Padding) (Cipher, error) {
+	switch c := c.(type) {
+	case *ecbBlockCipher:
+		cp := *c
+		cp.newPadding = newPadding
+		return &cp, nil
+	case *cbcBlockCipher:
+		cp := *c
+		cp.newPadding = newPadding
+		return &cp, nil
+	}
+	return nil, errors.New("pkcs: padding is only supported by ECB and CBC ciphers")
+}
+
+type paddingScheme struct {
+	newPadding func(blockSize uint) padding.Padding
+}
+
+func (p *paddingScheme) padding(block cipher.Block) padding.Padding {
+	if p.newPadding == nil {
+		return padding.NewPKCS7Padding(uint(block.BlockSize()))
+	}
+	return p.newPadding(uint(block.BlockSize()))
+}
+
 type ecbBlockCipher struct {
 	baseBlockCipher
+	paddingScheme
 }
 
 func (ecb *ecbBlockCipher) Encrypt(key, plaintext []byte) (*pkix.AlgorithmIdentifier, []byte, error) {
@@ -73,8 +105,7 @@ func (ecb *ecbBlockCipher) Encrypt(key, plaintext []byte) (*pkix.AlgorithmIdenti
 		return nil, nil, err
 	}
 	mode := smcipher.NewECBEncrypter(block)
-	pkcs7 := padding.NewPKCS7Padding(uint(block.BlockSize()))
-	plaintext = pkcs7.Pad(plaintext)
+	plaintext = ecb.padding(block).Pad(plaintext)
 	ciphertext := make([]byte, len(plaintext))
 	mode.CryptBlocks(ciphertext, plaintext)
 
@@ -93,8 +124,7 @@ func (ecb *ecbBlockCipher) Decrypt(key []byte, parameters *asn1.RawValue, cipher
 	mode := smcipher.NewECBDecrypter(block)
 	plaintext := make([]byte, len(ciphertext))
 	mode.CryptBlocks(plaintext, ciphertext)
-	pkcs7 := padding.NewPKCS7Padding(uint(block.BlockSize()))
-	unpadded, err := pkcs7.Unpad(plaintext)
+	unpadded, err := ecb.padding(block).Unpad(plaintext)
 	if err != nil { // In order to be compatible with some implementations without padding
 		return plaintext, nil
 	}
@@ -103,6 +133,7 @@ func (ecb *ecbBlockCipher) Decrypt(key []byte, parameters *asn1.RawValue, cipher
 
 type cbcBlockCipher struct {
 	baseBlockCipher
+	paddingScheme
 	ivSize int
 }
 
@@ -115,7 +146,7 @@ func (c *cbcBlockCipher) Encrypt(key, plaintext []byte) (*pkix.AlgorithmIdentifi
 	if err != nil {
 		return nil, nil, err
 	}
-	ciphertext, err := cbcEncrypt(block, key, iv, plaintext)
+	ciphertext, err := cbcEncrypt(block, c.padding(block), iv, plaintext)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -144,24 +175,22 @@ func (c *cbcBlockCipher) Decrypt(key []byte, parameters *asn1.RawValue, encrypte
 		return nil, errors.New("pkcs: invalid cipher parameters")
 	}
 
-	return cbcDecrypt(block, key, iv, encryptedKey)
+	return cbcDecrypt(block, c.padding(block), iv, encryptedKey)
 }
 
-func cbcEncrypt(block cipher.Block, key, iv, plaintext []byte) ([]byte, error) {
+func cbcEncrypt(block cipher.Block, pad padding.Padding, iv, plaintext []byte) ([]byte, error) {
 	mode := cipher.NewCBCEncrypter(block, iv)
-	pkcs7 := padding.NewPKCS7Padding(uint(block.BlockSize()))
-	plainText := pkcs7.Pad(plaintext)
+	plainText := pad.Pad(plaintext)
 	ciphertext := make([]byte, len(plainText))
 	mode.CryptBlocks(ciphertext, plainText)
 	return ciphertext, nil
 }
 
-func cbcDecrypt(block cipher.Block, key, iv, ciphertext []byte) ([]byte, error) {
+func cbcDecrypt(block cipher.Block, pad padding.Padding, iv, ciphertext []byte) ([]byte, error) {
 	mode := cipher.NewCBCDecrypter(block, iv)
-	pkcs7 := padding.NewPKCS7Padding(uint(block.BlockSize()))
 	plaintext := make([]byte, len(ciphertext))
 	mode.CryptBlocks(plaintext, ciphertext)
-	return pkcs7.Unpad(plaintext)
+	return pad.Unpad(plaintext)
 }
 
 type gcmBlockCipher struct {
@@ -233,6 +262,6 @@ func (c *gcmBlockCipher) Decrypt(key []byte, parameters *asn1.RawValue, encrypte
 
 func genRandom(len int) ([]byte, error) {
 	value := make([]byte, len)
-	_, err := rand.Read(value)
+	_, err := io.ReadFull(randutil.Reader(), value)
 	return value, err
 }
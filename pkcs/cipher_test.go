@@ -6,6 +6,7 @@ import (
 	"encoding/asn1"
 	"testing"
 
+	"github.com/emmansun/gmsm/padding"
 	"golang.org/x/crypto/cryptobyte"
 	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
 )
@@ -62,6 +63,38 @@ func TestInvalidKeyLen(t *testing.T) {
 	}
 }
 
+func TestWithPadding(t *testing.T) {
+	key := []byte("0123456789ABCDEF")
+	plaintext := []byte("Hello World")
+	for _, c := range []Cipher{SM4ECB, SM4CBC} {
+		padded, err := WithPadding(c, padding.NewISO9797M2Padding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		params, ciphertext, err := padded.Encrypt(key, plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ciphertext) != 16 {
+			t.Fatalf("%v: got %d bytes ciphertext, want 16", c.OID(), len(ciphertext))
+		}
+		got, err := padded.Decrypt(key, &params.Parameters, ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("%v: got %x, want %x", c.OID(), got, plaintext)
+		}
+		// the default PKCS#7 padding doesn't remove the ISO/IEC 9797-1 padding
+		if got, err := c.Decrypt(key, &params.Parameters, ciphertext); err == nil && bytes.Equal(got, plaintext) {
+			t.Errorf("%v: decrypted with the wrong padding", c.OID())
+		}
+	}
+	if _, err := WithPadding(SM4GCM, padding.NewZeroPadding); err == nil {
+		t.Error("expected error for GCM")
+	}
+}
+
 func TestGcmParameters(t *testing.T) {
 	var b cryptobyte.Builder
 	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
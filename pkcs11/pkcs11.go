@@ -0,0 +1,179 @@
+// Package pkcs11 adapts SM4 keys resident in a PKCS#11 token (HSM) to the
+// cipher.AEAD and cipher.KeyWrapper interfaces, so that the same code
+// encrypts and wraps keys with software keys and with hardware keys.
+//
+// The package doesn't link a PKCS#11 library, it calls the token through the
+// Session interface, which is typically a small adapter over a cgo binding
+// such as github.com/miekg/pkcs11, performing C_EncryptInit/C_Encrypt and
+// C_DecryptInit/C_Decrypt on a logged in session.
+//
+// The SM4 mechanisms are not assigned by the PKCS#11 standard, the tokens use
+// vendor defined values (CKM_VENDOR_DEFINED | n), so their types are given by
+// the caller.
+package pkcs11
+
+import (
+	"crypto/cipher"
+	"errors"
+
+	smcipher "github.com/emmansun/gmsm/cipher"
+)
+
+// VendorDefined is CKM_VENDOR_DEFINED, the first mechanism type reserved to
+// token vendors.
+const VendorDefined = 0x80000000
+
+const (
+	gcmNonceSize = 12
+	gcmTagSize   = 16
+)
+
+// ObjectHandle is the CK_OBJECT_HANDLE of a key in the token.
+type ObjectHandle uint
+
+// Mechanism is a CK_MECHANISM. Parameter is nil or a *GCMParams.
+type Mechanism struct {
+	Type      uint
+	Parameter any
+}
+
+// GCMParams are the CK_GCM_PARAMS of a GCM mechanism.
+type GCMParams struct {
+	IV      []byte
+	AAD     []byte
+	TagBits int
+}
+
+// Session is the subset of a PKCS#11 session used by this package.
+type Session interface {
+	// Encrypt encrypts plaintext with the key, as C_EncryptInit and C_Encrypt.
+	Encrypt(mech *Mechanism, key ObjectHandle, plaintext []byte) ([]byte, error)
+	// Decrypt decrypts ciphertext with the key, as C_DecryptInit and C_Decrypt.
+	Decrypt(mech *Mechanism, key ObjectHandle, ciphertext []byte) ([]byte, error)
+}
+
+var (
+	errOpen   = errors.New("pkcs11: message authentication failed")
+	errUnwrap = errors.New("pkcs11: key unwrap failed")
+)
+
+// TokenError is a failure of the token in GCM.Seal, the value of its panic.
+type TokenError struct {
+	Err error
+}
+
+func (e *TokenError) Error() string {
+	return "pkcs11: SM4-GCM encryption failed: " + e.Err.Error()
+}
+
+func (e *TokenError) Unwrap() error {
+	return e.Err
+}
+
+// GCM is the SM4-GCM cipher.AEAD of a SM4 key in a token.
+type GCM struct {
+	session   Session
+	key       ObjectHandle
+	mechanism uint
+}
+
+var _ cipher.AEAD = (*GCM)(nil)
+
+// NewGCM returns the SM4-GCM cipher.AEAD of the SM4 key in the token, with
+// the standard nonce and tag sizes. mechanism is the type of the token's
+// CKM_SM4_GCM.
+//
+// As cipher.AEAD.Seal can't return an error, Seal panics with a *TokenError if
+// the token fails, e.g. if the session is closed; use TrySeal to get the error
+// instead.
+func NewGCM(s Session, key ObjectHandle, mechanism uint) *GCM {
+	return &GCM{session: s, key: key, mechanism: mechanism}
+}
+
+func (g *GCM) NonceSize() int { return gcmNonceSize }
+
+func (g *GCM) Overhead() int { return gcmTagSize }
+
+func (g *GCM) params(nonce, additionalData []byte) *Mechanism {
+	return &Mechanism{Type: g.mechanism, Parameter: &GCMParams{IV: nonce, AAD: additionalData, TagBits: gcmTagSize * 8}}
+}
+
+// Seal is TrySeal, it panics with the *TokenError of a token failure.
+func (g *GCM) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	out, err := g.TrySeal(dst, nonce, plaintext, additionalData)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// TrySeal encrypts and authenticates plaintext like Seal, and returns a
+// *TokenError instead of panicking if the token fails.
+func (g *GCM) TrySeal(dst, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmNonceSize {
+		panic("pkcs11: incorrect nonce length given to GCM")
+	}
+	out, err := g.session.Encrypt(g.params(nonce, additionalData), g.key, plaintext)
+	if err != nil {
+		return nil, &TokenError{Err: err}
+	}
+	if len(out) != len(plaintext)+gcmTagSize {
+		return nil, &TokenError{Err: errors.New("unexpected ciphertext length")}
+	}
+	return append(dst, out...), nil
+}
+
+func (g *GCM) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmNonceSize {
+		panic("pkcs11: incorrect nonce length given to GCM")
+	}
+	if len(ciphertext) < gcmTagSize {
+		return nil, errOpen
+	}
+	out, err := g.session.Decrypt(g.params(nonce, additionalData), g.key, ciphertext)
+	if err != nil || len(out) != len(ciphertext)-gcmTagSize {
+		// tokens report CKR_ENCRYPTED_DATA_INVALID or similar, don't
+		// distinguish them from the other failures.
+		return nil, errOpen
+	}
+	return append(dst, out...), nil
+}
+
+type keyWrapper struct {
+	session   Session
+	key       ObjectHandle
+	mechanism uint
+}
+
+// NewKeyWrapper returns the RFC 3394 cipher.KeyWrapper of the SM4 key
+// encryption key in the token. mechanism is the type of the token's
+// CKM_SM4_KEY_WRAP, which must support C_Encrypt and C_Decrypt with the
+// default initial value, like CKM_AES_KEY_WRAP.
+func NewKeyWrapper(s Session, key ObjectHandle, mechanism uint) smcipher.KeyWrapper {
+	return &keyWrapper{session: s, key: key, mechanism: mechanism}
+}
+
+func (w *keyWrapper) Wrap(plaintext []byte) ([]byte, error) {
+	if len(plaintext) < 16 || len(plaintext)%8 != 0 {
+		return nil, errors.New("pkcs11: invalid key wrap plaintext length")
+	}
+	out, err := w.session.Encrypt(&Mechanism{Type: w.mechanism}, w.key, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) != len(plaintext)+8 {
+		return nil, errors.New("pkcs11: unexpected key wrap output length")
+	}
+	return out, nil
+}
+
+func (w *keyWrapper) Unwrap(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 24 || len(ciphertext)%8 != 0 {
+		return nil, errors.New("pkcs11: invalid key wrap ciphertext length")
+	}
+	out, err := w.session.Decrypt(&Mechanism{Type: w.mechanism}, w.key, ciphertext)
+	if err != nil || len(out) != len(ciphertext)-8 {
+		return nil, errUnwrap
+	}
+	return out, nil
+}
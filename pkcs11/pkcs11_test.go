@@ -0,0 +1,178 @@
+package pkcs11
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	smcipher "github.com/emmansun/gmsm/cipher"
+	"github.com/emmansun/gmsm/jose"
+	"github.com/emmansun/gmsm/sm4"
+)
+
+const (
+	ckmSM4GCM     = VendorDefined | 0x10
+	ckmSM4KeyWrap = VendorDefined | 0x11
+)
+
+var errClosed = errors.New("CKR_SESSION_CLOSED")
+
+// softToken emulates a token with SM4 keys in software.
+type softToken struct {
+	keys   map[ObjectHandle][]byte
+	closed bool
+}
+
+func (t *softToken) cipher(key ObjectHandle) (cipher.Block, error) {
+	if t.closed {
+		return nil, errClosed
+	}
+	k, ok := t.keys[key]
+	if !ok {
+		return nil, errors.New("CKR_KEY_HANDLE_INVALID")
+	}
+	return sm4.NewCipher(k)
+}
+
+func (t *softToken) Encrypt(mech *Mechanism, key ObjectHandle, plaintext []byte) ([]byte, error) {
+	block, err := t.cipher(key)
+	if err != nil {
+		return nil, err
+	}
+	switch mech.Type {
+	case ckmSM4GCM:
+		p := mech.Parameter.(*GCMParams)
+		aead, err := cipher.NewGCMWithTagSize(block, p.TagBits/8)
+		if err != nil {
+			return nil, err
+		}
+		return aead.Seal(nil, p.IV, plaintext, p.AAD), nil
+	case ckmSM4KeyWrap:
+		return smcipher.KeyWrap(block, plaintext)
+	}
+	return nil, errors.New("CKR_MECHANISM_INVALID")
+}
+
+func (t *softToken) Decrypt(mech *Mechanism, key ObjectHandle, ciphertext []byte) ([]byte, error) {
+	block, err := t.cipher(key)
+	if err != nil {
+		return nil, err
+	}
+	switch mech.Type {
+	case ckmSM4GCM:
+		p := mech.Parameter.(*GCMParams)
+		aead, err := cipher.NewGCMWithTagSize(block, p.TagBits/8)
+		if err != nil {
+			return nil, err
+		}
+		return aead.Open(nil, p.IV, ciphertext, p.AAD)
+	case ckmSM4KeyWrap:
+		return smcipher.KeyUnwrap(block, ciphertext)
+	}
+	return nil, errors.New("CKR_MECHANISM_INVALID")
+}
+
+func newToken(t *testing.T) (*softToken, []byte) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return &softToken{keys: map[ObjectHandle][]byte{1: key}}, key
+}
+
+func TestGCM(t *testing.T) {
+	token, key := newToken(t)
+	hard := NewGCM(token, 1, ckmSM4GCM)
+	block, _ := sm4.NewCipher(key)
+	soft, _ := cipher.NewGCM(block)
+	if hard.NonceSize() != soft.NonceSize() || hard.Overhead() != soft.Overhead() {
+		t.Fatal("sizes differ from the software implementation")
+	}
+
+	nonce := make([]byte, hard.NonceSize())
+	plaintext, aad := []byte("hello world"), []byte("header")
+	sealed := hard.Seal([]byte("prefix"), nonce, plaintext, aad)
+	if want := soft.Seal([]byte("prefix"), nonce, plaintext, aad); !bytes.Equal(sealed, want) {
+		t.Fatalf("got %x, want %x", sealed, want)
+	}
+	got, err := hard.Open(nil, nonce, sealed[6:], aad)
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := hard.Open(nil, nonce, sealed[6:], aad); err == nil {
+		t.Error("expected authentication failure")
+	}
+	if _, err := hard.Open(nil, nonce, sealed[:10], aad); err == nil {
+		t.Error("expected authentication failure")
+	}
+
+	if got, err := hard.TrySeal(nil, nonce, plaintext, aad); err != nil || !bytes.Equal(got, soft.Seal(nil, nonce, plaintext, aad)) {
+		t.Fatalf("TrySeal: got %x, %v", got, err)
+	}
+
+	token.closed = true
+	var tokenErr *TokenError
+	if _, err := hard.TrySeal(nil, nonce, plaintext, aad); !errors.As(err, &tokenErr) || !errors.Is(err, errClosed) {
+		t.Errorf("TrySeal: got %v, expected a TokenError wrapping the token's error", err)
+	}
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.As(err, &tokenErr) || !errors.Is(err, errClosed) {
+			t.Errorf("got panic %v, expected a TokenError wrapping the token's error", err)
+		}
+	}()
+	hard.Seal(nil, nonce, plaintext, aad)
+}
+
+func TestKeyWrapper(t *testing.T) {
+	token, key := newToken(t)
+	hard := NewKeyWrapper(token, 1, ckmSM4KeyWrap)
+	block, _ := sm4.NewCipher(key)
+	soft, _ := smcipher.NewKeyWrapper(block)
+
+	cek := make([]byte, 16)
+	rand.Read(cek)
+	wrapped, err := hard.Wrap(cek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := soft.Unwrap(wrapped); err != nil || !bytes.Equal(got, cek) {
+		t.Fatalf("got %x, %v", got, err)
+	}
+	if got, err := hard.Unwrap(wrapped); err != nil || !bytes.Equal(got, cek) {
+		t.Fatalf("got %x, %v", got, err)
+	}
+	wrapped[0] ^= 1
+	if _, err := hard.Unwrap(wrapped); err == nil {
+		t.Error("expected integrity check failure")
+	}
+	if _, err := hard.Wrap(cek[:12]); err == nil {
+		t.Error("expected invalid length error")
+	}
+}
+
+func TestJOSE(t *testing.T) {
+	token, key := newToken(t)
+	msg := []byte("hello world")
+	for _, tt := range []struct {
+		alg        string
+		hard, soft any
+	}{
+		{jose.Direct, NewGCM(token, 1, ckmSM4GCM), key},
+		{jose.SM4KW, NewKeyWrapper(token, 1, ckmSM4KeyWrap), key},
+	} {
+		jwe, err := jose.Encrypt(rand.Reader, &jose.Header{Algorithm: tt.alg, Encryption: jose.SM4GCM}, tt.hard, msg)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.alg, err)
+		}
+		for _, k := range []any{tt.hard, tt.soft} {
+			got, _, err := jose.Decrypt(jwe, tt.alg, jose.SM4GCM, k)
+			if err != nil || !bytes.Equal(got, msg) {
+				t.Fatalf("%s: got %q, %v", tt.alg, got, err)
+			}
+		}
+	}
+}
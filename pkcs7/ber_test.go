@@ -51,6 +51,11 @@ func TestBer2Der_Negatives(t *testing.T) {
 		{[]byte{0x30, 0x80, 0x1, 0x2}, "BER tag length is more than available data"},
 		{[]byte{0x30, 0x03, 0x01, 0x02}, "length is more than available data"},
 		{[]byte{0x30}, "end of ber data reached"},
+		// crashers found by fuzzing/testdata/fuzz/FuzzPKCS7
+		{[]byte{0x30, 0x82}, "end of ber data reached"},
+		{[]byte{0x30, 0x84, 0x01}, "end of ber data reached"},
+		{[]byte{0x1f, 0x81}, "end of ber data reached"},
+		{[]byte{0x1f, 0x81, 0x01}, "end of ber data reached"},
 	}
 
 	for _, fixture := range fixtures {
@@ -3,10 +3,10 @@ package pkcs7
 import (
 	"bytes"
 	"crypto"
-	"crypto/rand"
 	"encoding/asn1"
 	"errors"
 
+	"github.com/emmansun/gmsm/internal/randutil"
 	"github.com/emmansun/gmsm/pkcs"
 	"github.com/emmansun/gmsm/smx509"
 )
@@ -36,7 +36,7 @@ func (p7 *PKCS7) Decrypt(cert *smx509.Certificate, pkey crypto.PrivateKey) ([]by
 	switch pkey := pkey.(type) {
 	case crypto.Decrypter:
 		// Generic case to handle anything that provides the crypto.Decrypter interface.
-		contentKey, err := pkey.Decrypt(rand.Reader, recipient.EncryptedKey, nil)
+		contentKey, err := pkey.Decrypt(randutil.Reader(), recipient.EncryptedKey, nil)
 		if err != nil {
 			return nil, err
 		}
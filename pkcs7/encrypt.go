@@ -2,12 +2,13 @@ package pkcs7
 
 import (
 	"crypto/ecdsa"
-	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"errors"
+	"io"
 
+	"github.com/emmansun/gmsm/internal/randutil"
 	"github.com/emmansun/gmsm/pkcs"
 	"github.com/emmansun/gmsm/sm2"
 	"github.com/emmansun/gmsm/smx509"
@@ -84,7 +85,7 @@ func encrypt(cipher pkcs.Cipher, content []byte, recipients []*smx509.Certificat
 
 	// Create key
 	key = make([]byte, cipher.KeySize())
-	_, err = rand.Read(key)
+	_, err = io.ReadFull(randutil.Reader(), key)
 	if err != nil {
 		return nil, err
 	}
@@ -219,10 +220,10 @@ func marshalEncryptedContent(content []byte) asn1.RawValue {
 
 func encryptKey(key []byte, recipient *smx509.Certificate) ([]byte, error) {
 	if pub, ok := recipient.PublicKey.(*rsa.PublicKey); ok {
-		return rsa.EncryptPKCS1v15(rand.Reader, pub, key)
+		return rsa.EncryptPKCS1v15(randutil.Reader(), pub, key)
 	}
 	if pub, ok := recipient.PublicKey.(*ecdsa.PublicKey); ok && pub.Curve == sm2.P256() {
-		return sm2.EncryptASN1(rand.Reader, pub, key)
+		return sm2.EncryptASN1(randutil.Reader(), pub, key)
 	}
 	return nil, errors.New("pkcs7: only supports RSA/SM2 key")
 }
@@ -3,7 +3,6 @@ package pkcs7
 import (
 	"bytes"
 	"crypto"
-	"crypto/rand"
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"errors"
@@ -12,6 +11,7 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/emmansun/gmsm/internal/randutil"
 	"github.com/emmansun/gmsm/sm2"
 	"github.com/emmansun/gmsm/sm3"
 	"github.com/emmansun/gmsm/smx509"
@@ -242,12 +242,12 @@ func (sd *SignedData) SignWithoutAttr(ee *smx509.Certificate, pkey crypto.Privat
 	}
 	_, isSM2 := pkey.(sm2.Signer)
 	if isSM2 {
-		signature, err = key.Sign(rand.Reader, sd.data, sm2.DefaultSM2SignerOpts)
+		signature, err = key.Sign(randutil.Reader(), sd.data, sm2.DefaultSM2SignerOpts)
 	} else {
 		h := newHash(hasher, sd.digestOid)
 		h.Write(sd.data)
 		sd.messageDigest = h.Sum(nil)
-		signature, err = key.Sign(rand.Reader, sd.messageDigest, hasher)
+		signature, err = key.Sign(randutil.Reader(), sd.messageDigest, hasher)
 	}
 	if err != nil {
 		return err
@@ -382,7 +382,7 @@ func signAttributes(attrs []attribute, pkey crypto.PrivateKey, hasher crypto.Has
 	}
 
 	if key, ok := pkey.(sm2.Signer); ok {
-		return key.SignWithSM2(rand.Reader, nil, attrBytes)
+		return key.SignWithSM2(randutil.Reader(), nil, attrBytes)
 	}
 
 	h := hasher.New()
@@ -393,7 +393,7 @@ func signAttributes(attrs []attribute, pkey crypto.PrivateKey, hasher crypto.Has
 	if !ok {
 		return nil, errors.New("pkcs7: private key does not implement crypto.Signer")
 	}
-	return key.Sign(rand.Reader, hash, hasher)
+	return key.Sign(randutil.Reader(), hash, hasher)
 }
 
 // concats and wraps the certificates in the RawValue structure
@@ -2,11 +2,12 @@ package pkcs7
 
 import (
 	"crypto"
-	"crypto/rand"
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"errors"
+	"io"
 
+	"github.com/emmansun/gmsm/internal/randutil"
 	"github.com/emmansun/gmsm/pkcs"
 	"github.com/emmansun/gmsm/sm2"
 	"github.com/emmansun/gmsm/smx509"
@@ -114,7 +115,7 @@ func decryptSED(p7 *PKCS7, sed *signedEnvelopedData, recipient *recipientInfo, p
 	switch pkey := pkey.(type) {
 	case crypto.Decrypter:
 		// Generic case to handle anything that provides the crypto.Decrypter interface.
-		contentKey, err := pkey.Decrypt(rand.Reader, recipient.EncryptedKey, nil)
+		contentKey, err := pkey.Decrypt(randutil.Reader(), recipient.EncryptedKey, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -138,7 +139,7 @@ func NewSignedAndEnvelopedData(data []byte, cipher pkcs.Cipher) (*SignedAndEnvel
 
 	// Create key
 	key = make([]byte, cipher.KeySize())
-	_, err = rand.Read(key)
+	_, err = io.ReadFull(randutil.Reader(), key)
 	if err != nil {
 		return nil, err
 	}
@@ -229,7 +230,7 @@ func (saed *SignedAndEnvelopedData) AddSignerChain(ee *smx509.Certificate, pkey
 		h.Write(saed.data)
 		tobeSigned = h.Sum(nil)
 	}
-	signature, err := key.Sign(rand.Reader, tobeSigned, signOpt)
+	signature, err := key.Sign(randutil.Reader(), tobeSigned, signOpt)
 	if err != nil {
 		return err
 	}
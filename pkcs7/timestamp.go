@@ -0,0 +1,295 @@
+package pkcs7
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/emmansun/gmsm/ber"
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/smx509"
+)
+
+var (
+	// OIDTSTInfo is the content type of a RFC 3161 time-stamp token.
+	OIDTSTInfo = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	// OIDAttributeTimeStampToken is the unsigned attribute which carries the
+	// time-stamp token of a signature, RFC 3161 appendix A.
+	OIDAttributeTimeStampToken = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 14}
+)
+
+// MessageImprint is the hash of the time-stamped data.
+type MessageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+// Accuracy is the accuracy of the GenTime of a TSTInfo.
+type Accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+// TSTInfo is the signed content of a RFC 3161 time-stamp token.
+type TSTInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint MessageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time        `asn1:"generalized"`
+	Accuracy       Accuracy         `asn1:"optional"`
+	Ordering       bool             `asn1:"optional,default:false"`
+	Nonce          *big.Int         `asn1:"optional"`
+	TSA            asn1.RawValue    `asn1:"optional,explicit,tag:0"`
+	Extensions     []pkix.Extension `asn1:"optional,tag:1"`
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint MessageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional,default:false"`
+	Extensions     []pkix.Extension      `asn1:"optional,tag:0"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional,utf8"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+// TimestampAuthority obtains RFC 3161 time-stamp tokens.
+type TimestampAuthority interface {
+	// Timestamp returns the DER encoded time-stamp token of digest, which is
+	// computed with the hash algorithm hashAlg.
+	Timestamp(hashAlg asn1.ObjectIdentifier, digest []byte) ([]byte, error)
+}
+
+// HTTPTimestampAuthority requests time-stamp tokens from a RFC 3161 server
+// over HTTP.
+type HTTPTimestampAuthority struct {
+	URL string
+	// Client is used to send the requests, http.DefaultClient if nil.
+	Client *http.Client
+	// Policy is the requested TSA policy, optional.
+	Policy asn1.ObjectIdentifier
+}
+
+const maxTimestampResponseSize = 1 << 20
+
+// Timestamp implements TimestampAuthority. The request asks for the TSA
+// certificate and carries a random nonce, the response is checked to match
+// the request.
+func (tsa *HTTPTimestampAuthority) Timestamp(hashAlg asn1.ObjectIdentifier, digest []byte) ([]byte, error) {
+	nonce, err := rand.Int(randutil.Reader(), new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+	req, err := asn1.Marshal(timeStampReq{
+		Version:        1,
+		MessageImprint: MessageImprint{HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: hashAlg}, HashedMessage: digest},
+		ReqPolicy:      tsa.Policy,
+		Nonce:          nonce,
+		CertReq:        true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	client := tsa.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Post(tsa.URL, "application/timestamp-query", bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("pkcs7: time-stamp authority returned HTTP status %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTimestampResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	var tsResp timeStampResp
+	if rest, err := ber.Unmarshal(body, &tsResp); err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, errors.New("pkcs7: trailing data after time-stamp response")
+	}
+	// granted (0) or grantedWithMods (1)
+	if tsResp.Status.Status > 1 {
+		return nil, fmt.Errorf("pkcs7: time-stamp request rejected with status %d %q", tsResp.Status.Status, tsResp.Status.StatusString)
+	}
+	token := tsResp.TimeStampToken.FullBytes
+	_, info, err := ParseTimestampToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return nil, errors.New("pkcs7: time-stamp token nonce mismatch")
+	}
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(hashAlg) ||
+		!bytes.Equal(info.MessageImprint.HashedMessage, digest) {
+		return nil, errors.New("pkcs7: time-stamp token message imprint mismatch")
+	}
+	return token, nil
+}
+
+// ParseTimestampToken parses a DER encoded time-stamp token and returns the
+// token and its TSTInfo. It does not verify the token.
+func ParseTimestampToken(token []byte) (*PKCS7, *TSTInfo, error) {
+	p7, err := Parse(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	sd, ok := p7.raw.(signedData)
+	if !ok || !sd.ContentInfo.ContentType.Equal(OIDTSTInfo) {
+		return nil, nil, errors.New("pkcs7: time-stamp token content is not TSTInfo")
+	}
+	info := new(TSTInfo)
+	if rest, err := asn1.Unmarshal(p7.Content, info); err != nil {
+		return nil, nil, err
+	} else if len(rest) > 0 {
+		return nil, nil, errors.New("pkcs7: trailing data after TSTInfo")
+	}
+	return p7, info, nil
+}
+
+// Timestamp obtains from tsa a time-stamp token of the signature value of
+// every signer and adds it as the signatureTimeStampToken unsigned attribute.
+// The message imprint is computed with the digest algorithm of the signer.
+//
+// This should be called after adding the signers and before Finish. If an
+// error is returned, no signer is modified.
+func (sd *SignedData) Timestamp(tsa TimestampAuthority) error {
+	tokens := make([][]byte, len(sd.sd.SignerInfos))
+	for i, signer := range sd.sd.SignerInfos {
+		hashOid := signer.DigestAlgorithm.Algorithm
+		hasher, err := getHashForOID(hashOid)
+		if err != nil {
+			return err
+		}
+		h := newHash(hasher, hashOid)
+		h.Write(signer.EncryptedDigest)
+		if tokens[i], err = tsa.Timestamp(hashOid, h.Sum(nil)); err != nil {
+			return err
+		}
+	}
+	for i := range sd.sd.SignerInfos {
+		si := &sd.sd.SignerInfos[i]
+		si.UnauthenticatedAttributes = append(si.UnauthenticatedAttributes, attribute{
+			Type:  OIDAttributeTimeStampToken,
+			Value: asn1.RawValue{Tag: 17, IsCompound: true, Bytes: tokens[i]}, // 17 == SET tag
+		})
+	}
+	return nil
+}
+
+// SignWithTimestamp signs data with pkey, typically a SM2 private key, and
+// embeds the time-stamp token of the signature obtained from tsa, producing a
+// single structure for long-term archival. The digest algorithm is SM3 and
+// the content types are the SM2 ones. It returns the DER encoded SignedData
+// only if both the signing and the time-stamping succeed.
+func SignWithTimestamp(data []byte, ee *smx509.Certificate, pkey crypto.PrivateKey, parents []*smx509.Certificate, tsa TimestampAuthority, detached bool) ([]byte, error) {
+	sd, err := NewSMSignedData(data)
+	if err != nil {
+		return nil, err
+	}
+	if err = sd.AddSignerChain(ee, pkey, parents, SignerInfoConfig{}); err != nil {
+		return nil, err
+	}
+	if err = sd.Timestamp(tsa); err != nil {
+		return nil, err
+	}
+	if detached {
+		sd.Detach()
+	}
+	return sd.Finish()
+}
+
+// VerifyWithTimestamp checks the signatures of a PKCS7 object with their
+// time-stamp tokens. Every signer must carry a time-stamp token of its
+// signature value, issued by a time-stamp authority which chains to tsaStore,
+// and the signer certificate chain is verified against truststore at the
+// time-stamped time instead of now.
+//
+// tsaStore must not be nil, a time-stamp token that isn't anchored to a
+// trusted time-stamp authority could be minted by anyone to move the
+// verification time back. A nil truststore disables the signer chain
+// verification, like Verify.
+func (p7 *PKCS7) VerifyWithTimestamp(truststore, tsaStore *smx509.CertPool) error {
+	if len(p7.Signers) == 0 {
+		return errors.New("pkcs7: Message has no signers")
+	}
+	if tsaStore == nil {
+		return errors.New("pkcs7: no trusted time-stamp authorities")
+	}
+	for _, signer := range p7.Signers {
+		genTime, err := verifySignatureTimestamp(signer, tsaStore)
+		if err != nil {
+			return err
+		}
+		if err := verifySignature(p7, signer, truststore, &genTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifySignatureTimestamp verifies the time-stamp token of the signer and
+// returns its time.
+func verifySignatureTimestamp(signer signerInfo, tsaStore *smx509.CertPool) (time.Time, error) {
+	var token asn1.RawValue
+	if err := unmarshalAttribute(signer.UnauthenticatedAttributes, OIDAttributeTimeStampToken, &token); err != nil {
+		return time.Time{}, err
+	}
+	tsToken, info, err := ParseTimestampToken(token.FullBytes)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err = tsToken.VerifyWithChainAtTime(tsaStore, &info.GenTime); err != nil {
+		return time.Time{}, err
+	}
+	tsaCert := tsToken.GetOnlySigner()
+	if tsaCert == nil || !hasTimeStampingUsage(tsaCert) {
+		return time.Time{}, errors.New("pkcs7: time-stamp token is not signed by a time-stamping certificate")
+	}
+	hashOid := info.MessageImprint.HashAlgorithm.Algorithm
+	hasher, err := getHashForOID(hashOid)
+	if err != nil {
+		return time.Time{}, err
+	}
+	h := newHash(hasher, hashOid)
+	h.Write(signer.EncryptedDigest)
+	if subtle.ConstantTimeCompare(h.Sum(nil), info.MessageImprint.HashedMessage) != 1 {
+		return time.Time{}, errors.New("pkcs7: time-stamp token does not match the signature")
+	}
+	return info.GenTime, nil
+}
+
+func hasTimeStampingUsage(cert *smx509.Certificate) bool {
+	for _, usage := range cert.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageTimeStamping {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package pkcs7
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/smx509"
+)
+
+// testTSA is a minimal RFC 3161 time-stamp authority.
+type testTSA struct {
+	cert   *smx509.Certificate
+	key    crypto.PrivateKey
+	now    time.Time
+	serial int64
+}
+
+func newTestTSA(t *testing.T, root *certKeyPair) *testTSA {
+	t.Helper()
+	key, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1000),
+		Subject:      pkix.Name{CommonName: "PKCS7 Test TSA", Organization: []string{"Acme Co"}},
+		NotBefore:    time.Now().Add(-1 * time.Second),
+		NotAfter:     time.Now().AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+	der, err := smx509.CreateCertificate(rand.Reader, &template, (*x509.Certificate)(root.Certificate), key.Public(), *root.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := smx509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testTSA{cert: cert, key: key, now: time.Now().UTC().Truncate(time.Second)}
+}
+
+func (tsa *testTSA) token(imprint MessageImprint, nonce *big.Int) ([]byte, error) {
+	tsa.serial++
+	info, err := asn1.Marshal(TSTInfo{
+		Version:        1,
+		Policy:         asn1.ObjectIdentifier{1, 2, 3, 4},
+		MessageImprint: imprint,
+		SerialNumber:   big.NewInt(tsa.serial),
+		GenTime:        tsa.now,
+		Accuracy:       Accuracy{Seconds: 1},
+		Nonce:          nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sd, err := NewSMSignedData(info)
+	if err != nil {
+		return nil, err
+	}
+	sd.sd.ContentInfo.ContentType = OIDTSTInfo
+	if err = sd.AddSigner(tsa.cert, tsa.key, SignerInfoConfig{}); err != nil {
+		return nil, err
+	}
+	return sd.Finish()
+}
+
+func (tsa *testTSA) Timestamp(hashAlg asn1.ObjectIdentifier, digest []byte) ([]byte, error) {
+	return tsa.token(MessageImprint{pkix.AlgorithmIdentifier{Algorithm: hashAlg}, digest}, nil)
+}
+
+func (tsa *testTSA) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var req timeStampReq
+	if _, err := asn1.Unmarshal(body, &req); err != nil || !req.CertReq {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	token, err := tsa.token(req.MessageImprint, req.Nonce)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp, _ := asn1.Marshal(timeStampResp{TimeStampToken: asn1.RawValue{FullBytes: token}})
+	w.Header().Set("Content-Type", "application/timestamp-reply")
+	w.Write(resp)
+}
+
+type failingTSA struct{}
+
+func (failingTSA) Timestamp(asn1.ObjectIdentifier, []byte) ([]byte, error) {
+	return nil, errors.New("tsa unavailable")
+}
+
+func TestSignWithTimestamp(t *testing.T) {
+	root, err := createTestCertificateByIssuer("PKCS7 Test Root CA", nil, smx509.SM2WithSM3, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := createTestCertificateByIssuer("PKCS7 Test Signer Cert", root, smx509.SM2WithSM3, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	truststore := smx509.NewCertPool()
+	truststore.AddCert(root.Certificate)
+	tsa := newTestTSA(t, root)
+	server := httptest.NewServer(tsa)
+	defer server.Close()
+
+	content := []byte("archived contract")
+	for _, detached := range []bool{false, true} {
+		signed, err := SignWithTimestamp(content, signer.Certificate, *signer.PrivateKey, nil, &HTTPTimestampAuthority{URL: server.URL}, detached)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p7, err := Parse(signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if detached {
+			p7.Content = content
+		}
+		if err = p7.VerifyWithTimestamp(truststore, truststore); err != nil {
+			t.Fatalf("detached %t: %v", detached, err)
+		}
+
+		// the token is bound to the signature value
+		p7.Signers[0].EncryptedDigest[len(p7.Signers[0].EncryptedDigest)-1] ^= 1
+		if err = p7.VerifyWithTimestamp(truststore, truststore); err == nil {
+			t.Errorf("detached %t: verification of modified signature succeeded", detached)
+		}
+	}
+
+	// the time-stamp authority must be trusted
+	signed, err := SignWithTimestamp(content, signer.Certificate, *signer.PrivateKey, nil, tsa, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p7, err := Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p7.VerifyWithTimestamp(truststore, smx509.NewCertPool()); err == nil {
+		t.Errorf("verification with untrusted time-stamp authority succeeded")
+	}
+	if err = p7.VerifyWithTimestamp(truststore, nil); err == nil {
+		t.Errorf("verification without time-stamp authorities succeeded")
+	}
+
+	// a signature without time-stamp token
+	sd, err := NewSMSignedData(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sd.AddSigner(signer.Certificate, *signer.PrivateKey, SignerInfoConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	signed, err = sd.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p7, err = Parse(signed); err != nil {
+		t.Fatal(err)
+	}
+	if err = p7.VerifyWithTimestamp(truststore, truststore); err == nil {
+		t.Errorf("verification without time-stamp token succeeded")
+	}
+
+	if _, err = SignWithTimestamp(content, signer.Certificate, *signer.PrivateKey, nil, failingTSA{}, false); err == nil {
+		t.Errorf("expected error from failing time-stamp authority")
+	}
+}
+
+func TestParseTimestampToken(t *testing.T) {
+	root, err := createTestCertificateByIssuer("PKCS7 Test Root CA", nil, smx509.SM2WithSM3, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tsa := newTestTSA(t, root)
+	digest := bytes.Repeat([]byte{0xab}, 32)
+	token, err := tsa.Timestamp(OIDDigestAlgorithmSM3, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, info, err := ParseTimestampToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.GenTime.Equal(tsa.now) || !bytes.Equal(info.MessageImprint.HashedMessage, digest) ||
+		info.Accuracy.Seconds != 1 || info.SerialNumber.Int64() != 1 {
+		t.Errorf("unexpected TSTInfo %+v", info)
+	}
+
+	// a signed data which is not a time-stamp token
+	sd, _ := NewSMSignedData([]byte("not a token"))
+	if err = sd.AddSigner(tsa.cert, tsa.key, SignerInfoConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	signed, _ := sd.Finish()
+	if _, _, err = ParseTimestampToken(signed); err == nil {
+		t.Errorf("expected error for non TSTInfo content")
+	}
+}
@@ -3,7 +3,6 @@ package pkcs8
 
 import (
 	"crypto/ecdsa"
-	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha1"
 	"crypto/sha256"
@@ -14,8 +13,10 @@ import (
 	"errors"
 	"fmt"
 	"hash"
+	"io"
 	"strconv"
 
+	"github.com/emmansun/gmsm/internal/randutil"
 	"github.com/emmansun/gmsm/pkcs"
 	"github.com/emmansun/gmsm/sm2"
 	"github.com/emmansun/gmsm/sm3"
@@ -215,7 +216,7 @@ func MarshalPrivateKey(priv any, password []byte, opts *Opts) ([]byte, error) {
 
 	encAlg := opts.Cipher
 	salt := make([]byte, opts.KDFOpts.GetSaltSize())
-	_, err = rand.Read(salt)
+	_, err = io.ReadFull(randutil.Reader(), salt)
 	if err != nil {
 		return nil, err
 	}
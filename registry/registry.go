@@ -0,0 +1,166 @@
+// Package registry maps the identifiers of the ShangMi algorithms, i.e. the GM
+// OIDs (GM/T 0006), JOSE and COSE identifiers, to the constructors of this module,
+// so that generic code which dispatches on algorithm identifiers can discover the
+// SM algorithms without special cases.
+//
+// Note that crypto.RegisterHash only accepts the crypto.Hash values predefined by
+// the standard library, SM3 can't be registered there; use Lookup instead.
+//
+// There are no IANA registered JOSE/COSE identifiers for the SM algorithms yet, the
+// built-in JOSE names follow the style of RFC 7518, and the built-in COSE values are
+// taken from the private use range (less than -65536). Applications can register
+// other identifiers with Register.
+package registry
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"encoding/asn1"
+	"errors"
+	"hash"
+	"sync"
+
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm4"
+)
+
+// Kind is the kind of an algorithm.
+type Kind int
+
+const (
+	Hash Kind = iota + 1
+	BlockCipher
+	Signature
+	PublicKeyEncryption
+	KeyAgreement
+	PublicKey
+	KeyWrap
+	MAC
+)
+
+// Algorithm describes one algorithm and its identifiers.
+type Algorithm struct {
+	Name string
+	Kind Kind
+	OID  asn1.ObjectIdentifier // nil if the algorithm has no OID
+	JOSE string                // empty if the algorithm has no JOSE identifier
+	COSE int                   // 0 if the algorithm has no COSE identifier
+
+	// NewHash returns a new hash.Hash for the Hash kind.
+	NewHash func() hash.Hash
+	// NewMAC returns a new keyed hash.Hash for the MAC kind.
+	NewMAC func(key []byte) hash.Hash
+	// NewCipher returns a new cipher.Block for the BlockCipher and KeyWrap kinds.
+	NewCipher func(key []byte) (cipher.Block, error)
+}
+
+var (
+	errDuplicate          = errors.New("registry: duplicate algorithm identifier")
+	errMissingConstructor = errors.New("registry: missing constructor for the algorithm kind")
+)
+
+var (
+	mu         sync.RWMutex
+	algorithms []*Algorithm
+)
+
+var builtin = []Algorithm{
+	{Name: "SM3", Kind: Hash, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401}, COSE: -65537, NewHash: sm3.New},
+	{Name: "HMAC-SM3", Kind: MAC, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401, 2}, JOSE: "HS-SM3", NewMAC: newHMACSM3},
+	{Name: "SM4", Kind: BlockCipher, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104}, NewCipher: sm4.NewCipher},
+	{Name: "SM4-ECB", Kind: BlockCipher, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 1}, NewCipher: sm4.NewCipher},
+	{Name: "SM4-CBC", Kind: BlockCipher, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 2}, NewCipher: sm4.NewCipher},
+	{Name: "SM4-OFB", Kind: BlockCipher, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 3}, NewCipher: sm4.NewCipher},
+	{Name: "SM4-CFB", Kind: BlockCipher, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 4}, NewCipher: sm4.NewCipher},
+	{Name: "SM4-CTR", Kind: BlockCipher, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 7}, NewCipher: sm4.NewCipher},
+	{Name: "SM4-GCM", Kind: BlockCipher, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 8}, JOSE: "SM4GCM", COSE: -65538, NewCipher: sm4.NewCipher},
+	{Name: "SM4-CCM", Kind: BlockCipher, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 9}, NewCipher: sm4.NewCipher},
+	{Name: "SM4-KW", Kind: KeyWrap, JOSE: "SM4KW", NewCipher: sm4.NewCipher},
+	{Name: "SM2", Kind: PublicKey, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301}},
+	{Name: "SM2-Sign", Kind: Signature, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301, 1}},
+	{Name: "SM2-KeyExchange", Kind: KeyAgreement, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301, 2}},
+	{Name: "ECDH-SM2", Kind: KeyAgreement, JOSE: "ECDH-SM2"},
+	{Name: "ECDH-SM2+SM4-KW", Kind: KeyAgreement, JOSE: "ECDH-SM2+SM4KW"},
+	{Name: "SM2-Encrypt", Kind: PublicKeyEncryption, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301, 3}},
+	{Name: "SM2-SM3", Kind: Signature, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 501}, JOSE: "SM2SM3", COSE: -65539},
+	{Name: "SM9", Kind: PublicKey, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 302}},
+	{Name: "SM9-Sign", Kind: Signature, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 302, 1}},
+	{Name: "SM9-KeyExchange", Kind: KeyAgreement, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 302, 2}},
+	{Name: "SM9-Encrypt", Kind: PublicKeyEncryption, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 302, 3}},
+	{Name: "SM9-SM3", Kind: Signature, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 502}},
+}
+
+func init() {
+	for i := range builtin {
+		if err := Register(builtin[i]); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func newHMACSM3(key []byte) hash.Hash {
+	return hmac.New(sm3.New, key)
+}
+
+// Register registers the algorithm a, the name and the non-empty identifiers
+// must be unique. An algorithm of the Hash or MAC kind must have the
+// corresponding constructor.
+func Register(a Algorithm) error {
+	if (a.Kind == Hash && a.NewHash == nil) || (a.Kind == MAC && a.NewMAC == nil) {
+		return errMissingConstructor
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, b := range algorithms {
+		if b.Name == a.Name ||
+			(a.OID != nil && a.OID.Equal(b.OID)) ||
+			(a.JOSE != "" && a.JOSE == b.JOSE) ||
+			(a.COSE != 0 && a.COSE == b.COSE) {
+			return errDuplicate
+		}
+	}
+	algorithms = append(algorithms, &a)
+	return nil
+}
+
+func lookup(match func(*Algorithm) bool) (Algorithm, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, a := range algorithms {
+		if match(a) {
+			return *a, true
+		}
+	}
+	return Algorithm{}, false
+}
+
+// Lookup returns the algorithm with the given name.
+func Lookup(name string) (Algorithm, bool) {
+	return lookup(func(a *Algorithm) bool { return a.Name == name })
+}
+
+// LookupOID returns the algorithm with the given object identifier.
+func LookupOID(oid asn1.ObjectIdentifier) (Algorithm, bool) {
+	return lookup(func(a *Algorithm) bool { return a.OID != nil && a.OID.Equal(oid) })
+}
+
+// LookupJOSE returns the algorithm with the given JOSE "alg" or "enc" identifier.
+func LookupJOSE(id string) (Algorithm, bool) {
+	return lookup(func(a *Algorithm) bool { return a.JOSE != "" && a.JOSE == id })
+}
+
+// LookupCOSE returns the algorithm with the given COSE algorithm identifier.
+func LookupCOSE(id int) (Algorithm, bool) {
+	return lookup(func(a *Algorithm) bool { return a.COSE != 0 && a.COSE == id })
+}
+
+// Algorithms returns all registered algorithms in registration order.
+func Algorithms() []Algorithm {
+	mu.RLock()
+	defer mu.RUnlock()
+	ret := make([]Algorithm, 0, len(algorithms))
+	for _, a := range algorithms {
+		ret = append(ret, *a)
+	}
+	return ret
+}
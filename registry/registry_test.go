@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"encoding/asn1"
+	"encoding/hex"
+	"testing"
+)
+
+func TestLookupOID(t *testing.T) {
+	a, ok := LookupOID(asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401})
+	if !ok || a.Name != "SM3" || a.Kind != Hash {
+		t.Fatalf("unexpected algorithm %v", a)
+	}
+	h := a.NewHash()
+	h.Write([]byte("abc"))
+	if hex.EncodeToString(h.Sum(nil)) != "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0" {
+		t.Errorf("unexpected digest")
+	}
+
+	a, ok = LookupOID(asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 2})
+	if !ok || a.Name != "SM4-CBC" {
+		t.Fatalf("unexpected algorithm %v", a)
+	}
+	if _, err := a.NewCipher(make([]byte, 16)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := LookupOID(asn1.ObjectIdentifier{1, 2, 3}); ok {
+		t.Errorf("unknown OID should not be found")
+	}
+}
+
+func TestLookupMAC(t *testing.T) {
+	a, ok := LookupJOSE("HS-SM3")
+	if !ok || a.Name != "HMAC-SM3" || a.Kind != MAC || a.NewHash != nil {
+		t.Fatalf("unexpected algorithm %v", a)
+	}
+	h := a.NewMAC([]byte("key"))
+	h.Write([]byte("abc"))
+	if h.Size() != 32 || len(h.Sum(nil)) != 32 {
+		t.Errorf("unexpected MAC size")
+	}
+	for _, a := range Algorithms() {
+		if a.Kind == Hash && a.NewHash == nil {
+			t.Errorf("%s: Hash kind without NewHash", a.Name)
+		}
+	}
+}
+
+func TestLookupJOSEAndCOSE(t *testing.T) {
+	a, ok := LookupJOSE("SM2SM3")
+	if !ok || a.Name != "SM2-SM3" || a.Kind != Signature {
+		t.Fatalf("unexpected algorithm %v", a)
+	}
+	b, ok := LookupCOSE(a.COSE)
+	if !ok || b.Name != a.Name {
+		t.Fatalf("unexpected algorithm %v", b)
+	}
+	for id, kind := range map[string]Kind{"SM4GCM": BlockCipher, "SM4KW": KeyWrap, "ECDH-SM2": KeyAgreement, "ECDH-SM2+SM4KW": KeyAgreement} {
+		if a, ok := LookupJOSE(id); !ok || a.Kind != kind {
+			t.Errorf("%s: unexpected algorithm %v", id, a)
+		}
+	}
+	if _, ok := LookupJOSE(""); ok {
+		t.Errorf("empty JOSE identifier should not be found")
+	}
+	if _, ok := LookupCOSE(0); ok {
+		t.Errorf("zero COSE identifier should not be found")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	if err := Register(Algorithm{Name: "SM3"}); err == nil {
+		t.Errorf("expected duplicate name error")
+	}
+	if err := Register(Algorithm{Name: "SM3-Alias", OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401}}); err == nil {
+		t.Errorf("expected duplicate OID error")
+	}
+	if err := Register(Algorithm{Name: "SM3-NoHash", Kind: Hash}); err == nil {
+		t.Errorf("expected missing constructor error")
+	}
+	if err := Register(Algorithm{Name: "ZUC-128", Kind: BlockCipher, OID: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 800}}); err != nil {
+		t.Fatal(err)
+	}
+	if a, ok := Lookup("ZUC-128"); !ok || a.Kind != BlockCipher {
+		t.Errorf("registered algorithm not found")
+	}
+	if len(Algorithms()) != len(builtin)+1 {
+		t.Errorf("unexpected number of algorithms")
+	}
+}
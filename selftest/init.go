@@ -0,0 +1,9 @@
+//go:build gmsm_selftest
+
+package selftest
+
+func init() {
+	if err := Run(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,125 @@
+package selftest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm4"
+	"github.com/emmansun/gmsm/sm9"
+	"github.com/emmansun/gmsm/sm9/bn256"
+	"github.com/emmansun/gmsm/zuc"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+func decodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// testSM3 uses the example 1 of GB/T 32905-2016.
+func testSM3() error {
+	expected := decodeHex("66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0")
+	sum := sm3.Sum([]byte("abc"))
+	if !bytes.Equal(sum[:], expected) {
+		return errors.New("selftest: SM3 digest mismatch")
+	}
+	return nil
+}
+
+// testSM4 uses the example 1 of GB/T 32907-2016.
+func testSM4() error {
+	key := decodeHex("0123456789abcdeffedcba9876543210")
+	expected := decodeHex("681edf34d206965e86b3e94f536e4246")
+	c, err := sm4.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	out := make([]byte, sm4.BlockSize)
+	c.Encrypt(out, key)
+	if !bytes.Equal(out, expected) {
+		return errors.New("selftest: SM4 encryption mismatch")
+	}
+	c.Decrypt(out, out)
+	if !bytes.Equal(out, key) {
+		return errors.New("selftest: SM4 decryption mismatch")
+	}
+	return nil
+}
+
+// testZUC uses the test set 1 of 128-EEA3.
+func testZUC() error {
+	key := decodeHex("173d14ba5003731d7a60049470f00a29")
+	in := decodeHex("6cf65340735552ab0c9752fa6f9025fe0bd675d9005875b2")
+	expected := decodeHex("a6c85fc66afb8533aafc2518dfe784940ee1e4b030238cc8")
+	c, err := zuc.NewEEACipher(key, 0x66035492, 0xf, 0)
+	if err != nil {
+		return err
+	}
+	out := make([]byte, len(in))
+	c.XORKeyStream(out, in)
+	if !bytes.Equal(out, expected) {
+		return errors.New("selftest: ZUC keystream mismatch")
+	}
+	return nil
+}
+
+// testSM2 verifies a known signature with the default uid, then runs a pairwise
+// consistency test of signature generation and verification.
+func testSM2() error {
+	priv, err := sm2.NewPrivateKey(decodeHex("5d352db21d7f3531258292d48041869bccde4703d691098bc4fa1cb25b1cac8a"))
+	if err != nil {
+		return err
+	}
+	pub, err := sm2.NewPublicKey(decodeHex("042230e34bfd38e348be4e517a4641c915b029ef807deddd8dc7dd1d3c0f24a9233458620709330e0e2c426223362c234303caddf32a3bcf3a92c26ca858e1b592"))
+	if err != nil {
+		return err
+	}
+	if !priv.PublicKey.Equal(pub) {
+		return errors.New("selftest: SM2 public key mismatch")
+	}
+	msg := []byte("GM/T 0003 known answer test")
+	var b cryptobyte.Builder
+	b.AddASN1(0x30, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(new(big.Int).SetBytes(decodeHex("20d655134feeb205f363b011d77d919c699bff78bb087168ccf23948f26acc60")))
+		b.AddASN1BigInt(new(big.Int).SetBytes(decodeHex("9499298d3cf75887dc9106786002ad89ce5296c16d540e2894d04761d2101d96")))
+	})
+	sig := b.BytesOrPanic()
+	if !sm2.VerifyASN1WithSM2(pub, nil, msg, sig) {
+		return errors.New("selftest: SM2 known signature verification failed")
+	}
+	sig, err = priv.SignWithSM2(randutil.Reader(), nil, msg)
+	if err != nil {
+		return err
+	}
+	if !sm2.VerifyASN1WithSM2(pub, nil, msg, sig) {
+		return errors.New("selftest: SM2 pairwise consistency test failed")
+	}
+	return nil
+}
+
+// testSM9 verifies the signature example of GB/T 38635.2-2020.
+func testSM9() error {
+	var b cryptobyte.Builder
+	b.AddASN1BigInt(new(big.Int).SetBytes(decodeHex("0130E78459D78545CB54C587E02CF480CE0B66340F319F348A1D5B1F2DC5F4")))
+	master := new(sm9.SignMasterPrivateKey)
+	if err := master.UnmarshalASN1(b.BytesOrPanic()); err != nil {
+		return err
+	}
+	h := new(big.Int).SetBytes(decodeHex("823c4b21e4bd2dfe1ed92c606653e996668563152fc33f55d7bfbb9bd9705adb"))
+	s := new(bn256.G1)
+	if _, err := s.Unmarshal(decodeHex("73bf96923ce58b6ad0e13e9643a406d8eb98417c50ef1b29cef9adb48b6d598c856712f1c2e0968ab7769f42a99586aed139d5b8b3e15891827cc2aced9baa05")); err != nil {
+		return err
+	}
+	if !sm9.Verify(master.Public(), []byte("Alice"), 0x01, []byte("Chinese IBS standard"), h, s) {
+		return errors.New("selftest: SM9 known signature verification failed")
+	}
+	return nil
+}
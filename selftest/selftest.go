@@ -0,0 +1,78 @@
+// Package selftest implements the power-on self-tests of the commercial cryptographic
+// algorithms SM2/SM3/SM4/SM9/ZUC, which are required by GM/T 0008 and GM/T 0028 for
+// cryptographic module certification.
+//
+// The known answer tests are run on demand with Run, or at package initialization
+// when the program is built with the gmsm_selftest build tag. Once a test fails,
+// the failure state is latched and reported by Err until the process exits.
+package selftest
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrSelfTestFailed is returned when one of the known answer tests failed.
+var ErrSelfTestFailed = errors.New("selftest: cryptographic self-test failed")
+
+// Result records the outcome of the known answer test of one algorithm.
+type Result struct {
+	Algorithm string
+	Err       error // nil if the test passed
+}
+
+type kat struct {
+	algorithm string
+	run       func() error
+}
+
+var tests = []kat{
+	{"SM3", testSM3},
+	{"SM4", testSM4},
+	{"ZUC", testZUC},
+	{"SM2", testSM2},
+	{"SM9", testSM9},
+}
+
+var (
+	mu      sync.Mutex
+	results []Result
+	failed  error
+)
+
+// Run runs all the known answer tests and returns ErrSelfTestFailed if any of them
+// failed, or if a previous run failed.
+func Run() error {
+	rs := make([]Result, 0, len(tests))
+	var err error
+	for _, t := range tests {
+		r := Result{Algorithm: t.algorithm, Err: t.run()}
+		if r.Err != nil {
+			err = ErrSelfTestFailed
+		}
+		rs = append(rs, r)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	results = rs
+	if err != nil && failed == nil {
+		failed = err
+	}
+	return failed
+}
+
+// Results returns the results of the last run, it's nil if the self-tests
+// were never run.
+func Results() []Result {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]Result(nil), results...)
+}
+
+// Err returns the latched failure state, it's nil if no self-test has failed.
+func Err() error {
+	mu.Lock()
+	defer mu.Unlock()
+	return failed
+}
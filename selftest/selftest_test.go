@@ -0,0 +1,45 @@
+package selftest
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	if err := Run(); err != nil {
+		for _, r := range Results() {
+			if r.Err != nil {
+				t.Errorf("%s: %v", r.Algorithm, r.Err)
+			}
+		}
+		t.Fatal(err)
+	}
+	rs := Results()
+	if len(rs) != len(tests) {
+		t.Fatalf("got %d results, want %d", len(rs), len(tests))
+	}
+	if Err() != nil {
+		t.Fatal("unexpected failure state")
+	}
+}
+
+func TestFailureLatched(t *testing.T) {
+	saved := tests
+	savedFailed := failed
+	defer func() {
+		tests = saved
+		failed = savedFailed
+	}()
+
+	tests = append([]kat{{"broken", func() error { return errors.New("broken") }}}, saved...)
+	if err := Run(); err != ErrSelfTestFailed {
+		t.Fatalf("got %v, want ErrSelfTestFailed", err)
+	}
+	tests = saved
+	if err := Run(); err != ErrSelfTestFailed {
+		t.Fatalf("failure state is not latched, got %v", err)
+	}
+	if Err() != ErrSelfTestFailed {
+		t.Fatalf("Err() = %v", Err())
+	}
+}
@@ -0,0 +1,55 @@
+package sm2
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The failure modes of this package. Errors returned by this package either
+// are one of them or wrap one of them, test them with [errors.Is] instead of
+// matching the error strings.
+var (
+	// ErrInvalidPrivateKey represents an invalid or unsupported private key.
+	ErrInvalidPrivateKey = errors.New("sm2: invalid private key")
+	// ErrInvalidPublicKey represents an invalid or unsupported public key.
+	ErrInvalidPublicKey = errors.New("sm2: invalid public key")
+	// ErrPointNotOnCurve represents a point which is malformed or not on the sm2 curve.
+	ErrPointNotOnCurve = errors.New("sm2: point is not on curve")
+	// ErrInvalidSignature represents a malformed signature.
+	ErrInvalidSignature = errors.New("sm2: invalid signature")
+	// ErrInvalidCiphertext represents a malformed ciphertext.
+	ErrInvalidCiphertext = errors.New("sm2: invalid ciphertext")
+	// ErrInvalidArgument represents an invalid or unsupported argument.
+	ErrInvalidArgument = errors.New("sm2: invalid argument")
+	// ErrKeyExchange represents a failure of the key exchange.
+	ErrKeyExchange = errors.New("sm2: key exchange failed")
+	// ErrInvalidWarrant represents a malformed, expired or forged proxy
+	// signature warrant.
+	ErrInvalidWarrant = errors.New("sm2: invalid proxy warrant")
+	// ErrRetryLimit represents an operation which can't find a valid
+	// random value within the retry limit.
+	ErrRetryLimit = errors.New("sm2: retry limit exceeded")
+)
+
+// Error is an error with context returned by this package, its Kind is one of
+// the failure modes above.
+type Error struct {
+	Kind error
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func newError(kind error, msg string) error {
+	return &Error{Kind: kind, Msg: msg}
+}
+
+func errorf(kind error, format string, args ...any) error {
+	return &Error{Kind: kind, Msg: fmt.Sprintf(format, args...)}
+}
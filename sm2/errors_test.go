@@ -0,0 +1,43 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{"private key size", func() error { _, err := NewPrivateKey([]byte{1}); return err }(), ErrInvalidPrivateKey},
+		{"public key", func() error { _, err := NewPublicKey([]byte{2}); return err }(), ErrInvalidPublicKey},
+		{"point not on curve", func() error {
+			key := priv.PublicKey.X.FillBytes(make([]byte, 65)) // 0x00 || x
+			key[0] = 4
+			_, err := NewPublicKey(key)
+			return err
+		}(), ErrPointNotOnCurve},
+		{"ciphertext", func() error { _, err := priv.Decrypt(nil, make([]byte, 65), nil); return err }(), ErrInvalidCiphertext},
+		{"uid", func() error { _, err := CalculateZA(&priv.PublicKey, make([]byte, 0x2000)); return err }(), ErrInvalidArgument},
+		{"signature", func() error { _, _, err := parseSignature([]byte{0x30}); return err }(), ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		if tt.err == nil {
+			t.Fatalf("%s: expected error", tt.name)
+		}
+		if !errors.Is(tt.err, tt.kind) {
+			t.Errorf("%s: %v is not %v", tt.name, tt.err, tt.kind)
+		}
+		var e *Error
+		if tt.err != tt.kind && !errors.As(tt.err, &e) {
+			t.Errorf("%s: %v is not an *Error", tt.name, tt.err)
+		}
+	}
+}
@@ -15,13 +15,13 @@ import (
 	"crypto/elliptic"
 	_subtle "crypto/subtle"
 	"errors"
-	"fmt"
 	"io"
 	"math/big"
 	"sync"
 
 	"github.com/emmansun/gmsm/ecdh"
 	"github.com/emmansun/gmsm/internal/bigmod"
+	"github.com/emmansun/gmsm/internal/instrument"
 	"github.com/emmansun/gmsm/internal/randutil"
 	_sm2ec "github.com/emmansun/gmsm/internal/sm2ec"
 	"github.com/emmansun/gmsm/internal/subtle"
@@ -60,43 +60,56 @@ const (
 	MarshalHybrid
 )
 
-type ciphertextSplicingOrder byte
+// CiphertextSplicingOrder is the order of C2 and C3 in a plain SM2 ciphertext.
+type CiphertextSplicingOrder byte
 
 const (
-	C1C3C2 ciphertextSplicingOrder = iota
+	C1C3C2 CiphertextSplicingOrder = iota
 	C1C2C3
 )
 
-type ciphertextEncoding byte
+// CiphertextEncoding is the encoding of a SM2 ciphertext, plain C1||C3||C2
+// (or C1||C2||C3) or ASN.1.
+type CiphertextEncoding byte
 
 const (
-	ENCODING_PLAIN ciphertextEncoding = iota
+	ENCODING_PLAIN CiphertextEncoding = iota
 	ENCODING_ASN1
 )
 
 // EncrypterOpts encryption options
 type EncrypterOpts struct {
-	ciphertextEncoding      ciphertextEncoding
+	ciphertextEncoding      CiphertextEncoding
 	pointMarshalMode        pointMarshalMode
-	ciphertextSplicingOrder ciphertextSplicingOrder
+	ciphertextSplicingOrder CiphertextSplicingOrder
 }
 
 // DecrypterOpts decryption options
 type DecrypterOpts struct {
-	ciphertextEncoding      ciphertextEncoding
-	cipherTextSplicingOrder ciphertextSplicingOrder
+	ciphertextEncoding      CiphertextEncoding
+	cipherTextSplicingOrder CiphertextSplicingOrder
 }
 
 // NewPlainEncrypterOpts creates a SM2 non-ASN1 encrypter options.
-func NewPlainEncrypterOpts(marhsalMode pointMarshalMode, splicingOrder ciphertextSplicingOrder) *EncrypterOpts {
+func NewPlainEncrypterOpts(marhsalMode pointMarshalMode, splicingOrder CiphertextSplicingOrder) *EncrypterOpts {
 	return &EncrypterOpts{ENCODING_PLAIN, marhsalMode, splicingOrder}
 }
 
 // NewPlainDecrypterOpts creates a SM2 non-ASN1 decrypter options.
-func NewPlainDecrypterOpts(splicingOrder ciphertextSplicingOrder) *DecrypterOpts {
+func NewPlainDecrypterOpts(splicingOrder CiphertextSplicingOrder) *DecrypterOpts {
 	return &DecrypterOpts{ENCODING_PLAIN, splicingOrder}
 }
 
+// Encoding returns the ciphertext encoding of the options.
+func (opts *DecrypterOpts) Encoding() CiphertextEncoding {
+	return opts.ciphertextEncoding
+}
+
+// SplicingOrder returns the splicing order of plain ciphertexts of the options.
+func (opts *DecrypterOpts) SplicingOrder() CiphertextSplicingOrder {
+	return opts.cipherTextSplicingOrder
+}
+
 func toBytes(curve elliptic.Curve, value *big.Int) []byte {
 	byteLen := (curve.Params().BitSize + 7) >> 3
 	result := make([]byte, byteLen)
@@ -147,10 +160,22 @@ func (*SM2SignerOption) HashFunc() crypto.Hash {
 	return directSigning
 }
 
+// ForceGMSign reports whether the data to sign is the raw message, which is
+// hashed together with ZA of the UID. Signers which delegate the signing, e.g.
+// to a remote KMS, use it to compute the digest.
+func (opt *SM2SignerOption) ForceGMSign() bool {
+	return opt.forceGMSign
+}
+
+// UID returns the user identity of ZA, it's only used if ForceGMSign is true.
+func (opt *SM2SignerOption) UID() []byte {
+	return opt.uid
+}
+
 // FromECPrivateKey convert an ecdsa private key to SM2 private key.
 func (priv *PrivateKey) FromECPrivateKey(key *ecdsa.PrivateKey) (*PrivateKey, error) {
 	if key.Curve != sm2ec.P256() {
-		return nil, errors.New("sm2: it's NOT a sm2 curve private key")
+		return nil, newError(ErrInvalidPrivateKey, "sm2: it's NOT a sm2 curve private key")
 	}
 	priv.PrivateKey = *key
 	return priv, nil
@@ -199,7 +224,7 @@ func (priv *PrivateKey) Decrypt(rand io.Reader, msg []byte, opts crypto.Decrypte
 const maxRetryLimit = 100
 
 var (
-	errCiphertextTooShort = errors.New("sm2: ciphertext too short")
+	errCiphertextTooShort = newError(ErrInvalidCiphertext, "sm2: ciphertext too short")
 )
 
 // EncryptASN1 sm2 encrypt and output ASN.1 result, compliance with GB/T 32918.4-2016.
@@ -217,25 +242,42 @@ func EncryptASN1(random io.Reader, pub *ecdsa.PublicKey, msg []byte) ([]byte, er
 // encrypting the same message twice doesn't result in the same ciphertext.
 // Most applications should use [crypto/rand.Reader] as random.
 func Encrypt(random io.Reader, pub *ecdsa.PublicKey, msg []byte, opts *EncrypterOpts) ([]byte, error) {
+	return AppendEncrypt(nil, random, pub, msg, opts)
+}
+
+// AppendEncrypt is like Encrypt but appends the ciphertext to dst and returns
+// the extended buffer, so that the caller can reuse the output buffer.
+func AppendEncrypt(dst []byte, random io.Reader, pub *ecdsa.PublicKey, msg []byte, opts *EncrypterOpts) ([]byte, error) {
+	done := instrument.Start("sm2", "encrypt")
+	ciphertext, err := encrypt(dst, random, pub, msg, opts)
+	done(err)
+	return ciphertext, err
+}
+
+func encrypt(dst []byte, random io.Reader, pub *ecdsa.PublicKey, msg []byte, opts *EncrypterOpts) ([]byte, error) {
 	//A3, requirement is to check if h*P is infinite point, h is 1
 	if pub.X.Sign() == 0 && pub.Y.Sign() == 0 {
-		return nil, errors.New("sm2: public key point is the infinity")
+		return nil, newError(ErrInvalidPublicKey, "sm2: public key point is the infinity")
 	}
 	if len(msg) == 0 {
-		return nil, nil
+		return dst, nil
 	}
 	if opts == nil {
 		opts = defaultEncrypterOpts
 	}
 	switch pub.Curve.Params() {
 	case P256().Params():
-		return encryptSM2EC(p256(), pub, random, msg, opts)
+		return encryptSM2EC(dst, p256(), pub, random, msg, opts)
 	default:
-		return encryptLegacy(random, pub, msg, opts)
+		ciphertext, err := encryptLegacy(random, pub, msg, opts)
+		if err != nil {
+			return nil, err
+		}
+		return append(dst, ciphertext...), nil
 	}
 }
 
-func encryptSM2EC(c *sm2Curve, pub *ecdsa.PublicKey, random io.Reader, msg []byte, opts *EncrypterOpts) ([]byte, error) {
+func encryptSM2EC(dst []byte, c *sm2Curve, pub *ecdsa.PublicKey, random io.Reader, msg []byte, opts *EncrypterOpts) ([]byte, error) {
 	Q, err := c.pointFromAffine(pub.X, pub.Y)
 	if err != nil {
 		return nil, err
@@ -252,10 +294,17 @@ func encryptSM2EC(c *sm2Curve, pub *ecdsa.PublicKey, random io.Reader, msg []byt
 		}
 		C2Bytes := C2.Bytes()[1:]
 		c2 := kdf.Kdf(sm3.New(), C2Bytes, len(msg))
+		if instrument.Tracing() {
+			instrument.Trace("sm2", "encrypt", "k", k.Bytes(c.N))
+			instrument.Trace("sm2", "encrypt", "C1", C1.Bytes())
+			instrument.Trace("sm2", "encrypt", "x2", C2Bytes[:len(C2Bytes)/2])
+			instrument.Trace("sm2", "encrypt", "y2", C2Bytes[len(C2Bytes)/2:])
+			instrument.Trace("sm2", "encrypt", "t", c2)
+		}
 		if subtle.ConstantTimeAllZero(c2) {
 			retryCount++
 			if retryCount > maxRetryLimit {
-				return nil, fmt.Errorf("sm2: A5, failed to calculate valid t, tried %v times", retryCount)
+				return nil, errorf(ErrRetryLimit, "sm2: A5, failed to calculate valid t, tried %v times", retryCount)
 			}
 			continue
 		}
@@ -268,15 +317,19 @@ func encryptSM2EC(c *sm2Curve, pub *ecdsa.PublicKey, random io.Reader, msg []byt
 		md.Write(msg)
 		md.Write(C2Bytes[len(C2Bytes)/2:])
 		c3 := md.Sum(nil)
+		if instrument.Tracing() {
+			instrument.Trace("sm2", "encrypt", "C2", c2)
+			instrument.Trace("sm2", "encrypt", "C3", c3)
+		}
 
 		if opts.ciphertextEncoding == ENCODING_PLAIN {
-			return encodingCiphertext(opts, C1, c2, c3)
+			return appendCiphertext(dst, opts, C1, c2, c3)
 		}
-		return encodingCiphertextASN1(C1, c2, c3)
+		return appendCiphertextASN1(dst, C1, c2, c3)
 	}
 }
 
-func encodingCiphertext(opts *EncrypterOpts, C1 *_sm2ec.SM2P256Point, c2, c3 []byte) ([]byte, error) {
+func appendCiphertext(dst []byte, opts *EncrypterOpts, C1 *_sm2ec.SM2P256Point, c2, c3 []byte) ([]byte, error) {
 	var c1 []byte
 	switch opts.pointMarshalMode {
 	case MarshalCompressed:
@@ -285,17 +338,18 @@ func encodingCiphertext(opts *EncrypterOpts, C1 *_sm2ec.SM2P256Point, c2, c3 []b
 		c1 = C1.Bytes()
 	}
 
+	dst = append(dst, c1...)
 	if opts.ciphertextSplicingOrder == C1C3C2 {
 		// c1 || c3 || c2
-		return append(append(c1, c3...), c2...), nil
+		return append(append(dst, c3...), c2...), nil
 	}
 	// c1 || c2 || c3
-	return append(append(c1, c2...), c3...), nil
+	return append(append(dst, c2...), c3...), nil
 }
 
-func encodingCiphertextASN1(C1 *_sm2ec.SM2P256Point, c2, c3 []byte) ([]byte, error) {
+func appendCiphertextASN1(dst []byte, C1 *_sm2ec.SM2P256Point, c2, c3 []byte) ([]byte, error) {
 	c1 := C1.Bytes()
-	var b cryptobyte.Builder
+	b := cryptobyte.NewBuilder(dst)
 	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
 		addASN1IntBytes(b, c1[1:len(c1)/2+1])
 		addASN1IntBytes(b, c1[len(c1)/2+1:])
@@ -335,11 +389,11 @@ func GenerateKey(rand io.Reader) (*PrivateKey, error) {
 func NewPrivateKey(key []byte) (*PrivateKey, error) {
 	c := p256()
 	if len(key) != c.N.Size() {
-		return nil, errors.New("sm2: invalid private key size")
+		return nil, newError(ErrInvalidPrivateKey, "sm2: invalid private key size")
 	}
 	k, err := bigmod.NewNat().SetBytes(key, c.N)
 	if err != nil || k.IsZero() == 1 || k.Equal(c.nMinus1) == 1 {
-		return nil, errInvalidPrivateKey
+		return nil, ErrInvalidPrivateKey
 	}
 	p, err := c.newPoint().ScalarBaseMult(k.Bytes(c.N))
 	if err != nil {
@@ -358,7 +412,7 @@ func NewPrivateKey(key []byte) (*PrivateKey, error) {
 // NewPrivateKeyFromInt checks that key is valid and returns a SM2 PrivateKey.
 func NewPrivateKeyFromInt(key *big.Int) (*PrivateKey, error) {
 	if key == nil {
-		return nil, errors.New("sm2: invalid private key size")
+		return nil, newError(ErrInvalidPrivateKey, "sm2: invalid private key size")
 	}
 	keyBytes := make([]byte, p256().N.Size())
 	return NewPrivateKey(key.FillBytes(keyBytes))
@@ -369,12 +423,12 @@ func NewPublicKey(key []byte) (*ecdsa.PublicKey, error) {
 	c := p256()
 	// Reject the point at infinity and compressed encodings.
 	if len(key) == 0 || key[0] != 4 {
-		return nil, errors.New("sm2: invalid public key")
+		return nil, ErrInvalidPublicKey
 	}
 	// SetBytes also checks that the point is on the curve.
 	p, err := c.newPoint().SetBytes(key)
 	if err != nil {
-		return nil, err
+		return nil, newError(ErrPointNotOnCurve, err.Error())
 	}
 	k := new(ecdsa.PublicKey)
 	k.Curve = c.curve
@@ -387,6 +441,8 @@ func NewPublicKey(key []byte) (*ecdsa.PublicKey, error) {
 
 // Decrypt sm2 decrypt implementation by default DecrypterOpts{C1C3C2}.
 // Compliance with GB/T 32918.4-2016.
+//
+// ASN.1 ciphertexts are detected by their leading SEQUENCE tag.
 func Decrypt(priv *PrivateKey, ciphertext []byte) ([]byte, error) {
 	return decrypt(priv, ciphertext, nil)
 }
@@ -396,6 +452,13 @@ func Decrypt(priv *PrivateKey, ciphertext []byte) ([]byte, error) {
 var ErrDecryption = errors.New("sm2: decryption error")
 
 func decrypt(priv *PrivateKey, ciphertext []byte, opts *DecrypterOpts) ([]byte, error) {
+	done := instrument.Start("sm2", "decrypt")
+	plaintext, err := decryptCiphertext(priv, ciphertext, opts)
+	done(err)
+	return plaintext, err
+}
+
+func decryptCiphertext(priv *PrivateKey, ciphertext []byte, opts *DecrypterOpts) ([]byte, error) {
 	ciphertextLen := len(ciphertext)
 	if ciphertextLen <= 1+(priv.Params().BitSize/8)+sm3.Size {
 		return nil, errCiphertextTooShort
@@ -425,6 +488,11 @@ func decryptSM2EC(c *sm2Curve, priv *PrivateKey, ciphertext []byte, opts *Decryp
 	C2Bytes := C2.Bytes()[1:]
 	msgLen := len(c2)
 	msg := kdf.Kdf(sm3.New(), C2Bytes, msgLen)
+	if instrument.Tracing() {
+		instrument.Trace("sm2", "decrypt", "x2", C2Bytes[:len(C2Bytes)/2])
+		instrument.Trace("sm2", "decrypt", "y2", C2Bytes[len(C2Bytes)/2:])
+		instrument.Trace("sm2", "decrypt", "t", msg)
+	}
 	if subtle.ConstantTimeAllZero(c2) {
 		return nil, ErrDecryption
 	}
@@ -437,6 +505,10 @@ func decryptSM2EC(c *sm2Curve, priv *PrivateKey, ciphertext []byte, opts *Decryp
 	md.Write(msg)
 	md.Write(C2Bytes[len(C2Bytes)/2:])
 	u := md.Sum(nil)
+	if instrument.Tracing() {
+		instrument.Trace("sm2", "decrypt", "M'", msg)
+		instrument.Trace("sm2", "decrypt", "u", u)
+	}
 
 	if _subtle.ConstantTimeCompare(u, c3) == 1 {
 		return msg, nil
@@ -450,6 +522,9 @@ func parseCiphertext(c *sm2Curve, ciphertext []byte, opts *DecrypterOpts) (*_sm2
 	byteLen := (bitSize + 7) / 8
 	splicingOrder := C1C3C2
 	if opts != nil {
+		if opts.ciphertextEncoding == ENCODING_ASN1 {
+			return parseCiphertextASN1(c, ciphertext)
+		}
 		splicingOrder = opts.cipherTextSplicingOrder
 	}
 
@@ -473,13 +548,14 @@ func parseCiphertext(c *sm2Curve, ciphertext []byte, opts *DecrypterOpts) (*_sm2
 		c2, c3 := parseCiphertextC2C3(ciphertext[1+byteLen:], splicingOrder)
 		return C1, c2, c3, nil
 	case byte(0x30):
+		// A plain C1 never starts with 0x30, so the guess is unambiguous.
 		return parseCiphertextASN1(c, ciphertext)
 	default:
-		return nil, nil, nil, errors.New("sm2: invalid/unsupport ciphertext format")
+		return nil, nil, nil, newError(ErrInvalidCiphertext, "sm2: invalid/unsupport ciphertext format")
 	}
 }
 
-func parseCiphertextC2C3(ciphertext []byte, order ciphertextSplicingOrder) ([]byte, []byte) {
+func parseCiphertextC2C3(ciphertext []byte, order CiphertextSplicingOrder) ([]byte, []byte) {
 	if order == C1C3C2 {
 		return ciphertext[sm3.Size:], ciphertext[:sm3.Size]
 	}
@@ -499,8 +575,9 @@ func unmarshalASN1Ciphertext(ciphertext []byte) (*big.Int, *big.Int, []byte, []b
 		!inner.ReadASN1Integer(y1) ||
 		!inner.ReadASN1Bytes(&c3, asn1.OCTET_STRING) ||
 		!inner.ReadASN1Bytes(&c2, asn1.OCTET_STRING) ||
-		!inner.Empty() {
-		return nil, nil, nil, nil, errors.New("sm2: invalid asn1 format ciphertext")
+		!inner.Empty() ||
+		len(c3) != sm3.Size || len(c2) == 0 {
+		return nil, nil, nil, nil, newError(ErrInvalidCiphertext, "sm2: invalid asn1 format ciphertext")
 	}
 	return x1, y1, c2, c3, nil
 }
@@ -526,7 +603,7 @@ var defaultUID = []byte{0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x31, 0x
 func CalculateZA(pub *ecdsa.PublicKey, uid []byte) ([]byte, error) {
 	uidLen := len(uid)
 	if uidLen >= 0x2000 {
-		return nil, errors.New("sm2: the uid is too long")
+		return nil, newError(ErrInvalidArgument, "sm2: the uid is too long")
 	}
 	entla := uint16(uidLen) << 3
 	md := sm3.New()
@@ -574,6 +651,19 @@ func CalculateSM2Hash(pub *ecdsa.PublicKey, data, uid []byte) ([]byte, error) {
 // If the opts argument is instance of [*SM2SignerOption], and its ForceGMSign is true,
 // then the hash will be treated as raw message.
 func SignASN1(rand io.Reader, priv *PrivateKey, hash []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return AppendSignASN1(nil, rand, priv, hash, opts)
+}
+
+// AppendSignASN1 is like SignASN1 but appends the ASN.1 encoded signature to dst
+// and returns the extended buffer, so that the caller can reuse the output buffer.
+func AppendSignASN1(dst []byte, rand io.Reader, priv *PrivateKey, hash []byte, opts crypto.SignerOpts) ([]byte, error) {
+	done := instrument.Start("sm2", "sign")
+	sig, err := appendSignASN1(dst, rand, priv, hash, opts)
+	done(err)
+	return sig, err
+}
+
+func appendSignASN1(dst []byte, rand io.Reader, priv *PrivateKey, hash []byte, opts crypto.SignerOpts) ([]byte, error) {
 	if sm2Opts, ok := opts.(*SM2SignerOption); ok && sm2Opts.forceGMSign {
 		newHash, err := CalculateSM2Hash(&priv.PublicKey, hash, sm2Opts.uid)
 		if err != nil {
@@ -586,9 +676,13 @@ func SignASN1(rand io.Reader, priv *PrivateKey, hash []byte, opts crypto.SignerO
 
 	switch priv.Curve.Params() {
 	case P256().Params():
-		return signSM2EC(p256(), priv, rand, hash)
+		return signSM2EC(dst, p256(), priv, rand, hash)
 	default:
-		return signLegacy(priv, rand, hash)
+		sig, err := signLegacy(priv, rand, hash)
+		if err != nil {
+			return nil, err
+		}
+		return append(dst, sig...), nil
 	}
 }
 
@@ -604,7 +698,7 @@ func (priv *PrivateKey) inverseOfPrivateKeyPlus1(c *sm2Curve) (*bigmod.Nat, erro
 		if err == nil {
 			dp1Inv.Add(oneNat, c.N)
 			if dp1Inv.IsZero() == 1 { // make sure private key is NOT N-1
-				err = errInvalidPrivateKey
+				err = ErrInvalidPrivateKey
 			} else {
 				dp1Bytes, err = _sm2ec.P256OrdInverse(dp1Inv.Bytes(c.N))
 				if err == nil {
@@ -614,12 +708,12 @@ func (priv *PrivateKey) inverseOfPrivateKeyPlus1(c *sm2Curve) (*bigmod.Nat, erro
 		}
 	})
 	if err != nil {
-		return nil, errInvalidPrivateKey
+		return nil, ErrInvalidPrivateKey
 	}
 	return priv.inverseOfKeyPlus1, nil
 }
 
-func signSM2EC(c *sm2Curve, priv *PrivateKey, rand io.Reader, hash []byte) (sig []byte, err error) {
+func signSM2EC(dst []byte, c *sm2Curve, priv *PrivateKey, rand io.Reader, hash []byte) (sig []byte, err error) {
 	// dp1Inv = (d+1)⁻¹
 	dp1Inv, err := priv.inverseOfPrivateKeyPlus1(c)
 	if err != nil {
@@ -634,6 +728,9 @@ func signSM2EC(c *sm2Curve, priv *PrivateKey, rand io.Reader, hash []byte) (sig
 	// hash to int
 	e := bigmod.NewNat()
 	hashToNat(c, e, hash)
+	if instrument.Tracing() {
+		instrument.Trace("sm2", "sign", "e", e.Bytes(c.N))
+	}
 
 	for {
 		for {
@@ -652,6 +749,11 @@ func signSM2EC(c *sm2Curve, priv *PrivateKey, rand io.Reader, hash []byte) (sig
 
 			// r = [Rx + e]
 			r.Add(e, c.N)
+			if instrument.Tracing() {
+				instrument.Trace("sm2", "sign", "k", k.Bytes(c.N))
+				instrument.Trace("sm2", "sign", "x1", Rx)
+				instrument.Trace("sm2", "sign", "r", r.Bytes(c.N))
+			}
 
 			// checks if r is zero or [r+k] is zero
 			if r.IsZero() == 0 {
@@ -675,12 +777,19 @@ func signSM2EC(c *sm2Curve, priv *PrivateKey, rand io.Reader, hash []byte) (sig
 			break
 		}
 	}
+	if instrument.Tracing() {
+		instrument.Trace("sm2", "sign", "s", k.Bytes(c.N))
+	}
 
-	return encodeSignature(r.Bytes(c.N), k.Bytes(c.N))
+	return appendSignature(dst, r.Bytes(c.N), k.Bytes(c.N))
 }
 
 func encodeSignature(r, s []byte) ([]byte, error) {
-	var b cryptobyte.Builder
+	return appendSignature(nil, r, s)
+}
+
+func appendSignature(dst, r, s []byte) ([]byte, error) {
+	b := cryptobyte.NewBuilder(dst)
 	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
 		addASN1IntBytes(b, r)
 		addASN1IntBytes(b, s)
@@ -713,6 +822,13 @@ func addASN1IntBytes(b *cryptobyte.Builder, bytes []byte) {
 // Caller should make sure the hash's correctness, in other words,
 // the caller must pre-calculate the hash value.
 func VerifyASN1(pub *ecdsa.PublicKey, hash, sig []byte) bool {
+	done := instrument.Start("sm2", "verify")
+	ok := verifyASN1(pub, hash, sig)
+	done(instrument.VerifyResult(ok))
+	return ok
+}
+
+func verifyASN1(pub *ecdsa.PublicKey, hash, sig []byte) bool {
 	switch pub.Curve.Params() {
 	case P256().Params():
 		return verifySM2EC(p256(), pub, hash, sig)
@@ -773,6 +889,12 @@ func verifySM2EC(c *sm2Curve, pub *ecdsa.PublicKey, hash, sig []byte) bool {
 		return false
 	}
 	v.Add(e, c.N)
+	if instrument.Tracing() {
+		instrument.Trace("sm2", "verify", "e", e.Bytes(c.N))
+		instrument.Trace("sm2", "verify", "t", t.Bytes(c.N))
+		instrument.Trace("sm2", "verify", "x1'", Rx)
+		instrument.Trace("sm2", "verify", "R", v.Bytes(c.N))
+	}
 
 	return v.Equal(r) == 1
 }
@@ -797,7 +919,7 @@ func parseSignature(sig []byte) (r, s []byte, err error) {
 		!inner.ReadASN1Integer(&r) ||
 		!inner.ReadASN1Integer(&s) ||
 		!inner.Empty() {
-		return nil, nil, errors.New("invalid ASN.1")
+		return nil, nil, newError(ErrInvalidSignature, "invalid ASN.1")
 	}
 	return r, s, nil
 }
@@ -844,10 +966,10 @@ func P256() elliptic.Curve {
 func PublicKeyToECDH(k *ecdsa.PublicKey) (*ecdh.PublicKey, error) {
 	c := curveToECDH(k.Curve)
 	if c == nil {
-		return nil, errors.New("sm2: unsupported curve by ecdh")
+		return nil, newError(ErrInvalidArgument, "sm2: unsupported curve by ecdh")
 	}
 	if !k.Curve.IsOnCurve(k.X, k.Y) {
-		return nil, errors.New("sm2: invalid public key")
+		return nil, ErrInvalidPublicKey
 	}
 	return c.NewPublicKey(elliptic.Marshal(k.Curve, k.X, k.Y))
 }
@@ -858,11 +980,11 @@ func PublicKeyToECDH(k *ecdsa.PublicKey) (*ecdh.PublicKey, error) {
 func (k *PrivateKey) ECDH() (*ecdh.PrivateKey, error) {
 	c := curveToECDH(k.Curve)
 	if c == nil {
-		return nil, errors.New("sm2: unsupported curve by ecdh")
+		return nil, newError(ErrInvalidArgument, "sm2: unsupported curve by ecdh")
 	}
 	size := (k.Curve.Params().N.BitLen() + 7) / 8
 	if k.D.BitLen() > size*8 {
-		return nil, errors.New("sm2: invalid private key")
+		return nil, ErrInvalidPrivateKey
 	}
 	return c.NewPrivateKey(k.D.FillBytes(make([]byte, size)))
 }
@@ -932,10 +1054,10 @@ func (curve *sm2Curve) pointFromAffine(x, y *big.Int) (p *_sm2ec.SM2P256Point, e
 	bitSize := curve.curve.Params().BitSize
 	// Reject values that would not get correctly encoded.
 	if x.Sign() < 0 || y.Sign() < 0 {
-		return p, errors.New("negative coordinate")
+		return p, newError(ErrPointNotOnCurve, "negative coordinate")
 	}
 	if x.BitLen() > bitSize || y.BitLen() > bitSize {
-		return p, errors.New("overflowing coordinate")
+		return p, newError(ErrPointNotOnCurve, "overflowing coordinate")
 	}
 	// Encode the coordinates and let SetBytes reject invalid points.
 	byteLen := (bitSize + 7) / 8
@@ -951,7 +1073,7 @@ func (curve *sm2Curve) pointToAffine(p *_sm2ec.SM2P256Point) (x, y *big.Int, err
 	out := p.Bytes()
 	if len(out) == 1 && out[0] == 0 {
 		// This is the encoding of the point at infinity.
-		return nil, nil, errors.New("sm2: public key point is the infinity")
+		return nil, nil, newError(ErrInvalidPublicKey, "sm2: public key point is the infinity")
 	}
 	byteLen := (curve.curve.Params().BitSize + 7) / 8
 	x = new(big.Int).SetBytes(out[1 : 1+byteLen])
@@ -979,5 +1101,3 @@ func precomputeParams(c *sm2Curve, curve elliptic.Curve) {
 	c.nMinus2 = new(big.Int).Sub(params.N, big.NewInt(2)).Bytes()
 	c.nMinus1, _ = bigmod.NewNat().SetBytes(new(big.Int).Sub(params.N, big.NewInt(1)).Bytes(), c.N)
 }
-
-var errInvalidPrivateKey = errors.New("sm2: invalid private key")
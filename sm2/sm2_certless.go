@@ -0,0 +1,254 @@
+package sm2
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"io"
+	"math/big"
+
+	"github.com/emmansun/gmsm/internal/bigmod"
+	_sm2ec "github.com/emmansun/gmsm/internal/sm2ec"
+	"github.com/emmansun/gmsm/sm3"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// This file implements a pairing-free certificateless public key cryptography
+// (CL-PKC) on the sm2 curve. The key generation center (KGC) has the master
+// key s, Ppub = [s]G. A user chooses the secret x, X = [x]G, and gets from the
+// KGC the partial key
+//
+//	R = [r]G, h = SM3(len(uid) || uid || X || R || Ppub) mod n, d = r + h·s
+//
+// The full private key is x + d and the public key is
+//
+//	Q = X + R + [h]Ppub
+//
+// which anybody computes from uid, X, R and Ppub, without certificate. The
+// KGC does not know x, so there is no key escrow. Q is an ordinary sm2 public
+// key, the full private key an ordinary sm2 private key, so the sm2 signature
+// and encryption are used unchanged.
+
+// CLPartialKey is the partial private key issued by the KGC to a user. It
+// must be sent to the user over a confidential channel.
+type CLPartialKey struct {
+	R *ecdsa.PublicKey
+	D *big.Int
+}
+
+// CLPublicKey is the public key of a user, it is published instead of a
+// certificate.
+type CLPublicKey struct {
+	UID []byte
+	X   *ecdsa.PublicKey // user chosen part
+	R   *ecdsa.PublicKey // KGC chosen part
+}
+
+func clHash(c *sm2Curve, uid []byte, x, r, master *_sm2ec.SM2P256Point) *bigmod.Nat {
+	md := sm3.New()
+	md.Write([]byte{byte(len(uid) >> 8), byte(len(uid))})
+	md.Write(uid)
+	md.Write(x.Bytes())
+	md.Write(r.Bytes())
+	md.Write(master.Bytes())
+	h := bigmod.NewNat()
+	hashToNat(c, h, md.Sum(nil))
+	return h
+}
+
+func sm2Point(c *sm2Curve, pub *ecdsa.PublicKey) (*_sm2ec.SM2P256Point, error) {
+	if pub == nil || pub.Curve != P256() {
+		return nil, newError(ErrInvalidPublicKey, "sm2: certificateless key requires sm2 public keys")
+	}
+	return c.pointFromAffine(pub.X, pub.Y)
+}
+
+func sm2PublicKey(c *sm2Curve, p *_sm2ec.SM2P256Point) (*ecdsa.PublicKey, error) {
+	x, y, err := c.pointToAffine(p)
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{Curve: c.curve, X: x, Y: y}, nil
+}
+
+// IssueCLPartialKey issues with the KGC master key the partial key of the
+// user uid whose secret public part is userPub.
+func IssueCLPartialKey(rand io.Reader, master *PrivateKey, uid []byte, userPub *ecdsa.PublicKey) (*CLPartialKey, error) {
+	if master == nil || master.Curve != P256() {
+		return nil, newError(ErrInvalidPrivateKey, "sm2: certificateless key requires a sm2 master key")
+	}
+	if len(uid) > 0xffff {
+		return nil, newError(ErrInvalidArgument, "sm2: uid is too long")
+	}
+	c := p256()
+	x, err := sm2Point(c, userPub)
+	if err != nil {
+		return nil, err
+	}
+	ppub, err := sm2Point(c, &master.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	s, err := bigmod.NewNat().SetBytes(master.D.Bytes(), c.N)
+	if err != nil || s.IsZero() == 1 {
+		return nil, ErrInvalidPrivateKey
+	}
+	r, rPoint, err := randomPoint(c, rand, false)
+	if err != nil {
+		return nil, err
+	}
+	h := clHash(c, uid, x, rPoint, ppub)
+	d := h.Mul(s, c.N).Add(r, c.N)
+	rPub, err := sm2PublicKey(c, rPoint)
+	if err != nil {
+		return nil, err
+	}
+	return &CLPartialKey{R: rPub, D: new(big.Int).SetBytes(d.Bytes(c.N))}, nil
+}
+
+// NewCLPrivateKey checks the partial key issued by the KGC whose master public
+// key is master, and combines it with the user secret into the full private
+// key. It also returns the public key to publish.
+func NewCLPrivateKey(master *ecdsa.PublicKey, uid []byte, secret *PrivateKey, partial *CLPartialKey) (*PrivateKey, *CLPublicKey, error) {
+	if secret == nil || secret.Curve != P256() {
+		return nil, nil, newError(ErrInvalidPrivateKey, "sm2: certificateless key requires a sm2 secret key")
+	}
+	if partial == nil || partial.D == nil {
+		return nil, nil, newError(ErrInvalidPrivateKey, "sm2: invalid certificateless partial key")
+	}
+	pub := &CLPublicKey{UID: uid, X: &secret.PublicKey, R: partial.R}
+	q, err := pub.PublicKey(master)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := p256()
+	d, err := bigmod.NewNat().SetBytes(partial.D.Bytes(), c.N)
+	if err != nil {
+		return nil, nil, newError(ErrInvalidPrivateKey, "sm2: invalid certificateless partial key")
+	}
+	// [d]G = R + [h]Ppub, i.e. [x + d]G = Q
+	x, err := bigmod.NewNat().SetBytes(secret.D.Bytes(), c.N)
+	if err != nil {
+		return nil, nil, ErrInvalidPrivateKey
+	}
+	full := x.Add(d, c.N)
+	priv, err := NewPrivateKey(full.Bytes(c.N))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !priv.PublicKey.Equal(q) {
+		return nil, nil, newError(ErrInvalidPrivateKey, "sm2: certificateless partial key does not match the KGC master public key")
+	}
+	return priv, pub, nil
+}
+
+// PublicKey computes the sm2 public key Q = X + R + [h]Ppub of pub under the
+// KGC master public key master. Signatures of the user are verified and
+// messages to the user are encrypted with it.
+func (pub *CLPublicKey) PublicKey(master *ecdsa.PublicKey) (*ecdsa.PublicKey, error) {
+	if len(pub.UID) > 0xffff {
+		return nil, newError(ErrInvalidArgument, "sm2: uid is too long")
+	}
+	c := p256()
+	ppub, err := sm2Point(c, master)
+	if err != nil {
+		return nil, err
+	}
+	x, err := sm2Point(c, pub.X)
+	if err != nil {
+		return nil, err
+	}
+	r, err := sm2Point(c, pub.R)
+	if err != nil {
+		return nil, err
+	}
+	h := clHash(c, pub.UID, x, r, ppub)
+	q, err := c.newPoint().ScalarMult(ppub, h.Bytes(c.N))
+	if err != nil {
+		return nil, err
+	}
+	q.Add(q, x)
+	q.Add(q, r)
+	return sm2PublicKey(c, q)
+}
+
+// MarshalASN1 encodes the partial key as
+//
+//	CLPartialKey ::= SEQUENCE {
+//	  r  OCTET STRING, -- uncompressed sm2 point
+//	  d  OCTET STRING  -- 32 bytes big endian
+//	}
+func (k *CLPartialKey) MarshalASN1() ([]byte, error) {
+	if k.R == nil || k.D == nil || k.D.Sign() < 0 || k.D.BitLen() > 256 {
+		return nil, newError(ErrInvalidPrivateKey, "sm2: invalid certificateless partial key")
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1OctetString(elliptic.Marshal(k.R.Curve, k.R.X, k.R.Y))
+		b.AddASN1OctetString(k.D.FillBytes(make([]byte, 32)))
+	})
+	return b.Bytes()
+}
+
+// ParseCLPartialKey parses a partial key encoded by CLPartialKey.MarshalASN1.
+func ParseCLPartialKey(der []byte) (*CLPartialKey, error) {
+	var (
+		inner cryptobyte.String
+		r, d  []byte
+	)
+	input := cryptobyte.String(der)
+	if !input.ReadASN1(&inner, asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1Bytes(&r, asn1.OCTET_STRING) ||
+		!inner.ReadASN1Bytes(&d, asn1.OCTET_STRING) || !inner.Empty() || len(d) != 32 {
+		return nil, newError(ErrInvalidPrivateKey, "sm2: malformed certificateless partial key")
+	}
+	rPub, err := NewPublicKey(r)
+	if err != nil {
+		return nil, err
+	}
+	return &CLPartialKey{R: rPub, D: new(big.Int).SetBytes(d)}, nil
+}
+
+// MarshalASN1 encodes the public key as
+//
+//	CLPublicKey ::= SEQUENCE {
+//	  uid  OCTET STRING,
+//	  x    OCTET STRING, -- uncompressed sm2 point
+//	  r    OCTET STRING  -- uncompressed sm2 point
+//	}
+func (pub *CLPublicKey) MarshalASN1() ([]byte, error) {
+	if pub.X == nil || pub.R == nil {
+		return nil, newError(ErrInvalidPublicKey, "sm2: invalid certificateless public key")
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1OctetString(pub.UID)
+		b.AddASN1OctetString(elliptic.Marshal(pub.X.Curve, pub.X.X, pub.X.Y))
+		b.AddASN1OctetString(elliptic.Marshal(pub.R.Curve, pub.R.X, pub.R.Y))
+	})
+	return b.Bytes()
+}
+
+// ParseCLPublicKey parses a public key encoded by CLPublicKey.MarshalASN1.
+func ParseCLPublicKey(der []byte) (*CLPublicKey, error) {
+	var (
+		inner     cryptobyte.String
+		uid, x, r []byte
+	)
+	input := cryptobyte.String(der)
+	if !input.ReadASN1(&inner, asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1Bytes(&uid, asn1.OCTET_STRING) ||
+		!inner.ReadASN1Bytes(&x, asn1.OCTET_STRING) ||
+		!inner.ReadASN1Bytes(&r, asn1.OCTET_STRING) || !inner.Empty() {
+		return nil, newError(ErrInvalidPublicKey, "sm2: malformed certificateless public key")
+	}
+	xPub, err := NewPublicKey(x)
+	if err != nil {
+		return nil, err
+	}
+	rPub, err := NewPublicKey(r)
+	if err != nil {
+		return nil, err
+	}
+	return &CLPublicKey{UID: uid, X: xPub, R: rPub}, nil
+}
@@ -0,0 +1,113 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestCertificateless(t *testing.T) {
+	kgc, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := []byte("alice@example.com")
+	partial, err := IssueCLPartialKey(rand.Reader, kgc, uid, &secret.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := partial.MarshalASN1()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if partial, err = ParseCLPartialKey(der); err != nil {
+		t.Fatal(err)
+	}
+	priv, pub, err := NewCLPrivateKey(&kgc.PublicKey, uid, secret, partial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv.D.Cmp(secret.D) == 0 || priv.D.Cmp(partial.D) == 0 {
+		t.Fatal("full private key must differ from its parts")
+	}
+
+	der, err = pub.MarshalASN1()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub, err = ParseCLPublicKey(der); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pub.UID, uid) {
+		t.Fatalf("got uid %q, expected %q", pub.UID, uid)
+	}
+	q, err := pub.PublicKey(&kgc.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !q.Equal(&priv.PublicKey) {
+		t.Fatal("derived public key mismatch")
+	}
+
+	msg := []byte("certificateless message")
+	sig, err := priv.Sign(rand.Reader, msg, NewSM2SignerOption(true, uid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyASN1WithSM2(q, uid, msg, sig) {
+		t.Fatal("signature verification failed")
+	}
+	ciphertext, err := EncryptASN1(rand.Reader, q, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := priv.Decrypt(nil, ciphertext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, msg) {
+		t.Fatalf("got %q, expected %q", plaintext, msg)
+	}
+
+	// another identity or KGC derives another public key
+	other := &CLPublicKey{UID: []byte("bob@example.com"), X: pub.X, R: pub.R}
+	if q2, err := other.PublicKey(&kgc.PublicKey); err != nil || q2.Equal(q) {
+		t.Fatal("public key must be bound to the uid")
+	}
+	kgc2, _ := GenerateKey(rand.Reader)
+	if q2, err := pub.PublicKey(&kgc2.PublicKey); err != nil || q2.Equal(q) {
+		t.Fatal("public key must be bound to the KGC")
+	}
+}
+
+func TestCertificatelessInvalidPartialKey(t *testing.T) {
+	kgc, _ := GenerateKey(rand.Reader)
+	secret, _ := GenerateKey(rand.Reader)
+	uid := []byte("alice@example.com")
+	partial, err := IssueCLPartialKey(rand.Reader, kgc, uid, &secret.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = NewCLPrivateKey(&kgc.PublicKey, []byte("bob@example.com"), secret, partial); err == nil {
+		t.Error("partial key of another uid should be rejected")
+	}
+	other, _ := GenerateKey(rand.Reader)
+	if _, _, err = NewCLPrivateKey(&kgc.PublicKey, uid, other, partial); err == nil {
+		t.Error("partial key of another secret should be rejected")
+	}
+	tampered := &CLPartialKey{R: partial.R, D: new(big.Int).Add(partial.D, big.NewInt(1))}
+	if _, _, err = NewCLPrivateKey(&kgc.PublicKey, uid, secret, tampered); err == nil {
+		t.Error("tampered partial key should be rejected")
+	}
+	if _, err = ParseCLPartialKey([]byte{0x30, 0x00}); err == nil {
+		t.Error("malformed partial key should be rejected")
+	}
+	if _, err = ParseCLPublicKey([]byte{0x30, 0x00}); err == nil {
+		t.Error("malformed public key should be rejected")
+	}
+}
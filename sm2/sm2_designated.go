@@ -0,0 +1,190 @@
+package sm2
+
+import (
+	"crypto/ecdsa"
+	"io"
+
+	"github.com/emmansun/gmsm/internal/bigmod"
+	"github.com/emmansun/gmsm/internal/randutil"
+	_sm2ec "github.com/emmansun/gmsm/internal/sm2ec"
+	"github.com/emmansun/gmsm/sm3"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// This file implements designated verifier signatures over the sm2 curve, in
+// the style of Jakobsson, Sako and Impagliazzo, "Designated Verifier Proofs and
+// Their Applications" (EUROCRYPT 1996).
+//
+// A designated verifier signature is a non-interactive Schnorr OR-proof that
+// the author knows the private key of the signer OR the private key of the
+// verifier, bound to the message. The verifier, who knows that they did not
+// produce it, is convinced that the signer did. Anybody else can not tell
+// whether the signer or the verifier produced it, because the verifier can
+// produce indistinguishable signatures with SimulateDesignatedVerifier, so the
+// signature is not a transferable proof.
+//
+// The signature is the ASN.1 SEQUENCE of the INTEGERs c₁, c₂, z₁, z₂, where the
+// index 1 is the signer branch and 2 is the verifier branch, and
+//
+//	Rᵢ = [zᵢ]G + [cᵢ]Pᵢ
+//	c₁ + c₂ = SM3(domain || P₁ || P₂ || R₁ || R₂ || msg) mod n
+
+var designatedVerifierDomain = []byte("SM2-DESIGNATED-VERIFIER-SIGNATURE")
+
+// SignDesignatedVerifier signs msg with priv so that only the owner of the
+// verifier public key can be convinced by the signature.
+func SignDesignatedVerifier(rand io.Reader, priv *PrivateKey, verifier *ecdsa.PublicKey, msg []byte) ([]byte, error) {
+	if priv == nil || priv.Curve != P256() {
+		return nil, newError(ErrInvalidPrivateKey, "sm2: designated verifier signature requires a sm2 private key")
+	}
+	return signDesignatedVerifier(p256(), rand, priv, &priv.PublicKey, verifier, 0, msg)
+}
+
+// SimulateDesignatedVerifier creates, with the verifier private key, a
+// signature of msg which is indistinguishable from one created by the owner of
+// the signer public key with SignDesignatedVerifier. It gives the deniability of
+// the scheme.
+func SimulateDesignatedVerifier(rand io.Reader, verifierPriv *PrivateKey, signer *ecdsa.PublicKey, msg []byte) ([]byte, error) {
+	if verifierPriv == nil || verifierPriv.Curve != P256() {
+		return nil, newError(ErrInvalidPrivateKey, "sm2: designated verifier signature requires a sm2 private key")
+	}
+	return signDesignatedVerifier(p256(), rand, verifierPriv, signer, &verifierPriv.PublicKey, 1, msg)
+}
+
+// VerifyDesignatedVerifier reports whether sig is a valid designated verifier
+// signature of msg by signer for verifier. A valid signature only proves that
+// the signer or the verifier created it.
+func VerifyDesignatedVerifier(signer, verifier *ecdsa.PublicKey, msg, sig []byte) bool {
+	c := p256()
+	if signer == nil || verifier == nil || signer.Curve != P256() || verifier.Curve != P256() {
+		return false
+	}
+	var pubs [2]*_sm2ec.SM2P256Point
+	var err error
+	if pubs[0], err = c.pointFromAffine(signer.X, signer.Y); err != nil {
+		return false
+	}
+	if pubs[1], err = c.pointFromAffine(verifier.X, verifier.Y); err != nil {
+		return false
+	}
+
+	var ints [4][]byte
+	var inner cryptobyte.String
+	input := cryptobyte.String(sig)
+	if !input.ReadASN1(&inner, asn1.SEQUENCE) || !input.Empty() {
+		return false
+	}
+	for i := range ints {
+		if !inner.ReadASN1Integer(&ints[i]) {
+			return false
+		}
+	}
+	if !inner.Empty() {
+		return false
+	}
+	var nats [4]*bigmod.Nat
+	for i := range nats {
+		nats[i], err = bigmod.NewNat().SetBytes(ints[i], c.N)
+		if err != nil || nats[i].IsZero() == 1 {
+			return false
+		}
+	}
+	cs, zs := nats[:2], nats[2:]
+
+	var rs [2]*_sm2ec.SM2P256Point
+	for i := range rs {
+		if rs[i], err = designatedCommitment(c, zs[i], cs[i], pubs[i]); err != nil {
+			return false
+		}
+	}
+	e := designatedChallenge(c, pubs, rs, msg)
+	sum := bigmod.NewNat().Set(cs[0]).Add(cs[1], c.N)
+	return sum.Equal(e) == 1
+}
+
+// signDesignatedVerifier computes the OR-proof with the private key of the
+// branch known, 0 for the signer and 1 for the verifier, the other branch is
+// simulated.
+func signDesignatedVerifier(c *sm2Curve, rand io.Reader, priv *PrivateKey, signer, verifier *ecdsa.PublicKey, known int, msg []byte) ([]byte, error) {
+	if signer == nil || verifier == nil || signer.Curve != P256() || verifier.Curve != P256() {
+		return nil, newError(ErrInvalidPublicKey, "sm2: designated verifier signature requires sm2 public keys")
+	}
+	var pubs [2]*_sm2ec.SM2P256Point
+	var err error
+	if pubs[0], err = c.pointFromAffine(signer.X, signer.Y); err != nil {
+		return nil, err
+	}
+	if pubs[1], err = c.pointFromAffine(verifier.X, verifier.Y); err != nil {
+		return nil, err
+	}
+	x, err := bigmod.NewNat().SetBytes(priv.D.Bytes(), c.N)
+	if err != nil || x.IsZero() == 1 {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	randutil.MaybeReadByte(rand)
+
+	other := 1 - known
+	var cs, zs [2]*bigmod.Nat
+	var rs [2]*_sm2ec.SM2P256Point
+	for {
+		// simulated branch: pick cₒ, zₒ and set Rₒ = [zₒ]G + [cₒ]Pₒ
+		if cs[other], _, err = randomPoint(c, rand, false); err != nil {
+			return nil, err
+		}
+		if zs[other], _, err = randomPoint(c, rand, false); err != nil {
+			return nil, err
+		}
+		if rs[other], err = designatedCommitment(c, zs[other], cs[other], pubs[other]); err != nil {
+			return nil, err
+		}
+		// real branch: Rₖ = [k]G
+		var k *bigmod.Nat
+		if k, rs[known], err = randomPoint(c, rand, false); err != nil {
+			return nil, err
+		}
+		// cₖ = e - cₒ, zₖ = k - cₖ * x
+		cs[known] = designatedChallenge(c, pubs, rs, msg)
+		cs[known].Sub(cs[other], c.N)
+		t := bigmod.NewNat().Set(cs[known]).Mul(x, c.N)
+		zs[known] = k.Sub(t, c.N)
+		if cs[known].IsZero() == 0 && zs[known].IsZero() == 0 {
+			break
+		}
+	}
+
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		for _, n := range [4]*bigmod.Nat{cs[0], cs[1], zs[0], zs[1]} {
+			addASN1IntBytes(b, n.Bytes(c.N))
+		}
+	})
+	return b.Bytes()
+}
+
+// designatedCommitment returns [z]G + [c]P.
+func designatedCommitment(c *sm2Curve, z, ch *bigmod.Nat, p *_sm2ec.SM2P256Point) (*_sm2ec.SM2P256Point, error) {
+	r, err := c.newPoint().ScalarBaseMult(z.Bytes(c.N))
+	if err != nil {
+		return nil, err
+	}
+	t, err := c.newPoint().ScalarMult(p, ch.Bytes(c.N))
+	if err != nil {
+		return nil, err
+	}
+	return r.Add(r, t), nil
+}
+
+// designatedChallenge returns SM3(domain || P₁ || P₂ || R₁ || R₂ || msg) mod n.
+func designatedChallenge(c *sm2Curve, pubs, rs [2]*_sm2ec.SM2P256Point, msg []byte) *bigmod.Nat {
+	md := sm3.New()
+	md.Write(designatedVerifierDomain)
+	for _, p := range [4]*_sm2ec.SM2P256Point{pubs[0], pubs[1], rs[0], rs[1]} {
+		md.Write(p.Bytes())
+	}
+	md.Write(msg)
+	e := bigmod.NewNat()
+	hashToNat(c, e, md.Sum(nil))
+	return e
+}
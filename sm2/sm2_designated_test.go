@@ -0,0 +1,95 @@
+package sm2
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestDesignatedVerifier(t *testing.T) {
+	signer, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("contract draft v2")
+
+	sig, err := SignDesignatedVerifier(rand.Reader, signer, &verifier.PublicKey, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyDesignatedVerifier(&signer.PublicKey, &verifier.PublicKey, msg, sig) {
+		t.Errorf("Verify failed")
+	}
+	if VerifyDesignatedVerifier(&signer.PublicKey, &verifier.PublicKey, []byte("contract draft v3"), sig) {
+		t.Errorf("Verify with wrong message succeeded")
+	}
+	if VerifyDesignatedVerifier(&signer.PublicKey, &other.PublicKey, msg, sig) {
+		t.Errorf("Verify with wrong verifier succeeded")
+	}
+	if VerifyDesignatedVerifier(&verifier.PublicKey, &signer.PublicKey, msg, sig) {
+		t.Errorf("Verify with swapped keys succeeded")
+	}
+	if VerifyDesignatedVerifier(&signer.PublicKey, &verifier.PublicKey, msg, sig[:len(sig)-1]) {
+		t.Errorf("Verify with truncated signature succeeded")
+	}
+
+	// the verifier can produce signatures which look the same
+	simulated, err := SimulateDesignatedVerifier(rand.Reader, verifier, &signer.PublicKey, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyDesignatedVerifier(&signer.PublicKey, &verifier.PublicKey, msg, simulated) {
+		t.Errorf("Verify of simulated signature failed")
+	}
+	// a third party can't simulate
+	if sig, err := SimulateDesignatedVerifier(rand.Reader, other, &signer.PublicKey, msg); err != nil {
+		t.Fatal(err)
+	} else if VerifyDesignatedVerifier(&signer.PublicKey, &verifier.PublicKey, msg, sig) {
+		t.Errorf("Verify of third party signature succeeded")
+	}
+}
+
+func TestDesignatedVerifierInvalidKeys(t *testing.T) {
+	signer, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = SignDesignatedVerifier(rand.Reader, signer, &p256.PublicKey, []byte("msg"))
+	if !errors.Is(err, ErrInvalidPublicKey) {
+		t.Errorf("got %v, expected ErrInvalidPublicKey", err)
+	}
+	_, err = SignDesignatedVerifier(rand.Reader, nil, &signer.PublicKey, []byte("msg"))
+	if !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("got %v, expected ErrInvalidPrivateKey", err)
+	}
+	if VerifyDesignatedVerifier(&signer.PublicKey, &p256.PublicKey, []byte("msg"), []byte{0x30, 0x00}) {
+		t.Errorf("Verify with P-256 key succeeded")
+	}
+}
+
+func BenchmarkSignDesignatedVerifier(b *testing.B) {
+	signer, _ := GenerateKey(rand.Reader)
+	verifier, _ := GenerateKey(rand.Reader)
+	msg := []byte("contract draft v2")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := SignDesignatedVerifier(rand.Reader, signer, &verifier.PublicKey, msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package sm2
+
+import (
+	"crypto/ecdsa"
+	"hash"
+	"io"
+
+	"github.com/emmansun/gmsm/sm3"
+)
+
+// DefaultUID returns the default user id of GM/T 0009, 1234567812345678, which
+// is used when the uid is empty, e.g. to compute ZA with CalculateZA.
+func DefaultUID() []byte {
+	return append([]byte{}, defaultUID...)
+}
+
+// NewHash returns an SM3 hash.Hash which has already absorbed ZA of pub and
+// uid, the default one if empty. Writing the message M to it and calling Sum
+// yields SM3(ZA || M), the digest of SignDigest and VerifyDigest, without
+// holding the whole message in memory.
+func NewHash(pub *ecdsa.PublicKey, uid []byte) (hash.Hash, error) {
+	if len(uid) == 0 {
+		uid = defaultUID
+	}
+	za, err := CalculateZA(pub, uid)
+	if err != nil {
+		return nil, err
+	}
+	md := sm3.New()
+	md.Write(za)
+	return md, nil
+}
+
+// SignDigest signs digest, the externally computed SM3(ZA || M) of the message
+// M, with priv and returns the ASN.1 encoded signature. It is for HSMs and
+// streaming, where the message is hashed elsewhere, see NewHash and
+// CalculateZA. Unlike SignASN1, it rejects digests which are not sm3.Size
+// bytes long, so that a raw message is never signed by mistake.
+func SignDigest(rand io.Reader, priv *PrivateKey, digest []byte) ([]byte, error) {
+	if len(digest) != sm3.Size {
+		return nil, newError(ErrInvalidArgument, "sm2: invalid digest size")
+	}
+	return SignASN1(rand, priv, digest, nil)
+}
+
+// VerifyDigest verifies the ASN.1 encoded signature, sig, of digest, the
+// externally computed SM3(ZA || M) of the message M, using the public key,
+// pub. Its return value records whether the signature is valid.
+func VerifyDigest(pub *ecdsa.PublicKey, digest, sig []byte) bool {
+	if len(digest) != sm3.Size {
+		return false
+	}
+	return VerifyASN1(pub, digest, sig)
+}
@@ -0,0 +1,55 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm3"
+)
+
+func TestSignDigest(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("ShangMi SM2 Sign Standard")
+	uid := []byte("Alice")
+
+	for _, id := range [][]byte{nil, uid} {
+		h, err := NewHash(&priv.PublicKey, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Write(msg[:10])
+		h.Write(msg[10:])
+		digest := h.Sum(nil)
+
+		sig, err := SignDigest(rand.Reader, priv, digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyDigest(&priv.PublicKey, digest, sig) {
+			t.Errorf("uid %q: VerifyDigest failed", id)
+		}
+		if !VerifyASN1WithSM2(&priv.PublicKey, id, msg, sig) {
+			t.Errorf("uid %q: VerifyASN1WithSM2 failed", id)
+		}
+		if VerifyDigest(&priv.PublicKey, digest[1:], sig) {
+			t.Errorf("uid %q: VerifyDigest accepted a short digest", id)
+		}
+	}
+
+	za, err := CalculateZA(&priv.PublicKey, DefaultUID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, _ := NewHash(&priv.PublicKey, nil)
+	if want := sm3.Sum(za); string(h.Sum(nil)) != string(want[:]) {
+		t.Error("NewHash doesn't start with ZA of the default uid")
+	}
+
+	if _, err := SignDigest(rand.Reader, priv, msg); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("SignDigest of a message: got %v, want ErrInvalidArgument", err)
+	}
+}
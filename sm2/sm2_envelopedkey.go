@@ -3,14 +3,12 @@ package sm2
 import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
-	"crypto/rand"
 	"crypto/x509/pkix"
 	"encoding/asn1"
-	"errors"
-	"fmt"
 	"io"
 
 	"github.com/emmansun/gmsm/cipher"
+	"github.com/emmansun/gmsm/internal/randutil"
 	"github.com/emmansun/gmsm/sm4"
 	"golang.org/x/crypto/cryptobyte"
 	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
@@ -36,7 +34,7 @@ func MarshalEnvelopedPrivateKey(rand io.Reader, pub *ecdsa.PublicKey, tobeEnvelo
 	// encrypt sm2 private key
 	size := (tobeEnveloped.Curve.Params().N.BitLen() + 7) / 8
 	if tobeEnveloped.D.BitLen() > size*8 {
-		return nil, errors.New("sm2: invalid private key")
+		return nil, ErrInvalidPrivateKey
 	}
 	plaintext := tobeEnveloped.D.FillBytes(make([]byte, size))
 
@@ -93,7 +91,7 @@ func ParseEnvelopedPrivateKey(priv *PrivateKey, enveloped []byte) (*PrivateKey,
 		!inner.ReadASN1BitString(&pub) ||
 		!inner.ReadASN1BitString(&encryptedPrivateKey) ||
 		!inner.Empty() {
-		return nil, errors.New("sm2: invalid asn1 format enveloped key")
+		return nil, newError(ErrInvalidCiphertext, "sm2: invalid asn1 format enveloped key")
 	}
 
 	if _, err := asn1.Unmarshal(symAlgIdBytes, &symAlgId); err != nil {
@@ -101,7 +99,7 @@ func ParseEnvelopedPrivateKey(priv *PrivateKey, enveloped []byte) (*PrivateKey,
 	}
 
 	if !(symAlgId.Algorithm.Equal(oidSM4) || symAlgId.Algorithm.Equal(oidSM4ECB)) {
-		return nil, fmt.Errorf("sm2: unsupported symmetric cipher <%v>", symAlgId.Algorithm)
+		return nil, errorf(ErrInvalidArgument, "sm2: unsupported symmetric cipher <%v>", symAlgId.Algorithm)
 	}
 
 	// parse public key
@@ -111,7 +109,7 @@ func ParseEnvelopedPrivateKey(priv *PrivateKey, enveloped []byte) (*PrivateKey,
 	}
 
 	// decrypt symmetric cipher key
-	key, err := priv.Decrypt(rand.Reader, symEncryptedKey, nil)
+	key, err := priv.Decrypt(randutil.Reader(), symEncryptedKey, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -131,7 +129,7 @@ func ParseEnvelopedPrivateKey(priv *PrivateKey, enveloped []byte) (*PrivateKey,
 		return nil, err
 	}
 	if !sm2Key.PublicKey.Equal(pubKey) {
-		return nil, errors.New("sm2: mismatch key pair in enveloped data")
+		return nil, newError(ErrInvalidPrivateKey, "sm2: mismatch key pair in enveloped data")
 	}
 
 	return sm2Key, nil
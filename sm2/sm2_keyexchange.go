@@ -3,7 +3,6 @@ package sm2
 import (
 	"crypto/ecdsa"
 	"crypto/subtle"
-	"errors"
 	"io"
 	"math/big"
 
@@ -114,11 +113,11 @@ func (ke *KeyExchange) SetPeerParameters(peerPub *ecdsa.PublicKey, peerUID []byt
 		peerUID = defaultUID
 	}
 	if ke.peerPub != nil {
-		return errors.New("sm2: 'peerPub' already exists, please do not set it")
+		return newError(ErrInvalidArgument, "sm2: 'peerPub' already exists, please do not set it")
 	}
 
 	if peerPub.Curve != ke.privateKey.Curve {
-		return errors.New("sm2: peer public key is not expected/supported")
+		return newError(ErrInvalidPublicKey, "sm2: peer public key is not expected/supported")
 	}
 
 	var err error
@@ -218,10 +217,10 @@ func (ke *KeyExchange) mqv() {
 
 func respondKeyExchange(ke *KeyExchange, rA *ecdsa.PublicKey, r *big.Int) (*ecdsa.PublicKey, []byte, error) {
 	if ke.peerPub == nil {
-		return nil, nil, errors.New("sm2: no peer public key given")
+		return nil, nil, newError(ErrInvalidArgument, "sm2: no peer public key given")
 	}
 	if !ke.privateKey.IsOnCurve(rA.X, rA.Y) {
-		return nil, nil, errors.New("sm2: invalid initiator's ephemeral public key")
+		return nil, nil, newError(ErrInvalidPublicKey, "sm2: invalid initiator's ephemeral public key")
 	}
 	ke.peerSecret = rA
 	// secret = RB = [r]G
@@ -230,7 +229,7 @@ func respondKeyExchange(ke *KeyExchange, rA *ecdsa.PublicKey, r *big.Int) (*ecds
 
 	ke.mqv()
 	if ke.v.X.Sign() == 0 && ke.v.Y.Sign() == 0 {
-		return nil, nil, errors.New("sm2: key exchange failed, V is infinity point")
+		return nil, nil, newError(ErrKeyExchange, "sm2: key exchange failed, V is infinity point")
 	}
 
 	if !ke.genSignature {
@@ -260,22 +259,22 @@ func (ke *KeyExchange) RepondKeyExchange(rand io.Reader, rA *ecdsa.PublicKey) (*
 // signature and return generated signature depends on KeyExchange.genSignature value.
 func (ke *KeyExchange) ConfirmResponder(rB *ecdsa.PublicKey, sB []byte) ([]byte, []byte, error) {
 	if ke.peerPub == nil {
-		return nil, nil, errors.New("sm2: no peer public key given")
+		return nil, nil, newError(ErrInvalidArgument, "sm2: no peer public key given")
 	}
 	if !ke.privateKey.IsOnCurve(rB.X, rB.Y) {
-		return nil, nil, errors.New("sm2: invalid responder's ephemeral public key")
+		return nil, nil, newError(ErrInvalidPublicKey, "sm2: invalid responder's ephemeral public key")
 	}
 	ke.peerSecret = rB
 
 	ke.mqv()
 	if ke.v.X.Sign() == 0 && ke.v.Y.Sign() == 0 {
-		return nil, nil, errors.New("sm2: key exchange failed, U is infinity point")
+		return nil, nil, newError(ErrKeyExchange, "sm2: key exchange failed, U is infinity point")
 	}
 
 	if len(sB) > 0 {
 		buffer := ke.sign(false, 0x02)
 		if subtle.ConstantTimeCompare(buffer, sB) != 1 {
-			return nil, nil, errors.New("sm2: invalid responder's signature")
+			return nil, nil, newError(ErrKeyExchange, "sm2: invalid responder's signature")
 		}
 	}
 	key, err := ke.generateSharedKey(false)
@@ -294,7 +293,7 @@ func (ke *KeyExchange) ConfirmInitiator(s1 []byte) ([]byte, error) {
 	if s1 != nil {
 		buffer := ke.sign(true, 0x03)
 		if subtle.ConstantTimeCompare(buffer, s1) != 1 {
-			return nil, errors.New("sm2: invalid initiator's signature")
+			return nil, newError(ErrKeyExchange, "sm2: invalid initiator's signature")
 		}
 	}
 	return ke.generateSharedKey(true)
@@ -4,12 +4,11 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	_subtle "crypto/subtle"
-	"errors"
-	"fmt"
 	"io"
 	"math/big"
 	"strings"
 
+	"github.com/emmansun/gmsm/internal/impl"
 	"github.com/emmansun/gmsm/internal/subtle"
 	"github.com/emmansun/gmsm/kdf"
 	"github.com/emmansun/gmsm/sm2/sm2ec"
@@ -21,6 +20,14 @@ import (
 // This file contains a math/big implementation of SM2 DSA/Encryption that is only used for
 // deprecated custom curves.
 
+// legacyPath is the variable-time code path of this file, signing, encryption and
+// decryption are refused once constant time is required.
+const legacyPath = "sm2: custom curves (math/big)"
+
+func init() {
+	impl.RegisterVariableTimePath(legacyPath)
+}
+
 // A invertible implements fast inverse in GF(N).
 type invertible interface {
 	// Inverse returns the inverse of k mod Params().N.
@@ -51,7 +58,7 @@ func hashToInt(hash []byte, c elliptic.Curve) *big.Int {
 	return ret
 }
 
-var errZeroParam = errors.New("zero parameter")
+var errZeroParam = newError(ErrInvalidPrivateKey, "zero parameter")
 
 // Sign signs a hash (which should be the result of hashing a larger message)
 // using the private key, priv. If the hash is longer than the bit-length of the
@@ -76,12 +83,15 @@ func Sign(rand io.Reader, priv *ecdsa.PrivateKey, hash []byte) (r, s *big.Int, e
 		!inner.ReadASN1Integer(r) ||
 		!inner.ReadASN1Integer(s) ||
 		!inner.Empty() {
-		return nil, nil, errors.New("invalid ASN.1 from SignASN1")
+		return nil, nil, newError(ErrInvalidSignature, "invalid ASN.1 from SignASN1")
 	}
 	return r, s, nil
 }
 
 func signLegacy(priv *PrivateKey, rand io.Reader, hash []byte) (sig []byte, err error) {
+	if err := impl.CheckPath(legacyPath); err != nil {
+		return nil, err
+	}
 	// See [NSA] 3.4.1
 	c := priv.PublicKey.Curve
 	N := c.Params().N
@@ -241,6 +251,9 @@ func randFieldElement(c elliptic.Curve, rand io.Reader) (k *big.Int, err error)
 }
 
 func encryptLegacy(random io.Reader, pub *ecdsa.PublicKey, msg []byte, opts *EncrypterOpts) ([]byte, error) {
+	if err := impl.CheckPath(legacyPath); err != nil {
+		return nil, err
+	}
 	curve := pub.Curve
 	msgLen := len(msg)
 
@@ -264,7 +277,7 @@ func encryptLegacy(random io.Reader, pub *ecdsa.PublicKey, msg []byte, opts *Enc
 		if subtle.ConstantTimeAllZero(c2) {
 			retryCount++
 			if retryCount > maxRetryLimit {
-				return nil, fmt.Errorf("sm2: A5, failed to calculate valid t, tried %v times", retryCount)
+				return nil, errorf(ErrRetryLimit, "sm2: A5, failed to calculate valid t, tried %v times", retryCount)
 			}
 			continue
 		}
@@ -317,6 +330,9 @@ func ASN1Ciphertext2Plain(ciphertext []byte, opts *EncrypterOpts) ([]byte, error
 		return nil, err
 	}
 	curve := sm2ec.P256()
+	if !curve.IsOnCurve(x1, y1) {
+		return nil, errorf(ErrPointNotOnCurve, "sm2: point is not on curve %s", curve.Params().Name)
+	}
 	c1 := opts.pointMarshalMode.mashal(curve, x1, y1)
 	if opts.ciphertextSplicingOrder == C1C3C2 {
 		// c1 || c3 || c2
@@ -327,9 +343,9 @@ func ASN1Ciphertext2Plain(ciphertext []byte, opts *EncrypterOpts) ([]byte, error
 }
 
 // PlainCiphertext2ASN1 utility method to convert plain encoding ciphertext to ASN.1 encoding format
-func PlainCiphertext2ASN1(ciphertext []byte, from ciphertextSplicingOrder) ([]byte, error) {
-	if ciphertext[0] == 0x30 {
-		return nil, errors.New("sm2: invalid plain encoding ciphertext")
+func PlainCiphertext2ASN1(ciphertext []byte, from CiphertextSplicingOrder) ([]byte, error) {
+	if from != C1C3C2 && from != C1C2C3 {
+		return nil, newError(ErrInvalidArgument, "sm2: unknown ciphertext splicing order")
 	}
 	curve := sm2ec.P256()
 	ciphertextLen := len(ciphertext)
@@ -341,6 +357,9 @@ func PlainCiphertext2ASN1(ciphertext []byte, from ciphertextSplicingOrder) ([]by
 	if err != nil {
 		return nil, err
 	}
+	if ciphertextLen <= c3Start+sm3.Size {
+		return nil, errCiphertextTooShort
+	}
 
 	var c2, c3 []byte
 
@@ -355,7 +374,7 @@ func PlainCiphertext2ASN1(ciphertext []byte, from ciphertextSplicingOrder) ([]by
 }
 
 // AdjustCiphertextSplicingOrder utility method to change c2 c3 order
-func AdjustCiphertextSplicingOrder(ciphertext []byte, from, to ciphertextSplicingOrder) ([]byte, error) {
+func AdjustCiphertextSplicingOrder(ciphertext []byte, from, to CiphertextSplicingOrder) ([]byte, error) {
 	curve := sm2ec.P256()
 	if from == to {
 		return ciphertext, nil
@@ -370,6 +389,9 @@ func AdjustCiphertextSplicingOrder(ciphertext []byte, from, to ciphertextSplicin
 	if err != nil {
 		return nil, err
 	}
+	if ciphertextLen <= c3Start+sm3.Size {
+		return nil, errCiphertextTooShort
+	}
 
 	var c1, c2, c3 []byte
 
@@ -424,6 +446,9 @@ func rawDecrypt(priv *PrivateKey, x1, y1 *big.Int, c2, c3 []byte) ([]byte, error
 }
 
 func decryptLegacy(priv *PrivateKey, ciphertext []byte, opts *DecrypterOpts) ([]byte, error) {
+	if err := impl.CheckPath(legacyPath); err != nil {
+		return nil, err
+	}
 	splicingOrder := C1C3C2
 	if opts != nil {
 		if opts.ciphertextEncoding == ENCODING_ASN1 {
@@ -431,6 +456,7 @@ func decryptLegacy(priv *PrivateKey, ciphertext []byte, opts *DecrypterOpts) ([]
 		}
 		splicingOrder = opts.cipherTextSplicingOrder
 	}
+	// A plain C1 never starts with 0x30, so the guess is unambiguous.
 	if ciphertext[0] == 0x30 {
 		return decryptASN1(priv, ciphertext)
 	}
@@ -457,39 +483,39 @@ func decryptLegacy(priv *PrivateKey, ciphertext []byte, opts *DecrypterOpts) ([]
 
 func bytes2Point(curve elliptic.Curve, bytes []byte) (*big.Int, *big.Int, int, error) {
 	if len(bytes) < 1+(curve.Params().BitSize/8) {
-		return nil, nil, 0, fmt.Errorf("sm2: invalid bytes length %d", len(bytes))
+		return nil, nil, 0, errorf(ErrPointNotOnCurve, "sm2: invalid bytes length %d", len(bytes))
 	}
 	format := bytes[0]
 	byteLen := (curve.Params().BitSize + 7) >> 3
 	switch format {
 	case uncompressed, hybrid06, hybrid07: // what's the hybrid format purpose?
 		if len(bytes) < 1+byteLen*2 {
-			return nil, nil, 0, fmt.Errorf("sm2: invalid point uncompressed/hybrid form bytes length %d", len(bytes))
+			return nil, nil, 0, errorf(ErrPointNotOnCurve, "sm2: invalid point uncompressed/hybrid form bytes length %d", len(bytes))
 		}
 		data := make([]byte, 1+byteLen*2)
 		data[0] = uncompressed
 		copy(data[1:], bytes[1:1+byteLen*2])
 		x, y := sm2ec.Unmarshal(curve, data)
 		if x == nil || y == nil {
-			return nil, nil, 0, fmt.Errorf("sm2: point is not on curve %s", curve.Params().Name)
+			return nil, nil, 0, errorf(ErrPointNotOnCurve, "sm2: point is not on curve %s", curve.Params().Name)
 		}
 		return x, y, 1 + byteLen*2, nil
 	case compressed02, compressed03:
 		if len(bytes) < 1+byteLen {
-			return nil, nil, 0, fmt.Errorf("sm2: invalid point compressed form bytes length %d", len(bytes))
+			return nil, nil, 0, errorf(ErrPointNotOnCurve, "sm2: invalid point compressed form bytes length %d", len(bytes))
 		}
 		// Make sure it's NIST curve or SM2 P-256 curve
 		if strings.HasPrefix(curve.Params().Name, "P-") || strings.EqualFold(curve.Params().Name, sm2ec.P256().Params().Name) {
 			// y² = x³ - 3x + b, prime curves
 			x, y := sm2ec.UnmarshalCompressed(curve, bytes[:1+byteLen])
 			if x == nil || y == nil {
-				return nil, nil, 0, fmt.Errorf("sm2: point is not on curve %s", curve.Params().Name)
+				return nil, nil, 0, errorf(ErrPointNotOnCurve, "sm2: point is not on curve %s", curve.Params().Name)
 			}
 			return x, y, 1 + byteLen, nil
 		}
-		return nil, nil, 0, fmt.Errorf("sm2: unsupport point form %d, curve %s", format, curve.Params().Name)
+		return nil, nil, 0, errorf(ErrPointNotOnCurve, "sm2: unsupport point form %d, curve %s", format, curve.Params().Name)
 	}
-	return nil, nil, 0, fmt.Errorf("sm2: unknown point form %d", format)
+	return nil, nil, 0, errorf(ErrPointNotOnCurve, "sm2: unknown point form %d", format)
 }
 
 func (mode pointMarshalMode) mashal(curve elliptic.Curve, x, y *big.Int) []byte {
@@ -0,0 +1,240 @@
+package sm2
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"io"
+	"time"
+
+	"github.com/emmansun/gmsm/sm3"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// This file implements proxy signatures by delegation by warrant, see Mambo,
+// Usuda and Okamoto, "Proxy Signatures for Delegating Signing Operation" (CCS
+// 1996). The original signer signs a warrant which names the proxy public key,
+// a validity period and a scope. The proxy signs messages with its own key,
+// bound to the warrant, so a proxy signature is verified against both
+// identities: the warrant must be signed by the original signer and the
+// message by the proxy named in it. It is not a split-key scheme, the proxy
+// signs alone once it holds the warrant.
+//
+// The warrant has the ASN.1 format:
+//
+//	ProxyWarrant ::= SEQUENCE {
+//	  tbsWarrant ::= SEQUENCE {
+//	    version         INTEGER (0),
+//	    originalSigner  OCTET STRING, -- uncompressed sm2 point
+//	    proxySigner     OCTET STRING, -- uncompressed sm2 point
+//	    notBefore       GeneralizedTime,
+//	    notAfter        GeneralizedTime,
+//	    scope           OCTET STRING
+//	  },
+//	  signature  OCTET STRING -- sm2 signature of tbsWarrant by originalSigner
+//	}
+//
+// The proxy signature is the sm2 signature by the proxy signer of
+//
+//	SM3(Z_proxy || domain || SM3(tbsWarrant) || msg)
+
+var proxySignatureDomain = []byte("SM2-PROXY-SIGNATURE")
+
+const proxyWarrantVersion = 0
+
+// ProxyWarrant delegates the signing right of OriginalSigner to Proxy.
+type ProxyWarrant struct {
+	OriginalSigner *ecdsa.PublicKey
+	Proxy          *ecdsa.PublicKey
+	NotBefore      time.Time
+	NotAfter       time.Time
+	// Scope describes the messages the proxy may sign, such as the document
+	// types, it is opaque to this package.
+	Scope []byte
+	// Signature is the signature of the original signer, set by
+	// IssueProxyWarrant and ParseProxyWarrant.
+	Signature []byte
+
+	raw []byte // tbsWarrant
+}
+
+// IssueProxyWarrant creates a warrant signed by original, delegating its
+// signing right to proxy from notBefore to notAfter for scope.
+func IssueProxyWarrant(rand io.Reader, original *PrivateKey, proxy *ecdsa.PublicKey, notBefore, notAfter time.Time, scope []byte) (*ProxyWarrant, error) {
+	if original == nil || original.Curve != P256() {
+		return nil, newError(ErrInvalidPrivateKey, "sm2: proxy warrant requires a sm2 private key")
+	}
+	if proxy == nil || proxy.Curve != P256() || !proxy.Curve.IsOnCurve(proxy.X, proxy.Y) {
+		return nil, newError(ErrInvalidPublicKey, "sm2: proxy warrant requires a sm2 proxy public key")
+	}
+	if !notBefore.Before(notAfter) {
+		return nil, newError(ErrInvalidArgument, "sm2: proxy warrant expires before it is valid")
+	}
+	w := &ProxyWarrant{
+		OriginalSigner: &original.PublicKey,
+		Proxy:          proxy,
+		NotBefore:      notBefore.UTC().Truncate(time.Second),
+		NotAfter:       notAfter.UTC().Truncate(time.Second),
+		Scope:          append([]byte(nil), scope...),
+	}
+	raw, err := w.marshalTBS()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := SignASN1(rand, original, raw, DefaultSM2SignerOpts)
+	if err != nil {
+		return nil, err
+	}
+	w.raw = raw
+	w.Signature = sig
+	return w, nil
+}
+
+func (w *ProxyWarrant) marshalTBS() ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1Int64(proxyWarrantVersion)
+		b.AddASN1OctetString(elliptic.Marshal(w.OriginalSigner.Curve, w.OriginalSigner.X, w.OriginalSigner.Y))
+		b.AddASN1OctetString(elliptic.Marshal(w.Proxy.Curve, w.Proxy.X, w.Proxy.Y))
+		b.AddASN1GeneralizedTime(w.NotBefore)
+		b.AddASN1GeneralizedTime(w.NotAfter)
+		b.AddASN1OctetString(w.Scope)
+	})
+	return b.Bytes()
+}
+
+// tbs returns the signed part of the warrant.
+func (w *ProxyWarrant) tbs() ([]byte, error) {
+	if w.raw != nil {
+		return w.raw, nil
+	}
+	if w.OriginalSigner == nil || w.Proxy == nil {
+		return nil, newError(ErrInvalidWarrant, "sm2: proxy warrant without signer")
+	}
+	return w.marshalTBS()
+}
+
+// Marshal returns the ASN.1 encoding of the signed warrant.
+func (w *ProxyWarrant) Marshal() ([]byte, error) {
+	raw, err := w.tbs()
+	if err != nil {
+		return nil, err
+	}
+	if len(w.Signature) == 0 {
+		return nil, newError(ErrInvalidWarrant, "sm2: proxy warrant is not signed")
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddBytes(raw)
+		b.AddASN1OctetString(w.Signature)
+	})
+	return b.Bytes()
+}
+
+// ParseProxyWarrant parses an ASN.1 encoded warrant. It does not verify the
+// warrant, call Verify before trusting it.
+func ParseProxyWarrant(der []byte) (*ProxyWarrant, error) {
+	var (
+		inner, tbs      cryptobyte.String
+		version         int64
+		original, proxy []byte
+		w               ProxyWarrant
+	)
+	input := cryptobyte.String(der)
+	if !input.ReadASN1(&inner, asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1Element(&tbs, asn1.SEQUENCE) ||
+		!inner.ReadASN1Bytes(&w.Signature, asn1.OCTET_STRING) || !inner.Empty() {
+		return nil, newError(ErrInvalidWarrant, "sm2: malformed proxy warrant")
+	}
+	w.raw = append([]byte(nil), tbs...)
+	if !tbs.ReadASN1(&inner, asn1.SEQUENCE) ||
+		!inner.ReadASN1Integer(&version) ||
+		!inner.ReadASN1Bytes(&original, asn1.OCTET_STRING) ||
+		!inner.ReadASN1Bytes(&proxy, asn1.OCTET_STRING) ||
+		!inner.ReadASN1GeneralizedTime(&w.NotBefore) ||
+		!inner.ReadASN1GeneralizedTime(&w.NotAfter) ||
+		!inner.ReadASN1Bytes(&w.Scope, asn1.OCTET_STRING) || !inner.Empty() {
+		return nil, newError(ErrInvalidWarrant, "sm2: malformed proxy warrant")
+	}
+	if version != proxyWarrantVersion {
+		return nil, errorf(ErrInvalidWarrant, "sm2: unsupported proxy warrant version %d", version)
+	}
+	var err error
+	if w.OriginalSigner, err = NewPublicKey(original); err != nil {
+		return nil, errorf(ErrInvalidWarrant, "sm2: invalid original signer in proxy warrant: %v", err)
+	}
+	if w.Proxy, err = NewPublicKey(proxy); err != nil {
+		return nil, errorf(ErrInvalidWarrant, "sm2: invalid proxy signer in proxy warrant: %v", err)
+	}
+	return &w, nil
+}
+
+// Verify checks that the warrant is signed by OriginalSigner and valid at now.
+func (w *ProxyWarrant) Verify(now time.Time) error {
+	raw, err := w.tbs()
+	if err != nil {
+		return err
+	}
+	if !VerifyASN1WithSM2(w.OriginalSigner, nil, raw, w.Signature) {
+		return newError(ErrInvalidWarrant, "sm2: invalid proxy warrant signature")
+	}
+	if now.Before(w.NotBefore) || now.After(w.NotAfter) {
+		return newError(ErrInvalidWarrant, "sm2: proxy warrant is not valid at the given time")
+	}
+	return nil
+}
+
+// proxyDigest returns the digest signed by the proxy.
+func proxyDigest(w *ProxyWarrant, msg []byte) ([]byte, error) {
+	raw, err := w.tbs()
+	if err != nil {
+		return nil, err
+	}
+	za, err := CalculateZA(w.Proxy, defaultUID)
+	if err != nil {
+		return nil, err
+	}
+	warrantHash := sm3.Sum(raw)
+	md := sm3.New()
+	md.Write(za)
+	md.Write(proxySignatureDomain)
+	md.Write(warrantHash[:])
+	md.Write(msg)
+	return md.Sum(nil), nil
+}
+
+// SignAsProxy signs msg with the proxy private key on behalf of the original
+// signer of w. The proxy key must be the one named by the warrant, the warrant
+// itself is not verified.
+func SignAsProxy(rand io.Reader, proxy *PrivateKey, w *ProxyWarrant, msg []byte) ([]byte, error) {
+	if proxy == nil || proxy.Curve != P256() {
+		return nil, newError(ErrInvalidPrivateKey, "sm2: proxy signature requires a sm2 private key")
+	}
+	if w == nil || w.Proxy == nil || !proxy.PublicKey.Equal(w.Proxy) {
+		return nil, newError(ErrInvalidWarrant, "sm2: proxy key is not the one named by the warrant")
+	}
+	digest, err := proxyDigest(w, msg)
+	if err != nil {
+		return nil, err
+	}
+	return SignASN1(rand, proxy, digest, nil)
+}
+
+// VerifyProxySignature checks that sig is a signature of msg by the proxy of
+// the warrant w and that w is a valid warrant of its original signer at now.
+func VerifyProxySignature(w *ProxyWarrant, msg, sig []byte, now time.Time) error {
+	if w == nil {
+		return newError(ErrInvalidWarrant, "sm2: no proxy warrant")
+	}
+	if err := w.Verify(now); err != nil {
+		return err
+	}
+	digest, err := proxyDigest(w, msg)
+	if err != nil {
+		return err
+	}
+	if !VerifyASN1(w.Proxy, digest, sig) {
+		return newError(ErrInvalidSignature, "sm2: invalid proxy signature")
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProxySignature(t *testing.T) {
+	original, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	w, err := IssueProxyWarrant(rand.Reader, original, &proxy.PublicKey, now.Add(-time.Hour), now.Add(time.Hour), []byte("purchase orders"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := w.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseProxyWarrant(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.OriginalSigner.Equal(&original.PublicKey) || !parsed.Proxy.Equal(&proxy.PublicKey) ||
+		!parsed.NotBefore.Equal(w.NotBefore) || !parsed.NotAfter.Equal(w.NotAfter) || string(parsed.Scope) != "purchase orders" {
+		t.Fatalf("parsed warrant mismatch")
+	}
+
+	msg := []byte("order #42")
+	sig, err := SignAsProxy(rand.Reader, proxy, parsed, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = VerifyProxySignature(w, msg, sig, now); err != nil {
+		t.Errorf("VerifyProxySignature failed: %v", err)
+	}
+	if err = VerifyProxySignature(w, []byte("order #43"), sig, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("got %v, expected ErrInvalidSignature", err)
+	}
+	if err = VerifyProxySignature(w, msg, sig, now.Add(2*time.Hour)); !errors.Is(err, ErrInvalidWarrant) {
+		t.Errorf("got %v, expected ErrInvalidWarrant for expired warrant", err)
+	}
+	// a plain signature of the proxy is not a proxy signature
+	plain, err := proxy.Sign(rand.Reader, msg, DefaultSM2SignerOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = VerifyProxySignature(w, msg, plain, now); err == nil {
+		t.Errorf("plain signature accepted as proxy signature")
+	}
+	// the original signer can't sign as the proxy
+	if _, err = SignAsProxy(rand.Reader, original, w, msg); !errors.Is(err, ErrInvalidWarrant) {
+		t.Errorf("got %v, expected ErrInvalidWarrant", err)
+	}
+
+	// a forged warrant for another proxy
+	other, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := *parsed
+	forged.raw = nil
+	forged.Proxy = &other.PublicKey
+	if err = forged.Verify(now); !errors.Is(err, ErrInvalidWarrant) {
+		t.Errorf("got %v, expected ErrInvalidWarrant for forged warrant", err)
+	}
+	forgedSig, err := SignAsProxy(rand.Reader, other, &forged, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = VerifyProxySignature(&forged, msg, forgedSig, now); err == nil {
+		t.Errorf("forged warrant accepted")
+	}
+}
+
+func TestParseProxyWarrantInvalid(t *testing.T) {
+	original, _ := GenerateKey(rand.Reader)
+	proxy, _ := GenerateKey(rand.Reader)
+	now := time.Now()
+	if _, err := IssueProxyWarrant(rand.Reader, original, &proxy.PublicKey, now, now, nil); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("got %v, expected ErrInvalidArgument", err)
+	}
+	w, err := IssueProxyWarrant(rand.Reader, original, &proxy.PublicKey, now, now.Add(time.Minute), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := w.Marshal()
+	for _, data := range [][]byte{nil, der[:len(der)-1], append(der, 0)} {
+		if _, err := ParseProxyWarrant(data); !errors.Is(err, ErrInvalidWarrant) {
+			t.Errorf("got %v, expected ErrInvalidWarrant", err)
+		}
+	}
+}
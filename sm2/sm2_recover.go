@@ -0,0 +1,92 @@
+package sm2
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/emmansun/gmsm/internal/bigmod"
+	_sm2ec "github.com/emmansun/gmsm/internal/sm2ec"
+)
+
+// This file implements the public key recovery of sm2 signatures, as used by
+// blockchains which transmit the recovery id instead of the public key. The
+// verifier computes R = [s]G + [t]Q with t = r + s, and the x-coordinate of R
+// is x1 = r - e (mod n), so
+//
+//	Q = [t⁻¹](R - [s]G)
+//
+// where R is one of the up to four points with the x-coordinate x1 or x1 + n.
+// The recovery id v selects it: bit 0 is the parity of the y-coordinate of R
+// and bit 1 is set if the x-coordinate is x1 + n, which is very unlikely.
+
+// RecoverPublicKey recovers the public key from the ASN.1 encoded signature
+// sig of hash, hash is used as e, like SignASN1 without opts does, and the
+// recovery id v, from 0 to 3.
+func RecoverPublicKey(hash, sig []byte, v byte) (*ecdsa.PublicKey, error) {
+	if v > 3 {
+		return nil, newError(ErrInvalidSignature, "sm2: invalid recovery id")
+	}
+	rBytes, sBytes, err := parseSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	c := p256()
+	r, err := bigmod.NewNat().SetBytes(rBytes, c.N)
+	if err != nil || r.IsZero() == 1 {
+		return nil, ErrInvalidSignature
+	}
+	s, err := bigmod.NewNat().SetBytes(sBytes, c.N)
+	if err != nil || s.IsZero() == 1 {
+		return nil, ErrInvalidSignature
+	}
+	// t = [r + s]
+	t := bigmod.NewNat().Set(r).Add(s, c.N)
+	if t.IsZero() == 1 {
+		return nil, ErrInvalidSignature
+	}
+
+	e := bigmod.NewNat()
+	hashToNat(c, e, hash)
+	// x₁ = [r - e], plus n if v says so
+	x1 := new(big.Int).SetBytes(bigmod.NewNat().Set(r).Sub(e, c.N).Bytes(c.N))
+	if v&2 != 0 {
+		x1.Add(x1, c.curve.Params().N)
+		if x1.Cmp(c.curve.Params().P) >= 0 {
+			return nil, ErrInvalidSignature
+		}
+	}
+	compressed := make([]byte, 33)
+	compressed[0] = 2 | v&1
+	x1.FillBytes(compressed[1:])
+	R, err := c.newPoint().SetBytes(compressed)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	// p₁ = [-s]G
+	negS := bigmod.NewNat().ExpandFor(c.N).Sub(s, c.N)
+	p1, err := c.newPoint().ScalarBaseMult(negS.Bytes(c.N))
+	if err != nil {
+		return nil, err
+	}
+	tInv, err := _sm2ec.P256OrdInverse(t.Bytes(c.N))
+	if err != nil {
+		return nil, err
+	}
+	Q, err := R.ScalarMult(R.Add(R, p1), tInv)
+	if err != nil {
+		return nil, err
+	}
+	return sm2PublicKey(c, Q)
+}
+
+// RecoveryID returns the recovery id of the valid ASN.1 encoded signature sig
+// of hash by pub, so that RecoverPublicKey(hash, sig, v) returns pub.
+func RecoveryID(pub *ecdsa.PublicKey, hash, sig []byte) (byte, error) {
+	for v := byte(0); v < 4; v++ {
+		if key, err := RecoverPublicKey(hash, sig, v); err == nil && key.Equal(pub) {
+			return v, nil
+		}
+	}
+	return 0, newError(ErrInvalidSignature, "sm2: signature is not from the public key")
+}
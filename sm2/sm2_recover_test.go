@@ -0,0 +1,67 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm3"
+)
+
+func TestRecoverPublicKey(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		priv, err := GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash := sm3.Sum([]byte{byte(i)})
+		sig, err := SignASN1(rand.Reader, priv, hash[:], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := RecoveryID(&priv.PublicKey, hash[:], sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := RecoverPublicKey(hash[:], sig, v)
+		if err != nil || !pub.Equal(&priv.PublicKey) {
+			t.Fatalf("got %v, %v", pub, err)
+		}
+		if !VerifyASN1(pub, hash[:], sig) {
+			t.Error("recovered key doesn't verify the signature")
+		}
+		// the other parity gives a different key
+		if other, err := RecoverPublicKey(hash[:], sig, v^1); err == nil && other.Equal(pub) {
+			t.Error("both parities recover the same key")
+		}
+		hash[0] ^= 1
+		if other, err := RecoverPublicKey(hash[:], sig, v); err == nil && other.Equal(pub) {
+			t.Error("recovered the key from another hash")
+		}
+	}
+}
+
+func TestRecoverPublicKeyInvalid(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sm3.Sum([]byte("hello"))
+	sig, err := SignASN1(rand.Reader, priv, hash[:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RecoverPublicKey(hash[:], sig, 4); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("got %v, want ErrInvalidSignature", err)
+	}
+	if _, err := RecoverPublicKey(hash[:], sig[:len(sig)-1], 0); err == nil {
+		t.Error("expected error with a truncated signature")
+	}
+	if _, err := RecoveryID(&other.PublicKey, hash[:], sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("got %v, want ErrInvalidSignature", err)
+	}
+}
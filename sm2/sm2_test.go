@@ -26,18 +26,18 @@ func TestNewPrivateKey(t *testing.T) {
 	// test all zero
 	key := make([]byte, c.N.Size())
 	_, err = NewPrivateKey(key)
-	if err == nil || err != errInvalidPrivateKey {
-		t.Errorf("should throw errInvalidPrivateKey")
+	if err == nil || err != ErrInvalidPrivateKey {
+		t.Errorf("should throw ErrInvalidPrivateKey")
 	}
 	// test N-1
 	_, err = NewPrivateKey(c.nMinus1.Bytes(c.N))
-	if err == nil || err != errInvalidPrivateKey {
-		t.Errorf("should throw errInvalidPrivateKey")
+	if err == nil || err != ErrInvalidPrivateKey {
+		t.Errorf("should throw ErrInvalidPrivateKey")
 	}
 	// test N
 	_, err = NewPrivateKey(P256().Params().N.Bytes())
-	if err == nil || err != errInvalidPrivateKey {
-		t.Errorf("should throw errInvalidPrivateKey")
+	if err == nil || err != ErrInvalidPrivateKey {
+		t.Errorf("should throw ErrInvalidPrivateKey")
 	}
 	// test 1
 	key[31] = 1
@@ -65,21 +65,21 @@ func TestNewPrivateKeyFromInt(t *testing.T) {
 	}
 	// test N
 	_, err = NewPrivateKeyFromInt(P256().Params().N)
-	if err == nil || err != errInvalidPrivateKey {
-		t.Errorf("should throw errInvalidPrivateKey")
+	if err == nil || err != ErrInvalidPrivateKey {
+		t.Errorf("should throw ErrInvalidPrivateKey")
 	}
 
 	// test N + 1
 	_, err = NewPrivateKeyFromInt(new(big.Int).Add(P256().Params().N, big.NewInt(1)))
-	if err == nil || err != errInvalidPrivateKey {
-		t.Errorf("should throw errInvalidPrivateKey")
+	if err == nil || err != ErrInvalidPrivateKey {
+		t.Errorf("should throw ErrInvalidPrivateKey")
 	}
 
 	c := p256()
 	// test N - 1
 	_, err = NewPrivateKeyFromInt(new(big.Int).SetBytes(c.nMinus1.Bytes(c.N)))
-	if err == nil || err != errInvalidPrivateKey {
-		t.Errorf("should throw errInvalidPrivateKey")
+	if err == nil || err != ErrInvalidPrivateKey {
+		t.Errorf("should throw ErrInvalidPrivateKey")
 	}
 }
 
@@ -114,8 +114,8 @@ func TestSplicingOrder(t *testing.T) {
 	tests := []struct {
 		name      string
 		plainText string
-		from      ciphertextSplicingOrder
-		to        ciphertextSplicingOrder
+		from      CiphertextSplicingOrder
+		to        CiphertextSplicingOrder
 	}{
 		// TODO: Add test cases.
 		{"less than 32 1", "encryption standard", C1C2C3, C1C3C2},
@@ -194,6 +194,18 @@ func TestEncryptDecryptASN1(t *testing.T) {
 			if !reflect.DeepEqual(string(plaintext), tt.plainText) {
 				t.Errorf("Decrypt() = %v, want %v", string(plaintext), tt.plainText)
 			}
+			// plain options still detect ASN.1 ciphertexts
+			plaintext, err = tt.priv.Decrypt(rand.Reader, ciphertext, NewPlainDecrypterOpts(C1C3C2))
+			if err != nil || string(plaintext) != tt.plainText {
+				t.Errorf("%v ASN.1 ciphertext with plain options: %v", tt.priv.Curve.Params().Name, err)
+			}
+			plain, err := Encrypt(rand.Reader, &tt.priv.PublicKey, []byte(tt.plainText), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = tt.priv.Decrypt(rand.Reader, plain, ASN1DecrypterOpts); err == nil {
+				t.Errorf("%v plain ciphertext decrypted as ASN.1", tt.priv.Curve.Params().Name)
+			}
 		})
 	}
 }
@@ -232,6 +244,40 @@ func TestAdjustCiphertextSplicingOrder(t *testing.T) {
 	}
 }
 
+// crashers found by fuzzing/testdata/fuzz/FuzzSM2Ciphertext
+func TestCiphertextConversionCrashers(t *testing.T) {
+	offCurve, err := mashalASN1Ciphertext(big.NewInt(1), big.NewInt(1), []byte{1}, make([]byte, sm3.Size))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		ciphertext string
+	}{
+		{"empty", ""},
+		{"c1 and one byte", "04539bad3351328fe8d89bc7ab9d75ad43f5073acaf0c25e85bb051662051248907c3ce33f3c9b0028326fdc7251deebab8aa5cfd497591a7e27205df70c179c9230"},
+		{"asn1 point not on curve", hex.EncodeToString(offCurve)},
+	}
+	for _, tt := range tests {
+		ciphertext, err := hex.DecodeString(tt.ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = PlainCiphertext2ASN1(ciphertext, C1C3C2); err == nil {
+			t.Errorf("%s: PlainCiphertext2ASN1(C1C3C2) should be failed", tt.name)
+		}
+		if _, err = PlainCiphertext2ASN1(ciphertext, C1C2C3); err == nil {
+			t.Errorf("%s: PlainCiphertext2ASN1(C1C2C3) should be failed", tt.name)
+		}
+		if _, err = AdjustCiphertextSplicingOrder(ciphertext, C1C3C2, C1C2C3); err == nil {
+			t.Errorf("%s: AdjustCiphertextSplicingOrder should be failed", tt.name)
+		}
+		if _, err = ASN1Ciphertext2Plain(ciphertext, nil); err == nil {
+			t.Errorf("%s: ASN1Ciphertext2Plain should be failed", tt.name)
+		}
+	}
+}
+
 func TestCiphertext2ASN1(t *testing.T) {
 	priv, _ := GenerateKey(rand.Reader)
 	tests := []struct {
@@ -435,7 +481,7 @@ func TestPrivateKeyPlus1WithOrderMinus1(t *testing.T) {
 	priv.PublicKey.X, priv.PublicKey.Y = P256().ScalarBaseMult(priv.D.Bytes())
 
 	_, err := priv.inverseOfPrivateKeyPlus1(p256())
-	if err == nil || err != errInvalidPrivateKey {
+	if err == nil || err != ErrInvalidPrivateKey {
 		t.Errorf("expected invalid private key error")
 	}
 }
@@ -851,3 +897,59 @@ func BenchmarkMoreThan32_P256(b *testing.B) {
 func BenchmarkMoreThan32_SM2(b *testing.B) {
 	benchmarkEncrypt(b, P256(), "encryption standard encryption standard encryption standard encryption standard encryption standard encryption standard encryption standard")
 }
+
+func TestAppendSignASN1(t *testing.T) {
+	priv, _ := GenerateKey(rand.Reader)
+	hashed := sm3.Sum([]byte("encryption standard"))
+	prefix := []byte("prefix")
+	buf := make([]byte, len(prefix), 128)
+	copy(buf, prefix)
+	out, err := AppendSignASN1(buf, rand.Reader, priv, hashed[:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out[:len(prefix)], prefix) {
+		t.Fatalf("prefix was overwritten")
+	}
+	if &out[0] != &buf[0] {
+		t.Errorf("expected the caller buffer to be reused")
+	}
+	if !VerifyASN1(&priv.PublicKey, hashed[:], out[len(prefix):]) {
+		t.Errorf("verify failed")
+	}
+}
+
+func TestAppendEncrypt(t *testing.T) {
+	priv, _ := GenerateKey(rand.Reader)
+	msg := []byte("encryption standard")
+	prefix := []byte("prefix")
+	tests := []struct {
+		encOpts *EncrypterOpts
+		decOpts *DecrypterOpts
+	}{
+		{nil, nil},
+		{ASN1EncrypterOpts, ASN1DecrypterOpts},
+		{NewPlainEncrypterOpts(MarshalCompressed, C1C2C3), NewPlainDecrypterOpts(C1C2C3)},
+	}
+	for _, tt := range tests {
+		buf := make([]byte, len(prefix), 256)
+		copy(buf, prefix)
+		out, err := AppendEncrypt(buf, rand.Reader, &priv.PublicKey, msg, tt.encOpts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out[:len(prefix)], prefix) {
+			t.Fatalf("prefix was overwritten")
+		}
+		if &out[0] != &buf[0] {
+			t.Errorf("expected the caller buffer to be reused")
+		}
+		got, err := priv.Decrypt(nil, out[len(prefix):], tt.decOpts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, msg) {
+			t.Errorf("expected %v, got %v", string(msg), string(got))
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package sm2
+
+import (
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"io"
+
+	"github.com/emmansun/gmsm/internal/bigmod"
+	_sm2ec "github.com/emmansun/gmsm/internal/sm2ec"
+	"github.com/emmansun/gmsm/kdf"
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm4"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// This file implements a t-of-n threshold encryption for designated sm2 key
+// holders. A random secret s is split with Shamir's secret sharing over the
+// scalar field of the sm2 curve, every share is sm2 encrypted to its holder,
+// and the plaintext is encrypted with SM4-GCM under a key derived from s.
+// Any t holders can decrypt their shares and hand them to a combiner, which
+// recovers s and the plaintext, less than t shares give no information on s.
+//
+// The ciphertext has the ASN.1 format:
+//
+//	SM2ThresholdCiphertext ::= SEQUENCE {
+//	  header SEQUENCE {
+//	    version    INTEGER (0),
+//	    threshold  INTEGER,
+//	    shares     SEQUENCE OF SEQUENCE {
+//	      index           INTEGER,      -- x coordinate of the share, 1..n
+//	      recipient       OCTET STRING, -- uncompressed sm2 point
+//	      encryptedShare  OCTET STRING  -- SM2Cipher of the 32 bytes share
+//	    }
+//	  },
+//	  nonce             OCTET STRING,
+//	  encryptedContent  OCTET STRING  -- SM4-GCM, the header is the additional data
+//	}
+
+const (
+	thresholdVersion    = 0
+	maxThresholdHolders = 255
+)
+
+// ThresholdShare is a decrypted share of a threshold ciphertext.
+type ThresholdShare struct {
+	// Index is the position of the share, starting from 1.
+	Index int
+	// Value is the big-endian share value.
+	Value []byte
+}
+
+type thresholdEntry struct {
+	index          int64
+	recipient      []byte
+	encryptedShare []byte
+}
+
+type thresholdCiphertext struct {
+	header    []byte
+	threshold int64
+	entries   []thresholdEntry
+	nonce     []byte
+	content   []byte
+}
+
+// ThresholdEncrypt encrypts plaintext so that any threshold of the
+// recipients can decrypt it together, with DecryptThresholdShare and
+// CombineThresholdShares. There can be at most 255 recipients.
+func ThresholdEncrypt(rand io.Reader, recipients []*ecdsa.PublicKey, threshold int, plaintext []byte) ([]byte, error) {
+	if threshold < 1 || threshold > len(recipients) || len(recipients) > maxThresholdHolders {
+		return nil, errorf(ErrInvalidArgument, "sm2: invalid threshold %d of %d recipients", threshold, len(recipients))
+	}
+	for _, pub := range recipients {
+		if pub == nil || pub.Curve != P256() {
+			return nil, newError(ErrInvalidPublicKey, "sm2: threshold encryption requires sm2 public keys")
+		}
+	}
+	c := p256()
+
+	// f(x) = s + a₁x + ... + aₜ₋₁xᵗ⁻¹
+	coefficients := make([]*bigmod.Nat, threshold)
+	for i := range coefficients {
+		k, _, err := randomPoint(c, rand, false)
+		if err != nil {
+			return nil, err
+		}
+		coefficients[i] = k
+	}
+	secret := coefficients[0].Bytes(c.N)
+
+	var header cryptobyte.Builder
+	var encErr error
+	header.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1Int64(thresholdVersion)
+		b.AddASN1Int64(int64(threshold))
+		b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			for i, pub := range recipients {
+				x, _ := bigmod.NewNat().SetBytes([]byte{byte(i + 1)}, c.N)
+				share := evaluatePolynomial(c, coefficients, x)
+				encryptedShare, err := EncryptASN1(rand, pub, share.Bytes(c.N))
+				if err != nil {
+					encErr = err
+					return
+				}
+				b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					b.AddASN1Int64(int64(i + 1))
+					b.AddASN1OctetString(elliptic.Marshal(pub.Curve, pub.X, pub.Y))
+					b.AddASN1OctetString(encryptedShare)
+				})
+			}
+		})
+	})
+	if encErr != nil {
+		return nil, encErr
+	}
+	headerBytes, err := header.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := thresholdAEAD(secret)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand, nonce); err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddBytes(headerBytes)
+		b.AddASN1OctetString(nonce)
+		b.AddASN1OctetString(aead.Seal(nil, nonce, plaintext, headerBytes))
+	})
+	return b.Bytes()
+}
+
+// evaluatePolynomial returns f(x) with Horner's method.
+func evaluatePolynomial(c *sm2Curve, coefficients []*bigmod.Nat, x *bigmod.Nat) *bigmod.Nat {
+	y := bigmod.NewNat().Set(coefficients[len(coefficients)-1])
+	for i := len(coefficients) - 2; i >= 0; i-- {
+		y.Mul(x, c.N).Add(coefficients[i], c.N)
+	}
+	return y
+}
+
+func thresholdAEAD(secret []byte) (cipher.AEAD, error) {
+	key := kdf.Kdf(sm3.New(), secret, sm4.BlockSize)
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func parseThresholdCiphertext(ciphertext []byte) (*thresholdCiphertext, error) {
+	var (
+		ct                        thresholdCiphertext
+		inner, header, hdr, items cryptobyte.String
+		version                   int64
+	)
+	input := cryptobyte.String(ciphertext)
+	if !input.ReadASN1(&inner, asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1Element(&header, asn1.SEQUENCE) ||
+		!inner.ReadASN1Bytes(&ct.nonce, asn1.OCTET_STRING) ||
+		!inner.ReadASN1Bytes(&ct.content, asn1.OCTET_STRING) || !inner.Empty() {
+		return nil, newError(ErrInvalidCiphertext, "sm2: malformed threshold ciphertext")
+	}
+	ct.header = header
+	if !header.ReadASN1(&hdr, asn1.SEQUENCE) ||
+		!hdr.ReadASN1Integer(&version) ||
+		!hdr.ReadASN1Integer(&ct.threshold) ||
+		!hdr.ReadASN1(&items, asn1.SEQUENCE) || !hdr.Empty() {
+		return nil, newError(ErrInvalidCiphertext, "sm2: malformed threshold ciphertext header")
+	}
+	if version != thresholdVersion {
+		return nil, errorf(ErrInvalidCiphertext, "sm2: unsupported threshold ciphertext version %d", version)
+	}
+	for !items.Empty() {
+		var item cryptobyte.String
+		var e thresholdEntry
+		if !items.ReadASN1(&item, asn1.SEQUENCE) ||
+			!item.ReadASN1Integer(&e.index) ||
+			!item.ReadASN1Bytes(&e.recipient, asn1.OCTET_STRING) ||
+			!item.ReadASN1Bytes(&e.encryptedShare, asn1.OCTET_STRING) || !item.Empty() {
+			return nil, newError(ErrInvalidCiphertext, "sm2: malformed threshold ciphertext share")
+		}
+		if e.index < 1 || e.index > maxThresholdHolders {
+			return nil, errorf(ErrInvalidCiphertext, "sm2: invalid threshold share index %d", e.index)
+		}
+		ct.entries = append(ct.entries, e)
+	}
+	if ct.threshold < 1 || ct.threshold > int64(len(ct.entries)) {
+		return nil, errorf(ErrInvalidCiphertext, "sm2: invalid threshold %d of %d shares", ct.threshold, len(ct.entries))
+	}
+	return &ct, nil
+}
+
+// DecryptThresholdShare decrypts the share of priv in a ciphertext created by
+// ThresholdEncrypt. The share must be kept secret and only be handed to the
+// combiner.
+func DecryptThresholdShare(priv *PrivateKey, ciphertext []byte) (*ThresholdShare, error) {
+	if priv == nil || priv.Curve != P256() {
+		return nil, newError(ErrInvalidPrivateKey, "sm2: threshold decryption requires a sm2 private key")
+	}
+	ct, err := parseThresholdCiphertext(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	pub := elliptic.Marshal(priv.Curve, priv.X, priv.Y)
+	for _, e := range ct.entries {
+		if string(e.recipient) != string(pub) {
+			continue
+		}
+		value, err := priv.Decrypt(nil, e.encryptedShare, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(value) != p256().N.Size() {
+			return nil, newError(ErrInvalidCiphertext, "sm2: invalid threshold share size")
+		}
+		return &ThresholdShare{Index: int(e.index), Value: value}, nil
+	}
+	return nil, newError(ErrInvalidPrivateKey, "sm2: private key is not a recipient of the threshold ciphertext")
+}
+
+// CombineThresholdShares recovers the plaintext of a ciphertext created by
+// ThresholdEncrypt from at least threshold distinct shares.
+func CombineThresholdShares(ciphertext []byte, shares []*ThresholdShare) ([]byte, error) {
+	ct, err := parseThresholdCiphertext(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(shares)) < ct.threshold {
+		return nil, errorf(ErrInvalidArgument, "sm2: %d shares given, %d are required", len(shares), ct.threshold)
+	}
+	shares = shares[:ct.threshold]
+	c := p256()
+	xs := make([]*bigmod.Nat, len(shares))
+	ys := make([]*bigmod.Nat, len(shares))
+	seen := make(map[int]bool, len(shares))
+	for i, share := range shares {
+		if share == nil || share.Index < 1 || share.Index > len(ct.entries) || seen[share.Index] {
+			return nil, newError(ErrInvalidArgument, "sm2: invalid or duplicated threshold share")
+		}
+		seen[share.Index] = true
+		xs[i], _ = bigmod.NewNat().SetBytes([]byte{byte(share.Index)}, c.N)
+		if ys[i], err = bigmod.NewNat().SetBytes(share.Value, c.N); err != nil {
+			return nil, newError(ErrInvalidArgument, "sm2: invalid threshold share value")
+		}
+	}
+
+	// s = f(0) = Σ yᵢ Π_{j≠i} xⱼ / (xⱼ - xᵢ)
+	secret := bigmod.NewNat().ExpandFor(c.N)
+	for i := range xs {
+		num, _ := bigmod.NewNat().SetBytes([]byte{1}, c.N)
+		den, _ := bigmod.NewNat().SetBytes([]byte{1}, c.N)
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			num.Mul(xs[j], c.N)
+			den.Mul(bigmod.NewNat().Set(xs[j]).Sub(xs[i], c.N), c.N)
+		}
+		denInv, err := _sm2ec.P256OrdInverse(den.Bytes(c.N))
+		if err != nil {
+			return nil, err
+		}
+		l, err := bigmod.NewNat().SetBytes(denInv, c.N)
+		if err != nil {
+			return nil, err
+		}
+		l.Mul(num, c.N).Mul(ys[i], c.N)
+		secret.Add(l, c.N)
+	}
+
+	aead, err := thresholdAEAD(secret.Bytes(c.N))
+	if err != nil {
+		return nil, err
+	}
+	if len(ct.nonce) != aead.NonceSize() {
+		return nil, newError(ErrInvalidCiphertext, "sm2: invalid threshold ciphertext nonce")
+	}
+	plaintext, err := aead.Open(nil, ct.nonce, ct.content, ct.header)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+	return plaintext, nil
+}
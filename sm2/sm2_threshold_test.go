@@ -0,0 +1,111 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func generateThresholdHolders(t *testing.T, n int) ([]*PrivateKey, []*ecdsa.PublicKey) {
+	t.Helper()
+	privs := make([]*PrivateKey, n)
+	pubs := make([]*ecdsa.PublicKey, n)
+	for i := range privs {
+		priv, err := GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[i] = priv
+		pubs[i] = &priv.PublicKey
+	}
+	return privs, pubs
+}
+
+func TestThresholdEncrypt(t *testing.T) {
+	privs, pubs := generateThresholdHolders(t, 5)
+	plaintext := []byte("escrowed document")
+	ciphertext, err := ThresholdEncrypt(rand.Reader, pubs, 3, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares := make([]*ThresholdShare, len(privs))
+	for i, priv := range privs {
+		if shares[i], err = DecryptThresholdShare(priv, ciphertext); err != nil {
+			t.Fatal(err)
+		}
+		if shares[i].Index != i+1 {
+			t.Errorf("got share index %d, expected %d", shares[i].Index, i+1)
+		}
+	}
+
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var given []*ThresholdShare
+		for _, i := range subset {
+			given = append(given, shares[i])
+		}
+		got, err := CombineThresholdShares(ciphertext, given)
+		if err != nil {
+			t.Fatalf("%v: %v", subset, err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("%v: got %q, expected %q", subset, got, plaintext)
+		}
+	}
+
+	if _, err = CombineThresholdShares(ciphertext, shares[:2]); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("got %v, expected ErrInvalidArgument for too few shares", err)
+	}
+	if _, err = CombineThresholdShares(ciphertext, []*ThresholdShare{shares[0], shares[0], shares[1]}); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("got %v, expected ErrInvalidArgument for duplicated shares", err)
+	}
+	corrupted := &ThresholdShare{Index: shares[1].Index, Value: append([]byte(nil), shares[1].Value...)}
+	corrupted.Value[31] ^= 1
+	if _, err = CombineThresholdShares(ciphertext, []*ThresholdShare{shares[0], corrupted, shares[2]}); !errors.Is(err, ErrDecryption) {
+		t.Errorf("got %v, expected ErrDecryption for corrupted share", err)
+	}
+	relabeled := &ThresholdShare{Index: shares[3].Index, Value: shares[1].Value}
+	if _, err = CombineThresholdShares(ciphertext, []*ThresholdShare{shares[0], relabeled, shares[2]}); err == nil {
+		t.Errorf("relabeled share accepted")
+	}
+
+	other, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = DecryptThresholdShare(other, ciphertext); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("got %v, expected ErrInvalidPrivateKey for non recipient", err)
+	}
+	tampered := append([]byte(nil), ciphertext...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err = CombineThresholdShares(tampered, shares[:3]); !errors.Is(err, ErrDecryption) {
+		t.Errorf("got %v, expected ErrDecryption for tampered ciphertext", err)
+	}
+	if _, err = CombineThresholdShares(ciphertext[:len(ciphertext)-1], shares[:3]); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("got %v, expected ErrInvalidCiphertext for truncated ciphertext", err)
+	}
+}
+
+func TestThresholdEncryptInvalidArguments(t *testing.T) {
+	_, pubs := generateThresholdHolders(t, 2)
+	for _, threshold := range []int{0, 3} {
+		if _, err := ThresholdEncrypt(rand.Reader, pubs, threshold, []byte("msg")); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("threshold %d: got %v, expected ErrInvalidArgument", threshold, err)
+		}
+	}
+	// 1-of-1 and empty plaintext
+	privs, pubs := generateThresholdHolders(t, 1)
+	ciphertext, err := ThresholdEncrypt(rand.Reader, pubs, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share, err := DecryptThresholdShare(privs[0], ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := CombineThresholdShares(ciphertext, []*ThresholdShare{share})
+	if err != nil || len(got) != 0 {
+		t.Errorf("got %q, %v, expected empty plaintext", got, err)
+	}
+}
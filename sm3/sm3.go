@@ -211,3 +211,12 @@ func Sum(data []byte) [Size]byte {
 	d.Write(data)
 	return d.checkSum()
 }
+
+// AppendSum appends the SM3 checksum of the data to dst and returns the resulting slice.
+func AppendSum(dst, data []byte) []byte {
+	var d digest
+	d.Reset()
+	d.Write(data)
+	sum := d.checkSum()
+	return append(dst, sum[:]...)
+}
@@ -0,0 +1,41 @@
+package sm3
+
+import "encoding/binary"
+
+// SumBatch returns the SM3 checksums of msgs. The messages are hashed in
+// parallel by the multi-buffer implementation when the CPU supports it (AVX2
+// on amd64), which is much faster than calling Sum for each of many small
+// messages; otherwise it's equivalent to calling Sum for each message.
+func SumBatch(msgs [][]byte) [][Size]byte {
+	sums := make([][Size]byte, len(msgs))
+	sumBatch(sums, msgs)
+	return sums
+}
+
+func sumBatchGeneric(sums [][Size]byte, msgs [][]byte) {
+	for i, msg := range msgs {
+		sums[i] = Sum(msg)
+	}
+}
+
+// padTail writes the last partial block of msg and the padding to tail and
+// returns the padded blocks, one or two.
+func padTail(tail *[2 * chunk]byte, msg []byte) []byte {
+	rem := copy(tail[:], msg[len(msg)&^(chunk-1):])
+	n := chunk
+	if rem >= chunk-8 {
+		n = 2 * chunk
+	}
+	tail[rem] = 0x80
+	for i := rem + 1; i < n-8; i++ {
+		tail[i] = 0
+	}
+	binary.BigEndian.PutUint64(tail[n-8:], uint64(len(msg))<<3)
+	return tail[:n]
+}
+
+func putSum(sum *[Size]byte, h *[8]uint32) {
+	for i, v := range h {
+		binary.BigEndian.PutUint32(sum[i*4:], v)
+	}
+}
@@ -532,3 +532,58 @@ func TestGenerateT(t *testing.T) {
 	fmt.Println()
 }
 */
+
+func TestAppendSum(t *testing.T) {
+	data := []byte("abc")
+	expected := Sum(data)
+	out := AppendSum([]byte("prefix"), data)
+	if !bytes.Equal(out, append([]byte("prefix"), expected[:]...)) {
+		t.Errorf("AppendSum = %x", out)
+	}
+	buf := make([]byte, 0, Size)
+	if n := testing.AllocsPerRun(10, func() { AppendSum(buf, data) }); n > 0 {
+		t.Errorf("AppendSum allocates %v times", n)
+	}
+}
+
+func TestSumBatch(t *testing.T) {
+	lengths := []int{0, 1, 3, 55, 56, 57, 63, 64, 65, 119, 120, 128, 200, 1000, 4096}
+	for _, count := range []int{0, 1, 3, 4, 8, 9, 17, 100} {
+		msgs := make([][]byte, count)
+		for i := range msgs {
+			n := lengths[(i*7+count)%len(lengths)]
+			msgs[i] = make([]byte, n)
+			for j := range msgs[i] {
+				msgs[i][j] = byte(i + j*3)
+			}
+		}
+		sums := SumBatch(msgs)
+		if len(sums) != count {
+			t.Fatalf("SumBatch returned %d sums for %d messages", len(sums), count)
+		}
+		for i, msg := range msgs {
+			if sums[i] != Sum(msg) {
+				t.Errorf("count %d: SumBatch[%d] of %d bytes = %x, want %x", count, i, len(msg), sums[i], Sum(msg))
+			}
+		}
+	}
+}
+
+func benchmarkSumBatch(b *testing.B, size int) {
+	msgs := make([][]byte, 64)
+	for i := range msgs {
+		msgs[i] = buf[:size]
+	}
+	b.SetBytes(int64(size * len(msgs)))
+	for i := 0; i < b.N; i++ {
+		SumBatch(msgs)
+	}
+}
+
+func BenchmarkSumBatch64Bytes(b *testing.B) {
+	benchmarkSumBatch(b, 64)
+}
+
+func BenchmarkSumBatch1K(b *testing.B) {
+	benchmarkSumBatch(b, 1024)
+}
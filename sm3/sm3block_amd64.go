@@ -2,12 +2,22 @@
 
 package sm3
 
-import "golang.org/x/sys/cpu"
+import (
+	"github.com/emmansun/gmsm/internal/impl"
+	"golang.org/x/sys/cpu"
+)
 
 var useAVX2 = cpu.X86.HasAVX2 && cpu.X86.HasBMI2
 var useAVX = cpu.X86.HasAVX
 var useSSSE3 = cpu.X86.HasSSSE3
 
+func init() {
+	impl.Register("sm3", "AVX2", useAVX2, true)
+	impl.Register("sm3", "AVX", useAVX, true)
+	impl.Register("sm3", "SSSE3", useSSSE3, true)
+	impl.Register("sm3", "amd64", true, true)
+}
+
 //go:noescape
 func blockAMD64(dig *digest, p []byte)
 
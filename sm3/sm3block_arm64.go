@@ -4,11 +4,18 @@ package sm3
 
 import (
 	"os"
+
+	"github.com/emmansun/gmsm/internal/impl"
 	"golang.org/x/sys/cpu"
 )
 
 var useSM3NI = cpu.ARM64.HasSM3 && os.Getenv("DISABLE_SM3NI") != "1"
 
+func init() {
+	impl.Register("sm3", "SM3", useSM3NI, true)
+	impl.Register("sm3", "NEON", true, true)
+}
+
 var t = []uint32{
 	0x79cc4519,
 	0x9d8a7a87,
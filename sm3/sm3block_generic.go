@@ -2,6 +2,12 @@
 
 package sm3
 
+import "github.com/emmansun/gmsm/internal/impl"
+
+func init() {
+	impl.Register("sm3", impl.Generic, true, true)
+}
+
 func block(dig *digest, p []byte) {
 	blockGeneric(dig, p)
 }
@@ -0,0 +1,118 @@
+//go:build amd64 && !purego
+
+package sm3
+
+// blockMultBy8 compresses blocks blocks of each of the 8 messages p points
+// to into the states dig points to.
+//
+//go:noescape
+func blockMultBy8(dig *[8]*[8]uint32, p *[8]*byte, blocks int)
+
+// minBatchLanes is the minimum number of busy lanes of blockMultBy8, the
+// last messages of a batch are finished one by one.
+const minBatchLanes = 4
+
+// batchLane is a message which is hashed by a lane of blockMultBy8.
+type batchLane struct {
+	idx   int // index of the message, -1 if the lane is idle
+	h     [8]uint32
+	data  []byte // remaining blocks of the current segment
+	tail  []byte // padded last blocks, nil when they're the current segment
+	block [2 * chunk]byte
+}
+
+func (l *batchLane) start(idx int, msg []byte) {
+	l.idx = idx
+	l.h = [8]uint32{init0, init1, init2, init3, init4, init5, init6, init7}
+	l.data = msg[:len(msg)&^(chunk-1)]
+	l.tail = padTail(&l.block, msg)
+	if len(l.data) == 0 {
+		l.data, l.tail = l.tail, nil
+	}
+}
+
+func sumBatch(sums [][Size]byte, msgs [][]byte) {
+	if !useAVX2 || len(msgs) < minBatchLanes {
+		sumBatchGeneric(sums, msgs)
+		return
+	}
+	var (
+		lanes   [8]batchLane
+		scratch [8]uint32
+		digs    [8]*[8]uint32
+		ptrs    [8]*byte
+	)
+	for i := range lanes {
+		lanes[i].idx = -1
+	}
+	next := 0
+	for {
+		busy := 0
+		for i := range lanes {
+			l := &lanes[i]
+			if l.idx < 0 && next < len(msgs) {
+				l.start(next, msgs[next])
+				next++
+			}
+			if l.idx >= 0 {
+				busy++
+			}
+		}
+		if busy < minBatchLanes {
+			break
+		}
+
+		blocks := -1
+		var busyData *byte
+		for i := range lanes {
+			l := &lanes[i]
+			if l.idx < 0 {
+				continue
+			}
+			if n := len(l.data) / chunk; blocks < 0 || n < blocks {
+				blocks = n
+			}
+			busyData = &l.data[0]
+		}
+		for i := range lanes {
+			l := &lanes[i]
+			if l.idx < 0 {
+				// idle lanes hash the data of a busy one to a scratch state
+				digs[i], ptrs[i] = &scratch, busyData
+			} else {
+				digs[i], ptrs[i] = &l.h, &l.data[0]
+			}
+		}
+		blockMultBy8(&digs, &ptrs, blocks)
+
+		for i := range lanes {
+			l := &lanes[i]
+			if l.idx < 0 {
+				continue
+			}
+			l.data = l.data[blocks*chunk:]
+			if len(l.data) > 0 {
+				continue
+			}
+			if l.tail != nil {
+				l.data, l.tail = l.tail, nil
+				continue
+			}
+			putSum(&sums[l.idx], &l.h)
+			l.idx = -1
+		}
+	}
+
+	for i := range lanes {
+		l := &lanes[i]
+		if l.idx < 0 {
+			continue
+		}
+		d := digest{h: l.h}
+		block(&d, l.data)
+		if l.tail != nil {
+			block(&d, l.tail)
+		}
+		putSum(&sums[l.idx], &d.h)
+	}
+}
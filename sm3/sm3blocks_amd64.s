@@ -0,0 +1,364 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+#include "sm3_const_asm.s"
+
+// Multi-buffer SM3 with AVX2: the 8 lanes of each YMM register are the same
+// state or message word of 8 independent messages, so 8 blocks are
+// compressed at once with the vector instructions of the scalar algorithm.
+
+#define W_OFF 0         // W[0..67] of the 8 lanes, 68 * 32 bytes
+#define STATE_OFF 2176  // V(i) of the 8 lanes, 8 * 32 bytes
+
+#define a Y8
+#define b Y9
+#define c Y10
+#define d Y11
+#define e Y12
+#define f Y13
+#define g Y14
+#define h Y15
+
+// TRANSPOSE_MATRIX transposes the 8x8 matrix of 32-bit words in the rows Y0-Y7
+// to the rows Y8-Y15, Y0-Y7 are clobbered.
+#define TRANSPOSE_MATRIX() \
+	VPUNPCKLDQ Y1, Y0, Y8;                   \
+	VPUNPCKHDQ Y1, Y0, Y9;                   \
+	VPUNPCKLDQ Y3, Y2, Y10;                  \
+	VPUNPCKHDQ Y3, Y2, Y11;                  \
+	VPUNPCKLDQ Y5, Y4, Y12;                  \
+	VPUNPCKHDQ Y5, Y4, Y13;                  \
+	VPUNPCKLDQ Y7, Y6, Y14;                  \
+	VPUNPCKHDQ Y7, Y6, Y15;                  \
+	VPUNPCKLQDQ Y10, Y8, Y0;                 \
+	VPUNPCKHQDQ Y10, Y8, Y1;                 \
+	VPUNPCKLQDQ Y11, Y9, Y2;                 \
+	VPUNPCKHQDQ Y11, Y9, Y3;                 \
+	VPUNPCKLQDQ Y14, Y12, Y4;                \
+	VPUNPCKHQDQ Y14, Y12, Y5;                \
+	VPUNPCKLQDQ Y15, Y13, Y6;                \
+	VPUNPCKHQDQ Y15, Y13, Y7;                \
+	VPERM2I128 $0x20, Y4, Y0, Y8;            \
+	VPERM2I128 $0x31, Y4, Y0, Y12;           \
+	VPERM2I128 $0x20, Y5, Y1, Y9;            \
+	VPERM2I128 $0x31, Y5, Y1, Y13;           \
+	VPERM2I128 $0x20, Y6, Y2, Y10;           \
+	VPERM2I128 $0x31, Y6, Y2, Y14;           \
+	VPERM2I128 $0x20, Y7, Y3, Y11;           \
+	VPERM2I128 $0x31, Y7, Y3, Y15
+
+// LOAD_LANES loads 32 bytes at offset off of the 8 lane pointers to Y0-Y7.
+#define LOAD_LANES(off) \
+	VMOVDQU off(R8), Y0;                     \
+	VMOVDQU off(R9), Y1;                     \
+	VMOVDQU off(R10), Y2;                    \
+	VMOVDQU off(R11), Y3;                    \
+	VMOVDQU off(R12), Y4;                    \
+	VMOVDQU off(R13), Y5;                    \
+	VMOVDQU off(R14), Y6;                    \
+	VMOVDQU off(R15), Y7
+
+// STORE_MSG byte swaps Y8-Y15 and stores them to W[i..i+7].
+#define STORE_MSG(i) \
+	VPSHUFB flip_mask<>(SB), Y8, Y8;         \
+	VPSHUFB flip_mask<>(SB), Y9, Y9;         \
+	VPSHUFB flip_mask<>(SB), Y10, Y10;       \
+	VPSHUFB flip_mask<>(SB), Y11, Y11;       \
+	VPSHUFB flip_mask<>(SB), Y12, Y12;       \
+	VPSHUFB flip_mask<>(SB), Y13, Y13;       \
+	VPSHUFB flip_mask<>(SB), Y14, Y14;       \
+	VPSHUFB flip_mask<>(SB), Y15, Y15;       \
+	VMOVDQU Y8, (W_OFF+32*(i+0))(SP);        \
+	VMOVDQU Y9, (W_OFF+32*(i+1))(SP);        \
+	VMOVDQU Y10, (W_OFF+32*(i+2))(SP);       \
+	VMOVDQU Y11, (W_OFF+32*(i+3))(SP);       \
+	VMOVDQU Y12, (W_OFF+32*(i+4))(SP);       \
+	VMOVDQU Y13, (W_OFF+32*(i+5))(SP);       \
+	VMOVDQU Y14, (W_OFF+32*(i+6))(SP);       \
+	VMOVDQU Y15, (W_OFF+32*(i+7))(SP)
+
+// PROLD sets r = x <<< n, tmp is clobbered.
+#define PROLD(x, n, r, tmp) \
+	VPSLLD $n, x, tmp;                       \
+	VPSRLD $(32-n), x, r;                    \
+	VPOR tmp, r, r
+
+// MESSAGE_EXPAND sets W[j] = P1(W[j-16] ^ W[j-9] ^ (W[j-3] <<< 15)) ^ (W[j-13] <<< 7) ^ W[j-6],
+// BX points to W[j].
+#define MESSAGE_EXPAND() \
+	VMOVDQU -512(BX), Y0;                    \
+	VPXOR -288(BX), Y0, Y0;                  \
+	VMOVDQU -96(BX), Y1;                     \
+	PROLD(Y1, 15, Y1, Y2);                   \
+	VPXOR Y1, Y0, Y0;                        \
+	PROLD(Y0, 15, Y1, Y2);                   \
+	PROLD(Y0, 23, Y3, Y2);                   \
+	VPXOR Y1, Y0, Y0;                        \
+	VPXOR Y3, Y0, Y0;                        \
+	VMOVDQU -416(BX), Y1;                    \
+	PROLD(Y1, 7, Y1, Y2);                    \
+	VPXOR Y1, Y0, Y0;                        \
+	VPXOR -192(BX), Y0, Y0;                  \
+	VMOVDQU Y0, (BX)
+
+#define FF0(x, y, z, r, tmp) \
+	VPXOR y, x, r;                           \
+	VPXOR z, r, r
+
+#define FF1(x, y, z, r, tmp) \
+	VPOR y, x, r;                            \
+	VPAND z, r, r;                           \
+	VPAND y, x, tmp;                         \
+	VPOR tmp, r, r
+
+#define GG0(x, y, z, r, tmp) \
+	VPXOR y, x, r;                           \
+	VPXOR z, r, r
+
+#define GG1(x, y, z, r, tmp) \
+	VPAND y, x, r;                           \
+	VPANDN z, x, tmp;                        \
+	VPOR tmp, r, r
+
+// ROUND is the round j = 4*n+k, AX points to T(4*n) and BX to W[4*n]. The
+// new A and E are written to d and h, B and F are rotated in place, so the
+// registers of the next round are (d, a, b, c, h, e, f, g).
+#define ROUND(FF, GG, k, a, b, c, d, e, f, g, h) \
+	PROLD(a, 12, Y0, Y1);                    \ // Y0 = A <<< 12
+	VPBROADCASTD (4*k)(AX), Y1;              \
+	VPADDD Y0, Y1, Y1;                       \
+	VPADDD e, Y1, Y1;                        \
+	PROLD(Y1, 7, Y1, Y2);                    \ // Y1 = SS1
+	VPXOR Y1, Y0, Y0;                        \ // Y0 = SS2
+	FF(a, b, c, Y2, Y3);                     \
+	VPADDD Y2, d, d;                         \
+	VPADDD Y0, d, d;                         \
+	VMOVDQU (32*k)(BX), Y2;                  \ // Y2 = W[j]
+	VPXOR (128+32*k)(BX), Y2, Y3;            \ // Y3 = W[j] ^ W[j+4]
+	VPADDD Y3, d, d;                         \ // d = TT1
+	GG(e, f, g, Y0, Y3);                     \
+	VPADDD Y0, h, h;                         \
+	VPADDD Y1, h, h;                         \
+	VPADDD Y2, h, h;                         \ // h = TT2
+	PROLD(h, 9, Y0, Y1);                     \
+	PROLD(h, 17, Y1, Y2);                    \
+	VPXOR Y0, h, h;                          \
+	VPXOR Y1, h, h;                          \ // h = P0(TT2)
+	PROLD(b, 9, b, Y0);                      \
+	PROLD(f, 19, f, Y0)
+
+#define ROUNDS_4(FF, GG) \
+	ROUND(FF, GG, 0, a, b, c, d, e, f, g, h); \
+	ROUND(FF, GG, 1, d, a, b, c, h, e, f, g); \
+	ROUND(FF, GG, 2, c, d, a, b, g, h, e, f); \
+	ROUND(FF, GG, 3, b, c, d, a, f, g, h, e); \
+	ADDQ $16, AX;                            \
+	ADDQ $128, BX
+
+// func blockMultBy8(dig *[8]*[8]uint32, p *[8]*byte, blocks int)
+TEXT ·blockMultBy8(SB), 0, $2432-24
+	MOVQ dig+0(FP), DI
+	MOVQ p+8(FP), SI
+	MOVQ blocks+16(FP), DX
+
+	// transpose the states of the lanes to the state words
+	MOVQ 0(DI), R8
+	MOVQ 8(DI), R9
+	MOVQ 16(DI), R10
+	MOVQ 24(DI), R11
+	MOVQ 32(DI), R12
+	MOVQ 40(DI), R13
+	MOVQ 48(DI), R14
+	MOVQ 56(DI), R15
+	LOAD_LANES(0)
+	TRANSPOSE_MATRIX()
+	VMOVDQU a, (STATE_OFF+0*32)(SP)
+	VMOVDQU b, (STATE_OFF+1*32)(SP)
+	VMOVDQU c, (STATE_OFF+2*32)(SP)
+	VMOVDQU d, (STATE_OFF+3*32)(SP)
+	VMOVDQU e, (STATE_OFF+4*32)(SP)
+	VMOVDQU f, (STATE_OFF+5*32)(SP)
+	VMOVDQU g, (STATE_OFF+6*32)(SP)
+	VMOVDQU h, (STATE_OFF+7*32)(SP)
+
+	MOVQ 0(SI), R8
+	MOVQ 8(SI), R9
+	MOVQ 16(SI), R10
+	MOVQ 24(SI), R11
+	MOVQ 32(SI), R12
+	MOVQ 40(SI), R13
+	MOVQ 48(SI), R14
+	MOVQ 56(SI), R15
+
+loop:
+	LOAD_LANES(0)
+	TRANSPOSE_MATRIX()
+	STORE_MSG(0)
+	LOAD_LANES(32)
+	TRANSPOSE_MATRIX()
+	STORE_MSG(8)
+
+	LEAQ (W_OFF+16*32)(SP), BX
+	MOVQ $52, CX
+
+expand:
+	MESSAGE_EXPAND()
+	ADDQ $32, BX
+	DECQ CX
+	JNZ expand
+
+	VMOVDQU (STATE_OFF+0*32)(SP), a
+	VMOVDQU (STATE_OFF+1*32)(SP), b
+	VMOVDQU (STATE_OFF+2*32)(SP), c
+	VMOVDQU (STATE_OFF+3*32)(SP), d
+	VMOVDQU (STATE_OFF+4*32)(SP), e
+	VMOVDQU (STATE_OFF+5*32)(SP), f
+	VMOVDQU (STATE_OFF+6*32)(SP), g
+	VMOVDQU (STATE_OFF+7*32)(SP), h
+
+	LEAQ t_consts<>(SB), AX
+	LEAQ W_OFF(SP), BX
+	MOVQ $4, CX
+
+rounds_0_15:
+	ROUNDS_4(FF0, GG0)
+	DECQ CX
+	JNZ rounds_0_15
+
+	MOVQ $12, CX
+
+rounds_16_63:
+	ROUNDS_4(FF1, GG1)
+	DECQ CX
+	JNZ rounds_16_63
+
+	VPXOR (STATE_OFF+0*32)(SP), a, a
+	VPXOR (STATE_OFF+1*32)(SP), b, b
+	VPXOR (STATE_OFF+2*32)(SP), c, c
+	VPXOR (STATE_OFF+3*32)(SP), d, d
+	VPXOR (STATE_OFF+4*32)(SP), e, e
+	VPXOR (STATE_OFF+5*32)(SP), f, f
+	VPXOR (STATE_OFF+6*32)(SP), g, g
+	VPXOR (STATE_OFF+7*32)(SP), h, h
+	VMOVDQU a, (STATE_OFF+0*32)(SP)
+	VMOVDQU b, (STATE_OFF+1*32)(SP)
+	VMOVDQU c, (STATE_OFF+2*32)(SP)
+	VMOVDQU d, (STATE_OFF+3*32)(SP)
+	VMOVDQU e, (STATE_OFF+4*32)(SP)
+	VMOVDQU f, (STATE_OFF+5*32)(SP)
+	VMOVDQU g, (STATE_OFF+6*32)(SP)
+	VMOVDQU h, (STATE_OFF+7*32)(SP)
+
+	ADDQ $64, R8
+	ADDQ $64, R9
+	ADDQ $64, R10
+	ADDQ $64, R11
+	ADDQ $64, R12
+	ADDQ $64, R13
+	ADDQ $64, R14
+	ADDQ $64, R15
+	DECQ DX
+	JNZ loop
+
+	// transpose the state words back to the states of the lanes
+	VMOVDQU (STATE_OFF+0*32)(SP), Y0
+	VMOVDQU (STATE_OFF+1*32)(SP), Y1
+	VMOVDQU (STATE_OFF+2*32)(SP), Y2
+	VMOVDQU (STATE_OFF+3*32)(SP), Y3
+	VMOVDQU (STATE_OFF+4*32)(SP), Y4
+	VMOVDQU (STATE_OFF+5*32)(SP), Y5
+	VMOVDQU (STATE_OFF+6*32)(SP), Y6
+	VMOVDQU (STATE_OFF+7*32)(SP), Y7
+	TRANSPOSE_MATRIX()
+	MOVQ 0(DI), R8
+	MOVQ 8(DI), R9
+	MOVQ 16(DI), R10
+	MOVQ 24(DI), R11
+	MOVQ 32(DI), R12
+	MOVQ 40(DI), R13
+	MOVQ 48(DI), R14
+	MOVQ 56(DI), R15
+	VMOVDQU Y8, (R8)
+	VMOVDQU Y9, (R9)
+	VMOVDQU Y10, (R10)
+	VMOVDQU Y11, (R11)
+	VMOVDQU Y12, (R12)
+	VMOVDQU Y13, (R13)
+	VMOVDQU Y14, (R14)
+	VMOVDQU Y15, (R15)
+
+	VZEROUPPER
+	RET
+
+DATA flip_mask<>+0x00(SB)/8, $0x0405060700010203
+DATA flip_mask<>+0x08(SB)/8, $0x0c0d0e0f08090a0b
+DATA flip_mask<>+0x10(SB)/8, $0x0405060700010203
+DATA flip_mask<>+0x18(SB)/8, $0x0c0d0e0f08090a0b
+GLOBL flip_mask<>(SB), RODATA, $32
+
+DATA t_consts<>+0x000(SB)/4, $T0
+DATA t_consts<>+0x004(SB)/4, $T1
+DATA t_consts<>+0x008(SB)/4, $T2
+DATA t_consts<>+0x00c(SB)/4, $T3
+DATA t_consts<>+0x010(SB)/4, $T4
+DATA t_consts<>+0x014(SB)/4, $T5
+DATA t_consts<>+0x018(SB)/4, $T6
+DATA t_consts<>+0x01c(SB)/4, $T7
+DATA t_consts<>+0x020(SB)/4, $T8
+DATA t_consts<>+0x024(SB)/4, $T9
+DATA t_consts<>+0x028(SB)/4, $T10
+DATA t_consts<>+0x02c(SB)/4, $T11
+DATA t_consts<>+0x030(SB)/4, $T12
+DATA t_consts<>+0x034(SB)/4, $T13
+DATA t_consts<>+0x038(SB)/4, $T14
+DATA t_consts<>+0x03c(SB)/4, $T15
+DATA t_consts<>+0x040(SB)/4, $T16
+DATA t_consts<>+0x044(SB)/4, $T17
+DATA t_consts<>+0x048(SB)/4, $T18
+DATA t_consts<>+0x04c(SB)/4, $T19
+DATA t_consts<>+0x050(SB)/4, $T20
+DATA t_consts<>+0x054(SB)/4, $T21
+DATA t_consts<>+0x058(SB)/4, $T22
+DATA t_consts<>+0x05c(SB)/4, $T23
+DATA t_consts<>+0x060(SB)/4, $T24
+DATA t_consts<>+0x064(SB)/4, $T25
+DATA t_consts<>+0x068(SB)/4, $T26
+DATA t_consts<>+0x06c(SB)/4, $T27
+DATA t_consts<>+0x070(SB)/4, $T28
+DATA t_consts<>+0x074(SB)/4, $T29
+DATA t_consts<>+0x078(SB)/4, $T30
+DATA t_consts<>+0x07c(SB)/4, $T31
+DATA t_consts<>+0x080(SB)/4, $T32
+DATA t_consts<>+0x084(SB)/4, $T33
+DATA t_consts<>+0x088(SB)/4, $T34
+DATA t_consts<>+0x08c(SB)/4, $T35
+DATA t_consts<>+0x090(SB)/4, $T36
+DATA t_consts<>+0x094(SB)/4, $T37
+DATA t_consts<>+0x098(SB)/4, $T38
+DATA t_consts<>+0x09c(SB)/4, $T39
+DATA t_consts<>+0x0a0(SB)/4, $T40
+DATA t_consts<>+0x0a4(SB)/4, $T41
+DATA t_consts<>+0x0a8(SB)/4, $T42
+DATA t_consts<>+0x0ac(SB)/4, $T43
+DATA t_consts<>+0x0b0(SB)/4, $T44
+DATA t_consts<>+0x0b4(SB)/4, $T45
+DATA t_consts<>+0x0b8(SB)/4, $T46
+DATA t_consts<>+0x0bc(SB)/4, $T47
+DATA t_consts<>+0x0c0(SB)/4, $T48
+DATA t_consts<>+0x0c4(SB)/4, $T49
+DATA t_consts<>+0x0c8(SB)/4, $T50
+DATA t_consts<>+0x0cc(SB)/4, $T51
+DATA t_consts<>+0x0d0(SB)/4, $T52
+DATA t_consts<>+0x0d4(SB)/4, $T53
+DATA t_consts<>+0x0d8(SB)/4, $T54
+DATA t_consts<>+0x0dc(SB)/4, $T55
+DATA t_consts<>+0x0e0(SB)/4, $T56
+DATA t_consts<>+0x0e4(SB)/4, $T57
+DATA t_consts<>+0x0e8(SB)/4, $T58
+DATA t_consts<>+0x0ec(SB)/4, $T59
+DATA t_consts<>+0x0f0(SB)/4, $T60
+DATA t_consts<>+0x0f4(SB)/4, $T61
+DATA t_consts<>+0x0f8(SB)/4, $T62
+DATA t_consts<>+0x0fc(SB)/4, $T63
+GLOBL t_consts<>(SB), RODATA, $256
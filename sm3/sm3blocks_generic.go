@@ -0,0 +1,7 @@
+//go:build !amd64 || purego
+
+package sm3
+
+func sumBatch(sums [][Size]byte, msgs [][]byte) {
+	sumBatchGeneric(sums, msgs)
+}
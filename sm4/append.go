@@ -0,0 +1,40 @@
+package sm4
+
+import "crypto/cipher"
+
+// AppendCryptBlocks encrypts or decrypts src with the block mode and appends the
+// result to dst, it returns the extended buffer. The length of src must be a
+// multiple of the block size of the mode. When dst has enough spare capacity,
+// no allocation is made.
+//
+// For AEAD modes like GCM, the Seal and Open methods already follow the append
+// style, use them directly.
+func AppendCryptBlocks(dst []byte, mode cipher.BlockMode, src []byte) []byte {
+	ret, out := sliceForAppend(dst, len(src))
+	mode.CryptBlocks(out, src)
+	return ret
+}
+
+// AppendXORKeyStream XORs src with the key stream of the stream cipher and appends
+// the result to dst, it returns the extended buffer. When dst has enough spare
+// capacity, no allocation is made.
+func AppendXORKeyStream(dst []byte, stream cipher.Stream, src []byte) []byte {
+	ret, out := sliceForAppend(dst, len(src))
+	stream.XORKeyStream(out, src)
+	return ret
+}
+
+// sliceForAppend takes a slice and a requested number of bytes. It returns a
+// slice with the contents of the given slice followed by that many bytes and a
+// second slice that aliases into it and contains only the extra bytes. If the
+// original slice has sufficient capacity then no allocation is performed.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
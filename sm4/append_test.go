@@ -0,0 +1,52 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func TestAppendCryptBlocks(t *testing.T) {
+	key := []byte("0123456789ABCDEF")
+	iv := make([]byte, BlockSize)
+	src := bytes.Repeat([]byte("a"), 4*BlockSize)
+	c, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := make([]byte, len(src))
+	cipher.NewCBCEncrypter(c, iv).CryptBlocks(expected, src)
+
+	out := AppendCryptBlocks([]byte("prefix"), cipher.NewCBCEncrypter(c, iv), src)
+	if !bytes.Equal(out, append([]byte("prefix"), expected...)) {
+		t.Fatalf("AppendCryptBlocks = %x", out)
+	}
+
+	buf := make([]byte, 0, len(src))
+	out = AppendCryptBlocks(buf, cipher.NewCBCDecrypter(c, iv), expected)
+	if !bytes.Equal(out, src) || &out[0] != &buf[:1][0] {
+		t.Errorf("AppendCryptBlocks did not decrypt into the caller buffer")
+	}
+}
+
+func TestAppendXORKeyStream(t *testing.T) {
+	key := []byte("0123456789ABCDEF")
+	iv := make([]byte, BlockSize)
+	src := []byte("some plaintext which is not block aligned")
+	c, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := make([]byte, len(src))
+	cipher.NewCTR(c, iv).XORKeyStream(expected, src)
+
+	out := AppendXORKeyStream([]byte("prefix"), cipher.NewCTR(c, iv), src)
+	if !bytes.Equal(out, append([]byte("prefix"), expected...)) {
+		t.Fatalf("AppendXORKeyStream = %x", out)
+	}
+	buf := make([]byte, 0, len(src))
+	stream := cipher.NewCTR(c, iv)
+	if n := testing.AllocsPerRun(10, func() { AppendXORKeyStream(buf, stream, src) }); n > 0 {
+		t.Errorf("AppendXORKeyStream allocates %v times", n)
+	}
+}
@@ -25,6 +25,7 @@
 #define XTMP7 V7
 
 #include "aesni_macros_arm64.s"
+#include "neon_macros_arm64.s"
 
 #define SM4_TAO_L2(x, y)         \
 	SM4_SBOX(x, y, XTMP6);                      \
@@ -172,6 +173,9 @@ TEXT ·encryptBlocksAsm(SB),NOSPLIT,$0
 	CMP $1, R11
 	BEQ sm4niblocks
 
+	CMP $2, R11
+	BEQ neonblocks
+
 	CMP $128, R12
 	BEQ double_enc
 
@@ -251,6 +255,84 @@ encrypt8BlocksLoop:
 
 	RET
 
+neonblocks:
+	CMP $128, R12
+	BEQ neon_double_enc
+
+	VLD1 (R10), [t0.S4, t1.S4, t2.S4, t3.S4]
+	VREV32 t0.B16, t0.B16
+	VREV32 t1.B16, t1.B16
+	VREV32 t2.B16, t2.B16
+	VREV32 t3.B16, t3.B16
+	PRE_TRANSPOSE_MATRIX(t0, t1, t2, t3, x, y, XTMP6, XTMP7)
+
+	LOAD_SM4_NEON_CONSTS()
+
+	EOR R0, R0
+
+neonBlocksLoop:
+		SM4_ROUND_NEON(R8, R19, x, y, XTMP6, t0, t1, t2, t3)
+		SM4_ROUND_NEON(R8, R19, x, y, XTMP6, t1, t2, t3, t0)
+		SM4_ROUND_NEON(R8, R19, x, y, XTMP6, t2, t3, t0, t1)
+		SM4_ROUND_NEON(R8, R19, x, y, XTMP6, t3, t0, t1, t2)
+
+		ADD $16, R0
+		CMP $128, R0
+		BNE neonBlocksLoop
+
+	TRANSPOSE_MATRIX(t0, t1, t2, t3, x, y, XTMP6, XTMP7)
+	VREV32 t0.B16, t0.B16
+	VREV32 t1.B16, t1.B16
+	VREV32 t2.B16, t2.B16
+	VREV32 t3.B16, t3.B16
+
+	VST1 [t0.S4, t1.S4, t2.S4, t3.S4], (R9)
+	RET
+
+neon_double_enc:
+	VLD1.P 64(R10), [t0.S4, t1.S4, t2.S4, t3.S4]
+	VLD1.P 64(R10), [t4.S4, t5.S4, t6.S4, t7.S4]
+	VREV32 t0.B16, t0.B16
+	VREV32 t1.B16, t1.B16
+	VREV32 t2.B16, t2.B16
+	VREV32 t3.B16, t3.B16
+	VREV32 t4.B16, t4.B16
+	VREV32 t5.B16, t5.B16
+	VREV32 t6.B16, t6.B16
+	VREV32 t7.B16, t7.B16
+	PRE_TRANSPOSE_MATRIX(t0, t1, t2, t3, x, y, XTMP6, XTMP7)
+	PRE_TRANSPOSE_MATRIX(t4, t5, t6, t7, x, y, XTMP6, XTMP7)
+
+	LOAD_SM4_NEON_CONSTS()
+
+	EOR R0, R0
+
+neon8BlocksLoop:
+		SM4_8BLOCKS_ROUND_NEON(R8, R19, x, y, XTMP6, XTMP7, t0, t1, t2, t3, t4, t5, t6, t7)
+		SM4_8BLOCKS_ROUND_NEON(R8, R19, x, y, XTMP6, XTMP7, t1, t2, t3, t0, t5, t6, t7, t4)
+		SM4_8BLOCKS_ROUND_NEON(R8, R19, x, y, XTMP6, XTMP7, t2, t3, t0, t1, t6, t7, t4, t5)
+		SM4_8BLOCKS_ROUND_NEON(R8, R19, x, y, XTMP6, XTMP7, t3, t0, t1, t2, t7, t4, t5, t6)
+
+		ADD $16, R0
+		CMP $128, R0
+		BNE neon8BlocksLoop
+
+	TRANSPOSE_MATRIX(t0, t1, t2, t3, x, y, XTMP6, XTMP7)
+	TRANSPOSE_MATRIX(t4, t5, t6, t7, x, y, XTMP6, XTMP7)
+	VREV32 t0.B16, t0.B16
+	VREV32 t1.B16, t1.B16
+	VREV32 t2.B16, t2.B16
+	VREV32 t3.B16, t3.B16
+	VREV32 t4.B16, t4.B16
+	VREV32 t5.B16, t5.B16
+	VREV32 t6.B16, t6.B16
+	VREV32 t7.B16, t7.B16
+
+	VST1.P [t0.S4, t1.S4, t2.S4, t3.S4], 64(R9)
+	VST1.P [t4.S4, t5.S4, t6.S4, t7.S4], 64(R9)
+
+	RET
+
 sm4niblocks:
 	VLD1.P  64(R8), [V0.S4, V1.S4, V2.S4, V3.S4]
 	VLD1.P  64(R8), [V4.S4, V5.S4, V6.S4, V7.S4]
@@ -277,6 +359,9 @@ TEXT ·encryptBlockAsm(SB),NOSPLIT,$0
 	VMOV t0.S[2], t2.S[0]
 	VMOV t0.S[3], t3.S[0]
 
+	CMP $2, R11
+	BEQ neonblock
+
 	load_global_data_2()
 
 	VEOR ZERO.B16, ZERO.B16, ZERO.B16
@@ -299,6 +384,28 @@ encryptBlockLoop:
 	VST1 [t3.B16], (R9)
 	RET
 
+neonblock:
+	LOAD_SM4_NEON_CONSTS()
+
+	EOR R0, R0
+
+neonBlockLoop:
+		SM4_ROUND_NEON(R8, R19, x, y, XTMP6, t0, t1, t2, t3)
+		SM4_ROUND_NEON(R8, R19, x, y, XTMP6, t1, t2, t3, t0)
+		SM4_ROUND_NEON(R8, R19, x, y, XTMP6, t2, t3, t0, t1)
+		SM4_ROUND_NEON(R8, R19, x, y, XTMP6, t3, t0, t1, t2)
+
+		ADD $16, R0
+		CMP $128, R0
+		BNE neonBlockLoop
+
+	VMOV t2.S[0], t3.S[1]
+	VMOV t1.S[0], t3.S[2]
+	VMOV t0.S[0], t3.S[3]
+	VREV32 t3.B16, t3.B16
+	VST1 [t3.B16], (R9)
+	RET
+
 sm4niblock:
 	VLD1 (R10), [V8.B16]
 	VREV32 V8.B16, V8.B16
@@ -156,12 +156,7 @@ func decryptBlockGo(xk []uint32, dst, src []byte) {
 
 // T
 func t(in uint32) uint32 {
-	var b uint32
-
-	b = uint32(sbox[in&0xff])
-	b |= uint32(sbox[(in>>8)&0xff]) << 8
-	b |= uint32(sbox[(in>>16)&0xff]) << 16
-	b |= uint32(sbox[(in>>24)&0xff]) << 24
+	b := sboxWord(in)
 
 	// L
 	return b ^ (b<<2 | b>>30) ^ (b<<10 | b>>22) ^ (b<<18 | b>>14) ^ (b<<24 | b>>8)
@@ -169,20 +164,8 @@ func t(in uint32) uint32 {
 
 // T'
 func t2(in uint32) uint32 {
-	var b uint32
-
-	b = uint32(sbox[in&0xff])
-	b |= uint32(sbox[(in>>8)&0xff]) << 8
-	b |= uint32(sbox[(in>>16)&0xff]) << 16
-	b |= uint32(sbox[(in>>24)&0xff]) << 24
+	b := sboxWord(in)
 
 	// L2
 	return b ^ (b<<13 | b>>19) ^ (b<<23 | b>>9)
 }
-
-func precompute_t(in uint32) uint32 {
-	return sbox_t0[byte(in>>24)] ^
-		sbox_t1[byte(in>>16)] ^
-		sbox_t2[byte(in>>8)] ^
-		sbox_t3[byte(in)]
-}
@@ -0,0 +1,10 @@
+//go:build tinygo || gmsm_small || gmsm_sm4ct
+
+package sm4
+
+// precompute_t falls back to the T transformation without the 4KB precomputed
+// tables, to reduce the memory footprint, or because their lookups are not
+// constant time with gmsm_sm4ct.
+func precompute_t(in uint32) uint32 {
+	return t(in)
+}
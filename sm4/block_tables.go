@@ -0,0 +1,12 @@
+//go:build !tinygo && !gmsm_small && !gmsm_sm4ct
+
+package sm4
+
+// precompute_t is the T transformation using the precomputed tables, which
+// combine the sbox and the linear transformation L.
+func precompute_t(in uint32) uint32 {
+	return sbox_t0[byte(in>>24)] ^
+		sbox_t1[byte(in>>16)] ^
+		sbox_t2[byte(in>>8)] ^
+		sbox_t3[byte(in)]
+}
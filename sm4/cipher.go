@@ -3,9 +3,11 @@ package sm4
 
 import (
 	"crypto/cipher"
+	"errors"
 	"fmt"
 
 	"github.com/emmansun/gmsm/internal/alias"
+	"github.com/emmansun/gmsm/internal/impl"
 )
 
 // BlockSize the sm4 block size in bytes.
@@ -13,6 +15,16 @@ const BlockSize = 16
 
 const rounds = 32
 
+// ErrInvalidKeySize is wrapped by the error of NewCipher for a key which is
+// not 16 bytes, test it with [errors.Is].
+var ErrInvalidKeySize = errors.New("sm4: invalid key size")
+
+func init() {
+	// the generic implementation uses table lookups, it's constant time only
+	// with the gmsm_sm4ct build tag.
+	impl.Register("sm4", impl.Generic, true, constantTimeSbox)
+}
+
 // A cipher is an instance of SM4 encryption using a particular key.
 type sm4Cipher struct {
 	enc []uint32
@@ -25,10 +37,13 @@ func NewCipher(key []byte) (cipher.Block, error) {
 	k := len(key)
 	switch k {
 	default:
-		return nil, fmt.Errorf("sm4: invalid key size %d", k)
+		return nil, fmt.Errorf("%w %d", ErrInvalidKeySize, k)
 	case 16:
 		break
 	}
+	if err := impl.CheckConstantTime("sm4"); err != nil {
+		return nil, err
+	}
 	return newCipher(key)
 }
 
@@ -7,18 +7,30 @@ import (
 	"os"
 
 	"github.com/emmansun/gmsm/internal/alias"
+	"github.com/emmansun/gmsm/internal/impl"
 	"golang.org/x/sys/cpu"
 )
 
 var supportSM4 = cpu.ARM64.HasSM4 && os.Getenv("DISABLE_SM4NI") != "1"
 var supportsAES = cpu.X86.HasAES || cpu.ARM64.HasAES
+
+// supportsNEON reports whether the NEON table lookup implementation is used,
+// on arm64 cores without the SM4 and AES instructions.
+var supportsNEON = cpu.ARM64.HasASIMD && !supportSM4 && !supportsAES
 var supportsGFMUL = cpu.X86.HasPCLMULQDQ || cpu.ARM64.HasPMULL
 var useAVX2 = cpu.X86.HasAVX2
 var useAVX = cpu.X86.HasAVX
 
+func init() {
+	impl.Register("sm4", "SM4", supportSM4, true)
+	impl.Register("sm4", "AES", supportsAES, true)
+	impl.Register("sm4", "NEON", supportsNEON, true)
+}
+
 const (
 	INST_AES int = iota
 	INST_SM4
+	INST_NEON
 )
 
 //go:noescape
@@ -34,6 +46,7 @@ type sm4CipherAsm struct {
 	sm4Cipher
 	batchBlocks int
 	blocksSize  int
+	inst        int
 }
 
 func newCipher(key []byte) (cipher.Block, error) {
@@ -41,6 +54,10 @@ func newCipher(key []byte) (cipher.Block, error) {
 		return newCipherNI(key)
 	}
 
+	if supportsNEON {
+		return newCipherNEON(key)
+	}
+
 	if !supportsAES {
 		return newCipherGeneric(key)
 	}
@@ -49,7 +66,7 @@ func newCipher(key []byte) (cipher.Block, error) {
 	if useAVX2 {
 		blocks = 8
 	}
-	c := &sm4CipherAsm{sm4Cipher{make([]uint32, rounds), make([]uint32, rounds)}, blocks, blocks * BlockSize}
+	c := &sm4CipherAsm{sm4Cipher{make([]uint32, rounds), make([]uint32, rounds)}, blocks, blocks * BlockSize, INST_AES}
 	expandKeyAsm(&key[0], &ck[0], &c.enc[0], &c.dec[0], INST_AES)
 	if supportsGFMUL {
 		return &sm4CipherGCM{c}, nil
@@ -69,7 +86,7 @@ func (c *sm4CipherAsm) Encrypt(dst, src []byte) {
 	if alias.InexactOverlap(dst[:BlockSize], src[:BlockSize]) {
 		panic("sm4: invalid buffer overlap")
 	}
-	encryptBlockAsm(&c.enc[0], &dst[0], &src[0], INST_AES)
+	encryptBlockAsm(&c.enc[0], &dst[0], &src[0], c.inst)
 }
 
 func (c *sm4CipherAsm) EncryptBlocks(dst, src []byte) {
@@ -82,7 +99,7 @@ func (c *sm4CipherAsm) EncryptBlocks(dst, src []byte) {
 	if alias.InexactOverlap(dst[:c.blocksSize], src[:c.blocksSize]) {
 		panic("sm4: invalid buffer overlap")
 	}
-	encryptBlocksAsm(&c.enc[0], dst, src, INST_AES)
+	encryptBlocksAsm(&c.enc[0], dst, src, c.inst)
 }
 
 func (c *sm4CipherAsm) Decrypt(dst, src []byte) {
@@ -95,7 +112,7 @@ func (c *sm4CipherAsm) Decrypt(dst, src []byte) {
 	if alias.InexactOverlap(dst[:BlockSize], src[:BlockSize]) {
 		panic("sm4: invalid buffer overlap")
 	}
-	encryptBlockAsm(&c.dec[0], &dst[0], &src[0], INST_AES)
+	encryptBlockAsm(&c.dec[0], &dst[0], &src[0], c.inst)
 }
 
 func (c *sm4CipherAsm) DecryptBlocks(dst, src []byte) {
@@ -108,7 +125,7 @@ func (c *sm4CipherAsm) DecryptBlocks(dst, src []byte) {
 	if alias.InexactOverlap(dst[:c.blocksSize], src[:c.blocksSize]) {
 		panic("sm4: invalid buffer overlap")
 	}
-	encryptBlocksAsm(&c.dec[0], dst, src, INST_AES)
+	encryptBlocksAsm(&c.dec[0], dst, src, c.inst)
 }
 
 // expandKey is used by BenchmarkExpand to ensure that the asm implementation
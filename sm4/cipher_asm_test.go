@@ -18,6 +18,8 @@ func TestWithoutGFMUL(t *testing.T) {
 
 	if supportSM4 {
 		c, err = newCipherNI(key)
+	} else if supportsNEON {
+		c, err = newCipherNEON(key)
 	} else if !supportsAES {
 		c, err = newCipherGeneric(key)
 	} else {
@@ -25,7 +27,7 @@ func TestWithoutGFMUL(t *testing.T) {
 		if useAVX2 {
 			blocks = 8
 		}
-		c1 := &sm4CipherAsm{sm4Cipher{make([]uint32, rounds), make([]uint32, rounds)}, blocks, blocks * BlockSize}
+		c1 := &sm4CipherAsm{sm4Cipher{make([]uint32, rounds), make([]uint32, rounds)}, blocks, blocks * BlockSize, INST_AES}
 		expandKeyAsm(&key[0], &ck[0], &c1.enc[0], &c1.dec[0], INST_AES)
 		c = c1
 	}
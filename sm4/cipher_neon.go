@@ -0,0 +1,49 @@
+//go:build arm64 && !purego
+
+package sm4
+
+import (
+	"crypto/cipher"
+)
+
+// Assert that sm4CipherNEON implements the ctrAble and gcmAble interfaces.
+var _ ctrAble = (*sm4CipherNEON)(nil)
+var _ gcmAble = (*sm4CipherNEON)(nil)
+
+// sm4CipherNEON is the SM4 cipher of the arm64 cores without the SM4 and AES
+// instructions, it encrypts 8 blocks in parallel with NEON table lookups of
+// the sbox. It only provides the optimized CTR and GCM modes, the assembly of
+// the other modes is built on AESE.
+type sm4CipherNEON struct {
+	c *sm4CipherAsm
+}
+
+func newCipherNEON(key []byte) (cipher.Block, error) {
+	c := &sm4CipherAsm{sm4Cipher{make([]uint32, rounds), make([]uint32, rounds)}, 8, 8 * BlockSize, INST_NEON}
+	expandKeyGo(key, c.enc, c.dec)
+	return &sm4CipherNEON{c}, nil
+}
+
+func (c *sm4CipherNEON) BlockSize() int { return BlockSize }
+
+func (c *sm4CipherNEON) Concurrency() int { return c.c.batchBlocks }
+
+func (c *sm4CipherNEON) Encrypt(dst, src []byte) { c.c.Encrypt(dst, src) }
+
+func (c *sm4CipherNEON) Decrypt(dst, src []byte) { c.c.Decrypt(dst, src) }
+
+func (c *sm4CipherNEON) EncryptBlocks(dst, src []byte) { c.c.EncryptBlocks(dst, src) }
+
+func (c *sm4CipherNEON) DecryptBlocks(dst, src []byte) { c.c.DecryptBlocks(dst, src) }
+
+// NewCTR returns a Stream which encrypts/decrypts using the SM4 block
+// cipher in counter mode. The length of iv must be the same as BlockSize.
+func (c *sm4CipherNEON) NewCTR(iv []byte) cipher.Stream {
+	return c.c.NewCTR(iv)
+}
+
+// NewGCM returns the SM4 cipher wrapped in Galois Counter Mode. This is only
+// called by crypto/cipher.NewGCM via the gcmAble interface.
+func (c *sm4CipherNEON) NewGCM(nonceSize, tagSize int) (cipher.AEAD, error) {
+	return c.c.NewGCM(nonceSize, tagSize)
+}
@@ -0,0 +1,10 @@
+//go:build amd64 && !purego
+
+package sm4
+
+import "crypto/cipher"
+
+// newCipherNEON is not reachable on amd64, where supportsNEON is false.
+func newCipherNEON(key []byte) (cipher.Block, error) {
+	panic("sm4: NEON is not available on amd64")
+}
@@ -0,0 +1,75 @@
+//go:build arm64 && !purego
+
+package sm4
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+
+	"golang.org/x/sys/cpu"
+)
+
+func TestNEON(t *testing.T) {
+	if !cpu.ARM64.HasASIMD {
+		t.Skip("NEON is not available")
+	}
+	key := []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10}
+	c, err := newCipherNEON(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generic, _ := newCipherGeneric(key)
+	neon := c.(*sm4CipherNEON)
+
+	src := make([]byte, 8*BlockSize)
+	for i := range src {
+		src[i] = byte(i * 7)
+	}
+	want := make([]byte, len(src))
+	for i := 0; i < len(src); i += BlockSize {
+		generic.Encrypt(want[i:], src[i:])
+	}
+	got := make([]byte, len(src))
+	neon.EncryptBlocks(got, src)
+	if !bytes.Equal(got, want) {
+		t.Errorf("EncryptBlocks = %x, want %x", got, want)
+	}
+	neon.DecryptBlocks(got, want)
+	if !bytes.Equal(got, src) {
+		t.Errorf("DecryptBlocks = %x, want %x", got, src)
+	}
+	neon.c.blocksSize = 4 * BlockSize
+	neon.EncryptBlocks(got[:4*BlockSize], src)
+	if !bytes.Equal(got[:4*BlockSize], want[:4*BlockSize]) {
+		t.Errorf("EncryptBlocks of 4 blocks = %x, want %x", got[:4*BlockSize], want[:4*BlockSize])
+	}
+	neon.c.blocksSize = 8 * BlockSize
+	neon.Encrypt(got, src)
+	if !bytes.Equal(got[:BlockSize], want[:BlockSize]) {
+		t.Errorf("Encrypt = %x, want %x", got[:BlockSize], want[:BlockSize])
+	}
+	neon.Decrypt(got, want)
+	if !bytes.Equal(got[:BlockSize], src[:BlockSize]) {
+		t.Errorf("Decrypt = %x, want %x", got[:BlockSize], src[:BlockSize])
+	}
+
+	// CTR and GCM of 8 block batches against the generic implementation.
+	iv := make([]byte, BlockSize)
+	msg := make([]byte, 1000)
+	ctrGot, ctrWant := make([]byte, len(msg)), make([]byte, len(msg))
+	cipher.NewCTR(neon, iv).XORKeyStream(ctrGot, msg)
+	cipher.NewCTR(generic, iv).XORKeyStream(ctrWant, msg)
+	if !bytes.Equal(ctrGot, ctrWant) {
+		t.Error("CTR mismatch")
+	}
+	aead, err := cipher.NewGCM(neon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genericAEAD, _ := cipher.NewGCM(generic)
+	nonce := make([]byte, aead.NonceSize())
+	if !bytes.Equal(aead.Seal(nil, nonce, msg, iv), genericAEAD.Seal(nil, nonce, msg, iv)) {
+		t.Error("GCM mismatch")
+	}
+}
@@ -1,6 +1,7 @@
 package sm4
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -60,6 +61,14 @@ func Test_sample2(t *testing.T) {
 	}
 }
 
+func TestInvalidKeySize(t *testing.T) {
+	for _, n := range []int{0, 15, 17, 32} {
+		if _, err := NewCipher(make([]byte, n)); !errors.Is(err, ErrInvalidKeySize) {
+			t.Errorf("%d bytes key: got %v, want ErrInvalidKeySize", n, err)
+		}
+	}
+}
+
 func TestEncryptDecryptPanic(t *testing.T) {
 	key := make([]byte, 16)
 	src := make([]byte, 15)
@@ -123,3 +132,28 @@ func BenchmarkExpand(b *testing.B) {
 		expandKey(tt.key, c.enc, c.dec)
 	}
 }
+
+func TestSboxScan(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		in := uint32(i) | uint32(255-i)<<8 | uint32(i^0x5a)<<16 | uint32(i*7)<<24
+		if got, want := sboxScan(in), sboxLookup(in); got != want {
+			t.Fatalf("sboxScan(%08x) = %08x, want %08x", in, got, want)
+		}
+	}
+	for _, tt := range encryptTests {
+		c := &sm4Cipher{make([]uint32, rounds), make([]uint32, rounds)}
+		expandKeyGo(tt.key, c.enc, c.dec)
+		out := make([]byte, BlockSize)
+		encryptBlockGo(c.enc, out, tt.in)
+		if !reflect.DeepEqual(out, tt.out) {
+			t.Errorf("encryptBlockGo = %x, want %x", out, tt.out)
+		}
+	}
+}
+
+func BenchmarkSboxScan(b *testing.B) {
+	var x uint32
+	for i := 0; i < b.N; i++ {
+		x = sboxScan(x + uint32(i))
+	}
+}
@@ -0,0 +1,119 @@
+// NEON implementation of the SM4 sbox with table lookups, for arm64 cores
+// without the SM4 and AES instructions. The 256 bytes sbox is kept in the 16
+// registers V16-V31, each VTBL looks up the indexes in one quarter of it and
+// returns zero for the indexes out of its range, the quarters are selected by
+// flipping the two high bits of the indexes. Like the AESE based sbox, the
+// lookups don't access memory, so their timing doesn't depend on the data.
+
+DATA sbox_neon<>+0x00(SB)/8, $0xB73DE1CCFEE990D6
+DATA sbox_neon<>+0x08(SB)/8, $0x052CFB28C214B616
+DATA sbox_neon<>+0x10(SB)/8, $0xC304BE2A769A672B
+DATA sbox_neon<>+0x18(SB)/8, $0x99068649261344AA
+DATA sbox_neon<>+0x20(SB)/8, $0x7A98EF91F450429C
+DATA sbox_neon<>+0x28(SB)/8, $0x62ACCFED430B5433
+DATA sbox_neon<>+0x30(SB)/8, $0x95E808C9A91CB3E4
+DATA sbox_neon<>+0x38(SB)/8, $0xA63F8F75FA94DF80
+DATA sbox_neon<>+0x40(SB)/8, $0xBA1773F3FCA70747
+DATA sbox_neon<>+0x48(SB)/8, $0xA84F85E6193C5983
+DATA sbox_neon<>+0x50(SB)/8, $0x8BDA6471B2816B68
+DATA sbox_neon<>+0x58(SB)/8, $0x359D56704B0FEBF8
+DATA sbox_neon<>+0x60(SB)/8, $0xA2D158635E0E241E
+DATA sbox_neon<>+0x68(SB)/8, $0x877821013B7C2225
+DATA sbox_neon<>+0x70(SB)/8, $0x5227D39F574600D4
+DATA sbox_neon<>+0x78(SB)/8, $0x9EC8C4A0E702364C
+DATA sbox_neon<>+0x80(SB)/8, $0xB538C740D28ABFEA
+DATA sbox_neon<>+0x88(SB)/8, $0xA11561F9CEF2F7A3
+DATA sbox_neon<>+0x90(SB)/8, $0x551A349BA45DAEE0
+DATA sbox_neon<>+0x98(SB)/8, $0xE3B18CF5303293AD
+DATA sbox_neon<>+0xa0(SB)/8, $0x60CA66822EE2F61D
+DATA sbox_neon<>+0xa8(SB)/8, $0x6F4E530DAB2329C0
+DATA sbox_neon<>+0xb0(SB)/8, $0x2F8EFDDE4537DBD5
+DATA sbox_neon<>+0xb8(SB)/8, $0x515B6C6D726AFF03
+DATA sbox_neon<>+0xc0(SB)/8, $0x7FBCDDBB92AF1B8D
+DATA sbox_neon<>+0xc8(SB)/8, $0xD85A101F415CD911
+DATA sbox_neon<>+0xd0(SB)/8, $0xBD7BCDA58831C10A
+DATA sbox_neon<>+0xd8(SB)/8, $0xB0B4E5B812D0742D
+DATA sbox_neon<>+0xe0(SB)/8, $0x7E77960C4A976989
+DATA sbox_neon<>+0xe8(SB)/8, $0x84C66EC509F1B965
+DATA sbox_neon<>+0xf0(SB)/8, $0x204DDC3AEC7DF018
+DATA sbox_neon<>+0xf8(SB)/8, $0x4839CBD73E5FEE79
+GLOBL sbox_neon<>(SB), (16+8), $256
+
+#define NEON_R08_MASK V12
+#define NEON_X40 V13
+#define NEON_XC0 V14
+
+#define LOAD_SM4_NEON_CONSTS() \
+	MOVD $sbox_neon<>(SB), R20                                  \
+	VLD1.P 64(R20), [V16.B16, V17.B16, V18.B16, V19.B16]        \
+	VLD1.P 64(R20), [V20.B16, V21.B16, V22.B16, V23.B16]        \
+	VLD1.P 64(R20), [V24.B16, V25.B16, V26.B16, V27.B16]        \
+	VLD1 (R20), [V28.B16, V29.B16, V30.B16, V31.B16]            \
+	MOVD $r08_mask<>(SB), R20                                   \
+	VLD1 (R20), [NEON_R08_MASK.B16]                             \
+	MOVW $0x40404040, R20                                       \
+	VMOV R20, NEON_X40.S4                                       \
+	MOVW $0xC0C0C0C0, R20                                       \
+	VMOV R20, NEON_XC0.S4
+
+// SM4 sbox function with table lookups
+// parameters:
+// -  x: 128 bits register as sbox input/output data
+// -  y: 128 bits temp register
+// -  z: 128 bits temp register
+#define SM4_SBOX_NEON(x, y, z) \
+	VTBL x.B16, [V16.B16, V17.B16, V18.B16, V19.B16], y.B16;   \ // indexes 0x00-0x3f
+	VEOR NEON_X40.B16, x.B16, x.B16;                            \
+	VTBL x.B16, [V20.B16, V21.B16, V22.B16, V23.B16], z.B16;   \ // indexes 0x40-0x7f
+	VORR z.B16, y.B16, y.B16;                                   \
+	VEOR NEON_XC0.B16, x.B16, x.B16;                            \
+	VTBL x.B16, [V24.B16, V25.B16, V26.B16, V27.B16], z.B16;   \ // indexes 0x80-0xbf
+	VORR z.B16, y.B16, y.B16;                                   \
+	VEOR NEON_X40.B16, x.B16, x.B16;                            \
+	VTBL x.B16, [V28.B16, V29.B16, V30.B16, V31.B16], z.B16;   \ // indexes 0xc0-0xff
+	VORR z.B16, y.B16, x.B16
+
+// SM4 TAO L1 function with table lookups
+// parameters:
+// -  x: 128 bits register as TAO_L1 input/output data
+// -  y: 128 bits temp register
+// -  z: 128 bits temp register
+#define SM4_TAO_L1_NEON(x, y, z)         \
+	SM4_SBOX_NEON(x, y, z);                              \
+	VTBL NEON_R08_MASK.B16, [x.B16], y.B16;              \ // y = x <<< 8
+	VTBL NEON_R08_MASK.B16, [y.B16], z.B16;              \ // z = x <<< 16
+	VEOR x.B16, y.B16, y.B16;                            \ // y = x ^ (x <<< 8)
+	VEOR z.B16, y.B16, y.B16;                            \ // y = x ^ (x <<< 8) ^ (x <<< 16)
+	VTBL NEON_R08_MASK.B16, [z.B16], z.B16;              \ // z = x <<< 24
+	VEOR z.B16, x.B16, x.B16;                            \ // x = x ^ (x <<< 24)
+	VSHL $2, y.S4, z.S4;                                 \
+	VSRI $30, y.S4, z.S4;                                \ // z = (x <<< 2) ^ (x <<< 10) ^ (x <<< 18)
+	VEOR z.B16, x.B16, x.B16
+
+// SM4 round function with table lookups
+// t0 ^= tao_l1(t1^t2^t3^xk)
+#define SM4_ROUND_NEON(RK, tmp32, x, y, z, t0, t1, t2, t3) \
+	MOVW.P 4(RK), tmp32;                              \
+	VDUP tmp32, x.S4;                                 \
+	VEOR t1.B16, x.B16, x.B16;                        \
+	VEOR t2.B16, x.B16, x.B16;                        \
+	VEOR t3.B16, x.B16, x.B16;                        \
+	SM4_TAO_L1_NEON(x, y, z);                         \
+	VEOR x.B16, t0.B16, t0.B16
+
+// SM4 round function of 8 blocks with table lookups
+// t0 ^= tao_l1(t1^t2^t3^xk), t4 ^= tao_l1(t5^t6^t7^xk)
+#define SM4_8BLOCKS_ROUND_NEON(RK, tmp32, x, y, z, tmp, t0, t1, t2, t3, t4, t5, t6, t7) \
+	MOVW.P 4(RK), tmp32;                              \
+	VDUP tmp32, tmp.S4;                               \
+	VEOR t1.B16, tmp.B16, x.B16;                      \
+	VEOR t2.B16, x.B16, x.B16;                        \
+	VEOR t3.B16, x.B16, x.B16;                        \
+	SM4_TAO_L1_NEON(x, y, z);                         \
+	VEOR x.B16, t0.B16, t0.B16;                       \
+	;                                                 \
+	VEOR t5.B16, tmp.B16, x.B16;                      \
+	VEOR t6.B16, x.B16, x.B16;                        \
+	VEOR t7.B16, x.B16, x.B16;                        \
+	SM4_TAO_L1_NEON(x, y, z);                         \
+	VEOR x.B16, t4.B16, t4.B16
@@ -0,0 +1,47 @@
+package sm4
+
+// sboxWords is the sbox packed in little endian 64-bit words, sbox[i] is the
+// byte i&7 of sboxWords[i>>3].
+var sboxWords = func() (w [32]uint64) {
+	for i, s := range sbox {
+		w[i>>3] |= uint64(s) << (8 * (i & 7))
+	}
+	return
+}()
+
+// sboxLookup substitutes the 4 bytes of in with table lookups, whose memory
+// access pattern depends on in.
+func sboxLookup(in uint32) uint32 {
+	return uint32(sbox[in&0xff]) |
+		uint32(sbox[(in>>8)&0xff])<<8 |
+		uint32(sbox[(in>>16)&0xff])<<16 |
+		uint32(sbox[(in>>24)&0xff])<<24
+}
+
+// sboxScan substitutes the 4 bytes of in in constant time: all 32 words of
+// the packed sbox are read for each call, the words of the input bytes are
+// selected with masks and their bytes with shifts, so neither the memory
+// access pattern nor the branches depend on in.
+func sboxScan(in uint32) uint32 {
+	h0 := uint64(in>>3) & 31
+	h1 := uint64(in>>11) & 31
+	h2 := uint64(in>>19) & 31
+	h3 := uint64(in>>27) & 31
+	var w0, w1, w2, w3 uint64
+	for i := uint64(0); i < 32; i++ {
+		w := sboxWords[i]
+		w0 |= w & eqMask(i, h0)
+		w1 |= w & eqMask(i, h1)
+		w2 |= w & eqMask(i, h2)
+		w3 |= w & eqMask(i, h3)
+	}
+	return uint32(byte(w0>>(8*(in&7)))) |
+		uint32(byte(w1>>(8*((in>>8)&7))))<<8 |
+		uint32(byte(w2>>(8*((in>>16)&7))))<<16 |
+		uint32(byte(w3>>(8*((in>>24)&7))))<<24
+}
+
+// eqMask returns all ones if x == y, zero otherwise, for x, y < 2^63.
+func eqMask(x, y uint64) uint64 {
+	return -(((x ^ y) - 1) >> 63)
+}
@@ -0,0 +1,14 @@
+//go:build gmsm_sm4ct
+
+package sm4
+
+// constantTimeSbox reports whether the generic implementation evaluates the
+// sbox in constant time. The gmsm_sm4ct build tag trades an order of
+// magnitude of the throughput of the generic implementation for resistance to
+// cache-timing attacks, e.g. on shared hosts without the SM4 or AES
+// instructions.
+const constantTimeSbox = true
+
+func sboxWord(in uint32) uint32 {
+	return sboxScan(in)
+}
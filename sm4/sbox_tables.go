@@ -0,0 +1,11 @@
+//go:build !gmsm_sm4ct
+
+package sm4
+
+// constantTimeSbox reports whether the generic implementation evaluates the
+// sbox in constant time.
+const constantTimeSbox = false
+
+func sboxWord(in uint32) uint32 {
+	return sboxLookup(in)
+}
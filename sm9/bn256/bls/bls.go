@@ -0,0 +1,286 @@
+// Package bls implements a small toolkit of pairing-based aggregate and
+// multi-signature primitives over the SM9 bn256 groups, independent of the
+// SM9 semantics: key generation, proof-of-possession, signature and public key
+// aggregation and batched pairing verification, in the style of BLS
+// signatures (https://datatracker.ietf.org/doc/draft-irtf-cfrg-bls-signature/).
+//
+// Signatures are points of G1, public keys are points of G2. Messages are
+// hashed to G1 with bn256.HashToG1, the constant time RFC 9380 random oracle
+// encoding with SM3.
+//
+// This package is intended for research and prototyping, the constructions
+// are not standardized for the SM9 curve.
+package bls
+
+import (
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/emmansun/gmsm/sm9/bn256"
+)
+
+// Domain separation tags of the signature and the proof-of-possession hashes,
+// named after the hash to curve suite SM9G1_XMD:SM3_SVDW_RO_ like the
+// ciphersuites of the BLS signature draft.
+var (
+	SignatureDST  = []byte("BLS_SIG_SM9G1_XMD:SM3_SVDW_RO_POP_")
+	PossessionDST = []byte("BLS_POP_SM9G1_XMD:SM3_SVDW_RO_POP_")
+)
+
+// PrivateKey is a BLS private key, a scalar in [1, Order-1].
+type PrivateKey struct {
+	PublicKey
+	D *big.Int
+}
+
+// PublicKey is a BLS public key, [D]Gen2.
+type PublicKey struct {
+	Point *bn256.G2
+}
+
+// GenerateKey generates a private key with randomness from rand.
+func GenerateKey(rand io.Reader) (*PrivateKey, error) {
+	d, pub, err := bn256.RandomG2(rand)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{PublicKey{pub}, d}, nil
+}
+
+// NewPrivateKey creates a private key from the big-endian scalar d.
+func NewPrivateKey(d []byte) (*PrivateKey, error) {
+	k := new(big.Int).SetBytes(d)
+	if k.Sign() == 0 || k.Cmp(bn256.Order) >= 0 {
+		return nil, errors.New("bls: invalid private key")
+	}
+	pub, err := new(bn256.G2).ScalarBaseMult(bn256.NormalizeScalar(k.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{PublicKey{pub}, k}, nil
+}
+
+// Public returns the public key corresponding to priv.
+func (priv *PrivateKey) Public() *PublicKey {
+	return &priv.PublicKey
+}
+
+// Validate checks that pub is a point of the order Order subgroup of the
+// twist and is not the identity.
+func (pub *PublicKey) Validate() error {
+	if pub == nil || pub.Point == nil || pub.Point.IsInfinity() {
+		return errors.New("bls: public key is the point at infinity")
+	}
+	if !pub.Point.IsOnCurve() {
+		return errors.New("bls: public key is not on the curve")
+	}
+	if !pub.Point.IsInSubgroup() {
+		return errors.New("bls: public key is not in G2")
+	}
+	return nil
+}
+
+// HashToG1 hashes msg to a point of G1 with the domain separation tag dst, it
+// is bn256.HashToG1 and runs in constant time.
+func HashToG1(msg, dst []byte) (*bn256.G1, error) {
+	return bn256.HashToG1(msg, dst)
+}
+
+func sign(priv *PrivateKey, msg, dst []byte) (*bn256.G1, error) {
+	if priv == nil || priv.D == nil || priv.D.Sign() <= 0 || priv.D.Cmp(bn256.Order) >= 0 {
+		return nil, errors.New("bls: invalid private key")
+	}
+	h, err := HashToG1(msg, dst)
+	if err != nil {
+		return nil, err
+	}
+	return new(bn256.G1).ScalarMult(h, bn256.NormalizeScalar(priv.D.Bytes()))
+}
+
+// Sign signs msg with priv, the signature is [D]H(msg).
+func Sign(priv *PrivateKey, msg []byte) (*bn256.G1, error) {
+	return sign(priv, msg, SignatureDST)
+}
+
+func verify(pub *PublicKey, msg []byte, sig *bn256.G1, dst []byte) bool {
+	if pub.Validate() != nil || !validSignature(sig) {
+		return false
+	}
+	h, err := HashToG1(msg, dst)
+	if err != nil {
+		return false
+	}
+	// e(sig, -Gen2) * e(H(msg), pub) == 1
+	return PairingCheck([]*bn256.G1{sig, h},
+		[]*bn256.G2{negGen2(), pub.Point})
+}
+
+// Verify reports whether sig is a valid signature of msg by pub.
+func Verify(pub *PublicKey, msg []byte, sig *bn256.G1) bool {
+	return verify(pub, msg, sig, SignatureDST)
+}
+
+// ProvePossession returns a proof that the holder of pub knows the private
+// key, i.e. a signature of the public key with PossessionDST. It protects
+// FastAggregateVerify against rogue key attacks.
+func ProvePossession(priv *PrivateKey) (*bn256.G1, error) {
+	if priv == nil || priv.Point == nil {
+		return nil, errors.New("bls: invalid private key")
+	}
+	return sign(priv, priv.Point.MarshalUncompressed(), PossessionDST)
+}
+
+// VerifyPossession reports whether proof is a valid proof-of-possession for pub.
+func VerifyPossession(pub *PublicKey, proof *bn256.G1) bool {
+	if pub == nil || pub.Point == nil {
+		return false
+	}
+	return verify(pub, pub.Point.MarshalUncompressed(), proof, PossessionDST)
+}
+
+// AggregatePublicKeys returns the sum of pubs.
+func AggregatePublicKeys(pubs []*PublicKey) (*PublicKey, error) {
+	if len(pubs) == 0 {
+		return nil, errors.New("bls: no public keys to aggregate")
+	}
+	sum := new(bn256.G2)
+	for i, pub := range pubs {
+		if pub == nil || pub.Point == nil {
+			return nil, errors.New("bls: invalid public key")
+		}
+		if i == 0 {
+			sum.Set(pub.Point)
+		} else {
+			sum.Add(sum, pub.Point)
+		}
+	}
+	return &PublicKey{sum}, nil
+}
+
+// AggregateSignatures returns the sum of sigs.
+func AggregateSignatures(sigs []*bn256.G1) (*bn256.G1, error) {
+	if len(sigs) == 0 {
+		return nil, errors.New("bls: no signatures to aggregate")
+	}
+	sum := new(bn256.G1)
+	for i, sig := range sigs {
+		if !validSignature(sig) {
+			return nil, errors.New("bls: invalid signature")
+		}
+		if i == 0 {
+			sum.Set(sig)
+		} else {
+			sum.Add(sum, sig)
+		}
+	}
+	return sum, nil
+}
+
+// AggregateVerify reports whether sig is the aggregate of the signatures of
+// msgs[i] by pubs[i]. The messages must be distinct, which prevents rogue key
+// attacks without proof-of-possession.
+func AggregateVerify(pubs []*PublicKey, msgs [][]byte, sig *bn256.G1) bool {
+	if len(pubs) == 0 || len(pubs) != len(msgs) || !validSignature(sig) {
+		return false
+	}
+	seen := make(map[string]bool, len(msgs))
+	g1s := make([]*bn256.G1, 0, len(pubs)+1)
+	g2s := make([]*bn256.G2, 0, len(pubs)+1)
+	g1s = append(g1s, sig)
+	g2s = append(g2s, negGen2())
+	for i, pub := range pubs {
+		if seen[string(msgs[i])] || pub.Validate() != nil {
+			return false
+		}
+		seen[string(msgs[i])] = true
+		h, err := HashToG1(msgs[i], SignatureDST)
+		if err != nil {
+			return false
+		}
+		g1s = append(g1s, h)
+		g2s = append(g2s, pub.Point)
+	}
+	return PairingCheck(g1s, g2s)
+}
+
+// FastAggregateVerify reports whether sig is the aggregate of the signatures
+// of the same msg by pubs. Every public key must have been checked with
+// VerifyPossession before.
+func FastAggregateVerify(pubs []*PublicKey, msg []byte, sig *bn256.G1) bool {
+	pub, err := AggregatePublicKeys(pubs)
+	if err != nil {
+		return false
+	}
+	return Verify(pub, msg, sig)
+}
+
+// BatchVerify reports whether every sigs[i] is a valid signature of msgs[i]
+// by pubs[i], with a single multi-pairing. The signatures are combined with
+// random 128-bit coefficients from rand, so that invalid signatures can not
+// cancel out each other.
+func BatchVerify(rand io.Reader, pubs []*PublicKey, msgs [][]byte, sigs []*bn256.G1) (bool, error) {
+	if len(pubs) == 0 || len(pubs) != len(msgs) || len(pubs) != len(sigs) {
+		return false, errors.New("bls: mismatched batch lengths")
+	}
+	sum := new(bn256.G1)
+	g1s := make([]*bn256.G1, 0, len(pubs)+1)
+	g2s := make([]*bn256.G2, 0, len(pubs)+1)
+	r := make([]byte, 16)
+	for i, pub := range pubs {
+		if pub.Validate() != nil || !validSignature(sigs[i]) {
+			return false, nil
+		}
+		if _, err := io.ReadFull(rand, r); err != nil {
+			return false, err
+		}
+		k := bn256.NormalizeScalar(r)
+		s, err := new(bn256.G1).ScalarMult(sigs[i], k)
+		if err != nil {
+			return false, err
+		}
+		h, err := HashToG1(msgs[i], SignatureDST)
+		if err != nil {
+			return false, err
+		}
+		if _, err = h.ScalarMult(h, k); err != nil {
+			return false, err
+		}
+		if i == 0 {
+			sum.Set(s)
+		} else {
+			sum.Add(sum, s)
+		}
+		g1s = append(g1s, h)
+		g2s = append(g2s, pub.Point)
+	}
+	g1s = append(g1s, sum)
+	g2s = append(g2s, negGen2())
+	return PairingCheck(g1s, g2s), nil
+}
+
+// PairingCheck reports whether the product of e(g1s[i], g2s[i]) is the
+// identity of GT, with bn256.PairingIsOne. Pairs with a point at infinity are
+// skipped.
+func PairingCheck(g1s []*bn256.G1, g2s []*bn256.G2) bool {
+	if len(g1s) != len(g2s) {
+		return false
+	}
+	pairs := make([]bn256.PairingInput, len(g1s))
+	for i := range g1s {
+		if g1s[i] == nil || g2s[i] == nil {
+			return false
+		}
+		pairs[i] = bn256.PairingInput{G1: g1s[i], G2: g2s[i]}
+	}
+	return bn256.PairingIsOne(pairs...)
+}
+
+func validSignature(sig *bn256.G1) bool {
+	// G1 has cofactor 1, every point on the curve is in G1.
+	return sig != nil && !sig.IsInfinity() && sig.IsOnCurve()
+}
+
+func negGen2() *bn256.G2 {
+	return new(bn256.G2).Neg(bn256.Gen2)
+}
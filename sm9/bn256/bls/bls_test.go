@@ -0,0 +1,252 @@
+package bls
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm9/bn256"
+)
+
+func generateKeys(t *testing.T, n int) []*PrivateKey {
+	t.Helper()
+	keys := make([]*PrivateKey, n)
+	for i := range keys {
+		k, err := GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = k
+	}
+	return keys
+}
+
+func TestHashToG1(t *testing.T) {
+	hash := func(msg string, dst []byte) *bn256.G1 {
+		t.Helper()
+		p, err := HashToG1([]byte(msg), dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	p1 := hash("abc", SignatureDST)
+	p2 := hash("abc", SignatureDST)
+	if !bytes.Equal(p1.Marshal(), p2.Marshal()) {
+		t.Errorf("hash to G1 is not deterministic")
+	}
+	if !p1.IsOnCurve() || p1.IsInfinity() {
+		t.Errorf("invalid hash to G1 result")
+	}
+	want, err := bn256.HashToG1([]byte("abc"), SignatureDST)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(p1.Marshal(), want.Marshal()) {
+		t.Errorf("HashToG1 is not bn256.HashToG1")
+	}
+	p3 := hash("abc", PossessionDST)
+	if bytes.Equal(p1.Marshal(), p3.Marshal()) {
+		t.Errorf("domain separation tag is ignored")
+	}
+	p4 := hash("abd", SignatureDST)
+	if bytes.Equal(p1.Marshal(), p4.Marshal()) {
+		t.Errorf("different messages hash to the same point")
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	priv := generateKeys(t, 1)[0]
+	msg := []byte("hello bls")
+	sig, err := Sign(priv, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Verify(priv.Public(), msg, sig) {
+		t.Errorf("Verify failed")
+	}
+	if Verify(priv.Public(), []byte("hello"), sig) {
+		t.Errorf("Verify with wrong message succeeded")
+	}
+	other := generateKeys(t, 1)[0]
+	if Verify(other.Public(), msg, sig) {
+		t.Errorf("Verify with wrong key succeeded")
+	}
+	if Verify(priv.Public(), msg, new(bn256.G1).Neg(sig)) {
+		t.Errorf("Verify with wrong signature succeeded")
+	}
+
+	priv2, err := NewPrivateKey(priv.D.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(priv2.Point.Marshal(), priv.Point.Marshal()) {
+		t.Errorf("NewPrivateKey public key mismatch")
+	}
+	if _, err = NewPrivateKey(nil); err == nil {
+		t.Errorf("expected error for zero private key")
+	}
+	if _, err = NewPrivateKey(bn256.Order.Bytes()); err == nil {
+		t.Errorf("expected error for private key equals to order")
+	}
+}
+
+func TestPossession(t *testing.T) {
+	keys := generateKeys(t, 2)
+	proof, err := ProvePossession(keys[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyPossession(keys[0].Public(), proof) {
+		t.Errorf("VerifyPossession failed")
+	}
+	if VerifyPossession(keys[1].Public(), proof) {
+		t.Errorf("VerifyPossession with wrong key succeeded")
+	}
+	// a proof-of-possession is not a signature of the public key encoding
+	sig, err := Sign(keys[0], keys[0].Point.MarshalUncompressed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if VerifyPossession(keys[0].Public(), sig) {
+		t.Errorf("signature accepted as proof-of-possession")
+	}
+}
+
+func TestFastAggregateVerify(t *testing.T) {
+	keys := generateKeys(t, 4)
+	msg := []byte("same message")
+	pubs := make([]*PublicKey, len(keys))
+	sigs := make([]*bn256.G1, len(keys))
+	for i, k := range keys {
+		pubs[i] = k.Public()
+		sig, err := Sign(k, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sigs[i] = sig
+	}
+	agg, err := AggregateSignatures(sigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !FastAggregateVerify(pubs, msg, agg) {
+		t.Errorf("FastAggregateVerify failed")
+	}
+	if FastAggregateVerify(pubs[1:], msg, agg) {
+		t.Errorf("FastAggregateVerify with missing key succeeded")
+	}
+	if FastAggregateVerify(pubs, []byte("other message"), agg) {
+		t.Errorf("FastAggregateVerify with wrong message succeeded")
+	}
+	if _, err = AggregateSignatures(nil); err == nil {
+		t.Errorf("expected error for empty signatures")
+	}
+	if _, err = AggregatePublicKeys(nil); err == nil {
+		t.Errorf("expected error for empty public keys")
+	}
+}
+
+func TestAggregateVerify(t *testing.T) {
+	keys := generateKeys(t, 3)
+	pubs := make([]*PublicKey, len(keys))
+	msgs := make([][]byte, len(keys))
+	sigs := make([]*bn256.G1, len(keys))
+	for i, k := range keys {
+		pubs[i] = k.Public()
+		msgs[i] = []byte(fmt.Sprintf("message %d", i))
+		sig, err := Sign(k, msgs[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sigs[i] = sig
+	}
+	agg, err := AggregateSignatures(sigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !AggregateVerify(pubs, msgs, agg) {
+		t.Errorf("AggregateVerify failed")
+	}
+	msgs[2] = msgs[1]
+	if AggregateVerify(pubs, msgs, agg) {
+		t.Errorf("AggregateVerify with duplicated messages succeeded")
+	}
+	if AggregateVerify(pubs[:2], msgs[:2], agg) {
+		t.Errorf("AggregateVerify with missing signer succeeded")
+	}
+}
+
+func TestBatchVerify(t *testing.T) {
+	keys := generateKeys(t, 3)
+	pubs := make([]*PublicKey, len(keys))
+	msgs := make([][]byte, len(keys))
+	sigs := make([]*bn256.G1, len(keys))
+	for i, k := range keys {
+		pubs[i] = k.Public()
+		msgs[i] = []byte("batch")
+		sig, err := Sign(k, msgs[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sigs[i] = sig
+	}
+	ok, err := BatchVerify(rand.Reader, pubs, msgs, sigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Errorf("BatchVerify failed")
+	}
+
+	// two invalid signatures which would cancel out in a plain aggregate
+	delta, err := HashToG1([]byte("delta"), SignatureDST)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := []*bn256.G1{
+		new(bn256.G1).Add(sigs[0], delta),
+		new(bn256.G1).Add(sigs[1], new(bn256.G1).Neg(delta)),
+		sigs[2],
+	}
+	ok, err = BatchVerify(rand.Reader, pubs, msgs, bad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Errorf("BatchVerify with invalid signatures succeeded")
+	}
+	if _, err = BatchVerify(rand.Reader, pubs, msgs, sigs[:2]); err == nil {
+		t.Errorf("expected error for mismatched lengths")
+	}
+}
+
+func TestPairingCheck(t *testing.T) {
+	// e(aP, Q) * e(-P, aQ) == 1
+	a := bn256.NormalizeScalar([]byte{0x12, 0x34})
+	aP, _ := new(bn256.G1).ScalarBaseMult(a)
+	aQ, _ := new(bn256.G2).ScalarBaseMult(a)
+	minusP := new(bn256.G1).Neg(bn256.Gen1)
+	if !PairingCheck([]*bn256.G1{aP, minusP}, []*bn256.G2{bn256.Gen2, aQ}) {
+		t.Errorf("PairingCheck failed")
+	}
+	if PairingCheck([]*bn256.G1{aP, bn256.Gen1}, []*bn256.G2{bn256.Gen2, aQ}) {
+		t.Errorf("PairingCheck with wrong input succeeded")
+	}
+	if !PairingCheck(nil, nil) {
+		t.Errorf("empty PairingCheck should succeed")
+	}
+	if PairingCheck([]*bn256.G1{aP}, nil) {
+		t.Errorf("PairingCheck with mismatched lengths succeeded")
+	}
+}
+
+func TestPublicKeyValidate(t *testing.T) {
+	if (&PublicKey{}).Validate() == nil {
+		t.Errorf("expected error for empty public key")
+	}
+	if (&PublicKey{Point: new(bn256.G2).Neg(bn256.Gen2)}).Validate() != nil {
+		t.Errorf("unexpected error for valid public key")
+	}
+}
@@ -105,32 +105,66 @@ func mulLine(ret *gfP12, a, b, c *gfP2) {
 	gfp4Copy(&ret.z, tz)
 }
 
+// pairingScratch holds all temporaries of the pairing computation. It is
+// allocated once per operation (usually on the stack) and passed down the call
+// tree, so that the Miller loop and the final exponentiation don't allocate.
+type pairingScratch struct {
+	// Miller loop
+	aAffine, minusA, r, newR, q1, minusQ2 twistPoint
+	bAffine                               curvePoint
+	r2, a, b, c                           gfP2
+	f                                     gfP12
+
+	// final exponentiation
+	t0, t1, inv, fp, fp2, fp3 gfP12
+	y0, y1, y2, y3, y4, y6    gfP12
+	fu3p                      gfP12
+}
+
 // R-ate Pairing G2 x G1 -> GT
 //
 // P is a point of order q in G1. Q(x,y) is a point of order q in G2.
 // Note that Q is a point on the sextic twist of the curve over Fp^2, P(x,y) is a point on the
 // curve over the base field Fp
 func miller(q *twistPoint, p *curvePoint) *gfP12 {
-	ret := (&gfP12{}).SetOne()
+	var scratch pairingScratch
+	ret := &gfP12{}
+	return ret.Set(millerWithScratch(q, p, &scratch))
+}
 
-	aAffine := &twistPoint{}
+// millerWithScratch runs the Miller loop with the temporaries of s, the result
+// is stored in s.f and returned. The running point is kept in Jacobian
+// coordinates, or in homogeneous projective ones if useProjectiveMiller is set.
+func millerWithScratch(q *twistPoint, p *curvePoint, s *pairingScratch) *gfP12 {
+	if useProjectiveMiller {
+		return millerProjectiveWithScratch(q, p, s)
+	}
+	return millerJacobianWithScratch(q, p, s)
+}
+
+// millerJacobianWithScratch is millerWithScratch with the running point in
+// Jacobian coordinates.
+func millerJacobianWithScratch(q *twistPoint, p *curvePoint, s *pairingScratch) *gfP12 {
+	ret := s.f.SetOne()
+
+	aAffine := &s.aAffine
 	aAffine.Set(q)
 	aAffine.MakeAffine()
 
-	minusA := &twistPoint{}
+	minusA := &s.minusA
 	minusA.Neg(aAffine)
 
-	bAffine := &curvePoint{}
+	bAffine := &s.bAffine
 	bAffine.Set(p)
 	bAffine.MakeAffine()
 
-	r := &twistPoint{}
+	r := &s.r
 	r.Set(aAffine)
 
-	r2 := (&gfP2{}).Square(&aAffine.y)
+	r2 := s.r2.Square(&aAffine.y)
 
-	a, b, c := &gfP2{}, &gfP2{}, &gfP2{}
-	newR := &twistPoint{}
+	a, b, c := &s.a, &s.b, &s.c
+	newR := &s.newR
 	var tmpR *twistPoint
 	for i := len(sixUPlus2NAF) - 1; i > 0; i-- {
 		lineFunctionDouble(r, newR, bAffine, a, b, c)
@@ -167,7 +201,7 @@ func miller(q *twistPoint, p *curvePoint) *gfP12 {
 	// rewrite this as x̄*β^((-p+1)/3)*β^(-1/3).
 	//
 	// A similar argument can be made for the y value.
-	q1 := &twistPoint{}
+	q1 := &s.q1
 	q1.x.Conjugate(&aAffine.x)
 	q1.x.MulScalar(&q1.x, betaToNegPPlus1Over3)
 	q1.y.Conjugate(&aAffine.y)
@@ -175,7 +209,7 @@ func miller(q *twistPoint, p *curvePoint) *gfP12 {
 	q1.z.SetOne()
 	q1.t.SetOne()
 
-	minusQ2 := &twistPoint{}
+	minusQ2 := &s.minusQ2
 	minusQ2.x.Set(&aAffine.x)
 	minusQ2.x.MulScalar(&minusQ2.x, betaToNegP2Plus1Over3)
 	minusQ2.y.Neg(&aAffine.y)
@@ -201,20 +235,36 @@ func miller(q *twistPoint, p *curvePoint) *gfP12 {
 // GF(p¹²) to obtain an element of GT. https://eprint.iacr.org/2007/390.pdf
 // http://cryptojedi.org/papers/dclxvi-20100714.pdf
 func finalExponentiation(in *gfP12) *gfP12 {
+	var scratch pairingScratch
+	ret := &gfP12{}
+	return ret.Set(finalExponentiationWithScratch(in, &scratch))
+}
+
+// finalExponentiationWithScratch is the final exponentiation with the temporaries
+// of s, the result is stored in s.t0 and returned. in must not be s.t0.
+func finalExponentiationWithScratch(in *gfP12, s *pairingScratch) *gfP12 {
+	t0, t1 := finalExponentiationSplit(in, s)
+	t0.Cyclo6Square(t0).Mul(t0, t1)
+	return t0
+}
+
+// finalExponentiationSplit is the final exponentiation without its last
+// multiplication, the result is t0² * t1 with t0 = s.t0 and t1 = s.t1.
+func finalExponentiationSplit(in *gfP12, s *pairingScratch) (t0, t1 *gfP12) {
 	// This is the p^6-Frobenius
-	t1 := (&gfP12{}).FrobeniusP6(in)
+	t1 = s.t1.FrobeniusP6(in)
 
-	inv := (&gfP12{}).Invert(in)
+	inv := s.inv.Invert(in)
 	t1.Mul(t1, inv)
 
 	t2 := inv.FrobeniusP2(t1) // reuse inv
 	t1.Mul(t1, t2)            // t1 = in ^ ((p^6 - 1) * (p^2 + 1)), the first two parts of the exponentiation
 
-	fp := (&gfP12{}).Frobenius(t1)
-	fp2 := (&gfP12{}).FrobeniusP2(t1)
-	fp3 := (&gfP12{}).Frobenius(fp2)
+	fp := s.fp.Frobenius(t1)
+	fp2 := s.fp2.FrobeniusP2(t1)
+	fp3 := s.fp3.Frobenius(fp2)
 
-	y0 := &gfP12{}
+	y0 := &s.y0
 	y0.MulNC(fp, fp2).Mul(y0, fp3) // y0 = (t1^p) * (t1^(p^2)) * (t1^(p^3))
 
 	// reuse fp, fp2, fp3 local variables
@@ -222,35 +272,42 @@ func finalExponentiation(in *gfP12) *gfP12 {
 	fu2 := fp2.Cyclo6PowToU(fu)
 	fu3 := fp3.Cyclo6PowToU(fu2)
 
-	fu2p := (&gfP12{}).Frobenius(fu2)
-	fu3p := (&gfP12{}).Frobenius(fu3)
+	fu2p := s.inv.Frobenius(fu2) // reuse inv
+	fu3p := s.fu3p.Frobenius(fu3)
 
-	y1 := (&gfP12{}).Conjugate(t1)    // y1 = 1 / t1
-	y2 := (&gfP12{}).FrobeniusP2(fu2) // y2 = (t1^(u^2))^(p^2)
-	y3 := (&gfP12{}).Frobenius(fu)    // y3 = (t1^u)^p
-	y3.Conjugate(y3)                  // y3 = 1 / (t1^u)^p
-	y4 := (&gfP12{}).MulNC(fu, fu2p)  // y4 = (t1^u) * ((t1^(u^2))^p)
-	y4.Conjugate(y4)                  // y4 = 1 / ((t1^u) * ((t1^(u^2))^p))
-	y5 := fu2p.Conjugate(fu2)         // y5 = 1 / t1^(u^2), reuse fu2p
-	y6 := (&gfP12{}).MulNC(fu3, fu3p) // y6 = t1^(u^3) * (t1^(u^3))^p
-	y6.Conjugate(y6)                  // y6 = 1 / (t1^(u^3) * (t1^(u^3))^p)
+	y1 := s.y1.Conjugate(t1)    // y1 = 1 / t1
+	y2 := s.y2.FrobeniusP2(fu2) // y2 = (t1^(u^2))^(p^2)
+	y3 := s.y3.Frobenius(fu)    // y3 = (t1^u)^p
+	y3.Conjugate(y3)            // y3 = 1 / (t1^u)^p
+	y4 := s.y4.MulNC(fu, fu2p)  // y4 = (t1^u) * ((t1^(u^2))^p)
+	y4.Conjugate(y4)            // y4 = 1 / ((t1^u) * ((t1^(u^2))^p))
+	y5 := fu2p.Conjugate(fu2)   // y5 = 1 / t1^(u^2), reuse fu2p
+	y6 := s.y6.MulNC(fu3, fu3p) // y6 = t1^(u^3) * (t1^(u^3))^p
+	y6.Conjugate(y6)            // y6 = 1 / (t1^(u^3) * (t1^(u^3))^p)
 
 	// https://eprint.iacr.org/2008/490.pdf
-	t0 := (&gfP12{}).Cyclo6SquareNC(y6)
+	t0 = s.t0.Cyclo6SquareNC(y6)
 	t0.Mul(t0, y4).Mul(t0, y5)
 	t1.Mul(y3, y5).Mul(t1, t0)
 	t0.Mul(t0, y2)
 	t1.Cyclo6Square(t1).Mul(t1, t0).Cyclo6Square(t1)
 	t0.Mul(t1, y1)
 	t1.Mul(t1, y0)
-	t0.Cyclo6Square(t0).Mul(t0, t1)
 
-	return t0
+	return t0, t1
 }
 
 func pairing(a *twistPoint, b *curvePoint) *gfP12 {
-	e := miller(a, b)
-	ret := finalExponentiation(e)
+	ret := &gfP12{}
+	return pairingTo(ret, a, b)
+}
+
+// pairingTo computes the pairing of a and b into ret with a stack allocated
+// scratch, and returns ret.
+func pairingTo(ret *gfP12, a *twistPoint, b *curvePoint) *gfP12 {
+	var scratch pairingScratch
+	e := millerWithScratch(a, b, &scratch)
+	ret.Set(finalExponentiationWithScratch(e, &scratch))
 
 	if a.IsInfinity() || b.IsInfinity() {
 		ret.SetOne()
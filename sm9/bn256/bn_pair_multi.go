@@ -0,0 +1,144 @@
+package bn256
+
+import (
+	"errors"
+
+	"github.com/emmansun/gmsm/internal/instrument"
+)
+
+// millerPair is the state of one pair of millerMulti.
+type millerPair struct {
+	aAffine, minusA, r, newR twistPoint
+	bAffine                  curvePoint
+}
+
+// millerMulti runs the projective Miller loops of the pairs (qs[i], ps[i])
+// together into ret, so that the squarings of the accumulator are shared, and
+// then returns ret. None of the points may be at infinity.
+func millerMulti(ret *gfP12, qs []*twistPoint, ps []*curvePoint) *gfP12 {
+	pairs := make([]millerPair, len(qs))
+	for i := range pairs {
+		s := &pairs[i]
+		s.aAffine.Set(qs[i])
+		s.aAffine.MakeAffine()
+		s.minusA.Neg(&s.aAffine)
+		s.bAffine.Set(ps[i])
+		s.bAffine.MakeAffine()
+		s.r.Set(&s.aAffine)
+	}
+
+	ret.SetOne()
+	a, b, c := &gfP2{}, &gfP2{}, &gfP2{}
+	for i := len(sixUPlus2NAF) - 1; i > 0; i-- {
+		if i != len(sixUPlus2NAF)-1 {
+			ret.Square(ret)
+		}
+		for j := range pairs {
+			s := &pairs[j]
+			lineFunctionDoubleProjective(&s.r, &s.newR, &s.bAffine, a, b, c)
+			mulLine(ret, a, b, c)
+			switch sixUPlus2NAF[i-1] {
+			case 1:
+				lineFunctionAddProjective(&s.newR, &s.aAffine, &s.r, &s.bAffine, a, b, c)
+			case -1:
+				lineFunctionAddProjective(&s.newR, &s.minusA, &s.r, &s.bAffine, a, b, c)
+			default:
+				s.r.Set(&s.newR)
+				continue
+			}
+			mulLine(ret, a, b, c)
+		}
+	}
+
+	q1, minusQ2 := &twistPoint{}, &twistPoint{}
+	for j := range pairs {
+		s := &pairs[j]
+		// the points of the last two lines, ψ(a) and -ψ²(a), are affine
+		q1.Psi(&s.aAffine)
+		minusQ2.Psi2(&s.aAffine)
+		minusQ2.Neg(minusQ2)
+		lineFunctionAddProjective(&s.r, q1, &s.newR, &s.bAffine, a, b, c)
+		mulLine(ret, a, b, c)
+		lineFunctionAddProjective(&s.newR, minusQ2, &s.r, &s.bAffine, a, b, c)
+		mulLine(ret, a, b, c)
+	}
+	return ret
+}
+
+// gfP12Equal returns 1 if a and b are equal and 0 otherwise, in constant time.
+func gfP12Equal(a, b *gfP12) int {
+	d := &gfP12{}
+	d.Sub(a, b)
+	var acc uint64
+	for _, x := range [...]*gfP4{&d.x, &d.y, &d.z} {
+		for _, y := range [...]*gfP2{&x.x, &x.y} {
+			for _, l := range [...]*gfP{&y.x, &y.y} {
+				acc |= l[0] | l[1] | l[2] | l[3]
+			}
+		}
+	}
+	return int((acc|-acc)>>63 ^ 1)
+}
+
+// PairingCheckProduct returns the product of the pairings e(as[i], bs[i]). The
+// Miller loops share their squarings and a single final exponentiation is done,
+// which is much faster than multiplying the results of Pair.
+func PairingCheckProduct(as []*G1, bs []*G2) (*GT, error) {
+	if len(as) != len(bs) {
+		return nil, errors.New("sm9.bn256: the numbers of G1 and G2 points differ")
+	}
+	done := instrument.Start("sm9", "pairing")
+	defer done(nil)
+
+	e := &GT{&gfP12{}}
+	millerProduct(e.p, as, bs)
+	return e.Finalize(), nil
+}
+
+// millerProduct sets ret to the product of the Miller loops of the pairs
+// which don't have a point at infinity, and then returns ret.
+func millerProduct(ret *gfP12, as []*G1, bs []*G2) *gfP12 {
+	ps := make([]*curvePoint, 0, len(as))
+	qs := make([]*twistPoint, 0, len(bs))
+	for i := range as {
+		if as[i].IsInfinity() || bs[i].IsInfinity() {
+			continue
+		}
+		ps = append(ps, as[i].p)
+		qs = append(qs, bs[i].p)
+	}
+	if len(ps) == 0 {
+		return ret.SetOne()
+	}
+	return millerMulti(ret, qs, ps)
+}
+
+// PairingInput is a pair of points of PairingIsOne.
+type PairingInput struct {
+	G1 *G1
+	G2 *G2
+}
+
+// PairingIsOne reports whether the product of the pairings e(G1, G2) of pairs
+// is the identity, e.g. PairingIsOne({a, b}, {-c, d}) reports whether
+// e(a, b) = e(c, d). It shares the Miller loops like PairingCheckProduct and
+// skips the last multiplication of the final exponentiation: the result
+// t0² * t1 is in the cyclotomic subgroup, where the inverse is the conjugate,
+// so it is the identity if and only if t0² equals the conjugate of t1. No GT
+// element is encoded or returned.
+func PairingIsOne(pairs ...PairingInput) bool {
+	done := instrument.Start("sm9", "pairing")
+	defer done(nil)
+
+	as := make([]*G1, len(pairs))
+	bs := make([]*G2, len(pairs))
+	for i := range pairs {
+		as[i], bs[i] = pairs[i].G1, pairs[i].G2
+	}
+	var scratch pairingScratch
+	f := millerProduct(&scratch.f, as, bs)
+	t0, t1 := finalExponentiationSplit(f, &scratch)
+	t0.Cyclo6Square(t0)
+	t1.Conjugate(t1)
+	return gfP12Equal(t0, t1) == 1
+}
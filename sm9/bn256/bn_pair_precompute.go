@@ -0,0 +1,123 @@
+package bn256
+
+import "github.com/emmansun/gmsm/internal/instrument"
+
+// lineCoeffs are the coefficients of a line of the Miller loop which don't
+// depend on the G1 point, the line evaluated at (Xq, Yq) is
+// (c*Yq*v + a) + b*Xq*w², see mulLine.
+type lineCoeffs struct {
+	a, b, c gfP2
+}
+
+// G2Prepared holds the line coefficients of the Miller loop of a fixed G2
+// point, e.g. the user private key of SM9 decryption, so that pairings with
+// it skip all the G2 point arithmetic. It is safe for concurrent use.
+type G2Prepared struct {
+	lines []lineCoeffs // nil for the point at infinity
+}
+
+// PairingPreCompute returns the precomputed Miller loop of g2, for use with
+// MillerPrecomputed and PairPrecomputed.
+func PairingPreCompute(g2 *G2) *G2Prepared {
+	if g2.IsInfinity() {
+		return &G2Prepared{}
+	}
+	return &G2Prepared{lines: millerLines(g2.p)}
+}
+
+// millerLines runs the projective Miller loop of q without a G1 point and
+// returns its lines in the order of evaluation. The lines are evaluated at
+// (1, 1), which leaves the coefficients of Xq and Yq as they are.
+func millerLines(q *twistPoint) []lineCoeffs {
+	lines := make([]lineCoeffs, 0, 2*len(sixUPlus2NAF))
+	unit := &curvePoint{x: *one, y: *one}
+
+	aAffine := &twistPoint{}
+	aAffine.Set(q)
+	aAffine.MakeAffine()
+	minusA := &twistPoint{}
+	minusA.Neg(aAffine)
+
+	r, newR := &twistPoint{}, &twistPoint{}
+	r.Set(aAffine)
+	var l lineCoeffs
+	for i := len(sixUPlus2NAF) - 1; i > 0; i-- {
+		lineFunctionDoubleProjective(r, newR, unit, &l.a, &l.b, &l.c)
+		lines = append(lines, l)
+		r, newR = newR, r
+		switch sixUPlus2NAF[i-1] {
+		case 1:
+			lineFunctionAddProjective(r, aAffine, newR, unit, &l.a, &l.b, &l.c)
+		case -1:
+			lineFunctionAddProjective(r, minusA, newR, unit, &l.a, &l.b, &l.c)
+		default:
+			continue
+		}
+		lines = append(lines, l)
+		r, newR = newR, r
+	}
+
+	q1, minusQ2 := &twistPoint{}, &twistPoint{}
+	q1.Psi(aAffine)
+	minusQ2.Psi2(aAffine)
+	minusQ2.Neg(minusQ2)
+
+	lineFunctionAddProjective(r, q1, newR, unit, &l.a, &l.b, &l.c)
+	lines = append(lines, l)
+	r, newR = newR, r
+
+	lineFunctionAddProjective(r, minusQ2, newR, unit, &l.a, &l.b, &l.c)
+	lines = append(lines, l)
+
+	return lines
+}
+
+// millerPrecomputed evaluates the lines of millerLines at the affine point p
+// into ret, and then returns ret.
+func millerPrecomputed(ret *gfP12, lines []lineCoeffs, p *curvePoint) *gfP12 {
+	ret.SetOne()
+	b, c := &gfP2{}, &gfP2{}
+	eval := func(l *lineCoeffs) {
+		b.MulScalar(&l.b, &p.x)
+		c.MulScalar(&l.c, &p.y)
+		mulLine(ret, &l.a, b, c)
+	}
+	k := 0
+	for i := len(sixUPlus2NAF) - 1; i > 0; i-- {
+		if i != len(sixUPlus2NAF)-1 {
+			ret.Square(ret)
+		}
+		eval(&lines[k])
+		k++
+		if sixUPlus2NAF[i-1] != 0 {
+			eval(&lines[k])
+			k++
+		}
+	}
+	eval(&lines[k])
+	eval(&lines[k+1])
+	return ret
+}
+
+// MillerPrecomputed is Miller with the precomputed Miller loop of the G2
+// point. MillerPrecomputed(g1, PairingPreCompute(g2)).Finalize() is equivalent
+// to Pair(g1, g2), the output of Miller may differ by a factor which is removed
+// by the final exponentiation.
+func MillerPrecomputed(g1 *G1, g2 *G2Prepared) *GT {
+	ret := &gfP12{}
+	if g1.IsInfinity() || g2.lines == nil {
+		return &GT{ret.SetOne()}
+	}
+	p := &curvePoint{}
+	p.Set(g1.p)
+	p.MakeAffine()
+	return &GT{millerPrecomputed(ret, g2.lines, p)}
+}
+
+// PairPrecomputed is Pair with the precomputed Miller loop of the G2 point.
+func PairPrecomputed(g1 *G1, g2 *G2Prepared) *GT {
+	done := instrument.Start("sm9", "pairing")
+	e := MillerPrecomputed(g1, g2).Finalize()
+	done(nil)
+	return e
+}
@@ -162,6 +162,153 @@ func TestBilinearity(t *testing.T) {
 	}
 }
 
+func TestMillerProjective(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		_, p1, err := RandomG1(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, p2, err := RandomG2(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s1, s2 pairingScratch
+		e1 := finalExponentiation(millerJacobianWithScratch(p2.p, p1.p, &s1))
+		e2 := finalExponentiation(millerProjectiveWithScratch(p2.p, p1.p, &s2))
+		if *e1 != *e2 {
+			t.Fatalf("projective and Jacobian Miller loops mismatch")
+		}
+	}
+}
+
+func TestPairingNegatedPoints(t *testing.T) {
+	// Neg must keep the Jacobian t = z^2 of its input, which is used by the
+	// Jacobian Miller loop.
+	one := new(GT).SetOne()
+	e := Pair(Gen1, Gen2)
+	if got := new(GT).Add(e, Pair(Gen1, new(G2).Neg(Gen2))); *got.p != *one.p {
+		t.Errorf("e(P, Q) * e(P, -Q) != 1")
+	}
+	if got := new(GT).Add(e, Pair(new(G1).Neg(Gen1), Gen2)); *got.p != *one.p {
+		t.Errorf("e(P, Q) * e(-P, Q) != 1")
+	}
+	var s1, s2 pairingScratch
+	minusQ := new(G2).Neg(Gen2)
+	e1 := finalExponentiation(millerJacobianWithScratch(minusQ.p, curveGen, &s1))
+	e2 := finalExponentiation(millerProjectiveWithScratch(minusQ.p, curveGen, &s2))
+	if *e1 != *e2 {
+		t.Errorf("projective and Jacobian Miller loops mismatch for -Q")
+	}
+}
+
+func TestPairPrecomputed(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		_, p1, err := RandomG1(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, p2, err := RandomG2(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pre := PairingPreCompute(p2)
+		if !PairPrecomputed(p1, pre).Equal(Pair(p1, p2)) {
+			t.Fatalf("precomputed pairing mismatch")
+		}
+		if !MillerPrecomputed(p1, pre).Finalize().Equal(Pair(p1, p2)) {
+			t.Fatalf("precomputed Miller loop mismatch")
+		}
+		if !PairPrecomputed(new(G1).Neg(p1), pre).Equal(new(GT).Neg(Pair(p1, p2))) {
+			t.Fatalf("precomputed pairing of -P mismatch")
+		}
+	}
+	one := new(GT).SetOne()
+	g1Inf, g2Inf := &G1{&curvePoint{}}, &G2{&twistPoint{}}
+	g1Inf.p.SetInfinity()
+	g2Inf.p.SetInfinity()
+	if !PairPrecomputed(Gen1, PairingPreCompute(g2Inf)).Equal(one) {
+		t.Errorf("e(P, O) != 1")
+	}
+	if !PairPrecomputed(g1Inf, PairingPreCompute(Gen2)).Equal(one) {
+		t.Errorf("e(O, Q) != 1")
+	}
+}
+
+func TestPairingCheckProduct(t *testing.T) {
+	as := make([]*G1, 3)
+	bs := make([]*G2, 3)
+	want := new(GT).SetOne()
+	for i := range as {
+		var err error
+		if _, as[i], err = RandomG1(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		if _, bs[i], err = RandomG2(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		want.Add(want, Pair(as[i], bs[i]))
+	}
+	got, err := PairingCheckProduct(as, bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("product of pairings mismatch")
+	}
+
+	// e(P, Q) * e(-P, Q) = 1, pairs with the point at infinity are skipped
+	g2Inf := &G2{&twistPoint{}}
+	g2Inf.p.SetInfinity()
+	got, err = PairingCheckProduct([]*G1{Gen1, new(G1).Neg(Gen1), Gen1}, []*G2{Gen2, Gen2, g2Inf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(new(GT).SetOne()) {
+		t.Errorf("e(P, Q) * e(-P, Q) != 1")
+	}
+	got, err = PairingCheckProduct(nil, nil)
+	if err != nil || !got.Equal(new(GT).SetOne()) {
+		t.Errorf("empty product is not the identity")
+	}
+	if _, err = PairingCheckProduct(as, bs[:2]); err == nil {
+		t.Errorf("expected error for mismatched lengths")
+	}
+}
+
+func TestPairingIsOne(t *testing.T) {
+	k, p1, err := RandomG1(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, p2, err := RandomG2(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// e([k]P, Q) = e(P, [k]Q)
+	kp, err := new(G1).ScalarBaseMult(NormalizeScalar(k.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kQ, err := new(G2).ScalarBaseMult(NormalizeScalar(k.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !PairingIsOne(PairingInput{kp, Gen2}, PairingInput{new(G1).Neg(Gen1), kQ}) {
+		t.Errorf("e([k]P, Q) * e(-P, [k]Q) != 1")
+	}
+	if PairingIsOne(PairingInput{kp, Gen2}, PairingInput{Gen1, kQ}) {
+		t.Errorf("e([k]P, Q) * e(P, [k]Q) = 1")
+	}
+	if PairingIsOne(PairingInput{p1, p2}) {
+		t.Errorf("e(P, Q) = 1")
+	}
+	g2Inf := &G2{&twistPoint{}}
+	g2Inf.p.SetInfinity()
+	if !PairingIsOne(PairingInput{p1, g2Inf}) || !PairingIsOne() {
+		t.Errorf("the identity is not one")
+	}
+}
+
 func BenchmarkFinalExponentiation(b *testing.B) {
 	x := testGfp12
 	exp := new(big.Int).Exp(p, big.NewInt(12), nil)
@@ -193,6 +340,52 @@ func BenchmarkMiller(b *testing.B) {
 	}
 }
 
+func BenchmarkMillerJacobian(b *testing.B) {
+	var s pairingScratch
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		millerJacobianWithScratch(twistGen, curveGen, &s)
+	}
+}
+
+func BenchmarkMillerProjective(b *testing.B) {
+	var s pairingScratch
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		millerProjectiveWithScratch(twistGen, curveGen, &s)
+	}
+}
+
+func BenchmarkMillerPrecomputed(b *testing.B) {
+	pre := PairingPreCompute(Gen2)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MillerPrecomputed(Gen1, pre)
+	}
+}
+
+func BenchmarkPairingCheckProduct(b *testing.B) {
+	as := []*G1{Gen1, new(G1).Neg(Gen1)}
+	bs := []*G2{Gen2, Gen2}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		PairingCheckProduct(as, bs)
+	}
+}
+
+func BenchmarkPairingIsOne(b *testing.B) {
+	minusGen1 := new(G1).Neg(Gen1)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		PairingIsOne(PairingInput{Gen1, Gen2}, PairingInput{minusGen1, Gen2})
+	}
+}
+
 func BenchmarkPairingB4(b *testing.B) {
 	pk := bigFromHex("0130E78459D78545CB54C587E02CF480CE0B66340F319F348A1D5B1F2DC5F4")
 	g2 := &G2{}
@@ -209,3 +402,34 @@ func BenchmarkPairingB4(b *testing.B) {
 		}
 	}
 }
+
+func TestPairingToAllocations(t *testing.T) {
+	pk := bigFromHex("0130E78459D78545CB54C587E02CF480CE0B66340F319F348A1D5B1F2DC5F4")
+	g2 := &G2{}
+	_, err := g2.ScalarBaseMult(NormalizeScalar(pk.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret := &gfP12{}
+	if n := testing.AllocsPerRun(10, func() { pairingTo(ret, g2.p, curveGen) }); n > 0 {
+		t.Errorf("pairingTo allocates %v times", n)
+	}
+	if *ret != *expected1 {
+		t.Errorf("not expected")
+	}
+}
+
+func BenchmarkPairingTo(b *testing.B) {
+	pk := bigFromHex("0130E78459D78545CB54C587E02CF480CE0B66340F319F348A1D5B1F2DC5F4")
+	g2 := &G2{}
+	_, err := g2.ScalarBaseMult(NormalizeScalar(pk.Bytes()))
+	if err != nil {
+		b.Fatal(err)
+	}
+	ret := &gfP12{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pairingTo(ret, g2.p, curveGen)
+	}
+}
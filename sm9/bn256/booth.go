@@ -0,0 +1,85 @@
+package bn256
+
+// The variable-base scalar multiplications of G1 and G2 use a regular signed
+// digit (Booth) recoding with a 5-bit window, G1 on the halves of the GLV
+// decomposition of the scalar (see curvePointMulGLV): every window of the scalar is
+// recoded to a digit in [-16, 16], so the evaluation does exactly one table
+// lookup and one complete point addition per window, whatever the bit pattern
+// of the scalar. Scalars shorter than 32 bytes are zero extended, so the
+// number of windows doesn't depend on the length of the scalar either.
+
+const boothWindow = 5
+
+// boothW5 recodes the 6-bit window in, made of the five bits of a window and
+// the top bit of the previous one, to a digit magnitude in [0, 16] and a sign
+// (1 for negative), in constant time.
+func boothW5(in uint) (uint8, int) {
+	var s uint = ^((in >> 5) - 1)
+	var d uint = (1 << 6) - in - 1
+	d = (d & s) | (in & (^s))
+	d = (d >> 1) + (d & 1)
+	return uint8(d), int(s & 1)
+}
+
+// boothWindowCount returns the number of 5-bit signed windows of a scalar of
+// n bytes. One more bit than the scalar is covered, as the top digit can be
+// carried.
+func boothWindowCount(n int) int {
+	return 8*n/boothWindow + 1
+}
+
+// boothWindowBits returns the bits [5i-1, 5i+4] of the big-endian scalar, the
+// bits out of the scalar are zero. It only branches on the public i.
+func boothWindowBits(scalar []byte, i int) uint {
+	var v uint
+	for j := boothWindow; j >= 0; j-- {
+		v <<= 1
+		if b := i*boothWindow + j - 1; b >= 0 && b < 8*len(scalar) {
+			v |= uint(scalar[len(scalar)-1-b/8]>>(b%8)) & 1
+		}
+	}
+	return v
+}
+
+// boothScalar zero extends scalar to 32 bytes into buf, longer scalars are
+// returned unchanged.
+func boothScalar(buf *[32]byte, scalar []byte) []byte {
+	if len(scalar) >= 32 {
+		return scalar
+	}
+	*buf = [32]byte{}
+	copy(buf[32-len(scalar):], scalar)
+	return buf[:]
+}
+
+// twistPointMulBooth sets c to [scalar]a in constant time, see boothW5.
+func twistPointMulBooth(c, a *twistPoint, scalar []byte) {
+	var buf [32]byte
+	scalar = boothScalar(&buf, scalar)
+	// [1]a to [16]a
+	var points [1 << (boothWindow - 1)]twistPoint
+	var table [len(points)]*twistPoint
+	for i := range table {
+		table[i] = &points[i]
+	}
+	table[0].Set(a)
+	for i := 1; i < len(table); i += 2 {
+		table[i].Double(table[i/2])
+		if i+1 < len(table) {
+			table[i+1].Add(table[i], a)
+		}
+	}
+
+	t, negY := &twistPoint{}, &gfP2{}
+	c.SetInfinity()
+	for i := boothWindowCount(len(scalar)) - 1; i >= 0; i-- {
+		for j := 0; j < boothWindow; j++ {
+			c.Double(c)
+		}
+		d, sign := boothW5(boothWindowBits(scalar, i))
+		twistPointSelect(t, table[:], d)
+		negY.Neg(&t.y)
+		t.y.Select(negY, &t.y, sign)
+		c.Add(c, t)
+	}
+}
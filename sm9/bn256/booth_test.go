@@ -0,0 +1,97 @@
+package bn256
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestBoothRecoding(t *testing.T) {
+	scalars := [][]byte{
+		make([]byte, 32),
+		bytes.Repeat([]byte{0xff}, 32),
+		Order.Bytes(),
+		{0x01},
+		bytes.Repeat([]byte{0xa5}, 40),
+	}
+	for i := 0; i < 20; i++ {
+		k := make([]byte, 32)
+		rand.Read(k)
+		scalars = append(scalars, k)
+	}
+	for _, k := range scalars {
+		var buf [32]byte
+		s := boothScalar(&buf, k)
+		sum := new(big.Int)
+		for i := boothWindowCount(len(s)) - 1; i >= 0; i-- {
+			sum.Lsh(sum, boothWindow)
+			d, sign := boothW5(boothWindowBits(s, i))
+			if d > 16 {
+				t.Fatalf("digit %v out of range", d)
+			}
+			if sign == 1 {
+				sum.Sub(sum, big.NewInt(int64(d)))
+			} else {
+				sum.Add(sum, big.NewInt(int64(d)))
+			}
+		}
+		if sum.Cmp(new(big.Int).SetBytes(k)) != 0 {
+			t.Errorf("recoding of %x gives %x", k, sum)
+		}
+	}
+}
+
+func TestScalarMultBooth(t *testing.T) {
+	orderMinus1 := new(big.Int).Sub(Order, big.NewInt(1)).Bytes()
+	long := bytes.Repeat([]byte{0x5a}, 40)
+	longReduced := NormalizeScalar(long)
+
+	_, p, err := RandomG1(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := new(G1).ScalarMult(p, Order.Bytes())
+	if !e.IsInfinity() {
+		t.Errorf("[Order]P is not the point at infinity")
+	}
+	e, _ = new(G1).ScalarMult(p, orderMinus1)
+	if !bytes.Equal(e.Marshal(), new(G1).Neg(p).Marshal()) {
+		t.Errorf("[Order-1]P != -P")
+	}
+	e, _ = new(G1).ScalarMult(Gen1, []byte{0x03})
+	expected := new(G1).Add(Gen1, new(G1).Double(Gen1))
+	if !bytes.Equal(e.Marshal(), expected.Marshal()) {
+		t.Errorf("[3]G != G + [2]G")
+	}
+	e, _ = new(G1).ScalarMult(p, long)
+	e2, _ := new(G1).ScalarMult(p, longReduced)
+	if !bytes.Equal(e.Marshal(), e2.Marshal()) {
+		t.Errorf("long scalar mismatch")
+	}
+
+	_, q, err := RandomG2(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, _ := new(G2).ScalarMult(q, Order.Bytes())
+	if !f.IsInfinity() {
+		t.Errorf("[Order]Q is not the point at infinity")
+	}
+	f, _ = new(G2).ScalarMult(q, orderMinus1)
+	if !bytes.Equal(f.Marshal(), new(G2).Neg(q).Marshal()) {
+		t.Errorf("[Order-1]Q != -Q")
+	}
+	k := make([]byte, 32)
+	rand.Read(k)
+	f, _ = new(G2).ScalarMult(Gen2, k)
+	f2, _ := new(G2).ScalarBaseMult(k)
+	if !bytes.Equal(f.Marshal(), f2.Marshal()) {
+		t.Errorf("ScalarMult and ScalarBaseMult mismatch")
+	}
+	f, _ = new(G2).ScalarMult(q, long)
+	f2, _ = new(G2).ScalarMult(q, longReduced)
+	if !bytes.Equal(f.Marshal(), f2.Marshal()) {
+		t.Errorf("long scalar mismatch")
+	}
+}
@@ -1,7 +1,6 @@
 package bn256
 
 import (
-	"crypto/subtle"
 	"math/big"
 )
 
@@ -37,8 +36,8 @@ func (c *curvePoint) Set(a *curvePoint) {
 	c.t.Set(&a.t)
 }
 
-func (c *curvePoint) polynomial(x *gfP) *gfP {
-	x3 := &gfP{}
+// polynomial sets x3 to x³ + b and returns x3.
+func (c *curvePoint) polynomial(x3, x *gfP) *gfP {
 	gfpSqr(x3, x, 1)
 	gfpMul(x3, x3, x)
 	gfpAdd(x3, x3, curveB)
@@ -55,7 +54,7 @@ func (c *curvePoint) IsOnCurve() bool {
 	y2 := &gfP{}
 	gfpSqr(y2, &c.y, 1)
 
-	x3 := c.polynomial(&c.x)
+	x3 := c.polynomial(&gfP{}, &c.x)
 
 	return y2.Equal(x3) == 1
 }
@@ -83,20 +82,22 @@ func (c *curvePoint) IsInfinity() bool {
 	return c.z.Equal(zero) == 1
 }
 
+// Mul sets c to [scalar]a, scalar is reduced modulo Order if it is negative or
+// longer than 256 bits.
 func (c *curvePoint) Mul(a *curvePoint, scalar *big.Int) {
-	sum, t := &curvePoint{}, &curvePoint{}
-	sum.SetInfinity()
-
-	for i := scalar.BitLen(); i >= 0; i-- {
-		t.Double(sum)
-		if scalar.Bit(i) != 0 {
-			sum.Add(t, a)
-		} else {
-			sum.Set(t)
-		}
+	k := scalar
+	if k.Sign() < 0 || k.BitLen() > 256 {
+		k = new(big.Int).Mod(k, Order)
 	}
+	c.ScalarMult(a, k.FillBytes(make([]byte, 32)))
+}
 
-	c.Set(sum)
+// ScalarMult sets c to [scalar]a, where scalar is a big-endian integer. It uses
+// the GLV endomorphism and a regular signed digit recoding of the scalar, see
+// curvePointMulGLV, so its running time doesn't depend on the value of the
+// scalar, nor on its length if it is at most 32 bytes.
+func (c *curvePoint) ScalarMult(a *curvePoint, scalar []byte) {
+	curvePointMulGLV(c, a, scalar)
 }
 
 // MakeAffine reverses the Jacobian transform.
@@ -130,7 +131,7 @@ func (c *curvePoint) Neg(a *curvePoint) {
 	c.x.Set(&a.x)
 	gfpNeg(&c.y, &a.y)
 	c.z.Set(&a.z)
-	c.t.Set(zero)
+	c.t.Set(&a.t)
 }
 
 // A curvePointTable holds the first 15 multiples of a point at offset -1, so [1]P
@@ -141,14 +142,16 @@ type curvePointTable [15]*curvePoint
 // Select selects the n-th multiple of the table base point into p. It works in
 // constant time by iterating over every entry of the table. n must be in [0, 15].
 func (table *curvePointTable) Select(p *curvePoint, n uint8) {
-	if n >= 16 {
+	curvePointSelect(p, table[:], n)
+}
+
+// curvePointSelect selects the n-th multiple of the base point of a table with
+// 2^w-1 entries into p, in constant time. n must be in [0, len(table)].
+func curvePointSelect(p *curvePoint, table []*curvePoint, n uint8) {
+	if int(n) > len(table) {
 		panic("sm9: internal error: curvePointTable called with out-of-bounds value")
 	}
-	p.SetInfinity()
-	for i, f := range table {
-		cond := subtle.ConstantTimeByteEq(uint8(i+1), n)
-		curvePointMovCond(p, f, p, cond)
-	}
+	curvePointGather(p, table, int(n))
 }
 
 // Equal compare e and other
@@ -0,0 +1,167 @@
+package bn256
+
+import (
+	"errors"
+	"math/big"
+)
+
+// FieldElementSize is the size of the encoding of a FieldElement.
+const FieldElementSize = 32
+
+// FieldElement is an element of GF(p), the base field of the SM9 curve, for
+// protocols which need the field arithmetic of this package. The zero value is
+// zero. Elements are kept in the Montgomery domain; SetBytes and Bytes use the
+// canonical big-endian encoding, SetMontgomeryBytes and MontgomeryBytes the
+// Montgomery one (x·2²⁵⁶ mod p). All methods run in constant time, except
+// where noted, and the receiver may alias the arguments.
+type FieldElement struct {
+	x gfP
+}
+
+// FieldModulus returns p, the characteristic of GF(p).
+func FieldModulus() *big.Int {
+	return new(big.Int).Set(p)
+}
+
+// One sets e to one and returns e.
+func (e *FieldElement) One() *FieldElement {
+	e.x.Set(one)
+	return e
+}
+
+// Set sets e to a and returns e.
+func (e *FieldElement) Set(a *FieldElement) *FieldElement {
+	e.x.Set(&a.x)
+	return e
+}
+
+// SetBytes sets e to the big-endian value of b, which must be
+// FieldElementSize bytes and less than p, and returns e.
+func (e *FieldElement) SetBytes(b []byte) (*FieldElement, error) {
+	x, err := canonicalGFp(b)
+	if err != nil {
+		return nil, err
+	}
+	montEncode(&e.x, x)
+	return e, nil
+}
+
+// Bytes returns the FieldElementSize bytes big-endian encoding of e.
+func (e *FieldElement) Bytes() []byte {
+	x := &gfP{}
+	montDecode(x, &e.x)
+	out := make([]byte, FieldElementSize)
+	gfpMarshal((*[32]byte)(out), x)
+	return out
+}
+
+// SetMontgomeryBytes sets e to x·2⁻²⁵⁶ mod p, where x is the big-endian value
+// of b, which must be FieldElementSize bytes and less than p, and returns e.
+// It is the inverse of MontgomeryBytes.
+func (e *FieldElement) SetMontgomeryBytes(b []byte) (*FieldElement, error) {
+	x, err := canonicalGFp(b)
+	if err != nil {
+		return nil, err
+	}
+	e.x.Set(x)
+	return e, nil
+}
+
+// MontgomeryBytes returns the FieldElementSize bytes big-endian encoding of
+// e·2²⁵⁶ mod p, the internal representation of e.
+func (e *FieldElement) MontgomeryBytes() []byte {
+	out := make([]byte, FieldElementSize)
+	gfpMarshal((*[32]byte)(out), &e.x)
+	return out
+}
+
+func canonicalGFp(b []byte) (*gfP, error) {
+	if len(b) != FieldElementSize {
+		return nil, errors.New("bn256: invalid field element length")
+	}
+	x := &gfP{}
+	gfpUnmarshal(x, (*[32]byte)(b))
+	if lessThanP(x) != 1 {
+		return nil, errors.New("bn256: invalid field element encoding")
+	}
+	return x, nil
+}
+
+// Equal returns 1 if e == a, and 0 otherwise.
+func (e *FieldElement) Equal(a *FieldElement) int {
+	return e.x.Equal(&a.x)
+}
+
+// IsZero returns 1 if e == 0, and 0 otherwise.
+func (e *FieldElement) IsZero() int {
+	return e.x.Equal(zero)
+}
+
+// Add sets e = a + b and returns e.
+func (e *FieldElement) Add(a, b *FieldElement) *FieldElement {
+	gfpAdd(&e.x, &a.x, &b.x)
+	return e
+}
+
+// Sub sets e = a - b and returns e.
+func (e *FieldElement) Sub(a, b *FieldElement) *FieldElement {
+	gfpSub(&e.x, &a.x, &b.x)
+	return e
+}
+
+// Neg sets e = -a and returns e.
+func (e *FieldElement) Neg(a *FieldElement) *FieldElement {
+	gfpNeg(&e.x, &a.x)
+	return e
+}
+
+// Mul sets e = a × b and returns e.
+func (e *FieldElement) Mul(a, b *FieldElement) *FieldElement {
+	gfpMul(&e.x, &a.x, &b.x)
+	return e
+}
+
+// Square sets e = a² and returns e.
+func (e *FieldElement) Square(a *FieldElement) *FieldElement {
+	gfpSqr(&e.x, &a.x, 1)
+	return e
+}
+
+// Invert sets e = 1/a and returns e. If a == 0, e is set to 0.
+func (e *FieldElement) Invert(a *FieldElement) *FieldElement {
+	e.x.Invert(&a.x)
+	return e
+}
+
+// Sqrt sets e to a square root of a and returns e and 1 if a is a square.
+// Otherwise it returns e unchanged and 0. The running time doesn't depend on
+// the value of a, but the result does.
+func (e *FieldElement) Sqrt(a *FieldElement) (*FieldElement, int) {
+	candidate := &gfP{}
+	isSquare := sqrtVerified(candidate, &a.x)
+	e.x.Select(candidate, &e.x, isSquare)
+	return e, isSquare
+}
+
+// Legendre returns the Legendre symbol of e: 1 if e is a non-zero square, -1
+// if e is not a square and 0 if e is zero.
+func (e *FieldElement) Legendre() int {
+	return e.x.Legendre()
+}
+
+// IsSquare returns 1 if e is a square, zero included, and 0 otherwise.
+func (e *FieldElement) IsSquare() int {
+	return e.x.IsSquare()
+}
+
+// Select sets e to a if cond == 1, and to b if cond == 0.
+func (e *FieldElement) Select(a, b *FieldElement, cond int) *FieldElement {
+	e.x.Select(&a.x, &b.x, cond)
+	return e
+}
+
+// String returns the hexadecimal canonical encoding of e. It is not constant
+// time.
+func (e *FieldElement) String() string {
+	return new(big.Int).SetBytes(e.Bytes()).Text(16)
+}
@@ -0,0 +1,130 @@
+package bn256
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func randomFieldElement(t *testing.T) (*FieldElement, *big.Int) {
+	t.Helper()
+	k, err := rand.Int(rand.Reader, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := new(FieldElement).SetBytes(k.FillBytes(make([]byte, FieldElementSize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, k
+}
+
+func checkFieldElement(t *testing.T, op string, e *FieldElement, want *big.Int) {
+	t.Helper()
+	if got := new(big.Int).SetBytes(e.Bytes()); got.Cmp(want) != 0 {
+		t.Errorf("%s = %x, want %x", op, got, want)
+	}
+}
+
+func TestFieldElementArithmetic(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		a, ab := randomFieldElement(t)
+		b, bb := randomFieldElement(t)
+		mod := func(x *big.Int) *big.Int { return x.Mod(x, p) }
+
+		checkFieldElement(t, "Add", new(FieldElement).Add(a, b), mod(new(big.Int).Add(ab, bb)))
+		checkFieldElement(t, "Sub", new(FieldElement).Sub(a, b), mod(new(big.Int).Sub(ab, bb)))
+		checkFieldElement(t, "Neg", new(FieldElement).Neg(a), mod(new(big.Int).Neg(ab)))
+		checkFieldElement(t, "Mul", new(FieldElement).Mul(a, b), mod(new(big.Int).Mul(ab, bb)))
+		checkFieldElement(t, "Square", new(FieldElement).Square(a), mod(new(big.Int).Mul(ab, ab)))
+		checkFieldElement(t, "Invert", new(FieldElement).Invert(a), new(big.Int).ModInverse(ab, p))
+
+		// aliasing
+		c := new(FieldElement).Set(a)
+		c.Mul(c, c)
+		checkFieldElement(t, "Mul aliased", c, mod(new(big.Int).Mul(ab, ab)))
+
+		r, isSquare := new(FieldElement).Sqrt(c)
+		if isSquare != 1 || new(FieldElement).Square(r).Equal(c) != 1 {
+			t.Errorf("Sqrt(a²) failed")
+		}
+		if big.Jacobi(ab, p) == -1 {
+			before := new(FieldElement).Set(b)
+			if _, isSquare := b.Sqrt(a); isSquare != 0 || b.Equal(before) != 1 {
+				t.Errorf("Sqrt of a non-square returned %d or changed the receiver", isSquare)
+			}
+		}
+	}
+	if new(FieldElement).IsZero() != 1 || new(FieldElement).One().IsZero() != 0 {
+		t.Error("IsZero failed")
+	}
+	checkFieldElement(t, "Invert(0)", new(FieldElement).Invert(new(FieldElement)), new(big.Int))
+}
+
+func TestFieldElementLegendre(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		a, ab := randomFieldElement(t)
+		want := big.Jacobi(ab, p)
+		if got := a.Legendre(); got != want {
+			t.Errorf("Legendre(%x) = %d, want %d", ab, got, want)
+		}
+		if got := a.IsSquare(); got != 1-(want>>1&1) {
+			t.Errorf("IsSquare(%x) = %d, want Legendre %d", ab, got, want)
+		}
+		if new(FieldElement).Square(a).Legendre() != 1-a.IsZero() {
+			t.Errorf("Legendre(a²) != 1")
+		}
+	}
+	zero := new(FieldElement)
+	if zero.Legendre() != 0 || zero.IsSquare() != 1 {
+		t.Errorf("Legendre(0) = %d, IsSquare(0) = %d", zero.Legendre(), zero.IsSquare())
+	}
+	// p ≡ 5 mod 8: -1 is a square and 2 is not.
+	minusOne := new(FieldElement).Neg(new(FieldElement).One())
+	if minusOne.Legendre() != 1 {
+		t.Errorf("Legendre(-1) = %d", minusOne.Legendre())
+	}
+	two := new(FieldElement).Add(new(FieldElement).One(), new(FieldElement).One())
+	if two.Legendre() != -1 || two.IsSquare() != 0 {
+		t.Errorf("Legendre(2) = %d", two.Legendre())
+	}
+}
+
+func TestFieldElementEncoding(t *testing.T) {
+	one := new(FieldElement).One()
+	want := make([]byte, FieldElementSize)
+	want[FieldElementSize-1] = 1
+	if !bytes.Equal(one.Bytes(), want) {
+		t.Errorf("Bytes(1) = %x", one.Bytes())
+	}
+	// the Montgomery encoding of 1 is R mod p.
+	r := new(big.Int).Lsh(big.NewInt(1), 256)
+	r.Mod(r, p)
+	if !bytes.Equal(one.MontgomeryBytes(), r.FillBytes(make([]byte, FieldElementSize))) {
+		t.Errorf("MontgomeryBytes(1) = %x", one.MontgomeryBytes())
+	}
+
+	a, _ := randomFieldElement(t)
+	b, err := new(FieldElement).SetMontgomeryBytes(a.MontgomeryBytes())
+	if err != nil || b.Equal(a) != 1 {
+		t.Errorf("SetMontgomeryBytes round trip failed: %v", err)
+	}
+
+	for _, in := range [][]byte{
+		p.Bytes(),
+		new(big.Int).Add(p, big.NewInt(1)).Bytes(),
+		bytes.Repeat([]byte{0xff}, FieldElementSize),
+		make([]byte, FieldElementSize-1),
+	} {
+		if _, err := new(FieldElement).SetBytes(in); err == nil {
+			t.Errorf("SetBytes(%x) succeeded", in)
+		}
+		if _, err := new(FieldElement).SetMontgomeryBytes(in); err == nil {
+			t.Errorf("SetMontgomeryBytes(%x) succeeded", in)
+		}
+	}
+	if FieldModulus().Cmp(p) != 0 {
+		t.Error("FieldModulus mismatch")
+	}
+}
@@ -6,8 +6,12 @@ import (
 	"io"
 	"math/big"
 	"sync"
+
+	"github.com/emmansun/gmsm/internal/bigmod"
 )
 
+var orderModulus, _ = bigmod.NewModulusFromBig(Order)
+
 func randomK(r io.Reader) (k *big.Int, err error) {
 	for {
 		k, err = rand.Int(r, Order)
@@ -31,51 +35,12 @@ var g1GeneratorTableOnce sync.Once
 
 func (g *G1) generatorTable() *[32 * 2]curvePointTable {
 	g1GeneratorTableOnce.Do(func() {
+		points := decodeG1Table(g1GeneratorTableEmbed)
 		g1GeneratorTable = new([32 * 2]curvePointTable)
-		base := NewCurveGenerator()
-		for i := 0; i < 32*2; i++ {
-			g1GeneratorTable[i][0] = &curvePoint{}
-			g1GeneratorTable[i][0].Set(base)
-
-			g1GeneratorTable[i][1] = &curvePoint{}
-			g1GeneratorTable[i][1].Double(g1GeneratorTable[i][0])
-			g1GeneratorTable[i][2] = &curvePoint{}
-			g1GeneratorTable[i][2].Add(g1GeneratorTable[i][1], base)
-
-			g1GeneratorTable[i][3] = &curvePoint{}
-			g1GeneratorTable[i][3].Double(g1GeneratorTable[i][1])
-			g1GeneratorTable[i][4] = &curvePoint{}
-			g1GeneratorTable[i][4].Add(g1GeneratorTable[i][3], base)
-
-			g1GeneratorTable[i][5] = &curvePoint{}
-			g1GeneratorTable[i][5].Double(g1GeneratorTable[i][2])
-			g1GeneratorTable[i][6] = &curvePoint{}
-			g1GeneratorTable[i][6].Add(g1GeneratorTable[i][5], base)
-
-			g1GeneratorTable[i][7] = &curvePoint{}
-			g1GeneratorTable[i][7].Double(g1GeneratorTable[i][3])
-			g1GeneratorTable[i][8] = &curvePoint{}
-			g1GeneratorTable[i][8].Add(g1GeneratorTable[i][7], base)
-
-			g1GeneratorTable[i][9] = &curvePoint{}
-			g1GeneratorTable[i][9].Double(g1GeneratorTable[i][4])
-			g1GeneratorTable[i][10] = &curvePoint{}
-			g1GeneratorTable[i][10].Add(g1GeneratorTable[i][9], base)
-
-			g1GeneratorTable[i][11] = &curvePoint{}
-			g1GeneratorTable[i][11].Double(g1GeneratorTable[i][5])
-			g1GeneratorTable[i][12] = &curvePoint{}
-			g1GeneratorTable[i][12].Add(g1GeneratorTable[i][11], base)
-
-			g1GeneratorTable[i][13] = &curvePoint{}
-			g1GeneratorTable[i][13].Double(g1GeneratorTable[i][6])
-			g1GeneratorTable[i][14] = &curvePoint{}
-			g1GeneratorTable[i][14].Add(g1GeneratorTable[i][13], base)
-
-			base.Double(base)
-			base.Double(base)
-			base.Double(base)
-			base.Double(base)
+		for i := range g1GeneratorTable {
+			for j := range g1GeneratorTable[i] {
+				g1GeneratorTable[i][j] = &points[i*15+j]
+			}
 		}
 	})
 	return g1GeneratorTable
@@ -96,16 +61,19 @@ func (g *G1) String() string {
 	return "sm9.G1" + g.p.String()
 }
 
+// NormalizeScalar returns the 32 bytes big endian scalar, shorter input is zero-extended,
+// longer input is reduced modulo Order in constant time.
 func NormalizeScalar(scalar []byte) []byte {
 	if len(scalar) == 32 {
 		return scalar
 	}
-	s := new(big.Int).SetBytes(scalar)
-	if len(scalar) > 32 {
-		s.Mod(s, Order)
-	}
 	out := make([]byte, 32)
-	return s.FillBytes(out)
+	if len(scalar) < 32 {
+		copy(out[32-len(scalar):], scalar)
+		return out
+	}
+	s := bigmod.NewNat().SetUnreducedBytes(scalar)
+	return bigmod.NewNat().Mod(s, orderModulus).Bytes(orderModulus)
 }
 
 // ScalarBaseMult sets e to scaler*g where g is the generator of the group and then
@@ -117,6 +85,10 @@ func (e *G1) ScalarBaseMult(scalar []byte) (*G1, error) {
 	if e.p == nil {
 		e.p = &curvePoint{}
 	}
+	if w := GeneratorWindow(); w != DefaultWindow {
+		e.scalarBaseMultWindow(scalar, w)
+		return e, nil
+	}
 
 	//e.p.Mul(curveGen, k)
 
@@ -143,46 +115,14 @@ func (e *G1) ScalarBaseMult(scalar []byte) (*G1, error) {
 	return e, nil
 }
 
-// ScalarMult sets e to a*k and then returns e.
+// ScalarMult sets e to a*k and then returns e. It uses a regular signed digit
+// recoding of the scalar, so its running time doesn't depend on the value of
+// the scalar, nor on its length if it is at most 32 bytes.
 func (e *G1) ScalarMult(a *G1, scalar []byte) (*G1, error) {
 	if e.p == nil {
 		e.p = &curvePoint{}
 	}
-	//e.p.Mul(a.p, k)
-	// Compute a curvePointTable for the base point a.
-	var table = curvePointTable{NewCurvePoint(), NewCurvePoint(), NewCurvePoint(),
-		NewCurvePoint(), NewCurvePoint(), NewCurvePoint(), NewCurvePoint(),
-		NewCurvePoint(), NewCurvePoint(), NewCurvePoint(), NewCurvePoint(),
-		NewCurvePoint(), NewCurvePoint(), NewCurvePoint(), NewCurvePoint()}
-	table[0].Set(a.p)
-	for i := 1; i < 15; i += 2 {
-		table[i].Double(table[i/2])
-		table[i+1].Add(table[i], a.p)
-	}
-	// Instead of doing the classic double-and-add chain, we do it with a
-	// four-bit window: we double four times, and then add [0-15]P.
-	t := &G1{NewCurvePoint()}
-	e.p.SetInfinity()
-	for i, byte := range scalar {
-		// No need to double on the first iteration, as p is the identity at
-		// this point, and [N]∞ = ∞.
-		if i != 0 {
-			e.Double(e)
-			e.Double(e)
-			e.Double(e)
-			e.Double(e)
-		}
-		windowValue := byte >> 4
-		table.Select(t.p, windowValue)
-		e.Add(e, t)
-		e.Double(e)
-		e.Double(e)
-		e.Double(e)
-		e.Double(e)
-		windowValue = byte & 0b1111
-		table.Select(t.p, windowValue)
-		e.Add(e, t)
-	}
+	e.p.ScalarMult(a.p, scalar)
 	return e, nil
 }
 
@@ -267,8 +207,10 @@ func (e *G1) MarshalCompressed() []byte {
 	return ret
 }
 
-// UnmarshalCompressed sets e to the result of converting the output of Marshal back into
-// a group element and then returns e.
+// UnmarshalCompressed sets e to the point of the 33 bytes compressed encoding
+// of MarshalCompressed and GM/T 0044, 0x02 or 0x03 for the parity of y and
+// then x, and returns the remaining data. x must be less than p and x³ + 5 a
+// square. An all zero x is the point at infinity.
 func (e *G1) UnmarshalCompressed(data []byte) ([]byte, error) {
 	// Each value is a 256-bit number.
 	const numBytes = 256 / 8
@@ -280,29 +222,27 @@ func (e *G1) UnmarshalCompressed(data []byte) ([]byte, error) {
 	}
 	if e.p == nil {
 		e.p = &curvePoint{}
-	} else {
-		e.p.x.Set(zero)
-		e.p.y.Set(zero)
 	}
-	e.p.x.Unmarshal(data[1:])
+	if err := e.p.x.Unmarshal(data[1:]); err != nil {
+		return nil, err
+	}
 	montEncode(&e.p.x, &e.p.x)
-	x3 := e.p.polynomial(&e.p.x)
-	e.p.y.Sqrt(x3)
+	if e.p.x.Equal(zero) == 1 {
+		// This is the point at infinity, b = 5 is not a square so there is
+		// no point with x = 0 on the curve.
+		e.p.SetInfinity()
+		return data[numBytes+1:], nil
+	}
+	x3 := e.p.polynomial(&gfP{}, &e.p.x)
+	if sqrtVerified(&e.p.y, x3) != 1 {
+		return nil, errors.New("sm9.G1: invalid compressed point encoding")
+	}
 	montDecode(x3, &e.p.y)
 	if byte(x3[0]&1) != data[0]&1 {
 		gfpNeg(&e.p.y, &e.p.y)
 	}
-	if e.p.x.Equal(zero) == 1 && e.p.y.Equal(zero) == 1 {
-		// This is the point at infinity.
-		e.p.SetInfinity()
-	} else {
-		e.p.z.Set(one)
-		e.p.t.Set(one)
-
-		if !e.p.IsOnCurve() {
-			return nil, errors.New("sm9.G1: malformed point")
-		}
-	}
+	e.p.z.Set(one)
+	e.p.t.Set(one)
 
 	return data[numBytes+1:], nil
 }
@@ -371,6 +311,11 @@ func (e *G1) Equal(other *G1) bool {
 	return e.p.Equal(other.p)
 }
 
+// IsInfinity returns true if e is the point at infinity, the identity of G1.
+func (e *G1) IsInfinity() bool {
+	return e.p == nil || e.p.IsInfinity()
+}
+
 // IsOnCurve returns true if e is on the curve.
 func (e *G1) IsOnCurve() bool {
 	return e.p.IsOnCurve()
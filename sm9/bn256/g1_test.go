@@ -635,6 +635,45 @@ func Test_G1MarshalCompressed(t *testing.T) {
 	}
 }
 
+func Test_G1UnmarshalCompressedInvalid(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		_, e, err := RandomG1(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e2 := new(G1)
+		rest, err := e2.UnmarshalCompressed(append(e.MarshalCompressed(), 0xff))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rest) != 1 || !e2.Equal(e) {
+			t.Errorf("UnmarshalCompressed(MarshalCompressed(%v)) = %v, %x", e, e2, rest)
+		}
+	}
+
+	// x = p is not canonical
+	data := append([]byte{2}, p.Bytes()...)
+	if _, err := new(G1).UnmarshalCompressed(data); err == nil {
+		t.Error("expected error for x = p")
+	}
+	// x³ + 5 is not a square
+	x := big.NewInt(1)
+	for ; ; x.Add(x, big.NewInt(1)) {
+		y2 := new(big.Int).Exp(x, big.NewInt(3), p)
+		y2.Add(y2, big.NewInt(5))
+		if y2.ModSqrt(y2, p) == nil {
+			break
+		}
+	}
+	data = append([]byte{3}, x.FillBytes(make([]byte, 32))...)
+	if _, err := new(G1).UnmarshalCompressed(data); err == nil {
+		t.Errorf("expected error for x = %v", x)
+	}
+	if _, err := new(G1).UnmarshalCompressed(data[:32]); err == nil {
+		t.Error("expected error for short data")
+	}
+}
+
 func benchmarkAllCurves(b *testing.B, f func(*testing.B, Curve)) {
 	tests := []struct {
 		name  string
@@ -741,3 +780,20 @@ func BenchmarkDoublePoint(b *testing.B) {
 	})
 	
 }
+
+func TestNormalizeScalar(t *testing.T) {
+	for _, k := range []*big.Int{
+		big.NewInt(1),
+		new(big.Int).Lsh(big.NewInt(1), 300),
+		new(big.Int).Lsh(Order, 40),
+	} {
+		got := NormalizeScalar(k.Bytes())
+		if len(got) != 32 {
+			t.Fatalf("unexpected length %v", len(got))
+		}
+		expected := new(big.Int).Mod(k, Order)
+		if new(big.Int).SetBytes(got).Cmp(expected) != 0 {
+			t.Errorf("NormalizeScalar(%x) = %x, want %x", k.Bytes(), got, expected.Bytes())
+		}
+	}
+}
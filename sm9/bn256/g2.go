@@ -21,51 +21,12 @@ var g2GeneratorTableOnce sync.Once
 
 func (g *G2) generatorTable() *[32 * 2]twistPointTable {
 	g2GeneratorTableOnce.Do(func() {
+		points := decodeG2Table(g2GeneratorTableEmbed)
 		g2GeneratorTable = new([32 * 2]twistPointTable)
-		base := NewTwistGenerator()
-		for i := 0; i < 32*2; i++ {
-			g2GeneratorTable[i][0] = &twistPoint{}
-			g2GeneratorTable[i][0].Set(base)
-
-			g2GeneratorTable[i][1] = &twistPoint{}
-			g2GeneratorTable[i][1].Double(g2GeneratorTable[i][0])
-			g2GeneratorTable[i][2] = &twistPoint{}
-			g2GeneratorTable[i][2].Add(g2GeneratorTable[i][1], base)
-
-			g2GeneratorTable[i][3] = &twistPoint{}
-			g2GeneratorTable[i][3].Double(g2GeneratorTable[i][1])
-			g2GeneratorTable[i][4] = &twistPoint{}
-			g2GeneratorTable[i][4].Add(g2GeneratorTable[i][3], base)
-
-			g2GeneratorTable[i][5] = &twistPoint{}
-			g2GeneratorTable[i][5].Double(g2GeneratorTable[i][2])
-			g2GeneratorTable[i][6] = &twistPoint{}
-			g2GeneratorTable[i][6].Add(g2GeneratorTable[i][5], base)
-
-			g2GeneratorTable[i][7] = &twistPoint{}
-			g2GeneratorTable[i][7].Double(g2GeneratorTable[i][3])
-			g2GeneratorTable[i][8] = &twistPoint{}
-			g2GeneratorTable[i][8].Add(g2GeneratorTable[i][7], base)
-
-			g2GeneratorTable[i][9] = &twistPoint{}
-			g2GeneratorTable[i][9].Double(g2GeneratorTable[i][4])
-			g2GeneratorTable[i][10] = &twistPoint{}
-			g2GeneratorTable[i][10].Add(g2GeneratorTable[i][9], base)
-
-			g2GeneratorTable[i][11] = &twistPoint{}
-			g2GeneratorTable[i][11].Double(g2GeneratorTable[i][5])
-			g2GeneratorTable[i][12] = &twistPoint{}
-			g2GeneratorTable[i][12].Add(g2GeneratorTable[i][11], base)
-
-			g2GeneratorTable[i][13] = &twistPoint{}
-			g2GeneratorTable[i][13].Double(g2GeneratorTable[i][6])
-			g2GeneratorTable[i][14] = &twistPoint{}
-			g2GeneratorTable[i][14].Add(g2GeneratorTable[i][13], base)
-
-			base.Double(base)
-			base.Double(base)
-			base.Double(base)
-			base.Double(base)
+		for i := range g2GeneratorTable {
+			for j := range g2GeneratorTable[i] {
+				g2GeneratorTable[i][j] = &points[i*15+j]
+			}
 		}
 	})
 	return g2GeneratorTable
@@ -94,6 +55,10 @@ func (e *G2) ScalarBaseMult(scalar []byte) (*G2, error) {
 	if e.p == nil {
 		e.p = &twistPoint{}
 	}
+	if w := GeneratorWindow(); w != DefaultWindow {
+		e.scalarBaseMultWindow(scalar, w)
+		return e, nil
+	}
 	//e.p.Mul(twistGen, k)
 
 	tables := e.generatorTable()
@@ -120,46 +85,16 @@ func (e *G2) ScalarBaseMult(scalar []byte) (*G2, error) {
 	return e, nil
 }
 
-// ScalarMult sets e to a*k and then returns e.
+// ScalarMult sets e to a*k and then returns e. It runs in constant time, the
+// scalar is decomposed with the endomorphism ψ, see twistPointMulGLS, so a
+// must be in G2: the result is wrong for other points of the twist, e.g.
+// [Order]a is always the point at infinity. Use IsInSubgroup to check that a
+// point is in G2.
 func (e *G2) ScalarMult(a *G2, scalar []byte) (*G2, error) {
 	if e.p == nil {
 		e.p = &twistPoint{}
 	}
-	//e.p.Mul(a.p, k)
-	// Compute a twistPointTable for the base point a.
-	var table = twistPointTable{NewTwistPoint(), NewTwistPoint(), NewTwistPoint(),
-		NewTwistPoint(), NewTwistPoint(), NewTwistPoint(), NewTwistPoint(),
-		NewTwistPoint(), NewTwistPoint(), NewTwistPoint(), NewTwistPoint(),
-		NewTwistPoint(), NewTwistPoint(), NewTwistPoint(), NewTwistPoint()}
-	table[0].Set(a.p)
-	for i := 1; i < 15; i += 2 {
-		table[i].Double(table[i/2])
-		table[i+1].Add(table[i], a.p)
-	}
-	// Instead of doing the classic double-and-add chain, we do it with a
-	// four-bit window: we double four times, and then add [0-15]P.
-	t := &G2{NewTwistPoint()}
-	e.p.SetInfinity()
-	for i, byte := range scalar {
-		// No need to double on the first iteration, as p is the identity at
-		// this point, and [N]∞ = ∞.
-		if i != 0 {
-			e.p.Double(e.p)
-			e.p.Double(e.p)
-			e.p.Double(e.p)
-			e.p.Double(e.p)
-		}
-		windowValue := byte >> 4
-		table.Select(t.p, windowValue)
-		e.Add(e, t)
-		e.p.Double(e.p)
-		e.p.Double(e.p)
-		e.p.Double(e.p)
-		e.p.Double(e.p)
-		windowValue = byte & 0b1111
-		table.Select(t.p, windowValue)
-		e.Add(e, t)
-	}
+	twistPointMulGLS(e.p, a.p, scalar)
 	return e, nil
 }
 
@@ -181,6 +116,48 @@ func (e *G2) Neg(a *G2) *G2 {
 	return e
 }
 
+// Psi sets e to ψ(a) and then returns e. ψ is the endomorphism of the twist
+// obtained by composing the untwist map, the p-power Frobenius of E(GF(p^12))
+// and the twist map. On G2 it acts as multiplication by p, so it is useful for
+// subgroup membership checks and GLS style scalar decomposition.
+func (e *G2) Psi(a *G2) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	e.p.Psi(a.p)
+	return e
+}
+
+// Psi2 sets e to ψ²(a) and then returns e. It is cheaper than two
+// applications of Psi.
+func (e *G2) Psi2(a *G2) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	e.p.Psi2(a.p)
+	return e
+}
+
+// PsiPower sets e to ψⁿ(a) and then returns e. n can be negative, ψ¹² is the
+// identity map on the twist.
+func (e *G2) PsiPower(a *G2, n int) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	n %= 12
+	if n < 0 {
+		n += 12
+	}
+	e.p.Set(a.p)
+	for ; n >= 2; n -= 2 {
+		e.p.Psi2(e.p)
+	}
+	if n == 1 {
+		e.p.Psi(e.p)
+	}
+	return e
+}
+
 // Set sets e to a and then returns e.
 func (e *G2) Set(a *G2) *G2 {
 	if e.p == nil {
@@ -209,7 +186,7 @@ func (e *G2) MarshalUncompressed() []byte {
 	return ret
 }
 
-// MarshalCompressed converts e into a byte slice with uncompressed point prefix
+// MarshalCompressed converts e into a byte slice with compressed point prefix
 func (e *G2) MarshalCompressed() []byte {
 	// Each value is a 256-bit number.
 	const numBytes = 256 / 8
@@ -218,10 +195,8 @@ func (e *G2) MarshalCompressed() []byte {
 		e.p = &twistPoint{}
 	}
 	e.p.MakeAffine()
+	ret[0] = compressedSign(&e.p.y) | 2
 	temp := &gfP{}
-	montDecode(temp, &e.p.y.y)
-	temp.Marshal(ret[1:])
-	ret[0] = (ret[numBytes] & 1) | 2
 
 	montDecode(temp, &e.p.x.x)
 	temp.Marshal(ret[1:])
@@ -231,8 +206,26 @@ func (e *G2) MarshalCompressed() []byte {
 	return ret
 }
 
-// UnmarshalCompressed sets e to the result of converting the output of Marshal back into
-// a group element and then returns e.
+// errNotInSubgroup is returned by Unmarshal and UnmarshalCompressed for a point
+// of the twist which isn't in G2.
+var errNotInSubgroup = errors.New("sm9.G2: point is not in the subgroup")
+
+// compressedSign returns the sign bit of y of the compressed encoding, the
+// parity of the constant coefficient y.y, or of y.x if y.y is zero, so that y
+// and -y always have different signs.
+func compressedSign(y *gfP2) byte {
+	yx, yy := &gfP{}, &gfP{}
+	montDecode(yx, &y.x)
+	montDecode(yy, &y.y)
+	yyZero := yy.Equal(&gfP{})
+	return byte((yy[0]&1)*uint64(1-yyZero) | (yx[0]&1)*uint64(yyZero))
+}
+
+// UnmarshalCompressed sets e to the point of the 65 bytes compressed encoding
+// of MarshalCompressed, 0x02 or 0x03 for the sign of y (see compressedSign)
+// and then x, and returns the remaining data. The coordinates must be less
+// than p, and the decoded point on the twist and in G2. An all zero x is the
+// point at infinity.
 func (e *G2) UnmarshalCompressed(data []byte) ([]byte, error) {
 	// Each value is a 256-bit number.
 	const numBytes = 256 / 8
@@ -255,25 +248,29 @@ func (e *G2) UnmarshalCompressed(data []byte) ([]byte, error) {
 	}
 	montEncode(&e.p.x.x, &e.p.x.x)
 	montEncode(&e.p.x.y, &e.p.x.y)
-	x3 := e.p.polynomial(&e.p.x)
-	e.p.y.Sqrt(x3)
-	x3y := &gfP{}
-	montDecode(x3y, &e.p.y.y)
-	if byte(x3y[0]&1) != data[0]&1 {
-		e.p.y.Neg(&e.p.y)
-	}
-	if e.p.x.IsZero() && e.p.y.IsZero() {
-		// This is the point at infinity.
+	if e.p.x.IsZero() {
+		// This is the point at infinity, b' is not a square so there is no
+		// point with x = 0 on the twist.
 		e.p.y.SetOne()
 		e.p.z.SetZero()
 		e.p.t.SetZero()
-	} else {
-		e.p.z.SetOne()
-		e.p.t.SetOne()
-
-		if !e.p.IsOnCurve() {
-			return nil, errors.New("sm9.G2: malformed point")
-		}
+		return data[1+2*numBytes:], nil
+	}
+	x3 := e.p.polynomial(&gfP2{}, &e.p.x)
+	if _, isSquare := e.p.y.Sqrt(x3); isSquare != 1 {
+		return nil, errors.New("sm9.G2: invalid compressed point encoding")
+	}
+	yNeg := &gfP2{}
+	yNeg.Neg(&e.p.y)
+	e.p.y.Select(&e.p.y, yNeg, int(compressedSign(&e.p.y)^data[0]&1^1))
+	e.p.z.SetOne()
+	e.p.t.SetOne()
+
+	if !e.p.IsOnCurve() {
+		return nil, errors.New("sm9.G2: malformed point")
+	}
+	if !e.p.inSubgroup() {
+		return nil, errNotInSubgroup
 	}
 	return data[1+2*numBytes:], nil
 }
@@ -345,6 +342,9 @@ func (e *G2) Unmarshal(m []byte) ([]byte, error) {
 		if !e.p.IsOnCurve() {
 			return nil, errors.New("sm9.G2: malformed point")
 		}
+		if !e.p.inSubgroup() {
+			return nil, errNotInSubgroup
+		}
 	}
 	return m[4*numBytes:], nil
 }
@@ -360,7 +360,18 @@ func (e *G2) Equal(other *G2) bool {
 		e.p.t.Equal(&other.p.t) == 1
 }
 
+// IsInfinity returns true if e is the point at infinity, the identity of G2.
+func (e *G2) IsInfinity() bool {
+	return e.p == nil || e.p.IsInfinity()
+}
+
 // IsOnCurve returns true if e is on the twist curve.
 func (e *G2) IsOnCurve() bool {
 	return e.p.IsOnCurve()
 }
+
+// IsInSubgroup returns true if e, a point on the twist curve, is in G2, the
+// subgroup of order Order.
+func (e *G2) IsInSubgroup() bool {
+	return e.p.inSubgroup()
+}
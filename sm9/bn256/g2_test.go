@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/hex"
+	"math/big"
 	"testing"
 )
 
@@ -77,6 +78,72 @@ func Test_G2MarshalCompressed(t *testing.T) {
 	}
 }
 
+func Test_G2UnmarshalCompressedRandom(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		_, e, err := RandomG2(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e2 := &G2{}
+		if _, err = e2.UnmarshalCompressed(e.MarshalCompressed()); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(e.Marshal(), e2.Marshal()) {
+			t.Errorf("got %v, expected %v", e2, e)
+		}
+	}
+
+	// x = 3, 27 + b' is not a square
+	data := make([]byte, 65)
+	data[0] = 2
+	data[64] = 3
+	if _, err := new(G2).UnmarshalCompressed(data); err == nil {
+		t.Errorf("expected error for invalid compressed point")
+	}
+}
+
+func TestG2Psi(t *testing.T) {
+	pModOrder := new(big.Int).Mod(p, Order)
+	for i := 0; i < 5; i++ {
+		_, q, err := RandomG2(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		psi := new(G2).Psi(q)
+		pq, err := new(G2).ScalarMult(q, pModOrder.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(psi.Marshal(), pq.Marshal()) {
+			t.Errorf("ψ(Q) != [p]Q")
+		}
+
+		psi2 := new(G2).Psi2(q)
+		if !bytes.Equal(psi2.Marshal(), new(G2).Psi(psi).Marshal()) {
+			t.Errorf("ψ²(Q) != ψ(ψ(Q))")
+		}
+		if !bytes.Equal(psi2.Marshal(), new(G2).PsiPower(q, 2).Marshal()) {
+			t.Errorf("PsiPower(Q, 2) != ψ²(Q)")
+		}
+		if !bytes.Equal(psi.Marshal(), new(G2).PsiPower(q, 13).Marshal()) {
+			t.Errorf("PsiPower(Q, 13) != ψ(Q)")
+		}
+		if !bytes.Equal(q.Marshal(), new(G2).PsiPower(q, 12).Marshal()) {
+			t.Errorf("PsiPower(Q, 12) != Q")
+		}
+		inv := new(G2).PsiPower(q, -1)
+		if !bytes.Equal(q.Marshal(), inv.Psi(inv).Marshal()) {
+			t.Errorf("ψ(PsiPower(Q, -1)) != Q")
+		}
+	}
+
+	inf := &G2{p: &twistPoint{}}
+	inf.p.SetInfinity()
+	if !new(G2).Psi(inf).p.IsInfinity() {
+		t.Errorf("ψ(∞) != ∞")
+	}
+}
+
 func TestScaleMult(t *testing.T) {
 	k, e, err := RandomG2(rand.Reader)
 	if err != nil {
@@ -124,3 +191,35 @@ func BenchmarkG2(b *testing.B) {
 		new(G2).ScalarBaseMult(xb)
 	}
 }
+
+func TestG2CompressedSign(t *testing.T) {
+	ys := []*gfP2{
+		{x: *newGFp(7), y: *newGFp(0)},
+		{x: *newGFp(0), y: *newGFp(7)},
+		{x: *newGFp(3), y: *newGFp(5)},
+	}
+	for _, y := range ys {
+		neg := (&gfP2{}).Neg(y)
+		if compressedSign(y) == compressedSign(neg) {
+			t.Errorf("y = %v and -y have the same sign", y)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		_, e, err := RandomG2(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range []*G2{e, new(G2).Neg(e)} {
+			got := new(G2)
+			if _, err := got.UnmarshalCompressed(p.MarshalCompressed()); err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(p) {
+				t.Errorf("UnmarshalCompressed(MarshalCompressed(%v)) = %v", p, got)
+			}
+		}
+	}
+	if _, err := new(G2).UnmarshalCompressed(twistPointNotInG2(t).MarshalCompressed()); err != errNotInSubgroup {
+		t.Errorf("got %v, want %v", err, errNotInSubgroup)
+	}
+}
@@ -0,0 +1,89 @@
+//go:build ignore
+
+package main
+
+// Running this generator writes g1_table.bin and g2_table.bin, the fixed-base
+// tables of G1.ScalarBaseMult and G2.ScalarBaseMult. Table i holds the
+// multiples [1..15]×[2^(4×i)]G, i in [0, 63], in affine coordinates, encoded
+// as the little-endian words of the Montgomery domain curvePoint and
+// twistPoint structures.
+
+import (
+	"encoding/binary"
+	"log"
+	"math/big"
+	"os"
+
+	"github.com/emmansun/gmsm/sm9/bn256"
+)
+
+var (
+	p, _ = new(big.Int).SetString("b640000002a3a6f1d603ab4ff58ec74521f2934b1a7aeedbe56f9b27e351457d", 16)
+	// r is the Montgomery radix 2^256.
+	r = new(big.Int).Lsh(big.NewInt(1), 256)
+)
+
+// appendGFp appends the Montgomery encoding of the big-endian coordinate b.
+func appendGFp(out, b []byte) []byte {
+	x := new(big.Int).SetBytes(b)
+	x.Mul(x, r).Mod(x, p)
+	var words [32]byte
+	x.FillBytes(words[:])
+	var le [8]byte
+	for i := 3; i >= 0; i-- {
+		binary.LittleEndian.PutUint64(le[:], binary.BigEndian.Uint64(words[i*8:]))
+		out = append(out, le[:]...)
+	}
+	return out
+}
+
+func scalar(j, i int) []byte {
+	k := new(big.Int).Lsh(big.NewInt(int64(j)), uint(4*i))
+	k.Mod(k, bn256.Order)
+	return k.FillBytes(make([]byte, 32))
+}
+
+func main() {
+	one := []byte{1}
+	zero := []byte{0}
+
+	var g1 []byte
+	for i := 0; i < 64; i++ {
+		for j := 1; j <= 15; j++ {
+			e, err := new(bn256.G1).ScalarMult(bn256.Gen1, scalar(j, i))
+			if err != nil {
+				log.Fatal(err)
+			}
+			b := e.Marshal()
+			g1 = appendGFp(g1, b[:32]) // x
+			g1 = appendGFp(g1, b[32:]) // y
+			g1 = appendGFp(g1, one)    // z
+			g1 = appendGFp(g1, one)    // t
+		}
+	}
+	if err := os.WriteFile("g1_table.bin", g1, 0644); err != nil {
+		log.Fatal(err)
+	}
+
+	var g2 []byte
+	for i := 0; i < 64; i++ {
+		for j := 1; j <= 15; j++ {
+			e, err := new(bn256.G2).ScalarMult(bn256.Gen2, scalar(j, i))
+			if err != nil {
+				log.Fatal(err)
+			}
+			b := e.Marshal()
+			g2 = appendGFp(g2, b[:32])   // x.x
+			g2 = appendGFp(g2, b[32:64]) // x.y
+			g2 = appendGFp(g2, b[64:96]) // y.x
+			g2 = appendGFp(g2, b[96:])   // y.y
+			g2 = appendGFp(g2, zero)     // z.x
+			g2 = appendGFp(g2, one)      // z.y
+			g2 = appendGFp(g2, zero)     // t.x
+			g2 = appendGFp(g2, one)      // t.y
+		}
+	}
+	if err := os.WriteFile("g2_table.bin", g2, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
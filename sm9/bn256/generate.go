@@ -81,22 +81,6 @@ func main() {
 		log.Fatal(err)
 	}
 
-	out, err = generate(tmplAddchainExp1, "0x2d90000000a8e9bc7580ead3fd63b1d1487ca4d2c69ebbb6f95be6c9f8d4515f", "gfP2")
-	if err != nil {
-		log.Fatal(err)
-	}
-	out1, err = generate(tmplAddchainExp2, "0xb640000002a3a6f1d603ab4ff58ec74521f2934b1a7aeedbe56f9b27e351457d", "gfP2")
-	if err != nil {
-		log.Fatal(err)
-	}
-	out2, err := generate(tmplAddchainExp3, "0x5b2000000151d378eb01d5a7fac763a290f949a58d3d776df2b7cd93f1a8a2be", "gfP2")
-	if err != nil {
-		log.Fatal(err)
-	}
-	if err = writeFile("gfp2_sqrt.go", out, out1, out2); err != nil {
-		log.Fatal(err)
-	}
-
 	out, err = generate(tmplAddchainExp12, "0x600000000058f98a", "gfP12")
 	if err != nil {
 		log.Fatal(err)
@@ -106,113 +90,6 @@ func main() {
 	}
 }
 
-const tmplAddchainExp1 = `// Code generated by {{ .Meta.Name }}. DO NOT EDIT.
-package bn256
-
-func (e *Element) expPMinus1Over4(x *Element) *Element {
-	// The sequence of {{ .Ops.Adds }} multiplications and {{ .Ops.Doubles }} squarings is derived from the
-	// following addition chain generated with {{ .Meta.Module }} {{ .Meta.ReleaseTag }}.
-	//
-	{{- range lines (format .Script) }}
-	//	{{ . }}
-	{{- end }}
-	//
-	var z = new(Element).Set(e)
-	{{- range .Program.Temporaries }}
-	var {{ . }} = new(Element)
-	{{- end }}
-	{{ range $i := .Program.Instructions -}}
-	{{- with add $i.Op }}
-	{{ $i.Output }}.Mul({{ .X }}, {{ .Y }})
-	{{- end -}}
-	{{- with double $i.Op }}
-	{{ $i.Output }}.Square({{ .X }})
-	{{- end -}}
-	{{- with shift $i.Op -}}
-	{{- $first := 0 -}}
-	{{- if ne $i.Output.Identifier .X.Identifier }}
-	{{ $i.Output }}.Square({{ .X }})
-	{{- $first = 1 -}}
-	{{- end }}
-	for s := {{ $first }}; s < {{ .S }}; s++ {
-		{{ $i.Output }}.Square({{ $i.Output }})
-	}
-	{{- end -}}
-	{{- end }}
-	return e.Set(z)
-}
-`
-
-const tmplAddchainExp2 = `
-func (e *Element) expP(x *Element) *Element {
-	// The sequence of {{ .Ops.Adds }} multiplications and {{ .Ops.Doubles }} squarings is derived from the
-	// following addition chain generated with {{ .Meta.Module }} {{ .Meta.ReleaseTag }}.
-	//
-	{{- range lines (format .Script) }}
-	//	{{ . }}
-	{{- end }}
-	//
-	var z = new(Element).Set(e)
-	{{- range .Program.Temporaries }}
-	var {{ . }} = new(Element)
-	{{- end }}
-	{{ range $i := .Program.Instructions -}}
-	{{- with add $i.Op }}
-	{{ $i.Output }}.Mul({{ .X }}, {{ .Y }})
-	{{- end -}}
-	{{- with double $i.Op }}
-	{{ $i.Output }}.Square({{ .X }})
-	{{- end -}}
-	{{- with shift $i.Op -}}
-	{{- $first := 0 -}}
-	{{- if ne $i.Output.Identifier .X.Identifier }}
-	{{ $i.Output }}.Square({{ .X }})
-	{{- $first = 1 -}}
-	{{- end }}
-	for s := {{ $first }}; s < {{ .S }}; s++ {
-		{{ $i.Output }}.Square({{ $i.Output }})
-	}
-	{{- end -}}
-	{{- end }}
-	return e.Set(z)
-}
-`
-
-const tmplAddchainExp3 = `
-func (e *Element) expPMinus1Over2(x *Element) *Element {
-	// The sequence of {{ .Ops.Adds }} multiplications and {{ .Ops.Doubles }} squarings is derived from the
-	// following addition chain generated with {{ .Meta.Module }} {{ .Meta.ReleaseTag }}.
-	//
-	{{- range lines (format .Script) }}
-	//	{{ . }}
-	{{- end }}
-	//
-	var z = new(Element).Set(e)
-	{{- range .Program.Temporaries }}
-	var {{ . }} = new(Element)
-	{{- end }}
-	{{ range $i := .Program.Instructions -}}
-	{{- with add $i.Op }}
-	{{ $i.Output }}.Mul({{ .X }}, {{ .Y }})
-	{{- end -}}
-	{{- with double $i.Op }}
-	{{ $i.Output }}.Square({{ .X }})
-	{{- end -}}
-	{{- with shift $i.Op -}}
-	{{- $first := 0 -}}
-	{{- if ne $i.Output.Identifier .X.Identifier }}
-	{{ $i.Output }}.Square({{ .X }})
-	{{- $first = 1 -}}
-	{{- end }}
-	for s := {{ $first }}; s < {{ .S }}; s++ {
-		{{ $i.Output }}.Square({{ $i.Output }})
-	}
-	{{- end -}}
-	{{- end }}
-	return e.Set(z)
-}
-`
-
 const tmplAddchainInvert = `// Code generated by {{ .Meta.Name }}. DO NOT EDIT.
 package bn256
 // Invert sets e = 1/x, and returns e.
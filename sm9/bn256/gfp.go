@@ -13,6 +13,7 @@ type gfP [4]uint64
 var zero = newGFp(0)
 var one = newGFp(1)
 var two = newGFp(2)
+var twoInv = new(gfP).Invert(two)
 
 func newGFp(x int64) (out *gfP) {
 	if x >= 0 {
@@ -119,10 +120,42 @@ func (e *gfP) Sqrt(f *gfP) {
 	e.Set(i)
 }
 
+// sqrtVerified sets e to a square root candidate of x, and returns 1 if it is
+// indeed a square root, 0 otherwise. Unlike the Sqrt function, it runs in constant time
+// whether x is a square or not. e and x can overlap.
+func sqrtVerified(e, x *gfP) int {
+	candidate, square := &gfP{}, &gfP{}
+	candidate.Sqrt(x)
+	gfpSqr(square, candidate, 1)
+	ret := square.Equal(x)
+	e.Set(candidate)
+	return ret
+}
+
 func (e *gfP) Marshal(out []byte) {
 	gfpMarshal((*[32]byte)(out), e)
 }
 
+// Legendre returns the Legendre symbol of e: 1 if e is a non-zero square, -1
+// if e is not a square and 0 if e is zero. It runs in constant time.
+func (e *gfP) Legendre() int {
+	// e^((p-1)/2) = (e^((p-5)/8))⁴ · e², reusing the exponentiation of
+	// sqrtCandidate, the result is 1, -1 or 0.
+	t, e2 := &gfP{}, &gfP{}
+	sqrtCandidate(t, e)
+	gfpSqr(t, t, 2)
+	gfpSqr(e2, e, 1)
+	gfpMul(t, t, e2)
+	isOne, isZero := t.Equal(one), e.Equal(zero)
+	return isOne - (1 ^ isOne ^ isZero)
+}
+
+// IsSquare returns 1 if e is a square, zero included, and 0 otherwise. It
+// runs in constant time.
+func (e *gfP) IsSquare() int {
+	return 1 ^ int(uint(e.Legendre())>>(bits.UintSize-1))
+}
+
 // uint64IsZero returns 1 if x is zero and zero otherwise.
 func uint64IsZero(x uint64) int {
 	x = ^x
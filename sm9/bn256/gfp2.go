@@ -206,46 +206,68 @@ func (e *gfP2) Frobenius(a *gfP2) *gfP2 {
 	return e
 }
 
-// Sqrt method is only required when we implement compressed format
-// TODO: use addchain to improve performance for 3 exp operations.
-func (ret *gfP2) Sqrt(a *gfP2) *gfP2 {
-	// Algorithm 10 https://eprint.iacr.org/2012/685.pdf
-	// TODO
-	ret.SetZero()
-	c := &twistGen.x
-	b, b2, bq := &gfP2{}, &gfP2{}, &gfP2{}
-	b = b.expPMinus1Over4(a)
-	b2.Mul(b, b)
-	bq = bq.expP(b)
-
-	t := &gfP2{}
-	x0 := &gfP{}
-	/* ignore sqrt existing check
-	a0 := &gfP2{}
-	a0.Exp(b2, p)
-	a0.Mul(a0, b2)
-	a0 = gfP2Decode(a0)
-	*/
-	t.Mul(bq, b)
-	if t.x.Equal(zero) == 1 && t.y.Equal(one) == 1 {
-		t.Mul(b2, a)
-		x0.Sqrt(&t.y)
-		t.MulScalar(bq, x0)
-		ret.Set(t)
-	} else {
-		d, e, f := &gfP2{}, &gfP2{}, &gfP2{}
-		d = d.expPMinus1Over2(c)
-		e.Mul(d, c)
-		f.Square(e)
-		e.Invert(e)
-		t.Mul(b2, a)
-		t.Mul(t, f)
-		x0.Sqrt(&t.y)
-		t.MulScalar(bq, x0)
-		t.Mul(t, e)
-		ret.Set(t)
-	}
-	return ret
+// Sqrt sets e to a square root of a and returns e together with 1 if a is a
+// square in GF(p²). Otherwise e is set to an unspecified value and 0 is
+// returned. e and a can overlap. It runs in constant time.
+//
+// The complex method (Algorithm 8, https://eprint.iacr.org/2012/685.pdf) is
+// used: for a = a1*u + a0 with u² = -2, the norm a0² + 2*a1² is a square in
+// GF(p), and with δ = (a0 ± sqrt(norm))/2 chosen to be a square,
+//
+//	sqrt(a) = a1/(2*sqrt(δ))*u + sqrt(δ)
+//
+// When a1 is zero, the root is either sqrt(a0) or sqrt(-a0/2)*u.
+func (e *gfP2) Sqrt(a *gfP2) (*gfP2, int) {
+	a0, a1 := &gfP{}, &gfP{}
+	a0.Set(&a.y)
+	a1.Set(&a.x)
+
+	// norm = a0² + 2*a1²
+	norm, t := &gfP{}, &gfP{}
+	gfpSqr(norm, a0, 1)
+	gfpSqr(t, a1, 1)
+	gfpDouble(t, t)
+	gfpAdd(norm, norm, t)
+
+	gamma := &gfP{}
+	sqrtVerified(gamma, norm)
+
+	// δ = (a0 + γ)/2, or (a0 - γ)/2 if the former is not a square.
+	delta, deltaNeg := &gfP{}, &gfP{}
+	gfpAdd(delta, a0, gamma)
+	gfpMul(delta, delta, twoInv)
+	gfpSub(deltaNeg, a0, gamma)
+	gfpMul(deltaNeg, deltaNeg, twoInv)
+
+	x0, x0Neg := &gfP{}, &gfP{}
+	ok := sqrtVerified(x0, delta)
+	sqrtVerified(x0Neg, deltaNeg)
+	x0.Select(x0, x0Neg, ok)
+
+	// x1 = a1/(2*x0)
+	x1 := &gfP{}
+	gfpDouble(x1, x0)
+	x1.Invert(x1)
+	gfpMul(x1, x1, a1)
+
+	// a1 == 0: a is sqrt(a0)² or (sqrt(-a0/2)*u)².
+	r0, r1 := &gfP{}, &gfP{}
+	a0IsSquare := sqrtVerified(r0, a0)
+	gfpNeg(t, a0)
+	gfpMul(t, t, twoInv)
+	sqrtVerified(r1, t)
+	r1.Select(zero, r1, a0IsSquare)
+	r0.Select(r0, zero, a0IsSquare)
+
+	isReal := a1.Equal(zero)
+	ret := &gfP2{}
+	ret.x.Select(r1, x1, isReal)
+	ret.y.Select(r0, x0, isReal)
+
+	t2 := &gfP2{}
+	isSquare := t2.Square(ret).Equal(a)
+	e.Set(ret)
+	return e, isSquare
 }
 
 // Select sets e to p1 if cond == 1, and to p2 if cond == 0.
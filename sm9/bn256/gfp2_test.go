@@ -225,6 +225,58 @@ func Test_gfP2Sqrt(t *testing.T) {
 	}
 }
 
+func Test_gfP2SqrtSpecialCases(t *testing.T) {
+	check := func(name string, a *gfP2, expected int) {
+		t.Helper()
+		r, x2 := &gfP2{}, &gfP2{}
+		_, isSquare := r.Sqrt(a)
+		if isSquare != expected {
+			t.Fatalf("%s: got isSquare %v, expected %v", name, isSquare, expected)
+		}
+		if isSquare == 1 && x2.Square(r).Equal(a) != 1 {
+			t.Errorf("%s: wrong square root", name)
+		}
+	}
+	check("zero", &gfP2{}, 1)
+	check("one", (&gfP2{}).SetOne(), 1)
+	check("u", (&gfP2{}).SetU(), 0)
+	// -2 = u², it is a square in GF(p²) but not in GF(p)
+	check("-2", &gfP2{*zero, *newGFp(-2)}, 1)
+	check("u²", (&gfP2{}).Square((&gfP2{}).SetU()), 1)
+	check("(3u)²", (&gfP2{}).Square(&gfP2{*newGFp(3), *zero}), 1)
+	check("twistB", twistB, 0)
+
+	x := &gfP2{*newGFp(5), *newGFp(7)}
+	for i := 0; i < 20; i++ {
+		x2 := (&gfP2{}).Square(x)
+		check("random square", x2, 1)
+		nonSquare := (&gfP2{}).Mul(x2, twistB)
+		check("random non-square", nonSquare, 0)
+		x.Mul(x, x2).Add(x, (&gfP2{}).SetOne())
+	}
+
+	// in-place
+	x2 := (&gfP2{}).Square(x)
+	r := (&gfP2{}).Set(x2)
+	if _, isSquare := r.Sqrt(r); isSquare != 1 || (&gfP2{}).Square(r).Equal(x2) != 1 {
+		t.Errorf("in-place square root failed")
+	}
+}
+
+func BenchmarkGfP2Sqrt(b *testing.B) {
+	x := &gfP2{
+		*fromBigInt(bigFromHex("85AEF3D078640C98597B6027B441A01FF1DD2C190F5E93C454806C11D8806141")),
+		*fromBigInt(bigFromHex("3722755292130B08D2AAB97FD34EC120EE265948D19C17ABF9B7213BAF82D65B")),
+	}
+	x.Square(x)
+	t := &gfP2{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t.Sqrt(x)
+	}
+}
+
 func BenchmarkGfP2Mul(b *testing.B) {
 	x := &gfP2{
 		*fromBigInt(bigFromHex("85AEF3D078640C98597B6027B441A01FF1DD2C190F5E93C454806C11D8806141")),
@@ -6,6 +6,9 @@ package bn256
 // assembly implementations of these functions, provided that they exist.
 
 import (
+	"runtime"
+
+	"github.com/emmansun/gmsm/internal/impl"
 	"golang.org/x/sys/cpu"
 )
 
@@ -14,6 +17,11 @@ import (
 // unrolls loops. arm64 processes four words at a time.
 var supportADX = cpu.X86.HasADX && cpu.X86.HasBMI2
 
+func init() {
+	impl.Register("sm9", "ADX", supportADX, true)
+	impl.Register("sm9", runtime.GOARCH, true, true)
+}
+
 // Set c = p - a, if c == p, then c = 0
 // It seems this function's performance is worse than gfpSub with zero.
 //
@@ -4,8 +4,14 @@ package bn256
 
 import (
 	"math/bits"
+
+	"github.com/emmansun/gmsm/internal/impl"
 )
 
+func init() {
+	impl.Register("sm9", impl.Generic, true, true)
+}
+
 func gfpCarry(a *gfP, head uint64) {
 	b := &gfP{}
 
@@ -90,6 +96,10 @@ func addMulVVW(z, x []uint64, y uint64) (carry uint64) {
 }
 
 func gfpMul(c, a, b *gfP) {
+	if bits.UintSize == 32 {
+		gfpMul32(c, a, b)
+		return
+	}
 	var T [8]uint64
 	// This loop implements Word-by-Word Montgomery Multiplication, as
 	// described in Algorithm 4 (Fig. 3) of "Efficient Software
@@ -143,6 +153,10 @@ func gfpSqr(res, in *gfP, n int) {
 }
 
 func gfpFromMont(res, in *gfP) {
+	if bits.UintSize == 32 {
+		gfpMul32(res, in, &gfP{1})
+		return
+	}
 	var T [8]uint64
 	var carry uint64
 	copy(T[:], in[:])
@@ -0,0 +1,66 @@
+//go:build (!amd64 && !arm64) || purego
+
+package bn256
+
+// On 32-bit architectures (arm, 386, mips, ...) bits.Mul64 and bits.Add64 are
+// emulated with 32-bit instructions and long carry chains, so the Montgomery
+// multiplication is computed with 32-bit limbs instead, where every step maps
+// to a native 32x32->64 multiplication (UMULL on armv7). The Montgomery
+// constant R = 2^256 is the same for both limb sizes, so the results are
+// identical and the other field operations are shared with the 64-bit path.
+
+// p32 is p2 in 32-bit limbs, little endian.
+var p32 = [8]uint32{
+	uint32(p2[0]), uint32(p2[0] >> 32), uint32(p2[1]), uint32(p2[1] >> 32),
+	uint32(p2[2]), uint32(p2[2] >> 32), uint32(p2[3]), uint32(p2[3] >> 32),
+}
+
+// np32 is -p⁻¹ mod 2^32, the low limb of np.
+var np32 = uint32(np[0])
+
+// gfpMul32 is gfpMul with 32-bit limbs, see gfpMul for the algorithm. The
+// product and the reduction are interleaved per limb (CIOS), x*y + z + carry
+// fits in 64 bits, and the 32x32->64 multiplication of zero extended values
+// compiles to a single instruction on 32-bit architectures.
+func gfpMul32(c, a, b *gfP) {
+	var x [8]uint32
+	for i := 0; i < 4; i++ {
+		x[2*i], x[2*i+1] = uint32(a[i]), uint32(a[i]>>32)
+	}
+
+	var T [9]uint32
+	for i := 0; i < 8; i++ {
+		y := uint64(uint32(b[i/2] >> (32 * (i % 2))))
+
+		// T += x * y
+		var carry uint64
+		for j := 0; j < 8; j++ {
+			t := uint64(x[j])*y + uint64(T[j]) + carry
+			T[j] = uint32(t)
+			carry = t >> 32
+		}
+		t := uint64(T[8]) + carry
+		T[8] = uint32(t)
+		hi := t >> 32
+
+		// T = (T + m * p) / 2^32
+		m := uint64(T[0] * np32)
+		carry = (uint64(p32[0])*m + uint64(T[0])) >> 32
+		for j := 1; j < 8; j++ {
+			t := uint64(p32[j])*m + uint64(T[j]) + carry
+			T[j-1] = uint32(t)
+			carry = t >> 32
+		}
+		t = uint64(T[8]) + carry
+		T[7] = uint32(t)
+		T[8] = uint32(hi + t>>32)
+	}
+
+	*c = gfP{
+		uint64(T[0]) | uint64(T[1])<<32,
+		uint64(T[2]) | uint64(T[3])<<32,
+		uint64(T[4]) | uint64(T[5])<<32,
+		uint64(T[6]) | uint64(T[7])<<32,
+	}
+	gfpCarry(c, uint64(T[8]))
+}
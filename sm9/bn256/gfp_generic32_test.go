@@ -0,0 +1,39 @@
+//go:build (!amd64 && !arm64) || purego
+
+package bn256
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestGfpMul32(t *testing.T) {
+	pMinus1 := new(big.Int).Sub(p, big.NewInt(1))
+	values := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(2), pMinus1}
+	for i := 0; i < 20; i++ {
+		v, err := rand.Int(rand.Reader, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, v)
+	}
+	for _, x := range values {
+		for _, y := range values {
+			expected := fromBigInt(new(big.Int).Mod(new(big.Int).Mul(x, y), p))
+			got := &gfP{}
+			gfpMul32(got, fromBigInt(x), fromBigInt(y))
+			if *got != *expected {
+				t.Fatalf("%x * %x: got %v, expected %v", x, y, got, expected)
+			}
+		}
+	}
+
+	got := &gfP{}
+	gfpMul32(got, fromBigInt(pMinus1), &gfP{1})
+	expected := &gfP{}
+	montDecode(expected, fromBigInt(pMinus1))
+	if *got != *expected {
+		t.Errorf("from Montgomery form: got %v, expected %v", got, expected)
+	}
+}
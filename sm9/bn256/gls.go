@@ -0,0 +1,140 @@
+package bn256
+
+import "encoding/binary"
+
+// G₂ has the endomorphism ψ (see twistPoint.Psi) with ψ(Q) = [λ]Q for every
+// point Q of G₂, where λ = p mod Order = 6u². Its minimal polynomial over the
+// integers has degree 4, so a scalar k is decomposed into k₀ + k₁λ + k₂λ² +
+// k₃λ³ (mod Order) with |kᵢ| of about 64 bits (4-dimensional GLS), and [k]Q =
+// [k₀]Q + [k₁]ψ(Q) + [k₂]ψ²(Q) + [k₃]ψ³(Q) is evaluated with a quarter of the
+// doublings of a plain scalar multiplication, see twistPointMulGLS. It only
+// holds in G₂, not on the whole twist.
+
+// glsBasis is a reduced basis of the lattice {(x₀, x₁, x₂, x₃) : x₀ + x₁λ +
+// x₂λ² + x₃λ³ = 0 mod Order}, found with LLL:
+//
+//	(2u, u+1, -u, u)
+//	(u, -u, u, 2u+1)
+//	(u+1, u, u, -2u)
+//	(2u+1, -u, -u-1, -u)
+//
+// The values are 256-bit two's complement little-endian 64-bit limbs.
+var glsBasis = [4][4][4]uint64{
+	{
+		{0xc000000000b1f314, 0, 0, 0},
+		{0x600000000058f98b, 0, 0, 0},
+		{0x9fffffffffa70676, 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff},
+		{0x600000000058f98a, 0, 0, 0},
+	},
+	{
+		{0x600000000058f98a, 0, 0, 0},
+		{0x9fffffffffa70676, 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff},
+		{0x600000000058f98a, 0, 0, 0},
+		{0xc000000000b1f315, 0, 0, 0},
+	},
+	{
+		{0x600000000058f98b, 0, 0, 0},
+		{0x600000000058f98a, 0, 0, 0},
+		{0x600000000058f98a, 0, 0, 0},
+		{0x3fffffffff4e0cec, 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff},
+	},
+	{
+		{0xc000000000b1f315, 0, 0, 0},
+		{0x9fffffffffa70676, 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff},
+		{0x9fffffffffa70675, 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff},
+		{0x9fffffffffa70676, 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff},
+	},
+}
+
+// glsG are round(2²⁵⁶ × ℓⱼ / Order), where (ℓ₀, ..., ℓ₃) × Order is the first
+// row of the inverse of glsBasis: ℓ₀ = 6u³ - u, ℓ₁ = 6u³ + 6u² + 2u, ℓ₂ = 6u³ +
+// 6u² + 4u + 1 and ℓ₃ = 6u³ + 6u² + u.
+var glsG = [4][4]uint64{
+	{0x820c3662fc2e483e, 0xda135840d3281d93, 0x71c71c71c6b2fe2b, 0},
+	{0x7ee62e24005a094e, 0x097ba41ae3ec39c4, 0x71c71c71c6b2fe2d, 0},
+	{0x8c9838acf1d58220, 0x097ba41ae3ec39c5, 0x71c71c71c6b2fe2d, 0},
+	{0xf80d28df879c4ce7, 0x097ba41ae3ec39c3, 0x71c71c71c6b2fe2d, 0},
+}
+
+// glsScalarSize is the size in bytes of |kᵢ|, which are less than 6u + 2 <
+// 2⁶⁶ for any 256-bit k.
+const glsScalarSize = 9
+
+// glsDecompose writes |kᵢ| of k = k₀ + k₁λ + k₂λ² + k₃λ³ (mod Order) to ks,
+// big-endian, and returns their signs, 1 if negative. k is a big-endian
+// 256-bit integer, it runs in constant time.
+func glsDecompose(ks *[4][32]byte, k *[32]byte) (negs [4]int) {
+	var x [4]uint64
+	for i := range x {
+		x[i] = binary.BigEndian.Uint64(k[24-8*i:])
+	}
+	// (c₀, ..., c₃) is the rounding of (k, 0, 0, 0) × glsBasis⁻¹ (Babai), the
+	// error of the rounding of glsG is less than k / 2²⁵⁷ < 1/2.
+	var c [4][4]uint64
+	for j := range c {
+		c[j] = glvRound(&x, &glsG[j])
+	}
+	// (k₀, ..., k₃) = (k, 0, 0, 0) - Σ cⱼ × glsBasis[j], modulo 2²⁵⁶
+	var r [4][4]uint64
+	r[0] = x
+	for i := range r {
+		for j := range c {
+			t, _ := glvMul(&c[j], &glsBasis[j][i])
+			glvSub(&r[i], &r[i], &t)
+		}
+		negs[i] = glvAbs(&ks[i], &r[i])
+	}
+	return
+}
+
+// twistPointMulGLS sets c to [scalar]a in constant time, a must be in G₂. The
+// scalar is decomposed by glsDecompose and the four mini-scalars are recoded
+// with boothW5, the additions of the digits of [k₀]a, [k₁]ψ(a), [k₂]ψ²(a) and
+// [k₃]ψ³(a) are interleaved after each run of doublings. Scalars longer than
+// 32 bytes are reduced modulo Order.
+func twistPointMulGLS(c, a *twistPoint, scalar []byte) {
+	var k [32]byte
+	if len(scalar) > 32 {
+		scalar = NormalizeScalar(scalar)
+	}
+	copy(k[32-len(scalar):], scalar)
+	var ks [4][32]byte
+	negs := glsDecompose(&ks, &k)
+
+	// [1]a to [16]a and their images by ψ, ψ² and ψ³
+	var points [4][1 << (boothWindow - 1)]twistPoint
+	var tables [4][len(points[0])]*twistPoint
+	for i := range tables {
+		for j := range tables[i] {
+			tables[i][j] = &points[i][j]
+		}
+	}
+	table := &tables[0]
+	table[0].Set(a)
+	for i := 1; i < len(table); i += 2 {
+		table[i].Double(table[i/2])
+		if i+1 < len(table) {
+			table[i+1].Add(table[i], a)
+		}
+	}
+	for i, p := range table {
+		tables[1][i].Psi(p)
+		tables[2][i].Psi2(p)
+		tables[3][i].Psi(tables[2][i])
+	}
+
+	t, negY := &twistPoint{}, &gfP2{}
+	c.SetInfinity()
+	for i := boothWindowCount(glsScalarSize) - 1; i >= 0; i-- {
+		for j := 0; j < boothWindow; j++ {
+			c.Double(c)
+		}
+		for j := range tables {
+			d, sign := boothW5(boothWindowBits(ks[j][32-glsScalarSize:], i))
+			twistPointSelect(t, tables[j][:], d)
+			negY.Neg(&t.y)
+			t.y.Select(negY, &t.y, sign^negs[j])
+			c.Add(c, t)
+		}
+	}
+}
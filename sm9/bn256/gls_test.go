@@ -0,0 +1,115 @@
+package bn256
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestGLSEndomorphism(t *testing.T) {
+	lambda := new(big.Int).Mul(u, u)
+	lambda.Mul(lambda, big.NewInt(6))
+	if new(big.Int).Mod(p, Order).Cmp(lambda) != 0 {
+		t.Fatal("p mod Order != 6u²")
+	}
+	got, _ := new(G2).ScalarMult(Gen2, lambda.Bytes())
+	want := &G2{&twistPoint{}}
+	want.p.ScalarMult(twistGen, lambda.Bytes())
+	if !bytes.Equal(got.Marshal(), want.Marshal()) || !bytes.Equal(got.Marshal(), new(G2).Psi(Gen2).Marshal()) {
+		t.Errorf("[λ]Q != ψ(Q)")
+	}
+}
+
+func TestGLSDecompose(t *testing.T) {
+	lambda := new(big.Int).Mod(p, Order)
+	max := new(big.Int).Lsh(big.NewInt(1), 8*glsScalarSize)
+	scalars := []*big.Int{
+		big.NewInt(0), big.NewInt(1), lambda,
+		new(big.Int).Sub(Order, big.NewInt(1)), Order,
+		new(big.Int).Lsh(big.NewInt(1), 255),
+		new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)),
+	}
+	for i := 0; i < 1000; i++ {
+		k := make([]byte, 32)
+		rand.Read(k)
+		scalars = append(scalars, new(big.Int).SetBytes(k))
+	}
+	for _, k := range scalars {
+		var kb [32]byte
+		var ks [4][32]byte
+		k.FillBytes(kb[:])
+		negs := glsDecompose(&ks, &kb)
+		sum, pow := new(big.Int).Neg(k), big.NewInt(1)
+		for i := range ks {
+			ki := new(big.Int).SetBytes(ks[i][:])
+			if ki.Cmp(max) >= 0 {
+				t.Fatalf("decomposition of %x is too long: k%d = %x", k, i, ki)
+			}
+			if negs[i] == 1 {
+				ki.Neg(ki)
+			}
+			sum.Add(sum, ki.Mul(ki, pow))
+			pow.Mul(pow, lambda)
+		}
+		if sum.Mod(sum, Order).Sign() != 0 {
+			t.Fatalf("k0 + k1λ + k2λ² + k3λ³ != %x", k)
+		}
+	}
+}
+
+func TestG2ScalarMultGLS(t *testing.T) {
+	_, q, err := RandomG2(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := randomK(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, scalar := range []*big.Int{
+		big.NewInt(0), big.NewInt(1), big.NewInt(15), big.NewInt(16), k,
+		new(big.Int).Sub(Order, big.NewInt(1)),
+		new(big.Int).Lsh(big.NewInt(1), 128),
+		new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)),
+	} {
+		got, _ := new(G2).ScalarMult(q, scalar.Bytes())
+		want := &G2{&twistPoint{}}
+		want.p.ScalarMult(q.p, scalar.Bytes())
+		if !bytes.Equal(got.Marshal(), want.Marshal()) {
+			t.Errorf("ScalarMult(%x) mismatch", scalar)
+		}
+	}
+
+	// infinity and in place
+	inf := &G2{&twistPoint{}}
+	inf.p.SetInfinity()
+	if e, _ := new(G2).ScalarMult(inf, k.Bytes()); !e.IsInfinity() {
+		t.Error("[k]O != O")
+	}
+	want, _ := new(G2).ScalarMult(q, k.Bytes())
+	e := new(G2).Set(q)
+	e.ScalarMult(e, k.Bytes())
+	if !bytes.Equal(e.Marshal(), want.Marshal()) {
+		t.Error("in place ScalarMult mismatch")
+	}
+}
+
+func BenchmarkG2ScalarMult(b *testing.B) {
+	x, _ := rand.Int(rand.Reader, Order)
+	xb := NormalizeScalar(x.Bytes())
+	_, q, _ := RandomG2(rand.Reader)
+	b.Run("GLS", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			new(G2).ScalarMult(q, xb)
+		}
+	})
+	b.Run("Booth", func(b *testing.B) {
+		e := &twistPoint{}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			e.ScalarMult(q.p, xb)
+		}
+	})
+}
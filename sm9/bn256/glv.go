@@ -0,0 +1,165 @@
+package bn256
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// G₁ has the endomorphism φ(x, y) = (βx, y) where β is a primitive cube root
+// of unity in GF(p), and φ(P) = [λ]P for every point P of G₁, where λ is a
+// primitive cube root of unity modulo Order. A scalar k is decomposed into
+// k₁ + k₂λ (mod Order) with |k₁|, |k₂| of about 128 bits (GLV), so that [k]P
+// = [k₁]P + [k₂]φ(P) is evaluated with half the doublings of a plain scalar
+// multiplication, see curvePointMulGLV.
+
+// glvBeta is β, φ(x, y) = (βx, y).
+var glvBeta = fromBigInt(bigFromHex("b640000002a3a6f0e303ab4ff2eb2052a9f02115caef75e70f738991676af249"))
+
+// glvLambda is λ = -(36u³ + 18u² + 6u + 2) mod Order, φ(P) = [λ]P.
+var glvLambda = bigFromHex("b640000002a3a6eff003ab4ff0477961e1edaee07e84c2d0b978eb1109153e3f")
+
+// The short basis of the lattice {(x, y) : x + yλ = 0 mod Order} is
+// (glvA1, -glvU) and (glvU, glvB2), where glvU = 2u + 1, and glvG1, glvG2 are
+// round(2²⁵⁶ × glvB2 / Order) and round(2²⁵⁶ × glvU / Order). The values are
+// little-endian 64-bit limbs.
+var (
+	glvA1 = [4]uint64{0xc000b98b0d64696c, 0xd8000000019062ed, 0, 0}
+	glvB2 = [4]uint64{0x8000b98b0e165c81, 0xd8000000019062ee, 0, 0}
+	glvU  = [4]uint64{0xc000000000b1f315, 0, 0, 0}
+	glvG1 = [4]uint64{0x83b2fd057ce97d7a, 0x2f684bda10c41c31, 0x0000000000000001, 0}
+	glvG2 = [4]uint64{0x0db20a88f17b78d1, 0x0000000000000001, 0, 0}
+)
+
+// glvScalarSize is the size in bytes of |k₁| and |k₂|, which are less than
+// 2¹²⁹ for any 256-bit k.
+const glvScalarSize = 17
+
+// glvMul returns the 512-bit product of x and y.
+func glvMul(x, y *[4]uint64) (lo, hi [4]uint64) {
+	var t [8]uint64
+	for i := 0; i < 4; i++ {
+		var carry uint64
+		for j := 0; j < 4; j++ {
+			h, l := bits.Mul64(x[i], y[j])
+			var c uint64
+			l, c = bits.Add64(l, t[i+j], 0)
+			h += c
+			l, c = bits.Add64(l, carry, 0)
+			h += c
+			t[i+j], carry = l, h
+		}
+		t[i+4] = carry
+	}
+	copy(lo[:], t[:4])
+	copy(hi[:], t[4:])
+	return
+}
+
+// glvRound returns round(k × g / 2²⁵⁶).
+func glvRound(k, g *[4]uint64) [4]uint64 {
+	lo, hi := glvMul(k, g)
+	_, c := bits.Add64(lo[3], 1<<63, 0)
+	for i := range hi {
+		hi[i], c = bits.Add64(hi[i], 0, c)
+	}
+	return hi
+}
+
+// glvSub sets z to x - y modulo 2²⁵⁶.
+func glvSub(z, x, y *[4]uint64) {
+	var b uint64
+	for i := range z {
+		z[i], b = bits.Sub64(x[i], y[i], b)
+	}
+}
+
+// glvAbs writes |x| of the two's complement x to out, big-endian, and returns
+// 1 if x is negative, in constant time.
+func glvAbs(out *[32]byte, x *[4]uint64) int {
+	neg := x[3] >> 63
+	mask := -neg
+	c := neg
+	for i := range x {
+		var v uint64
+		v, c = bits.Add64(x[i]^mask, 0, c)
+		binary.BigEndian.PutUint64(out[24-8*i:], v)
+	}
+	return int(neg)
+}
+
+// glvDecompose writes |k₁| and |k₂| of k = k₁ + k₂λ (mod Order) to k1 and k2,
+// big-endian, and returns their signs, 1 if negative. k is a big-endian 256-bit
+// integer, it runs in constant time.
+func glvDecompose(k1, k2 *[32]byte, k *[32]byte) (neg1, neg2 int) {
+	var x [4]uint64
+	for i := range x {
+		x[i] = binary.BigEndian.Uint64(k[24-8*i:])
+	}
+	c1 := glvRound(&x, &glvG1)
+	c2 := glvRound(&x, &glvG2)
+
+	var r1, r2 [4]uint64
+	t, _ := glvMul(&c1, &glvA1)
+	glvSub(&r1, &x, &t)
+	t, _ = glvMul(&c2, &glvU)
+	glvSub(&r1, &r1, &t) // k₁ = k - c₁A₁ - c₂U
+
+	r2, _ = glvMul(&c1, &glvU)
+	t, _ = glvMul(&c2, &glvB2)
+	glvSub(&r2, &r2, &t) // k₂ = c₁U - c₂B₂
+
+	return glvAbs(k1, &r1), glvAbs(k2, &r2)
+}
+
+// curvePointMulGLV sets c to [scalar]a in constant time. The scalar is
+// decomposed by glvDecompose and both halves are recoded with boothW5, the
+// additions of the digits of [k₁]a and [k₂]φ(a) are interleaved after each
+// run of doublings. Scalars longer than 32 bytes are reduced modulo Order.
+func curvePointMulGLV(c, a *curvePoint, scalar []byte) {
+	var k [32]byte
+	if len(scalar) > 32 {
+		scalar = NormalizeScalar(scalar)
+	}
+	copy(k[32-len(scalar):], scalar)
+	var k1, k2 [32]byte
+	neg1, neg2 := glvDecompose(&k1, &k2, &k)
+	s1, s2 := k1[32-glvScalarSize:], k2[32-glvScalarSize:]
+
+	// [1]a to [16]a and their images by φ
+	var points, phiPoints [1 << (boothWindow - 1)]curvePoint
+	var table, phiTable [len(points)]*curvePoint
+	for i := range table {
+		table[i] = &points[i]
+		phiTable[i] = &phiPoints[i]
+	}
+	table[0].Set(a)
+	for i := 1; i < len(table); i += 2 {
+		table[i].Double(table[i/2])
+		if i+1 < len(table) {
+			table[i+1].Add(table[i], a)
+		}
+	}
+	for i, p := range table {
+		phiTable[i].Set(p)
+		gfpMul(&phiTable[i].x, &p.x, glvBeta)
+	}
+
+	t, negY := &curvePoint{}, &gfP{}
+	c.SetInfinity()
+	for i := boothWindowCount(glvScalarSize) - 1; i >= 0; i-- {
+		for j := 0; j < boothWindow; j++ {
+			c.Double(c)
+		}
+		d, sign := boothW5(boothWindowBits(s1, i))
+		curvePointSelect(t, table[:], d)
+		gfpNeg(negY, &t.y)
+		t.y.Select(negY, &t.y, sign^neg1)
+		c.Add(c, t)
+
+		d, sign = boothW5(boothWindowBits(s2, i))
+		curvePointSelect(t, phiTable[:], d)
+		gfpNeg(negY, &t.y)
+		t.y.Select(negY, &t.y, sign^neg2)
+		c.Add(c, t)
+	}
+}
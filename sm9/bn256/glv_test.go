@@ -0,0 +1,121 @@
+package bn256
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestGLVEndomorphism(t *testing.T) {
+	want := &curvePoint{}
+	curvePointMulVarTime(want, curveGen, glvLambda)
+	want.MakeAffine()
+	got := &curvePoint{}
+	got.Set(curveGen)
+	gfpMul(&got.x, &got.x, glvBeta)
+	if got.x != want.x || got.y != want.y {
+		t.Errorf("φ(G) != [λ]G")
+	}
+}
+
+func TestGLVDecompose(t *testing.T) {
+	max := new(big.Int).Lsh(big.NewInt(1), 8*glvScalarSize)
+	scalars := []*big.Int{
+		big.NewInt(0), big.NewInt(1), glvLambda,
+		new(big.Int).Sub(Order, big.NewInt(1)), Order,
+		new(big.Int).Lsh(big.NewInt(1), 255),
+		new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)),
+	}
+	for i := 0; i < 1000; i++ {
+		k := make([]byte, 32)
+		rand.Read(k)
+		scalars = append(scalars, new(big.Int).SetBytes(k))
+	}
+	for _, k := range scalars {
+		var kb, b1, b2 [32]byte
+		k.FillBytes(kb[:])
+		neg1, neg2 := glvDecompose(&b1, &b2, &kb)
+		k1, k2 := new(big.Int).SetBytes(b1[:]), new(big.Int).SetBytes(b2[:])
+		if k1.Cmp(max) >= 0 || k2.Cmp(max) >= 0 {
+			t.Fatalf("decomposition of %x is too long: %x, %x", k, k1, k2)
+		}
+		if neg1 == 1 {
+			k1.Neg(k1)
+		}
+		if neg2 == 1 {
+			k2.Neg(k2)
+		}
+		sum := new(big.Int).Mul(k2, glvLambda)
+		sum.Add(sum, k1).Sub(sum, k)
+		if sum.Mod(sum, Order).Sign() != 0 {
+			t.Fatalf("k1 + k2λ != %x", k)
+		}
+	}
+}
+
+// curvePointMulVarTime is the double-and-add reference of curvePoint.Mul.
+func curvePointMulVarTime(c, a *curvePoint, scalar *big.Int) {
+	sum, t := NewCurvePoint(), &curvePoint{}
+	for i := scalar.BitLen(); i >= 0; i-- {
+		t.Double(sum)
+		if scalar.Bit(i) != 0 {
+			sum.Add(t, a)
+		} else {
+			sum.Set(t)
+		}
+	}
+	c.Set(sum)
+}
+
+func TestCurvePointMul(t *testing.T) {
+	k, err := randomK(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, scalar := range []*big.Int{
+		big.NewInt(0), big.NewInt(1), big.NewInt(15), big.NewInt(16), k, glvLambda,
+		new(big.Int).Sub(Order, big.NewInt(1)),
+		new(big.Int).Lsh(big.NewInt(1), 128),
+	} {
+		got, want := &curvePoint{}, &curvePoint{}
+		got.Mul(curveGen, scalar)
+		curvePointMulVarTime(want, curveGen, scalar)
+		got.MakeAffine()
+		want.MakeAffine()
+		if got.x != want.x || got.y != want.y {
+			t.Errorf("Mul(%x) mismatch", scalar)
+		}
+	}
+
+	// scalars are reduced modulo Order
+	got, want := &curvePoint{}, &curvePoint{}
+	got.Mul(curveGen, new(big.Int).Add(new(big.Int).Lsh(Order, 10), k))
+	want.Mul(curveGen, k)
+	if !bytes.Equal(marshalCurvePoint(got), marshalCurvePoint(want)) {
+		t.Error("Mul of long scalar mismatch")
+	}
+	got.Mul(curveGen, new(big.Int).Neg(k))
+	want.Mul(curveGen, k)
+	want.Neg(want)
+	if !bytes.Equal(marshalCurvePoint(got), marshalCurvePoint(want)) {
+		t.Error("Mul of negative scalar mismatch")
+	}
+
+	// infinity and in place
+	p := NewCurvePoint()
+	p.Mul(p, k)
+	if !p.IsInfinity() {
+		t.Error("[k]O != O")
+	}
+	p.Set(curveGen)
+	p.Mul(p, k)
+	want.Mul(curveGen, k)
+	if !bytes.Equal(marshalCurvePoint(p), marshalCurvePoint(want)) {
+		t.Error("in place Mul mismatch")
+	}
+}
+
+func marshalCurvePoint(c *curvePoint) []byte {
+	return (&G1{p: c}).Marshal()
+}
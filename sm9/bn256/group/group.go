@@ -0,0 +1,147 @@
+// Package group adapts the bn256 pairing engine to a generic prime order group
+// interface, in the style of kyber.Group and gnark-crypto, so that higher level
+// protocol libraries (zero knowledge proofs, threshold cryptography, ...) can
+// target the SM9 curve without bespoke glue.
+//
+// The groups G1, G2 and GT share the scalar field of order bn256.Order. All
+// groups are written additively: the group operation of GT, which is the
+// multiplication in GF(p¹²), is Add, and the exponentiation is Mul.
+//
+// Scalars are backed by math/big and are not constant time, nor is the
+// exponentiation in GT, NewSuite refuses to create a suite once constant time
+// is required, see backend.RequireConstantTime.
+package group
+
+import (
+	"io"
+
+	"github.com/emmansun/gmsm/internal/impl"
+	"github.com/emmansun/gmsm/sm9/bn256"
+)
+
+// scalarPath is the variable-time code path of this package.
+const scalarPath = "sm9/bn256/group: math/big scalars"
+
+func init() {
+	impl.RegisterVariableTimePath(scalarPath)
+}
+
+// Scalar is an element of the scalar field of the bn256 groups. Methods with a
+// Scalar receiver set the receiver to the result and return it.
+//
+// Scalars are math/big integers, all operations run in variable time: they
+// leak the values through timing, don't use them for long term secrets.
+type Scalar interface {
+	// MarshalBinary encodes the scalar as ScalarLen big-endian bytes.
+	MarshalBinary() ([]byte, error)
+	// UnmarshalBinary decodes a scalar of ScalarLen bytes, it must be reduced.
+	UnmarshalBinary(data []byte) error
+	String() string
+
+	Equal(s2 Scalar) bool
+	Set(a Scalar) Scalar
+	Clone() Scalar
+	SetInt64(v int64) Scalar
+	// SetBytes sets the scalar from big-endian bytes, reduced modulo the order.
+	SetBytes(b []byte) Scalar
+	Zero() Scalar
+	One() Scalar
+	Add(a, b Scalar) Scalar
+	Sub(a, b Scalar) Scalar
+	Neg(a Scalar) Scalar
+	Mul(a, b Scalar) Scalar
+	// Div sets the scalar to a/b, it returns an error if b is zero.
+	Div(a, b Scalar) (Scalar, error)
+	// Inv sets the scalar to 1/a, it returns an error if a is zero.
+	Inv(a Scalar) (Scalar, error)
+	// Pick sets the scalar to a uniformly random value read from rand.
+	Pick(rand io.Reader) (Scalar, error)
+}
+
+// Point is an element of one of the bn256 groups. Methods with a Point
+// receiver set the receiver to the result and return it. Points of different
+// groups can not be mixed.
+type Point interface {
+	MarshalBinary() ([]byte, error)
+	// UnmarshalBinary decodes a point of PointLen bytes and checks it.
+	UnmarshalBinary(data []byte) error
+	String() string
+
+	Equal(p2 Point) bool
+	// Null sets the point to the identity element.
+	Null() Point
+	// Base sets the point to the standard generator.
+	Base() Point
+	// Pick sets the point to a uniformly random element read from rand.
+	Pick(rand io.Reader) (Point, error)
+	Set(p Point) Point
+	Clone() Point
+	Add(a, b Point) Point
+	Sub(a, b Point) Point
+	Neg(a Point) Point
+	// Mul sets the point to [s]p, or [s]Base if p is nil.
+	Mul(s Scalar, p Point) Point
+}
+
+// Group is a prime order group with its scalar field.
+type Group interface {
+	String() string
+	// ScalarLen returns the encoded length of a scalar.
+	ScalarLen() int
+	// Scalar returns a new zero scalar.
+	Scalar() Scalar
+	// PointLen returns the encoded length of a point.
+	PointLen() int
+	// Point returns a new identity point.
+	Point() Point
+}
+
+// Suite is the bn256 pairing suite, it gives access to the three groups and
+// the pairing between them.
+type Suite struct{}
+
+// NewSuite returns the bn256 pairing suite. It returns an error wrapping
+// backend.ErrVariableTime if constant time is required.
+func NewSuite() (*Suite, error) {
+	if err := impl.CheckPath(scalarPath); err != nil {
+		return nil, err
+	}
+	return &Suite{}, nil
+}
+
+var (
+	g1Instance = &g1Group{}
+	g2Instance = &g2Group{}
+	gtInstance = &gtGroup{}
+)
+
+// G1 returns the group G1 of the curve over GF(p).
+func (s *Suite) G1() Group { return g1Instance }
+
+// G2 returns the group G2 of the twist curve over GF(p²).
+func (s *Suite) G2() Group { return g2Instance }
+
+// GT returns the target group of the pairing.
+func (s *Suite) GT() Group { return gtInstance }
+
+// Pair returns e(p1, p2), p1 must be a point of G1 and p2 a point of G2.
+func (s *Suite) Pair(p1, p2 Point) Point {
+	return &pointGT{bn256.Pair(p1.(*pointG1).g, p2.(*pointG2).g)}
+}
+
+// PairingCheck reports whether the product of e(p1[i], p2[i]) is the identity,
+// with one shared final exponentiation.
+func (s *Suite) PairingCheck(p1, p2 []Point) bool {
+	if len(p1) != len(p2) {
+		return false
+	}
+	acc := new(bn256.GT).SetOne()
+	for i := range p1 {
+		a, b := p1[i].(*pointG1).g, p2[i].(*pointG2).g
+		if a.IsInfinity() || b.IsInfinity() {
+			continue
+		}
+		acc.Add(acc, bn256.Miller(a, b))
+	}
+	return acc.Finalize().Equal(new(bn256.GT).SetOne())
+}
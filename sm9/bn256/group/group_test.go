@@ -0,0 +1,179 @@
+package group
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm9/bn256"
+)
+
+func testGroup(t *testing.T, g Group) {
+	t.Helper()
+	a, err := g.Scalar().Pick(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := g.Scalar().Pick(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ab := g.Scalar().Add(a, b)
+
+	// [a]B + [b]B == [a+b]B
+	pa := g.Point().Mul(a, nil)
+	pb := g.Point().Mul(b, g.Point().Base())
+	sum := g.Point().Add(pa, pb)
+	if !sum.Equal(g.Point().Mul(ab, nil)) {
+		t.Errorf("%v: [a]B + [b]B != [a+b]B", g)
+	}
+	// [a+b]B - [b]B == [a]B
+	if !g.Point().Sub(sum, pb).Equal(pa) {
+		t.Errorf("%v: subtraction failed", g)
+	}
+	// P + (-P) == 0
+	if !g.Point().Add(pa, g.Point().Neg(pa)).Equal(g.Point().Null()) {
+		t.Errorf("%v: P - P is not the identity", g)
+	}
+	// [a][1/a]P == P
+	inv, err := g.Scalar().Inv(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Point().Mul(a, g.Point().Mul(inv, pb)).Equal(pb) {
+		t.Errorf("%v: [a][1/a]P != P", g)
+	}
+	// [0]P == 0 and [-1]P == -P
+	if !g.Point().Mul(g.Scalar().Zero(), pa).Equal(g.Point().Null()) {
+		t.Errorf("%v: [0]P is not the identity", g)
+	}
+	minusOne := g.Scalar().SetInt64(-1)
+	if !g.Point().Mul(minusOne, pa).Equal(g.Point().Neg(pa)) {
+		t.Errorf("%v: [-1]P != -P", g)
+	}
+
+	// encoding round trip
+	data, err := sum.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != g.PointLen() {
+		t.Errorf("%v: got point length %v, expected %v", g, len(data), g.PointLen())
+	}
+	p := g.Point()
+	if err = p.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Equal(sum) {
+		t.Errorf("%v: point encoding round trip failed", g)
+	}
+	if err = p.UnmarshalBinary(data[1:]); err == nil {
+		t.Errorf("%v: expected error for short point encoding", g)
+	}
+	c := p.Clone()
+	p.Null()
+	if !c.Equal(sum) {
+		t.Errorf("%v: clone shares state with the original", g)
+	}
+}
+
+func newTestSuite(t *testing.T) *Suite {
+	t.Helper()
+	suite, err := NewSuite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return suite
+}
+
+func TestGroups(t *testing.T) {
+	suite := newTestSuite(t)
+	for _, g := range []Group{suite.G1(), suite.G2(), suite.GT()} {
+		testGroup(t, g)
+	}
+}
+
+func TestScalar(t *testing.T) {
+	g := newTestSuite(t).G1()
+	a, err := g.Scalar().Pick(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := g.Scalar().SetInt64(7)
+	q, err := g.Scalar().Div(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Scalar().Mul(q, b).Equal(a) {
+		t.Errorf("a/b*b != a")
+	}
+	if !g.Scalar().Add(a, g.Scalar().Neg(a)).Equal(g.Scalar().Zero()) {
+		t.Errorf("a + (-a) != 0")
+	}
+	inv, err := g.Scalar().Inv(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Scalar().Mul(a, inv).Equal(g.Scalar().One()) {
+		t.Errorf("a * 1/a != 1")
+	}
+	zero := g.Scalar().Zero()
+	if _, err := g.Scalar().Div(a, zero); err == nil {
+		t.Errorf("a/0 succeeded")
+	}
+	if _, err := g.Scalar().Inv(zero); err == nil {
+		t.Errorf("1/0 succeeded")
+	}
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != g.ScalarLen() {
+		t.Errorf("got scalar length %v, expected %v", len(data), g.ScalarLen())
+	}
+	c := g.Scalar()
+	if err = c.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Equal(a) {
+		t.Errorf("scalar encoding round trip failed")
+	}
+	for i := range data {
+		data[i] = 0xff
+	}
+	if err = c.UnmarshalBinary(data); err == nil {
+		t.Errorf("expected error for unreduced scalar")
+	}
+	if !g.Scalar().SetBytes(bn256.Order.Bytes()).Equal(g.Scalar().Zero()) {
+		t.Errorf("SetBytes is not reduced")
+	}
+}
+
+func TestPairing(t *testing.T) {
+	suite := newTestSuite(t)
+	a, _ := suite.G1().Scalar().Pick(rand.Reader)
+	b, _ := suite.G1().Scalar().Pick(rand.Reader)
+	pa := suite.G1().Point().Mul(a, nil)
+	qb := suite.G2().Point().Mul(b, nil)
+
+	// e([a]P, [b]Q) == e(P, Q)^(ab)
+	ab := suite.GT().Scalar().Mul(a, b)
+	want := suite.GT().Point().Mul(ab, nil)
+	if !suite.Pair(pa, qb).Equal(want) {
+		t.Errorf("pairing is not bilinear")
+	}
+	if !suite.Pair(suite.G1().Point().Base(), suite.G2().Point().Base()).Equal(suite.GT().Point().Base()) {
+		t.Errorf("GT base mismatch")
+	}
+
+	// e([a]P, [b]Q) * e(-[ab]P, Q) == 1
+	pab := suite.G1().Point().Mul(ab, nil)
+	p1 := []Point{pa, suite.G1().Point().Neg(pab)}
+	p2 := []Point{qb, suite.G2().Point().Base()}
+	if !suite.PairingCheck(p1, p2) {
+		t.Errorf("PairingCheck failed")
+	}
+	p1[1] = pab
+	if suite.PairingCheck(p1, p2) {
+		t.Errorf("PairingCheck with wrong input succeeded")
+	}
+}
@@ -0,0 +1,314 @@
+package group
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/sm9/bn256"
+)
+
+type g1Group struct{}
+
+func (g *g1Group) String() string { return "sm9.bn256.G1" }
+func (g *g1Group) ScalarLen() int { return scalarLen }
+func (g *g1Group) Scalar() Scalar { return newScalar() }
+func (g *g1Group) PointLen() int  { return 64 }
+func (g *g1Group) Point() Point   { return newPointG1() }
+
+type g2Group struct{}
+
+func (g *g2Group) String() string { return "sm9.bn256.G2" }
+func (g *g2Group) ScalarLen() int { return scalarLen }
+func (g *g2Group) Scalar() Scalar { return newScalar() }
+func (g *g2Group) PointLen() int  { return 128 }
+func (g *g2Group) Point() Point   { return newPointG2() }
+
+type gtGroup struct{}
+
+func (g *gtGroup) String() string { return "sm9.bn256.GT" }
+func (g *gtGroup) ScalarLen() int { return scalarLen }
+func (g *gtGroup) Scalar() Scalar { return newScalar() }
+func (g *gtGroup) PointLen() int  { return 384 }
+func (g *gtGroup) Point() Point   { return newPointGT() }
+
+// pointG1 wraps a bn256.G1, the wrapped point is never nil.
+type pointG1 struct {
+	g *bn256.G1
+}
+
+func newPointG1() *pointG1 {
+	p := &pointG1{new(bn256.G1)}
+	p.Null()
+	return p
+}
+
+func (p *pointG1) MarshalBinary() ([]byte, error) {
+	return p.g.Marshal(), nil
+}
+
+func (p *pointG1) UnmarshalBinary(data []byte) error {
+	if len(data) != g1Instance.PointLen() {
+		return errors.New("group: invalid G1 point length")
+	}
+	g := new(bn256.G1)
+	if _, err := g.Unmarshal(data); err != nil {
+		return err
+	}
+	// G1 has cofactor 1, every point on the curve is in G1.
+	p.g = g
+	return nil
+}
+
+func (p *pointG1) String() string {
+	return p.g.String()
+}
+
+func (p *pointG1) Equal(p2 Point) bool {
+	return bytes.Equal(p.g.Marshal(), p2.(*pointG1).g.Marshal())
+}
+
+func (p *pointG1) Null() Point {
+	p.g.ScalarMult(bn256.Gen1, []byte{0})
+	return p
+}
+
+func (p *pointG1) Base() Point {
+	p.g.Set(bn256.Gen1)
+	return p
+}
+
+func (p *pointG1) Pick(r io.Reader) (Point, error) {
+	if r == nil {
+		r = randutil.Reader()
+	}
+	_, g, err := bn256.RandomG1(r)
+	if err != nil {
+		return nil, err
+	}
+	p.g = g
+	return p, nil
+}
+
+func (p *pointG1) Set(a Point) Point {
+	p.g.Set(a.(*pointG1).g)
+	return p
+}
+
+func (p *pointG1) Clone() Point {
+	return &pointG1{new(bn256.G1).Set(p.g)}
+}
+
+func (p *pointG1) Add(a, b Point) Point {
+	p.g.Add(a.(*pointG1).g, b.(*pointG1).g)
+	return p
+}
+
+func (p *pointG1) Sub(a, b Point) Point {
+	t := new(bn256.G1).Neg(b.(*pointG1).g)
+	p.g.Add(a.(*pointG1).g, t)
+	return p
+}
+
+func (p *pointG1) Neg(a Point) Point {
+	p.g.Neg(a.(*pointG1).g)
+	return p
+}
+
+func (p *pointG1) Mul(s Scalar, a Point) Point {
+	k := s.(*scalar).bytes()
+	if a == nil {
+		p.g.ScalarBaseMult(k)
+	} else {
+		p.g.ScalarMult(a.(*pointG1).g, k)
+	}
+	return p
+}
+
+// pointG2 wraps a bn256.G2, the wrapped point is never nil.
+type pointG2 struct {
+	g *bn256.G2
+}
+
+func newPointG2() *pointG2 {
+	p := &pointG2{new(bn256.G2)}
+	p.Null()
+	return p
+}
+
+func (p *pointG2) MarshalBinary() ([]byte, error) {
+	return p.g.Marshal(), nil
+}
+
+func (p *pointG2) UnmarshalBinary(data []byte) error {
+	if len(data) != g2Instance.PointLen() {
+		return errors.New("group: invalid G2 point length")
+	}
+	g := new(bn256.G2)
+	if _, err := g.Unmarshal(data); err != nil {
+		return err
+	}
+	if !g.IsInSubgroup() {
+		return errors.New("group: point is not in G2")
+	}
+	p.g = g
+	return nil
+}
+
+func (p *pointG2) String() string {
+	return p.g.String()
+}
+
+func (p *pointG2) Equal(p2 Point) bool {
+	return bytes.Equal(p.g.Marshal(), p2.(*pointG2).g.Marshal())
+}
+
+func (p *pointG2) Null() Point {
+	p.g.ScalarMult(bn256.Gen2, []byte{0})
+	return p
+}
+
+func (p *pointG2) Base() Point {
+	p.g.Set(bn256.Gen2)
+	return p
+}
+
+func (p *pointG2) Pick(r io.Reader) (Point, error) {
+	if r == nil {
+		r = randutil.Reader()
+	}
+	_, g, err := bn256.RandomG2(r)
+	if err != nil {
+		return nil, err
+	}
+	p.g = g
+	return p, nil
+}
+
+func (p *pointG2) Set(a Point) Point {
+	p.g.Set(a.(*pointG2).g)
+	return p
+}
+
+func (p *pointG2) Clone() Point {
+	return &pointG2{new(bn256.G2).Set(p.g)}
+}
+
+func (p *pointG2) Add(a, b Point) Point {
+	p.g.Add(a.(*pointG2).g, b.(*pointG2).g)
+	return p
+}
+
+func (p *pointG2) Sub(a, b Point) Point {
+	t := new(bn256.G2).Neg(b.(*pointG2).g)
+	p.g.Add(a.(*pointG2).g, t)
+	return p
+}
+
+func (p *pointG2) Neg(a Point) Point {
+	p.g.Neg(a.(*pointG2).g)
+	return p
+}
+
+func (p *pointG2) Mul(s Scalar, a Point) Point {
+	k := s.(*scalar).bytes()
+	if a == nil {
+		p.g.ScalarBaseMult(k)
+	} else {
+		p.g.ScalarMult(a.(*pointG2).g, k)
+	}
+	return p
+}
+
+// pointGT wraps a bn256.GT, the wrapped element is never nil.
+type pointGT struct {
+	g *bn256.GT
+}
+
+func newPointGT() *pointGT {
+	return &pointGT{new(bn256.GT).SetOne()}
+}
+
+func (p *pointGT) MarshalBinary() ([]byte, error) {
+	return p.g.Marshal(), nil
+}
+
+// UnmarshalBinary decodes an element of GT, bn256.GT.Unmarshal rejects the
+// elements of GF(p¹²) which are not in the order bn256.Order subgroup.
+func (p *pointGT) UnmarshalBinary(data []byte) error {
+	if len(data) != gtInstance.PointLen() {
+		return errors.New("group: invalid GT element length")
+	}
+	g := new(bn256.GT)
+	if _, err := g.Unmarshal(data); err != nil {
+		return err
+	}
+	p.g = g
+	return nil
+}
+
+func (p *pointGT) String() string {
+	return p.g.String()
+}
+
+func (p *pointGT) Equal(p2 Point) bool {
+	return p.g.Equal(p2.(*pointGT).g)
+}
+
+func (p *pointGT) Null() Point {
+	p.g.SetOne()
+	return p
+}
+
+func (p *pointGT) Base() Point {
+	p.g.Set(bn256.Pair(bn256.Gen1, bn256.Gen2))
+	return p
+}
+
+func (p *pointGT) Pick(r io.Reader) (Point, error) {
+	if r == nil {
+		r = randutil.Reader()
+	}
+	_, g, err := bn256.RandomGT(r)
+	if err != nil {
+		return nil, err
+	}
+	p.g = g
+	return p, nil
+}
+
+func (p *pointGT) Set(a Point) Point {
+	p.g.Set(a.(*pointGT).g)
+	return p
+}
+
+func (p *pointGT) Clone() Point {
+	return &pointGT{new(bn256.GT).Set(p.g)}
+}
+
+func (p *pointGT) Add(a, b Point) Point {
+	p.g.Add(a.(*pointGT).g, b.(*pointGT).g)
+	return p
+}
+
+func (p *pointGT) Sub(a, b Point) Point {
+	t := new(bn256.GT).Neg(b.(*pointGT).g)
+	p.g.Add(a.(*pointGT).g, t)
+	return p
+}
+
+func (p *pointGT) Neg(a Point) Point {
+	p.g.Neg(a.(*pointGT).g)
+	return p
+}
+
+func (p *pointGT) Mul(s Scalar, a Point) Point {
+	k := &s.(*scalar).v
+	if a == nil {
+		p.g.ScalarBaseMult(k)
+	} else {
+		p.g.ScalarMult(a.(*pointGT).g, k)
+	}
+	return p
+}
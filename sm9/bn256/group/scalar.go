@@ -0,0 +1,137 @@
+package group
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/sm9/bn256"
+)
+
+const scalarLen = 32
+
+var errZeroInverse = errors.New("group: inverse of zero")
+
+// scalar is an integer modulo bn256.Order, always kept reduced.
+type scalar struct {
+	v big.Int
+}
+
+func newScalar() *scalar {
+	return &scalar{}
+}
+
+func (s *scalar) reduce() *scalar {
+	s.v.Mod(&s.v, bn256.Order)
+	return s
+}
+
+func (s *scalar) bytes() []byte {
+	return s.v.FillBytes(make([]byte, scalarLen))
+}
+
+func (s *scalar) MarshalBinary() ([]byte, error) {
+	return s.bytes(), nil
+}
+
+func (s *scalar) UnmarshalBinary(data []byte) error {
+	if len(data) != scalarLen {
+		return errors.New("group: invalid scalar length")
+	}
+	v := new(big.Int).SetBytes(data)
+	if v.Cmp(bn256.Order) >= 0 {
+		return errors.New("group: scalar is not reduced")
+	}
+	s.v.Set(v)
+	return nil
+}
+
+func (s *scalar) String() string {
+	return s.v.Text(16)
+}
+
+func (s *scalar) Equal(s2 Scalar) bool {
+	return s.v.Cmp(&s2.(*scalar).v) == 0
+}
+
+func (s *scalar) Set(a Scalar) Scalar {
+	s.v.Set(&a.(*scalar).v)
+	return s
+}
+
+func (s *scalar) Clone() Scalar {
+	return newScalar().Set(s)
+}
+
+func (s *scalar) SetInt64(v int64) Scalar {
+	s.v.SetInt64(v)
+	return s.reduce()
+}
+
+func (s *scalar) SetBytes(b []byte) Scalar {
+	s.v.SetBytes(b)
+	return s.reduce()
+}
+
+func (s *scalar) Zero() Scalar {
+	s.v.SetInt64(0)
+	return s
+}
+
+func (s *scalar) One() Scalar {
+	s.v.SetInt64(1)
+	return s
+}
+
+func (s *scalar) Add(a, b Scalar) Scalar {
+	s.v.Add(&a.(*scalar).v, &b.(*scalar).v)
+	return s.reduce()
+}
+
+func (s *scalar) Sub(a, b Scalar) Scalar {
+	s.v.Sub(&a.(*scalar).v, &b.(*scalar).v)
+	return s.reduce()
+}
+
+func (s *scalar) Neg(a Scalar) Scalar {
+	s.v.Neg(&a.(*scalar).v)
+	return s.reduce()
+}
+
+func (s *scalar) Mul(a, b Scalar) Scalar {
+	s.v.Mul(&a.(*scalar).v, &b.(*scalar).v)
+	return s.reduce()
+}
+
+func (s *scalar) Div(a, b Scalar) (Scalar, error) {
+	bv := &b.(*scalar).v
+	if bv.Sign() == 0 {
+		return nil, errZeroInverse
+	}
+	inv := new(big.Int).ModInverse(bv, bn256.Order)
+	s.v.Mul(&a.(*scalar).v, inv)
+	return s.reduce(), nil
+}
+
+func (s *scalar) Inv(a Scalar) (Scalar, error) {
+	av := &a.(*scalar).v
+	if av.Sign() == 0 {
+		return nil, errZeroInverse
+	}
+	s.v.ModInverse(av, bn256.Order)
+	return s, nil
+}
+
+func (s *scalar) Pick(r io.Reader) (Scalar, error) {
+	if r == nil {
+		r = randutil.Reader()
+	}
+	v, err := rand.Int(r, bn256.Order)
+	if err != nil {
+		return nil, err
+	}
+	s.v.Set(v)
+	return s, nil
+}
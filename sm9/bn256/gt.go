@@ -5,6 +5,8 @@ import (
 	"errors"
 	"io"
 	"math/big"
+
+	"github.com/emmansun/gmsm/internal/instrument"
 )
 
 // GT is an abstract cyclic group. The zero value is suitable for use as the
@@ -26,7 +28,10 @@ func RandomGT(r io.Reader) (*big.Int, *GT, error) {
 
 // Pair calculates an R-Ate pairing.
 func Pair(g1 *G1, g2 *G2) *GT {
-	return &GT{pairing(g2.p, g1.p)}
+	done := instrument.Start("sm9", "pairing")
+	e := &GT{pairing(g2.p, g1.p)}
+	done(nil)
+	return e
 }
 
 // Miller applies Miller's algorithm, which is a bilinear function from the
@@ -36,6 +41,36 @@ func Miller(g1 *G1, g2 *G2) *GT {
 	return &GT{miller(g2.p, g1.p)}
 }
 
+// MillerLoopInto is Miller which writes the result into out, and then returns
+// out. The temporaries are kept on the stack, so it doesn't allocate if out has
+// been used before, which makes it suitable for tight verification loops and
+// custom pairing products, see FinalExpInto.
+func MillerLoopInto(out *GT, g1 *G1, g2 *G2) *GT {
+	if out.p == nil {
+		out.p = &gfP12{}
+	}
+	if g1.IsInfinity() || g2.IsInfinity() {
+		out.p.SetOne()
+		return out
+	}
+	var scratch pairingScratch
+	out.p.Set(millerWithScratch(g2.p, g1.p, &scratch))
+	return out
+}
+
+// FinalExpInto sets out to the final exponentiation of in, the output of
+// MillerLoopInto or a product of such outputs, and then returns out. in and out
+// may be the same. Like MillerLoopInto, it doesn't allocate if out has been used
+// before.
+func FinalExpInto(out, in *GT) *GT {
+	if out.p == nil {
+		out.p = &gfP12{}
+	}
+	var scratch pairingScratch
+	out.p.Set(finalExponentiationWithScratch(in.p, &scratch))
+	return out
+}
+
 func (g *GT) String() string {
 	return "sm9.GT" + g.p.String()
 }
@@ -68,6 +103,21 @@ func (e *GT) Add(a, b *GT) *GT {
 	return e
 }
 
+// Neg sets e to -a, the inverse of a in the multiplicative group, and then
+// returns e.
+func (e *GT) Neg(a *GT) *GT {
+	if e.p == nil {
+		e.p = &gfP12{}
+	}
+	e.p.Invert(a.p)
+	return e
+}
+
+// Equal returns true if e and other are the same element.
+func (e *GT) Equal(other *GT) bool {
+	return subtle.ConstantTimeCompare(e.Marshal(), other.Marshal()) == 1
+}
+
 // Set sets e to a and then returns e.
 func (e *GT) Set(a *GT) *GT {
 	if e.p == nil {
@@ -88,9 +138,7 @@ func (e *GT) SetOne() *GT {
 
 // Finalize is a linear function from F_p^12 to GT.
 func (e *GT) Finalize() *GT {
-	ret := finalExponentiation(e.p)
-	e.p.Set(ret)
-	return e
+	return FinalExpInto(e, e)
 }
 
 // Marshal converts e into a byte slice.
@@ -132,7 +180,8 @@ func (e *GT) Marshal() []byte {
 }
 
 // Unmarshal sets e to the result of converting the output of Marshal back into
-// a group element and then returns e.
+// a group element and then returns e. The element must be in GT, which rules
+// out the elements of small order of GF(p¹²)*.
 func (e *GT) Unmarshal(m []byte) ([]byte, error) {
 	// Each value is a 256-bit number.
 	const numBytes = 256 / 8
@@ -196,9 +245,121 @@ func (e *GT) Unmarshal(m []byte) ([]byte, error) {
 	montEncode(&e.p.z.y.x, &e.p.z.y.x)
 	montEncode(&e.p.z.y.y, &e.p.z.y.y)
 
+	if !gtInSubgroup(e.p) {
+		return nil, errGTNotInSubgroup
+	}
 	return m[12*numBytes:], nil
 }
 
+// errGTNotInSubgroup is returned by Unmarshal and UnmarshalCompressed for an
+// element of GF(p¹²) which isn't in GT.
+var errGTNotInSubgroup = errors.New("sm9.GT: element is not in the subgroup")
+
+// gtInSubgroup reports whether a is in GT, the subgroup of order Order of the
+// cyclotomic subgroup of GF(p¹²)*. a is in the cyclotomic subgroup, of order
+// Φ₁₂(p) = p⁴ - p² + 1, if a^(p⁴) * a = a^(p²), and then in GT if
+// a^p = a^(6u²), as p = 6u² mod Order, see "Co-factor clearing and subgroup
+// membership testing on pairing-friendly curves",
+// https://eprint.iacr.org/2022/352, section 6.
+func gtInSubgroup(a *gfP12) bool {
+	if a.IsZero() {
+		return false
+	}
+	p2, p4 := &gfP12{}, &gfP12{}
+	p2.FrobeniusP2(a)
+	p4.FrobeniusP2(p2)
+	p4.Mul(p4, a)
+	if *p4 != *p2 {
+		return false
+	}
+	// a^(6u²) = (a^(u²))² * (a^(u²))⁴ with the cyclotomic squaring
+	t, t2 := &gfP12{}, &gfP12{}
+	t2.Cyclo6PowToU(a)
+	t.Cyclo6PowToU(t2) // Cyclo6PowToU doesn't support aliasing
+	t2.Cyclo6Square(t)
+	t.Cyclo6Square(t2)
+	t.Mul(t, t2)
+	p4.Frobenius(a)
+	return *p4 == *t
+}
+
+// gtTorusT is t, the generator of gfP12 over gfP6, in the gfP12 layout.
+var gtTorusT = func() *gfP12 {
+	t := &gfP12b6{}
+	t.x.SetOne()
+	return t.ToGfP12()
+}()
+
+// MarshalCompressed converts e into a byte slice of half the size of Marshal.
+// GT is in the algebraic torus T₂(p⁶), whose elements y + xt, x ≠ 0, are
+// determined by the single gfP6 value c = (1 + y)/x, see "Compression in
+// Finite Fields and Torus-Based Cryptography", Rubin and Silverberg. The
+// identity, the only element of GT with x = 0, is encoded as c = 0.
+func (e *GT) MarshalCompressed() []byte {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	// 2y = e + conj(e) and 2xt = e - conj(e), where conj is the p⁶-th power.
+	conj, num, den := &gfP12{}, &gfP12{}, &gfP12{}
+	conj.FrobeniusP6(e.p)
+	num.Add(e.p, conj)
+	den.Sub(e.p, conj)
+	gfpAdd(&num.z.y.y, &num.z.y.y, two)
+
+	// c = (2 + 2y)/(2xt) * t, the inverse of zero is zero.
+	den.Invert(den)
+	num.Mul(num, den)
+	num.Mul(num, gtTorusT)
+	c := (&gfP12b6{}).SetGfP12(num).y
+
+	ret := make([]byte, numBytes*6)
+	temp := &gfP{}
+	for i, v := range []*gfP{&c.x.x, &c.x.y, &c.y.x, &c.y.y, &c.z.x, &c.z.y} {
+		montDecode(temp, v)
+		temp.Marshal(ret[i*numBytes:])
+	}
+	return ret
+}
+
+// UnmarshalCompressed sets e to the result of converting the output of
+// MarshalCompressed back into an element of the torus, (c + t)/(c - t), and
+// then returns e. The element must be in GT.
+func (e *GT) UnmarshalCompressed(m []byte) ([]byte, error) {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	if len(m) < 6*numBytes {
+		return nil, errors.New("sm9.GT: not enough data")
+	}
+
+	c := &gfP12b6{}
+	for i, v := range []*gfP{&c.y.x.x, &c.y.x.y, &c.y.y.x, &c.y.y.y, &c.y.z.x, &c.y.z.y} {
+		if err := v.Unmarshal(m[i*numBytes:]); err != nil {
+			return nil, err
+		}
+		montEncode(v, v)
+	}
+
+	if e.p == nil {
+		e.p = &gfP12{}
+	}
+	if c.y.IsZero() {
+		e.p.SetOne()
+		return m[6*numBytes:], nil
+	}
+	num, den := c.ToGfP12(), c.ToGfP12()
+	num.Add(num, gtTorusT)
+	den.Sub(den, gtTorusT)
+	den.Invert(den)
+	num.Mul(num, den)
+	if !gtInSubgroup(num) {
+		return nil, errGTNotInSubgroup
+	}
+	e.p.Set(num)
+
+	return m[6*numBytes:], nil
+}
+
 // A GTFieldTable holds the first 15 Exp of a value at offset -1, so P
 // is at table[0], P^15 is at table[14], and P^0 is implicitly the identity
 // point.
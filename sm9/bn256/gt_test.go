@@ -49,6 +49,100 @@ func TestGT(t *testing.T) {
 	}
 }
 
+func TestGTNeg(t *testing.T) {
+	_, Ga, err := RandomGT(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Gb := new(GT).Neg(Ga)
+	if Gb.Equal(Ga) {
+		t.Errorf("-a equals to a")
+	}
+	Gb.Add(Gb, Ga)
+	if !Gb.Equal(new(GT).SetOne()) {
+		t.Errorf("a + (-a) is not the identity")
+	}
+}
+
+func TestGTMarshalCompressed(t *testing.T) {
+	_, g1, err := RandomG1(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, g2, err := RandomG2(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range []*GT{Pair(g1, g2), {gfP12Gen}, new(GT).SetOne()} {
+		m := x.MarshalCompressed()
+		if len(m) != len(x.Marshal())/2 {
+			t.Fatalf("unexpected compressed length %d", len(m))
+		}
+		y := new(GT)
+		rest, err := y.UnmarshalCompressed(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rest) != 0 {
+			t.Errorf("unexpected remaining data")
+		}
+		if !y.Equal(x) {
+			t.Errorf("got %v, want %v", y, x)
+		}
+		// the special squaring is only correct in the cyclotomic subgroup
+		sq, cyclo := &gfP12{}, &gfP12{}
+		sq.Square(y.p)
+		cyclo.Cyclo6Square(y.p)
+		if *sq != *cyclo {
+			t.Errorf("decompressed element is not in the cyclotomic subgroup")
+		}
+	}
+
+	if _, err := new(GT).UnmarshalCompressed(make([]byte, 191)); err == nil {
+		t.Errorf("expected error for short data")
+	}
+	m := make([]byte, 192)
+	for i := range m[:32] {
+		m[i] = 0xff
+	}
+	if _, err := new(GT).UnmarshalCompressed(m); err == nil {
+		t.Errorf("expected error for coordinate exceeding modulus")
+	}
+}
+
+func TestGTUnmarshalNotInSubgroup(t *testing.T) {
+	// testGfp12 is not in the cyclotomic subgroup, its image by the easy part
+	// of the final exponentiation, testGfp12^((p⁶-1)(p²+1)), is in the
+	// cyclotomic subgroup but not in GT.
+	cyclotomic := &gfP12{}
+	cyclotomic.FrobeniusP6(testGfp12)
+	cyclotomic.Mul(cyclotomic, (&gfP12{}).Invert(testGfp12))
+	cyclotomic.Mul(cyclotomic, (&gfP12{}).FrobeniusP2(cyclotomic))
+	tests := []struct {
+		name string
+		p    *gfP12
+	}{
+		{"zero", &gfP12{}},
+		{"not cyclotomic", testGfp12},
+		{"cyclotomic", cyclotomic},
+	}
+	for _, tt := range tests {
+		if gtInSubgroup(tt.p) {
+			t.Fatalf("%s: unexpected subgroup membership", tt.name)
+		}
+		if _, err := new(GT).Unmarshal((&GT{tt.p}).Marshal()); err != errGTNotInSubgroup {
+			t.Errorf("%s: got %v, want %v", tt.name, err, errGTNotInSubgroup)
+		}
+	}
+	// the torus T₂(p⁶) is larger than GT
+	if _, err := new(GT).UnmarshalCompressed((&GT{cyclotomic}).MarshalCompressed()); err != errGTNotInSubgroup {
+		t.Errorf("compressed: got %v, want %v", err, errGTNotInSubgroup)
+	}
+	if !gtInSubgroup(gfP12Gen) || !gtInSubgroup(new(GT).SetOne().p) {
+		t.Errorf("element of GT is not in the subgroup")
+	}
+}
+
 func BenchmarkGTMarshal(b *testing.B) {
 	x := &GT{gfP12Gen}
 	b.ReportAllocs()
@@ -58,6 +152,16 @@ func BenchmarkGTMarshal(b *testing.B) {
 	}
 }
 
+func BenchmarkGTUnmarshalCompressed(b *testing.B) {
+	m := (&GT{gfP12Gen}).MarshalCompressed()
+	x := new(GT)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.UnmarshalCompressed(m)
+	}
+}
+
 func BenchmarkGT(b *testing.B) {
 	x, _ := rand.Int(rand.Reader, Order)
 	b.ReportAllocs()
@@ -74,3 +178,61 @@ func BenchmarkPairing(b *testing.B) {
 		Pair(&G1{curveGen}, &G2{twistGen})
 	}
 }
+
+func TestMillerLoopInto(t *testing.T) {
+	_, g1, err := RandomG1(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, g2, err := RandomG2(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Pair(g1, g2)
+
+	out := new(GT)
+	MillerLoopInto(out, g1, g2)
+	if !bytes.Equal(out.Marshal(), Miller(g1, g2).Marshal()) {
+		t.Errorf("MillerLoopInto mismatch with Miller")
+	}
+	FinalExpInto(out, out)
+	if !out.Equal(want) {
+		t.Errorf("got %v, expected %v", out, want)
+	}
+
+	// e(g1, g2) * e(-g1, g2) == 1
+	acc := new(GT)
+	tmp := new(GT)
+	minusG1 := new(G1).Neg(g1)
+	MillerLoopInto(acc, g1, g2)
+	MillerLoopInto(tmp, minusG1, g2)
+	acc.Add(acc, tmp)
+	FinalExpInto(acc, acc)
+	if !acc.Equal(new(GT).SetOne()) {
+		t.Errorf("pairing product is not the identity")
+	}
+
+	// the point at infinity contributes one
+	MillerLoopInto(tmp, new(G1).Add(g1, minusG1), g2)
+	if !tmp.Equal(new(GT).SetOne()) {
+		t.Errorf("Miller loop of the point at infinity is not one")
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		MillerLoopInto(out, g1, g2)
+		FinalExpInto(out, out)
+	})
+	if allocs != 0 {
+		t.Errorf("got %v allocations, expected 0", allocs)
+	}
+}
+
+func BenchmarkMillerLoopInto(b *testing.B) {
+	out := new(GT)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MillerLoopInto(out, Gen1, Gen2)
+		FinalExpInto(out, out)
+	}
+}
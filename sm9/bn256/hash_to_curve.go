@@ -0,0 +1,121 @@
+package bn256
+
+import (
+	"github.com/emmansun/gmsm/internal/sm2ec"
+)
+
+// Hash to curve implementation for G1, follows RFC 9380 with the suites
+//
+//	SM9G1_XMD:SM3_SVDW_RO_ (HashToG1)
+//	SM9G1_XMD:SM3_SVDW_NU_ (EncodeToG1)
+//
+// The curve y² = x³ + 5 has A = 0, so the simplified SWU map doesn't apply
+// without an isogeny, the Shallue-van de Woestijne map of RFC 9380 6.6.1 is
+// used instead with Z = -1, the value selected by the find_z_svdw procedure
+// of RFC 9380 Appendix H.1. G1 has cofactor 1, clear_cofactor is a no-op.
+
+// h2cFieldElementLength is ceil((ceil(log2(p)) + k) / 8), k = 128.
+const h2cFieldElementLength = 48
+
+var (
+	// svdwZ is Z = -1.
+	svdwZ = newGFp(-1)
+	// svdwC1 is g(Z) = Z³ + 5 = 4.
+	svdwC1 = newGFp(4)
+	// svdwC2 is -Z / 2.
+	svdwC2 = fromBigInt(bigFromHex("5b2000000151d378eb01d5a7fac763a290f949a58d3d776df2b7cd93f1a8a2bf"))
+	// svdwC3 is sqrt(-g(Z) * 3Z²) with sgn0(c3) = 0.
+	svdwC3 = fromBigInt(bigFromHex("03cc0000000a8e9bc9e009c8d53e2de3d357f04659ef994cce"))
+	// svdwC4 is -4g(Z) / 3Z².
+	svdwC4 = fromBigInt(bigFromHex("3cc0000000e137a5f201391aa72f97c1b5fb866e5e28fa494c7a890d4bc5c1cf"))
+	// twoTo192 is 2¹⁹², used to reduce the 48 bytes outputs of hash_to_field.
+	twoTo192 = fromBigInt(bigFromHex("01000000000000000000000000000000000000000000000000"))
+)
+
+// HashToG1 hashes msg to a point of G1 with the domain separation tag dst,
+// it's the random oracle encoding hash_to_curve of RFC 9380. It runs in
+// constant time.
+func HashToG1(msg, dst []byte) (*G1, error) {
+	uniform, err := sm2ec.ExpandMessageXMD(msg, dst, 2*h2cFieldElementLength)
+	if err != nil {
+		return nil, err
+	}
+	q0 := svdwMap(hashToField(uniform[:h2cFieldElementLength]))
+	q1 := svdwMap(hashToField(uniform[h2cFieldElementLength:]))
+	e := &G1{&curvePoint{}}
+	e.p.Add(q0, q1)
+	return e, nil
+}
+
+// EncodeToG1 hashes msg to a point of G1 with the domain separation tag dst,
+// it's the nonuniform encoding encode_to_curve of RFC 9380. It runs in
+// constant time.
+func EncodeToG1(msg, dst []byte) (*G1, error) {
+	uniform, err := sm2ec.ExpandMessageXMD(msg, dst, h2cFieldElementLength)
+	if err != nil {
+		return nil, err
+	}
+	return &G1{svdwMap(hashToField(uniform))}, nil
+}
+
+// hashToField reduces the 48 bytes big endian value b modulo p, in the
+// Montgomery domain.
+func hashToField(b []byte) *gfP {
+	var buf [32]byte
+	hi, lo := &gfP{}, &gfP{}
+	copy(buf[8:], b[:24])
+	gfpUnmarshal(hi, &buf)
+	copy(buf[8:], b[24:])
+	gfpUnmarshal(lo, &buf)
+	// both halves are less than 2¹⁹² < p
+	montEncode(hi, hi)
+	montEncode(lo, lo)
+	gfpMul(hi, hi, twoTo192)
+	gfpAdd(hi, hi, lo)
+	return hi
+}
+
+// sgn0 returns the parity of the canonical value of e.
+func sgn0(e *gfP) int {
+	t := &gfP{}
+	montDecode(t, e)
+	return int(t[0] & 1)
+}
+
+// svdwMap is the Shallue-van de Woestijne map of RFC 9380 6.6.1, it returns
+// an affine point and runs in constant time.
+func svdwMap(u *gfP) *curvePoint {
+	c := &curvePoint{}
+
+	tv1, tv2, tv3, tv4 := &gfP{}, &gfP{}, &gfP{}, &gfP{}
+	gfpSqr(tv1, u, 1)
+	gfpMul(tv1, tv1, svdwC1)
+	gfpAdd(tv2, one, tv1) // tv2 = 1 + u² * g(Z)
+	gfpSub(tv1, one, tv1) // tv1 = 1 - u² * g(Z)
+	gfpMul(tv3, tv1, tv2)
+	tv3.Invert(tv3) // inv0, the inverse of zero is zero
+	gfpMul(tv4, u, tv1)
+	gfpMul(tv4, tv4, tv3)
+	gfpMul(tv4, tv4, svdwC3)
+
+	x1, x2, x3, gx, y := &gfP{}, &gfP{}, &gfP{}, &gfP{}, &gfP{}
+	gfpSub(x1, svdwC2, tv4)
+	e1 := sqrtVerified(y, c.polynomial(gx, x1))
+	gfpAdd(x2, svdwC2, tv4)
+	e2 := sqrtVerified(y, c.polynomial(gx, x2)) &^ e1
+	gfpSqr(x3, tv2, 1)
+	gfpMul(x3, x3, tv3)
+	gfpSqr(x3, x3, 1)
+	gfpMul(x3, x3, svdwC4)
+	gfpAdd(x3, x3, svdwZ)
+
+	c.x.Select(x1, x3, e1)
+	c.x.Select(x2, &c.x, e2)
+	// one of x1, x2 and x3 is always on the curve
+	sqrtVerified(y, c.polynomial(gx, &c.x))
+	gfpNeg(&c.y, y)
+	c.y.Select(y, &c.y, 1^sgn0(u)^sgn0(y))
+	c.z.Set(one)
+	c.t.Set(one)
+	return c
+}
@@ -0,0 +1,66 @@
+package bn256
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestHashToG1(t *testing.T) {
+	tests := []struct {
+		msg    string
+		dst    string
+		encode func(msg, dst []byte) (*G1, error)
+		want   string
+	}{
+		{"", "QUUX-V01-CS02-with-SM9G1_XMD:SM3_SVDW_RO_", HashToG1, "193d6d73e05304e0d3c9a6b7575a48b6a3ef90eb6d9cf0d7fd7c7022f0b6aedd6c4b0bd08c92217a76eee7a5dd791ad1ed0f57ca0d8d85d0f52d4c92cfdf407e"},
+		{"abc", "QUUX-V01-CS02-with-SM9G1_XMD:SM3_SVDW_RO_", HashToG1, "8605af5a751276e5faca5f6c70aebdb2da866803216d353c1fc107da361d46df85574731a065f19c18c83b77fe77727650966831aa69654dd7f1bef725446659"},
+		{"", "QUUX-V01-CS02-with-SM9G1_XMD:SM3_SVDW_NU_", EncodeToG1, "241383477ee546d86b14445ab4381bd7d8748193ce47d235096df337362d92bf94ecc6d916ad0522724d8c0f2c12323ee5f4242095bd9ee2baf38dc44b155621"},
+		{"abc", "QUUX-V01-CS02-with-SM9G1_XMD:SM3_SVDW_NU_", EncodeToG1, "311ee8e52b39919e85a1f86ebb69b40cfc558926912df8263fd3dd7ebe500da14cc45d619ab019b2f83f0e6e2be18c886fd386a6367a9ac0da9d757655c88294"},
+	}
+	for _, test := range tests {
+		e, err := test.encode([]byte(test.msg), []byte(test.dst))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !e.p.IsOnCurve() {
+			t.Errorf("%q: point is not on the curve", test.msg)
+		}
+		if got := hex.EncodeToString(e.Marshal()); got != test.want {
+			t.Errorf("%q: got %s, want %s", test.msg, got, test.want)
+		}
+	}
+
+	if _, err := HashToG1([]byte("abc"), make([]byte, 256)); err == nil {
+		t.Errorf("expected error for too long DST")
+	}
+}
+
+func TestSVDWMapExceptionalCases(t *testing.T) {
+	// u = 0 makes tv2 = 1 and u = ±1 makes tv1 = 1 - g(Z) = -3, the values
+	// below are computed by an independent implementation.
+	tests := []struct {
+		u    *gfP
+		want string
+	}{
+		{newGFp(0), "3cc0000000e137a5f201391aa72f97c1b5fb866e5e28fa494c7a890d4bc5c1ce7980000001c26f5d300272357e7711a94c239fc49eb13b5562748b7b6945e18c"},
+		{newGFp(1), "1239999999dd2a4c2499f78801113315b033d07ef57bdf0d0eee6a62c6a6c9833f0d34a8c681614666cb4a528e5ba8d6f4028197e2444b3debb313307af0b5ff"},
+		{newGFp(-1), "1239999999dd2a4c2499f78801113315b033d07ef57bdf0d0eee6a62c6a6c9837732cb573c2245ab6f3860fd67331e6e2df011b33836a39df9bc87f768608f7e"},
+		{newGFp(5), "2161e6a749fd067fc09637754c239161b7f573e3844e8aeda834e782da9b93117b9ceeba8fd717509918c6c88204e40324da3c53b1a8d28721882c1b748b8e37"},
+	}
+	for i, test := range tests {
+		e := &G1{svdwMap(test.u)}
+		if got := hex.EncodeToString(e.Marshal()); got != test.want {
+			t.Errorf("#%d: got %s, want %s", i, got, test.want)
+		}
+	}
+}
+
+func BenchmarkHashToG1(b *testing.B) {
+	msg := []byte("abc")
+	dst := []byte("QUUX-V01-CS02-with-SM9G1_XMD:SM3_SVDW_RO_")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		HashToG1(msg, dst)
+	}
+}
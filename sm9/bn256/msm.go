@@ -0,0 +1,179 @@
+package bn256
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// msmScalars reduces the scalars modulo Order into little-endian 64-bit limbs.
+func msmScalars(scalars []*big.Int) ([][4]uint64, error) {
+	out := make([][4]uint64, len(scalars))
+	var buf [32]byte
+	for i, s := range scalars {
+		if s == nil {
+			return nil, errors.New("sm9.bn256: nil scalar")
+		}
+		if s.Sign() < 0 || s.Cmp(Order) >= 0 {
+			s = new(big.Int).Mod(s, Order)
+		}
+		s.FillBytes(buf[:])
+		for j := range out[i] {
+			out[i][j] = binary.BigEndian.Uint64(buf[24-8*j:])
+		}
+	}
+	return out, nil
+}
+
+// msmWindow returns the bucket window size of a multi-scalar multiplication of
+// n points which minimizes the number of point additions: each of the
+// ⌈257/w⌉ signed windows costs n additions into the buckets and 2^w additions
+// to sum the 2^(w-1) buckets.
+func msmWindow(n int) int {
+	best, bestCost := 2, -1
+	for w := 2; w <= 16; w++ {
+		cost := (257 + w - 1) / w * (n + 1<<w)
+		if bestCost < 0 || cost < bestCost {
+			best, bestCost = w, cost
+		}
+	}
+	return best
+}
+
+// msmDigits recodes k into signed w-bit digits in [-2^(w-1), 2^(w-1)], least
+// significant first, the last window holds the final carry.
+func msmDigits(digits []int32, k *[4]uint64, w int) {
+	var carry int32
+	for i := range digits {
+		b := i * w
+		var v uint64
+		if b < 256 {
+			limb, shift := b/64, uint(b%64)
+			v = k[limb] >> shift
+			if shift+uint(w) > 64 && limb+1 < len(k) {
+				v |= k[limb+1] << (64 - shift)
+			}
+		}
+		d := int32(v&(1<<w-1)) + carry
+		carry = 0
+		if d > 1<<(w-1) {
+			d -= 1 << w
+			carry = 1
+		}
+		digits[i] = d
+	}
+}
+
+// msmRecode checks the lengths of the inputs of a multi-scalar multiplication
+// of n points and recodes the scalars, the digits of the i-th scalar are
+// digits[i*windows:(i+1)*windows].
+func msmRecode(n int, scalars []*big.Int) (w, windows int, digits []int32, err error) {
+	if n != len(scalars) {
+		return 0, 0, nil, errors.New("sm9.bn256: the numbers of points and scalars differ")
+	}
+	ks, err := msmScalars(scalars)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	w = msmWindow(n)
+	windows = (257 + w - 1) / w
+	digits = make([]int32, n*windows)
+	for i := range ks {
+		msmDigits(digits[i*windows:(i+1)*windows], &ks[i], w)
+	}
+	return w, windows, digits, nil
+}
+
+// msmPoint is the point type of a multi-scalar multiplication, *curvePoint or
+// *twistPoint.
+type msmPoint[T any] interface {
+	*T
+	SetInfinity()
+	Neg(a *T)
+	Add(a, b *T)
+	Double(a *T)
+}
+
+// multiScalarMul returns the sum of [scalars[i]]points[i] with the bucket
+// method of Pippenger, the points must not be nil.
+func multiScalarMul[T any, P msmPoint[T]](points []*T, scalars []*big.Int) (*T, error) {
+	w, windows, digits, err := msmRecode(len(points), scalars)
+	if err != nil {
+		return nil, err
+	}
+	negs := make([]T, len(points))
+	for i, p := range points {
+		P(&negs[i]).Neg(p)
+	}
+
+	buckets := make([]T, 1<<(w-1))
+	sum, windowSum, acc := P(new(T)), P(new(T)), P(new(T))
+	acc.SetInfinity()
+	for j := windows - 1; j >= 0; j-- {
+		for k := 0; k < w; k++ {
+			acc.Double(acc)
+		}
+		for i := range buckets {
+			P(&buckets[i]).SetInfinity()
+		}
+		for i, p := range points {
+			switch d := digits[i*windows+j]; {
+			case d > 0:
+				P(&buckets[d-1]).Add(&buckets[d-1], p)
+			case d < 0:
+				P(&buckets[-d-1]).Add(&buckets[-d-1], &negs[i])
+			}
+		}
+		// windowSum = Σ d × buckets[d-1], as the sum of the running sums
+		// from the top bucket down.
+		sum.SetInfinity()
+		windowSum.SetInfinity()
+		for i := len(buckets) - 1; i >= 0; i-- {
+			sum.Add(sum, &buckets[i])
+			windowSum.Add(windowSum, sum)
+		}
+		acc.Add(acc, windowSum)
+	}
+	return acc, nil
+}
+
+// MultiScalarMul returns the sum of [scalars[i]]points[i] with the bucket
+// method of Pippenger, which is much faster than a scalar multiplication per
+// point for more than a few points, e.g. in batch verifications. Scalars are
+// reduced modulo Order.
+//
+// It is not constant time and must only be used with public scalars.
+func MultiScalarMul(points []*G1, scalars []*big.Int) (*G1, error) {
+	ps := make([]*curvePoint, len(points))
+	for i, p := range points {
+		if p == nil || p.p == nil {
+			return nil, errors.New("sm9.bn256: nil point")
+		}
+		ps[i] = p.p
+	}
+	acc, err := multiScalarMul(ps, scalars)
+	if err != nil {
+		return nil, err
+	}
+	return &G1{p: acc}, nil
+}
+
+// MultiScalarMulG2 returns the sum of [scalars[i]]points[i] like
+// MultiScalarMul, e.g. to combine the G2 operations of aggregated
+// verifications.
+//
+// It is not constant time and must only be used with public scalars.
+func MultiScalarMulG2(points []*G2, scalars []*big.Int) (*G2, error) {
+	ps := make([]*twistPoint, len(points))
+	for i, p := range points {
+		if p == nil || p.p == nil {
+			return nil, errors.New("sm9.bn256: nil point")
+		}
+		ps[i] = p.p
+	}
+	acc, err := multiScalarMul(ps, scalars)
+	if err != nil {
+		return nil, err
+	}
+	return &G2{p: acc}, nil
+}
@@ -0,0 +1,111 @@
+package bn256
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func randomMSMInput(t testing.TB, n int) ([]*G1, []*big.Int) {
+	points := make([]*G1, n)
+	scalars := make([]*big.Int, n)
+	for i := range points {
+		k, p, err := RandomG1(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		points[i] = p
+		scalars[i] = k
+	}
+	return points, scalars
+}
+
+func TestMultiScalarMul(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 7, 64, 300} {
+		points, scalars := randomMSMInput(t, n)
+		if n > 2 {
+			scalars[0] = big.NewInt(0)
+			scalars[1] = new(big.Int).Neg(scalars[1])
+			scalars[2] = new(big.Int).Add(scalars[2], Order)
+		}
+		got, err := MultiScalarMul(points, scalars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &G1{p: NewCurvePoint()}
+		for i, p := range points {
+			want.Add(want, new(G1).Set(p).scalarMultBig(scalars[i]))
+		}
+		if !bytes.Equal(got.Marshal(), want.Marshal()) {
+			t.Errorf("n = %d: MultiScalarMul mismatch", n)
+		}
+	}
+
+	if _, err := MultiScalarMul(make([]*G1, 2), make([]*big.Int, 1)); err == nil {
+		t.Error("expected error for length mismatch")
+	}
+	if _, err := MultiScalarMul([]*G1{nil}, []*big.Int{big.NewInt(1)}); err == nil {
+		t.Error("expected error for nil point")
+	}
+}
+
+func (e *G1) scalarMultBig(k *big.Int) *G1 {
+	e.p.Mul(e.p, k)
+	return e
+}
+
+func TestMultiScalarMulG2(t *testing.T) {
+	for _, n := range []int{0, 1, 5, 40} {
+		points := make([]*G2, n)
+		scalars := make([]*big.Int, n)
+		want := &G2{p: NewTwistPoint()}
+		for i := range points {
+			k, p, err := RandomG2(rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i == 1 {
+				k.Neg(k)
+			}
+			points[i], scalars[i] = p, k
+			e := new(G2).Set(p)
+			e.p.Mul(e.p, k)
+			want.Add(want, e)
+		}
+		got, err := MultiScalarMulG2(points, scalars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Marshal(), want.Marshal()) {
+			t.Errorf("n = %d: MultiScalarMulG2 mismatch", n)
+		}
+	}
+	if _, err := MultiScalarMulG2([]*G2{new(G2)}, []*big.Int{big.NewInt(1)}); err == nil {
+		t.Error("expected error for nil point")
+	}
+}
+
+func BenchmarkMultiScalarMul(b *testing.B) {
+	points, scalars := randomMSMInput(b, 256)
+	b.Run("Pippenger", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := MultiScalarMul(points, scalars); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ScalarMult", func(b *testing.B) {
+		bytes := make([][]byte, len(scalars))
+		for i, k := range scalars {
+			bytes[i] = k.FillBytes(make([]byte, 32))
+		}
+		for i := 0; i < b.N; i++ {
+			sum, t := &G1{p: NewCurvePoint()}, new(G1)
+			for j, p := range points {
+				t.ScalarMult(p, bytes[j])
+				sum.Add(sum, t)
+			}
+		}
+	})
+}
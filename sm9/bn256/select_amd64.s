@@ -870,3 +870,134 @@ move_avx2:
 
 	VZEROUPPER
 	RET
+
+/* ---------------------------------------*/
+// func curvePointGather(res *curvePoint, table []*curvePoint, n int)
+// The entries are masked with (i == n) and ORed into accumulators, which stay
+// in registers, entry 0 is ·curvePointInfinity.
+TEXT ·curvePointGather(SB),NOSPLIT,$0
+	MOVQ res+0(FP), res_ptr
+	MOVQ table_base+8(FP), x_ptr
+	MOVQ table_len+16(FP), CX
+	LEAQ ·curvePointInfinity(SB), AX
+	MOVQ $1, DX
+
+	CMPB ·supportAVX2+0(SB), $0x01
+	JEQ  gather_avx2
+
+	MOVQ n+32(FP), X12
+	PSHUFD $0, X12, X12
+	MOVQ DX, X14
+	PSHUFD $0, X14, X14
+	PXOR X13, X13
+	MOVOU X13, X15
+	PCMPEQL X12, X15
+
+	MOVOU (16*0)(AX), X0
+	MOVOU (16*1)(AX), X1
+	MOVOU (16*2)(AX), X2
+	MOVOU (16*3)(AX), X3
+	MOVOU (16*4)(AX), X4
+	MOVOU (16*5)(AX), X5
+	MOVOU (16*6)(AX), X6
+	MOVOU (16*7)(AX), X7
+	PAND X15, X0
+	PAND X15, X1
+	PAND X15, X2
+	PAND X15, X3
+	PAND X15, X4
+	PAND X15, X5
+	PAND X15, X6
+	PAND X15, X7
+
+	TESTQ CX, CX
+	JEQ   gather_sse_done
+
+gather_sse_loop:
+	PADDL X14, X13
+	MOVOU X13, X15
+	PCMPEQL X12, X15
+	MOVQ (x_ptr), BX
+
+	MOVOU (16*0)(BX), X8
+	MOVOU (16*1)(BX), X9
+	MOVOU (16*2)(BX), X10
+	MOVOU (16*3)(BX), X11
+	PAND X15, X8
+	PAND X15, X9
+	PAND X15, X10
+	PAND X15, X11
+	POR X8, X0
+	POR X9, X1
+	POR X10, X2
+	POR X11, X3
+
+	MOVOU (16*4)(BX), X8
+	MOVOU (16*5)(BX), X9
+	MOVOU (16*6)(BX), X10
+	MOVOU (16*7)(BX), X11
+	PAND X15, X8
+	PAND X15, X9
+	PAND X15, X10
+	PAND X15, X11
+	POR X8, X4
+	POR X9, X5
+	POR X10, X6
+	POR X11, X7
+
+	ADDQ $8, x_ptr
+	DECQ CX
+	JNE  gather_sse_loop
+
+gather_sse_done:
+	MOVOU X0, (16*0)(res_ptr)
+	MOVOU X1, (16*1)(res_ptr)
+	MOVOU X2, (16*2)(res_ptr)
+	MOVOU X3, (16*3)(res_ptr)
+	MOVOU X4, (16*4)(res_ptr)
+	MOVOU X5, (16*5)(res_ptr)
+	MOVOU X6, (16*6)(res_ptr)
+	MOVOU X7, (16*7)(res_ptr)
+	RET
+
+gather_avx2:
+	VPBROADCASTD n+32(FP), Y12
+	VMOVQ DX, X14
+	VPBROADCASTD X14, Y14
+	VPXOR Y13, Y13, Y13
+	VPCMPEQD Y12, Y13, Y15
+
+	VPAND (32*0)(AX), Y15, Y0
+	VPAND (32*1)(AX), Y15, Y1
+	VPAND (32*2)(AX), Y15, Y2
+	VPAND (32*3)(AX), Y15, Y3
+
+	TESTQ CX, CX
+	JEQ   gather_avx2_done
+
+gather_avx2_loop:
+	VPADDD Y14, Y13, Y13
+	VPCMPEQD Y12, Y13, Y15
+	MOVQ (x_ptr), BX
+
+	VPAND (32*0)(BX), Y15, Y8
+	VPAND (32*1)(BX), Y15, Y9
+	VPAND (32*2)(BX), Y15, Y10
+	VPAND (32*3)(BX), Y15, Y11
+	VPOR Y8, Y0, Y0
+	VPOR Y9, Y1, Y1
+	VPOR Y10, Y2, Y2
+	VPOR Y11, Y3, Y3
+
+	ADDQ $8, x_ptr
+	DECQ CX
+	JNE  gather_avx2_loop
+
+gather_avx2_done:
+	VMOVDQU Y0, (32*0)(res_ptr)
+	VMOVDQU Y1, (32*1)(res_ptr)
+	VMOVDQU Y2, (32*2)(res_ptr)
+	VMOVDQU Y3, (32*3)(res_ptr)
+
+	VZEROUPPER
+	RET
@@ -0,0 +1,14 @@
+//go:build amd64 && !purego
+
+package bn256
+
+// curvePointInfinity is the point at infinity of curvePoint.SetInfinity, the
+// entry 0 of the tables of curvePointGather.
+var curvePointInfinity = curvePoint{y: *one}
+
+// curvePointGather sets res to the point at infinity if n is 0, and to
+// *table[n-1] otherwise. It reads every entry of the table once, keeping the
+// result in vector registers, so its running time doesn't depend on n.
+//
+//go:noescape
+func curvePointGather(res *curvePoint, table []*curvePoint, n int)
@@ -0,0 +1,14 @@
+//go:build amd64 && !purego
+
+package bn256
+
+import "testing"
+
+func TestCurvePointGatherNoAVX2(t *testing.T) {
+	if !supportAVX2 {
+		t.Skip("the SSE path is already tested")
+	}
+	supportAVX2 = false
+	defer func() { supportAVX2 = true }()
+	testCurvePointGather(t)
+}
@@ -0,0 +1,15 @@
+//go:build !amd64 || purego
+
+package bn256
+
+import "crypto/subtle"
+
+// curvePointGather sets res to the point at infinity if n is 0, and to
+// *table[n-1] otherwise, in constant time.
+func curvePointGather(res *curvePoint, table []*curvePoint, n int) {
+	res.SetInfinity()
+	for i, f := range table {
+		cond := subtle.ConstantTimeByteEq(uint8(i+1), uint8(n))
+		curvePointMovCond(res, f, res, cond)
+	}
+}
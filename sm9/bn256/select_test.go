@@ -1,6 +1,10 @@
 package bn256
 
-import "testing"
+import (
+	"crypto/subtle"
+	"math/big"
+	"testing"
+)
 
 func BenchmarkGfP12Copy(b *testing.B) {
 	x := &gfP12{
@@ -52,3 +56,54 @@ func BenchmarkGfP12Set(b *testing.B) {
 		gfp12CopyForTest(res, x)
 	}
 }
+
+// curvePointSelectForTest is the loop of conditional moves of curvePointGather.
+func curvePointSelectForTest(p *curvePoint, table []*curvePoint, n uint8) {
+	p.SetInfinity()
+	for i, f := range table {
+		cond := subtle.ConstantTimeByteEq(uint8(i+1), n)
+		curvePointMovCond(p, f, p, cond)
+	}
+}
+
+func curvePointTableForTest(n int) []*curvePoint {
+	table := make([]*curvePoint, n)
+	for i := range table {
+		table[i] = &curvePoint{}
+		table[i].Mul(curveGen, big.NewInt(int64(i+1)))
+	}
+	return table
+}
+
+func TestCurvePointGather(t *testing.T) {
+	testCurvePointGather(t)
+}
+
+func testCurvePointGather(t *testing.T) {
+	for _, size := range []int{15, 16} {
+		table := curvePointTableForTest(size)
+		for n := 0; n <= size; n++ {
+			got, want := &curvePoint{}, &curvePoint{}
+			curvePointSelect(got, table, uint8(n))
+			curvePointSelectForTest(want, table, uint8(n))
+			if *got != *want {
+				t.Errorf("table of %d entries, n = %d: got %v, want %v", size, n, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkCurvePointSelect(b *testing.B) {
+	table := curvePointTableForTest(16)
+	p := &curvePoint{}
+	b.Run("gather", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			curvePointSelect(p, table, uint8(i&15))
+		}
+	})
+	b.Run("movcond", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			curvePointSelectForTest(p, table, uint8(i&15))
+		}
+	})
+}
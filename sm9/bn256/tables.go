@@ -0,0 +1,68 @@
+package bn256
+
+import (
+	_ "embed"
+)
+
+//go:generate go run gen_tables.go
+
+// g1GeneratorTableEmbed and g2GeneratorTableEmbed are the precomputed
+// fixed-base tables of ScalarBaseMult: the multiples [1..15]×[2^(4×i)]G of
+// the generators, i in [0, 63], as the little-endian Montgomery domain words
+// of the x, y, z, t coordinates of affine curvePoint and twistPoint values
+// (x then y for a gfP2), see gen_tables.go. They are copied into typed tables
+// on first use.
+var (
+	//go:embed g1_table.bin
+	g1GeneratorTableEmbed string
+	//go:embed g2_table.bin
+	g2GeneratorTableEmbed string
+)
+
+const (
+	gfPEncodedLen        = 4 * 8
+	curvePointEncodedLen = 4 * gfPEncodedLen
+	twistPointEncodedLen = 8 * gfPEncodedLen
+)
+
+// decodeG1Table decodes the embedded G1 table data.
+func decodeG1Table(data string) *[32 * 2 * 15]curvePoint {
+	points := new([32 * 2 * 15]curvePoint)
+	if len(data) != len(points)*curvePointEncodedLen {
+		panic("sm9: internal error: invalid embedded table size")
+	}
+	for i := range points {
+		p := &points[i]
+		for _, e := range []*gfP{&p.x, &p.y, &p.z, &p.t} {
+			data = decodeGFp(e, data)
+		}
+	}
+	return points
+}
+
+// decodeG2Table decodes the embedded G2 table data.
+func decodeG2Table(data string) *[32 * 2 * 15]twistPoint {
+	points := new([32 * 2 * 15]twistPoint)
+	if len(data) != len(points)*twistPointEncodedLen {
+		panic("sm9: internal error: invalid embedded table size")
+	}
+	for i := range points {
+		p := &points[i]
+		for _, e := range []*gfP2{&p.x, &p.y, &p.z, &p.t} {
+			data = decodeGFp(&e.x, data)
+			data = decodeGFp(&e.y, data)
+		}
+	}
+	return points
+}
+
+// decodeGFp decodes the four little-endian words of e from data and returns
+// the rest of data.
+func decodeGFp(e *gfP, data string) string {
+	for i := range e {
+		w := data[i*8 : i*8+8]
+		e[i] = uint64(w[0]) | uint64(w[1])<<8 | uint64(w[2])<<16 | uint64(w[3])<<24 |
+			uint64(w[4])<<32 | uint64(w[5])<<40 | uint64(w[6])<<48 | uint64(w[7])<<56
+	}
+	return data[gfPEncodedLen:]
+}
@@ -0,0 +1,63 @@
+package bn256
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestG1GeneratorTable(t *testing.T) {
+	tables := new(G1).generatorTable()
+	base := NewCurveGenerator()
+	for i := range tables {
+		p := NewCurvePoint()
+		for j := range tables[i] {
+			p.Add(p, base)
+			want := (&G1{p}).Marshal()
+			entry := *tables[i][j]
+			if got := (&G1{&entry}).Marshal(); !bytes.Equal(got, want) {
+				t.Fatalf("incorrect table entry [%d][%d]", i, j)
+			}
+		}
+		for k := 0; k < 4; k++ {
+			base.Double(base)
+		}
+	}
+}
+
+func TestG2GeneratorTable(t *testing.T) {
+	tables := new(G2).generatorTable()
+	base := NewTwistGenerator()
+	for i := range tables {
+		p := NewTwistPoint()
+		for j := range tables[i] {
+			p.Add(p, base)
+			want := (&G2{p}).Marshal()
+			entry := *tables[i][j]
+			if got := (&G2{&entry}).Marshal(); !bytes.Equal(got, want) {
+				t.Fatalf("incorrect table entry [%d][%d]", i, j)
+			}
+		}
+		for k := 0; k < 4; k++ {
+			base.Double(base)
+		}
+	}
+}
+
+// TestTableLayout pins the encoding of the embedded tables: the first entry of
+// each table is the generator with z = t = 1, and the size matches.
+func TestTableLayout(t *testing.T) {
+	g1 := decodeG1Table(g1GeneratorTableEmbed)
+	if want := *curveGen; g1[0] != want {
+		t.Errorf("G1 table starts with %v, want the generator", g1[0])
+	}
+	g2 := decodeG2Table(g2GeneratorTableEmbed)
+	if want := *twistGen; g2[0] != want {
+		t.Errorf("G2 table starts with %v, want the generator", g2[0])
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for a truncated table")
+		}
+	}()
+	decodeG1Table(g1GeneratorTableEmbed[curvePointEncodedLen:])
+}
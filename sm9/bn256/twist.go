@@ -61,8 +61,8 @@ func NewTwistGenerator() *twistPoint {
 	return c
 }
 
-func (c *twistPoint) polynomial(x *gfP2) *gfP2 {
-	x3 := &gfP2{}
+// polynomial sets x3 to x³ + b and returns x3.
+func (c *twistPoint) polynomial(x3, x *gfP2) *gfP2 {
 	x3.Square(x).Mul(x3, x).Add(x3, twistB)
 	return x3
 }
@@ -76,7 +76,7 @@ func (c *twistPoint) IsOnCurve() bool {
 
 	y2 := &gfP2{}
 	y2.Square(&c.y)
-	x3 := c.polynomial(&c.x)
+	x3 := c.polynomial(&gfP2{}, &c.x)
 
 	return y2.Equal(x3) == 1
 }
@@ -167,19 +167,23 @@ func (c *twistPoint) Double(p *twistPoint) {
 	c.z.Set(z3)
 }
 
+// Mul sets c to [scalar]a. Scalars which are negative or longer than 256 bits
+// are reduced modulo Order first, it then runs in constant time, see ScalarMult.
 func (c *twistPoint) Mul(a *twistPoint, scalar *big.Int) {
-	sum, t := &twistPoint{}, &twistPoint{}
-
-	for i := scalar.BitLen(); i >= 0; i-- {
-		t.Double(sum)
-		if scalar.Bit(i) != 0 {
-			sum.Add(t, a)
-		} else {
-			sum.Set(t)
-		}
+	k := scalar
+	if k.Sign() < 0 || k.BitLen() > 256 {
+		k = new(big.Int).Mod(k, Order)
 	}
+	c.ScalarMult(a, k.FillBytes(make([]byte, 32)))
+}
 
-	c.Set(sum)
+// ScalarMult sets c to [scalar]a, where scalar is a big-endian integer. It uses
+// a regular signed digit recoding of the scalar, see twistPointMulBooth, so its
+// running time doesn't depend on the value of the scalar, nor on its length if
+// it is at most 32 bytes. Unlike G2.ScalarMult, a can be any point of the
+// twist.
+func (c *twistPoint) ScalarMult(a *twistPoint, scalar []byte) {
+	twistPointMulBooth(c, a, scalar)
 }
 
 // MakeAffine reverses the Projective transform.
@@ -235,7 +239,7 @@ func (c *twistPoint) Neg(a *twistPoint) {
 	c.x.Set(&a.x)
 	c.y.Neg(&a.y)
 	c.z.Set(&a.z)
-	c.t.SetZero()
+	c.t.Set(&a.t)
 }
 
 // code logic is form https://github.com/guanzhi/GmSSL/blob/develop/src/sm9_alg.c
@@ -262,6 +266,70 @@ func (c *twistPoint) NegFrobeniusP2(a *twistPoint) {
 	c.t.Square(&a.z)
 }
 
+// Psi sets c to ψ(a), the image of a under the p-power Frobenius of
+// E(GF(p^12)) conjugated by the twist isomorphism. It works directly on the
+// projective coordinates of twistPoint:
+//
+//	(x, y, z) -> (x̄ * betaToNegPPlus1Over3, ȳ * betaToNegPPlus1Over2, z̄)
+//
+// Frobenius is the same map on Jacobian coordinates (from GmSSL), its result
+// only matches after AffineFromJacobian, so it can't be used on projective
+// points without an inversion.
+func (c *twistPoint) Psi(a *twistPoint) {
+	c.x.Conjugate(&a.x)
+	c.x.MulScalar(&c.x, betaToNegPPlus1Over3)
+	c.y.Conjugate(&a.y)
+	c.y.MulScalar(&c.y, betaToNegPPlus1Over2)
+	c.z.Conjugate(&a.z)
+	c.t.Square(&c.z)
+}
+
+// Psi2 sets c to ψ²(a) on projective coordinates, the projective counterpart
+// of FrobeniusP2:
+//
+//	(x, y, z) -> (x * betaToNegP2Plus1Over3, y * betaToNegP2Plus1Over2, z)
+func (c *twistPoint) Psi2(a *twistPoint) {
+	c.x.MulScalar(&a.x, betaToNegP2Plus1Over3)
+	c.y.MulScalar(&a.y, betaToNegP2Plus1Over2)
+	c.z.Set(&a.z)
+	c.t.Square(&c.z)
+}
+
+// sixUSquareNAF is the non-adjacent form of 6u², the eigenvalue p mod Order
+// of ψ on G2, least significant digit first.
+var sixUSquareNAF = []int8{0, 0, 0, -1, 0, -1, 0, 1, 0, -1, 0, -1, 0, 0, 0, 1, 0, 1, 0, 0, -1, 0, -1, 0, 1, 0, -1, 0, 1, 0, 0, 0, -1, 0, -1, 0, 1, 0, 0, -1, 0, 1, 0, -1, 0, 0, -1, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, -1, 0, -1, 0, 0, 0, -1, 0, 1, 0, 0, -1, 0, 1, 0, 0, 0, 0, 1, 0, 0, -1, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -1, 0, -1, 0, 0, 1}
+
+// inSubgroup reports whether c, a point of the twist, is in the subgroup of
+// order Order. For BN curves a point of the twist is in G2 if and only if
+// ψ(c) = [6u²]c, see "Co-factor clearing and subgroup membership testing on
+// pairing-friendly curves", https://eprint.iacr.org/2022/352, section 4. It
+// costs a 128-bit multiplication by a fixed NAF instead of the 256-bit
+// [Order]c = ∞. The sequence of operations only depends on the public
+// constant, it runs in constant time.
+func (c *twistPoint) inSubgroup() bool {
+	psi, t, neg := &twistPoint{}, &twistPoint{}, &twistPoint{}
+	psi.Psi(c)
+	neg.Neg(c)
+	t.SetInfinity()
+	for i := len(sixUSquareNAF) - 1; i >= 0; i-- {
+		t.Double(t)
+		switch sixUSquareNAF[i] {
+		case 1:
+			t.Add(t, c)
+		case -1:
+			t.Add(t, neg)
+		}
+	}
+	// compare the projective points, X1*Z2 = X2*Z1 and Y1*Z2 = Y2*Z1
+	l, r := &gfP2{}, &gfP2{}
+	l.Mul(&psi.x, &t.z)
+	r.Mul(&t.x, &psi.z)
+	eq := l.Equal(r)
+	l.Mul(&psi.y, &t.z)
+	r.Mul(&t.y, &psi.z)
+	return eq&l.Equal(r) == 1
+}
+
 // A twistPointTable holds the first 15 multiples of a point at offset -1, so [1]P
 // is at table[0], [15]P is at table[14], and [0]P is implicitly the identity
 // point.
@@ -270,7 +338,13 @@ type twistPointTable [15]*twistPoint
 // Select selects the n-th multiple of the table base point into p. It works in
 // constant time by iterating over every entry of the table. n must be in [0, 15].
 func (table *twistPointTable) Select(p *twistPoint, n uint8) {
-	if n >= 16 {
+	twistPointSelect(p, table[:], n)
+}
+
+// twistPointSelect selects the n-th multiple of the base point of a table with
+// 2^w-1 entries into p, in constant time. n must be in [0, len(table)].
+func twistPointSelect(p *twistPoint, table []*twistPoint, n uint8) {
+	if int(n) > len(table) {
 		panic("sm9: internal error: twistPointTable called with out-of-bounds value")
 	}
 	p.SetInfinity()
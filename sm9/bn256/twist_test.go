@@ -1,6 +1,8 @@
 package bn256
 
 import (
+	"crypto/rand"
+	"math/big"
 	"testing"
 )
 
@@ -25,6 +27,69 @@ func TestAddNeg(t *testing.T) {
 	}
 }
 
+// mulVarTime is the double-and-add reference of twistPoint.Mul.
+func mulVarTime(c, a *twistPoint, scalar *big.Int) {
+	sum, t := NewTwistPoint(), &twistPoint{}
+	for i := scalar.BitLen(); i >= 0; i-- {
+		t.Double(sum)
+		if scalar.Bit(i) != 0 {
+			sum.Add(t, a)
+		} else {
+			sum.Set(t)
+		}
+	}
+	c.Set(sum)
+}
+
+func TestTwistPointMul(t *testing.T) {
+	k, err := randomK(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, scalar := range []*big.Int{
+		big.NewInt(0), big.NewInt(1), big.NewInt(15), big.NewInt(16), k,
+		new(big.Int).Sub(Order, big.NewInt(1)),
+	} {
+		got, want := &twistPoint{}, &twistPoint{}
+		got.Mul(twistGen, scalar)
+		mulVarTime(want, twistGen, scalar)
+		got.MakeAffine()
+		want.MakeAffine()
+		if got.x != want.x || got.y != want.y {
+			t.Errorf("Mul(%x) mismatch", scalar)
+		}
+	}
+
+	// scalars are reduced modulo Order
+	got, want := &twistPoint{}, &twistPoint{}
+	got.Mul(twistGen, new(big.Int).Add(new(big.Int).Lsh(Order, 10), k))
+	want.Mul(twistGen, k)
+	got.MakeAffine()
+	want.MakeAffine()
+	if got.x != want.x || got.y != want.y {
+		t.Error("Mul of long scalar mismatch")
+	}
+	got.Mul(twistGen, new(big.Int).Neg(k))
+	want.Mul(twistGen, k)
+	want.Neg(want)
+	got.MakeAffine()
+	want.MakeAffine()
+	if got.x != want.x || got.y != want.y {
+		t.Error("Mul of negative scalar mismatch")
+	}
+
+	// in place
+	p := &twistPoint{}
+	p.Set(twistGen)
+	p.Mul(p, k)
+	want.Mul(twistGen, k)
+	p.MakeAffine()
+	want.MakeAffine()
+	if p.x != want.x || p.y != want.y {
+		t.Error("in place Mul mismatch")
+	}
+}
+
 func Test_TwistFrobeniusP(t *testing.T) {
 	ret1, ret2 := &twistPoint{}, &twistPoint{}
 	ret1.Frobenius(twistGen)
@@ -66,6 +131,33 @@ func Test_TwistFrobeniusP2(t *testing.T) {
 	}
 }
 
+func TestTwistPsiFrobenius(t *testing.T) {
+	_, q, err := RandomG2(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &twistPoint{}
+	a.Set(q.p)
+	a.MakeAffine()
+
+	psi, frob := &twistPoint{}, &twistPoint{}
+	psi.Psi(q.p)
+	psi.MakeAffine()
+	frob.Frobenius(a)
+	frob.AffineFromJacobian()
+	if psi.x != frob.x || psi.y != frob.y {
+		t.Errorf("Psi and Frobenius mismatch")
+	}
+
+	psi.Psi2(q.p)
+	psi.MakeAffine()
+	frob.FrobeniusP2(a)
+	frob.AffineFromJacobian()
+	if psi.x != frob.x || psi.y != frob.y {
+		t.Errorf("Psi2 and FrobeniusP2 mismatch")
+	}
+}
+
 func Test_TwistFrobeniusP2_Case2(t *testing.T) {
 	ret1, ret2 := &twistPoint{}, &twistPoint{}
 	ret1.x.Set(&twistGen.x)
@@ -111,3 +203,61 @@ func Test_TwistNegFrobeniusP2_Case2(t *testing.T) {
 		t.Errorf("not same")
 	}
 }
+
+func TestTwistPointInSubgroup(t *testing.T) {
+	// the cofactor of the twist is 2p - Order
+	cofactor := new(big.Int).Lsh(p, 1)
+	cofactor.Sub(cofactor, Order)
+	found := 0
+	for i := int64(1); i < 30; i++ {
+		c := &twistPoint{}
+		c.x.y.Set(newGFp(i))
+		y3 := c.polynomial(&gfP2{}, &c.x)
+		if _, ok := c.y.Sqrt(y3); ok != 1 {
+			continue
+		}
+		c.z.SetOne()
+		c.t.SetOne()
+		r := &twistPoint{}
+		r.ScalarMult(c, Order.Bytes())
+		if c.inSubgroup() != r.IsInfinity() {
+			t.Errorf("x = %d: inSubgroup mismatch", i)
+		}
+		if !r.IsInfinity() {
+			found++
+			if _, err := new(G2).Unmarshal((&G2{c}).Marshal()); err != errNotInSubgroup {
+				t.Errorf("x = %d: got %v, want %v", i, err, errNotInSubgroup)
+			}
+			if (&G2{c}).IsInSubgroup() {
+				t.Errorf("x = %d: IsInSubgroup accepted a point outside of G2", i)
+			}
+		}
+		mulVarTime(r, c, cofactor)
+		if !r.inSubgroup() {
+			t.Errorf("x = %d: [h]c is not in the subgroup", i)
+		}
+	}
+	if found == 0 {
+		t.Fatal("no point outside of the subgroup")
+	}
+
+	_, g, err := RandomG2(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.p.inSubgroup() || !twistGen.inSubgroup() {
+		t.Errorf("point of G2 is not in the subgroup")
+	}
+	inf := &twistPoint{}
+	inf.SetInfinity()
+	if !inf.inSubgroup() {
+		t.Errorf("infinity is not in the subgroup")
+	}
+}
+
+func BenchmarkTwistPointInSubgroup(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		twistGen.inSubgroup()
+	}
+}
@@ -0,0 +1,182 @@
+package bn256
+
+import (
+	"errors"
+	"strings"
+)
+
+// The reasons why UnmarshalStrict, ValidateG1 and ValidateG2 reject an
+// encoding. The returned errors wrap one of them, test them with [errors.Is].
+var (
+	// ErrInvalidEncoding means the length or the prefix byte of the encoding
+	// is wrong.
+	ErrInvalidEncoding = errors.New("sm9.bn256: invalid point encoding")
+	// ErrNonCanonicalEncoding means a coordinate is not reduced modulo p, or
+	// the compressed point at infinity doesn't have the prefix 0x03 of
+	// MarshalCompressed.
+	ErrNonCanonicalEncoding = errors.New("sm9.bn256: non-canonical point encoding")
+	// ErrPointNotOnCurve means the coordinates do not satisfy the curve equation.
+	ErrPointNotOnCurve = errors.New("sm9.bn256: point is not on curve")
+	// ErrPointNotInSubgroup means the point is on the curve but not in the
+	// subgroup of order Order.
+	ErrPointNotInSubgroup = errors.New("sm9.bn256: point is not in the subgroup")
+	// ErrPointAtInfinity means the encoding is the point at infinity.
+	ErrPointAtInfinity = errors.New("sm9.bn256: point is the point at infinity")
+)
+
+// PointError is returned by UnmarshalStrict, ValidateG1 and ValidateG2, Group
+// is "G1" or "G2" and Kind is one of the errors above.
+type PointError struct {
+	Group string
+	Kind  error
+}
+
+func (e *PointError) Error() string {
+	return "sm9." + e.Group + ": " + strings.TrimPrefix(e.Kind.Error(), "sm9.bn256: ")
+}
+
+func (e *PointError) Unwrap() error {
+	return e.Kind
+}
+
+// checkCanonical returns an error if one of the 32 bytes big endian values in
+// in is not less than p.
+func checkCanonical(in []byte) error {
+	const numBytes = 256 / 8
+	t := &gfP{}
+	for i := 0; i < len(in); i += numBytes {
+		if err := t.Unmarshal(in[i : i+numBytes]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isZeroBytes(in []byte) bool {
+	for _, b := range in {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// parseEncoding splits data into the coordinates and reports whether it is a
+// compressed encoding. coordLen is the length of a single coordinate.
+func parseEncoding(data []byte, coordLen int) ([]byte, bool, bool) {
+	switch {
+	case len(data) == 2*coordLen:
+		return data, false, true
+	case len(data) == 2*coordLen+1 && data[0] == 4:
+		return data[1:], false, true
+	case len(data) == coordLen+1 && (data[0] == 2 || data[0] == 3):
+		return data[1:], true, true
+	}
+	return nil, false, false
+}
+
+// parseStrict splits data like parseEncoding and checks that the coordinates
+// are reduced modulo p and that the compressed point at infinity is encoded as
+// by MarshalCompressed, 0x03 and zero coordinates.
+func parseStrict(group string, data []byte, coordLen int) ([]byte, bool, error) {
+	coords, compressed, ok := parseEncoding(data, coordLen)
+	if !ok {
+		return nil, false, &PointError{group, ErrInvalidEncoding}
+	}
+	if checkCanonical(coords) != nil {
+		return nil, false, &PointError{group, ErrNonCanonicalEncoding}
+	}
+	if compressed && data[0] != 3 && isZeroBytes(coords) {
+		return nil, false, &PointError{group, ErrNonCanonicalEncoding}
+	}
+	return coords, compressed, nil
+}
+
+// UnmarshalStrict sets e to the point of data, which must be exactly an
+// output of Marshal, MarshalUncompressed or MarshalCompressed, so that every
+// point has a single encoding of each form. Unlike Unmarshal, coordinates
+// which are not less than p are rejected instead of being reduced, and unlike
+// UnmarshalCompressed, the point at infinity must have the prefix 0x03.
+// Trailing data is rejected. The returned error is a *PointError, e is
+// unchanged on error.
+func (e *G1) UnmarshalStrict(data []byte) error {
+	const numBytes = 256 / 8
+	coords, compressed, err := parseStrict("G1", data, numBytes)
+	if err != nil {
+		return err
+	}
+	t := new(G1)
+	if compressed {
+		_, err = t.UnmarshalCompressed(data)
+	} else {
+		_, err = t.Unmarshal(coords)
+	}
+	if err != nil {
+		return &PointError{"G1", ErrPointNotOnCurve}
+	}
+	e.Set(t)
+	return nil
+}
+
+// UnmarshalStrict sets e to the point of data, which must be exactly an
+// output of Marshal, MarshalUncompressed or MarshalCompressed, see
+// G1.UnmarshalStrict. The point must also be in G2. The returned error is a
+// *PointError, e is unchanged on error.
+func (e *G2) UnmarshalStrict(data []byte) error {
+	const numBytes = 2 * 256 / 8
+	coords, compressed, err := parseStrict("G2", data, numBytes)
+	if err != nil {
+		return err
+	}
+	t := new(G2)
+	// Unmarshal and UnmarshalCompressed check the subgroup.
+	if compressed {
+		_, err = t.UnmarshalCompressed(data)
+	} else {
+		_, err = t.Unmarshal(coords)
+	}
+	if err == errNotInSubgroup {
+		return &PointError{"G2", ErrPointNotInSubgroup}
+	}
+	if err != nil {
+		return &PointError{"G2", ErrPointNotOnCurve}
+	}
+	e.Set(t)
+	return nil
+}
+
+// ValidateG1 parses an untrusted G1 point with UnmarshalStrict and checks that
+// it is not the point at infinity. G1 has cofactor 1, so every point on the
+// curve is in the subgroup.
+//
+// The accepted encodings are the outputs of Marshal, MarshalUncompressed and
+// MarshalCompressed, without trailing data. The returned error is a
+// *PointError.
+func ValidateG1(data []byte) (*G1, error) {
+	e := new(G1)
+	if err := e.UnmarshalStrict(data); err != nil {
+		return nil, err
+	}
+	if e.IsInfinity() {
+		return nil, &PointError{"G1", ErrPointAtInfinity}
+	}
+	return e, nil
+}
+
+// ValidateG2 parses an untrusted G2 point with UnmarshalStrict, which checks
+// that it is in the subgroup of order Order, and checks that it is not the
+// point at infinity.
+//
+// The accepted encodings are the outputs of Marshal, MarshalUncompressed and
+// MarshalCompressed, without trailing data. The returned error is a
+// *PointError.
+func ValidateG2(data []byte) (*G2, error) {
+	e := new(G2)
+	if err := e.UnmarshalStrict(data); err != nil {
+		return nil, err
+	}
+	if e.IsInfinity() {
+		return nil, &PointError{"G2", ErrPointAtInfinity}
+	}
+	return e, nil
+}
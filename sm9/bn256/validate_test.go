@@ -0,0 +1,229 @@
+package bn256
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestValidateG1(t *testing.T) {
+	_, e, err := RandomG1(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range [][]byte{e.Marshal(), e.MarshalUncompressed(), e.MarshalCompressed()} {
+		g, err := ValidateG1(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !g.Equal(e) {
+			t.Errorf("got %v, expected %v", g, e)
+		}
+	}
+
+	uncompressed := e.MarshalUncompressed()
+	notOnCurve := append([]byte{}, uncompressed...)
+	notOnCurve[64] ^= 1
+	nonCanonical := append([]byte{}, uncompressed...)
+	for i := 1; i < 33; i++ {
+		nonCanonical[i] = 0xff
+	}
+	badPrefix := append([]byte{}, uncompressed...)
+	badPrefix[0] = 5
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrInvalidEncoding},
+		{"trailing data", append(e.Marshal(), 0), ErrInvalidEncoding},
+		{"invalid prefix", badPrefix, ErrInvalidEncoding},
+		{"non-canonical", nonCanonical, ErrNonCanonicalEncoding},
+		{"not on curve", notOnCurve, ErrPointNotOnCurve},
+		{"infinity", make([]byte, 64), ErrPointAtInfinity},
+	}
+	for _, tt := range tests {
+		_, err := ValidateG1(tt.data)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, expected %v", tt.name, err, tt.want)
+		}
+		var pe *PointError
+		if !errors.As(err, &pe) || pe.Group != "G1" {
+			t.Errorf("%s: unexpected error type %T", tt.name, err)
+		}
+	}
+}
+
+// twistPointNotInG2 returns a point on the twist curve which is not in G2.
+func twistPointNotInG2(t *testing.T) *G2 {
+	for i := int64(1); i < 100; i++ {
+		x := &gfP2{}
+		x.y.Set(newGFp(i))
+		y3 := (&twistPoint{}).polynomial(&gfP2{}, x)
+		y, ok := (&gfP2{}).Sqrt(y3)
+		if ok != 1 {
+			continue
+		}
+		e := &G2{&twistPoint{}}
+		e.p.x.Set(x)
+		e.p.y.Set(y)
+		e.p.z.SetOne()
+		e.p.t.SetOne()
+		if !e.p.IsOnCurve() {
+			t.Fatal("point is not on the twist curve")
+		}
+		r := &twistPoint{}
+		r.ScalarMult(e.p, Order.Bytes())
+		if !r.IsInfinity() {
+			return e
+		}
+	}
+	t.Fatal("no point found")
+	return nil
+}
+
+func TestValidateG2(t *testing.T) {
+	_, e, err := RandomG2(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range [][]byte{e.Marshal(), e.MarshalUncompressed(), e.MarshalCompressed()} {
+		g, err := ValidateG2(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !g.Equal(e) {
+			t.Errorf("got %v, expected %v", g, e)
+		}
+	}
+
+	uncompressed := e.MarshalUncompressed()
+	notOnCurve := append([]byte{}, uncompressed...)
+	notOnCurve[128] ^= 1
+	nonCanonical := append([]byte{}, uncompressed...)
+	for i := 33; i < 65; i++ {
+		nonCanonical[i] = 0xff
+	}
+	notSquare := make([]byte, 65)
+	notSquare[0] = 2
+	notSquare[64] = 3
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrInvalidEncoding},
+		{"short", uncompressed[:100], ErrInvalidEncoding},
+		{"non-canonical", nonCanonical, ErrNonCanonicalEncoding},
+		{"not on curve", notOnCurve, ErrPointNotOnCurve},
+		{"compressed not on curve", notSquare, ErrPointNotOnCurve},
+		{"not in subgroup", twistPointNotInG2(t).MarshalUncompressed(), ErrPointNotInSubgroup},
+		{"compressed not in subgroup", twistPointNotInG2(t).MarshalCompressed(), ErrPointNotInSubgroup},
+		{"infinity", make([]byte, 128), ErrPointAtInfinity},
+	}
+	for _, tt := range tests {
+		_, err := ValidateG2(tt.data)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, expected %v", tt.name, err, tt.want)
+		}
+		var pe *PointError
+		if !errors.As(err, &pe) || pe.Group != "G2" {
+			t.Errorf("%s: unexpected error type %T", tt.name, err)
+		}
+	}
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	_, g1, err := RandomG1(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, g2, err := RandomG2(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inf1, inf2 := new(G1), new(G2)
+	for _, e := range []*G1{g1, inf1} {
+		for _, data := range [][]byte{e.Marshal(), e.MarshalUncompressed(), e.MarshalCompressed()} {
+			got := new(G1)
+			if err := got.UnmarshalStrict(data); err != nil {
+				t.Fatalf("G1 %x: %v", data, err)
+			}
+			if !bytes.Equal(got.Marshal(), e.Marshal()) {
+				t.Errorf("got %v, expected %v", got, e)
+			}
+		}
+	}
+	for _, e := range []*G2{g2, inf2} {
+		for _, data := range [][]byte{e.Marshal(), e.MarshalUncompressed(), e.MarshalCompressed()} {
+			got := new(G2)
+			if err := got.UnmarshalStrict(data); err != nil {
+				t.Fatalf("G2 %x: %v", data, err)
+			}
+			if !bytes.Equal(got.Marshal(), e.Marshal()) {
+				t.Errorf("got %v, expected %v", got, e)
+			}
+		}
+	}
+
+	// x + p, for a small x, is reduced by Unmarshal but not by UnmarshalStrict.
+	small := new(G1)
+	for i := byte(1); ; i++ {
+		enc := make([]byte, 33)
+		enc[0], enc[32] = 2, i
+		if _, err := small.UnmarshalCompressed(enc); err == nil {
+			break
+		}
+	}
+	unreduced := small.Marshal()
+	x := new(big.Int).SetBytes(unreduced[:32])
+	x.Add(x, p).FillBytes(unreduced[:32])
+	reduced := new(G1)
+	if _, err := reduced.Unmarshal(unreduced); err != nil || !bytes.Equal(reduced.Marshal(), small.Marshal()) {
+		t.Fatalf("Unmarshal of unreduced x: %v", err)
+	}
+	// the compressed point at infinity with the prefix 0x02
+	inf1Compressed := inf1.MarshalCompressed()
+	inf1Compressed[0] = 2
+	if _, err := new(G1).UnmarshalCompressed(inf1Compressed); err != nil {
+		t.Fatal(err)
+	}
+	inf2Compressed := inf2.MarshalCompressed()
+	inf2Compressed[0] = 2
+
+	tests := []struct {
+		name  string
+		group string
+		data  []byte
+		want  error
+	}{
+		{"trailing data", "G1", append(g1.Marshal(), 0), ErrInvalidEncoding},
+		{"unreduced", "G1", unreduced, ErrNonCanonicalEncoding},
+		{"infinity with prefix 2", "G1", inf1Compressed, ErrNonCanonicalEncoding},
+		{"trailing data", "G2", append(g2.MarshalCompressed(), 0), ErrInvalidEncoding},
+		{"infinity with prefix 2", "G2", inf2Compressed, ErrNonCanonicalEncoding},
+		{"not in subgroup", "G2", twistPointNotInG2(t).Marshal(), ErrPointNotInSubgroup},
+	}
+	for _, tt := range tests {
+		var err error
+		if tt.group == "G1" {
+			e := new(G1).Set(g1)
+			if err = e.UnmarshalStrict(tt.data); !e.Equal(g1) {
+				t.Errorf("%s: G1 changed on error", tt.name)
+			}
+		} else {
+			e := new(G2).Set(g2)
+			if err = e.UnmarshalStrict(tt.data); !e.Equal(g2) {
+				t.Errorf("%s: G2 changed on error", tt.name)
+			}
+		}
+		var pe *PointError
+		if !errors.Is(err, tt.want) || !errors.As(err, &pe) || pe.Group != tt.group {
+			t.Errorf("%s %s: got %v, expected %v", tt.group, tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package bn256
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// The window sizes of the fixed-window scalar multiplications. A w-bit window
+// takes ⌈256/w⌉ point additions per scalar multiplication with a table of
+// 2^w-1 multiples of the point.
+const (
+	MinWindow     = 4
+	MaxWindow     = 6
+	DefaultWindow = 4
+)
+
+var errInvalidWindow = errors.New("sm9: window size must be in [4, 6]")
+
+// curvePointWindowTable holds the first 2^w-1 multiples of a point at offset
+// -1, so [1]P is at table[0], and [0]P is implicitly the identity point.
+type curvePointWindowTable []*curvePoint
+
+func newCurvePointWindowTable(p *curvePoint, w int) curvePointWindowTable {
+	table := make(curvePointWindowTable, 1<<w-1)
+	for i := range table {
+		table[i] = &curvePoint{}
+	}
+	table[0].Set(p)
+	for i := 1; i < len(table); i += 2 {
+		table[i].Double(table[i/2])
+		table[i+1].Add(table[i], p)
+	}
+	return table
+}
+
+// twistPointWindowTable holds the first 2^w-1 multiples of a point at offset
+// -1, so [1]P is at table[0], and [0]P is implicitly the identity point.
+type twistPointWindowTable []*twistPoint
+
+func newTwistPointWindowTable(p *twistPoint, w int) twistPointWindowTable {
+	table := make(twistPointWindowTable, 1<<w-1)
+	for i := range table {
+		table[i] = &twistPoint{}
+	}
+	table[0].Set(p)
+	for i := 1; i < len(table); i += 2 {
+		table[i].Double(table[i/2])
+		table[i+1].Add(table[i], p)
+	}
+	return table
+}
+
+// scalarWindow returns the i-th w-bit window of the big-endian scalar, counted
+// from the least significant bit. It only branches on the public i and w.
+func scalarWindow(scalar []byte, w, i int) uint8 {
+	var v uint8
+	for j := w - 1; j >= 0; j-- {
+		v <<= 1
+		if b := i*w + j; b < 8*len(scalar) {
+			v |= (scalar[len(scalar)-1-b/8] >> (b % 8)) & 1
+		}
+	}
+	return v
+}
+
+func windowCount(scalar []byte, w int) int {
+	return (8*len(scalar) + w - 1) / w
+}
+
+// curvePointMulWindow sets c to [scalar]P in constant time, where table is the
+// w-bit window table of P.
+func curvePointMulWindow(c *curvePoint, table curvePointWindowTable, w int, scalar []byte) {
+	t := &curvePoint{}
+	c.SetInfinity()
+	for i := windowCount(scalar, w) - 1; i >= 0; i-- {
+		for j := 0; j < w; j++ {
+			c.Double(c)
+		}
+		curvePointSelect(t, table, scalarWindow(scalar, w, i))
+		c.Add(c, t)
+	}
+}
+
+// twistPointMulWindow sets c to [scalar]P in constant time, where table is the
+// w-bit window table of P.
+func twistPointMulWindow(c *twistPoint, table twistPointWindowTable, w int, scalar []byte) {
+	t := &twistPoint{}
+	c.SetInfinity()
+	for i := windowCount(scalar, w) - 1; i >= 0; i-- {
+		for j := 0; j < w; j++ {
+			c.Double(c)
+		}
+		twistPointSelect(t, table, scalarWindow(scalar, w, i))
+		c.Add(c, t)
+	}
+}
+
+var generatorWindow int32 = DefaultWindow
+
+// SetGeneratorWindow sets the window size w, in [MinWindow, MaxWindow], of the
+// fixed-base tables used by G1.ScalarBaseMult and G2.ScalarBaseMult.
+//
+// Larger windows trade memory for fewer point additions, the tables are built
+// on first use of each window size:
+//
+//	w   additions   G1 tables   G2 tables
+//	4   64          120 KiB     240 KiB
+//	5   52          202 KiB     403 KiB
+//	6   43          339 KiB     677 KiB
+//
+// The constant-time table lookup scans all the 2^w-1 entries of a table, which
+// offsets part of the saving, so benchmark on the target platform before
+// changing the default.
+func SetGeneratorWindow(w int) error {
+	if w < MinWindow || w > MaxWindow {
+		return errInvalidWindow
+	}
+	atomic.StoreInt32(&generatorWindow, int32(w))
+	return nil
+}
+
+// GeneratorWindow returns the window size of the fixed-base tables.
+func GeneratorWindow() int {
+	return int(atomic.LoadInt32(&generatorWindow))
+}
+
+var g1WindowTables [MaxWindow + 1]struct {
+	once   sync.Once
+	tables []curvePointWindowTable
+}
+
+// g1GeneratorWindowTables returns the w-bit window tables of [2^(i×w)]G for
+// all the windows i of a 256-bit scalar.
+func g1GeneratorWindowTables(w int) []curvePointWindowTable {
+	t := &g1WindowTables[w]
+	t.once.Do(func() {
+		t.tables = make([]curvePointWindowTable, windowCount(make([]byte, 32), w))
+		base := NewCurveGenerator()
+		for i := range t.tables {
+			t.tables[i] = newCurvePointWindowTable(base, w)
+			for j := 0; j < w; j++ {
+				base.Double(base)
+			}
+		}
+	})
+	return t.tables
+}
+
+var g2WindowTables [MaxWindow + 1]struct {
+	once   sync.Once
+	tables []twistPointWindowTable
+}
+
+// g2GeneratorWindowTables returns the w-bit window tables of [2^(i×w)]G for
+// all the windows i of a 256-bit scalar.
+func g2GeneratorWindowTables(w int) []twistPointWindowTable {
+	t := &g2WindowTables[w]
+	t.once.Do(func() {
+		t.tables = make([]twistPointWindowTable, windowCount(make([]byte, 32), w))
+		base := NewTwistGenerator()
+		for i := range t.tables {
+			t.tables[i] = newTwistPointWindowTable(base, w)
+			for j := 0; j < w; j++ {
+				base.Double(base)
+			}
+		}
+	})
+	return t.tables
+}
+
+// scalarBaseMultWindow sets e to [scalar]G with the w-bit window fixed-base
+// tables, the doublings between windows are precomputed.
+func (e *G1) scalarBaseMultWindow(scalar []byte, w int) {
+	tables := g1GeneratorWindowTables(w)
+	t := &curvePoint{}
+	e.p.SetInfinity()
+	for i := range tables {
+		curvePointSelect(t, tables[i], scalarWindow(scalar, w, i))
+		e.p.Add(e.p, t)
+	}
+}
+
+// scalarBaseMultWindow sets e to [scalar]G with the w-bit window fixed-base
+// tables, the doublings between windows are precomputed.
+func (e *G2) scalarBaseMultWindow(scalar []byte, w int) {
+	tables := g2GeneratorWindowTables(w)
+	t := &twistPoint{}
+	e.p.SetInfinity()
+	for i := range tables {
+		twistPointSelect(t, tables[i], scalarWindow(scalar, w, i))
+		e.p.Add(e.p, t)
+	}
+}
+
+// G1Table is a precomputed w-bit window table of a G1 point, for repeated
+// constant-time scalar multiplications of the same point, e.g. a master public
+// key. A table takes (2^w-1)×128 bytes.
+type G1Table struct {
+	w     int
+	table curvePointWindowTable
+}
+
+// NewG1Table returns the w-bit window table of a, w must be in [MinWindow, MaxWindow].
+func NewG1Table(a *G1, w int) (*G1Table, error) {
+	if w < MinWindow || w > MaxWindow {
+		return nil, errInvalidWindow
+	}
+	return &G1Table{w: w, table: newCurvePointWindowTable(a.p, w)}, nil
+}
+
+// ScalarMultTable sets e to the table point multiplied by scalar and then returns e.
+func (e *G1) ScalarMultTable(t *G1Table, scalar []byte) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	curvePointMulWindow(e.p, t.table, t.w, scalar)
+	return e
+}
+
+// G2Table is a precomputed w-bit window table of a G2 point, for repeated
+// constant-time scalar multiplications of the same point, e.g. a master public
+// key. A table takes (2^w-1)×256 bytes.
+type G2Table struct {
+	w     int
+	table twistPointWindowTable
+}
+
+// NewG2Table returns the w-bit window table of a, w must be in [MinWindow, MaxWindow].
+func NewG2Table(a *G2, w int) (*G2Table, error) {
+	if w < MinWindow || w > MaxWindow {
+		return nil, errInvalidWindow
+	}
+	return &G2Table{w: w, table: newTwistPointWindowTable(a.p, w)}, nil
+}
+
+// ScalarMultTable sets e to the table point multiplied by scalar and then returns e.
+func (e *G2) ScalarMultTable(t *G2Table, scalar []byte) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	twistPointMulWindow(e.p, t.table, t.w, scalar)
+	return e
+}
@@ -0,0 +1,132 @@
+package bn256
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"testing"
+)
+
+func TestScalarWindow(t *testing.T) {
+	scalar := []byte{0b10110011, 0b01011100}
+	for _, tc := range []struct {
+		w, i int
+		want uint8
+	}{
+		{4, 0, 0b1100}, {4, 1, 0b0101}, {4, 2, 0b0011}, {4, 3, 0b1011},
+		{5, 0, 0b11100}, {5, 1, 0b11010}, {5, 2, 0b01100}, {5, 3, 0b00001},
+		{6, 2, 0b001011}, {6, 3, 0},
+	} {
+		if got := scalarWindow(scalar, tc.w, tc.i); got != tc.want {
+			t.Errorf("scalarWindow(w=%d, i=%d) = %b, want %b", tc.w, tc.i, got, tc.want)
+		}
+	}
+}
+
+func TestGeneratorWindow(t *testing.T) {
+	defer SetGeneratorWindow(DefaultWindow)
+	if err := SetGeneratorWindow(3); err == nil {
+		t.Error("expected invalid window error")
+	}
+	if err := SetGeneratorWindow(7); err == nil {
+		t.Error("expected invalid window error")
+	}
+	for i := 0; i < 5; i++ {
+		k, err := randomK(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scalar := NormalizeScalar(k.Bytes())
+		SetGeneratorWindow(DefaultWindow)
+		want1, _ := new(G1).ScalarBaseMult(scalar)
+		want2, _ := new(G2).ScalarBaseMult(scalar)
+		for w := MinWindow + 1; w <= MaxWindow; w++ {
+			if err := SetGeneratorWindow(w); err != nil {
+				t.Fatal(err)
+			}
+			if GeneratorWindow() != w {
+				t.Fatalf("got window %d, want %d", GeneratorWindow(), w)
+			}
+			got1, _ := new(G1).ScalarBaseMult(scalar)
+			if !bytes.Equal(got1.Marshal(), want1.Marshal()) {
+				t.Errorf("w=%d: G1 ScalarBaseMult mismatch", w)
+			}
+			got2, _ := new(G2).ScalarBaseMult(scalar)
+			if !bytes.Equal(got2.Marshal(), want2.Marshal()) {
+				t.Errorf("w=%d: G2 ScalarBaseMult mismatch", w)
+			}
+		}
+	}
+}
+
+func TestScalarMultTable(t *testing.T) {
+	_, a, err := RandomG1(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, b, err := RandomG2(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewG1Table(a, 8); err == nil {
+		t.Error("expected invalid window error")
+	}
+	for w := MinWindow; w <= MaxWindow; w++ {
+		t1, err := NewG1Table(a, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t2, err := NewG2Table(b, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, scalar := range [][]byte{make([]byte, 32), {1}, {0xff, 0xff}} {
+			want1, _ := new(G1).ScalarMult(a, scalar)
+			if got := new(G1).ScalarMultTable(t1, scalar); !bytes.Equal(got.Marshal(), want1.Marshal()) {
+				t.Errorf("w=%d: G1 ScalarMultTable(%x) mismatch", w, scalar)
+			}
+			want2, _ := new(G2).ScalarMult(b, scalar)
+			if got := new(G2).ScalarMultTable(t2, scalar); !bytes.Equal(got.Marshal(), want2.Marshal()) {
+				t.Errorf("w=%d: G2 ScalarMultTable(%x) mismatch", w, scalar)
+			}
+		}
+		k, _ := randomK(rand.Reader)
+		scalar := NormalizeScalar(k.Bytes())
+		want1, _ := new(G1).ScalarMult(a, scalar)
+		if got := new(G1).ScalarMultTable(t1, scalar); !bytes.Equal(got.Marshal(), want1.Marshal()) {
+			t.Errorf("w=%d: G1 ScalarMultTable mismatch", w)
+		}
+		want2, _ := new(G2).ScalarMult(b, scalar)
+		if got := new(G2).ScalarMultTable(t2, scalar); !bytes.Equal(got.Marshal(), want2.Marshal()) {
+			t.Errorf("w=%d: G2 ScalarMultTable mismatch", w)
+		}
+	}
+}
+
+func BenchmarkG1ScalarBaseMultWindow(b *testing.B) {
+	defer SetGeneratorWindow(DefaultWindow)
+	scalar := NormalizeScalar(Order.Bytes())
+	for w := MinWindow; w <= MaxWindow; w++ {
+		SetGeneratorWindow(w)
+		new(G1).ScalarBaseMult(scalar)
+		b.Run(fmt.Sprintf("w=%d", w), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				new(G1).ScalarBaseMult(scalar)
+			}
+		})
+	}
+}
+
+func BenchmarkG2ScalarMultTable(b *testing.B) {
+	scalar := NormalizeScalar(Order.Bytes())
+	for w := MinWindow; w <= MaxWindow; w++ {
+		table, _ := NewG2Table(&G2{twistGen}, w)
+		b.Run(fmt.Sprintf("w=%d", w), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				new(G2).ScalarMultTable(table, scalar)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package sm9
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/emmansun/gmsm/sm9/bn256"
+)
+
+// DefaultVerifyCacheSize is the capacity of a VerifyCache created with a
+// non-positive size.
+const DefaultVerifyCacheSize = 1024
+
+// CacheEvent identifies what happened on a VerifyCache lookup.
+type CacheEvent int
+
+const (
+	// CacheHit means the value was found in the cache.
+	CacheHit CacheEvent = iota
+	// CacheMiss means the value was computed and added to the cache.
+	CacheMiss
+	// CacheEviction means the least recently used entry was dropped to make
+	// room for a new one.
+	CacheEviction
+)
+
+func (e CacheEvent) String() string {
+	switch e {
+	case CacheHit:
+		return "hit"
+	case CacheMiss:
+		return "miss"
+	case CacheEviction:
+		return "eviction"
+	}
+	return "unknown"
+}
+
+// CacheStats records the counters of a VerifyCache.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// VerifyCache is an optional LRU cache of the signature independent
+// intermediate values of SM9 signature verification: the user sign public keys
+// keyed by (master public key, uid, hid). The powers of e(P1, Ppub) are
+// already cached by the master public key, and the pairing e(S, P) depends on
+// the signature, caching it would only help to verify the same signature
+// again.
+//
+// It helps services which repeatedly verify signatures with the same master
+// public key and user identities. A VerifyCache is safe for concurrent use.
+type VerifyCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	entries  map[string]*list.Element
+	stats    CacheStats
+	hook     func(CacheEvent)
+}
+
+type cacheEntry struct {
+	key   string
+	point *bn256.G2
+}
+
+// NewVerifyCache creates a VerifyCache holding at most size entries. If size is
+// not positive, DefaultVerifyCacheSize is used.
+func NewVerifyCache(size int) *VerifyCache {
+	if size <= 0 {
+		size = DefaultVerifyCacheSize
+	}
+	return &VerifyCache{
+		capacity: size,
+		ll:       list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// SetHook registers fn to be called on every cache event, e.g. to feed metrics.
+// fn is called with the cache lock held, so it must be fast and must not use
+// the cache. A nil fn removes the hook.
+func (c *VerifyCache) SetHook(fn func(CacheEvent)) {
+	c.mu.Lock()
+	c.hook = fn
+	c.mu.Unlock()
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *VerifyCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Len returns the number of entries in the cache.
+func (c *VerifyCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Purge removes all entries from the cache, the counters are kept.
+func (c *VerifyCache) Purge() {
+	c.mu.Lock()
+	c.ll.Init()
+	c.entries = make(map[string]*list.Element)
+	c.mu.Unlock()
+}
+
+func (c *VerifyCache) event(e CacheEvent) {
+	switch e {
+	case CacheHit:
+		c.stats.Hits++
+	case CacheMiss:
+		c.stats.Misses++
+	case CacheEviction:
+		c.stats.Evictions++
+	}
+	if c.hook != nil {
+		c.hook(e)
+	}
+}
+
+func (c *VerifyCache) get(key string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.ll.MoveToFront(elem)
+		c.event(CacheHit)
+		return elem.Value.(*cacheEntry)
+	}
+	c.event(CacheMiss)
+	return nil
+}
+
+func (c *VerifyCache) add(entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[entry.key]; ok {
+		// computed concurrently by another caller
+		c.ll.MoveToFront(elem)
+		return
+	}
+	c.entries[entry.key] = c.ll.PushFront(entry)
+	for c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+		c.event(CacheEviction)
+	}
+}
+
+// UserPublicKey returns the user sign public key generated from pub, uid and
+// hid, computing it only if it is not cached. The result is a copy, the caller
+// can modify it freely.
+func (c *VerifyCache) UserPublicKey(pub *SignMasterPublicKey, uid []byte, hid byte) *bn256.G2 {
+	key := "u" + string(pub.MasterPublicKey.Marshal()) + string([]byte{hid}) + string(uid)
+	if entry := c.get(key); entry != nil {
+		return new(bn256.G2).Set(entry.point)
+	}
+	p := pub.GenerateUserPublicKey(uid, hid)
+	c.add(&cacheEntry{key: key, point: new(bn256.G2).Set(p)})
+	return p
+}
@@ -0,0 +1,152 @@
+package sm9
+
+import (
+	"bytes"
+	"crypto/rand"
+	"sync"
+	"testing"
+)
+
+func TestVerifyASN1WithCache(t *testing.T) {
+	masterKey, err := GenerateSignMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := []byte("Chinese IBS standard")
+	uid := []byte("emmansun")
+	hid := byte(0x01)
+	userKey, err := masterKey.GenerateUserKey(uid, hid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := userKey.Sign(rand.Reader, hashed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewVerifyCache(0)
+	var events []CacheEvent
+	cache.SetHook(func(e CacheEvent) {
+		events = append(events, e)
+	})
+	for i := 0; i < 3; i++ {
+		if !VerifyASN1WithCache(cache, masterKey.Public(), uid, hid, hashed, sig) {
+			t.Fatalf("Verify failed on round %v", i)
+		}
+	}
+	stats := cache.Stats()
+	if stats.Misses != 1 || stats.Hits != 2 || stats.Evictions != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if len(events) != 3 || events[0] != CacheMiss || events[2] != CacheHit {
+		t.Errorf("unexpected events %v", events)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("got %v entries, expected 1", cache.Len())
+	}
+
+	// a cached result must not be affected by a failed verification
+	if VerifyASN1WithCache(cache, masterKey.Public(), uid, hid, []byte("another message"), sig) {
+		t.Errorf("Verify with wrong hash successed")
+	}
+	if VerifyASN1WithCache(cache, masterKey.Public(), []byte("another uid"), hid, hashed, sig) {
+		t.Errorf("Verify with wrong uid successed")
+	}
+	if !VerifyASN1WithCache(cache, masterKey.Public(), uid, hid, hashed, sig) {
+		t.Errorf("Verify failed after cache reuse")
+	}
+	if !VerifyASN1WithCache(nil, masterKey.Public(), uid, hid, hashed, sig) {
+		t.Errorf("Verify without cache failed")
+	}
+
+	cache.Purge()
+	if cache.Len() != 0 {
+		t.Errorf("got %v entries after purge", cache.Len())
+	}
+}
+
+func TestVerifyCacheEviction(t *testing.T) {
+	masterKey, err := GenerateSignMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := masterKey.Public()
+	hid := byte(0x01)
+	uids := [][]byte{[]byte("alice"), []byte("bob"), []byte("carol")}
+	cache := NewVerifyCache(2)
+	want := pub.GenerateUserPublicKey(uids[0], hid).Marshal()
+	cache.UserPublicKey(pub, uids[0], hid)
+	cache.UserPublicKey(pub, uids[1], hid)
+	cache.UserPublicKey(pub, uids[0], hid) // uids[1] becomes the least recently used
+	cache.UserPublicKey(pub, uids[2], hid)
+	if cache.Len() != 2 {
+		t.Fatalf("got %v entries, expected 2", cache.Len())
+	}
+	stats := cache.Stats()
+	if stats.Evictions != 1 || stats.Hits != 1 || stats.Misses != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	got := cache.UserPublicKey(pub, uids[0], hid)
+	if !bytes.Equal(got.Marshal(), want) {
+		t.Errorf("cached user public key mismatch")
+	}
+	if cache.Stats().Hits != 2 {
+		t.Errorf("expected cache hit for most recently used entry")
+	}
+	// modify the returned value must not change the cached one
+	got.Add(got, got)
+	if !bytes.Equal(cache.UserPublicKey(pub, uids[0], hid).Marshal(), want) {
+		t.Errorf("cached value was modified")
+	}
+}
+
+func TestVerifyCacheConcurrent(t *testing.T) {
+	masterKey, err := GenerateSignMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := masterKey.Public()
+	uid := []byte("emmansun")
+	want := pub.GenerateUserPublicKey(uid, 0x01).Marshal()
+	cache := NewVerifyCache(4)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !bytes.Equal(cache.UserPublicKey(pub, uid, 0x01).Marshal(), want) {
+				t.Errorf("user public key mismatch")
+			}
+		}()
+	}
+	wg.Wait()
+	if cache.Len() != 1 {
+		t.Errorf("got %v entries, expected 1", cache.Len())
+	}
+}
+
+func BenchmarkVerifyASN1WithCache(b *testing.B) {
+	masterKey, err := GenerateSignMasterKey(rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	hashed := []byte("Chinese IBS standard")
+	uid := []byte("emmansun")
+	hid := byte(0x01)
+	userKey, err := masterKey.GenerateUserKey(uid, hid)
+	if err != nil {
+		b.Fatal(err)
+	}
+	sig, err := userKey.Sign(rand.Reader, hashed, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	cache := NewVerifyCache(0)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !VerifyASN1WithCache(cache, masterKey.Public(), uid, hid, hashed, sig) {
+			b.Fatal("verify failed")
+		}
+	}
+}
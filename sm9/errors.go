@@ -0,0 +1,45 @@
+package sm9
+
+import "errors"
+
+// The failure modes of this package. Errors returned by this package either
+// are one of them or wrap one of them, test them with [errors.Is] instead of
+// matching the error strings.
+var (
+	// ErrInvalidPrivateKey represents an invalid or malformed private key.
+	ErrInvalidPrivateKey = errors.New("sm9: invalid private key")
+	// ErrInvalidPublicKey represents an invalid or malformed master public key.
+	ErrInvalidPublicKey = errors.New("sm9: invalid public key")
+	// ErrPointNotOnCurve represents a point which is malformed or not on the curve.
+	ErrPointNotOnCurve = errors.New("sm9: point is not on curve")
+	// ErrInvalidSignature represents a malformed signature.
+	ErrInvalidSignature = errors.New("sm9: invalid signature")
+	// ErrInvalidCiphertext represents a malformed ciphertext.
+	ErrInvalidCiphertext = errors.New("sm9: invalid ciphertext")
+	// ErrKeyExchange represents a failure of the key exchange.
+	ErrKeyExchange = errors.New("sm9: key exchange failed")
+)
+
+// Error is an error with context returned by this package, its Kind is one of
+// the failure modes above.
+type Error struct {
+	Kind error
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func newError(kind error, msg string) error {
+	return &Error{Kind: kind, Msg: msg}
+}
+
+// pointError wraps the point decoding error err of bn256 as ErrPointNotOnCurve.
+func pointError(err error) error {
+	return newError(ErrPointNotOnCurve, err.Error())
+}
@@ -0,0 +1,33 @@
+package sm9
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{"master private key", new(SignMasterPrivateKey).UnmarshalASN1([]byte{0x30, 0x00}), ErrInvalidPrivateKey},
+		{"master public key", new(EncryptMasterPublicKey).ParseFromPEM([]byte("invalid")), ErrInvalidPublicKey},
+		{"point identity", new(SignMasterPublicKey).UnmarshalRaw([]byte{0x05}), ErrPointNotOnCurve},
+		{"malformed point", new(SignMasterPublicKey).UnmarshalRaw(make([]byte, 129)), ErrPointNotOnCurve},
+		{"ciphertext", func() error { _, err := DecryptASN1(nil, nil, make([]byte, 10)); return err }(), ErrInvalidCiphertext},
+		{"signature", func() error { _, _, err := parseSignature([]byte{0x30}); return err }(), ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		if tt.err == nil {
+			t.Fatalf("%s: expected error", tt.name)
+		}
+		if !errors.Is(tt.err, tt.kind) {
+			t.Errorf("%s: %v is not %v", tt.name, tt.err, tt.kind)
+		}
+		var e *Error
+		if !errors.As(tt.err, &e) {
+			t.Errorf("%s: %v is not an *Error", tt.name, tt.err)
+		}
+	}
+}
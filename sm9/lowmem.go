@@ -0,0 +1,41 @@
+package sm9
+
+import "sync/atomic"
+
+// lowMemory is 1 if the low-memory profile is enabled, see SetLowMemory.
+var lowMemory int32
+
+func init() {
+	if lowMemoryBuild {
+		lowMemory = 1
+	}
+}
+
+// SetLowMemory enables or disables the low-memory profile for constrained
+// devices. It is enabled by default if the module is built with the
+// "gmsm_sm9lowmem" build tag.
+//
+// By default a master public key lazily precomputes a table of about 370KB
+// of the powers of its pairing base point, which speeds up encryption, key
+// encapsulation, signing and verification. In the low-memory profile the
+// powers are computed with a window of 15 GT elements instead, about 6KB,
+// which is about three times slower. Keys whose tables were already built
+// keep using them.
+//
+// Decryption, key decapsulation and key exchange don't use the table, they
+// compute one pairing. By default a user encrypt private key precomputes the
+// Miller loop lines of its point on first use, about 27KB, in the low-memory
+// profile the pairing is computed with a stack allocated scratch instead and
+// the peak heap of a decryption is a few kilobytes.
+func SetLowMemory(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&lowMemory, v)
+}
+
+// LowMemory reports whether the low-memory profile is enabled.
+func LowMemory() bool {
+	return atomic.LoadInt32(&lowMemory) == 1
+}
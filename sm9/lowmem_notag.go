@@ -0,0 +1,5 @@
+//go:build !gmsm_sm9lowmem
+
+package sm9
+
+const lowMemoryBuild = false
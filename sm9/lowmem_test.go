@@ -0,0 +1,106 @@
+package sm9
+
+import (
+	"bytes"
+	"crypto/rand"
+	"runtime"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm3"
+)
+
+// lowMemoryDecryptBudget is the heap budget of one decryption on gateway
+// class devices.
+const lowMemoryDecryptBudget = 64 << 10
+
+func TestLowMemory(t *testing.T) {
+	defer SetLowMemory(LowMemory())
+	SetLowMemory(true)
+
+	encMaster, err := GenerateEncryptMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := []byte("emmansun")
+	encKey, err := encMaster.GenerateUserKey(uid, 0x03)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("hello low memory")
+	ciphertext, err := EncryptASN1(rand.Reader, encMaster.Public(), uid, 0x03, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encMaster.Public().hasGeneratorTable() {
+		t.Error("the table was built in the low-memory profile")
+	}
+	der, err := encKey.MarshalASN1()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	key := new(EncryptPrivateKey)
+	if err := key.UnmarshalASN1(der); err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := DecryptASN1(key, uid, ciphertext)
+	runtime.ReadMemStats(&after)
+	if err != nil || !bytes.Equal(plaintext, msg) {
+		t.Fatalf("got %q, %v", plaintext, err)
+	}
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > lowMemoryDecryptBudget {
+		t.Errorf("decryption allocated %d bytes, budget %d", alloc, lowMemoryDecryptBudget)
+	}
+	if key.prepared != nil {
+		t.Error("the Miller loop lines were built in the low-memory profile")
+	}
+
+	signMaster, err := GenerateSignMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signKey, err := signMaster.GenerateUserKey(uid, 0x01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sm3.Sum(msg)
+	sig, err := SignASN1(rand.Reader, signKey, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyASN1(signMaster.Public(), uid, 0x01, hash[:], sig) {
+		t.Error("invalid signature in the low-memory profile")
+	}
+	if VerifyASN1(signMaster.Public(), uid, 0x01, msg, sig) {
+		t.Error("signature of another hash accepted in the low-memory profile")
+	}
+	if signMaster.Public().hasGeneratorTable() {
+		t.Error("the table was built in the low-memory profile")
+	}
+
+	// both profiles compute the same powers
+	scalar := hash[:]
+	low, err := signMaster.Public().ScalarBaseMult(scalar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetLowMemory(false)
+	fast, err := signMaster.Public().ScalarBaseMult(scalar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !low.Equal(fast) {
+		t.Error("the profiles disagree")
+	}
+	for i := 0; i < 2; i++ {
+		if plaintext, err := DecryptASN1(key, uid, ciphertext); err != nil || !bytes.Equal(plaintext, msg) {
+			t.Fatalf("got %q, %v", plaintext, err)
+		}
+	}
+	if key.prepared == nil {
+		t.Error("the Miller loop lines were not built")
+	}
+}
@@ -0,0 +1,115 @@
+package sm9
+
+import (
+	goSubtle "crypto/subtle"
+	"encoding/binary"
+	"io"
+
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm9/bn256"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// Public-key encryption with keyword search (PEKS), obtained from the
+// anonymity of the SM9 encryption with the transform of Abdalla et al.,
+// "Searchable Encryption Revisited" (CRYPTO 2005).
+//
+// The receiver holds a dedicated encryption master key. The keyword w of a
+// receiver uid is treated as the SM9 identity len(uid) || uid || w with the
+// hid peksHID, so a trapdoor is the SM9 encryption private key of that
+// identity. A sender encrypts the keyword as C = [r]Q, tag = SM3(C || g^r), and
+// the server which holds the trapdoor de tests tag == SM3(C || e(C, de))
+// without learning the keyword.
+//
+// The encrypted keyword has the ASN.1 format:
+//
+//	SM9PEKSCipher ::= SEQUENCE {
+//	  c    BIT STRING,  -- uncompressed G1 point
+//	  tag  OCTET STRING
+//	}
+
+const peksHID byte = 0x04
+
+func peksIdentity(uid, keyword []byte) []byte {
+	id := make([]byte, 2, 2+len(uid)+len(keyword))
+	binary.BigEndian.PutUint16(id, uint16(len(uid)))
+	id = append(id, uid...)
+	return append(id, keyword...)
+}
+
+func peksTag(c *bn256.G1, w *bn256.GT) []byte {
+	md := sm3.New()
+	md.Write(c.MarshalUncompressed())
+	md.Write(w.Marshal())
+	return md.Sum(nil)
+}
+
+// EncryptKeyword encrypts keyword for the receiver uid whose keyword search
+// master public key is pub. The result can be attached to a message and
+// tested by a server with a trapdoor of the receiver.
+//
+// The rand parameter is used as a source of entropy to ensure that
+// encrypting the same keyword twice doesn't result in the same ciphertext.
+// Most applications should use [crypto/rand.Reader] as random.
+func EncryptKeyword(rand io.Reader, pub *EncryptMasterPublicKey, uid, keyword []byte) ([]byte, error) {
+	if len(uid) > 0xffff {
+		return nil, newError(ErrInvalidPublicKey, "sm9: uid is too long")
+	}
+	q := pub.GenerateUserPublicKey(peksIdentity(uid, keyword), peksHID)
+	r, err := randomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	rBytes := r.Bytes(orderNat)
+	c, err := new(bn256.G1).ScalarMult(q, rBytes)
+	if err != nil {
+		return nil, err
+	}
+	w, err := pub.ScalarBaseMult(rBytes)
+	if err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BitString(c.MarshalUncompressed())
+		b.AddASN1OctetString(peksTag(c, w))
+	})
+	return b.Bytes()
+}
+
+// GenerateTrapdoor generates with the keyword search master private key the
+// trapdoor of keyword for the receiver uid. The trapdoor is given to the
+// server, it allows to test encrypted keywords against keyword only and can
+// be serialized like any encryption private key.
+//
+// The master key must be dedicated to keyword search, a trapdoor is the
+// decryption key of an identity derived from the keyword.
+func GenerateTrapdoor(master *EncryptMasterPrivateKey, uid, keyword []byte) (*EncryptPrivateKey, error) {
+	if len(uid) > 0xffff {
+		return nil, newError(ErrInvalidPrivateKey, "sm9: uid is too long")
+	}
+	return master.GenerateUserKey(peksIdentity(uid, keyword), peksHID)
+}
+
+// TestKeyword reports whether the encrypted keyword ciphertext, created by
+// EncryptKeyword, matches the keyword of trapdoor.
+func TestKeyword(trapdoor *EncryptPrivateKey, ciphertext []byte) bool {
+	var (
+		cBytes, tag []byte
+		inner       cryptobyte.String
+	)
+	input := cryptobyte.String(ciphertext)
+	if !input.ReadASN1(&inner, asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1BitStringAsBytes(&cBytes) ||
+		!inner.ReadASN1Bytes(&tag, asn1.OCTET_STRING) || !inner.Empty() ||
+		len(cBytes) == 0 {
+		return false
+	}
+	c, err := unmarshalG1(cBytes)
+	if err != nil || c.IsInfinity() {
+		return false
+	}
+	w := bn256.Pair(c, trapdoor.PrivateKey)
+	return goSubtle.ConstantTimeCompare(peksTag(c, w), tag) == 1
+}
@@ -0,0 +1,84 @@
+package sm9
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestPEKS(t *testing.T) {
+	master, err := GenerateEncryptMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := []byte("bob@example.com")
+	ciphertext, err := EncryptKeyword(rand.Reader, master.Public(), uid, []byte("urgent"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	trapdoor, err := GenerateTrapdoor(master, uid, []byte("urgent"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !TestKeyword(trapdoor, ciphertext) {
+		t.Errorf("TestKeyword failed")
+	}
+
+	// the trapdoor survives serialization
+	der, err := trapdoor.MarshalASN1()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed := new(EncryptPrivateKey)
+	if err = parsed.UnmarshalASN1(der); err != nil {
+		t.Fatal(err)
+	}
+	if !TestKeyword(parsed, ciphertext) {
+		t.Errorf("TestKeyword with unmarshaled trapdoor failed")
+	}
+
+	other, err := GenerateTrapdoor(master, uid, []byte("invoice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if TestKeyword(other, ciphertext) {
+		t.Errorf("TestKeyword with other keyword succeeded")
+	}
+	// the receiver identity is part of the keyword identity
+	other, err = GenerateTrapdoor(master, []byte("bob@example.comurgent"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if TestKeyword(other, ciphertext) {
+		t.Errorf("TestKeyword with other receiver succeeded")
+	}
+	// a regular decryption key of the same master key is not a trapdoor
+	userKey, err := master.GenerateUserKey(uid, 0x03)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if TestKeyword(userKey, ciphertext) {
+		t.Errorf("TestKeyword with decryption key succeeded")
+	}
+
+	tampered := append([]byte(nil), ciphertext...)
+	tampered[len(tampered)-1] ^= 1
+	for _, c := range [][]byte{nil, tampered, ciphertext[:len(ciphertext)-1], append(ciphertext, 0)} {
+		if TestKeyword(trapdoor, c) {
+			t.Errorf("TestKeyword with invalid ciphertext succeeded")
+		}
+	}
+}
+
+func BenchmarkTestKeyword(b *testing.B) {
+	master, _ := GenerateEncryptMasterKey(rand.Reader)
+	uid := []byte("bob@example.com")
+	ciphertext, _ := EncryptKeyword(rand.Reader, master.Public(), uid, []byte("urgent"))
+	trapdoor, _ := GenerateTrapdoor(master, uid, []byte("urgent"))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !TestKeyword(trapdoor, ciphertext) {
+			b.Fatal("TestKeyword failed")
+		}
+	}
+}
@@ -0,0 +1,436 @@
+package sm9
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/emmansun/gmsm/internal/subtle"
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm4"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// Puncturable encryption on top of the SM9 key encapsulation, with the Bloom
+// filter encryption of Derler et al., "Bloom Filter Encryption and
+// Applications to Efficient Forward-Secret 0-RTT Key Exchange" (EUROCRYPT 2018).
+//
+// The receiver key of an epoch is the set of SM9 encryption private keys of
+// the slot identities
+//
+//	"SM9-PUNCTURABLE" || len(uid) || uid || epoch || slot, slot = 0..m-1
+//
+// A ciphertext carries a random tag, which selects k slots with SM3, and a
+// content key wrapped to every selected slot, any of them decrypts it.
+// Puncturing a ciphertext deletes the keys of its slots, so it can not be
+// decrypted by the receiver anymore, and the other ciphertexts can still be
+// decrypted unless all their slots have been punctured too, which happens with
+// the false positive probability of the Bloom filter. Puncturing a whole epoch
+// is just deleting its key.
+//
+// Like every SM9 key, the slot keys can be generated again by the KGC: forward
+// secrecy is only against the compromise of the receiver.
+//
+// The ciphertext has the ASN.1 format:
+//
+//	SM9PuncturableCipher ::= SEQUENCE {
+//	  header SEQUENCE {
+//	    epoch  INTEGER,
+//	    slots  INTEGER,
+//	    tag    OCTET STRING,
+//	    keys   SEQUENCE OF SEQUENCE {
+//	      c1            BIT STRING,   -- uncompressed G1 point
+//	      encryptedKey  OCTET STRING  -- content key XOR wrapped key
+//	    }
+//	  },
+//	  nonce             OCTET STRING,
+//	  encryptedContent  OCTET STRING  -- SM4-GCM, the header is the additional data
+//	}
+
+var puncturableDomain = []byte("SM9-PUNCTURABLE")
+
+const (
+	puncturableTagSize   = 16
+	maxPuncturableSlots  = 1 << 20
+	maxPuncturableHashes = 32
+)
+
+// ErrPunctured is returned when decrypting a ciphertext whose slots have all
+// been punctured.
+var ErrPunctured = errors.New("sm9: ciphertext is punctured")
+
+// PuncturableParams are the Bloom filter parameters of a puncturable key.
+// After n punctures, a ciphertext can not be decrypted anymore with the
+// probability (1 - e^(-Hashes·n/Slots))^Hashes.
+type PuncturableParams struct {
+	// Slots is the number of slot keys of an epoch.
+	Slots int
+	// Hashes is the number of slots of a ciphertext.
+	Hashes int
+}
+
+func (p PuncturableParams) valid() bool {
+	return p.Slots > 0 && p.Slots <= maxPuncturableSlots && p.Hashes > 0 && p.Hashes <= maxPuncturableHashes
+}
+
+// indices returns the slots selected by tag.
+func (p PuncturableParams) indices(epoch uint64, tag []byte) []int {
+	indices := make([]int, p.Hashes)
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], epoch)
+	for i := range indices {
+		md := sm3.New()
+		md.Write(puncturableDomain)
+		md.Write(buf[:])
+		md.Write(tag)
+		md.Write([]byte{byte(i)})
+		indices[i] = int(binary.BigEndian.Uint64(md.Sum(nil)) % uint64(p.Slots))
+	}
+	return indices
+}
+
+func puncturableIdentity(uid []byte, epoch uint64, slot int) []byte {
+	id := make([]byte, 0, len(puncturableDomain)+2+len(uid)+8+4)
+	id = append(id, puncturableDomain...)
+	id = append(id, byte(len(uid)>>8), byte(len(uid)))
+	id = append(id, uid...)
+	var buf [12]byte
+	binary.BigEndian.PutUint64(buf[:], epoch)
+	binary.BigEndian.PutUint32(buf[8:], uint32(slot))
+	return append(id, buf[:]...)
+}
+
+func puncturableAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// PuncturableKey is the puncturable decryption key of an user for an epoch.
+type PuncturableKey struct {
+	UID    []byte
+	Epoch  uint64
+	Params PuncturableParams
+	// keys are the slot keys, nil once punctured.
+	keys []*EncryptPrivateKey
+}
+
+// GeneratePuncturableKey generates with the encryption master private key the
+// puncturable key of the user uid for epoch. The hid must be the one used by
+// the senders.
+func GeneratePuncturableKey(master *EncryptMasterPrivateKey, uid []byte, hid byte, epoch uint64, params PuncturableParams) (*PuncturableKey, error) {
+	if len(uid) > 0xffff {
+		return nil, newError(ErrInvalidPrivateKey, "sm9: uid is too long")
+	}
+	if !params.valid() {
+		return nil, newError(ErrInvalidPrivateKey, "sm9: invalid puncturable key parameters")
+	}
+	key := &PuncturableKey{
+		UID:    append([]byte(nil), uid...),
+		Epoch:  epoch,
+		Params: params,
+		keys:   make([]*EncryptPrivateKey, params.Slots),
+	}
+	for i := range key.keys {
+		k, err := master.GenerateUserKey(puncturableIdentity(uid, epoch, i), hid)
+		if err != nil {
+			return nil, err
+		}
+		key.keys[i] = k
+	}
+	return key, nil
+}
+
+// PuncturableEncrypt encrypts plaintext to the user uid, hid for epoch, under
+// the encryption master public key pub. The params must be the ones of the
+// receiver key.
+//
+// The rand parameter is used as a source of entropy to ensure that
+// encrypting the same message twice doesn't result in the same ciphertext.
+// Most applications should use [crypto/rand.Reader] as random.
+func PuncturableEncrypt(rand io.Reader, pub *EncryptMasterPublicKey, uid []byte, hid byte, epoch uint64, params PuncturableParams, plaintext []byte) ([]byte, error) {
+	if len(uid) > 0xffff {
+		return nil, newError(ErrInvalidPublicKey, "sm9: uid is too long")
+	}
+	if !params.valid() {
+		return nil, newError(ErrInvalidPublicKey, "sm9: invalid puncturable key parameters")
+	}
+	tag := make([]byte, puncturableTagSize)
+	contentKey := make([]byte, sm4.BlockSize)
+	if _, err := io.ReadFull(rand, tag); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(rand, contentKey); err != nil {
+		return nil, err
+	}
+
+	var header cryptobyte.Builder
+	var wrapErr error
+	header.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1Uint64(epoch)
+		b.AddASN1Int64(int64(params.Slots))
+		b.AddASN1OctetString(tag)
+		b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			for _, slot := range params.indices(epoch, tag) {
+				key, c1, err := WrapKey(rand, pub, puncturableIdentity(uid, epoch, slot), hid, len(contentKey))
+				if err != nil {
+					wrapErr = err
+					return
+				}
+				subtle.XORBytes(key, key, contentKey)
+				b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					b.AddASN1BitString(c1.MarshalUncompressed())
+					b.AddASN1OctetString(key)
+				})
+			}
+		})
+	})
+	if wrapErr != nil {
+		return nil, wrapErr
+	}
+	headerBytes, err := header.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := puncturableAEAD(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand, nonce); err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddBytes(headerBytes)
+		b.AddASN1OctetString(nonce)
+		b.AddASN1OctetString(aead.Seal(nil, nonce, plaintext, headerBytes))
+	})
+	return b.Bytes()
+}
+
+type puncturableEntry struct {
+	c1, encryptedKey []byte
+}
+
+type puncturableCiphertext struct {
+	header  []byte
+	epoch   uint64
+	slots   int64
+	tag     []byte
+	entries []puncturableEntry
+	nonce   []byte
+	content []byte
+}
+
+func parsePuncturableCiphertext(ciphertext []byte) (*puncturableCiphertext, error) {
+	var (
+		ct                        puncturableCiphertext
+		inner, header, hdr, items cryptobyte.String
+	)
+	input := cryptobyte.String(ciphertext)
+	if !input.ReadASN1(&inner, asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1Element(&header, asn1.SEQUENCE) ||
+		!inner.ReadASN1Bytes(&ct.nonce, asn1.OCTET_STRING) ||
+		!inner.ReadASN1Bytes(&ct.content, asn1.OCTET_STRING) || !inner.Empty() {
+		return nil, newError(ErrInvalidCiphertext, "sm9: invalid puncturable ciphertext asn.1 data")
+	}
+	ct.header = header
+	if !header.ReadASN1(&hdr, asn1.SEQUENCE) ||
+		!hdr.ReadASN1Integer(&ct.epoch) ||
+		!hdr.ReadASN1Integer(&ct.slots) ||
+		!hdr.ReadASN1Bytes(&ct.tag, asn1.OCTET_STRING) ||
+		!hdr.ReadASN1(&items, asn1.SEQUENCE) || !hdr.Empty() {
+		return nil, newError(ErrInvalidCiphertext, "sm9: invalid puncturable ciphertext header")
+	}
+	for !items.Empty() {
+		var item cryptobyte.String
+		var e puncturableEntry
+		if !items.ReadASN1(&item, asn1.SEQUENCE) ||
+			!item.ReadASN1BitStringAsBytes(&e.c1) ||
+			!item.ReadASN1Bytes(&e.encryptedKey, asn1.OCTET_STRING) || !item.Empty() ||
+			len(e.c1) == 0 || len(e.encryptedKey) != sm4.BlockSize {
+			return nil, newError(ErrInvalidCiphertext, "sm9: invalid puncturable ciphertext key")
+		}
+		ct.entries = append(ct.entries, e)
+	}
+	return &ct, nil
+}
+
+// check returns the slots of the ciphertext ct, which must be for key.
+func (key *PuncturableKey) check(ct *puncturableCiphertext) ([]int, error) {
+	if ct.epoch != key.Epoch {
+		return nil, newError(ErrInvalidCiphertext, "sm9: puncturable ciphertext is for another epoch")
+	}
+	if ct.slots != int64(key.Params.Slots) || len(ct.entries) != key.Params.Hashes {
+		return nil, newError(ErrInvalidCiphertext, "sm9: puncturable ciphertext parameters mismatch")
+	}
+	return key.Params.indices(ct.epoch, ct.tag), nil
+}
+
+// Decrypt decrypts a ciphertext created by PuncturableEncrypt. It returns
+// ErrPunctured if the ciphertext, or every ciphertext sharing its slots, has
+// been punctured.
+func (key *PuncturableKey) Decrypt(ciphertext []byte) ([]byte, error) {
+	ct, err := parsePuncturableCiphertext(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	slots, err := key.check(ct)
+	if err != nil {
+		return nil, err
+	}
+	punctured := true
+	for i, slot := range slots {
+		priv := key.keys[slot]
+		if priv == nil {
+			continue
+		}
+		punctured = false
+		c1, err := unmarshalG1(ct.entries[i].c1)
+		if err != nil {
+			return nil, ErrDecryption
+		}
+		contentKey, err := UnwrapKey(priv, puncturableIdentity(key.UID, key.Epoch, slot), c1, sm4.BlockSize)
+		if err != nil {
+			return nil, err
+		}
+		subtle.XORBytes(contentKey, contentKey, ct.entries[i].encryptedKey)
+		aead, err := puncturableAEAD(contentKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(ct.nonce) != aead.NonceSize() {
+			return nil, ErrDecryption
+		}
+		// the slot keys of a valid ciphertext all give the same content key
+		plaintext, err := aead.Open(nil, ct.nonce, ct.content, ct.header)
+		if err != nil {
+			return nil, ErrDecryption
+		}
+		return plaintext, nil
+	}
+	if punctured {
+		return nil, ErrPunctured
+	}
+	return nil, ErrDecryption
+}
+
+// Puncture irreversibly removes the ability of key to decrypt ciphertext. The
+// updated key must be persisted in place of the previous one, which must be
+// securely erased.
+func (key *PuncturableKey) Puncture(ciphertext []byte) error {
+	ct, err := parsePuncturableCiphertext(ciphertext)
+	if err != nil {
+		return err
+	}
+	slots, err := key.check(ct)
+	if err != nil {
+		return err
+	}
+	for _, slot := range slots {
+		key.keys[slot] = nil
+	}
+	return nil
+}
+
+// Remaining returns the number of slot keys which are not punctured.
+func (key *PuncturableKey) Remaining() int {
+	n := 0
+	for _, k := range key.keys {
+		if k != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// MarshalASN1 encodes the puncturable key, without the punctured slot keys,
+// as
+//
+//	SM9PuncturableKey ::= SEQUENCE {
+//	  uid     OCTET STRING,
+//	  epoch   INTEGER,
+//	  slots   INTEGER,
+//	  hashes  INTEGER,
+//	  keys    SEQUENCE OF SEQUENCE {
+//	    slot  INTEGER,
+//	    key   BIT STRING  -- uncompressed G2 point
+//	  }
+//	}
+func (key *PuncturableKey) MarshalASN1() ([]byte, error) {
+	if !key.Params.valid() || len(key.keys) != key.Params.Slots {
+		return nil, newError(ErrInvalidPrivateKey, "sm9: invalid puncturable key parameters")
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1OctetString(key.UID)
+		b.AddASN1Uint64(key.Epoch)
+		b.AddASN1Int64(int64(key.Params.Slots))
+		b.AddASN1Int64(int64(key.Params.Hashes))
+		b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			for i, k := range key.keys {
+				if k == nil {
+					continue
+				}
+				b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					b.AddASN1Int64(int64(i))
+					b.AddASN1BitString(k.PrivateKey.MarshalUncompressed())
+				})
+			}
+		})
+	})
+	return b.Bytes()
+}
+
+// UnmarshalASN1 decodes a puncturable key encoded by MarshalASN1.
+// Note, the EncryptMasterPublicKey of the slot keys is not set.
+func (key *PuncturableKey) UnmarshalASN1(der []byte) error {
+	var (
+		inner, items cryptobyte.String
+		uid          []byte
+		epoch        uint64
+		params       PuncturableParams
+	)
+	input := cryptobyte.String(der)
+	if !input.ReadASN1(&inner, asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1Bytes(&uid, asn1.OCTET_STRING) ||
+		!inner.ReadASN1Integer(&epoch) ||
+		!inner.ReadASN1Integer(&params.Slots) ||
+		!inner.ReadASN1Integer(&params.Hashes) ||
+		!inner.ReadASN1(&items, asn1.SEQUENCE) || !inner.Empty() {
+		return newError(ErrInvalidPrivateKey, "sm9: invalid puncturable key asn1 data")
+	}
+	if len(uid) > 0xffff || !params.valid() {
+		return newError(ErrInvalidPrivateKey, "sm9: invalid puncturable key parameters")
+	}
+	keys := make([]*EncryptPrivateKey, params.Slots)
+	for !items.Empty() {
+		var (
+			item cryptobyte.String
+			slot int
+			raw  []byte
+		)
+		if !items.ReadASN1(&item, asn1.SEQUENCE) ||
+			!item.ReadASN1Integer(&slot) ||
+			!item.ReadASN1BitStringAsBytes(&raw) || !item.Empty() || len(raw) == 0 ||
+			slot < 0 || slot >= params.Slots || keys[slot] != nil {
+			return newError(ErrInvalidPrivateKey, "sm9: invalid puncturable slot key")
+		}
+		k := new(EncryptPrivateKey)
+		if err := k.UnmarshalRaw(raw); err != nil {
+			return err
+		}
+		keys[slot] = k
+	}
+	key.UID = uid
+	key.Epoch = epoch
+	key.Params = params
+	key.keys = keys
+	return nil
+}
@@ -0,0 +1,110 @@
+package sm9
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestPuncturableEncrypt(t *testing.T) {
+	master, err := GenerateEncryptMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := []byte("emmansun")
+	hid := byte(0x03)
+	params := PuncturableParams{Slots: 256, Hashes: 3}
+	key, err := GeneratePuncturableKey(master, uid, hid, 202410, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg1 := []byte("first message")
+	msg2 := []byte("second message")
+	ct1, err := PuncturableEncrypt(rand.Reader, master.Public(), uid, hid, 202410, params, msg1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct2, err := PuncturableEncrypt(rand.Reader, master.Public(), uid, hid, 202410, params, msg2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct{ ct, msg []byte }{{ct1, msg1}, {ct2, msg2}} {
+		plaintext, err := key.Decrypt(c.ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(plaintext, c.msg) {
+			t.Fatalf("got %q, expected %q", plaintext, c.msg)
+		}
+	}
+
+	if err = key.Puncture(ct1); err != nil {
+		t.Fatal(err)
+	}
+	if key.Remaining() == params.Slots {
+		t.Fatal("no slot key punctured")
+	}
+	if _, err = key.Decrypt(ct1); !errors.Is(err, ErrPunctured) {
+		t.Fatalf("got %v, expected ErrPunctured", err)
+	}
+
+	// the punctured state survives serialization
+	der, err := key.MarshalASN1()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := new(PuncturableKey)
+	if err = restored.UnmarshalASN1(der); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Remaining() != key.Remaining() {
+		t.Fatalf("got %d slot keys, expected %d", restored.Remaining(), key.Remaining())
+	}
+	if _, err = restored.Decrypt(ct1); !errors.Is(err, ErrPunctured) {
+		t.Fatalf("got %v, expected ErrPunctured", err)
+	}
+	// ct2 shares all its slots with ct1 with the probability (3/256)^3
+	plaintext, err := restored.Decrypt(ct2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, msg2) {
+		t.Fatalf("got %q, expected %q", plaintext, msg2)
+	}
+}
+
+func TestPuncturableEncryptMismatch(t *testing.T) {
+	master, err := GenerateEncryptMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := []byte("emmansun")
+	hid := byte(0x03)
+	params := PuncturableParams{Slots: 16, Hashes: 2}
+	key, err := GeneratePuncturableKey(master, uid, hid, 1, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := PuncturableEncrypt(rand.Reader, master.Public(), uid, hid, 2, params, []byte("next epoch"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = key.Decrypt(ct); err == nil {
+		t.Error("ciphertext of another epoch should be rejected")
+	}
+	ct, err = PuncturableEncrypt(rand.Reader, master.Public(), []byte("another"), hid, 1, params, []byte("another user"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = key.Decrypt(ct); err == nil {
+		t.Error("ciphertext of another user should be rejected")
+	}
+	if _, err = key.Decrypt([]byte{0x30, 0x00}); err == nil {
+		t.Error("malformed ciphertext should be rejected")
+	}
+	if _, err = GeneratePuncturableKey(master, uid, hid, 1, PuncturableParams{}); err == nil {
+		t.Error("invalid parameters should be rejected")
+	}
+}
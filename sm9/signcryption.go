@@ -0,0 +1,166 @@
+package sm9
+
+import (
+	goSubtle "crypto/subtle"
+	"io"
+
+	"github.com/emmansun/gmsm/internal/subtle"
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm9/bn256"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// Signcryption combines the SM9 signature of the sender and the SM9 key
+// encapsulation to the receiver in one pass. The sender signs
+//
+//	M' = "SM9-SIGNCRYPTION" || len(uid_B) || uid_B || M
+//
+// to get (h, S), wraps a key K1 || K2 to uid_B to get C1, encrypts S || M with
+// K1 to get C2 and computes C3 = SM3(C2 || K2). Only h is sent in clear, it
+// does not reveal anything on M without S. The output is smaller than an
+// SM9Cipher of an SM9Signature, and the receiver gets an ordinary SM9
+// signature of M' as non-repudiation evidence.
+//
+// The signcrypted message has the ASN.1 format:
+//
+//	SM9Signcryption ::= SEQUENCE {
+//	  c1  BIT STRING,   -- uncompressed G1 point
+//	  h   OCTET STRING,
+//	  c3  OCTET STRING,
+//	  c2  OCTET STRING  -- compressed S || M, XOR encrypted
+//	}
+
+var signcryptionDomain = []byte("SM9-SIGNCRYPTION")
+
+const compressedG1Size = 1 + 32
+
+func signcryptionMessage(uid, msg []byte) []byte {
+	m := make([]byte, 0, len(signcryptionDomain)+2+len(uid)+len(msg))
+	m = append(m, signcryptionDomain...)
+	m = append(m, byte(len(uid)>>8), byte(len(uid)))
+	m = append(m, uid...)
+	return append(m, msg...)
+}
+
+// Signcrypt signs msg with the sender private key priv and encrypts it to the
+// receiver uid, hid under the encryption master public key pub.
+//
+// The rand parameter is used as a source of entropy to ensure that
+// signcrypting the same message twice doesn't result in the same output.
+// Most applications should use [crypto/rand.Reader] as random.
+func Signcrypt(rand io.Reader, priv *SignPrivateKey, pub *EncryptMasterPublicKey, uid []byte, hid byte, msg []byte) ([]byte, error) {
+	if len(msg) == 0 {
+		return nil, ErrEmptyPlaintext
+	}
+	if len(uid) > 0xffff {
+		return nil, newError(ErrInvalidPublicKey, "sm9: uid is too long")
+	}
+	sig, err := SignASN1(rand, priv, signcryptionMessage(uid, msg))
+	if err != nil {
+		return nil, err
+	}
+	h, s, err := parseSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := make([]byte, 0, compressedG1Size+len(msg))
+	plaintext = append(plaintext, s.MarshalCompressed()...)
+	plaintext = append(plaintext, msg...)
+	key, c1, err := WrapKey(rand, pub, uid, hid, len(plaintext)+sm3.Size)
+	if err != nil {
+		return nil, err
+	}
+	c2 := plaintext
+	subtle.XORBytes(c2, plaintext, key[:len(plaintext)])
+	md := sm3.New()
+	md.Write(c2)
+	md.Write(key[len(plaintext):])
+	c3 := md.Sum(nil)
+
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BitString(c1.MarshalUncompressed())
+		b.AddASN1OctetString(h)
+		b.AddASN1OctetString(c3)
+		b.AddASN1OctetString(c2)
+	})
+	return b.Bytes()
+}
+
+// SigncryptionEvidence is the non-repudiation evidence extracted by
+// Unsigncrypt: the sender's SM9 signature of the message for the receiver.
+// It can be handed to a third party, which checks it with Verify.
+type SigncryptionEvidence struct {
+	Message     []byte
+	ReceiverUID []byte
+	// Signature is an SM9Signature ASN.1 encoding.
+	Signature []byte
+}
+
+// Verify reports whether the evidence is a valid signature by the sender uid,
+// hid under the signature master public key pub.
+func (e *SigncryptionEvidence) Verify(pub *SignMasterPublicKey, uid []byte, hid byte) bool {
+	if len(e.ReceiverUID) > 0xffff {
+		return false
+	}
+	return VerifyASN1(pub, uid, hid, signcryptionMessage(e.ReceiverUID, e.Message), e.Signature)
+}
+
+// Unsigncrypt decrypts the output of Signcrypt with the receiver private key
+// priv of uid, and verifies the signature of the sender senderUID, senderHID
+// under the signature master public key senderPub. It returns the message
+// and the non-repudiation evidence.
+func Unsigncrypt(priv *EncryptPrivateKey, uid []byte, senderPub *SignMasterPublicKey, senderUID []byte, senderHID byte, ciphertext []byte) ([]byte, *SigncryptionEvidence, error) {
+	var (
+		c1Bytes, h, c2, c3 []byte
+		inner              cryptobyte.String
+	)
+	input := cryptobyte.String(ciphertext)
+	if !input.ReadASN1(&inner, asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1BitStringAsBytes(&c1Bytes) ||
+		!inner.ReadASN1Bytes(&h, asn1.OCTET_STRING) ||
+		!inner.ReadASN1Bytes(&c3, asn1.OCTET_STRING) ||
+		!inner.ReadASN1Bytes(&c2, asn1.OCTET_STRING) || !inner.Empty() ||
+		len(c1Bytes) == 0 || len(c2) <= compressedG1Size {
+		return nil, nil, newError(ErrInvalidCiphertext, "sm9: invalid signcryption asn.1 data")
+	}
+	if len(uid) > 0xffff {
+		return nil, nil, newError(ErrInvalidPrivateKey, "sm9: uid is too long")
+	}
+	c1, err := unmarshalG1(c1Bytes)
+	if err != nil {
+		return nil, nil, ErrDecryption
+	}
+	key, err := UnwrapKey(priv, uid, c1, len(c2)+sm3.Size)
+	if err != nil {
+		return nil, nil, err
+	}
+	md := sm3.New()
+	md.Write(c2)
+	md.Write(key[len(c2):])
+	if goSubtle.ConstantTimeCompare(md.Sum(nil), c3) != 1 {
+		return nil, nil, ErrDecryption
+	}
+	plaintext := make([]byte, len(c2))
+	subtle.XORBytes(plaintext, c2, key[:len(c2)])
+
+	s := new(bn256.G1)
+	if _, err = s.UnmarshalCompressed(plaintext[:compressedG1Size]); err != nil {
+		return nil, nil, newError(ErrInvalidSignature, "sm9: invalid signcryption signature")
+	}
+	sig, err := encodeSignature(h, s)
+	if err != nil {
+		return nil, nil, err
+	}
+	evidence := &SigncryptionEvidence{
+		Message:     plaintext[compressedG1Size:],
+		ReceiverUID: append([]byte(nil), uid...),
+		Signature:   sig,
+	}
+	if !evidence.Verify(senderPub, senderUID, senderHID) {
+		return nil, nil, newError(ErrInvalidSignature, "sm9: invalid signcryption signature")
+	}
+	return evidence.Message, evidence, nil
+}
@@ -0,0 +1,86 @@
+package sm9
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestSigncryption(t *testing.T) {
+	signMaster, err := GenerateSignMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encMaster, err := GenerateEncryptMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, bob := []byte("Alice"), []byte("Bob")
+	signKey, err := signMaster.GenerateUserKey(alice, 0x01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encKey, err := encMaster.GenerateUserKey(bob, 0x03)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("signcrypted message")
+	ciphertext, err := Signcrypt(rand.Reader, signKey, encMaster.Public(), bob, 0x03, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, evidence, err := Unsigncrypt(encKey, bob, signMaster.Public(), alice, 0x01, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Errorf("got %q, expected %q", got, msg)
+	}
+	if !evidence.Verify(signMaster.Public(), alice, 0x01) {
+		t.Errorf("evidence verification failed")
+	}
+	if evidence.Verify(signMaster.Public(), bob, 0x01) {
+		t.Errorf("evidence verification with wrong signer succeeded")
+	}
+	forged := *evidence
+	forged.Message = []byte("another message")
+	if forged.Verify(signMaster.Public(), alice, 0x01) {
+		t.Errorf("evidence verification with wrong message succeeded")
+	}
+
+	// a sign-then-encrypt of the same message is larger
+	sig, err := SignASN1(rand.Reader, signKey, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigEnc, err := EncryptASN1(rand.Reader, encMaster.Public(), bob, 0x03, append(sig, msg...), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ciphertext) >= len(sigEnc) {
+		t.Errorf("signcryption %d bytes is not smaller than sign-then-encrypt %d bytes", len(ciphertext), len(sigEnc))
+	}
+
+	if _, _, err = Unsigncrypt(encKey, bob, signMaster.Public(), bob, 0x01, ciphertext); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("got %v, expected ErrInvalidSignature for wrong sender", err)
+	}
+	otherKey, err := encMaster.GenerateUserKey(alice, 0x03)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = Unsigncrypt(otherKey, alice, signMaster.Public(), alice, 0x01, ciphertext); err == nil {
+		t.Errorf("Unsigncrypt with wrong receiver succeeded")
+	}
+	tampered := append([]byte(nil), ciphertext...)
+	tampered[len(tampered)-1] ^= 1
+	if _, _, err = Unsigncrypt(encKey, bob, signMaster.Public(), alice, 0x01, tampered); !errors.Is(err, ErrDecryption) {
+		t.Errorf("got %v, expected ErrDecryption for tampered ciphertext", err)
+	}
+	if _, _, err = Unsigncrypt(encKey, bob, signMaster.Public(), alice, 0x01, ciphertext[:len(ciphertext)-1]); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("got %v, expected ErrInvalidCiphertext for truncated ciphertext", err)
+	}
+	if _, err = Signcrypt(rand.Reader, signKey, encMaster.Public(), bob, 0x03, nil); !errors.Is(err, ErrEmptyPlaintext) {
+		t.Errorf("got %v, expected ErrEmptyPlaintext", err)
+	}
+}
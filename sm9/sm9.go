@@ -10,6 +10,7 @@ import (
 	"math/big"
 
 	"github.com/emmansun/gmsm/internal/bigmod"
+	"github.com/emmansun/gmsm/internal/instrument"
 	"github.com/emmansun/gmsm/internal/randutil"
 	"github.com/emmansun/gmsm/internal/subtle"
 	"github.com/emmansun/gmsm/kdf"
@@ -71,8 +72,7 @@ func hash(z []byte, h hashMode) *bigmod.Nat {
 	md.Write(countBytes[:])
 	copy(ha[sm3.Size:], md.Sum(nil))
 
-	k := new(big.Int).SetBytes(ha[:40])
-	kNat := bigmod.NewNat().SetBig(k)
+	kNat := bigmod.NewNat().SetUnreducedBytes(ha[:40])
 	kNat = bigmod.NewNat().ModNat(kNat, orderMinus1)
 	kNat.Add(bigOneNat, orderNat)
 	return kNat
@@ -151,6 +151,19 @@ func (priv *SignPrivateKey) Sign(rand io.Reader, hash []byte, opts crypto.Signer
 // as rand. Note that the returned signature does not depend deterministically on
 // the bytes read from rand, and may change between calls and/or between versions.
 func SignASN1(rand io.Reader, priv *SignPrivateKey, hash []byte) ([]byte, error) {
+	return AppendSignASN1(nil, rand, priv, hash)
+}
+
+// AppendSignASN1 is like SignASN1 but appends the ASN.1 encoded signature to dst
+// and returns the extended buffer, so that the caller can reuse the output buffer.
+func AppendSignASN1(dst []byte, rand io.Reader, priv *SignPrivateKey, hash []byte) ([]byte, error) {
+	done := instrument.Start("sm9", "sign")
+	sig, err := appendSignASN1(dst, rand, priv, hash)
+	done(err)
+	return sig, err
+}
+
+func appendSignASN1(dst []byte, rand io.Reader, priv *SignPrivateKey, hash []byte) ([]byte, error) {
 	var (
 		hNat *bigmod.Nat
 		s    *bn256.G1
@@ -167,11 +180,16 @@ func SignASN1(rand io.Reader, priv *SignPrivateKey, hash []byte) ([]byte, error)
 			return nil, err
 		}
 
-		var buffer []byte
+		buffer := make([]byte, 0, len(hash)+12*32)
 		buffer = append(buffer, hash...)
 		buffer = append(buffer, w.Marshal()...)
 
 		hNat = hashH2(buffer)
+		if instrument.Tracing() {
+			instrument.Trace("sm9", "sign", "r", r.Bytes(orderNat))
+			instrument.Trace("sm9", "sign", "w", w.Marshal())
+			instrument.Trace("sm9", "sign", "h", hNat.Bytes(orderNat))
+		}
 		r.Sub(hNat, orderNat)
 
 		if r.IsZero() == 0 {
@@ -179,11 +197,15 @@ func SignASN1(rand io.Reader, priv *SignPrivateKey, hash []byte) ([]byte, error)
 			if err != nil {
 				return nil, err
 			}
+			if instrument.Tracing() {
+				instrument.Trace("sm9", "sign", "l", r.Bytes(orderNat))
+				instrument.Trace("sm9", "sign", "S", s.MarshalUncompressed())
+			}
 			break
 		}
 	}
 
-	return encodeSignature(hNat.Bytes(orderNat), s)
+	return appendSignature(dst, hNat.Bytes(orderNat), s)
 }
 
 // Verify verifies the signature in h, s of hash using the master dsa public key and user id, uid and hid.
@@ -200,7 +222,11 @@ func Verify(pub *SignMasterPublicKey, uid []byte, hid byte, hash []byte, h *big.
 }
 
 func encodeSignature(hBytes []byte, s *bn256.G1) ([]byte, error) {
-	var b cryptobyte.Builder
+	return appendSignature(nil, hBytes, s)
+}
+
+func appendSignature(dst, hBytes []byte, s *bn256.G1) ([]byte, error) {
+	b := cryptobyte.NewBuilder(dst)
 	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
 		b.AddASN1OctetString(hBytes)
 		b.AddASN1BitString(s.MarshalUncompressed())
@@ -220,15 +246,15 @@ func parseSignature(sig []byte) ([]byte, *bn256.G1, error) {
 		!inner.ReadASN1Bytes(&hBytes, asn1.OCTET_STRING) ||
 		!inner.ReadASN1BitStringAsBytes(&sBytes) ||
 		!inner.Empty() {
-		return nil, nil, errors.New("invalid ASN.1")
+		return nil, nil, newError(ErrInvalidSignature, "invalid ASN.1")
 	}
-	if sBytes[0] != 4 {
-		return nil, nil, errors.New("sm9: invalid point format")
+	if len(sBytes) == 0 || sBytes[0] != 4 {
+		return nil, nil, newError(ErrInvalidSignature, "sm9: invalid point format")
 	}
 	s := new(bn256.G1)
 	_, err := s.Unmarshal(sBytes[1:])
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, pointError(err)
 	}
 	return hBytes, s, nil
 }
@@ -244,6 +270,23 @@ func parseSignatureLegacy(sig []byte) (*big.Int, *bn256.G1, error) {
 // VerifyASN1 verifies the ASN.1 encoded signature of type SM9Signature, sig, of hash using the
 // public key, pub. Its return value records whether the signature is valid.
 func VerifyASN1(pub *SignMasterPublicKey, uid []byte, hid byte, hash, sig []byte) bool {
+	return verifyASN1(pub, uid, hid, hash, sig, nil)
+}
+
+// VerifyASN1WithCache is like VerifyASN1, but looks up the user public key and
+// the pairing result in cache first. A nil cache disables caching.
+func VerifyASN1WithCache(cache *VerifyCache, pub *SignMasterPublicKey, uid []byte, hid byte, hash, sig []byte) bool {
+	return verifyASN1(pub, uid, hid, hash, sig, cache)
+}
+
+func verifyASN1(pub *SignMasterPublicKey, uid []byte, hid byte, hash, sig []byte, cache *VerifyCache) bool {
+	done := instrument.Start("sm9", "verify")
+	ok := verifySignature(pub, uid, hid, hash, sig, cache)
+	done(instrument.VerifyResult(ok))
+	return ok
+}
+
+func verifySignature(pub *SignMasterPublicKey, uid []byte, hid byte, hash, sig []byte, cache *VerifyCache) bool {
 	h, s, err := parseSignature(sig)
 	if err != nil {
 		return false
@@ -260,21 +303,44 @@ func VerifyASN1(pub *SignMasterPublicKey, uid []byte, hid byte, hash, sig []byte
 		return false
 	}
 
-	t, err := pub.ScalarBaseMult(hNat.Bytes(orderNat))
-	if err != nil {
-		return false
-	}
-
 	// user sign public key p generation
-	p := pub.GenerateUserPublicKey(uid, hid)
+	var p *bn256.G2
+	if cache != nil {
+		p = cache.UserPublicKey(pub, uid, hid)
+	} else {
+		p = pub.GenerateUserPublicKey(uid, hid)
+	}
 
-	u := bn256.Pair(s, p)
-	w := new(bn256.GT).Add(u, t)
+	var t, w *bn256.GT
+	if LowMemory() && !pub.hasGeneratorTable() {
+		// Without the GT table, w = e(S, P) * e(P1, Ppub)^h is cheaper as the
+		// product e(S, P) * e([h]P1, Ppub) with a single final exponentiation.
+		hP1, err := new(bn256.G1).ScalarBaseMult(hNat.Bytes(orderNat))
+		if err != nil {
+			return false
+		}
+		if w, err = bn256.PairingCheckProduct([]*bn256.G1{s, hP1}, []*bn256.G2{p, pub.MasterPublicKey}); err != nil {
+			return false
+		}
+	} else {
+		if t, err = pub.ScalarBaseMult(hNat.Bytes(orderNat)); err != nil {
+			return false
+		}
+		u := bn256.Pair(s, p)
+		w = u.Add(u, t)
+	}
 
-	var buffer []byte
+	buffer := make([]byte, 0, len(hash)+12*32)
 	buffer = append(buffer, hash...)
 	buffer = append(buffer, w.Marshal()...)
 	h2 := hashH2(buffer)
+	if instrument.Tracing() {
+		if t != nil {
+			instrument.Trace("sm9", "verify", "t", t.Marshal())
+		}
+		instrument.Trace("sm9", "verify", "w'", w.Marshal())
+		instrument.Trace("sm9", "verify", "h2", h2.Bytes(orderNat))
+	}
 
 	return h2.Equal(hNat) == 1
 }
@@ -292,6 +358,9 @@ func (pub *SignMasterPublicKey) Verify(uid []byte, hid byte, hash, sig []byte) b
 // Most applications should use [crypto/rand.Reader] as random.
 func WrapKey(rand io.Reader, pub *EncryptMasterPublicKey, uid []byte, hid byte, kLen int) (key []byte, cipher *bn256.G1, err error) {
 	q := pub.GenerateUserPublicKey(uid, hid)
+	if instrument.Tracing() {
+		instrument.Trace("sm9", "wrapkey", "QB", q.Marshal())
+	}
 	var (
 		r *bigmod.Nat
 		w *bn256.GT
@@ -312,12 +381,18 @@ func WrapKey(rand io.Reader, pub *EncryptMasterPublicKey, uid []byte, hid byte,
 		if err != nil {
 			return
 		}
-		var buffer []byte
+		buffer := make([]byte, 0, 64+12*32+len(uid))
 		buffer = append(buffer, cipher.Marshal()...)
 		buffer = append(buffer, w.Marshal()...)
 		buffer = append(buffer, uid...)
 
 		key = kdf.Kdf(sm3.New(), buffer, kLen)
+		if instrument.Tracing() {
+			instrument.Trace("sm9", "wrapkey", "r", rBytes)
+			instrument.Trace("sm9", "wrapkey", "C1", cipher.Marshal())
+			instrument.Trace("sm9", "wrapkey", "w", w.Marshal())
+			instrument.Trace("sm9", "wrapkey", "K", key)
+		}
 		if !subtle.ConstantTimeAllZero(key) {
 			break
 		}
@@ -374,7 +449,7 @@ func UnmarshalSM9KeyPackage(der []byte) ([]byte, *bn256.G1, error) {
 		!inner.ReadASN1Bytes(&key, asn1.OCTET_STRING) ||
 		!inner.ReadASN1BitStringAsBytes(&cipherBytes) ||
 		!inner.Empty() {
-		return nil, nil, errors.New("sm9: invalid SM9KeyPackage asn.1 data")
+		return nil, nil, newError(ErrInvalidCiphertext, "sm9: invalid SM9KeyPackage asn.1 data")
 	}
 	g, err := unmarshalG1(cipherBytes)
 	if err != nil {
@@ -396,14 +471,18 @@ func UnwrapKey(priv *EncryptPrivateKey, uid []byte, cipher *bn256.G1, kLen int)
 		return nil, ErrDecryption
 	}
 
-	w := bn256.Pair(cipher, priv.PrivateKey)
+	w := priv.pair(cipher)
 
-	var buffer []byte
+	buffer := make([]byte, 0, 64+12*32+len(uid))
 	buffer = append(buffer, cipher.Marshal()...)
 	buffer = append(buffer, w.Marshal()...)
 	buffer = append(buffer, uid...)
 
 	key := kdf.Kdf(sm3.New(), buffer, kLen)
+	if instrument.Tracing() {
+		instrument.Trace("sm9", "unwrapkey", "w'", w.Marshal())
+		instrument.Trace("sm9", "unwrapkey", "K'", key)
+	}
 	if subtle.ConstantTimeAllZero(key) {
 		return nil, ErrDecryption
 	}
@@ -427,16 +506,24 @@ func (priv *EncryptPrivateKey) UnwrapKey(uid, cipherDer []byte, kLen int) ([]byt
 
 // Encrypt encrypts plaintext, returns ciphertext with format C1||C3||C2.
 func Encrypt(rand io.Reader, pub *EncryptMasterPublicKey, uid []byte, hid byte, plaintext []byte, opts EncrypterOpts) ([]byte, error) {
+	return AppendEncrypt(nil, rand, pub, uid, hid, plaintext, opts)
+}
+
+// AppendEncrypt is like Encrypt but appends the C1||C3||C2 ciphertext to dst
+// and returns the extended buffer, so that the caller can reuse the output buffer.
+func AppendEncrypt(dst []byte, rand io.Reader, pub *EncryptMasterPublicKey, uid []byte, hid byte, plaintext []byte, opts EncrypterOpts) ([]byte, error) {
 	c1, c2, c3, err := encrypt(rand, pub, uid, hid, plaintext, opts)
 	if err != nil {
 		return nil, err
 	}
-	ciphertext := append(c1.Marshal(), c3...)
-	ciphertext = append(ciphertext, c2...)
-	return ciphertext, nil
+	dst = append(dst, c1.Marshal()...)
+	dst = append(dst, c3...)
+	return append(dst, c2...), nil
 }
 
 func encrypt(rand io.Reader, pub *EncryptMasterPublicKey, uid []byte, hid byte, plaintext []byte, opts EncrypterOpts) (c1 *bn256.G1, c2, c3 []byte, err error) {
+	done := instrument.Start("sm9", "encrypt")
+	defer func() { done(err) }()
 	if opts == nil {
 		opts = DefaultEncrypterOpts
 	}
@@ -457,6 +544,10 @@ func encrypt(rand io.Reader, pub *EncryptMasterPublicKey, uid []byte, hid byte,
 	hash.Write(c2)
 	hash.Write(key[key1Len:])
 	c3 = hash.Sum(nil)
+	if instrument.Tracing() {
+		instrument.Trace("sm9", "encrypt", "C2", c2)
+		instrument.Trace("sm9", "encrypt", "C3", c3)
+	}
 
 	return
 }
@@ -464,12 +555,12 @@ func encrypt(rand io.Reader, pub *EncryptMasterPublicKey, uid []byte, hid byte,
 // EncryptASN1 encrypts plaintext and returns ciphertext with ASN.1 format according
 // SM9 cryptographic algorithm application specification, SM9Cipher definition.
 func EncryptASN1(rand io.Reader, pub *EncryptMasterPublicKey, uid []byte, hid byte, plaintext []byte, opts EncrypterOpts) ([]byte, error) {
-	return pub.Encrypt(rand, uid, hid, plaintext, opts)
+	return AppendEncryptASN1(nil, rand, pub, uid, hid, plaintext, opts)
 }
 
-// Encrypt encrypts plaintext and returns ciphertext with ASN.1 format according
-// SM9 cryptographic algorithm application specification, SM9Cipher definition.
-func (pub *EncryptMasterPublicKey) Encrypt(rand io.Reader, uid []byte, hid byte, plaintext []byte, opts EncrypterOpts) ([]byte, error) {
+// AppendEncryptASN1 is like EncryptASN1 but appends the ASN.1 encoded ciphertext
+// to dst and returns the extended buffer, so that the caller can reuse the output buffer.
+func AppendEncryptASN1(dst []byte, rand io.Reader, pub *EncryptMasterPublicKey, uid []byte, hid byte, plaintext []byte, opts EncrypterOpts) ([]byte, error) {
 	if opts == nil {
 		opts = DefaultEncrypterOpts
 	}
@@ -478,7 +569,7 @@ func (pub *EncryptMasterPublicKey) Encrypt(rand io.Reader, uid []byte, hid byte,
 		return nil, err
 	}
 
-	var b cryptobyte.Builder
+	b := cryptobyte.NewBuilder(dst)
 	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
 		b.AddASN1Int64(int64(opts.GetEncryptType()))
 		b.AddASN1BitString(c1.MarshalUncompressed())
@@ -488,8 +579,21 @@ func (pub *EncryptMasterPublicKey) Encrypt(rand io.Reader, uid []byte, hid byte,
 	return b.Bytes()
 }
 
+// Encrypt encrypts plaintext and returns ciphertext with ASN.1 format according
+// SM9 cryptographic algorithm application specification, SM9Cipher definition.
+func (pub *EncryptMasterPublicKey) Encrypt(rand io.Reader, uid []byte, hid byte, plaintext []byte, opts EncrypterOpts) ([]byte, error) {
+	return AppendEncryptASN1(nil, rand, pub, uid, hid, plaintext, opts)
+}
+
 // Decrypt decrypts chipher, the ciphertext should be with format C1||C3||C2
 func Decrypt(priv *EncryptPrivateKey, uid, ciphertext []byte, opts EncrypterOpts) ([]byte, error) {
+	done := instrument.Start("sm9", "decrypt")
+	plaintext, err := decryptPlain(priv, uid, ciphertext, opts)
+	done(err)
+	return plaintext, err
+}
+
+func decryptPlain(priv *EncryptPrivateKey, uid, ciphertext []byte, opts EncrypterOpts) ([]byte, error) {
 	if opts == nil {
 		opts = DefaultEncrypterOpts
 	}
@@ -518,6 +622,9 @@ func decrypt(cipher *bn256.G1, key1, key2, c2, c3 []byte, opts EncrypterOpts) ([
 	hash.Write(c2)
 	hash.Write(key2)
 	c32 := hash.Sum(nil)
+	if instrument.Tracing() {
+		instrument.Trace("sm9", "decrypt", "u", c32)
+	}
 
 	if goSubtle.ConstantTimeCompare(c3, c32) != 1 {
 		return nil, ErrDecryption
@@ -529,8 +636,15 @@ func decrypt(cipher *bn256.G1, key1, key2, c2, c3 []byte, opts EncrypterOpts) ([
 // DecryptASN1 decrypts chipher, the ciphertext should be with ASN.1 format according
 // SM9 cryptographic algorithm application specification, SM9Cipher definition.
 func DecryptASN1(priv *EncryptPrivateKey, uid, ciphertext []byte) ([]byte, error) {
+	done := instrument.Start("sm9", "decrypt")
+	plaintext, err := decryptASN1(priv, uid, ciphertext)
+	done(err)
+	return plaintext, err
+}
+
+func decryptASN1(priv *EncryptPrivateKey, uid, ciphertext []byte) ([]byte, error) {
 	if len(ciphertext) <= 32+65 {
-		return nil, errors.New("sm9: ciphertext too short")
+		return nil, newError(ErrInvalidCiphertext, "sm9: ciphertext too short")
 	}
 	var (
 		encType int
@@ -547,7 +661,7 @@ func DecryptASN1(priv *EncryptPrivateKey, uid, ciphertext []byte) ([]byte, error
 		!inner.ReadASN1Bytes(&c3Bytes, asn1.OCTET_STRING) ||
 		!inner.ReadASN1Bytes(&c2Bytes, asn1.OCTET_STRING) ||
 		!inner.Empty() {
-		return nil, errors.New("sm9: invalid ciphertext asn.1 data")
+		return nil, newError(ErrInvalidCiphertext, "sm9: invalid ciphertext asn.1 data")
 	}
 	// We just make assumption block cipher is SM4 and padding scheme is pkcs7
 	opts := shangMiEncrypterOpts(encryptType(encType))
@@ -597,7 +711,12 @@ type KeyExchange struct {
 	g3           *bn256.GT          // internal state which will be used when compute the key and signature
 }
 
-// NewKeyExchange creates one new KeyExchange object
+// NewKeyExchange creates one new KeyExchange object.
+//
+// The peerUID can be nil if the peer identity is not known yet, for example
+// the server of the TLCP IBSDH cipher suites learns the client identity during
+// the handshake. It must then be set with SetPeerUID before InitKeyExchange or
+// RepondKeyExchange.
 func NewKeyExchange(priv *EncryptPrivateKey, uid, peerUID []byte, keyLen int, genSignature bool) *KeyExchange {
 	ke := &KeyExchange{}
 	ke.genSignature = genSignature
@@ -608,6 +727,19 @@ func NewKeyExchange(priv *EncryptPrivateKey, uid, peerUID []byte, keyLen int, ge
 	return ke
 }
 
+// SetPeerUID sets the peer identity of a KeyExchange created without it. It
+// can only be called once, before InitKeyExchange or RepondKeyExchange.
+func (ke *KeyExchange) SetPeerUID(peerUID []byte) error {
+	if len(ke.peerUID) > 0 {
+		return newError(ErrKeyExchange, "sm9: peer uid already exists, please do not set it")
+	}
+	if len(peerUID) == 0 {
+		return newError(ErrKeyExchange, "sm9: empty peer uid")
+	}
+	ke.peerUID = peerUID
+	return nil
+}
+
 // Destroy clears all internal state and Ephemeral private/public keys
 func (ke *KeyExchange) Destroy() {
 	if ke.r != nil {
@@ -636,6 +768,9 @@ func initKeyExchange(ke *KeyExchange, hid byte, r *bigmod.Nat) {
 
 // InitKeyExchange generates random with responder uid, for initiator's step A1-A4
 func (ke *KeyExchange) InitKeyExchange(rand io.Reader, hid byte) (*bn256.G1, error) {
+	if len(ke.peerUID) == 0 {
+		return nil, newError(ErrKeyExchange, "sm9: no peer uid given")
+	}
 	r, err := randomScalar(rand)
 	if err != nil {
 		return nil, err
@@ -690,7 +825,7 @@ func (ke *KeyExchange) generateSharedKey(isResponder bool) ([]byte, error) {
 
 func respondKeyExchange(ke *KeyExchange, hid byte, r *bigmod.Nat, rA *bn256.G1) (*bn256.G1, []byte, error) {
 	if !rA.IsOnCurve() {
-		return nil, nil, errors.New("sm9: invalid initiator's ephemeral public key")
+		return nil, nil, newError(ErrPointNotOnCurve, "sm9: invalid initiator's ephemeral public key")
 	}
 	ke.peerSecret = rA
 	pubA := ke.privateKey.GenerateUserPublicKey(ke.peerUID, hid)
@@ -702,7 +837,7 @@ func respondKeyExchange(ke *KeyExchange, hid byte, r *bigmod.Nat, rA *bn256.G1)
 	}
 	ke.secret = rB
 
-	ke.g1 = bn256.Pair(ke.peerSecret, ke.privateKey.PrivateKey)
+	ke.g1 = ke.privateKey.pair(ke.peerSecret)
 	ke.g3 = &bn256.GT{}
 	g3, err := bn256.ScalarMultGT(ke.g1, rBytes)
 	if err != nil {
@@ -725,6 +860,9 @@ func respondKeyExchange(ke *KeyExchange, hid byte, r *bigmod.Nat, rA *bn256.G1)
 
 // RepondKeyExchange when responder receive rA, for responder's step B1-B7
 func (ke *KeyExchange) RepondKeyExchange(rand io.Reader, hid byte, rA *bn256.G1) (*bn256.G1, []byte, error) {
+	if len(ke.peerUID) == 0 {
+		return nil, nil, newError(ErrKeyExchange, "sm9: no peer uid given")
+	}
 	r, err := randomScalar(rand)
 	if err != nil {
 		return nil, nil, err
@@ -735,7 +873,7 @@ func (ke *KeyExchange) RepondKeyExchange(rand io.Reader, hid byte, rA *bn256.G1)
 // ConfirmResponder for initiator's step A5-A7
 func (ke *KeyExchange) ConfirmResponder(rB *bn256.G1, sB []byte) ([]byte, []byte, error) {
 	if !rB.IsOnCurve() {
-		return nil, nil, errors.New("sm9: invalid responder's ephemeral public key")
+		return nil, nil, newError(ErrPointNotOnCurve, "sm9: invalid responder's ephemeral public key")
 	}
 	// step 5
 	ke.peerSecret = rB
@@ -744,7 +882,7 @@ func (ke *KeyExchange) ConfirmResponder(rB *bn256.G1, sB []byte) ([]byte, []byte
 		return nil, nil, err
 	}
 	ke.g1 = g1
-	ke.g2 = bn256.Pair(ke.peerSecret, ke.privateKey.PrivateKey)
+	ke.g2 = ke.privateKey.pair(ke.peerSecret)
 	ke.g3 = &bn256.GT{}
 	g3, err := bn256.ScalarMultGT(ke.g2, ke.r.Bytes(orderNat))
 	if err != nil {
@@ -755,7 +893,7 @@ func (ke *KeyExchange) ConfirmResponder(rB *bn256.G1, sB []byte) ([]byte, []byte
 	if len(sB) > 0 {
 		signature := ke.sign(false, 0x82)
 		if goSubtle.ConstantTimeCompare(signature, sB) != 1 {
-			return nil, nil, errors.New("sm9: invalid responder's signature")
+			return nil, nil, newError(ErrKeyExchange, "sm9: invalid responder's signature")
 		}
 	}
 	key, err := ke.generateSharedKey(false)
@@ -773,7 +911,7 @@ func (ke *KeyExchange) ConfirmInitiator(s1 []byte) ([]byte, error) {
 	if s1 != nil {
 		buffer := ke.sign(true, 0x83)
 		if goSubtle.ConstantTimeCompare(buffer, s1) != 1 {
-			return nil, errors.New("sm9: invalid initiator's signature")
+			return nil, newError(ErrKeyExchange, "sm9: invalid initiator's signature")
 		}
 	}
 	return ke.generateSharedKey(true)
@@ -3,10 +3,11 @@ package sm9
 import (
 	"encoding/pem"
 
-	"errors"
 	"io"
 	"math/big"
 	"sync"
+	"sync/atomic"
+	"unsafe"
 
 	"github.com/emmansun/gmsm/internal/bigmod"
 	"github.com/emmansun/gmsm/sm9/bn256"
@@ -27,6 +28,7 @@ type SignMasterPublicKey struct {
 	basePoint       *bn256.GT // the result of Pair(Gen1, pub.MasterPublicKey)
 	tableGenOnce    sync.Once
 	table           *[32 * 2]bn256.GTFieldTable // precomputed basePoint^n
+	tableBuilt      uint32                      // 1 once table is set
 }
 
 // SignPrivateKey user private key for sign, generated by KGC
@@ -48,12 +50,24 @@ type EncryptMasterPublicKey struct {
 	basePoint       *bn256.GT // the result of Pair(pub.MasterPublicKey, Gen2)
 	tableGenOnce    sync.Once
 	table           *[32 * 2]bn256.GTFieldTable // precomputed basePoint^n
+	tableBuilt      uint32                      // 1 once table is set
 }
 
 // EncryptPrivateKey user private key for encryption, generated by KGC
+//
+// The Miller loop lines of PrivateKey are precomputed on the first decryption
+// and rebuilt when PrivateKey is replaced, the point PrivateKey refers to must
+// not be changed in place.
 type EncryptPrivateKey struct {
-	PrivateKey              *bn256.G2 // user private key
-	*EncryptMasterPublicKey           // master public key
+	PrivateKey              *bn256.G2      // user private key
+	*EncryptMasterPublicKey                // master public key
+	prepared                unsafe.Pointer // *preparedKey, see pair
+}
+
+// preparedKey is the precomputed Miller loop lines of the point key.
+type preparedKey struct {
+	key   *bn256.G2
+	lines *bn256.G2Prepared
 }
 
 // GenerateSignMasterKey generates a master public and private key pair for DSA usage.
@@ -93,14 +107,14 @@ func (master *SignMasterPrivateKey) UnmarshalASN1(der []byte) error {
 		if !input.ReadASN1(&inner, cryptobyte_asn1.SEQUENCE) ||
 			!input.Empty() ||
 			!inner.ReadASN1Integer(d) {
-			return errors.New("sm9: invalid sign master private key asn1 data")
+			return newError(ErrInvalidPrivateKey, "sm9: invalid sign master private key asn1 data")
 		}
 		// Just parse it, did't validate it
 		if !inner.Empty() && (!inner.ReadASN1BitStringAsBytes(&pubBytes) || !inner.Empty()) {
-			return errors.New("sm9: invalid sign master public key asn1 data")
+			return newError(ErrInvalidPublicKey, "sm9: invalid sign master public key asn1 data")
 		}
 	} else if !input.ReadASN1Integer(d) || !input.Empty() {
-		return errors.New("sm9: invalid sign master private key asn1 data")
+		return newError(ErrInvalidPrivateKey, "sm9: invalid sign master private key asn1 data")
 	}
 	master.D = d
 	master.SignMasterPublicKey = new(SignMasterPublicKey)
@@ -112,6 +126,16 @@ func (master *SignMasterPrivateKey) UnmarshalASN1(der []byte) error {
 	return nil
 }
 
+// masterKeyNat converts the master private key to a scalar through a fixed width buffer,
+// the master private key must be less than the order.
+func masterKeyNat(d *big.Int) (*bigmod.Nat, error) {
+	if d == nil || d.Sign() < 0 || d.BitLen() > orderNat.BitLen() {
+		return nil, newError(ErrInvalidPrivateKey, "sm9: invalid master private key")
+	}
+	var buf [32]byte
+	return bigmod.NewNat().SetBytes(d.FillBytes(buf[:]), orderNat)
+}
+
 // GenerateUserKey generate an user dsa key.
 func (master *SignMasterPrivateKey) GenerateUserKey(uid []byte, hid byte) (*SignPrivateKey, error) {
 	var id []byte
@@ -120,14 +144,14 @@ func (master *SignMasterPrivateKey) GenerateUserKey(uid []byte, hid byte) (*Sign
 
 	t1Nat := hashH1(id)
 
-	d, err := bigmod.NewNat().SetBytes(master.D.Bytes(), orderNat)
+	d, err := masterKeyNat(master.D)
 	if err != nil {
 		return nil, err
 	}
 
 	t1Nat.Add(d, orderNat)
 	if t1Nat.IsZero() == 1 {
-		return nil, errors.New("sm9: need to re-generate sign master private key")
+		return nil, newError(ErrInvalidPrivateKey, "sm9: need to re-generate sign master private key")
 	}
 
 	t1Nat = bigmod.NewNat().Exp(t1Nat, orderMinus2, orderNat)
@@ -157,9 +181,15 @@ func (pub *SignMasterPublicKey) pair() *bn256.GT {
 	return pub.basePoint
 }
 
+// hasGeneratorTable reports whether the table of generatorTable was built.
+func (pub *SignMasterPublicKey) hasGeneratorTable() bool {
+	return atomic.LoadUint32(&pub.tableBuilt) == 1
+}
+
 func (pub *SignMasterPublicKey) generatorTable() *[32 * 2]bn256.GTFieldTable {
 	pub.tableGenOnce.Do(func() {
 		pub.table = bn256.GenerateGTFieldTable(pub.pair())
+		atomic.StoreUint32(&pub.tableBuilt, 1)
 	})
 	return pub.table
 }
@@ -167,6 +197,9 @@ func (pub *SignMasterPublicKey) generatorTable() *[32 * 2]bn256.GTFieldTable {
 // ScalarBaseMult compute basepoint^r with precomputed table
 // The base point = pair(Gen1, <master public key>)
 func (pub *SignMasterPublicKey) ScalarBaseMult(scalar []byte) (*bn256.GT, error) {
+	if LowMemory() && !pub.hasGeneratorTable() {
+		return bn256.ScalarMultGT(pub.pair(), scalar)
+	}
 	tables := pub.generatorTable()
 	return bn256.ScalarBaseMultGT(tables, scalar)
 }
@@ -207,15 +240,15 @@ func unmarshalG2(bytes []byte) (*bn256.G2, error) {
 	case 4:
 		_, err := g2.Unmarshal(bytes[1:])
 		if err != nil {
-			return nil, err
+			return nil, pointError(err)
 		}
 	case 2, 3:
 		_, err := g2.UnmarshalCompressed(bytes)
 		if err != nil {
-			return nil, err
+			return nil, pointError(err)
 		}
 	default:
-		return nil, errors.New("sm9: invalid point identity byte")
+		return nil, newError(ErrPointNotOnCurve, "sm9: invalid point identity byte")
 	}
 	return g2, nil
 }
@@ -240,10 +273,10 @@ func (pub *SignMasterPublicKey) UnmarshalASN1(der []byte) error {
 			!input.Empty() ||
 			!inner.ReadASN1BitStringAsBytes(&bytes) ||
 			!inner.Empty() {
-			return errors.New("sm9: invalid sign master public key asn1 data")
+			return newError(ErrInvalidPublicKey, "sm9: invalid sign master public key asn1 data")
 		}
 	} else if !input.ReadASN1BitStringAsBytes(&bytes) || !input.Empty() {
-		return errors.New("sm9: invalid sign master public key asn1 data")
+		return newError(ErrInvalidPublicKey, "sm9: invalid sign master public key asn1 data")
 	}
 	return pub.UnmarshalRaw(bytes)
 }
@@ -252,7 +285,7 @@ func (pub *SignMasterPublicKey) UnmarshalASN1(der []byte) error {
 func (pub *SignMasterPublicKey) ParseFromPEM(data []byte) error {
 	block, _ := pem.Decode([]byte(data))
 	if block == nil {
-		return errors.New("sm9: failed to parse PEM block")
+		return newError(ErrInvalidPublicKey, "sm9: failed to parse PEM block")
 	}
 	return pub.UnmarshalASN1(block.Bytes)
 }
@@ -291,15 +324,15 @@ func unmarshalG1(bytes []byte) (*bn256.G1, error) {
 	case 4:
 		_, err := g.Unmarshal(bytes[1:])
 		if err != nil {
-			return nil, err
+			return nil, pointError(err)
 		}
 	case 2, 3:
 		_, err := g.UnmarshalCompressed(bytes)
 		if err != nil {
-			return nil, err
+			return nil, pointError(err)
 		}
 	default:
-		return nil, errors.New("sm9: invalid point identity byte")
+		return nil, newError(ErrPointNotOnCurve, "sm9: invalid point identity byte")
 	}
 	return g, nil
 }
@@ -326,13 +359,13 @@ func (priv *SignPrivateKey) UnmarshalASN1(der []byte) error {
 		if !input.ReadASN1(&inner, cryptobyte_asn1.SEQUENCE) ||
 			!input.Empty() ||
 			!inner.ReadASN1BitStringAsBytes(&bytes) {
-			return errors.New("sm9: invalid sign user private key asn1 data")
+			return newError(ErrInvalidPrivateKey, "sm9: invalid sign user private key asn1 data")
 		}
 		if !inner.Empty() && (!inner.ReadASN1BitStringAsBytes(&pubBytes) || !inner.Empty()) {
-			return errors.New("sm9: invalid sign master public key asn1 data")
+			return newError(ErrInvalidPublicKey, "sm9: invalid sign master public key asn1 data")
 		}
 	} else if !input.ReadASN1BitStringAsBytes(&bytes) || !input.Empty() {
-		return errors.New("sm9: invalid sign user private key asn1 data")
+		return newError(ErrInvalidPrivateKey, "sm9: invalid sign user private key asn1 data")
 	}
 	err := priv.UnmarshalRaw(bytes)
 	if err != nil {
@@ -376,14 +409,14 @@ func (master *EncryptMasterPrivateKey) GenerateUserKey(uid []byte, hid byte) (*E
 
 	t1Nat := hashH1(id)
 
-	d, err := bigmod.NewNat().SetBytes(master.D.Bytes(), orderNat)
+	d, err := masterKeyNat(master.D)
 	if err != nil {
 		return nil, err
 	}
 
 	t1Nat.Add(d, orderNat)
 	if t1Nat.IsZero() == 1 {
-		return nil, errors.New("sm9: need to re-generate encrypt master private key")
+		return nil, newError(ErrInvalidPrivateKey, "sm9: need to re-generate encrypt master private key")
 	}
 
 	t1Nat = bigmod.NewNat().Exp(t1Nat, orderMinus2, orderNat)
@@ -423,14 +456,14 @@ func (master *EncryptMasterPrivateKey) UnmarshalASN1(der []byte) error {
 		if !input.ReadASN1(&inner, cryptobyte_asn1.SEQUENCE) ||
 			!input.Empty() ||
 			!inner.ReadASN1Integer(d) {
-			return errors.New("sm9: invalid encrypt master private key asn1 data")
+			return newError(ErrInvalidPrivateKey, "sm9: invalid encrypt master private key asn1 data")
 		}
 		// Just parse it, did't validate it
 		if !inner.Empty() && (!inner.ReadASN1BitStringAsBytes(&pubBytes) || !inner.Empty()) {
-			return errors.New("sm9: invalid encrypt master public key asn1 data")
+			return newError(ErrInvalidPublicKey, "sm9: invalid encrypt master public key asn1 data")
 		}
 	} else if !input.ReadASN1Integer(d) || !input.Empty() {
-		return errors.New("sm9: invalid encrypt master private key asn1 data")
+		return newError(ErrInvalidPrivateKey, "sm9: invalid encrypt master private key asn1 data")
 	}
 	master.D = d
 	master.EncryptMasterPublicKey = new(EncryptMasterPublicKey)
@@ -450,9 +483,15 @@ func (pub *EncryptMasterPublicKey) pair() *bn256.GT {
 	return pub.basePoint
 }
 
+// hasGeneratorTable reports whether the table of generatorTable was built.
+func (pub *EncryptMasterPublicKey) hasGeneratorTable() bool {
+	return atomic.LoadUint32(&pub.tableBuilt) == 1
+}
+
 func (pub *EncryptMasterPublicKey) generatorTable() *[32 * 2]bn256.GTFieldTable {
 	pub.tableGenOnce.Do(func() {
 		pub.table = bn256.GenerateGTFieldTable(pub.pair())
+		atomic.StoreUint32(&pub.tableBuilt, 1)
 	})
 	return pub.table
 }
@@ -460,6 +499,9 @@ func (pub *EncryptMasterPublicKey) generatorTable() *[32 * 2]bn256.GTFieldTable
 // ScalarBaseMult compute basepoint^r with precomputed table.
 // The base point = pair(<master public key>, Gen2)
 func (pub *EncryptMasterPublicKey) ScalarBaseMult(scalar []byte) (*bn256.GT, error) {
+	if LowMemory() && !pub.hasGeneratorTable() {
+		return bn256.ScalarMultGT(pub.pair(), scalar)
+	}
 	tables := pub.generatorTable()
 	return bn256.ScalarBaseMultGT(tables, scalar)
 }
@@ -508,7 +550,7 @@ func (pub *EncryptMasterPublicKey) UnmarshalRaw(bytes []byte) error {
 func (pub *EncryptMasterPublicKey) ParseFromPEM(data []byte) error {
 	block, _ := pem.Decode([]byte(data))
 	if block == nil {
-		return errors.New("sm9: failed to parse PEM block")
+		return newError(ErrInvalidPublicKey, "sm9: failed to parse PEM block")
 	}
 	return pub.UnmarshalASN1(block.Bytes)
 }
@@ -523,14 +565,28 @@ func (pub *EncryptMasterPublicKey) UnmarshalASN1(der []byte) error {
 			!input.Empty() ||
 			!inner.ReadASN1BitStringAsBytes(&bytes) ||
 			!inner.Empty() {
-			return errors.New("sm9: invalid encrypt master public key asn1 data")
+			return newError(ErrInvalidPublicKey, "sm9: invalid encrypt master public key asn1 data")
 		}
 	} else if !input.ReadASN1BitStringAsBytes(&bytes) || !input.Empty() {
-		return errors.New("sm9: invalid encrypt master public key asn1 data")
+		return newError(ErrInvalidPublicKey, "sm9: invalid encrypt master public key asn1 data")
 	}
 	return pub.UnmarshalRaw(bytes)
 }
 
+// pair returns e(g1, PrivateKey) with the precomputed Miller loop lines of the
+// private key, about 27KB, which are not built in the low-memory profile.
+func (priv *EncryptPrivateKey) pair(g1 *bn256.G1) *bn256.GT {
+	p := (*preparedKey)(atomic.LoadPointer(&priv.prepared))
+	if p == nil || p.key != priv.PrivateKey {
+		if LowMemory() {
+			return bn256.Pair(g1, priv.PrivateKey)
+		}
+		p = &preparedKey{priv.PrivateKey, bn256.PairingPreCompute(priv.PrivateKey)}
+		atomic.StorePointer(&priv.prepared, unsafe.Pointer(p))
+	}
+	return bn256.PairPrecomputed(g1, p.lines)
+}
+
 // MasterPublic returns the master public key corresponding to priv.
 func (priv *EncryptPrivateKey) MasterPublic() *EncryptMasterPublicKey {
 	return priv.EncryptMasterPublicKey
@@ -581,13 +637,13 @@ func (priv *EncryptPrivateKey) UnmarshalASN1(der []byte) error {
 		if !input.ReadASN1(&inner, cryptobyte_asn1.SEQUENCE) ||
 			!input.Empty() ||
 			!inner.ReadASN1BitStringAsBytes(&bytes) {
-			return errors.New("sm9: invalid encrypt user private key asn1 data")
+			return newError(ErrInvalidPrivateKey, "sm9: invalid encrypt user private key asn1 data")
 		}
 		if !inner.Empty() && (!inner.ReadASN1BitStringAsBytes(&pubBytes) || !inner.Empty()) {
-			return errors.New("sm9: invalid encrypt master public key asn1 data")
+			return newError(ErrInvalidPublicKey, "sm9: invalid encrypt master public key asn1 data")
 		}
 	} else if !input.ReadASN1BitStringAsBytes(&bytes) || !input.Empty() {
-		return errors.New("sm9: invalid encrypt user private key asn1 data")
+		return newError(ErrInvalidPrivateKey, "sm9: invalid encrypt user private key asn1 data")
 	}
 	err := priv.UnmarshalRaw(bytes)
 	if err != nil {
@@ -603,3 +659,51 @@ func (priv *EncryptPrivateKey) UnmarshalASN1(der []byte) error {
 	}
 	return nil
 }
+
+// ValidateSignUserKey checks that priv is the sign user private key of the
+// identity uid || hid issued by the KGC of the trusted master public key pub,
+// that is e(priv, [H1(uid || hid, N)]P2 + Ppub-s) = e(P1, Ppub-s). It should
+// be called when a user key received from a KGC is imported, a corrupted or
+// mismatched key would otherwise produce signatures which never verify.
+//
+// The master public key bound to priv, if any, must be pub.
+func ValidateSignUserKey(priv *SignPrivateKey, uid []byte, hid byte, pub *SignMasterPublicKey) error {
+	if pub == nil || pub.MasterPublicKey == nil || pub.MasterPublicKey.IsInfinity() {
+		return newError(ErrInvalidPublicKey, "sm9: invalid sign master public key")
+	}
+	if priv == nil || priv.PrivateKey == nil || priv.PrivateKey.IsInfinity() {
+		return newError(ErrInvalidPrivateKey, "sm9: invalid sign user private key")
+	}
+	if bound := priv.SignMasterPublicKey; bound != nil && bound.MasterPublicKey != nil &&
+		!bound.MasterPublicKey.Equal(pub.MasterPublicKey) {
+		return newError(ErrInvalidPrivateKey, "sm9: sign user private key is bound to another master public key")
+	}
+	if !bn256.Pair(priv.PrivateKey, pub.GenerateUserPublicKey(uid, hid)).Equal(pub.pair()) {
+		return newError(ErrInvalidPrivateKey, "sm9: sign user private key doesn't match the identity and master public key")
+	}
+	return nil
+}
+
+// ValidateEncryptUserKey checks that priv is the encrypt user private key of
+// the identity uid || hid issued by the KGC of the trusted master public key
+// pub, that is e([H1(uid || hid, N)]P1 + Ppub-e, priv) = e(Ppub-e, P2). It
+// should be called when a user key received from a KGC is imported, a
+// corrupted or mismatched key would otherwise fail to decrypt.
+//
+// The master public key bound to priv, if any, must be pub.
+func ValidateEncryptUserKey(priv *EncryptPrivateKey, uid []byte, hid byte, pub *EncryptMasterPublicKey) error {
+	if pub == nil || pub.MasterPublicKey == nil || pub.MasterPublicKey.IsInfinity() {
+		return newError(ErrInvalidPublicKey, "sm9: invalid encrypt master public key")
+	}
+	if priv == nil || priv.PrivateKey == nil || priv.PrivateKey.IsInfinity() {
+		return newError(ErrInvalidPrivateKey, "sm9: invalid encrypt user private key")
+	}
+	if bound := priv.EncryptMasterPublicKey; bound != nil && bound.MasterPublicKey != nil &&
+		!bound.MasterPublicKey.Equal(pub.MasterPublicKey) {
+		return newError(ErrInvalidPrivateKey, "sm9: encrypt user private key is bound to another master public key")
+	}
+	if !bn256.Pair(pub.GenerateUserPublicKey(uid, hid), priv.PrivateKey).Equal(pub.pair()) {
+		return newError(ErrInvalidPrivateKey, "sm9: encrypt user private key doesn't match the identity and master public key")
+	}
+	return nil
+}
@@ -4,8 +4,10 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/pem"
+	"errors"
 	"testing"
 
+	"github.com/emmansun/gmsm/sm9/bn256"
 	"golang.org/x/crypto/cryptobyte"
 	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
 )
@@ -329,3 +331,88 @@ func TestParseSM9EncryptMasterPublicKey(t *testing.T) {
 		t.Fatalf("failed %s\n", pemContent)
 	}
 }
+
+func TestValidateSignUserKey(t *testing.T) {
+	masterKey, err := GenerateSignMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherMaster, err := GenerateSignMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := []byte("emmansun")
+	hid := byte(0x01)
+	userKey, err := masterKey.GenerateUserKey(uid, hid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := userKey.MarshalASN1()
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported := new(SignPrivateKey)
+	if err := imported.UnmarshalASN1(der); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateSignUserKey(imported, uid, hid, masterKey.Public()); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateSignUserKey(imported, []byte("emmansun2"), hid, masterKey.Public()); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("other uid: got %v", err)
+	}
+	if err := ValidateSignUserKey(imported, uid, 0x02, masterKey.Public()); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("other hid: got %v", err)
+	}
+	if err := ValidateSignUserKey(imported, uid, hid, otherMaster.Public()); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("other master public key: got %v", err)
+	}
+	if err := ValidateSignUserKey(userKey, uid, hid, otherMaster.Public()); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("bound to other master public key: got %v", err)
+	}
+	corrupted := &SignPrivateKey{PrivateKey: new(bn256.G1).Add(userKey.PrivateKey, bn256.Gen1)}
+	if err := ValidateSignUserKey(corrupted, uid, hid, masterKey.Public()); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("corrupted key: got %v", err)
+	}
+	if err := ValidateSignUserKey(userKey, uid, hid, nil); !errors.Is(err, ErrInvalidPublicKey) {
+		t.Errorf("nil master public key: got %v", err)
+	}
+}
+
+func TestValidateEncryptUserKey(t *testing.T) {
+	masterKey, err := GenerateEncryptMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherMaster, err := GenerateEncryptMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := []byte("emmansun")
+	hid := byte(0x03)
+	userKey, err := masterKey.GenerateUserKey(uid, hid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := userKey.MarshalASN1()
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported := new(EncryptPrivateKey)
+	if err := imported.UnmarshalASN1(der); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateEncryptUserKey(imported, uid, hid, masterKey.Public()); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateEncryptUserKey(imported, []byte("emmansun2"), hid, masterKey.Public()); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("other uid: got %v", err)
+	}
+	if err := ValidateEncryptUserKey(imported, uid, hid, otherMaster.Public()); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("other master public key: got %v", err)
+	}
+	corrupted := &EncryptPrivateKey{PrivateKey: new(bn256.G2).Add(userKey.PrivateKey, bn256.Gen2)}
+	if err := ValidateEncryptUserKey(corrupted, uid, hid, masterKey.Public()); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("corrupted key: got %v", err)
+	}
+}
@@ -1,8 +1,10 @@
 package sm9
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"math/big"
 	"testing"
 
@@ -137,6 +139,8 @@ func TestParseInvalidASN1(t *testing.T) {
 		// TODO: Add test cases.
 		{"invalid point format", "30660420723a8b38dd2441c2aa1c3ec092eaa34996c53bf9ca7515272395c012ab6e6e070342000C389fc45b711d9dfd9d91958f64d89d3528cf577c6dc2bc792c2969188e76865e16c2d85419f8f923a0e77c7f269c0eeb97b6c4d7e2735189180ec719a380fe1d"},
 		{"invalid point encoding length", "30660420723a8b38dd2441c2aa1c3ec092eaa34996c53bf9ca7515272395c012ab6e6e0703420004389fc45b711d9dfd9d91958f64d89d3528cf577c6dc2bc792c2969188e76865e16c2d85419f8f923a0e77c7f269c0eeb97b6c4d7e2735189180ec719a380fe"},
+		// crasher found by fuzzing: empty point bit string
+		{"empty point", "30250420723a8b38dd2441c2aa1c3ec092eaa34996c53bf9ca7515272395c012ab6e6e07030100"},
 	}
 	for _, tt := range tests {
 		sig, err := hex.DecodeString(tt.sigHex)
@@ -352,6 +356,62 @@ func TestKeyExchange(t *testing.T) {
 	}
 }
 
+func TestKeyExchangeSetPeerUID(t *testing.T) {
+	hid := byte(0x02)
+	client := []byte("client@example.com")
+	server := []byte("tlcp.example.com")
+	masterKey, err := GenerateEncryptMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientKey, err := masterKey.GenerateUserKey(client, hid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverKey, err := masterKey.GenerateUserKey(server, hid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the server learns the client identity during the handshake
+	initiator := NewKeyExchange(clientKey, client, nil, 48, false)
+	responder := NewKeyExchange(serverKey, server, nil, 48, false)
+	defer func() {
+		initiator.Destroy()
+		responder.Destroy()
+	}()
+	if _, err = initiator.InitKeyExchange(rand.Reader, hid); !errors.Is(err, ErrKeyExchange) {
+		t.Fatalf("got %v, expected ErrKeyExchange without peer uid", err)
+	}
+	if err = initiator.SetPeerUID(server); err != nil {
+		t.Fatal(err)
+	}
+	if err = initiator.SetPeerUID(server); !errors.Is(err, ErrKeyExchange) {
+		t.Fatalf("got %v, expected ErrKeyExchange for second SetPeerUID", err)
+	}
+	rA, err := initiator.InitKeyExchange(rand.Reader, hid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = responder.SetPeerUID(client); err != nil {
+		t.Fatal(err)
+	}
+	rB, _, err := responder.RepondKeyExchange(rand.Reader, hid, rA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key1, _, err := initiator.ConfirmResponder(rB, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key2, err := responder.ConfirmInitiator(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key1) != 48 || !bytes.Equal(key1, key2) {
+		t.Errorf("got different pre-master secrets")
+	}
+}
+
 func TestKeyExchangeWithoutSignature(t *testing.T) {
 	hid := byte(0x02)
 	userA := []byte("Alice")
@@ -759,6 +819,54 @@ func TestEncryptDecrypt(t *testing.T) {
 	}
 }
 
+func TestDecryptAfterUnmarshal(t *testing.T) {
+	masterKey, err := GenerateEncryptMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("Chinese IBE standard")
+	hid := byte(0x01)
+	uidA, uidB := []byte("alice"), []byte("bob")
+	keyA, err := masterKey.GenerateUserKey(uidA, hid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyB, err := masterKey.GenerateUserKey(uidB, hid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	derB, err := keyB.MarshalASN1()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cipherA, err := Encrypt(rand.Reader, masterKey.Public(), uidA, hid, plaintext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cipherB, err := Encrypt(rand.Reader, masterKey.Public(), uidB, hid, plaintext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Decrypt(keyA, uidA, cipherA, nil); err != nil {
+		t.Fatal(err)
+	}
+	// keyA now holds the Miller loop lines of A, which must not be reused for B
+	if err := keyA.UnmarshalASN1(derB); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decrypt(keyA, uidB, cipherB, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(plaintext) {
+		t.Errorf("expected %v, got %v", string(plaintext), string(got))
+	}
+	if _, err := Decrypt(keyA, uidA, cipherA, nil); err == nil {
+		t.Error("ciphertext of the replaced key decrypted")
+	}
+}
+
 func TestEncryptEmptyPlaintext(t *testing.T) {
 	masterKey, err := GenerateEncryptMasterKey(rand.Reader)
 	hid := byte(0x01)
@@ -948,3 +1056,76 @@ func BenchmarkDecryptASN1(b *testing.B) {
 		}
 	}
 }
+
+func TestAppendSignASN1(t *testing.T) {
+	masterKey, err := GenerateSignMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := []byte("Chinese IBS standard")
+	uid := []byte("emmansun")
+	hid := byte(0x01)
+	userKey, err := masterKey.GenerateUserKey(uid, hid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := []byte("prefix")
+	buf := make([]byte, len(prefix), 256)
+	copy(buf, prefix)
+	out, err := AppendSignASN1(buf, rand.Reader, userKey, hashed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out[:len(prefix)]) != string(prefix) {
+		t.Fatalf("prefix was overwritten")
+	}
+	if !masterKey.Public().Verify(uid, hid, hashed, out[len(prefix):]) {
+		t.Errorf("Verify failed")
+	}
+}
+
+func TestAppendEncrypt(t *testing.T) {
+	masterKey, err := GenerateEncryptMasterKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("Chinese IBE standard")
+	uid := []byte("emmansun")
+	hid := byte(0x01)
+	userKey, err := masterKey.GenerateUserKey(uid, hid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := []byte("prefix")
+	buf := make([]byte, len(prefix), 256)
+	copy(buf, prefix)
+	out, err := AppendEncrypt(buf, rand.Reader, masterKey.Public(), uid, hid, plaintext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out[:len(prefix)]) != string(prefix) {
+		t.Fatalf("prefix was overwritten")
+	}
+	got, err := Decrypt(userKey, uid, out[len(prefix):], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(plaintext) {
+		t.Errorf("expected %v, got %v", string(plaintext), string(got))
+	}
+
+	out, err = AppendEncryptASN1(buf[:len(prefix)], rand.Reader, masterKey.Public(), uid, hid, plaintext, SM4CBCEncrypterOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out[:len(prefix)]) != string(prefix) {
+		t.Fatalf("prefix was overwritten")
+	}
+	got, err = DecryptASN1(userKey, uid, out[len(prefix):])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(plaintext) {
+		t.Errorf("expected %v, got %v", string(plaintext), string(got))
+	}
+}
@@ -0,0 +1,245 @@
+package smage
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/emmansun/gmsm/ecdh"
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	sm2StanzaType        = "SM2"
+	sm2Label             = "smage/v1/SM2"
+	passphraseStanzaType = "pbkdf2-sm3"
+	passphraseLabel      = "smage/v1/pbkdf2-sm3"
+	passphraseSaltSize   = 16
+
+	// DefaultIterations is the default PBKDF2 iteration count of passphrase
+	// recipients.
+	DefaultIterations = 1 << 18
+	// maxIterations bounds the work of a passphrase identity.
+	maxIterations = 1 << 24
+)
+
+// wrappedKeySize is the size of a SM4-GCM sealed file key.
+const wrappedKeySize = fileKeySize + tagSize
+
+// zeroNonce is used with keys which encrypt only one file key.
+var zeroNonce [12]byte
+
+func sealFileKey(key, fileKey []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, zeroNonce[:], fileKey, nil), nil
+}
+
+// openFileKey returns ErrIncorrectIdentity if the body can't be opened with
+// key, as the key is derived from the identity.
+func openFileKey(key, body []byte) ([]byte, error) {
+	if len(body) != wrappedKeySize {
+		return nil, errors.New("smage: invalid stanza body size")
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	fileKey, err := aead.Open(nil, zeroNonce[:], body, nil)
+	if err != nil {
+		return nil, ErrIncorrectIdentity
+	}
+	return fileKey, nil
+}
+
+// SM2Recipient is a SM2 public key recipient. The file key is wrapped with a
+// key derived from the ECDH shared secret of an ephemeral key and the
+// recipient key.
+type SM2Recipient struct {
+	pub *ecdh.PublicKey
+}
+
+// NewSM2Recipient returns a recipient of the SM2 public key pub.
+func NewSM2Recipient(pub *ecdsa.PublicKey) (*SM2Recipient, error) {
+	if !sm2.IsSM2PublicKey(pub) {
+		return nil, errors.New("smage: not a SM2 public key")
+	}
+	k, err := sm2.PublicKeyToECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+	return &SM2Recipient{pub: k}, nil
+}
+
+func sm2WrapKey(shared, ephemeral, recipient []byte) []byte {
+	salt := make([]byte, 0, len(ephemeral)+len(recipient))
+	salt = append(salt, ephemeral...)
+	salt = append(salt, recipient...)
+	return hkdfKey(shared, salt, sm2Label, fileKeySize)
+}
+
+// Wrap implements Recipient.
+func (r *SM2Recipient) Wrap(fileKey []byte) ([]*Stanza, error) {
+	ephemeral, err := ecdh.P256().GenerateKey(randutil.Reader())
+	if err != nil {
+		return nil, err
+	}
+	shared, err := ephemeral.ECDH(r.pub)
+	if err != nil {
+		return nil, err
+	}
+	ephemeralBytes := ephemeral.PublicKey().Bytes()
+	body, err := sealFileKey(sm2WrapKey(shared, ephemeralBytes, r.pub.Bytes()), fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return []*Stanza{{Type: sm2StanzaType, Args: []string{b64.EncodeToString(ephemeralBytes)}, Body: body}}, nil
+}
+
+// SM2Identity is the SM2 private key of a SM2Recipient.
+type SM2Identity struct {
+	priv *ecdh.PrivateKey
+}
+
+// NewSM2Identity returns the identity of the SM2 private key priv.
+func NewSM2Identity(priv *sm2.PrivateKey) (*SM2Identity, error) {
+	k, err := priv.ECDH()
+	if err != nil {
+		return nil, err
+	}
+	return &SM2Identity{priv: k}, nil
+}
+
+// Recipient returns the recipient of i.
+func (i *SM2Identity) Recipient() *SM2Recipient {
+	return &SM2Recipient{pub: i.priv.PublicKey()}
+}
+
+// Unwrap implements Identity.
+func (i *SM2Identity) Unwrap(stanzas []*Stanza) ([]byte, error) {
+	for _, s := range stanzas {
+		if s.Type != sm2StanzaType {
+			continue
+		}
+		if len(s.Args) != 1 {
+			return nil, errors.New("smage: invalid SM2 recipient stanza")
+		}
+		ephemeralBytes, err := b64.Strict().DecodeString(s.Args[0])
+		if err != nil {
+			return nil, errors.New("smage: invalid SM2 recipient stanza")
+		}
+		ephemeral, err := ecdh.P256().NewPublicKey(ephemeralBytes)
+		if err != nil {
+			return nil, fmt.Errorf("smage: invalid SM2 recipient stanza: %w", err)
+		}
+		shared, err := i.priv.ECDH(ephemeral)
+		if err != nil {
+			return nil, err
+		}
+		fileKey, err := openFileKey(sm2WrapKey(shared, ephemeralBytes, i.priv.PublicKey().Bytes()), s.Body)
+		if errors.Is(err, ErrIncorrectIdentity) {
+			continue
+		}
+		return fileKey, err
+	}
+	return nil, ErrIncorrectIdentity
+}
+
+func passphraseKey(passphrase string, salt []byte, iterations int) []byte {
+	labeledSalt := make([]byte, 0, len(passphraseLabel)+len(salt))
+	labeledSalt = append(labeledSalt, passphraseLabel...)
+	labeledSalt = append(labeledSalt, salt...)
+	return pbkdf2.Key([]byte(passphrase), labeledSalt, iterations, fileKeySize, sm3.New)
+}
+
+// PassphraseRecipient is a passphrase recipient, the file key is wrapped with a
+// key derived with PBKDF2-HMAC-SM3. It can't be used with other recipients.
+type PassphraseRecipient struct {
+	passphrase string
+	iterations int
+}
+
+// NewPassphraseRecipient returns a recipient of passphrase with
+// DefaultIterations.
+func NewPassphraseRecipient(passphrase string) (*PassphraseRecipient, error) {
+	if passphrase == "" {
+		return nil, errors.New("smage: empty passphrase")
+	}
+	return &PassphraseRecipient{passphrase: passphrase, iterations: DefaultIterations}, nil
+}
+
+// SetIterations sets the PBKDF2 iteration count, which must be between 1000
+// and 2^24.
+func (r *PassphraseRecipient) SetIterations(iterations int) error {
+	if iterations < 1000 || iterations > maxIterations {
+		return fmt.Errorf("smage: invalid iteration count %d", iterations)
+	}
+	r.iterations = iterations
+	return nil
+}
+
+// Wrap implements Recipient.
+func (r *PassphraseRecipient) Wrap(fileKey []byte) ([]*Stanza, error) {
+	salt := make([]byte, passphraseSaltSize)
+	if _, err := io.ReadFull(randutil.Reader(), salt); err != nil {
+		return nil, err
+	}
+	body, err := sealFileKey(passphraseKey(r.passphrase, salt, r.iterations), fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return []*Stanza{{
+		Type: passphraseStanzaType,
+		Args: []string{b64.EncodeToString(salt), strconv.Itoa(r.iterations)},
+		Body: body,
+	}}, nil
+}
+
+// PassphraseIdentity is the passphrase of a PassphraseRecipient.
+type PassphraseIdentity struct {
+	passphrase string
+}
+
+// NewPassphraseIdentity returns the identity of passphrase.
+func NewPassphraseIdentity(passphrase string) (*PassphraseIdentity, error) {
+	if passphrase == "" {
+		return nil, errors.New("smage: empty passphrase")
+	}
+	return &PassphraseIdentity{passphrase: passphrase}, nil
+}
+
+// Unwrap implements Identity.
+func (i *PassphraseIdentity) Unwrap(stanzas []*Stanza) ([]byte, error) {
+	for _, s := range stanzas {
+		if s.Type != passphraseStanzaType {
+			continue
+		}
+		if len(stanzas) != 1 {
+			return nil, errors.New("smage: a passphrase stanza must be the only stanza")
+		}
+		if len(s.Args) != 2 {
+			return nil, errors.New("smage: invalid passphrase recipient stanza")
+		}
+		salt, err := b64.Strict().DecodeString(s.Args[0])
+		if err != nil || len(salt) != passphraseSaltSize {
+			return nil, errors.New("smage: invalid passphrase recipient stanza")
+		}
+		iterations, err := strconv.Atoi(s.Args[1])
+		if err != nil || iterations < 1 || iterations > maxIterations || s.Args[1] != strconv.Itoa(iterations) {
+			return nil, errors.New("smage: invalid passphrase recipient stanza")
+		}
+		fileKey, err := openFileKey(passphraseKey(i.passphrase, salt, iterations), s.Body)
+		if errors.Is(err, ErrIncorrectIdentity) {
+			return nil, fmt.Errorf("%w: incorrect passphrase", ErrIncorrectIdentity)
+		}
+		return fileKey, err
+	}
+	return nil, ErrIncorrectIdentity
+}
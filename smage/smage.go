@@ -0,0 +1,315 @@
+// Package smage implements a file encryption format in the style of age
+// (https://age-encryption.org/v1) on the ShangMi algorithms: a random file key
+// is wrapped in the header for every recipient, and the payload is encrypted
+// with SM4-GCM in 64 KiB chunks, so that files of any size can be encrypted
+// and decrypted in a streaming fashion.
+//
+// The recipients are SM2 public keys, with an ephemeral-static ECDH key
+// encapsulation, or a passphrase, with PBKDF2-HMAC-SM3. The format is not
+// interoperable with age.
+//
+// An encrypted file is
+//
+//	smage/v1
+//	-> SM2 <ephemeral public key>
+//	<wrapped file key>
+//	-> pbkdf2-sm3 <salt> <iterations>
+//	<wrapped file key>
+//	--- <HMAC-SM3 of the header>
+//	<16 bytes nonce><payload>
+//
+// where the stanza arguments and bodies are base64 encoded without padding,
+// the bodies wrapped at 64 columns, like age.
+package smage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/emmansun/gmsm/internal/randutil"
+	"github.com/emmansun/gmsm/sm3"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	version        = "smage/v1"
+	fileKeySize    = 16
+	nonceSize      = 16
+	columnsPerLine = 64
+)
+
+var b64 = base64.RawStdEncoding
+
+// Stanza is a recipient entry of the header, with its type, its arguments and
+// its body, which is typically the wrapped file key.
+type Stanza struct {
+	Type string
+	Args []string
+	Body []byte
+}
+
+// A Recipient wraps the file key for one or more stanzas.
+type Recipient interface {
+	Wrap(fileKey []byte) ([]*Stanza, error)
+}
+
+// An Identity unwraps the file key from the stanzas of the header. It returns
+// ErrIncorrectIdentity if none of the stanzas is for it.
+type Identity interface {
+	Unwrap(stanzas []*Stanza) ([]byte, error)
+}
+
+// ErrIncorrectIdentity is returned by Identity.Unwrap when the identity is not
+// a recipient of the file.
+var ErrIncorrectIdentity = errors.New("smage: incorrect identity for recipient stanza")
+
+// NoIdentityMatchError is returned by Decrypt when none of the identities is a
+// recipient of the file.
+type NoIdentityMatchError struct {
+	// Errors are the errors of the identities, one per identity.
+	Errors []error
+}
+
+func (e *NoIdentityMatchError) Error() string {
+	if len(e.Errors) == 1 {
+		return "smage: no identity matched any of the recipients: " + e.Errors[0].Error()
+	}
+	return "smage: no identity matched any of the recipients"
+}
+
+func hkdfKey(secret, salt []byte, info string, size int) []byte {
+	key := make([]byte, size)
+	if _, err := io.ReadFull(hkdf.New(sm3.New, secret, salt, []byte(info)), key); err != nil {
+		panic("smage: internal error: hkdf failed: " + err.Error())
+	}
+	return key
+}
+
+// Encrypt encrypts a file to one or more recipients. Writes to the returned
+// io.WriteCloser are encrypted and written to dst, Close must be called to
+// flush the last chunk, it doesn't close dst.
+func Encrypt(dst io.Writer, recipients ...Recipient) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("smage: no recipients specified")
+	}
+	fileKey := make([]byte, fileKeySize)
+	if _, err := io.ReadFull(randutil.Reader(), fileKey); err != nil {
+		return nil, err
+	}
+	var stanzas []*Stanza
+	for _, r := range recipients {
+		s, err := r.Wrap(fileKey)
+		if err != nil {
+			return nil, fmt.Errorf("smage: failed to wrap key for recipient: %w", err)
+		}
+		stanzas = append(stanzas, s...)
+	}
+	for _, s := range stanzas {
+		if s.Type == passphraseStanzaType && len(stanzas) != 1 {
+			return nil, errors.New("smage: a passphrase recipient can't be used with other recipients")
+		}
+	}
+
+	header, err := marshalHeader(stanzas, fileKey)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dst.Write(header); err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(randutil.Reader(), nonce); err != nil {
+		return nil, err
+	}
+	if _, err := dst.Write(nonce); err != nil {
+		return nil, err
+	}
+	return newWriter(payloadKey(fileKey, nonce), dst)
+}
+
+// Decrypt decrypts a file encrypted to one or more identities. Reads from the
+// returned io.Reader return the decrypted and authenticated plaintext, an error
+// is returned if the payload is truncated or tampered with.
+func Decrypt(src io.Reader, identities ...Identity) (io.Reader, error) {
+	if len(identities) == 0 {
+		return nil, errors.New("smage: no identities specified")
+	}
+	br := bufio.NewReader(src)
+	stanzas, mac, macInput, err := parseHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range stanzas {
+		if s.Type == passphraseStanzaType && len(stanzas) != 1 {
+			return nil, errors.New("smage: a passphrase stanza must be the only stanza")
+		}
+	}
+
+	var fileKey []byte
+	var errs []error
+	for _, id := range identities {
+		fileKey, err = id.Unwrap(stanzas)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrIncorrectIdentity) {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+	if fileKey == nil {
+		return nil, &NoIdentityMatchError{Errors: errs}
+	}
+	if !hmac.Equal(headerMAC(fileKey, macInput), mac) {
+		return nil, errors.New("smage: bad header MAC")
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(br, nonce); err != nil {
+		return nil, fmt.Errorf("smage: failed to read nonce: %w", err)
+	}
+	return newReader(payloadKey(fileKey, nonce), br)
+}
+
+func payloadKey(fileKey, nonce []byte) []byte {
+	return hkdfKey(fileKey, nonce, "payload", fileKeySize)
+}
+
+func headerMAC(fileKey, header []byte) []byte {
+	h := hmac.New(sm3.New, hkdfKey(fileKey, nil, "header", sm3.Size))
+	h.Write(header)
+	return h.Sum(nil)
+}
+
+func validString(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < 33 || c > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+func marshalHeader(stanzas []*Stanza, fileKey []byte) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(version + "\n")
+	for _, s := range stanzas {
+		if !validString(s.Type) {
+			return nil, errors.New("smage: invalid stanza type")
+		}
+		b.WriteString("-> " + s.Type)
+		for _, arg := range s.Args {
+			if !validString(arg) {
+				return nil, errors.New("smage: invalid stanza argument")
+			}
+			b.WriteString(" " + arg)
+		}
+		b.WriteString("\n")
+		body := b64.EncodeToString(s.Body)
+		for len(body) >= columnsPerLine {
+			b.WriteString(body[:columnsPerLine] + "\n")
+			body = body[columnsPerLine:]
+		}
+		// the last line is always shorter than a full line, maybe empty
+		b.WriteString(body + "\n")
+	}
+	b.WriteString("---")
+	mac := headerMAC(fileKey, b.Bytes())
+	b.WriteString(" " + b64.EncodeToString(mac) + "\n")
+	return b.Bytes(), nil
+}
+
+const maxHeaderLine = 4096
+
+func readLine(br *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := br.ReadLine()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", err
+		}
+		line = append(line, chunk...)
+		if len(line) > maxHeaderLine {
+			return "", errors.New("smage: header line too long")
+		}
+		if !isPrefix {
+			return string(line), nil
+		}
+	}
+}
+
+// parseHeader parses the header and returns its stanzas, its MAC and the
+// authenticated part of it.
+func parseHeader(br *bufio.Reader) (stanzas []*Stanza, mac, macInput []byte, err error) {
+	var raw bytes.Buffer
+	line, err := readLine(br)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("smage: failed to read header: %w", err)
+	}
+	if line != version {
+		return nil, nil, nil, fmt.Errorf("smage: unexpected version line %q", line)
+	}
+	raw.WriteString(line + "\n")
+	for {
+		line, err = readLine(br)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("smage: failed to read header: %w", err)
+		}
+		if strings.HasPrefix(line, "---") {
+			rest := strings.TrimPrefix(line, "---")
+			if !strings.HasPrefix(rest, " ") {
+				return nil, nil, nil, errors.New("smage: malformed header MAC line")
+			}
+			if mac, err = b64.Strict().DecodeString(rest[1:]); err != nil || len(mac) != sm3.Size {
+				return nil, nil, nil, errors.New("smage: malformed header MAC")
+			}
+			raw.WriteString("---")
+			if len(stanzas) == 0 {
+				return nil, nil, nil, errors.New("smage: no recipient stanza")
+			}
+			return stanzas, mac, raw.Bytes(), nil
+		}
+		raw.WriteString(line + "\n")
+		args := strings.Split(line, " ")
+		if len(args) < 2 || args[0] != "->" {
+			return nil, nil, nil, fmt.Errorf("smage: malformed stanza opening line %q", line)
+		}
+		for _, arg := range args[1:] {
+			if !validString(arg) {
+				return nil, nil, nil, fmt.Errorf("smage: malformed stanza opening line %q", line)
+			}
+		}
+		s := &Stanza{Type: args[1], Args: args[2:]}
+		for {
+			line, err = readLine(br)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("smage: failed to read header: %w", err)
+			}
+			raw.WriteString(line + "\n")
+			if len(line) > columnsPerLine {
+				return nil, nil, nil, errors.New("smage: stanza body line too long")
+			}
+			body, err := b64.Strict().DecodeString(line)
+			if err != nil {
+				return nil, nil, nil, errors.New("smage: malformed stanza body")
+			}
+			s.Body = append(s.Body, body...)
+			if len(line) < columnsPerLine {
+				break
+			}
+		}
+		stanzas = append(stanzas, s)
+	}
+}
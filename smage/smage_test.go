@@ -0,0 +1,161 @@
+package smage
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm2"
+)
+
+func encrypt(t *testing.T, plaintext []byte, recipients ...Recipient) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := Encrypt(&buf, recipients...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decrypt(ciphertext []byte, identities ...Identity) ([]byte, error) {
+	r, err := Decrypt(bytes.NewReader(ciphertext), identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func newSM2Identity(t *testing.T) *SM2Identity {
+	t.Helper()
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := NewSM2Identity(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestSM2Recipients(t *testing.T) {
+	alice, bob, eve := newSM2Identity(t), newSM2Identity(t), newSM2Identity(t)
+	for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 100} {
+		plaintext := make([]byte, size)
+		rand.Read(plaintext)
+		ciphertext := encrypt(t, plaintext, alice.Recipient(), bob.Recipient())
+		if !bytes.HasPrefix(ciphertext, []byte("smage/v1\n-> SM2 ")) {
+			t.Fatalf("unexpected header %q", ciphertext[:32])
+		}
+		for _, id := range []Identity{alice, bob} {
+			got, err := decrypt(ciphertext, eve, id)
+			if err != nil {
+				t.Fatalf("size %d: %v", size, err)
+			}
+			if !bytes.Equal(got, plaintext) {
+				t.Fatalf("size %d: plaintext mismatch", size)
+			}
+		}
+		var noMatch *NoIdentityMatchError
+		if _, err := decrypt(ciphertext, eve); !errors.As(err, &noMatch) {
+			t.Fatalf("size %d: got %v, expected NoIdentityMatchError", size, err)
+		}
+	}
+}
+
+func TestPassphraseRecipient(t *testing.T) {
+	r, err := NewPassphraseRecipient("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetIterations(1000); err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("hello smage")
+	ciphertext := encrypt(t, plaintext, r)
+	if !bytes.Contains(ciphertext, []byte("\n-> pbkdf2-sm3 ")) {
+		t.Fatalf("unexpected header %q", ciphertext)
+	}
+	id, _ := NewPassphraseIdentity("correct horse battery staple")
+	got, err := decrypt(ciphertext, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Fatalf("got %q, want %q", got, plaintext)
+	}
+	wrong, _ := NewPassphraseIdentity("wrong")
+	if _, err := decrypt(ciphertext, wrong); err == nil {
+		t.Error("expected error with wrong passphrase")
+	}
+
+	if _, err := Encrypt(io.Discard, r, newSM2Identity(t).Recipient()); err == nil {
+		t.Error("expected error mixing passphrase and other recipients")
+	}
+	if err := r.SetIterations(1); err == nil {
+		t.Error("expected error with too few iterations")
+	}
+}
+
+func TestTampering(t *testing.T) {
+	id := newSM2Identity(t)
+	plaintext := make([]byte, 2*chunkSize+10)
+	ciphertext := encrypt(t, plaintext, id.Recipient())
+	headerEnd := bytes.Index(ciphertext, []byte("\n---")) + 1
+
+	// header
+	tampered := bytes.Replace(ciphertext, []byte("smage/v1"), []byte("smage/v2"), 1)
+	if _, err := decrypt(tampered, id); err == nil {
+		t.Error("expected error with wrong version")
+	}
+	macLine := ciphertext[headerEnd:]
+	macLine = macLine[:bytes.IndexByte(macLine, '\n')]
+	tampered = bytes.Replace(ciphertext, macLine, []byte("--- "+strings.Repeat("A", len(macLine)-4)), 1)
+	if _, err := decrypt(tampered, id); err == nil {
+		t.Error("expected error with bad header MAC")
+	}
+
+	// payload
+	tampered = append([]byte(nil), ciphertext...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := decrypt(tampered, id); err == nil {
+		t.Error("expected error with modified payload")
+	}
+	// truncation at a chunk boundary
+	if _, err := decrypt(ciphertext[:len(ciphertext)-10-tagSize], id); err == nil {
+		t.Error("expected error with truncated payload")
+	}
+	if _, err := decrypt(ciphertext[:len(ciphertext)-1], id); err == nil {
+		t.Error("expected error with truncated payload")
+	}
+	// extension
+	if _, err := decrypt(append(append([]byte(nil), ciphertext...), 0), id); err == nil {
+		t.Error("expected error with trailing data")
+	}
+}
+
+func TestMalformedHeader(t *testing.T) {
+	id := newSM2Identity(t)
+	for _, header := range []string{
+		"",
+		"smage/v1\n",
+		"smage/v1\n---\n",
+		"smage/v1\n--- AAAA\n",
+		"smage/v1\n-> \n\n--- AAAA\n",
+		"smage/v1\n-> SM2\n" + strings.Repeat("A", 65) + "\n--- AAAA\n",
+	} {
+		if _, err := Decrypt(strings.NewReader(header), id); err == nil {
+			t.Errorf("%q: expected error", header)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package smage
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"errors"
+	"io"
+
+	"github.com/emmansun/gmsm/sm4"
+)
+
+// The payload is the STREAM construction of Hoang, Reyhanitabar, Rogaway and
+// Vizár, "Online Authenticated-Encryption and its Nonce-Reuse Misuse-Resistance",
+// as in age: the plaintext is split in chunks of 64 KiB, every chunk is sealed
+// with SM4-GCM and the nonce 11 bytes big endian counter || last chunk flag.
+// Only the last chunk may be shorter, and it's empty only if the plaintext is.
+
+const (
+	chunkSize    = 64 * 1024
+	tagSize      = 16
+	encChunkSize = chunkSize + tagSize
+	lastChunk    = 0x01
+)
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+type streamNonce [12]byte
+
+func (n *streamNonce) next() error {
+	for i := len(n) - 2; i >= 0; i-- {
+		n[i]++
+		if n[i] != 0 {
+			return nil
+		}
+	}
+	return errors.New("smage: stream counter overflow")
+}
+
+// first reports whether n is the nonce of the first chunk.
+func (n *streamNonce) first() bool {
+	for _, b := range n[:len(n)-1] {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type writer struct {
+	aead  cipher.AEAD
+	dst   io.Writer
+	nonce streamNonce
+	buf   []byte
+	err   error
+}
+
+func newWriter(key []byte, dst io.Writer) (*writer, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &writer{aead: aead, dst: dst, buf: make([]byte, 0, encChunkSize)}, nil
+}
+
+func (w *writer) Write(p []byte) (n int, err error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	total := len(p)
+	for len(p) > 0 {
+		// a full chunk is only flushed when more data comes, as it may be the last one
+		if len(w.buf) == chunkSize {
+			if err := w.flush(false); err != nil {
+				w.err = err
+				return total - len(p), err
+			}
+		}
+		k := copy(w.buf[len(w.buf):chunkSize], p)
+		w.buf = w.buf[:len(w.buf)+k]
+		p = p[k:]
+	}
+	return total, nil
+}
+
+// Close flushes the last chunk, it doesn't close the underlying writer.
+func (w *writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.err = w.flush(true)
+	if w.err != nil {
+		return w.err
+	}
+	w.err = errors.New("smage: write on closed writer")
+	return nil
+}
+
+func (w *writer) flush(last bool) error {
+	if last {
+		w.nonce[len(w.nonce)-1] = lastChunk
+	}
+	out := w.aead.Seal(w.buf[:0], w.nonce[:], w.buf, nil)
+	if _, err := w.dst.Write(out); err != nil {
+		return err
+	}
+	w.buf = w.buf[:0]
+	return w.nonce.next()
+}
+
+type reader struct {
+	aead      cipher.AEAD
+	src       *bufio.Reader
+	nonce     streamNonce
+	buf       []byte
+	plaintext []byte
+	done      bool
+	err       error
+}
+
+func newReader(key []byte, src *bufio.Reader) (*reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &reader{aead: aead, src: src, buf: make([]byte, encChunkSize)}, nil
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.plaintext) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.plaintext, r.err = r.readChunk()
+		if r.err != nil {
+			r.plaintext = nil
+		}
+	}
+	n := copy(p, r.plaintext)
+	r.plaintext = r.plaintext[n:]
+	return n, nil
+}
+
+func (r *reader) readChunk() ([]byte, error) {
+	n, err := io.ReadFull(r.src, r.buf)
+	switch {
+	case err == io.ErrUnexpectedEOF || err == io.EOF:
+		r.done = true
+	case err != nil:
+		return nil, err
+	default:
+		if _, err := r.src.Peek(1); err == io.EOF {
+			r.done = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if n < tagSize {
+		return nil, errors.New("smage: truncated payload")
+	}
+	if r.done {
+		r.nonce[len(r.nonce)-1] = lastChunk
+	}
+	plaintext, err := r.aead.Open(r.buf[:0], r.nonce[:], r.buf[:n], nil)
+	if err != nil {
+		return nil, errors.New("smage: failed to decrypt and authenticate payload chunk")
+	}
+	if r.done && len(plaintext) == 0 && !r.nonce.first() {
+		return nil, errors.New("smage: last chunk is empty")
+	}
+	if err := r.nonce.next(); err != nil {
+		return nil, err
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,309 @@
+// Package smssh implements the SM transport algorithms of SSH for bastion
+// hosts in SM only networks: the sm4-gcm AEAD cipher, the sm4-ctr cipher
+// with the hmac-sm3 MACs, and the sm2 host key algorithm.
+//
+// golang.org/x/crypto/ssh doesn't allow to register ciphers or MACs, the
+// packet ciphers here have the binary packet protocol of RFC 4253 (and of
+// aes-gcm@openssh.com for sm4-gcm) and the shape of its internal packetCipher,
+// so that they can be wired into a fork of it. SM2 host keys implement
+// ssh.Signer and ssh.PublicKey and are usable with ssh.ServerConfig.AddHostKey
+// as is.
+package smssh
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm4"
+)
+
+// Names of the SM cipher and MAC algorithms.
+const (
+	CipherSM4GCM = "sm4-gcm"
+	CipherSM4CTR = "sm4-ctr"
+	MACSM3       = "hmac-sm3"
+	MACSM3ETM    = "hmac-sm3-etm@openssh.com"
+)
+
+const (
+	packetSizeMultiple = 16
+	prefixLen          = 5 // packet length and padding length
+	gcmTagSize         = 16
+	gcmIVSize          = 12
+	// maxPacket is the maximum packet length of x/crypto/ssh and OpenSSH.
+	maxPacket = 256 * 1024
+)
+
+// PacketCipher encrypts and authenticates the binary packets of one direction
+// of an SSH connection.
+type PacketCipher interface {
+	// WritePacket encrypts packet, which may be overwritten, and writes it to w.
+	WritePacket(seqNum uint32, w io.Writer, rand io.Reader, packet []byte) error
+	// ReadPacket reads and decrypts a packet from r. The returned slice is
+	// only valid until the next call.
+	ReadPacket(seqNum uint32, r io.Reader) ([]byte, error)
+}
+
+// KeySizes returns the key and IV sizes of the cipher, which are derived by
+// the key exchange.
+func KeySizes(cipherName string) (keySize, ivSize int, err error) {
+	switch cipherName {
+	case CipherSM4GCM:
+		return sm4.BlockSize, gcmIVSize, nil
+	case CipherSM4CTR:
+		return sm4.BlockSize, sm4.BlockSize, nil
+	}
+	return 0, 0, fmt.Errorf("smssh: unsupported cipher %q", cipherName)
+}
+
+// MACKeySize returns the key size of the MAC.
+func MACKeySize(macName string) (int, error) {
+	switch macName {
+	case MACSM3, MACSM3ETM:
+		return sm3.Size, nil
+	}
+	return 0, fmt.Errorf("smssh: unsupported MAC %q", macName)
+}
+
+// NewPacketCipher returns the packet cipher of cipherName with macName, which
+// must be empty for sm4-gcm.
+func NewPacketCipher(cipherName, macName string, key, iv, macKey []byte) (PacketCipher, error) {
+	keySize, ivSize, err := KeySizes(cipherName)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != keySize || len(iv) != ivSize {
+		return nil, errors.New("smssh: invalid key or IV size")
+	}
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if cipherName == CipherSM4GCM {
+		if macName != "" {
+			return nil, errors.New("smssh: sm4-gcm doesn't use a MAC")
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		c := &gcmCipher{aead: aead}
+		copy(c.iv[:], iv)
+		return c, nil
+	}
+	macSize, err := MACKeySize(macName)
+	if err != nil {
+		return nil, err
+	}
+	if len(macKey) != macSize {
+		return nil, errors.New("smssh: invalid MAC key size")
+	}
+	return &streamCipher{
+		stream: cipher.NewCTR(block, iv),
+		mac:    hmac.New(sm3.New, macKey),
+		etm:    macName == MACSM3ETM,
+	}, nil
+}
+
+// paddingLength returns the padding length of a packet whose encrypted part
+// has n bytes besides the padding, at least 4 bytes as required by RFC 4253.
+func paddingLength(n int) int {
+	padding := packetSizeMultiple - n%packetSizeMultiple
+	if padding < 4 {
+		padding += packetSizeMultiple
+	}
+	return padding
+}
+
+// gcmCipher is sm4-gcm, it follows aes-gcm@openssh.com and RFC 5647: the
+// packet length is the additional data and the invocation counter, the last
+// 8 bytes of the nonce, is incremented for each packet.
+type gcmCipher struct {
+	aead   cipher.AEAD
+	iv     [gcmIVSize]byte
+	prefix [4]byte
+	buf    []byte
+}
+
+func (c *gcmCipher) incIV() {
+	for i := gcmIVSize - 1; i >= 4; i-- {
+		c.iv[i]++
+		if c.iv[i] != 0 {
+			break
+		}
+	}
+}
+
+func (c *gcmCipher) WritePacket(seqNum uint32, w io.Writer, rand io.Reader, packet []byte) error {
+	if len(packet) > maxPacket {
+		return errors.New("smssh: packet too large")
+	}
+	padding := paddingLength(1 + len(packet))
+	length := 1 + len(packet) + padding
+	binary.BigEndian.PutUint32(c.prefix[:], uint32(length))
+
+	if cap(c.buf) < length+gcmTagSize {
+		c.buf = make([]byte, length, length+gcmTagSize)
+	}
+	c.buf = c.buf[:length]
+	c.buf[0] = byte(padding)
+	copy(c.buf[1:], packet)
+	if _, err := io.ReadFull(rand, c.buf[1+len(packet):]); err != nil {
+		return err
+	}
+	c.buf = c.aead.Seal(c.buf[:0], c.iv[:], c.buf, c.prefix[:])
+	c.incIV()
+	if _, err := w.Write(c.prefix[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(c.buf)
+	return err
+}
+
+func (c *gcmCipher) ReadPacket(seqNum uint32, r io.Reader) ([]byte, error) {
+	if _, err := io.ReadFull(r, c.prefix[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(c.prefix[:])
+	if length > maxPacket {
+		return nil, errors.New("smssh: max packet length exceeded")
+	}
+	if length%packetSizeMultiple != 0 {
+		return nil, errors.New("smssh: invalid packet length")
+	}
+	if cap(c.buf) < int(length+gcmTagSize) {
+		c.buf = make([]byte, length+gcmTagSize)
+	}
+	c.buf = c.buf[:length+gcmTagSize]
+	if _, err := io.ReadFull(r, c.buf); err != nil {
+		return nil, err
+	}
+	plain, err := c.aead.Open(c.buf[:0], c.iv[:], c.buf, c.prefix[:])
+	if err != nil {
+		return nil, errors.New("smssh: message authentication failed")
+	}
+	c.incIV()
+	if len(plain) == 0 {
+		return nil, errors.New("smssh: empty packet")
+	}
+	padding := int(plain[0])
+	if padding < 4 || padding+1 >= len(plain) {
+		return nil, fmt.Errorf("smssh: illegal padding %d", padding)
+	}
+	return plain[1 : len(plain)-padding], nil
+}
+
+// streamCipher is sm4-ctr with hmac-sm3 or hmac-sm3-etm@openssh.com. With
+// encrypt-then-mac the packet length is sent in clear and the MAC is computed
+// over the ciphertext.
+type streamCipher struct {
+	stream cipher.Stream
+	mac    hash.Hash
+	etm    bool
+
+	prefix     [prefixLen]byte
+	seqNum     [4]byte
+	padding    [2 * packetSizeMultiple]byte
+	packetData []byte
+	macResult  []byte
+}
+
+func (s *streamCipher) WritePacket(seqNum uint32, w io.Writer, rand io.Reader, packet []byte) error {
+	if len(packet) > maxPacket {
+		return errors.New("smssh: packet too large")
+	}
+	encrypted := prefixLen + len(packet)
+	if s.etm {
+		encrypted -= 4
+	}
+	paddingLen := paddingLength(encrypted)
+	binary.BigEndian.PutUint32(s.prefix[:], uint32(len(packet)+1+paddingLen))
+	s.prefix[4] = byte(paddingLen)
+	padding := s.padding[:paddingLen]
+	if _, err := io.ReadFull(rand, padding); err != nil {
+		return err
+	}
+
+	s.mac.Reset()
+	binary.BigEndian.PutUint32(s.seqNum[:], seqNum)
+	s.mac.Write(s.seqNum[:])
+	if s.etm {
+		s.stream.XORKeyStream(s.prefix[4:], s.prefix[4:])
+		s.stream.XORKeyStream(packet, packet)
+		s.stream.XORKeyStream(padding, padding)
+		s.mac.Write(s.prefix[:])
+		s.mac.Write(packet)
+		s.mac.Write(padding)
+	} else {
+		s.mac.Write(s.prefix[:])
+		s.mac.Write(packet)
+		s.mac.Write(padding)
+		s.stream.XORKeyStream(s.prefix[:], s.prefix[:])
+		s.stream.XORKeyStream(packet, packet)
+		s.stream.XORKeyStream(padding, padding)
+	}
+	s.macResult = s.mac.Sum(s.macResult[:0])
+
+	for _, b := range [][]byte{s.prefix[:], packet, padding, s.macResult} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *streamCipher) ReadPacket(seqNum uint32, r io.Reader) ([]byte, error) {
+	if _, err := io.ReadFull(r, s.prefix[:]); err != nil {
+		return nil, err
+	}
+	var encryptedPrefix [prefixLen]byte
+	encryptedPrefix = s.prefix
+	if s.etm {
+		s.stream.XORKeyStream(s.prefix[4:], s.prefix[4:])
+	} else {
+		s.stream.XORKeyStream(s.prefix[:], s.prefix[:])
+	}
+	length := binary.BigEndian.Uint32(s.prefix[:])
+	paddingLen := uint32(s.prefix[4])
+	if length <= paddingLen+1 || paddingLen < 4 {
+		return nil, errors.New("smssh: invalid packet length, packet too small")
+	}
+	if length > maxPacket {
+		return nil, errors.New("smssh: invalid packet length, packet too large")
+	}
+
+	macSize := uint32(s.mac.Size())
+	if uint32(cap(s.packetData)) < length-1+macSize {
+		s.packetData = make([]byte, length-1+macSize)
+	}
+	s.packetData = s.packetData[:length-1+macSize]
+	if _, err := io.ReadFull(r, s.packetData); err != nil {
+		return nil, err
+	}
+	data, mac := s.packetData[:length-1], s.packetData[length-1:]
+
+	s.mac.Reset()
+	binary.BigEndian.PutUint32(s.seqNum[:], seqNum)
+	s.mac.Write(s.seqNum[:])
+	if s.etm {
+		s.mac.Write(encryptedPrefix[:])
+		s.mac.Write(data)
+		s.stream.XORKeyStream(data, data)
+	} else {
+		s.stream.XORKeyStream(data, data)
+		s.mac.Write(s.prefix[:])
+		s.mac.Write(data)
+	}
+	s.macResult = s.mac.Sum(s.macResult[:0])
+	if subtle.ConstantTimeCompare(s.macResult, mac) != 1 {
+		return nil, errors.New("smssh: MAC failure")
+	}
+	return data[:length-paddingLen-1], nil
+}
@@ -0,0 +1,130 @@
+package smssh
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm4"
+)
+
+func newTestCiphers(t *testing.T, cipherName, macName string) (PacketCipher, PacketCipher) {
+	t.Helper()
+	keySize, ivSize, err := KeySizes(cipherName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, iv := make([]byte, keySize), make([]byte, ivSize)
+	rand.Read(key)
+	rand.Read(iv)
+	var macKey []byte
+	if macName != "" {
+		n, err := MACKeySize(macName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		macKey = make([]byte, n)
+		rand.Read(macKey)
+	}
+	w, err := NewPacketCipher(cipherName, macName, key, iv, macKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := NewPacketCipher(cipherName, macName, key, iv, macKey)
+	return w, r
+}
+
+func TestPacketCiphers(t *testing.T) {
+	for _, tt := range []struct{ cipher, mac string }{
+		{CipherSM4GCM, ""},
+		{CipherSM4CTR, MACSM3},
+		{CipherSM4CTR, MACSM3ETM},
+	} {
+		name := tt.cipher + "/" + tt.mac
+		w, r := newTestCiphers(t, tt.cipher, tt.mac)
+		var buf bytes.Buffer
+		for seq := uint32(0); seq < 40; seq++ {
+			packet := bytes.Repeat([]byte{byte(seq)}, int(seq)+1)
+			want := append([]byte{}, packet...)
+			buf.Reset()
+			if err := w.WritePacket(seq, &buf, rand.Reader, packet); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			wire := append([]byte{}, buf.Bytes()...)
+			got, err := r.ReadPacket(seq, &buf)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("%s: got %x, want %x", name, got, want)
+			}
+			// the packet length is sent in clear, the encrypted part is a
+			// multiple of the block size.
+			if length := binary.BigEndian.Uint32(wire); tt.mac != MACSM3 && length%16 != 0 {
+				t.Errorf("%s: invalid packet length %d", name, length)
+			}
+		}
+
+		// the reader must reject a modified packet, it can't be used after.
+		buf.Reset()
+		w.WritePacket(40, &buf, rand.Reader, []byte("hello"))
+		wire := buf.Bytes()
+		wire[len(wire)-1] ^= 1
+		if _, err := r.ReadPacket(40, &buf); err == nil {
+			t.Errorf("%s: expected error for a modified packet", name)
+		}
+	}
+}
+
+func TestPacketCipherWrongSequence(t *testing.T) {
+	w, r := newTestCiphers(t, CipherSM4CTR, MACSM3)
+	var buf bytes.Buffer
+	w.WritePacket(1, &buf, rand.Reader, []byte("hello"))
+	if _, err := r.ReadPacket(2, &buf); err == nil {
+		t.Error("expected MAC failure with a wrong sequence number")
+	}
+}
+
+// TestGCMFormat checks the packet format of aes-gcm@openssh.com with SM4.
+func TestGCMFormat(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	iv := []byte("fixedcounter")
+	c, err := NewPacketCipher(CipherSM4GCM, "", key, iv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	zeros := bytes.NewReader(make([]byte, 64))
+	if err := c.WritePacket(0, &buf, zeros, []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	block, _ := sm4.NewCipher(key)
+	aead, _ := cipher.NewGCM(block)
+	// 1 byte padding length, 2 bytes payload and 13 bytes padding
+	plain := append([]byte{13, 'h', 'i'}, make([]byte, 13)...)
+	want := aead.Seal([]byte{0, 0, 0, 16}, iv, plain, []byte{0, 0, 0, 16})
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("got %x, want %x", buf.Bytes(), want)
+	}
+}
+
+func TestNewPacketCipherErrors(t *testing.T) {
+	key := make([]byte, 16)
+	for _, tt := range []struct {
+		cipher, mac string
+		iv, macKey  []byte
+	}{
+		{"aes128-ctr", MACSM3, key, key},
+		{CipherSM4GCM, MACSM3, key[:12], key},
+		{CipherSM4GCM, "", key, nil},
+		{CipherSM4CTR, "hmac-sha2-256", key, make([]byte, 32)},
+		{CipherSM4CTR, MACSM3, key, key},
+		{CipherSM4CTR, "", key, nil},
+	} {
+		if _, err := NewPacketCipher(tt.cipher, tt.mac, key, tt.iv, tt.macKey); err == nil {
+			t.Errorf("%s/%s: expected error", tt.cipher, tt.mac)
+		}
+	}
+}
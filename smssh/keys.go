@@ -0,0 +1,132 @@
+package smssh
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/emmansun/gmsm/codec"
+	"github.com/emmansun/gmsm/sm2"
+	"golang.org/x/crypto/ssh"
+)
+
+// KeyAlgoSM2 is the name of the SM2 host key and signature algorithm. The key
+// is encoded like the ECDSA keys of RFC 5656, string "sm2", string "sm2" and
+// string Q, the signature blob is mpint r and mpint s. The signed data is
+// hashed with SM3 and the Z value of the default user id.
+const KeyAlgoSM2 = "sm2"
+
+type sm2PublicKey struct {
+	*ecdsa.PublicKey
+}
+
+// NewPublicKey returns the SSH public key of an SM2 public key.
+func NewPublicKey(pub *ecdsa.PublicKey) (ssh.PublicKey, error) {
+	if pub == nil || pub.Curve != sm2.P256() {
+		return nil, errors.New("smssh: not an SM2 public key")
+	}
+	return &sm2PublicKey{pub}, nil
+}
+
+// ParsePublicKey parses an SM2 public key in the SSH wire format.
+func ParsePublicKey(in []byte) (ssh.PublicKey, error) {
+	var w struct {
+		Name  string
+		Curve string
+		Q     []byte
+	}
+	if err := ssh.Unmarshal(in, &w); err != nil {
+		return nil, err
+	}
+	if w.Name != KeyAlgoSM2 || w.Curve != KeyAlgoSM2 {
+		return nil, errors.New("smssh: not an SM2 public key")
+	}
+	pub, err := sm2.NewPublicKey(w.Q)
+	if err != nil {
+		return nil, err
+	}
+	return &sm2PublicKey{pub}, nil
+}
+
+func (k *sm2PublicKey) Type() string {
+	return KeyAlgoSM2
+}
+
+func (k *sm2PublicKey) Marshal() []byte {
+	w := struct {
+		Name  string
+		Curve string
+		Q     []byte
+	}{KeyAlgoSM2, KeyAlgoSM2, elliptic.Marshal(k.Curve, k.X, k.Y)}
+	return ssh.Marshal(&w)
+}
+
+func (k *sm2PublicKey) Verify(data []byte, sig *ssh.Signature) error {
+	if sig.Format != KeyAlgoSM2 {
+		return errors.New("smssh: signature type " + sig.Format + " for key type " + KeyAlgoSM2)
+	}
+	var rs struct {
+		R, S *big.Int
+		Rest []byte `ssh:"rest"`
+	}
+	if err := ssh.Unmarshal(sig.Blob, &rs); err != nil {
+		return err
+	}
+	if len(rs.Rest) != 0 || rs.R.Sign() <= 0 || rs.S.Sign() <= 0 ||
+		rs.R.BitLen() > 256 || rs.S.BitLen() > 256 {
+		return errors.New("smssh: invalid signature")
+	}
+	raw := make([]byte, 64)
+	rs.R.FillBytes(raw[:32])
+	rs.S.FillBytes(raw[32:])
+	der, err := codec.SignatureRSToASN1(raw)
+	if err != nil {
+		return err
+	}
+	if !sm2.VerifyASN1WithSM2(k.PublicKey, nil, data, der) {
+		return errors.New("smssh: signature did not verify")
+	}
+	return nil
+}
+
+// CryptoPublicKey returns the *ecdsa.PublicKey, it implements
+// ssh.CryptoPublicKey.
+func (k *sm2PublicKey) CryptoPublicKey() crypto.PublicKey {
+	return k.PublicKey
+}
+
+type sm2Signer struct {
+	priv *sm2.PrivateKey
+	pub  ssh.PublicKey
+}
+
+// NewSignerFromKey returns an ssh.Signer of the sm2 algorithm, e.g. a host
+// key for ssh.ServerConfig.AddHostKey.
+func NewSignerFromKey(priv *sm2.PrivateKey) (ssh.Signer, error) {
+	pub, err := NewPublicKey(&priv.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &sm2Signer{priv: priv, pub: pub}, nil
+}
+
+func (s *sm2Signer) PublicKey() ssh.PublicKey {
+	return s.pub
+}
+
+func (s *sm2Signer) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	der, err := s.priv.Sign(rand, data, sm2.DefaultSM2SignerOpts)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := codec.SignatureASN1ToRS(der)
+	if err != nil {
+		return nil, err
+	}
+	r, sv := new(big.Int).SetBytes(rs[:32]), new(big.Int).SetBytes(rs[32:])
+	blob := ssh.Marshal(&struct{ R, S *big.Int }{r, sv})
+	return &ssh.Signature{Format: KeyAlgoSM2, Blob: blob}, nil
+}
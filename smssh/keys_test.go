@@ -0,0 +1,66 @@
+package smssh
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm2"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSM2HostKey(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("exchange hash")
+	sig, err := signer.Sign(rand.Reader, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig.Format != KeyAlgoSM2 {
+		t.Errorf("unexpected signature format %s", sig.Format)
+	}
+
+	pub, err := ParsePublicKey(signer.PublicKey().Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub.Type() != KeyAlgoSM2 || !bytes.Equal(pub.Marshal(), signer.PublicKey().Marshal()) {
+		t.Error("public key mismatch")
+	}
+	if !priv.PublicKey.Equal(pub.(ssh.CryptoPublicKey).CryptoPublicKey()) {
+		t.Error("crypto public key mismatch")
+	}
+	if err := pub.Verify(data, sig); err != nil {
+		t.Error(err)
+	}
+	if err := pub.Verify([]byte("other"), sig); err == nil {
+		t.Error("expected error for other data")
+	}
+	if err := pub.Verify(data, &ssh.Signature{Format: ssh.KeyAlgoECDSA256, Blob: sig.Blob}); err == nil {
+		t.Error("expected error for another signature format")
+	}
+	if !bytes.HasPrefix(ssh.MarshalAuthorizedKey(pub), []byte("sm2 ")) {
+		t.Errorf("unexpected authorized key %s", ssh.MarshalAuthorizedKey(pub))
+	}
+}
+
+func TestSM2HostKeyErrors(t *testing.T) {
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if _, err := NewPublicKey(&p256.PublicKey); err == nil {
+		t.Error("expected error for a P-256 key")
+	}
+	ecdsaKey, _ := ssh.NewPublicKey(&p256.PublicKey)
+	if _, err := ParsePublicKey(ecdsaKey.Marshal()); err == nil {
+		t.Error("expected error for an ECDSA key")
+	}
+}
+
@@ -1,6 +1,17 @@
 package smx509
 
-import "testing"
+import (
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/emmansun/gmsm/sm2"
+)
 
 func TestCertPoolEqual(t *testing.T) {
 	tc := &Certificate{Raw: []byte{1, 2, 3}, RawSubject: []byte{2}}
@@ -102,3 +113,72 @@ func TestCertPoolEqual(t *testing.T) {
 		})
 	}
 }
+
+func generateSM2Root(t *testing.T, name string) (*Certificate, []byte) {
+	t.Helper()
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestSystemGMCertPool(t *testing.T) {
+	fileRoot, filePEM := generateSM2Root(t, "GM root in file")
+	dirRoot, dirPEM := generateSM2Root(t, "GM root in directory")
+	extraRoot, extraPEM := generateSM2Root(t, "GM root of application")
+
+	tmp := t.TempDir()
+	file := filepath.Join(tmp, "gm-bundle.pem")
+	if err := os.WriteFile(file, filePEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(tmp, "anchors")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "root.crt"), dirPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(gmCertFileEnv, file)
+	t.Setenv(gmCertDirEnv, dir)
+
+	pool, err := SystemGMCertPool(extraPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, root := range []*Certificate{fileRoot, dirRoot, extraRoot} {
+		if !pool.contains(root) {
+			t.Errorf("%s is not in the pool", root.Subject.CommonName)
+		}
+	}
+	system, err := SystemCertPool()
+	if err == nil && system.contains(fileRoot) {
+		t.Error("SystemGMCertPool modified the system cert pool")
+	}
+
+	if _, err := SystemGMCertPool([]byte("not a certificate")); err == nil {
+		t.Error("expected an error for an extra PEM without certificates")
+	}
+	t.Setenv(gmCertDirEnv, filepath.Join(tmp, "missing"))
+	if _, err := SystemGMCertPool(); err == nil {
+		t.Error("expected an error for a missing SSL_GM_CERT_DIR")
+	}
+}
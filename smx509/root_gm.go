@@ -0,0 +1,90 @@
+package smx509
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// gmCertFileEnv is the environment variable which identifies a file of GM
+	// root certificates, e.g. the SM2 root bundle of the OS vendor or of a
+	// national CA, which is loaded in addition to the system roots.
+	gmCertFileEnv = "SSL_GM_CERT_FILE"
+
+	// gmCertDirEnv is the environment variable which identifies which
+	// directories to check for GM root certificate files. If set this
+	// overrides the default local trust anchor directories. It is a colon
+	// separated list of directories.
+	gmCertDirEnv = "SSL_GM_CERT_DIR"
+)
+
+// SystemGMCertPool returns a copy of the system cert pool merged with the GM
+// root certificates of the system and the PEM encoded certificates of
+// extraPEM, e.g. the GM roots bundled with the application.
+//
+// The GM (SM2) roots are usually not part of the CA bundle of the
+// distribution, which is the only source of the system cert pool: UOS, Kylin
+// and openEuler install them as local trust anchors, which the bundle tools
+// of older releases skip because they don't parse SM2 certificates, or ship
+// them in a separate bundle. SystemGMCertPool also reads all the files of the
+// local trust anchor directories, /usr/local/share/ca-certificates on the
+// Debian based and /etc/pki/ca-trust/source/anchors on the RHEL based
+// distributions, or of the colon separated directories of SSL_GM_CERT_DIR,
+// and the bundle named by SSL_GM_CERT_FILE.
+//
+// Default directories which can't be read are skipped. It returns an error if the file or
+// the directories of the environment variables can't be read, or if a PEM
+// block of extraPEM has no certificate.
+func SystemGMCertPool(extraPEM ...[]byte) (*CertPool, error) {
+	pool, err := SystemCertPool()
+	if err != nil {
+		// The GM roots may be the only roots of the system.
+		pool = NewCertPool()
+	}
+	if err := appendGMRoots(pool); err != nil {
+		return nil, err
+	}
+	for i, data := range extraPEM {
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("x509: no certificates in extra PEM %d", i)
+		}
+	}
+	return pool, nil
+}
+
+// appendGMRoots appends the GM roots of the system to pool. Duplicates of the
+// certificates of the CA bundle are ignored by AddCert.
+func appendGMRoots(pool *CertPool) error {
+	if file := os.Getenv(gmCertFileEnv); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		pool.AppendCertsFromPEM(data)
+	}
+
+	dirs, explicit := gmCertDirectories, false
+	if d := os.Getenv(gmCertDirEnv); d != "" {
+		dirs, explicit = strings.Split(d, ":"), true
+	}
+	for _, directory := range dirs {
+		entries, err := os.ReadDir(directory)
+		if err != nil {
+			if explicit {
+				return err
+			}
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			data, err := os.ReadFile(directory + "/" + entry.Name())
+			if err == nil {
+				pool.AppendCertsFromPEM(data)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package smx509
+
+// Directories of local trust anchors, which hold the GM roots installed by
+// the administrators; all will be read by SystemGMCertPool.
+var gmCertDirectories = []string{}
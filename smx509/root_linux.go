@@ -2,11 +2,11 @@ package smx509
 
 // Possible certificate files; stop after finding one.
 var certFiles = []string{
-	"/etc/ssl/certs/ca-certificates.crt",                // Debian/Ubuntu/Gentoo etc.
+	"/etc/ssl/certs/ca-certificates.crt",                // Debian/Ubuntu/Gentoo/UOS/Kylin Desktop etc.
 	"/etc/pki/tls/certs/ca-bundle.crt",                  // Fedora/RHEL 6
 	"/etc/ssl/ca-bundle.pem",                            // OpenSUSE
 	"/etc/pki/tls/cacert.pem",                           // OpenELEC
-	"/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem", // CentOS/RHEL 7
+	"/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem", // CentOS/RHEL 7/openEuler/Kylin Server
 	"/etc/ssl/cert.pem",                                 // Alpine Linux
 }
 
@@ -16,3 +16,10 @@ var certDirectories = []string{
 	"/etc/pki/tls/certs",           // Fedora/RHEL
 	"/system/etc/security/cacerts", // Android
 }
+
+// Directories of local trust anchors, which hold the GM roots installed by
+// the administrators; all will be read by SystemGMCertPool.
+var gmCertDirectories = []string{
+	"/usr/local/share/ca-certificates", // Debian/Ubuntu/UOS/Kylin Desktop
+	"/etc/pki/ca-trust/source/anchors", // Fedora/RHEL/openEuler/Kylin Server
+}
@@ -0,0 +1,500 @@
+package smx509
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+)
+
+// This file implements human-readable dumps of certificates, certificate requests
+// and CRLs in the layout of "openssl x509 -text", "openssl req -text" and
+// "openssl crl -text", with the names of the ShangMi OIDs.
+
+var oidNames = map[string]string{
+	// GB/T 33560-2017
+	"1.2.156.10197.1.104":     "SM4",
+	"1.2.156.10197.1.104.1":   "SM4-ECB",
+	"1.2.156.10197.1.104.2":   "SM4-CBC",
+	"1.2.156.10197.1.104.8":   "SM4-GCM",
+	"1.2.156.10197.1.301":     "SM2",
+	"1.2.156.10197.1.301.1":   "sm2sign",
+	"1.2.156.10197.1.301.2":   "sm2exchange",
+	"1.2.156.10197.1.301.3":   "sm2encrypt",
+	"1.2.156.10197.1.302":     "SM9",
+	"1.2.156.10197.1.302.1":   "sm9sign",
+	"1.2.156.10197.1.302.2":   "sm9keyagreement",
+	"1.2.156.10197.1.302.3":   "sm9encrypt",
+	"1.2.156.10197.1.401":     "SM3",
+	"1.2.156.10197.1.401.2":   "hmac-sm3",
+	"1.2.156.10197.1.501":     "SM2-with-SM3",
+	"1.2.156.10197.1.502":     "SM9-with-SM3",
+	"1.2.156.10197.6.1.4.2.1": "data",
+	// GM/T 0015-2012 personal and organization identifiers
+	"1.2.156.10260.4.1.1": "Identify Code",
+	"1.2.156.10260.4.1.2": "Insurance Number",
+	"1.2.156.10260.4.1.3": "IC Registration Number",
+	"1.2.156.10260.4.1.4": "Organization Code",
+	"1.2.156.10260.4.1.5": "Taxation Number",
+
+	"1.2.840.10045.2.1":     "id-ecPublicKey",
+	"1.2.840.113549.1.1.1":  "rsaEncryption",
+	"1.3.101.112":           "ED25519",
+	"1.2.840.113549.1.9.1":  "emailAddress",
+	"1.3.6.1.5.5.7.48.1":    "OCSP",
+	"1.3.6.1.5.5.7.48.2":    "CA Issuers",
+	"2.5.29.14":             "X509v3 Subject Key Identifier",
+	"2.5.29.15":             "X509v3 Key Usage",
+	"2.5.29.17":             "X509v3 Subject Alternative Name",
+	"2.5.29.18":             "X509v3 Issuer Alternative Name",
+	"2.5.29.19":             "X509v3 Basic Constraints",
+	"2.5.29.20":             "X509v3 CRL Number",
+	"2.5.29.21":             "X509v3 CRL Reason Code",
+	"2.5.29.30":             "X509v3 Name Constraints",
+	"2.5.29.31":             "X509v3 CRL Distribution Points",
+	"2.5.29.32":             "X509v3 Certificate Policies",
+	"2.5.29.35":             "X509v3 Authority Key Identifier",
+	"2.5.29.37":             "X509v3 Extended Key Usage",
+	"1.3.6.1.5.5.7.1.1":     "Authority Information Access",
+	"1.3.6.1.5.5.7.3.1":     "TLS Web Server Authentication",
+	"1.3.6.1.5.5.7.3.2":     "TLS Web Client Authentication",
+	"1.3.6.1.5.5.7.3.3":     "Code Signing",
+	"1.3.6.1.5.5.7.3.4":     "E-mail Protection",
+	"1.3.6.1.5.5.7.3.8":     "Time Stamping",
+	"1.3.6.1.5.5.7.3.9":     "OCSP Signing",
+	"2.5.29.37.0":           "Any Extended Key Usage",
+	"1.2.840.113549.1.9.14": "Requested Extensions",
+}
+
+// attributeTypeNames are the short names of the attribute types of distinguished names.
+var attributeTypeNames = map[string]string{
+	"2.5.4.3":              "CN",
+	"2.5.4.4":              "SN",
+	"2.5.4.5":              "serialNumber",
+	"2.5.4.6":              "C",
+	"2.5.4.7":              "L",
+	"2.5.4.8":              "ST",
+	"2.5.4.9":              "street",
+	"2.5.4.10":             "O",
+	"2.5.4.11":             "OU",
+	"2.5.4.12":             "title",
+	"2.5.4.17":             "postalCode",
+	"2.5.4.42":             "GN",
+	"1.2.840.113549.1.9.1": "emailAddress",
+}
+
+var keyUsageNames = []string{
+	"Digital Signature",
+	"Non Repudiation",
+	"Key Encipherment",
+	"Data Encipherment",
+	"Key Agreement",
+	"Certificate Sign",
+	"CRL Sign",
+	"Encipher Only",
+	"Decipher Only",
+}
+
+func oidName(oid asn1.ObjectIdentifier) string {
+	if name, ok := oidNames[oid.String()]; ok {
+		return name
+	}
+	return oid.String()
+}
+
+func signatureAlgorithmName(ai pkix.AlgorithmIdentifier) string {
+	if name, ok := oidNames[ai.Algorithm.String()]; ok {
+		return name
+	}
+	if algo := getSignatureAlgorithmFromAI(ai); algo != UnknownSignatureAlgorithm {
+		return algo.String()
+	}
+	return ai.Algorithm.String()
+}
+
+type textWriter struct {
+	bytes.Buffer
+}
+
+func (w *textWriter) line(indent int, format string, args ...any) {
+	w.WriteString(strings.Repeat(" ", indent))
+	fmt.Fprintf(w, format, args...)
+	w.WriteByte('\n')
+}
+
+// hexDump writes b as colon separated hex bytes, perLine bytes per line.
+func (w *textWriter) hexDump(indent, perLine int, b []byte) {
+	for len(b) > 0 {
+		n := perLine
+		if n > len(b) {
+			n = len(b)
+		}
+		w.WriteString(strings.Repeat(" ", indent))
+		for i, c := range b[:n] {
+			fmt.Fprintf(w, "%02x", c)
+			if i < n-1 || n < len(b) {
+				w.WriteByte(':')
+			}
+		}
+		w.WriteByte('\n')
+		b = b[n:]
+	}
+}
+
+func hexString(b []byte) string {
+	var sb strings.Builder
+	for i, c := range b {
+		if i > 0 {
+			sb.WriteByte(':')
+		}
+		fmt.Fprintf(&sb, "%02X", c)
+	}
+	return sb.String()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("Jan _2 15:04:05 2006 GMT")
+}
+
+// formatName formats a distinguished name as "C = CN, O = org, CN = name".
+func formatName(raw []byte) string {
+	var rdns pkix.RDNSequence
+	if rest, err := asn1.Unmarshal(raw, &rdns); err != nil || len(rest) != 0 {
+		return "<invalid name>"
+	}
+	return formatRDNSequence(rdns)
+}
+
+func formatRDNSequence(rdns pkix.RDNSequence) string {
+	var parts []string
+	for _, rdn := range rdns {
+		for _, atv := range rdn {
+			name, ok := attributeTypeNames[atv.Type.String()]
+			if !ok {
+				name = oidName(atv.Type)
+			}
+			parts = append(parts, fmt.Sprintf("%s = %v", name, atv.Value))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatSerial(w *textWriter, serial *big.Int) {
+	if serial.Sign() >= 0 && serial.BitLen() <= 63 {
+		w.line(8, "Serial Number: %d (%#x)", serial, serial)
+		return
+	}
+	w.line(8, "Serial Number:")
+	b := serial.Bytes()
+	if serial.Sign() < 0 {
+		b = new(big.Int).Neg(serial).Bytes()
+		w.line(12, "(Negative)%s", strings.ToLower(hexString(b)))
+		return
+	}
+	w.line(12, "%s", strings.ToLower(hexString(b)))
+}
+
+func formatPublicKey(w *textWriter, algo pkix.AlgorithmIdentifier, pub any) {
+	w.line(8, "Subject Public Key Info:")
+	w.line(12, "Public Key Algorithm: %s", oidName(algo.Algorithm))
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		w.line(16, "Public-Key: (%d bit)", k.Curve.Params().BitSize)
+		w.line(16, "pub:")
+		w.hexDump(20, 15, elliptic.Marshal(k.Curve, k.X, k.Y))
+		curve := k.Curve.Params().Name
+		if oid, ok := oidFromNamedCurve(k.Curve); ok {
+			if name, ok := oidNames[oid.String()]; ok {
+				curve = name
+			}
+		}
+		w.line(16, "ASN1 OID: %s", curve)
+	case *rsa.PublicKey:
+		w.line(16, "Public-Key: (%d bit)", k.N.BitLen())
+		w.line(16, "Modulus:")
+		w.hexDump(20, 15, append([]byte{0}, k.N.Bytes()...))
+		w.line(16, "Exponent: %d (%#x)", k.E, k.E)
+	case ed25519.PublicKey:
+		w.line(16, "ED25519 Public-Key:")
+		w.line(16, "pub:")
+		w.hexDump(20, 15, k)
+	default:
+		w.line(16, "Unable to load Public Key")
+	}
+}
+
+func formatExtensions(w *textWriter, indent int, title string, exts []pkix.Extension) {
+	if len(exts) == 0 {
+		return
+	}
+	w.line(indent, "%s", title)
+	for _, ext := range exts {
+		critical := ""
+		if ext.Critical {
+			critical = "critical"
+		}
+		w.line(indent+4, "%s: %s", oidName(ext.Id), critical)
+		if !formatExtensionValue(w, indent+8, ext) {
+			w.hexDump(indent+8, 18, ext.Value)
+		}
+	}
+}
+
+// formatExtensionValue writes the value of the known extensions, it returns
+// false if the extension is unknown or malformed.
+func formatExtensionValue(w *textWriter, indent int, ext pkix.Extension) bool {
+	switch ext.Id.String() {
+	case "2.5.29.15":
+		var usage asn1.BitString
+		if !unmarshalStrict(ext.Value, &usage) {
+			return false
+		}
+		var names []string
+		for i, name := range keyUsageNames {
+			if usage.At(i) != 0 {
+				names = append(names, name)
+			}
+		}
+		w.line(indent, "%s", strings.Join(names, ", "))
+	case "2.5.29.19":
+		var bc basicConstraints
+		if !unmarshalStrict(ext.Value, &bc) {
+			return false
+		}
+		s := "CA:FALSE"
+		if bc.IsCA {
+			s = "CA:TRUE"
+		}
+		if bc.MaxPathLen >= 0 {
+			s += fmt.Sprintf(", pathlen:%d", bc.MaxPathLen)
+		}
+		w.line(indent, "%s", s)
+	case "2.5.29.14":
+		var id []byte
+		if !unmarshalStrict(ext.Value, &id) {
+			return false
+		}
+		w.line(indent, "%s", hexString(id))
+	case "2.5.29.35":
+		var aki authKeyId
+		if !unmarshalStrict(ext.Value, &aki) {
+			return false
+		}
+		w.line(indent, "%s", hexString(aki.Id))
+	case "2.5.29.17", "2.5.29.18":
+		var names []asn1.RawValue
+		if !unmarshalStrict(ext.Value, &names) {
+			return false
+		}
+		w.line(indent, "%s", strings.Join(formatGeneralNames(names), ", "))
+	case "2.5.29.37":
+		var usages []asn1.ObjectIdentifier
+		if !unmarshalStrict(ext.Value, &usages) {
+			return false
+		}
+		names := make([]string, len(usages))
+		for i, u := range usages {
+			names[i] = oidName(u)
+		}
+		w.line(indent, "%s", strings.Join(names, ", "))
+	case "2.5.29.31":
+		var dps []distributionPoint
+		if !unmarshalStrict(ext.Value, &dps) {
+			return false
+		}
+		for _, dp := range dps {
+			w.line(indent, "Full Name:")
+			for _, name := range formatGeneralNames(dp.DistributionPoint.FullName) {
+				w.line(indent+2, "%s", name)
+			}
+		}
+	case "1.3.6.1.5.5.7.1.1":
+		var aias []authorityInfoAccess
+		if !unmarshalStrict(ext.Value, &aias) {
+			return false
+		}
+		for _, aia := range aias {
+			w.line(indent, "%s - %s", oidName(aia.Method), strings.Join(formatGeneralNames([]asn1.RawValue{aia.Location}), ", "))
+		}
+	case "2.5.29.32":
+		var policies []policyInformation
+		if !unmarshalStrict(ext.Value, &policies) {
+			return false
+		}
+		for _, p := range policies {
+			w.line(indent, "Policy: %s", oidName(p.Policy))
+		}
+	case "2.5.29.20":
+		var n *big.Int
+		if !unmarshalStrict(ext.Value, &n) {
+			return false
+		}
+		w.line(indent, "%d", n)
+	default:
+		// GM/T 0015 identifiers and other extensions of printable values
+		var v asn1.RawValue
+		if !unmarshalStrict(ext.Value, &v) {
+			return false
+		}
+		if s, ok := asn1String(v); ok {
+			w.line(indent, "%s", s)
+			return true
+		}
+		if v.Class == asn1.ClassUniversal && v.Tag == asn1.TagSequence {
+			var values []asn1.RawValue
+			if !unmarshalStrict(v.FullBytes, &values) {
+				return false
+			}
+			var strs []string
+			for _, value := range values {
+				s, ok := asn1String(value)
+				if !ok {
+					return false
+				}
+				strs = append(strs, s)
+			}
+			w.line(indent, "%s", strings.Join(strs, ", "))
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+func unmarshalStrict(b []byte, v any) bool {
+	rest, err := asn1.Unmarshal(b, v)
+	return err == nil && len(rest) == 0
+}
+
+// asn1String returns the value of a universal string type, or a context-specific
+// tagged string as GM/T 0015 uses.
+func asn1String(v asn1.RawValue) (string, bool) {
+	switch {
+	case v.Class == asn1.ClassUniversal && (v.Tag == asn1.TagPrintableString ||
+		v.Tag == asn1.TagUTF8String || v.Tag == asn1.TagIA5String ||
+		v.Tag == asn1.TagNumericString || v.Tag == asn1.TagT61String):
+		return string(v.Bytes), true
+	case v.Class == asn1.ClassContextSpecific && !v.IsCompound:
+		return string(v.Bytes), true
+	}
+	return "", false
+}
+
+func formatGeneralNames(names []asn1.RawValue) []string {
+	var ret []string
+	for _, name := range names {
+		if name.Class != asn1.ClassContextSpecific {
+			continue
+		}
+		switch name.Tag {
+		case nameTypeEmail:
+			ret = append(ret, "email:"+string(name.Bytes))
+		case nameTypeDNS:
+			ret = append(ret, "DNS:"+string(name.Bytes))
+		case nameTypeURI:
+			ret = append(ret, "URI:"+string(name.Bytes))
+		case nameTypeIP:
+			ret = append(ret, "IP Address:"+net.IP(name.Bytes).String())
+		case 4:
+			var rdns pkix.RDNSequence
+			if unmarshalStrict(name.Bytes, &rdns) {
+				ret = append(ret, "DirName:"+formatRDNSequence(rdns))
+			}
+		default:
+			ret = append(ret, fmt.Sprintf("othername:<tag %d>", name.Tag))
+		}
+	}
+	return ret
+}
+
+func formatSignature(w *textWriter, algo pkix.AlgorithmIdentifier, sig []byte) {
+	w.line(4, "Signature Algorithm: %s", signatureAlgorithmName(algo))
+	w.line(4, "Signature Value:")
+	w.hexDump(8, 18, sig)
+}
+
+// CertificateText returns a human-readable dump of cert, in the layout of
+// "openssl x509 -text".
+func CertificateText(cert *Certificate) (string, error) {
+	var c certificate
+	if rest, err := asn1.Unmarshal(cert.Raw, &c); err != nil {
+		return "", err
+	} else if len(rest) != 0 {
+		return "", asn1.SyntaxError{Msg: "trailing data"}
+	}
+	w := &textWriter{}
+	w.line(0, "Certificate:")
+	w.line(4, "Data:")
+	w.line(8, "Version: %d (%#x)", cert.Version, cert.Version-1)
+	formatSerial(w, cert.SerialNumber)
+	w.line(8, "Signature Algorithm: %s", signatureAlgorithmName(c.TBSCertificate.SignatureAlgorithm))
+	w.line(8, "Issuer: %s", formatName(cert.RawIssuer))
+	w.line(8, "Validity")
+	w.line(12, "Not Before: %s", formatTime(cert.NotBefore))
+	w.line(12, "Not After : %s", formatTime(cert.NotAfter))
+	w.line(8, "Subject: %s", formatName(cert.RawSubject))
+	formatPublicKey(w, c.TBSCertificate.PublicKey.Algorithm, cert.PublicKey)
+	formatExtensions(w, 8, "X509v3 extensions:", cert.Extensions)
+	formatSignature(w, c.SignatureAlgorithm, c.SignatureValue.RightAlign())
+	return w.String(), nil
+}
+
+// CertificateRequestText returns a human-readable dump of csr, in the layout of
+// "openssl req -text".
+func CertificateRequestText(csr *CertificateRequest) (string, error) {
+	var req certificateRequest
+	if rest, err := asn1.Unmarshal(csr.Raw, &req); err != nil {
+		return "", err
+	} else if len(rest) != 0 {
+		return "", asn1.SyntaxError{Msg: "trailing data"}
+	}
+	w := &textWriter{}
+	w.line(0, "Certificate Request:")
+	w.line(4, "Data:")
+	w.line(8, "Version: %d (%#x)", csr.Version+1, csr.Version)
+	w.line(8, "Subject: %s", formatName(csr.RawSubject))
+	formatPublicKey(w, req.TBSCSR.PublicKey.Algorithm, csr.PublicKey)
+	w.line(8, "Attributes:")
+	if len(csr.Extensions) == 0 {
+		w.line(12, "(none)")
+	}
+	formatExtensions(w, 12, "Requested Extensions:", csr.Extensions)
+	formatSignature(w, req.SignatureAlgorithm, req.SignatureValue.RightAlign())
+	return w.String(), nil
+}
+
+// CRLText returns a human-readable dump of crl, in the layout of
+// "openssl crl -text".
+func CRLText(crl *pkix.CertificateList) string {
+	tbs := &crl.TBSCertList
+	w := &textWriter{}
+	w.line(0, "Certificate Revocation List (CRL):")
+	w.line(8, "Version %d (%#x)", tbs.Version+1, tbs.Version)
+	w.line(8, "Signature Algorithm: %s", signatureAlgorithmName(tbs.Signature))
+	w.line(8, "Issuer: %s", formatRDNSequence(tbs.Issuer))
+	w.line(8, "Last Update: %s", formatTime(tbs.ThisUpdate))
+	if !tbs.NextUpdate.IsZero() {
+		w.line(8, "Next Update: %s", formatTime(tbs.NextUpdate))
+	}
+	formatExtensions(w, 8, "CRL extensions:", tbs.Extensions)
+	if len(tbs.RevokedCertificates) == 0 {
+		w.line(0, "No Revoked Certificates.")
+	} else {
+		w.line(0, "Revoked Certificates:")
+		for _, rc := range tbs.RevokedCertificates {
+			w.line(4, "Serial Number: %s", strings.ToUpper(rc.SerialNumber.Text(16)))
+			w.line(8, "Revocation Date: %s", formatTime(rc.RevocationTime))
+			formatExtensions(w, 8, "CRL entry extensions:", rc.Extensions)
+		}
+	}
+	formatSignature(w, crl.SignatureAlgorithm, crl.SignatureValue.RightAlign())
+	return w.String()
+}
@@ -0,0 +1,137 @@
+package smx509
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emmansun/gmsm/sm2"
+)
+
+func TestCertificateText(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identifyCode, _ := asn1.Marshal("110101199001011234")
+	notBefore := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(0x1234),
+		Subject:               pkix.Name{Country: []string{"CN"}, Organization: []string{"GMSM"}, CommonName: "SM2 Test CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+		SubjectKeyId:          []byte{1, 2, 3, 4},
+		DNSNames:              []string{"example.com"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		CRLDistributionPoints: []string{"http://example.com/ca.crl"},
+		OCSPServer:            []string{"http://ocsp.example.com"},
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 2, 156, 10260, 4, 1, 1}, Value: identifyCode},
+			{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Value: []byte{0x05, 0x00}},
+		},
+		SignatureAlgorithm: SM2WithSM3,
+	}
+	der, err := CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := CertificateText(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Certificate:\n    Data:\n        Version: 3 (0x2)\n",
+		"        Serial Number: 4660 (0x1234)\n",
+		"        Signature Algorithm: SM2-with-SM3\n",
+		"        Issuer: C = CN, O = GMSM, CN = SM2 Test CA\n",
+		"            Not Before: Jan  2 03:04:05 2024 GMT\n",
+		"            Not After : Jan  2 03:04:05 2025 GMT\n",
+		"            Public Key Algorithm: id-ecPublicKey\n",
+		"                Public-Key: (256 bit)\n",
+		"                ASN1 OID: SM2\n",
+		"            X509v3 Key Usage: critical\n                Digital Signature, Certificate Sign, CRL Sign\n",
+		"            X509v3 Extended Key Usage: \n                TLS Web Server Authentication\n",
+		"            X509v3 Basic Constraints: critical\n                CA:TRUE, pathlen:0\n",
+		"            X509v3 Subject Key Identifier: \n                01:02:03:04\n",
+		"                DNS:example.com, IP Address:127.0.0.1\n",
+		"                Full Name:\n                  URI:http://example.com/ca.crl\n",
+		"                OCSP - URI:http://ocsp.example.com\n",
+		"            Identify Code: \n                110101199001011234\n",
+		"            1.2.3.4: \n                05:00\n",
+		"    Signature Algorithm: SM2-with-SM3\n    Signature Value:\n        30:",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q in\n%s", want, text)
+		}
+	}
+
+	csrDER, err := CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:            pkix.Name{CommonName: "SM2 Test"},
+		DNSNames:           []string{"example.com"},
+		SignatureAlgorithm: SM2WithSM3,
+	}, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := ParseCertificateRequest(csrDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err = CertificateRequestText(csr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Certificate Request:\n    Data:\n        Version: 1 (0x0)\n        Subject: CN = SM2 Test\n",
+		"                ASN1 OID: SM2\n",
+		"            Requested Extensions:\n                X509v3 Subject Alternative Name: \n                    DNS:example.com\n",
+		"    Signature Algorithm: SM2-with-SM3\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q in\n%s", want, text)
+		}
+	}
+
+	crlDER, err := CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(7),
+		ThisUpdate: notBefore,
+		NextUpdate: notBefore.AddDate(0, 0, 7),
+		RevokedCertificates: []pkix.RevokedCertificate{
+			{SerialNumber: big.NewInt(0xabcd), RevocationTime: notBefore},
+		},
+	}, cert, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crl, err := ParseDERCRL(crlDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text = CRLText(crl)
+	for _, want := range []string{
+		"Certificate Revocation List (CRL):\n        Version 2 (0x1)\n        Signature Algorithm: SM2-with-SM3\n",
+		"        Issuer: C = CN, O = GMSM, CN = SM2 Test CA\n",
+		"        Last Update: Jan  2 03:04:05 2024 GMT\n        Next Update: Jan  9 03:04:05 2024 GMT\n",
+		"            X509v3 CRL Number: \n                7\n",
+		"Revoked Certificates:\n    Serial Number: ABCD\n        Revocation Date: Jan  2 03:04:05 2024 GMT\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q in\n%s", want, text)
+		}
+	}
+}
@@ -5,8 +5,15 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math/bits"
+
+	"github.com/emmansun/gmsm/internal/impl"
 )
 
+func init() {
+	// the generic implementation uses table lookups, it's not constant time.
+	impl.Register("zuc", impl.Generic, true, false)
+}
+
 const (
 	IVSize128 = 16
 	IVSize256 = 23
@@ -197,6 +204,9 @@ func (s *zucState32) loadKeyIV32(key, iv, d []byte) {
 func newZUCState(key, iv []byte) (*zucState32, error) {
 	k := len(key)
 	ivLen := len(iv)
+	if err := impl.CheckConstantTime("zuc"); err != nil {
+		return nil, err
+	}
 	state := &zucState32{}
 	switch k {
 	default:
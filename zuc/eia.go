@@ -3,6 +3,8 @@ package zuc
 import (
 	"encoding/binary"
 	"fmt"
+
+	"github.com/emmansun/gmsm/internal/impl"
 )
 
 const (
@@ -25,6 +27,9 @@ type ZUC128Mac struct {
 func NewHash(key, iv []byte) (*ZUC128Mac, error) {
 	k := len(key)
 	ivLen := len(iv)
+	if err := impl.CheckConstantTime("zuc"); err != nil {
+		return nil, err
+	}
 	mac := &ZUC128Mac{}
 	mac.tagSize = 4
 
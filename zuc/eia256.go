@@ -3,6 +3,8 @@ package zuc
 import (
 	"encoding/binary"
 	"fmt"
+
+	"github.com/emmansun/gmsm/internal/impl"
 )
 
 type ZUC256Mac struct {
@@ -22,6 +24,9 @@ type ZUC256Mac struct {
 func NewHash256(key, iv []byte, tagSize int) (*ZUC256Mac, error) {
 	k := len(key)
 	ivLen := len(iv)
+	if err := impl.CheckConstantTime("zuc"); err != nil {
+		return nil, err
+	}
 	mac := &ZUC256Mac{}
 	var d []byte
 	switch tagSize {
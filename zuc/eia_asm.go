@@ -2,12 +2,19 @@
 
 package zuc
 
-import "golang.org/x/sys/cpu"
+import (
+	"github.com/emmansun/gmsm/internal/impl"
+	"golang.org/x/sys/cpu"
+)
 
 var supportsAES = cpu.X86.HasAES || cpu.ARM64.HasAES
 var useAVX = cpu.X86.HasAVX
 var supportsGFMUL = cpu.X86.HasPCLMULQDQ || cpu.ARM64.HasPMULL
 
+func init() {
+	impl.Register("zuc", "AES", supportsAES, true)
+}
+
 //go:noescape
 func eia3Round16B(t *uint32, keyStream *uint32, p *byte, tagSize int)
 